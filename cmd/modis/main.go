@@ -32,6 +32,8 @@ import (
 	"github.com/oceanbase/modis/connection/server"
 	"github.com/oceanbase/modis/log"
 	"github.com/oceanbase/modis/storage"
+	_ "github.com/oceanbase/modis/storage/memory"
+	_ "github.com/oceanbase/modis/storage/obkv"
 )
 
 // Version information.
@@ -70,9 +72,9 @@ func main() {
 	}
 	defer log.Sync()
 
-	s, err := storage.Open(&cfg.Storage.ObkvConfig)
+	s, err := storage.Open(&cfg.Storage)
 	if err != nil {
-		fmt.Println("open DB failed")
+		fmt.Println("open DB failed,", err)
 		log.Fatal("main", "", "open DB failed", log.Errors(err))
 		os.Exit(1)
 	}
@@ -0,0 +1,549 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/oceanbase/obkv-table-client-go/util"
+
+	"github.com/oceanbase/modis/connection/conncontext"
+	"github.com/oceanbase/modis/protocol/resp"
+)
+
+const (
+	// maxBitOffset is the limit of the bit offsets, the same as redis proto-max-bulk-len of 512MB
+	maxBitOffset = 512 * 1024 * 1024 * 8
+
+	errBitFieldType = "ERR Invalid bitfield type. Use something like i16 u8. Note that u64 is not supported but i64 is."
+)
+
+// parseBitUnit parses the BYTE or BIT unit of the range of BITCOUNT and BITPOS
+func parseBitUnit(arg []byte) (isBit bool, ok bool) {
+	switch strings.ToLower(util.BytesToString(arg)) {
+	case "bit":
+		return true, true
+	case "byte":
+		return false, true
+	}
+	return false, false
+}
+
+// BitPos returns the position of the first bit set to 1 or 0 in a string
+func BitPos(ctx *CmdContext) error {
+	bit, err := strconv.ParseInt(util.BytesToString(ctx.Args[1]), 10, 64)
+	if err != nil {
+		ctx.OutContent = resp.ResponseIntegerErr
+		return nil
+	}
+	if bit != 0 && bit != 1 {
+		ctx.OutContent = resp.EncError("ERR The bit argument must be 1 or 0.")
+		return nil
+	}
+
+	start, end := int64(0), int64(-1)
+	isBit, endGiven := false, false
+	switch len(ctx.Args) {
+	case 2:
+	case 3, 4, 5:
+		start, err = strconv.ParseInt(util.BytesToString(ctx.Args[2]), 10, 64)
+		if err != nil {
+			ctx.OutContent = resp.ResponseIntegerErr
+			return nil
+		}
+		if len(ctx.Args) == 5 {
+			var ok bool
+			if isBit, ok = parseBitUnit(ctx.Args[4]); !ok {
+				ctx.OutContent = resp.ResponseSyntaxErr
+				return nil
+			}
+		}
+		if len(ctx.Args) >= 4 {
+			end, err = strconv.ParseInt(util.BytesToString(ctx.Args[3]), 10, 64)
+			if err != nil {
+				ctx.OutContent = resp.ResponseIntegerErr
+				return nil
+			}
+			endGiven = true
+		}
+	default:
+		ctx.OutContent = resp.ResponseSyntaxErr
+		return nil
+	}
+
+	val, err := ctx.CodecCtx.DB.Storage.Get(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0])
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+		return nil
+	}
+	// a missing key is an infinite string of 0 bits
+	if val == nil {
+		ctx.OutContent = resp.EncInteger(-bit)
+		return nil
+	}
+
+	start, end = bitRange(len(val), start, end, isBit)
+	pos := bitPos(val, byte(bit), start, end)
+	if pos == -1 && bit == 0 && !endGiven && start <= end {
+		// the string is padded with 0 bits on the right unless the end is given
+		pos = end + 1
+	}
+	ctx.OutContent = resp.EncInteger(pos)
+	return nil
+}
+
+// BitOp performs a bitwise operation between strings and stores the result in the destination key
+func BitOp(ctx *CmdContext) error {
+	op := strings.ToLower(util.BytesToString(ctx.Args[0]))
+	switch op {
+	case "and", "or", "xor", "not":
+	default:
+		ctx.OutContent = resp.ResponseSyntaxErr
+		return nil
+	}
+	dst, srcs := ctx.Args[1], ctx.Args[2:]
+	if op == "not" && len(srcs) != 1 {
+		ctx.OutContent = resp.EncError("ERR BITOP NOT must be called with a single source key.")
+		return nil
+	}
+
+	// the destination which is a source too is written only if it is still the value read,
+	// the sources are read again otherwise
+	for {
+		done, err := bitOp(ctx, op, dst, srcs)
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// bitOp performs BITOP once, it returns false if the destination has been changed meanwhile
+func bitOp(ctx *CmdContext, op string, dst []byte, srcs [][]byte) (bool, error) {
+	vals := make([][]byte, len(srcs))
+	maxLen := 0
+	isSrc, old := false, []byte(nil)
+	for i, src := range srcs {
+		val, err := ctx.CodecCtx.DB.Storage.Get(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, src)
+		if err != nil {
+			return false, err
+		}
+		vals[i] = val
+		maxLen = max(maxLen, len(val))
+		if bytes.Equal(src, dst) {
+			isSrc, old = true, val
+		}
+	}
+
+	// the shorter strings are padded with 0 bytes
+	byteAt := func(val []byte, i int) byte {
+		if i < len(val) {
+			return val[i]
+		}
+		return 0
+	}
+	res := make([]byte, maxLen)
+	for i := range res {
+		b := byteAt(vals[0], i)
+		for _, val := range vals[1:] {
+			switch op {
+			case "and":
+				b &= byteAt(val, i)
+			case "or":
+				b |= byteAt(val, i)
+			case "xor":
+				b ^= byteAt(val, i)
+			}
+		}
+		if op == "not" {
+			b = ^b
+		}
+		res[i] = b
+	}
+
+	if maxLen == 0 {
+		deleted, err := ctx.CodecCtx.DB.Storage.Delete(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, [][]byte{dst})
+		if err != nil {
+			return false, err
+		}
+		if deleted == 0 {
+			ctx.Modified = [][]byte{}
+		} else {
+			ctx.ServCtx.NotifyKeyspaceEvent(conncontext.NotifyGeneric, "del", ctx.CodecCtx.DB.ID, dst)
+		}
+	} else if isSrc {
+		ok, err := ctx.CodecCtx.DB.Storage.CompareAndSet(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, dst, old, res)
+		if err != nil || !ok {
+			return false, err
+		}
+		// the ttl is removed like SET does
+		if old != nil {
+			if _, err = ctx.CodecCtx.DB.Storage.Persist(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, dst); err != nil {
+				return false, err
+			}
+		}
+		ctx.ServCtx.NotifyKeyspaceEvent(conncontext.NotifyString, "set", ctx.CodecCtx.DB.ID, dst)
+	} else {
+		err := ctx.CodecCtx.DB.Storage.Set(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, dst, res)
+		if err != nil {
+			return false, err
+		}
+		ctx.ServCtx.NotifyKeyspaceEvent(conncontext.NotifyString, "set", ctx.CodecCtx.DB.ID, dst)
+	}
+	ctx.OutContent = resp.EncInteger(int64(maxLen))
+	return true, nil
+}
+
+type bitFieldOpcode int
+
+const (
+	bitFieldGet bitFieldOpcode = iota
+	bitFieldSet
+	bitFieldIncrBy
+)
+
+type bitFieldOverflow int
+
+const (
+	bitFieldWrap bitFieldOverflow = iota
+	bitFieldSat
+	bitFieldFail
+)
+
+// bitFieldOp is an operation of BITFIELD on the integer of bits at offset
+type bitFieldOp struct {
+	opcode   bitFieldOpcode
+	overflow bitFieldOverflow
+	signed   bool
+	bits     uint
+	offset   int64
+	value    int64
+}
+
+// parseBitFieldType parses the type of BITFIELD, i1 to i64 or u1 to u63
+func parseBitFieldType(arg []byte) (signed bool, bits uint, ok bool) {
+	if len(arg) < 2 {
+		return false, 0, false
+	}
+	switch arg[0] {
+	case 'i', 'I':
+		signed = true
+	case 'u', 'U':
+	default:
+		return false, 0, false
+	}
+	n, err := strconv.ParseInt(util.BytesToString(arg[1:]), 10, 64)
+	if err != nil || n < 1 || (signed && n > 64) || (!signed && n > 63) {
+		return false, 0, false
+	}
+	return signed, uint(n), true
+}
+
+// parseBitFieldOffset parses the offset of BITFIELD, an offset prefixed by # is multiplied by bits
+func parseBitFieldOffset(arg []byte, bits uint) (int64, bool) {
+	multiply := len(arg) > 0 && arg[0] == '#'
+	if multiply {
+		arg = arg[1:]
+	}
+	offset, err := strconv.ParseInt(util.BytesToString(arg), 10, 64)
+	if err != nil || offset < 0 {
+		return 0, false
+	}
+	if multiply {
+		if offset > maxBitOffset/int64(bits) {
+			return 0, false
+		}
+		offset *= int64(bits)
+	}
+	if offset >= maxBitOffset {
+		return 0, false
+	}
+	return offset, true
+}
+
+// parseBitField parses the operations of BITFIELD and BITFIELD_RO
+func parseBitField(args [][]byte) ([]bitFieldOp, string) {
+	var ops []bitFieldOp
+	overflow := bitFieldWrap
+	for i := 0; i < len(args); i++ {
+		remain := len(args) - i - 1
+		op := bitFieldOp{overflow: overflow}
+		switch sub := strings.ToLower(util.BytesToString(args[i])); {
+		case sub == "get" && remain >= 2:
+			op.opcode = bitFieldGet
+		case sub == "set" && remain >= 3:
+			op.opcode = bitFieldSet
+		case sub == "incrby" && remain >= 3:
+			op.opcode = bitFieldIncrBy
+		case sub == "overflow" && remain >= 1:
+			i++
+			switch strings.ToLower(util.BytesToString(args[i])) {
+			case "wrap":
+				overflow = bitFieldWrap
+			case "sat":
+				overflow = bitFieldSat
+			case "fail":
+				overflow = bitFieldFail
+			default:
+				return nil, resp.EncError("ERR Invalid OVERFLOW type specified")
+			}
+			continue
+		default:
+			return nil, resp.ResponseSyntaxErr
+		}
+
+		var ok bool
+		if op.signed, op.bits, ok = parseBitFieldType(args[i+1]); !ok {
+			return nil, resp.EncError(errBitFieldType)
+		}
+		if op.offset, ok = parseBitFieldOffset(args[i+2], op.bits); !ok {
+			return nil, resp.ResponseBitOffsetErr
+		}
+		if op.opcode != bitFieldGet {
+			var err error
+			op.value, err = strconv.ParseInt(util.BytesToString(args[i+3]), 10, 64)
+			if err != nil {
+				return nil, resp.ResponseIntegerErr
+			}
+			i++
+		}
+		i += 2
+		ops = append(ops, op)
+	}
+	return ops, ""
+}
+
+// getBitField returns the unsigned integer of bits at offset, the bits beyond val are 0
+func getBitField(val []byte, offset int64, bits uint) uint64 {
+	var res uint64
+	for i := int64(0); i < int64(bits); i++ {
+		res <<= 1
+		if (offset+i)>>3 < int64(len(val)) {
+			res |= uint64(bitAt(val, offset+i))
+		}
+	}
+	return res
+}
+
+// setBitField sets the integer of bits at offset, val must be long enough
+func setBitField(val []byte, offset int64, bits uint, value uint64) {
+	for i := int64(0); i < int64(bits); i++ {
+		pos := offset + i
+		mask := byte(1) << (7 - pos&7)
+		if value&(uint64(1)<<(int64(bits)-1-i)) != 0 {
+			val[pos>>3] |= mask
+		} else {
+			val[pos>>3] &^= mask
+		}
+	}
+}
+
+// toSigned converts the unsigned integer of bits to the signed one
+func toSigned(value uint64, bits uint) int64 {
+	if bits < 64 && value&(uint64(1)<<(bits-1)) != 0 {
+		value |= ^uint64(0) << bits
+	}
+	return int64(value)
+}
+
+// checkUnsignedOverflow reports whether value+incr overflows the unsigned integer of bits,
+// and returns the wrapped or saturated result as redis does
+func checkUnsignedOverflow(value uint64, incr int64, bits uint, overflow bitFieldOverflow) (uint64, bool) {
+	maxVal := uint64(1)<<bits - 1
+	maxIncr := int64(maxVal - value)
+	minIncr := -int64(value)
+
+	var limit uint64
+	if value > maxVal || (incr > 0 && incr > maxIncr) {
+		limit = maxVal
+	} else if incr < 0 && incr < minIncr {
+		limit = 0
+	} else {
+		return 0, false
+	}
+	if overflow == bitFieldWrap {
+		limit = (value + uint64(incr)) &^ (^uint64(0) << bits)
+	}
+	return limit, true
+}
+
+// checkSignedOverflow reports whether value+incr overflows the signed integer of bits,
+// and returns the wrapped or saturated result as redis does
+func checkSignedOverflow(value int64, incr int64, bits uint, overflow bitFieldOverflow) (int64, bool) {
+	maxVal := int64(uint64(1)<<(bits-1) - 1)
+	minVal := -maxVal - 1
+	maxIncr := int64(uint64(maxVal) - uint64(value))
+	minIncr := minVal - value
+
+	var limit int64
+	if value > maxVal || (bits != 64 && incr > maxIncr) || (value >= 0 && incr > 0 && incr > maxIncr) {
+		limit = maxVal
+	} else if value < minVal || (bits != 64 && incr < minIncr) || (value < 0 && incr < 0 && incr < minIncr) {
+		limit = minVal
+	} else {
+		return 0, false
+	}
+	if overflow == bitFieldWrap {
+		wrapped := uint64(value) + uint64(incr)
+		if bits < 64 {
+			wrapped &^= ^uint64(0) << bits
+		}
+		limit = toSigned(wrapped, bits)
+	}
+	return limit, true
+}
+
+// BitField performs arbitrary bitfield integer operations on strings
+func BitField(ctx *CmdContext) error {
+	return bitField(ctx, false)
+}
+
+// BitFieldRo is the read-only variant of BITFIELD
+func BitFieldRo(ctx *CmdContext) error {
+	return bitField(ctx, true)
+}
+
+func bitField(ctx *CmdContext, readOnly bool) error {
+	key := ctx.Args[0]
+	ops, errReply := parseBitField(ctx.Args[1:])
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+	highest := int64(-1)
+	for _, op := range ops {
+		if op.opcode != bitFieldGet {
+			highest = max(highest, op.offset+int64(op.bits)-1)
+		}
+	}
+	if readOnly && highest >= 0 {
+		ctx.OutContent = resp.EncError("ERR BITFIELD_RO only supports the GET subcommand")
+		return nil
+	}
+
+	// the value is written only if it is still the one read, the operations are done again otherwise
+	for {
+		done, err := bitFieldOnce(ctx, key, ops, highest)
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// bitFieldOnce performs the operations of BITFIELD once, it returns false if the value has been
+// changed meanwhile
+func bitFieldOnce(ctx *CmdContext, key []byte, ops []bitFieldOp, highest int64) (bool, error) {
+	val, err := ctx.CodecCtx.DB.Storage.Get(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
+	if err != nil {
+		return false, err
+	}
+	// the string is created or grown to hold the farthest SET and INCRBY
+	newVal := val
+	if highest >= 0 && (val == nil || int64(len(val)) <= highest>>3) {
+		newVal = make([]byte, highest>>3+1)
+		copy(newVal, val)
+	} else if highest >= 0 {
+		newVal = bytes.Clone(val)
+	}
+
+	var out strings.Builder
+	out.WriteString(resp.ArrayFlag + strconv.Itoa(len(ops)) + resp.CRLF)
+	dirty := false
+	for _, op := range ops {
+		old := getBitField(newVal, op.offset, op.bits)
+		if op.opcode == bitFieldGet {
+			if op.signed {
+				out.WriteString(resp.EncInteger(toSigned(old, op.bits)))
+			} else {
+				out.WriteString(resp.EncInteger(int64(old)))
+			}
+			continue
+		}
+
+		var newBits uint64
+		var ret int64
+		var overflowed bool
+		if op.signed {
+			oldVal := toSigned(old, op.bits)
+			newSigned, wrapped := op.value, int64(0)
+			if op.opcode == bitFieldIncrBy {
+				wrapped, overflowed = checkSignedOverflow(oldVal, op.value, op.bits, op.overflow)
+				newSigned = oldVal + op.value
+			} else {
+				wrapped, overflowed = checkSignedOverflow(op.value, 0, op.bits, op.overflow)
+			}
+			if overflowed {
+				newSigned = wrapped
+			}
+			ret = oldVal
+			if op.opcode == bitFieldIncrBy {
+				ret = newSigned
+			}
+			newBits = uint64(newSigned)
+		} else {
+			newUnsigned, wrapped := uint64(op.value), uint64(0)
+			if op.opcode == bitFieldIncrBy {
+				wrapped, overflowed = checkUnsignedOverflow(old, op.value, op.bits, op.overflow)
+				newUnsigned = old + uint64(op.value)
+			} else {
+				wrapped, overflowed = checkUnsignedOverflow(uint64(op.value), 0, op.bits, op.overflow)
+			}
+			if overflowed {
+				newUnsigned = wrapped
+			}
+			ret = int64(old)
+			if op.opcode == bitFieldIncrBy {
+				ret = int64(newUnsigned)
+			}
+			newBits = newUnsigned
+		}
+
+		// the overflow FAIL leaves the integer as is and replies nil
+		if overflowed && op.overflow == bitFieldFail {
+			out.WriteString(resp.ResponsesNullBulkString)
+			continue
+		}
+		out.WriteString(resp.EncInteger(ret))
+		setBitField(newVal, op.offset, op.bits, newBits)
+		if getBitField(newVal, op.offset, op.bits) != old {
+			dirty = true
+		}
+	}
+
+	if highest >= 0 && (val == nil || !bytes.Equal(val, newVal)) {
+		ok, err := ctx.CodecCtx.DB.Storage.CompareAndSet(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, val, newVal)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	if dirty {
+		ctx.ServCtx.NotifyKeyspaceEvent(conncontext.NotifyString, "setbit", ctx.CodecCtx.DB.ID, key)
+	} else {
+		ctx.Modified = [][]byte{}
+	}
+	ctx.OutContent = out.String()
+	return true, nil
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/oceanbase/modis/connection/conncontext"
+	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/oceanbase/modis/util"
+)
+
+// blockSpec gives the keys a blocking command waits for
+type blockSpec struct {
+	keys [][]byte
+	// 0 blocks forever
+	timeout time.Duration
+}
+
+// parseBlockTimeout parses the timeout in seconds of a blocking command, an error reply is returned if it is invalid
+func parseBlockTimeout(arg []byte) (time.Duration, string) {
+	timeout, err := strconv.ParseFloat(util.BytesToString(arg), 64)
+	if err != nil || math.IsNaN(timeout) {
+		return 0, resp.EncError("ERR timeout is not a float or out of range")
+	}
+	if timeout < 0 {
+		return 0, resp.EncError("ERR timeout is negative")
+	}
+	if timeout > math.MaxInt64/float64(time.Second) {
+		return 0, resp.EncError("ERR timeout is out of range")
+	}
+	return time.Duration(timeout * float64(time.Second)), ""
+}
+
+// parseBlockTimeoutMillis parses the timeout in milliseconds of a blocking command, e.g. BLOCK of XREAD
+func parseBlockTimeoutMillis(arg []byte) (time.Duration, string) {
+	timeout, err := strconv.ParseInt(util.BytesToString(arg), 10, 64)
+	if err != nil || timeout > math.MaxInt64/int64(time.Millisecond) {
+		return 0, resp.EncError("ERR timeout is not an integer or out of range")
+	}
+	if timeout < 0 {
+		return 0, resp.EncError("ERR timeout is negative")
+	}
+	return time.Duration(timeout) * time.Millisecond, ""
+}
+
+// blockCommand blocks the client until the command is served, it is retried whenever one of its keys
+// is touched, and every BlockPollInterval for the writes from other modis. The reply of the last try
+// is kept on timeout, the client is woken up early by CLIENT UNBLOCK or when the connection is closed
+func blockCommand(ctx *CmdContext, cmdInfo *CmdInfo) {
+	spec := ctx.block
+	wakeChan := ctx.ServCtx.Blocked.Block(ctx.CodecCtx, ctx.CodecCtx.DB.ID, spec.keys)
+	defer ctx.ServCtx.Blocked.Unblock(ctx.CodecCtx)
+
+	var timeout <-chan time.Time
+	if spec.timeout > 0 {
+		timer := time.NewTimer(spec.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	poll := time.NewTicker(ctx.ServCtx.BlockPollInterval)
+	defer poll.Stop()
+
+	for {
+		// retry at once, the keys may be touched before the client is blocked
+		ctx.block = nil
+		withTxLock(ctx, cmdInfo, runCommand)
+		if ctx.block == nil {
+			return
+		}
+
+		select {
+		case reason := <-wakeChan:
+			switch reason {
+			case conncontext.UnblockTimeout, conncontext.UnblockClosed:
+				return
+			case conncontext.UnblockError:
+				ctx.OutContent = resp.EncError("UNBLOCKED client unblocked via CLIENT UNBLOCK")
+				return
+			}
+		case <-poll.C:
+		case <-timeout:
+			return
+		}
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/oceanbase/modis/connection/conncontext"
+	"github.com/oceanbase/modis/protocol/resp"
 )
 
 // CmdContext is the runtime context of a command
@@ -32,6 +33,10 @@ type CmdContext struct {
 	TraceID    string
 	// request without decoding, e.g. *2\r\n$3\r\nGET\r\n$3\r\nkey\r\n
 	PlainReq []byte
+	// keys of WriteKeys the command actually modified, nil for all of them
+	Modified [][]byte
+	// set by a blocking command which found nothing, the command waits for the keys
+	block    *blockSpec
 	CodecCtx *conncontext.CodecContext
 	ServCtx  *conncontext.ServerContext
 	context.Context
@@ -43,6 +48,14 @@ const (
 	CmdNone  CmdFlag = 0
 	CmdAdmin CmdFlag = 1 << iota
 	CmdSkipMonitor
+	// the command controls a transaction, it is not queued in a MULTI context
+	CmdTx
+	// the command holds the transaction lock exclusively, no other command runs meanwhile
+	CmdExclusive
+	// the command is not allowed in scripts
+	CmdNoScript
+	// the command is allowed in the subscriber mode
+	CmdPubSub
 )
 
 // CmdInfo describes a command with constraints
@@ -52,6 +65,79 @@ type CmdInfo struct {
 	Arity int
 	Flag  CmdFlag
 	Stats CmdStats
+	// keys modified by the command, the clients watching them are told
+	WriteKeys KeySpec
+	// keyspace events fired for WriteKeys once the command succeeds
+	Notify NotifySpec
+	// the transaction lock is held exclusively like CmdExclusive if it returns true for the arguments
+	ExclusiveIf func(args [][]byte) bool
+}
+
+// KeySpec gives the positions of the keys in the command line, where the command name is at 0,
+// Last < 0 counts from the end, e.g. {1, -1, 2} for MSET. No keys are given if First is 0
+type KeySpec struct {
+	First int
+	Last  int
+	Step  int
+}
+
+// keys returns the keys in args, which excludes the command name
+func (ks KeySpec) keys(args [][]byte) [][]byte {
+	if ks.First == 0 {
+		return nil
+	}
+	last := ks.Last
+	if last < 0 {
+		last += len(args) + 1
+	}
+	if last > len(args) {
+		last = len(args)
+	}
+	step := ks.Step
+	if step <= 0 {
+		step = 1
+	}
+	keys := make([][]byte, 0, (last-ks.First)/step+1)
+	for i := ks.First; i <= last; i += step {
+		keys = append(keys, args[i-1])
+	}
+	return keys
+}
+
+// NotifySpec gives the keyspace events of a command, the i-th key of WriteKeys fires Events[i],
+// the keys beyond Events fire the last one, e.g. {"rename_from", "rename_to"} for RENAME
+type NotifySpec struct {
+	Class  conncontext.NotifyClass
+	Events []string
+	// fire no event if the reply is 0 or nil, for the commands that may modify nothing, e.g. DEL
+	IfModified bool
+}
+
+// notify returns the spec firing events of class
+func notify(class conncontext.NotifyClass, events ...string) NotifySpec {
+	return NotifySpec{Class: class, Events: events}
+}
+
+// notifyIfModified returns the spec firing events of class unless the reply is 0 or nil
+func notifyIfModified(class conncontext.NotifyClass, events ...string) NotifySpec {
+	return NotifySpec{Class: class, Events: events, IfModified: true}
+}
+
+// fire publishes the events of keys
+func (ns NotifySpec) fire(ctx *CmdContext, keys [][]byte) {
+	if len(ns.Events) == 0 {
+		return
+	}
+	if ns.IfModified {
+		switch ctx.OutContent {
+		case ":0\r\n", resp.ResponsesNullBulkString, resp.ResponsesNullArray:
+			return
+		}
+	}
+	for i, key := range keys {
+		event := ns.Events[min(i, len(ns.Events)-1)]
+		ctx.ServCtx.NotifyKeyspaceEvent(ns.Class, event, ctx.CodecCtx.DB.ID, key)
+	}
 }
 
 // CmdStat describes command statistics
@@ -68,7 +154,7 @@ func (cs *CmdStats) GetUsecPerCall() float64 {
 type Command func(ctx *CmdContext) error
 
 var (
-	secondLevelCmd = []string{"client"}
+	secondLevelCmd = []string{"client", "script", "pubsub", "config", "xgroup", "xinfo"}
 )
 
 // NewCmdContext create a new command context
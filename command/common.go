@@ -82,36 +82,113 @@ func Call(ctx *CmdContext) {
 	if ctx.FullName != "auth" &&
 		ctx.ServCtx.Password != "" &&
 		!ctx.CodecCtx.Authenticated {
-		ctx.OutContent = resp.ResponsesNoautherr
+		rejectCommand(ctx, resp.ResponsesNoautherr)
 		return
 	}
 
 	// check command info
+	cmdInfo, errReply := lookupCommand(ctx)
+	ctx.CodecCtx.LastCmd = ctx.FullName
+	if errReply != "" {
+		rejectCommand(ctx, errReply)
+		return
+	}
+
+	// only the pub/sub commands are allowed in the subscriber mode
+	if isPubSubClient(ctx) && cmdInfo.Flag&CmdPubSub == 0 {
+		rejectCommand(ctx, resp.EncError(fmt.Sprintf("ERR Can't execute '%s': only (P)SUBSCRIBE / "+
+			"(P)UNSUBSCRIBE / PING / QUIT are allowed in this context", ctx.FullName)))
+		return
+	}
+
+	// queue the command in a MULTI context
+	if ctx.CodecCtx.Flag&conncontext.ClientMulti != 0 && cmdInfo.Flag&CmdTx == 0 {
+		queueCommand(ctx)
+		return
+	}
+
+	withTxLock(ctx, cmdInfo, execCommand)
+
+	// a blocking command found nothing, wait outside the lock for its keys
+	if ctx.block != nil {
+		blockCommand(ctx, cmdInfo)
+	}
+}
+
+// withTxLock runs the command with run holding the transaction lock, exclusively for CmdExclusive commands
+// and the commands whose ExclusiveIf returns true
+func withTxLock(ctx *CmdContext, cmdInfo *CmdInfo, run func(*CmdContext, *CmdInfo)) {
+	if cmdInfo.Flag&CmdExclusive != 0 || (cmdInfo.ExclusiveIf != nil && cmdInfo.ExclusiveIf(ctx.Args)) {
+		ctx.ServCtx.TxLock.Lock()
+		defer ctx.ServCtx.TxLock.Unlock()
+	} else {
+		ctx.ServCtx.TxLock.RLock()
+		defer ctx.ServCtx.TxLock.RUnlock()
+	}
+	run(ctx, cmdInfo)
+}
+
+// lookupCommand finds the command and checks the number of args, the full name of
+// a second level command is set, an error reply is returned if the check fails
+func lookupCommand(ctx *CmdContext) (*CmdInfo, string) {
 	argc := len(ctx.Args) + 1 // include the command name
 	for _, slc := range secondLevelCmd {
 		if ctx.FullName == slc {
 			if argc < 2 {
-				ctx.OutContent = resp.ErrWrongArgs(ctx.FullName)
-				return
+				return nil, resp.ErrWrongArgs(ctx.FullName)
 			}
 			ctx.FullName += "|" + strings.ToLower(util.BytesToString(ctx.Args[0]))
 		}
 	}
-	ctx.CodecCtx.LastCmd = ctx.FullName
 	cmdInfo, ok := commands[ctx.FullName]
 	if !ok {
-		ctx.OutContent = resp.ErrUnKnownCommand(ctx.FullName)
-		return
+		return nil, resp.ErrUnKnownCommand(ctx.FullName)
 	}
 	arity := cmdInfo.Arity
 	if (arity > 0 && argc != arity) ||
 		(arity < 0 && argc < -arity) {
-		ctx.OutContent = resp.ErrWrongArgs(ctx.FullName)
-		return
+		return nil, resp.ErrWrongArgs(ctx.FullName)
+	}
+	return cmdInfo, ""
+}
+
+// rejectCommand replies an error without executing the command,
+// the transaction is discarded on EXEC if the client is in a MULTI context
+func rejectCommand(ctx *CmdContext, reply string) {
+	ctx.OutContent = reply
+	if ctx.CodecCtx.Flag&conncontext.ClientMulti != 0 {
+		ctx.CodecCtx.Flag |= conncontext.ClientDirtyExec
 	}
+}
 
-	// exec command
+// execCommand executes a command which has passed the checks
+func execCommand(ctx *CmdContext, cmdInfo *CmdInfo) {
 	st := time.Now()
+	runCommand(ctx, cmdInfo)
+
+	// feed monitor
+	if (cmdInfo.Flag & (CmdSkipMonitor | CmdAdmin)) == 0 {
+		feedMonitors(ctx)
+	}
+
+	// stats after exec command
+	dur := time.Since(st)
+	cmdInfo.Stats.Calls++
+	cmdInfo.Stats.MicroSec += dur.Microseconds()
+}
+
+// touchWatched marks the clients watching any of keys of db dirty, and changes the versions of keys
+// in the storage for the clients watching them through other modis
+func touchWatched(ctx *CmdContext, db int64, keys [][]byte) {
+	ctx.ServCtx.Watched.Touch(db, keys)
+	if err := ctx.CodecCtx.DB.Storage.TouchKeys(ctx.CodecCtx.DB.Ctx, db, keys); err != nil {
+		log.Warn("command", ctx.TraceID, "fail to touch the keys written", log.Errors(err), log.Int64("db", db))
+	}
+}
+
+// runCommand runs the handler of a command, and tells the clients watching or blocked on
+// the modified keys
+func runCommand(ctx *CmdContext, cmdInfo *CmdInfo) {
 	err := cmdInfo.Cmd(ctx)
 	if err != nil {
 		log.Warn("command", ctx.TraceID, "fail to exec command", log.Errors(err))
@@ -121,13 +198,15 @@ func Call(ctx *CmdContext) {
 		ctx.OutContent = resp.ResponseSyntaxErr
 	}
 
-	// feed monitor
-	if (commands[ctx.FullName].Flag & (CmdSkipMonitor | CmdAdmin)) == 0 {
-		feedMonitors(ctx)
+	// touch the modified keys for WATCH and the blocked clients, and fire the keyspace events
+	if !strings.HasPrefix(ctx.OutContent, resp.SimpleErrFlag) {
+		if keys := cmdInfo.WriteKeys.keys(ctx.Args); len(keys) != 0 {
+			if ctx.Modified != nil {
+				keys = ctx.Modified
+			}
+			touchWatched(ctx, ctx.CodecCtx.DB.ID, keys)
+			ctx.ServCtx.Blocked.Touch(ctx.CodecCtx.DB.ID, keys)
+			cmdInfo.Notify.fire(ctx, keys)
+		}
 	}
-
-	// stats after exec command
-	dur := time.Since(st)
-	cmdInfo.Stats.Calls++
-	cmdInfo.Stats.MicroSec += dur.Microseconds()
 }
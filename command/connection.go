@@ -61,6 +61,8 @@ func Ping(ctx *CmdContext) error {
 	argc := len(ctx.Args)
 	if argc > 2 {
 		ctx.OutContent = resp.ErrWrongArgs(ctx.FullName)
+	} else if isPubSubClient(ctx) {
+		pubSubPing(ctx)
 	} else if argc > 0 {
 		ctx.OutContent = resp.EncBulkString(util.BytesToString(ctx.Args[0]))
 	} else {
@@ -135,6 +137,8 @@ func SwapDB(ctx *CmdContext) error {
 	tmpDB := *db1
 	*db1 = *db2
 	*db2 = tmpDB
+	ctx.ServCtx.Watched.TouchDB(int64(idx1))
+	ctx.ServCtx.Watched.TouchDB(int64(idx2))
 	ctx.OutContent = resp.ResponsesOk
 	return nil
 }
@@ -145,6 +149,10 @@ func ClientHelp(ctx *CmdContext) error {
 		[]byte("    Return information about the current client connection."),
 		[]byte("LIST"),
 		[]byte("    Return information about client connections."),
+		[]byte("ID"),
+		[]byte("    Return the ID of the current connection."),
+		[]byte("UNBLOCK <clientid> [TIMEOUT|ERROR]"),
+		[]byte("    Unblock the specified blocked client."),
 		[]byte("HELP"),
 		[]byte("    Print this help."),
 	}
@@ -152,10 +160,11 @@ func ClientHelp(ctx *CmdContext) error {
 	return nil
 }
 
-func getClientInfo(infoBuilder *strings.Builder, cliCtx *conncontext.CodecContext) error {
+func getClientInfo(infoBuilder *strings.Builder, servCtx *conncontext.ServerContext, cliCtx *conncontext.CodecContext) error {
 	unixTime := time.Now().Unix()
 	flag := clientFlag2Str(cliCtx.Flag)
 	queNum := cliCtx.QueNum.Load()
+	sub, psub := servCtx.PubSub.NumSubscriptions(cliCtx)
 	_, err := infoBuilder.WriteString(fmt.Sprintf(
 		"id=%d addr=%s laddr=%s fd=%d name=%s age=%d idle=%d flags=%s db=%d sub=%d psub=%d "+
 			"ssub=%d multi=%d qbuf=%d qbuf-free=%d argv-mem=%d multi-mem=%d rbs=%d rbp=%d obl=%d "+
@@ -169,8 +178,8 @@ func getClientInfo(infoBuilder *strings.Builder, cliCtx *conncontext.CodecContex
 		unixTime-cliCtx.LastCmdTime.Unix(),
 		flag,
 		cliCtx.DB.ID,
-		0,
-		0,
+		sub,
+		psub,
 		0,
 		-1,
 		queNum,
@@ -196,7 +205,7 @@ func getClientInfo(infoBuilder *strings.Builder, cliCtx *conncontext.CodecContex
 
 func ClientInfo(ctx *CmdContext) error {
 	var infoBuilder strings.Builder
-	err := getClientInfo(&infoBuilder, ctx.CodecCtx)
+	err := getClientInfo(&infoBuilder, ctx.ServCtx, ctx.CodecCtx)
 	if err != nil {
 		log.Warn("command", ctx.TraceID, "fail to get client info", log.Errors(err))
 		ctx.OutContent = resp.EncError("ERR " + err.Error())
@@ -226,7 +235,7 @@ func ClientList(ctx *CmdContext) error {
 				return nil
 			}
 			if cliCtx, ok := ctx.ServCtx.Clients.Get(int64(id)); ok {
-				err = getClientInfo(&infoBuilder, cliCtx)
+				err = getClientInfo(&infoBuilder, ctx.ServCtx, cliCtx)
 				if err != nil {
 					log.Warn("command", ctx.TraceID, "fail to get client info", log.Errors(err))
 					break
@@ -246,7 +255,7 @@ func ClientList(ctx *CmdContext) error {
 		var err error
 		ctx.ServCtx.Clients.ForEach(func(id int64, cliCtx *conncontext.CodecContext) bool {
 			// return `true` to continue iteration and `false` to break iteration
-			err = getClientInfo(&infoBuilder, cliCtx)
+			err = getClientInfo(&infoBuilder, ctx.ServCtx, cliCtx)
 			if err != nil {
 				log.Warn("command", ctx.TraceID, "fail to get client info", log.Errors(err))
 				return false
@@ -263,3 +272,37 @@ func ClientList(ctx *CmdContext) error {
 
 	return nil
 }
+
+// ClientID returns the ID of the current connection
+func ClientID(ctx *CmdContext) error {
+	ctx.OutContent = resp.EncInteger(ctx.CodecCtx.ID)
+	return nil
+}
+
+// ClientUnblock unblocks a client blocked by a blocking command, it replies as if the command
+// timed out, or an error if ERROR is given
+func ClientUnblock(ctx *CmdContext) error {
+	argc := len(ctx.Args)
+	if argc > 3 {
+		ctx.OutContent = resp.ResponseSyntaxErr
+		return nil
+	}
+	id, err := strconv.ParseInt(util.BytesToString(ctx.Args[1]), 10, 64)
+	if err != nil {
+		ctx.OutContent = resp.ResponseIntegerErr
+		return nil
+	}
+	reason := conncontext.UnblockTimeout
+	if argc == 3 {
+		switch strings.ToLower(util.BytesToString(ctx.Args[2])) {
+		case "timeout":
+		case "error":
+			reason = conncontext.UnblockError
+		default:
+			ctx.OutContent = resp.EncError("ERR CLIENT UNBLOCK reason should be TIMEOUT or ERROR")
+			return nil
+		}
+	}
+	ctx.OutContent = encBool(ctx.ServCtx.Blocked.Wake(id, reason))
+	return nil
+}
@@ -0,0 +1,608 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/oceanbase/obkv-table-client-go/util"
+
+	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/oceanbase/modis/storage"
+	modisutil "github.com/oceanbase/modis/util"
+)
+
+const (
+	errGeoMember = "ERR could not decode requested zset member"
+)
+
+// parseGeoUnit returns the meters of a unit of distance
+func parseGeoUnit(arg []byte) (float64, bool) {
+	switch strings.ToLower(util.BytesToString(arg)) {
+	case "m":
+		return 1, true
+	case "km":
+		return 1000, true
+	case "ft":
+		return 0.3048, true
+	case "mi":
+		return 1609.34, true
+	}
+	return 0, false
+}
+
+// parseLongLat parses a pair of longitude and latitude, an error reply is returned if it is invalid
+func parseLongLat(longArg []byte, latArg []byte) (float64, float64, string) {
+	longitude, ok1 := parseFloat(longArg)
+	latitude, ok2 := parseFloat(latArg)
+	if !ok1 || !ok2 {
+		return 0, 0, resp.ResponseFloatErr
+	}
+	if longitude < modisutil.GeoLongMin || longitude > modisutil.GeoLongMax ||
+		latitude < modisutil.GeoLatMin || latitude > modisutil.GeoLatMax {
+		return 0, 0, resp.EncError(fmt.Sprintf("ERR invalid longitude,latitude pair %f,%f", longitude, latitude))
+	}
+	return longitude, latitude, ""
+}
+
+// parseGeoDistance parses a non-negative distance, errMsg is replied if it is not a number
+func parseGeoDistance(arg []byte, errMsg string) (float64, string) {
+	distance, ok := parseFloat(arg)
+	if !ok {
+		return 0, resp.EncError(errMsg)
+	}
+	return distance, ""
+}
+
+// formatGeoDistance formats a distance with 4 decimals as redis does
+func formatGeoDistance(distance float64) string {
+	return strconv.FormatFloat(distance, 'f', 4, 64)
+}
+
+// formatGeoCoord formats a coordinate with up to 17 decimals as redis does
+func formatGeoCoord(coord float64) string {
+	s := strconv.FormatFloat(coord, 'f', 17, 64)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// geoPosition returns the location of a member, false if the member not exists
+func geoPosition(ctx *CmdContext, key []byte, member []byte) (float64, float64, bool, error) {
+	score, ok, err := ctx.CodecCtx.DB.Storage.ZScore(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, member)
+	if err != nil || !ok {
+		return 0, 0, false, err
+	}
+	longitude, latitude, ok := modisutil.GeoDecodeScore(score)
+	return longitude, latitude, ok, nil
+}
+
+// GeoAdd adds the locations of members to the sorted set stored at key,
+// the score of a member is the 52 bits geohash of its location
+func GeoAdd(ctx *CmdContext) error {
+	key := ctx.Args[0]
+	flags := storage.ZAddFlags{}
+	idx := 1
+options:
+	for ; idx < len(ctx.Args); idx++ {
+		switch strings.ToLower(util.BytesToString(ctx.Args[idx])) {
+		case "nx":
+			flags.NX = true
+		case "xx":
+			flags.XX = true
+		case "ch":
+			flags.CH = true
+		default:
+			break options
+		}
+	}
+	args := ctx.Args[idx:]
+	if len(args) == 0 || len(args)%3 != 0 || (flags.NX && flags.XX) {
+		ctx.OutContent = resp.ResponseSyntaxErr
+		return nil
+	}
+
+	members := make([]storage.ZMember, 0, len(args)/3)
+	for i := 0; i < len(args); i += 3 {
+		longitude, latitude, errReply := parseLongLat(args[i], args[i+1])
+		if errReply != "" {
+			ctx.OutContent = errReply
+			return nil
+		}
+		score, _ := modisutil.GeoScore(longitude, latitude)
+		members = append(members, storage.ZMember{Member: args[i+2], Score: float64(score)})
+	}
+
+	added, changed, err := ctx.CodecCtx.DB.Storage.ZAdd(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, members, flags)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+		return nil
+	}
+	if added+changed == 0 {
+		ctx.Modified = [][]byte{}
+	}
+	if flags.CH {
+		added += changed
+	}
+	ctx.OutContent = resp.EncInteger(added)
+	return nil
+}
+
+// GeoPos returns the longitude and latitude of members
+func GeoPos(ctx *CmdContext) error {
+	key := ctx.Args[0]
+	var out strings.Builder
+	out.WriteString(resp.ArrayFlag + strconv.Itoa(len(ctx.Args)-1) + resp.CRLF)
+	for _, member := range ctx.Args[1:] {
+		longitude, latitude, ok, err := geoPosition(ctx, key, member)
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+		if !ok {
+			out.WriteString(resp.ResponsesNullArray)
+			continue
+		}
+		out.WriteString(resp.EncArray([][]byte{[]byte(formatGeoCoord(longitude)), []byte(formatGeoCoord(latitude))}))
+	}
+	ctx.OutContent = out.String()
+	return nil
+}
+
+// GeoDist returns the distance between two members
+func GeoDist(ctx *CmdContext) error {
+	key := ctx.Args[0]
+	toMeters := 1.0
+	if len(ctx.Args) == 4 {
+		var ok bool
+		if toMeters, ok = parseGeoUnit(ctx.Args[3]); !ok {
+			ctx.OutContent = resp.EncError("ERR unsupported unit provided. please use M, KM, FT, MI")
+			return nil
+		}
+	} else if len(ctx.Args) > 4 {
+		ctx.OutContent = resp.ResponseSyntaxErr
+		return nil
+	}
+
+	long1, lat1, ok1, err := geoPosition(ctx, key, ctx.Args[1])
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+		return nil
+	}
+	long2, lat2, ok2, err := geoPosition(ctx, key, ctx.Args[2])
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else if !ok1 || !ok2 {
+		ctx.OutContent = resp.ResponsesNullBulkString
+	} else {
+		ctx.OutContent = resp.EncBulkString(formatGeoDistance(modisutil.GeoDistance(long1, lat1, long2, lat2) / toMeters))
+	}
+	return nil
+}
+
+// GeoHash returns the standard geohash strings of members
+func GeoHash(ctx *CmdContext) error {
+	key := ctx.Args[0]
+	res := make([][]byte, 0, len(ctx.Args)-1)
+	for _, member := range ctx.Args[1:] {
+		score, ok, err := ctx.CodecCtx.DB.Storage.ZScore(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, member)
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+		var hash string
+		if ok {
+			hash, ok = modisutil.GeoHashString(score)
+		}
+		if !ok {
+			res = append(res, nil)
+			continue
+		}
+		res = append(res, []byte(hash))
+	}
+	ctx.OutContent = resp.EncArray(res)
+	return nil
+}
+
+type geoSearchFlag int
+
+const (
+	// search around the longitude and latitude, GEORADIUS
+	geoRadiusCoords geoSearchFlag = 1 << iota
+	// search around a member, GEORADIUSBYMEMBER
+	geoRadiusMember
+	// STORE and STOREDIST are not allowed, the _RO variants
+	geoNoStore
+	// the arguments of GEOSEARCH
+	geoSearch
+	// the arguments of GEOSEARCHSTORE
+	geoSearchStore
+)
+
+type geoSort int
+
+const (
+	geoSortNone geoSort = iota
+	geoSortAsc
+	geoSortDesc
+)
+
+// geoPoint is a member found by a search
+type geoPoint struct {
+	member    []byte
+	score     float64
+	distance  float64
+	longitude float64
+	latitude  float64
+}
+
+// geoSearchArgs are the arguments of GEORADIUS and GEOSEARCH
+type geoSearchArgs struct {
+	shape      modisutil.GeoShape
+	conversion float64
+	withDist   bool
+	withHash   bool
+	withCoord  bool
+	any        bool
+	sort       geoSort
+	count      int64
+	storeKey   []byte
+	storeDist  bool
+}
+
+// parseGeoSearch parses the arguments following the base ones, the center is looked up from src
+// if it is given by a member, an error reply is returned if they are invalid
+func parseGeoSearch(ctx *CmdContext, src []byte, srcExists bool, args [][]byte, flags geoSearchFlag, sa *geoSearchArgs) string {
+	fromMember, fromLonLat, byRadius, byBox := false, false, false, false
+	for i := 0; i < len(args); i++ {
+		remain := len(args) - i - 1
+		switch arg := strings.ToLower(util.BytesToString(args[i])); {
+		case arg == "withdist":
+			sa.withDist = true
+		case arg == "withhash":
+			sa.withHash = true
+		case arg == "withcoord":
+			sa.withCoord = true
+		case arg == "any":
+			sa.any = true
+		case arg == "asc":
+			sa.sort = geoSortAsc
+		case arg == "desc":
+			sa.sort = geoSortDesc
+		case arg == "count" && remain >= 1:
+			count, err := strconv.ParseInt(util.BytesToString(args[i+1]), 10, 64)
+			if err != nil {
+				return resp.ResponseIntegerErr
+			}
+			if count <= 0 {
+				return resp.EncError("ERR COUNT must be > 0")
+			}
+			sa.count = count
+			i++
+		case (arg == "store" || arg == "storedist") && remain >= 1 && flags&(geoNoStore|geoSearch) == 0:
+			sa.storeKey = args[i+1]
+			sa.storeDist = arg == "storedist"
+			i++
+		case arg == "storedist" && flags&geoSearchStore != 0:
+			sa.storeDist = true
+		case arg == "frommember" && remain >= 1 && flags&geoSearch != 0 && !fromLonLat:
+			// the errors of the other arguments come first if src not exists
+			if srcExists {
+				longitude, latitude, ok, err := geoPosition(ctx, src, args[i+1])
+				if err != nil {
+					return encStorageError(err)
+				}
+				if !ok {
+					return resp.EncError(errGeoMember)
+				}
+				sa.shape.Longitude, sa.shape.Latitude = longitude, latitude
+			}
+			fromMember = true
+			i++
+		case arg == "fromlonlat" && remain >= 2 && flags&geoSearch != 0 && !fromMember:
+			longitude, latitude, errReply := parseLongLat(args[i+1], args[i+2])
+			if errReply != "" {
+				return errReply
+			}
+			sa.shape.Longitude, sa.shape.Latitude = longitude, latitude
+			fromLonLat = true
+			i += 2
+		case arg == "byradius" && remain >= 2 && flags&geoSearch != 0 && !byBox:
+			if errReply := parseGeoRadius(args[i+1:i+3], sa); errReply != "" {
+				return errReply
+			}
+			byRadius = true
+			i += 2
+		case arg == "bybox" && remain >= 3 && flags&geoSearch != 0 && !byRadius:
+			width, errReply := parseGeoDistance(args[i+1], "ERR need numeric width")
+			if errReply != "" {
+				return errReply
+			}
+			height, errReply := parseGeoDistance(args[i+2], "ERR need numeric height")
+			if errReply != "" {
+				return errReply
+			}
+			if width < 0 || height < 0 {
+				return resp.EncError("ERR height or width cannot be negative")
+			}
+			conversion, ok := parseGeoUnit(args[i+3])
+			if !ok {
+				return resp.EncError("ERR unsupported unit provided. please use M, KM, FT, MI")
+			}
+			sa.shape.IsBox, sa.shape.Width, sa.shape.Height, sa.conversion = true, width, height, conversion
+			byBox = true
+			i += 3
+		default:
+			return resp.ResponseSyntaxErr
+		}
+	}
+
+	if sa.storeKey != nil && (sa.withDist || sa.withHash || sa.withCoord) {
+		option := "STORE option in GEORADIUS"
+		if flags&geoSearchStore != 0 {
+			option = "GEOSEARCHSTORE"
+		}
+		return resp.EncError("ERR " + option + " is not compatible with WITHDIST, WITHHASH and WITHCOORD options")
+	}
+	if flags&geoSearch != 0 && !fromMember && !fromLonLat {
+		return resp.EncError("ERR exactly one of FROMMEMBER or FROMLONLAT can be specified for " + ctx.Name)
+	}
+	if flags&geoSearch != 0 && !byRadius && !byBox {
+		return resp.EncError("ERR exactly one of BYRADIUS and BYBOX can be specified for " + ctx.Name)
+	}
+	if sa.any && sa.count == 0 {
+		return resp.EncError("ERR the ANY argument requires COUNT argument")
+	}
+	return ""
+}
+
+// parseGeoRadius parses the radius and its unit
+func parseGeoRadius(args [][]byte, sa *geoSearchArgs) string {
+	radius, errReply := parseGeoDistance(args[0], "ERR need numeric radius")
+	if errReply != "" {
+		return errReply
+	}
+	if radius < 0 {
+		return resp.EncError("ERR radius cannot be negative")
+	}
+	conversion, ok := parseGeoUnit(args[1])
+	if !ok {
+		return resp.EncError("ERR unsupported unit provided. please use M, KM, FT, MI")
+	}
+	sa.shape.IsBox, sa.shape.Radius, sa.conversion = false, radius, conversion
+	return ""
+}
+
+// geoMembersInShape returns the members in the shape, it stops once limit members are found if limit > 0
+func geoMembersInShape(ctx *CmdContext, key []byte, shape *modisutil.GeoShape, limit int64) ([]geoPoint, error) {
+	var points []geoPoint
+	for _, r := range shape.Ranges() {
+		if limit > 0 && int64(len(points)) >= limit {
+			break
+		}
+		members, err := ctx.CodecCtx.DB.Storage.ZRangeByScore(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key,
+			storage.ScoreBound{Score: float64(r[0])}, storage.ScoreBound{Score: float64(r[1]), Exclusive: true}, false, 0, -1)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			longitude, latitude, ok := modisutil.GeoDecodeScore(m.Score)
+			if !ok {
+				continue
+			}
+			distance, ok := shape.Contains(longitude, latitude)
+			if !ok {
+				continue
+			}
+			points = append(points, geoPoint{member: m.Member, score: m.Score, distance: distance, longitude: longitude, latitude: latitude})
+			if limit > 0 && int64(len(points)) >= limit {
+				break
+			}
+		}
+	}
+	return points, nil
+}
+
+// geoRadius implements GEORADIUS, GEORADIUSBYMEMBER, GEOSEARCH, GEOSEARCHSTORE and the _RO variants
+func geoRadius(ctx *CmdContext, flags geoSearchFlag) error {
+	srcIdx := 0
+	if flags&geoSearchStore != 0 {
+		srcIdx = 1
+	}
+	src := ctx.Args[srcIdx]
+	// only the key stored by STORE, STOREDIST or GEOSEARCHSTORE is modified
+	ctx.Modified = [][]byte{}
+	card, err := ctx.CodecCtx.DB.Storage.ZCard(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, src)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+		return nil
+	}
+	srcExists := card > 0
+
+	sa := geoSearchArgs{}
+	var args [][]byte
+	switch {
+	case flags&geoRadiusCoords != 0:
+		longitude, latitude, errReply := parseLongLat(ctx.Args[1], ctx.Args[2])
+		if errReply == "" {
+			errReply = parseGeoRadius(ctx.Args[3:5], &sa)
+		}
+		if errReply != "" {
+			ctx.OutContent = errReply
+			return nil
+		}
+		sa.shape.Longitude, sa.shape.Latitude = longitude, latitude
+		args = ctx.Args[5:]
+	case flags&geoRadiusMember != 0:
+		// the errors of the other arguments come first if src not exists
+		if srcExists {
+			longitude, latitude, ok, err := geoPosition(ctx, src, ctx.Args[1])
+			if err != nil {
+				ctx.OutContent = encStorageError(err)
+				return nil
+			}
+			if !ok {
+				ctx.OutContent = resp.EncError(errGeoMember)
+				return nil
+			}
+			if errReply := parseGeoRadius(ctx.Args[2:4], &sa); errReply != "" {
+				ctx.OutContent = errReply
+				return nil
+			}
+			sa.shape.Longitude, sa.shape.Latitude = longitude, latitude
+		}
+		args = ctx.Args[4:]
+	default:
+		if flags&geoSearchStore != 0 {
+			sa.storeKey = ctx.Args[0]
+		}
+		args = ctx.Args[srcIdx+1:]
+	}
+	if errReply := parseGeoSearch(ctx, src, srcExists, args, flags, &sa); errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+
+	var points []geoPoint
+	if srcExists {
+		// COUNT without sorting returns the closest members, unless ANY is given
+		if sa.count != 0 && sa.sort == geoSortNone && !sa.any {
+			sa.sort = geoSortAsc
+		}
+		sa.shape.Radius *= sa.conversion
+		sa.shape.Width *= sa.conversion
+		sa.shape.Height *= sa.conversion
+		limit := int64(0)
+		if sa.any {
+			limit = sa.count
+		}
+		points, err = geoMembersInShape(ctx, src, &sa.shape, limit)
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+		switch sa.sort {
+		case geoSortAsc:
+			sort.SliceStable(points, func(i, j int) bool { return points[i].distance < points[j].distance })
+		case geoSortDesc:
+			sort.SliceStable(points, func(i, j int) bool { return points[i].distance > points[j].distance })
+		}
+		if sa.count != 0 && int64(len(points)) > sa.count {
+			points = points[:sa.count]
+		}
+		for i := range points {
+			points[i].distance /= sa.conversion
+		}
+	}
+
+	if sa.storeKey != nil {
+		geoStore(ctx, &sa, points, flags)
+		return nil
+	}
+	if len(points) == 0 {
+		ctx.OutContent = resp.EncArray([][]byte{})
+		return nil
+	}
+	var out strings.Builder
+	out.WriteString(resp.ArrayFlag + strconv.Itoa(len(points)) + resp.CRLF)
+	options := 0
+	for _, with := range []bool{sa.withDist, sa.withHash, sa.withCoord} {
+		if with {
+			options++
+		}
+	}
+	for _, p := range points {
+		if options == 0 {
+			out.WriteString(resp.EncBulkString(util.BytesToString(p.member)))
+			continue
+		}
+		out.WriteString(resp.ArrayFlag + strconv.Itoa(options+1) + resp.CRLF)
+		out.WriteString(resp.EncBulkString(util.BytesToString(p.member)))
+		if sa.withDist {
+			out.WriteString(resp.EncBulkString(formatGeoDistance(p.distance)))
+		}
+		if sa.withHash {
+			out.WriteString(resp.EncInteger(int64(p.score)))
+		}
+		if sa.withCoord {
+			out.WriteString(resp.EncArray([][]byte{[]byte(formatGeoCoord(p.longitude)), []byte(formatGeoCoord(p.latitude))}))
+		}
+	}
+	ctx.OutContent = out.String()
+	return nil
+}
+
+// geoStore stores the members found in a sorted set, with their geohashes or distances as the scores,
+// the key is deleted if none is found
+func geoStore(ctx *CmdContext, sa *geoSearchArgs, points []geoPoint, flags geoSearchFlag) {
+	members := make([]storage.ZMember, 0, len(points))
+	for _, p := range points {
+		score := p.score
+		if sa.storeDist {
+			score = p.distance
+		}
+		members = append(members, storage.ZMember{Member: p.member, Score: score})
+	}
+	event := "georadiusstore"
+	if flags&geoSearch != 0 {
+		event = "geosearchstore"
+	}
+	storeZMembers(ctx, sa.storeKey, members, event)
+}
+
+// geoRadiusStores reports whether GEORADIUS or GEORADIUSBYMEMBER is given STORE or STOREDIST, only then
+// the transaction lock is held exclusively. A member named store takes the lock as well, which is harmless
+func geoRadiusStores(args [][]byte) bool {
+	for _, arg := range args {
+		if strings.EqualFold(util.BytesToString(arg), "store") || strings.EqualFold(util.BytesToString(arg), "storedist") {
+			return true
+		}
+	}
+	return false
+}
+
+// GeoRadius returns the members within the radius around the longitude and latitude
+func GeoRadius(ctx *CmdContext) error {
+	return geoRadius(ctx, geoRadiusCoords)
+}
+
+// GeoRadiusRo is the read-only variant of GEORADIUS
+func GeoRadiusRo(ctx *CmdContext) error {
+	return geoRadius(ctx, geoRadiusCoords|geoNoStore)
+}
+
+// GeoRadiusByMember returns the members within the radius around a member
+func GeoRadiusByMember(ctx *CmdContext) error {
+	return geoRadius(ctx, geoRadiusMember)
+}
+
+// GeoRadiusByMemberRo is the read-only variant of GEORADIUSBYMEMBER
+func GeoRadiusByMemberRo(ctx *CmdContext) error {
+	return geoRadius(ctx, geoRadiusMember|geoNoStore)
+}
+
+// GeoSearch returns the members within the circle or the box around a member or a location
+func GeoSearch(ctx *CmdContext) error {
+	return geoRadius(ctx, geoSearch)
+}
+
+// GeoSearchStore stores the members found like GEOSEARCH in the destination key
+func GeoSearchStore(ctx *CmdContext) error {
+	return geoRadius(ctx, geoSearch|geoSearchStore)
+}
@@ -17,13 +17,14 @@
 package command
 
 import (
+	"errors"
 	"strconv"
 	"strings"
 
-	"github.com/oceanbase/obkv-table-client-go/table"
 	"github.com/oceanbase/obkv-table-client-go/util"
 
 	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/oceanbase/modis/storage"
 )
 
 const (
@@ -39,7 +40,7 @@ func HDel(ctx *CmdContext) error {
 
 	deleteNum, err := ctx.CodecCtx.DB.Storage.HDel(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, fields)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncInteger(deleteNum)
 	}
@@ -56,7 +57,7 @@ func HSetNX(ctx *CmdContext) error {
 		value := ctx.Args[2]
 		insertCount, err := ctx.CodecCtx.DB.Storage.HSetNx(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, field, value)
 		if err != nil {
-			ctx.OutContent = resp.EncError("ERR " + err.Error())
+			ctx.OutContent = encStorageError(err)
 		} else {
 			ctx.OutContent = resp.EncInteger(int64(insertCount))
 		}
@@ -70,7 +71,7 @@ func HGet(ctx *CmdContext) error {
 	field := ctx.Args[1]
 	val, err := ctx.CodecCtx.DB.Storage.HGet(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, field)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		if val == nil {
 			ctx.OutContent = resp.EncNullBulkString()
@@ -86,7 +87,7 @@ func HGetAll(ctx *CmdContext) error {
 	key := ctx.Args[0]
 	resValue, err := ctx.CodecCtx.DB.Storage.HGetAll(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncArray(resValue)
 	}
@@ -99,7 +100,7 @@ func HExists(ctx *CmdContext) error {
 	field := ctx.Args[1]
 	val, err := ctx.CodecCtx.DB.Storage.HGet(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, field)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		if val == nil {
 			ctx.OutContent = resp.EncInteger(int64(0))
@@ -117,14 +118,14 @@ func HIncrBy(ctx *CmdContext) error {
 	value := ctx.Args[2]
 	_, err := strconv.ParseInt(util.BytesToString(ctx.Args[2]), 10, 64)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 		return nil
 	}
 
 	res, err := ctx.CodecCtx.DB.Storage.HIncrBy(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, field, value)
 	if err != nil {
-		if strings.Contains(err.Error(), "-4262") {
-			ctx.OutContent = resp.EncError("ERR " + err.Error())
+		if strings.Contains(err.Error(), "-4262") || errors.Is(err, storage.ErrWrongType) {
+			ctx.OutContent = encStorageError(err)
 		} else {
 			ctx.OutContent = resp.EncError("ERR hash value is not an integer")
 		}
@@ -149,8 +150,8 @@ func HIncrByFloat(ctx *CmdContext) error {
 
 	f64, err := ctx.CodecCtx.DB.Storage.HIncrByFloat(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, field, value)
 	if err != nil {
-		if strings.Contains(err.Error(), "-4262") {
-			ctx.OutContent = resp.EncError("ERR " + err.Error())
+		if strings.Contains(err.Error(), "-4262") || errors.Is(err, storage.ErrWrongType) {
+			ctx.OutContent = encStorageError(err)
 		} else {
 			ctx.OutContent = resp.EncError("ERR hash value is not a float")
 		}
@@ -165,7 +166,7 @@ func HKeys(ctx *CmdContext) error {
 	key := ctx.Args[0]
 	resValue, err := ctx.CodecCtx.DB.Storage.HKeys(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncArray(resValue)
 	}
@@ -177,7 +178,7 @@ func HVals(ctx *CmdContext) error {
 	key := ctx.Args[0]
 	resValue, err := ctx.CodecCtx.DB.Storage.HVals(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncArray(resValue)
 	}
@@ -189,7 +190,7 @@ func HLen(ctx *CmdContext) error {
 	key := []byte(ctx.Args[0])
 	size, err := ctx.CodecCtx.DB.Storage.HLen(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncInteger(size)
 	}
@@ -205,24 +206,51 @@ func HMGet(ctx *CmdContext) error {
 
 	values, err := ctx.CodecCtx.DB.Storage.HMGet(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, fields)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncArray(values)
 	}
 	return nil
 }
 
-// compat server
-func HashCmdWithKey(ctx *CmdContext) error {
+func hashFieldValues(args [][]byte) map[string][]byte {
+	fieldValues := make(map[string][]byte, len(args)/2)
+	for i := 0; i < len(args); i += 2 {
+		fieldValues[util.BytesToString(args[i])] = args[i+1]
+	}
+	return fieldValues
+}
+
+// HSet sets the specified fields to their respective values in the hash stored at key
+func HSet(ctx *CmdContext) error {
+	key := ctx.Args[0]
+	if len(ctx.Args[1:])%2 != 0 {
+		ctx.OutContent = resp.ErrWrongArgs(ctx.FullName)
+		return nil
+	}
+
+	added, err := ctx.CodecCtx.DB.Storage.HSet(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, hashFieldValues(ctx.Args[1:]))
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(added)
+	}
+	return nil
+}
+
+// HMSet sets the specified fields to their respective values in the hash stored at key
+func HMSet(ctx *CmdContext) error {
 	key := ctx.Args[0]
-	var err error
-	rowKey := []*table.Column{
-		table.NewColumn(dbColumnName, ctx.CodecCtx.DB.ID),
-		table.NewColumn(keyColumnName, key),
+	if len(ctx.Args[1:])%2 != 0 {
+		ctx.OutContent = resp.ErrWrongArgs(ctx.FullName)
+		return nil
 	}
-	ctx.OutContent, err = ctx.CodecCtx.DB.Storage.ObServerCmd(ctx.CodecCtx.DB.Ctx, hashTableName, rowKey, ctx.PlainReq)
+
+	_, err := ctx.CodecCtx.DB.Storage.HSet(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, hashFieldValues(ctx.Args[1:]))
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.ResponsesOk
 	}
 	return nil
 }
@@ -0,0 +1,189 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/oceanbase/modis/util"
+)
+
+var errNotHLL = resp.EncError("WRONGTYPE Key is not a valid HyperLogLog string value.")
+
+// getHLL returns the HyperLogLog stored at key along with the value read, nil if the key does not exist
+func getHLL(ctx *CmdContext, key []byte) (*util.HLL, []byte, string) {
+	val, err := ctx.CodecCtx.DB.Storage.Get(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
+	if err != nil {
+		return nil, nil, encStorageError(err)
+	}
+	if val == nil {
+		return nil, nil, ""
+	}
+	h, ok := util.ParseHLL(val)
+	if !ok {
+		return nil, nil, errNotHLL
+	}
+	return h, val, ""
+}
+
+// PFAdd adds the elements to the HyperLogLog stored at key, returns 1 if it is changed. The value is
+// written only if it is still the one read, it is read again otherwise, so that the registers set by
+// another client meanwhile are kept
+func PFAdd(ctx *CmdContext) error {
+	key := ctx.Args[0]
+	for {
+		h, old, errReply := getHLL(ctx, key)
+		if errReply != "" {
+			ctx.OutContent = errReply
+			return nil
+		}
+		updated := false
+		if h == nil {
+			h, updated = util.NewHLL(), true
+		}
+		for _, ele := range ctx.Args[1:] {
+			ok, err := h.Add(ele)
+			if err != nil {
+				ctx.OutContent = resp.EncError(err.Error())
+				return nil
+			}
+			updated = updated || ok
+		}
+		if !updated {
+			ctx.OutContent = resp.EncInteger(0)
+			return nil
+		}
+
+		h.InvalidateCache()
+		ok, err := ctx.CodecCtx.DB.Storage.CompareAndSet(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, old, h.Bytes())
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+		if ok {
+			ctx.OutContent = resp.EncInteger(1)
+			return nil
+		}
+	}
+}
+
+// PFCount returns the approximated cardinality of the HyperLogLog, or of the union of several ones.
+// The cardinality of a single key is cached in the value, which is written back if it was invalid
+// and the value is still the one read
+func PFCount(ctx *CmdContext) error {
+	// only the cache of a single key is ever written
+	ctx.Modified = [][]byte{}
+	if len(ctx.Args) > 1 {
+		max := make([]uint8, util.HLLRegisters)
+		for _, key := range ctx.Args {
+			h, _, errReply := getHLL(ctx, key)
+			if errReply != "" {
+				ctx.OutContent = errReply
+				return nil
+			}
+			if h == nil {
+				continue
+			}
+			if err := h.MergeInto(max); err != nil {
+				ctx.OutContent = resp.EncError(err.Error())
+				return nil
+			}
+		}
+		ctx.OutContent = resp.EncInteger(int64(util.CountHLLRegisters(max)))
+		return nil
+	}
+
+	key := ctx.Args[0]
+	h, old, errReply := getHLL(ctx, key)
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+	if h == nil {
+		ctx.OutContent = resp.EncInteger(0)
+		return nil
+	}
+	card, cached, err := h.Count()
+	if err != nil {
+		ctx.OutContent = resp.EncError(err.Error())
+		return nil
+	}
+	if !cached {
+		// the cache is left invalid if the value has been changed meanwhile, card is still right for the value read
+		ok, err := ctx.CodecCtx.DB.Storage.CompareAndSet(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, old, h.Bytes())
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+		if ok {
+			ctx.Modified = nil
+		}
+	}
+	ctx.OutContent = resp.EncInteger(int64(card))
+	return nil
+}
+
+// PFMerge merges the HyperLogLogs into the one stored at the destination key, which is merged too.
+// The destination is written only if it is still the one read, the sources are read again otherwise
+func PFMerge(ctx *CmdContext) error {
+	for {
+		max := make([]uint8, util.HLLRegisters)
+		useDense := false
+		var dst *util.HLL
+		var old []byte
+		for i, key := range ctx.Args {
+			h, val, errReply := getHLL(ctx, key)
+			if errReply != "" {
+				ctx.OutContent = errReply
+				return nil
+			}
+			if h == nil {
+				continue
+			}
+			if i == 0 {
+				dst, old = h, val
+			}
+			// the destination is dense if any of the sources is
+			if h.IsDense() {
+				useDense = true
+			}
+			if err := h.MergeInto(max); err != nil {
+				ctx.OutContent = resp.EncError(err.Error())
+				return nil
+			}
+		}
+
+		if dst == nil {
+			dst = util.NewHLL()
+		}
+		if useDense {
+			if err := dst.ToDense(); err != nil {
+				ctx.OutContent = resp.EncError(err.Error())
+				return nil
+			}
+		}
+		dst.SetRegisters(max)
+		ok, err := ctx.CodecCtx.DB.Storage.CompareAndSet(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], old, dst.Bytes())
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+		if ok {
+			ctx.OutContent = resp.ResponsesOk
+			return nil
+		}
+	}
+}
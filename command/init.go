@@ -16,6 +16,8 @@
 
 package command
 
+import "github.com/oceanbase/modis/connection/conncontext"
+
 var (
 	commands map[string]*CmdInfo
 	tables   []string
@@ -26,119 +28,231 @@ func init() {
 		// connections
 		"auth":   {Cmd: Auth, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 		"echo":   {Cmd: Echo, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"ping":   {Cmd: Ping, Arity: -1, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"ping":   {Cmd: Ping, Arity: -1, Flag: CmdPubSub, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 		"hello":  {Cmd: TempNotSupport, Arity: -1, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"quit":   {Cmd: Quit, Arity: 1, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"quit":   {Cmd: Quit, Arity: 1, Flag: CmdPubSub, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 		"select": {Cmd: Select, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 		"swapdb": {Cmd: SwapDB, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 
 		// client
-		"client|help": {Cmd: ClientHelp, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"client|info": {Cmd: ClientInfo, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"client|list": {Cmd: ClientList, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"client|help":    {Cmd: ClientHelp, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"client|info":    {Cmd: ClientInfo, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"client|list":    {Cmd: ClientList, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"client|id":      {Cmd: ClientID, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"client|unblock": {Cmd: ClientUnblock, Arity: -3, Flag: CmdAdmin | CmdNoScript, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+
+		// config
+		"config|get": {Cmd: ConfigGet, Arity: -3, Flag: CmdAdmin | CmdNoScript, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"config|set": {Cmd: ConfigSet, Arity: -4, Flag: CmdAdmin | CmdNoScript, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 
 		// server
-		"info":    {Cmd: Info, Arity: -1, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"monitor": {Cmd: Monitor, Arity: 1, Flag: CmdAdmin, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"info":     {Cmd: Info, Arity: -1, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"dbsize":   {Cmd: DBSize, Arity: 1, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"flushdb":  {Cmd: FlushDB, Arity: -1, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"flushall": {Cmd: FlushAll, Arity: -1, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"monitor":  {Cmd: Monitor, Arity: 1, Flag: CmdAdmin | CmdNoScript, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+
+		// transactions
+		"multi":   {Cmd: Multi, Arity: 1, Flag: CmdTx | CmdNoScript, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"exec":    {Cmd: Exec, Arity: 1, Flag: CmdTx | CmdExclusive | CmdNoScript, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"discard": {Cmd: Discard, Arity: 1, Flag: CmdTx | CmdNoScript, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"watch":   {Cmd: Watch, Arity: -2, Flag: CmdTx | CmdNoScript, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"unwatch": {Cmd: Unwatch, Arity: 1, Flag: CmdNoScript, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+
+		// pub/sub
+		"subscribe":       {Cmd: Subscribe, Arity: -2, Flag: CmdPubSub | CmdNoScript, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"unsubscribe":     {Cmd: Unsubscribe, Arity: -1, Flag: CmdPubSub | CmdNoScript, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"psubscribe":      {Cmd: PSubscribe, Arity: -2, Flag: CmdPubSub | CmdNoScript, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"punsubscribe":    {Cmd: PUnsubscribe, Arity: -1, Flag: CmdPubSub | CmdNoScript, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"publish":         {Cmd: Publish, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"pubsub|channels": {Cmd: PubSubChannels, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"pubsub|numsub":   {Cmd: PubSubNumSub, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"pubsub|numpat":   {Cmd: PubSubNumPat, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+
+		// scripting
+		"eval":          {Cmd: Eval, Arity: -3, Flag: CmdExclusive | CmdNoScript, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"evalsha":       {Cmd: EvalSha, Arity: -3, Flag: CmdExclusive | CmdNoScript, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"script|load":   {Cmd: ScriptLoad, Arity: 3, Flag: CmdNoScript, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"script|exists": {Cmd: ScriptExists, Arity: -3, Flag: CmdNoScript, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"script|flush":  {Cmd: ScriptFlush, Arity: -2, Flag: CmdNoScript, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 
 		// strings
 		"get":         {Cmd: Get, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"set":         {Cmd: Set, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"setnx":       {Cmd: SetNx, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"setex":       {Cmd: SetEx, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"psetex":      {Cmd: PSetEx, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"set":         {Cmd: Set, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyString, "set")},
+		"setnx":       {Cmd: SetNx, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyString, "set")},
+		"setex":       {Cmd: SetEx, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyString, "set")},
+		"psetex":      {Cmd: PSetEx, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyString, "set")},
 		"mget":        {Cmd: MGet, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"mset":        {Cmd: MSet, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"mset":        {Cmd: MSet, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, -1, 2}, Notify: notify(conncontext.NotifyString, "set")},
 		"strlen":      {Cmd: Strlen, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"append":      {Cmd: Append, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"incr":        {Cmd: StringCmdWithKey, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"decr":        {Cmd: StringCmdWithKey, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"incrby":      {Cmd: StringCmdWithKey, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"incrbyfloat": {Cmd: IncrByFloat, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"decrby":      {Cmd: StringCmdWithKey, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"setbit":      {Cmd: StringCmdWithKey, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"append":      {Cmd: Append, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyString, "append")},
+		"incr":        {Cmd: StringCmdWithKey, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyString, "incrby")},
+		"decr":        {Cmd: StringCmdWithKey, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyString, "incrby")},
+		"incrby":      {Cmd: StringCmdWithKey, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyString, "incrby")},
+		"incrbyfloat": {Cmd: IncrByFloat, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyString, "incrbyfloat")},
+		"decrby":      {Cmd: StringCmdWithKey, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyString, "incrby")},
+		"setbit":      {Cmd: StringCmdWithKey, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyString, "setbit")},
 		"getbit":      {Cmd: GetBit, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 		"bitcount":    {Cmd: BitCount, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"getset":      {Cmd: StringCmdWithKey, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"setrange":    {Cmd: SetRange, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"bitpos":      {Cmd: BitPos, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"bitop":       {Cmd: BitOp, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{2, 2, 1}},
+		"bitfield":    {Cmd: BitField, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}},
+		"bitfield_ro": {Cmd: BitFieldRo, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"getset":      {Cmd: StringCmdWithKey, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyString, "set")},
+		"setrange":    {Cmd: SetRange, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyString, "setrange")},
 		"getrange":    {Cmd: GetRange, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 
 		// keys
 		"type":      {Cmd: Type, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 		"exists":    {Cmd: Exists, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"del":       {Cmd: Delete, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"expire":    {Cmd: Expire, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"expireat":  {Cmd: ExpireAt, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"pexpire":   {Cmd: PExpire, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"pexpireat": {Cmd: PExpireAt, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"persist":   {Cmd: Persist, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"del":       {Cmd: Delete, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, -1, 1}, Notify: notifyIfModified(conncontext.NotifyGeneric, "del")},
+		"expire":    {Cmd: Expire, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyGeneric, "expire")},
+		"expireat":  {Cmd: ExpireAt, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyGeneric, "expire")},
+		"pexpire":   {Cmd: PExpire, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyGeneric, "expire")},
+		"pexpireat": {Cmd: PExpireAt, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyGeneric, "expire")},
+		"persist":   {Cmd: Persist, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyGeneric, "persist")},
 		"ttl":       {Cmd: TTL, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 		"pttl":      {Cmd: PTTL, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"scan":      {Cmd: Scan, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"keys":      {Cmd: Keys, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"rename":    {Cmd: Rename, Arity: 3, Flag: CmdExclusive, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 2, 1}, Notify: notify(conncontext.NotifyGeneric, "rename_from", "rename_to")},
+		"renamenx":  {Cmd: RenameNX, Arity: 3, Flag: CmdExclusive, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 2, 1}, Notify: notifyIfModified(conncontext.NotifyGeneric, "rename_from", "rename_to")},
+		"copy":      {Cmd: Copy, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"move":      {Cmd: Move, Arity: 3, Flag: CmdExclusive, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+
+		// hyperloglog
+		"pfadd":   {Cmd: PFAdd, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyString, "pfadd")},
+		"pfcount": {Cmd: PFCount, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}},
+		"pfmerge": {Cmd: PFMerge, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyString, "pfadd")},
 
 		// hashes
-		"hdel":         {Cmd: HDel, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"hset":         {Cmd: HashCmdWithKey, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"hdel":         {Cmd: HDel, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyHash, "hdel")},
+		"hset":         {Cmd: HSet, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyHash, "hset")},
 		"hget":         {Cmd: HGet, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 		"hgetall":      {Cmd: HGetAll, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 		"hexists":      {Cmd: HExists, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"hincrby":      {Cmd: HIncrBy, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"hincrbyfloat": {Cmd: HIncrByFloat, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"hincrby":      {Cmd: HIncrBy, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyHash, "hincrby")},
+		"hincrbyfloat": {Cmd: HIncrByFloat, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyHash, "hincrbyfloat")},
 		"hkeys":        {Cmd: HKeys, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 		"hvals":        {Cmd: HVals, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 		"hlen":         {Cmd: HLen, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"hsetnx":       {Cmd: HSetNX, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"hsetnx":       {Cmd: HSetNX, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyHash, "hset")},
 		"hmget":        {Cmd: HMGet, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"hmset":        {Cmd: HashCmdWithKey, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"hmset":        {Cmd: HMSet, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyHash, "hset")},
+		"hscan":        {Cmd: HScan, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 
 		// sets
-		"sadd":        {Cmd: SetCmdWithKey, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"sadd":        {Cmd: SAdd, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifySet, "sadd")},
 		"smembers":    {Cmd: SMembers, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 		"srandmember": {Cmd: SRandMember, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 		"scard":       {Cmd: SCard, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 		"sismember":   {Cmd: SIsmember, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"spop":        {Cmd: SPop, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"srem":        {Cmd: SRem, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"sunion":      {Cmd: SetCmdWithKey, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"sunionstore": {Cmd: SetCmdWithKey, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"sinter":      {Cmd: SetCmdWithKey, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"sinterstore": {Cmd: SetCmdWithKey, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"sdiff":       {Cmd: SetCmdWithKey, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"sdiffstore":  {Cmd: SetCmdWithKey, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"smove":       {Cmd: SMove, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"spop":        {Cmd: SPop, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifySet, "spop")},
+		"srem":        {Cmd: SRem, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifySet, "srem")},
+		"sunion":      {Cmd: SUnion, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"sunionstore": {Cmd: SUnionStore, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifySet, "sunionstore")},
+		"sinter":      {Cmd: SInter, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"sinterstore": {Cmd: SInterStore, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifySet, "sinterstore")},
+		"sdiff":       {Cmd: SDiff, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"sdiffstore":  {Cmd: SDiffStore, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifySet, "sdiffstore")},
+		"smove":       {Cmd: SMove, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 2, 1}, Notify: notifyIfModified(conncontext.NotifySet, "srem", "sadd")},
+		"sscan":       {Cmd: SScan, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 
 		// zsets
-		"zadd":             {Cmd: ZSetCmdWithKey, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"zrange":           {Cmd: ZSetCmdWithKey, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"zrevrange":        {Cmd: ZSetCmdWithKey, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"zrem":             {Cmd: ZSetCmdWithKey, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"zcard":            {Cmd: ZSetCmdWithKey, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"zincrby":          {Cmd: ZIncrBy, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"zscore":           {Cmd: ZSetCmdWithKeyMember, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"zrank":            {Cmd: ZSetCmdWithKeyMember, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"zrevrank":         {Cmd: ZSetCmdWithKeyMember, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"zremrangebyrank":  {Cmd: ZSetCmdWithKey, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"zcount":           {Cmd: ZSetCmdWithKey, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"zadd":             {Cmd: ZAdd, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}},
+		"zrange":           {Cmd: ZRange, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"zrevrange":        {Cmd: ZRevRange, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"zrem":             {Cmd: ZRem, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyZSet, "zrem")},
+		"zcard":            {Cmd: ZCard, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"zincrby":          {Cmd: ZIncrBy, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyZSet, "zincr")},
+		"zscore":           {Cmd: ZScore, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"zmscore":          {Cmd: ZMScore, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"zrandmember":      {Cmd: ZRandMember, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"zrank":            {Cmd: ZRank, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"zrevrank":         {Cmd: ZRevRank, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"zremrangebyrank":  {Cmd: ZRemRangeByRank, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyZSet, "zremrangebyrank")},
+		"zcount":           {Cmd: ZCount, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 		"zrangebyscore":    {Cmd: ZRangeByScore, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"zrevrangebyscore": {Cmd: ZRangeByScore, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"zremrangebyscore": {Cmd: ZSetCmdWithKey, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"zunionstore":      {Cmd: ZSetCmdWithKey, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"zinterstore":      {Cmd: ZSetCmdWithKey, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"zrevrangebyscore": {Cmd: ZRevRangeByScore, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"zremrangebyscore": {Cmd: ZRemRangeByScore, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyZSet, "zremrangebyscore")},
+		"zrangebylex":      {Cmd: ZRangeByLex, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"zrevrangebylex":   {Cmd: ZRevRangeByLex, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"zlexcount":        {Cmd: ZLexCount, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"zremrangebylex":   {Cmd: ZRemRangeByLex, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyZSet, "zremrangebylex")},
+		"zpopmin":          {Cmd: ZPopMin, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyZSet, "zpopmin")},
+		"zpopmax":          {Cmd: ZPopMax, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyZSet, "zpopmax")},
+		"zmpop":            {Cmd: ZMPop, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{2, -1, 1}},
+		"bzpopmin":         {Cmd: BZPopMin, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, -2, 1}, Notify: notifyIfModified(conncontext.NotifyZSet, "zpopmin")},
+		"bzpopmax":         {Cmd: BZPopMax, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, -2, 1}, Notify: notifyIfModified(conncontext.NotifyZSet, "zpopmax")},
+		"zunionstore":      {Cmd: ZUnionStore, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyZSet, "zunionstore")},
+		"zinterstore":      {Cmd: ZInterStore, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyZSet, "zinterstore")},
+		"zrangestore":      {Cmd: ZRangeStore, Arity: -5, Flag: CmdExclusive, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}},
+		"zunion":           {Cmd: ZUnion, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"zinter":           {Cmd: ZInter, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"zdiff":            {Cmd: ZDiff, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"zintercard":       {Cmd: ZInterCard, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"zscan":            {Cmd: ZScan, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+
+		// geo
+		"geoadd":               {Cmd: GeoAdd, Arity: -5, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyZSet, "zadd")},
+		"geopos":               {Cmd: GeoPos, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"geodist":              {Cmd: GeoDist, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"geohash":              {Cmd: GeoHash, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"georadius":            {Cmd: GeoRadius, Arity: -6, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, ExclusiveIf: geoRadiusStores},
+		"georadius_ro":         {Cmd: GeoRadiusRo, Arity: -6, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"georadiusbymember":    {Cmd: GeoRadiusByMember, Arity: -5, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, ExclusiveIf: geoRadiusStores},
+		"georadiusbymember_ro": {Cmd: GeoRadiusByMemberRo, Arity: -5, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"geosearch":            {Cmd: GeoSearch, Arity: -7, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"geosearchstore":       {Cmd: GeoSearchStore, Arity: -8, Flag: CmdExclusive, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}},
 
 		// list
-		"lpush":     {Cmd: ListCmd, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"lpushx":    {Cmd: ListCmd, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"rpush":     {Cmd: ListCmd, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"rpushx":    {Cmd: ListCmd, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"lpop":      {Cmd: ListCmd, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"rpop":      {Cmd: ListCmd, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"lindex":    {Cmd: ListCmd, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"lset":      {Cmd: ListCmd, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"lrange":    {Cmd: ListCmd, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"ltrim":     {Cmd: ListCmd, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"linsert":   {Cmd: ListCmd, Arity: 5, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"llen":      {Cmd: ListCmd, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"lrem":      {Cmd: ListCmd, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
-		"rpoplpush": {Cmd: TempNotSupport, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"lpush":     {Cmd: LPush, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyList, "lpush")},
+		"lpushx":    {Cmd: LPushX, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyList, "lpush")},
+		"rpush":     {Cmd: RPush, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyList, "rpush")},
+		"rpushx":    {Cmd: RPushX, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyList, "rpush")},
+		"lpop":      {Cmd: LPop, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyList, "lpop")},
+		"rpop":      {Cmd: RPop, Arity: -2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyList, "rpop")},
+		"lindex":    {Cmd: LIndex, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"lset":      {Cmd: LSet, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyList, "lset")},
+		"lrange":    {Cmd: LRange, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"ltrim":     {Cmd: LTrim, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notify(conncontext.NotifyList, "ltrim")},
+		"linsert":   {Cmd: LInsert, Arity: 5, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyList, "linsert")},
+		"llen":      {Cmd: LLen, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"lrem":      {Cmd: LRem, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyList, "lrem")},
+		"rpoplpush": {Cmd: RPopLPush, Arity: 3, Flag: CmdExclusive, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 2, 1}},
+		"lmove":     {Cmd: LMove, Arity: 5, Flag: CmdExclusive, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 2, 1}},
+		"lmpop":     {Cmd: LMPop, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{2, -1, 1}},
+		"lpos":      {Cmd: LPos, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+
+		// blocking list
+		"blpop":      {Cmd: BLPop, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, -2, 1}, Notify: notifyIfModified(conncontext.NotifyList, "lpop")},
+		"brpop":      {Cmd: BRPop, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, -2, 1}, Notify: notifyIfModified(conncontext.NotifyList, "rpop")},
+		"brpoplpush": {Cmd: BRPopLPush, Arity: 4, Flag: CmdExclusive, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 2, 1}},
+		"blmove":     {Cmd: BLMove, Arity: 6, Flag: CmdExclusive, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 2, 1}},
+
+		// streams
+		"xadd":                  {Cmd: XAdd, Arity: -5, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}},
+		"xrange":                {Cmd: XRange, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"xrevrange":             {Cmd: XRevRange, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"xlen":                  {Cmd: XLen, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"xdel":                  {Cmd: XDel, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyStream, "xdel")},
+		"xtrim":                 {Cmd: XTrim, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{1, 1, 1}, Notify: notifyIfModified(conncontext.NotifyStream, "xtrim")},
+		"xread":                 {Cmd: XRead, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"xreadgroup":            {Cmd: XReadGroup, Arity: -7, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"xack":                  {Cmd: XAck, Arity: -4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"xpending":              {Cmd: XPending, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"xclaim":                {Cmd: XClaim, Arity: -6, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"xautoclaim":            {Cmd: XAutoClaim, Arity: -6, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"xgroup|create":         {Cmd: XGroupCreate, Arity: -5, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{2, 2, 1}, Notify: notify(conncontext.NotifyStream, "xgroup-create")},
+		"xgroup|setid":          {Cmd: XGroupSetID, Arity: -5, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{2, 2, 1}, Notify: notify(conncontext.NotifyStream, "xgroup-setid")},
+		"xgroup|destroy":        {Cmd: XGroupDestroy, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{2, 2, 1}, Notify: notifyIfModified(conncontext.NotifyStream, "xgroup-destroy")},
+		"xgroup|createconsumer": {Cmd: XGroupCreateConsumer, Arity: 5, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{2, 2, 1}, Notify: notifyIfModified(conncontext.NotifyStream, "xgroup-createconsumer")},
+		"xgroup|delconsumer":    {Cmd: XGroupDelConsumer, Arity: 5, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}, WriteKeys: KeySpec{2, 2, 1}},
+		"xgroup|help":           {Cmd: XGroupHelp, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"xinfo|stream":          {Cmd: XInfoStream, Arity: -3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"xinfo|groups":          {Cmd: XInfoGroups, Arity: 3, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"xinfo|consumers":       {Cmd: XInfoConsumers, Arity: 4, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
+		"xinfo|help":            {Cmd: XInfoHelp, Arity: 2, Flag: CmdNone, Stats: CmdStats{Calls: 0, MicroSec: 0}},
 	}
 
 	tables = []string{
@@ -147,5 +261,6 @@ func init() {
 		"modis_set_table",
 		"modis_list_table",
 		"modis_zset_table",
+		"modis_stream_table",
 	}
 }
@@ -17,13 +17,18 @@
 package command
 
 import (
+	"bytes"
+	"errors"
 	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/oceanbase/obkv-table-client-go/util"
 
+	"github.com/oceanbase/modis/connection/conncontext"
 	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/oceanbase/modis/storage"
 )
 
 // Delete removes the specified keys. A key is ignored if it does not exist
@@ -31,22 +36,43 @@ func Delete(ctx *CmdContext) error {
 	keys := make([][]byte, len(ctx.Args))
 	copy(keys, ctx.Args)
 
+	// delete the keys one by one to tell which of them fire the del event
+	if len(keys) > 1 && ctx.ServCtx.NotifyEvents()&conncontext.NotifyGeneric != 0 {
+		return deleteEach(ctx, keys)
+	}
 	delNum, err := ctx.CodecCtx.DB.Storage.Delete(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, keys)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncInteger(delNum)
 	}
 	return nil
 }
 
+// deleteEach deletes the keys one by one, and records the deleted ones in ctx.Modified
+func deleteEach(ctx *CmdContext, keys [][]byte) error {
+	ctx.Modified = make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		delNum, err := ctx.CodecCtx.DB.Storage.Delete(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, [][]byte{key})
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+		if delNum != 0 {
+			ctx.Modified = append(ctx.Modified, key)
+		}
+	}
+	ctx.OutContent = resp.EncInteger(int64(len(ctx.Modified)))
+	return nil
+}
+
 // Exists returns if key exists
 func Exists(ctx *CmdContext) error {
 	keys := make([][]byte, len(ctx.Args))
 	copy(keys, ctx.Args)
 	val, err := ctx.CodecCtx.DB.Storage.Exists(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, keys)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncInteger(val)
 	}
@@ -65,7 +91,7 @@ func Expire(ctx *CmdContext) error {
 	at := time.Now().Add(time.Second * time.Duration(seconds))
 	res, err := ctx.CodecCtx.DB.Storage.Expire(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, at)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncInteger(int64(res))
 	}
@@ -84,7 +110,7 @@ func ExpireAt(ctx *CmdContext) error {
 	at := time.Unix(timestamp, 0)
 	res, err := ctx.CodecCtx.DB.Storage.Expire(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, at)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncInteger(int64(res))
 	}
@@ -97,7 +123,7 @@ func Persist(ctx *CmdContext) error {
 
 	res, err := ctx.CodecCtx.DB.Storage.Persist(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncInteger(int64(res))
 	}
@@ -116,7 +142,7 @@ func PExpire(ctx *CmdContext) error {
 	at := time.Now().Add(time.Millisecond * time.Duration(ms))
 	res, err := ctx.CodecCtx.DB.Storage.Expire(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, at)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncInteger(int64(res))
 	}
@@ -137,7 +163,7 @@ func PExpireAt(ctx *CmdContext) error {
 	at := time.Unix(0, nanoseconds)
 	res, err := ctx.CodecCtx.DB.Storage.Expire(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, at)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncInteger(int64(res))
 	}
@@ -150,7 +176,7 @@ func TTL(ctx *CmdContext) error {
 
 	res, err := ctx.CodecCtx.DB.Storage.TTL(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else if res < 0 {
 		ctx.OutContent = resp.EncInteger(int64(res))
 	} else {
@@ -166,7 +192,7 @@ func PTTL(ctx *CmdContext) error {
 
 	res, err := ctx.CodecCtx.DB.Storage.TTL(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else if res < 0 {
 		ctx.OutContent = resp.EncInteger(int64(res))
 	} else {
@@ -180,11 +206,127 @@ func Type(ctx *CmdContext) error {
 	key := ctx.Args[0]
 	val, err := ctx.CodecCtx.DB.Storage.Type(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else if val == nil {
-		ctx.OutContent = resp.EncBulkString("none")
+		ctx.OutContent = resp.EncSimpleString("none")
+	} else {
+		ctx.OutContent = resp.EncSimpleString(string(val))
+	}
+	return nil
+}
+
+// Rename renames key to newkey, the value of newkey is overwritten if it exists
+func Rename(ctx *CmdContext) error {
+	db := ctx.CodecCtx.DB
+	_, err := db.Storage.Rename(db.Ctx, db.ID, ctx.Args[0], db.ID, ctx.Args[1], true)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
 	} else {
-		ctx.OutContent = resp.EncBulkString(string(val))
+		ctx.OutContent = resp.ResponsesOk
+	}
+	return nil
+}
+
+// RenameNX renames key to newkey only if newkey does not exist
+func RenameNX(ctx *CmdContext) error {
+	db := ctx.CodecCtx.DB
+	key, newKey := ctx.Args[0], ctx.Args[1]
+	if bytes.Equal(key, newKey) {
+		// the key exists as newkey once it is found
+		exists, err := db.Storage.Exists(db.Ctx, db.ID, [][]byte{key})
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+		} else if exists == 0 {
+			ctx.OutContent = encStorageError(storage.ErrNoSuchKey)
+		} else {
+			ctx.OutContent = resp.EncInteger(0)
+		}
+		return nil
+	}
+
+	ok, err := db.Storage.Rename(db.Ctx, db.ID, key, db.ID, newKey, false)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = encBool(ok)
+	}
+	return nil
+}
+
+// Copy copies the value of source to destination, in the db given by the DB option
+// if specified, destination is overwritten only with the REPLACE option
+func Copy(ctx *CmdContext) error {
+	db := ctx.CodecCtx.DB
+	src, dst := ctx.Args[0], ctx.Args[1]
+	dstDB := db.ID
+	replace := false
+	for i := 2; i < len(ctx.Args); i++ {
+		switch strings.ToLower(util.BytesToString(ctx.Args[i])) {
+		case "replace":
+			replace = true
+		case "db":
+			if i+1 >= len(ctx.Args) {
+				ctx.OutContent = resp.ResponseSyntaxErr
+				return nil
+			}
+			i++
+			var ok bool
+			if dstDB, ok = parseDBIndex(ctx, ctx.Args[i]); !ok {
+				return nil
+			}
+		default:
+			ctx.OutContent = resp.ResponseSyntaxErr
+			return nil
+		}
+	}
+	if dstDB == db.ID && bytes.Equal(src, dst) {
+		ctx.OutContent = resp.EncError("ERR source and destination objects are the same")
+		return nil
+	}
+
+	ok, err := db.Storage.Copy(db.Ctx, db.ID, src, dstDB, dst, replace)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+		return nil
+	}
+	if ok {
+		// the destination may be in another db, so it is not touched by the key spec
+		touchWatched(ctx, dstDB, [][]byte{dst})
+		ctx.ServCtx.Blocked.Touch(dstDB, [][]byte{dst})
+		ctx.ServCtx.NotifyKeyspaceEvent(conncontext.NotifyGeneric, "copy_to", dstDB, dst)
+	}
+	ctx.OutContent = encBool(ok)
+	return nil
+}
+
+// Move moves key to the given db, nothing is done if key exists in the target db
+func Move(ctx *CmdContext) error {
+	db := ctx.CodecCtx.DB
+	key := ctx.Args[0]
+	dstDB, ok := parseDBIndex(ctx, ctx.Args[1])
+	if !ok {
+		return nil
+	}
+	if dstDB == db.ID {
+		ctx.OutContent = resp.EncError("ERR source and destination objects are the same")
+		return nil
+	}
+
+	ok, err := db.Storage.Rename(db.Ctx, db.ID, key, dstDB, key, false)
+	if errors.Is(err, storage.ErrNoSuchKey) {
+		ctx.OutContent = resp.EncInteger(0)
+		return nil
+	} else if err != nil {
+		ctx.OutContent = encStorageError(err)
+		return nil
+	}
+	if ok {
+		touchWatched(ctx, db.ID, [][]byte{key})
+		touchWatched(ctx, dstDB, [][]byte{key})
+		ctx.ServCtx.Blocked.Touch(dstDB, [][]byte{key})
+		ctx.ServCtx.NotifyKeyspaceEvent(conncontext.NotifyGeneric, "move_from", db.ID, key)
+		ctx.ServCtx.NotifyKeyspaceEvent(conncontext.NotifyGeneric, "move_to", dstDB, key)
 	}
+	ctx.OutContent = encBool(ok)
 	return nil
 }
@@ -17,26 +17,517 @@
 package command
 
 import (
-	"github.com/oceanbase/modis/protocol/resp"
-	"github.com/oceanbase/obkv-table-client-go/table"
 	"math"
+	"strconv"
+	"strings"
+
+	"github.com/oceanbase/obkv-table-client-go/util"
+
+	"github.com/oceanbase/modis/connection/conncontext"
+	"github.com/oceanbase/modis/protocol/resp"
 )
 
 const (
 	listTableName = "modis_list_table"
 )
 
-func ListCmd(ctx *CmdContext) error {
-	key := ctx.Args[0]
+// LPush inserts all the specified values at the head of the list stored at key
+func LPush(ctx *CmdContext) error {
+	length, err := ctx.CodecCtx.DB.Storage.LPush(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], ctx.Args[1:])
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(length)
+	}
+	return nil
+}
+
+// LPushX inserts the specified values at the head of the list stored at key, only if key already exists
+func LPushX(ctx *CmdContext) error {
+	length, err := ctx.CodecCtx.DB.Storage.LPushX(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], ctx.Args[1:])
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(length)
+	}
+	return nil
+}
+
+// RPush inserts all the specified values at the tail of the list stored at key
+func RPush(ctx *CmdContext) error {
+	length, err := ctx.CodecCtx.DB.Storage.RPush(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], ctx.Args[1:])
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(length)
+	}
+	return nil
+}
+
+// RPushX inserts the specified values at the tail of the list stored at key, only if key already exists
+func RPushX(ctx *CmdContext) error {
+	length, err := ctx.CodecCtx.DB.Storage.RPushX(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], ctx.Args[1:])
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(length)
+	}
+	return nil
+}
+
+// LPop removes and returns the first elements of the list stored at key
+func LPop(ctx *CmdContext) error {
+	return pop(ctx, true)
+}
+
+// RPop removes and returns the last elements of the list stored at key
+func RPop(ctx *CmdContext) error {
+	return pop(ctx, false)
+}
+
+// pop removes and returns an element from the head or the tail of the list, or an array of
+// at most count elements if count is given
+func pop(ctx *CmdContext, left bool) error {
+	if len(ctx.Args) == 1 {
+		var value []byte
+		var err error
+		if left {
+			value, err = ctx.CodecCtx.DB.Storage.LPop(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0])
+		} else {
+			value, err = ctx.CodecCtx.DB.Storage.RPop(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0])
+		}
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+		} else if value == nil {
+			ctx.OutContent = resp.EncNullBulkString()
+		} else {
+			ctx.OutContent = resp.EncBulkString(util.BytesToString(value))
+		}
+		return nil
+	}
+	if len(ctx.Args) > 2 {
+		ctx.OutContent = resp.ErrWrongArgs(ctx.FullName)
+		return nil
+	}
+
+	count, err := strconv.ParseInt(util.BytesToString(ctx.Args[1]), 10, 64)
+	if err != nil || count < 0 {
+		ctx.OutContent = resp.EncError("ERR value is out of range, must be positive")
+		return nil
+	}
+	values, err := popCount(ctx, ctx.Args[0], left, count)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else if values == nil {
+		ctx.OutContent = resp.ResponsesNullArray
+	} else {
+		ctx.OutContent = resp.EncArray(values)
+	}
+	return nil
+}
+
+// popCount pops at most count elements from the head or the tail of the list, nil if the list not exists
+func popCount(ctx *CmdContext, key []byte, left bool, count int64) ([][]byte, error) {
+	var values [][]byte
 	var err error
-	rowKey := []*table.Column{
-		table.NewColumn(dbColumnName, ctx.CodecCtx.DB.ID),
-		table.NewColumn(keyColumnName, key),
-		table.NewColumn(indexColumnName, int64(math.MinInt64)),
+	if left {
+		values, err = ctx.CodecCtx.DB.Storage.LPopCount(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, count)
+	} else {
+		values, err = ctx.CodecCtx.DB.Storage.RPopCount(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, count)
+	}
+	if err == nil && len(values) == 0 {
+		// nothing is popped
+		ctx.Modified = [][]byte{}
 	}
-	ctx.OutContent, err = ctx.CodecCtx.DB.Storage.ObServerCmd(ctx.CodecCtx.DB.Ctx, listTableName, rowKey, ctx.PlainReq)
+	return values, err
+}
+
+// LMPop pops at most count elements from the first non-empty list of the keys
+func LMPop(ctx *CmdContext) error {
+	numKeys, err := strconv.ParseInt(util.BytesToString(ctx.Args[0]), 10, 64)
+	if err != nil {
+		ctx.OutContent = resp.ResponseIntegerErr
+		return nil
+	}
+	if numKeys <= 0 {
+		ctx.OutContent = resp.EncError("ERR numkeys should be greater than 0")
+		return nil
+	}
+	if numKeys > int64(len(ctx.Args)-2) {
+		ctx.OutContent = resp.ResponseSyntaxErr
+		return nil
+	}
+	keys := ctx.Args[1 : 1+numKeys]
+	left, ok := parseListWhere(ctx.Args[1+numKeys])
+	if !ok {
+		ctx.OutContent = resp.ResponseSyntaxErr
+		return nil
+	}
+	count := int64(1)
+	opts := ctx.Args[2+numKeys:]
+	if len(opts) != 0 {
+		if len(opts) != 2 || !strings.EqualFold(util.BytesToString(opts[0]), "count") {
+			ctx.OutContent = resp.ResponseSyntaxErr
+			return nil
+		}
+		count, err = strconv.ParseInt(util.BytesToString(opts[1]), 10, 64)
+		if err != nil || count <= 0 {
+			ctx.OutContent = resp.EncError("ERR count should be greater than 0")
+			return nil
+		}
+	}
+
+	for _, key := range keys {
+		values, err := popCount(ctx, key, left, count)
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+		if len(values) != 0 {
+			ctx.Modified = [][]byte{key}
+			event := "rpop"
+			if left {
+				event = "lpop"
+			}
+			ctx.ServCtx.NotifyKeyspaceEvent(conncontext.NotifyList, event, ctx.CodecCtx.DB.ID, key)
+			ctx.OutContent = resp.ArrayFlag + "2" + resp.CRLF +
+				resp.EncBulkString(util.BytesToString(key)) + resp.EncArray(values)
+			return nil
+		}
+	}
+	ctx.OutContent = resp.ResponsesNullArray
+	return nil
+}
+
+// LMove atomically pops an element from the source list and pushes it to the destination list
+func LMove(ctx *CmdContext) error {
+	srcLeft, dstLeft, ok := parseListWheres(ctx.Args[2], ctx.Args[3])
+	if !ok {
+		ctx.OutContent = resp.ResponseSyntaxErr
+		return nil
+	}
+	moveElement(ctx, srcLeft, dstLeft)
+	return nil
+}
+
+// RPopLPush atomically pops the last element of the source list and pushes it to the head of the destination list
+func RPopLPush(ctx *CmdContext) error {
+	moveElement(ctx, false, true)
+	return nil
+}
+
+// moveElement moves an element from the source list to the destination list, returns false
+// if the source list is empty
+func moveElement(ctx *CmdContext, srcLeft bool, dstLeft bool) bool {
+	src, dst := ctx.Args[0], ctx.Args[1]
+	value, err := ctx.CodecCtx.DB.Storage.LMove(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, src, dst, srcLeft, dstLeft)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+		return true
+	}
+	if value == nil {
+		ctx.OutContent = resp.EncNullBulkString()
+		return false
+	}
+	notifyListMove(ctx, src, dst, srcLeft, dstLeft)
+	ctx.OutContent = resp.EncBulkString(util.BytesToString(value))
+	return true
+}
+
+// BLPop is the blocking version of LPOP, it pops from the first non-empty list of the keys
+func BLPop(ctx *CmdContext) error {
+	return blockingPop(ctx, true)
+}
+
+// BRPop is the blocking version of RPOP, it pops from the first non-empty list of the keys
+func BRPop(ctx *CmdContext) error {
+	return blockingPop(ctx, false)
+}
+
+// blockingPop pops from the head or the tail of the first non-empty list, the client is blocked
+// if all lists are empty
+func blockingPop(ctx *CmdContext, left bool) error {
+	keys := ctx.Args[:len(ctx.Args)-1]
+	timeout, errReply := parseBlockTimeout(ctx.Args[len(ctx.Args)-1])
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+
+	for _, key := range keys {
+		var value []byte
+		var err error
+		if left {
+			value, err = ctx.CodecCtx.DB.Storage.LPop(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
+		} else {
+			value, err = ctx.CodecCtx.DB.Storage.RPop(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
+		}
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+		if value != nil {
+			ctx.Modified = [][]byte{key}
+			ctx.OutContent = resp.EncArray([][]byte{key, value})
+			return nil
+		}
+	}
+	ctx.OutContent = resp.ResponsesNullArray
+	ctx.block = &blockSpec{keys: keys, timeout: timeout}
+	return nil
+}
+
+// BLMove is the blocking version of LMOVE
+func BLMove(ctx *CmdContext) error {
+	srcLeft, dstLeft, ok := parseListWheres(ctx.Args[2], ctx.Args[3])
+	if !ok {
+		ctx.OutContent = resp.ResponseSyntaxErr
+		return nil
+	}
+	return blockingMove(ctx, srcLeft, dstLeft, ctx.Args[4])
+}
+
+// BRPopLPush is the blocking version of RPOPLPUSH
+func BRPopLPush(ctx *CmdContext) error {
+	return blockingMove(ctx, false, true, ctx.Args[2])
+}
+
+// blockingMove moves an element from the source list to the destination list, the client is blocked
+// if the source list is empty
+func blockingMove(ctx *CmdContext, srcLeft bool, dstLeft bool, timeoutArg []byte) error {
+	timeout, errReply := parseBlockTimeout(timeoutArg)
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+	if !moveElement(ctx, srcLeft, dstLeft) {
+		ctx.OutContent = resp.ResponsesNullArray
+		ctx.block = &blockSpec{keys: ctx.Args[:1], timeout: timeout}
+	}
+	return nil
+}
+
+// parseListWhere parses LEFT or RIGHT, returns true for LEFT
+func parseListWhere(arg []byte) (left bool, ok bool) {
+	switch strings.ToLower(util.BytesToString(arg)) {
+	case "left":
+		return true, true
+	case "right":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// parseListWheres parses the directions of the source and the destination of a move
+func parseListWheres(srcArg []byte, dstArg []byte) (srcLeft bool, dstLeft bool, ok bool) {
+	if srcLeft, ok = parseListWhere(srcArg); !ok {
+		return
+	}
+	dstLeft, ok = parseListWhere(dstArg)
+	return
+}
+
+// notifyListMove fires the pop and push events of an element moved from src to dst, which depend on the directions
+func notifyListMove(ctx *CmdContext, src []byte, dst []byte, srcLeft bool, dstLeft bool) {
+	popEvent, pushEvent := "rpop", "rpush"
+	if srcLeft {
+		popEvent = "lpop"
+	}
+	if dstLeft {
+		pushEvent = "lpush"
+	}
+	ctx.ServCtx.NotifyKeyspaceEvent(conncontext.NotifyList, popEvent, ctx.CodecCtx.DB.ID, src)
+	ctx.ServCtx.NotifyKeyspaceEvent(conncontext.NotifyList, pushEvent, ctx.CodecCtx.DB.ID, dst)
+}
+
+// LIndex returns the element at index in the list stored at key
+func LIndex(ctx *CmdContext) error {
+	index, err := strconv.ParseInt(util.BytesToString(ctx.Args[1]), 10, 64)
+	if err != nil {
+		ctx.OutContent = resp.ResponseIntegerErr
+		return nil
+	}
+
+	value, err := ctx.CodecCtx.DB.Storage.LIndex(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], index)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else if value == nil {
+		ctx.OutContent = resp.EncNullBulkString()
+	} else {
+		ctx.OutContent = resp.EncBulkString(util.BytesToString(value))
+	}
+	return nil
+}
+
+// LPos returns the index of the first match of element in the list stored at key, or the indexes
+// of the matches if COUNT is given
+func LPos(ctx *CmdContext) error {
+	rank, count, maxLen := int64(1), int64(1), int64(0)
+	hasCount := false
+	opts := ctx.Args[2:]
+	for i := 0; i < len(opts); i += 2 {
+		if i+1 >= len(opts) {
+			ctx.OutContent = resp.ResponseSyntaxErr
+			return nil
+		}
+		opt := strings.ToLower(util.BytesToString(opts[i]))
+		if opt != "rank" && opt != "count" && opt != "maxlen" {
+			ctx.OutContent = resp.ResponseSyntaxErr
+			return nil
+		}
+		val, err := strconv.ParseInt(util.BytesToString(opts[i+1]), 10, 64)
+		if err != nil || val == math.MinInt64 {
+			ctx.OutContent = resp.ResponseIntegerErr
+			return nil
+		}
+		switch opt {
+		case "rank":
+			if val == 0 {
+				ctx.OutContent = resp.EncError("ERR RANK can't be zero: use 1 to start from the first match, " +
+					"2 from the second ... or use negative to start from the end of the list")
+				return nil
+			}
+			rank = val
+		case "count":
+			if val < 0 {
+				ctx.OutContent = resp.EncError("ERR COUNT can't be negative")
+				return nil
+			}
+			count, hasCount = val, true
+		case "maxlen":
+			if val < 0 {
+				ctx.OutContent = resp.EncError("ERR MAXLEN can't be negative")
+				return nil
+			}
+			maxLen = val
+		}
+	}
+
+	indexes, err := ctx.CodecCtx.DB.Storage.LPos(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID,
+		ctx.Args[0], ctx.Args[1], rank, count, maxLen)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else if hasCount {
+		var out strings.Builder
+		out.WriteString(resp.ArrayFlag + strconv.Itoa(len(indexes)) + resp.CRLF)
+		for _, index := range indexes {
+			out.WriteString(resp.EncInteger(index))
+		}
+		ctx.OutContent = out.String()
+	} else if len(indexes) == 0 {
+		ctx.OutContent = resp.EncNullBulkString()
+	} else {
+		ctx.OutContent = resp.EncInteger(indexes[0])
+	}
+	return nil
+}
+
+// LSet sets the list element at index to value
+func LSet(ctx *CmdContext) error {
+	index, err := strconv.ParseInt(util.BytesToString(ctx.Args[1]), 10, 64)
+	if err != nil {
+		ctx.OutContent = resp.ResponseIntegerErr
+		return nil
+	}
+
+	err = ctx.CodecCtx.DB.Storage.LSet(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], index, ctx.Args[2])
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.ResponsesOk
+	}
+	return nil
+}
+
+// LRange returns the specified elements of the list stored at key
+func LRange(ctx *CmdContext) error {
+	start, err := strconv.ParseInt(util.BytesToString(ctx.Args[1]), 10, 64)
+	if err != nil {
+		ctx.OutContent = resp.ResponseIntegerErr
+		return nil
+	}
+	stop, err := strconv.ParseInt(util.BytesToString(ctx.Args[2]), 10, 64)
+	if err != nil {
+		ctx.OutContent = resp.ResponseIntegerErr
+		return nil
+	}
+
+	values, err := ctx.CodecCtx.DB.Storage.LRange(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], start, stop)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncArray(values)
+	}
+	return nil
+}
+
+// LTrim trims an existing list so that it will contain only the specified range of elements
+func LTrim(ctx *CmdContext) error {
+	start, err := strconv.ParseInt(util.BytesToString(ctx.Args[1]), 10, 64)
+	if err != nil {
+		ctx.OutContent = resp.ResponseIntegerErr
+		return nil
+	}
+	stop, err := strconv.ParseInt(util.BytesToString(ctx.Args[2]), 10, 64)
+	if err != nil {
+		ctx.OutContent = resp.ResponseIntegerErr
+		return nil
+	}
+
+	err = ctx.CodecCtx.DB.Storage.LTrim(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], start, stop)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.ResponsesOk
+	}
+	return nil
+}
+
+// LInsert inserts value in the list stored at key either before or after the reference value pivot
+func LInsert(ctx *CmdContext) error {
+	var before bool
+	where := util.BytesToString(ctx.Args[1])
+	if strings.EqualFold(where, "before") {
+		before = true
+	} else if !strings.EqualFold(where, "after") {
+		ctx.OutContent = resp.ResponseSyntaxErr
+		return nil
+	}
+
+	length, err := ctx.CodecCtx.DB.Storage.LInsert(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], before, ctx.Args[2], ctx.Args[3])
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(length)
+	}
+	return nil
+}
+
+// LLen returns the length of the list stored at key
+func LLen(ctx *CmdContext) error {
+	length, err := ctx.CodecCtx.DB.Storage.LLen(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0])
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(length)
+	}
+	return nil
+}
+
+// LRem removes the first count occurrences of elements equal to value from the list stored at key
+func LRem(ctx *CmdContext) error {
+	count, err := strconv.ParseInt(util.BytesToString(ctx.Args[1]), 10, 64)
+	if err != nil {
+		ctx.OutContent = resp.ResponseIntegerErr
+		return nil
+	}
+
+	removed, err := ctx.CodecCtx.DB.Storage.LRem(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], count, ctx.Args[2])
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(removed)
 	}
 	return nil
 }
@@ -0,0 +1,157 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/oceanbase/modis/connection/conncontext"
+	"github.com/oceanbase/modis/protocol/resp"
+)
+
+// Multi marks the start of a transaction block, the following commands are queued until EXEC
+func Multi(ctx *CmdContext) error {
+	if ctx.CodecCtx.Flag&conncontext.ClientMulti != 0 {
+		ctx.OutContent = resp.EncError("ERR MULTI calls can not be nested")
+		return nil
+	}
+	ctx.CodecCtx.Flag |= conncontext.ClientMulti
+	ctx.OutContent = resp.ResponsesOk
+	return nil
+}
+
+// queueCommand queues the command to run on EXEC
+func queueCommand(ctx *CmdContext) {
+	// the request buffers are reused once the reply is sent
+	args := make([][]byte, len(ctx.Args))
+	for i, arg := range ctx.Args {
+		args[i] = append([]byte(nil), arg...)
+	}
+	ctx.CodecCtx.TxQueue = append(ctx.CodecCtx.TxQueue, conncontext.QueuedCmd{
+		Name:     ctx.Name,
+		FullName: ctx.FullName,
+		Args:     args,
+		PlainReq: append([]byte(nil), ctx.PlainReq...),
+	})
+	ctx.OutContent = resp.ResponsesQueued
+}
+
+// Exec executes all commands queued since MULTI, nothing is executed and a null array is replied
+// if any watched key has been modified. The transaction lock is held exclusively by EXEC, so that
+// the commands of other clients never interleave with the transaction. It is still not atomic
+// against failures of the storage, the commands executed before a failure are not rolled back.
+// The writes from other modis are found by the versions of the watched keys, and such writes may
+// still interleave with the queued commands
+func Exec(ctx *CmdContext) error {
+	cc := ctx.CodecCtx
+	if cc.Flag&conncontext.ClientMulti == 0 {
+		ctx.OutContent = resp.EncError("ERR EXEC without MULTI")
+		return nil
+	}
+	defer discardTransaction(ctx)
+	if cc.Flag&conncontext.ClientDirtyExec != 0 {
+		ctx.OutContent = resp.EncError("EXECABORT Transaction discarded because of previous errors.")
+		return nil
+	}
+
+	if cc.WatchDirty.Load() {
+		ctx.OutContent = resp.ResponsesNullArray
+		return nil
+	}
+	changed, err := ctx.ServCtx.Watched.Changed(cc, func(db int64, key []byte) ([]byte, error) {
+		return watchVersion(ctx, db, key)
+	})
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+		return nil
+	}
+	if changed {
+		ctx.OutContent = resp.ResponsesNullArray
+		return nil
+	}
+	var out strings.Builder
+	out.WriteString(resp.ArrayFlag + strconv.Itoa(len(cc.TxQueue)) + resp.CRLF)
+	for _, queued := range cc.TxQueue {
+		subCtx := NewCmdContext(queued.Name, queued.Args, ctx.TraceID, queued.PlainReq, cc, ctx.ServCtx)
+		subCtx.FullName = queued.FullName
+		execCommand(subCtx, commands[queued.FullName])
+		out.WriteString(subCtx.OutContent)
+	}
+	ctx.OutContent = out.String()
+	return nil
+}
+
+// Discard flushes all commands queued since MULTI
+func Discard(ctx *CmdContext) error {
+	if ctx.CodecCtx.Flag&conncontext.ClientMulti == 0 {
+		ctx.OutContent = resp.EncError("ERR DISCARD without MULTI")
+		return nil
+	}
+	discardTransaction(ctx)
+	ctx.OutContent = resp.ResponsesOk
+	return nil
+}
+
+// discardTransaction leaves the MULTI context and unwatches all keys
+func discardTransaction(ctx *CmdContext) {
+	ctx.CodecCtx.TxQueue = nil
+	ctx.CodecCtx.Flag &^= conncontext.ClientMulti | conncontext.ClientDirtyExec
+	ctx.ServCtx.Watched.Unwatch(ctx.CodecCtx)
+}
+
+// Watch marks the keys to be watched for conditional execution of a transaction
+func Watch(ctx *CmdContext) error {
+	if ctx.CodecCtx.Flag&conncontext.ClientMulti != 0 {
+		ctx.OutContent = resp.EncError("ERR WATCH inside MULTI is not allowed")
+		return nil
+	}
+	for _, key := range ctx.Args {
+		version, err := watchVersion(ctx, ctx.CodecCtx.DB.ID, key)
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+		ctx.ServCtx.Watched.Watch(ctx.CodecCtx, ctx.CodecCtx.DB.ID, key, version)
+	}
+	ctx.OutContent = resp.ResponsesOk
+	return nil
+}
+
+// Unwatch flushes all the previously watched keys for a transaction
+func Unwatch(ctx *CmdContext) error {
+	ctx.ServCtx.Watched.Unwatch(ctx.CodecCtx)
+	ctx.OutContent = resp.ResponsesOk
+	return nil
+}
+
+// watchVersion returns the version of key of db along with its type, the version is changed by every write
+// through any modis, see touchWatched, and the type is changed when the key expires
+func watchVersion(ctx *CmdContext, db int64, key []byte) ([]byte, error) {
+	s := ctx.CodecCtx.DB.Storage
+	c := ctx.CodecCtx.DB.Ctx
+
+	version, err := s.KeyVersion(c, db, key)
+	if err != nil {
+		return nil, err
+	}
+	typ, err := s.Type(c, db, key)
+	if err != nil {
+		return nil, err
+	}
+	return append(version, typ...), nil
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/oceanbase/modis/connection/conncontext"
+	"github.com/oceanbase/modis/protocol/resp"
+)
+
+// encSubscription encodes the reply of a (un)subscription, a nil name is encoded as a null bulk string
+func encSubscription(kind string, name []byte, count int) string {
+	var out strings.Builder
+	out.WriteString(resp.ArrayFlag + "3" + resp.CRLF)
+	out.WriteString(resp.EncBulkString(kind))
+	if name == nil {
+		out.WriteString(resp.ResponsesNullBulkString)
+	} else {
+		out.WriteString(resp.EncBulkString(string(name)))
+	}
+	out.WriteString(resp.EncInteger(int64(count)))
+	return out.String()
+}
+
+// Subscribe subscribes the client to the channels, the client enters the subscriber mode
+func Subscribe(ctx *CmdContext) error {
+	var out strings.Builder
+	for _, channel := range ctx.Args {
+		count := ctx.ServCtx.PubSub.Subscribe(ctx.CodecCtx, channel)
+		out.WriteString(encSubscription("subscribe", channel, count))
+	}
+	ctx.OutContent = out.String()
+	return nil
+}
+
+// Unsubscribe unsubscribes the client from the channels, or from all channels if none is given
+func Unsubscribe(ctx *CmdContext) error {
+	ps := ctx.ServCtx.PubSub
+	channels := ctx.Args
+	if len(channels) == 0 {
+		channels = ps.SubscribedChannels(ctx.CodecCtx)
+		if len(channels) == 0 {
+			_, patterns := ps.NumSubscriptions(ctx.CodecCtx)
+			ctx.OutContent = encSubscription("unsubscribe", nil, patterns)
+			return nil
+		}
+	}
+	var out strings.Builder
+	for _, channel := range channels {
+		count := ps.Unsubscribe(ctx.CodecCtx, channel)
+		out.WriteString(encSubscription("unsubscribe", channel, count))
+	}
+	ctx.OutContent = out.String()
+	return nil
+}
+
+// PSubscribe subscribes the client to the channels matching the patterns
+func PSubscribe(ctx *CmdContext) error {
+	var out strings.Builder
+	for _, pattern := range ctx.Args {
+		count := ctx.ServCtx.PubSub.PSubscribe(ctx.CodecCtx, pattern)
+		out.WriteString(encSubscription("psubscribe", pattern, count))
+	}
+	ctx.OutContent = out.String()
+	return nil
+}
+
+// PUnsubscribe unsubscribes the client from the patterns, or from all patterns if none is given
+func PUnsubscribe(ctx *CmdContext) error {
+	ps := ctx.ServCtx.PubSub
+	patterns := ctx.Args
+	if len(patterns) == 0 {
+		patterns = ps.SubscribedPatterns(ctx.CodecCtx)
+		if len(patterns) == 0 {
+			channels, _ := ps.NumSubscriptions(ctx.CodecCtx)
+			ctx.OutContent = encSubscription("punsubscribe", nil, channels)
+			return nil
+		}
+	}
+	var out strings.Builder
+	for _, pattern := range patterns {
+		count := ps.PUnsubscribe(ctx.CodecCtx, pattern)
+		out.WriteString(encSubscription("punsubscribe", pattern, count))
+	}
+	ctx.OutContent = out.String()
+	return nil
+}
+
+// Publish posts a message to the channel, returns the number of clients that received it
+func Publish(ctx *CmdContext) error {
+	receivers := ctx.ServCtx.PubSub.Publish(ctx.Args[0], ctx.Args[1])
+	ctx.OutContent = resp.EncInteger(receivers)
+	return nil
+}
+
+// PubSubChannels lists the active channels, optionally the ones matching a pattern
+func PubSubChannels(ctx *CmdContext) error {
+	var pattern []byte
+	switch len(ctx.Args) {
+	case 1:
+	case 2:
+		pattern = ctx.Args[1]
+	default:
+		ctx.OutContent = resp.ErrWrongArgs(ctx.FullName)
+		return nil
+	}
+	ctx.OutContent = resp.EncArray(ctx.ServCtx.PubSub.Channels(pattern))
+	return nil
+}
+
+// PubSubNumSub returns the number of subscribers of the channels
+func PubSubNumSub(ctx *CmdContext) error {
+	channels := ctx.Args[1:]
+	var out strings.Builder
+	out.WriteString(resp.ArrayFlag + strconv.Itoa(2*len(channels)) + resp.CRLF)
+	for _, channel := range channels {
+		out.WriteString(resp.EncBulkString(string(channel)))
+		out.WriteString(resp.EncInteger(ctx.ServCtx.PubSub.NumSub(channel)))
+	}
+	ctx.OutContent = out.String()
+	return nil
+}
+
+// PubSubNumPat returns the number of unique patterns subscribed by all clients
+func PubSubNumPat(ctx *CmdContext) error {
+	ctx.OutContent = resp.EncInteger(ctx.ServCtx.PubSub.NumPat())
+	return nil
+}
+
+// pubSubPing replies the PING of a client in the subscriber mode
+func pubSubPing(ctx *CmdContext) {
+	msg := []byte{}
+	if len(ctx.Args) > 0 {
+		msg = ctx.Args[0]
+	}
+	ctx.OutContent = resp.EncArray([][]byte{[]byte("pong"), msg})
+}
+
+// isPubSubClient reports whether the client is in the subscriber mode
+func isPubSubClient(ctx *CmdContext) bool {
+	return ctx.CodecCtx.Flag&conncontext.ClientPubSub != 0
+}
@@ -0,0 +1,358 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/oceanbase/modis/util"
+)
+
+const (
+	defaultScanCount = 10
+	keysScanCount    = 1000
+
+	// scanCursorTTL is how long the position of an iteration is kept after its cursor is returned
+	scanCursorTTL = time.Hour
+)
+
+// scanTypes is the order in which SCAN iterates the data types
+var scanTypes = []string{"string", "hash", "list", "zset", "set", "stream"}
+
+// scanCursor is the position where an iteration stopped, after is the last key, field or member returned.
+// key is the collection iterated, nil for SCAN
+type scanCursor struct {
+	key     []byte
+	typeIdx int
+	after   []byte
+}
+
+// encode encodes the cursor as the type index, the length of key plus one (0 for nil) and key, followed by after
+func (c *scanCursor) encode() []byte {
+	b := make([]byte, 0, 1+binary.MaxVarintLen64+len(c.key)+len(c.after))
+	b = append(b, byte(c.typeIdx))
+	if c.key == nil {
+		b = binary.AppendUvarint(b, 0)
+	} else {
+		b = binary.AppendUvarint(b, uint64(len(c.key))+1)
+		b = append(b, c.key...)
+	}
+	return append(b, c.after...)
+}
+
+// decodeScanCursor decodes a cursor encoded by scanCursor.encode
+func decodeScanCursor(b []byte) (*scanCursor, bool) {
+	if len(b) == 0 {
+		return nil, false
+	}
+	c := &scanCursor{typeIdx: int(b[0])}
+	n, size := binary.Uvarint(b[1:])
+	if size <= 0 || n > uint64(len(b)-1-size) {
+		return nil, false
+	}
+	b = b[1+size:]
+	if n > 0 {
+		c.key, b = b[:n-1], b[n-1:]
+	}
+	c.after = b
+	return c, true
+}
+
+// parseCursorID parses the cursor passed by the client, which is a 64-bit integer like redis
+func parseCursorID(arg []byte) (uint64, bool) {
+	id, err := strconv.ParseUint(util.BytesToString(arg), 10, 64)
+	return id, err == nil
+}
+
+// loadScanCursor returns the position of the cursor id returned by an iteration of key in the current db,
+// nil if the cursor not exists, is expired or is returned by the iteration of another key
+func loadScanCursor(ctx *CmdContext, id uint64, key []byte) (*scanCursor, error) {
+	pos, err := ctx.CodecCtx.DB.Storage.LoadCursor(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, id)
+	if err != nil || pos == nil {
+		return nil, err
+	}
+	c, ok := decodeScanCursor(pos)
+	if !ok || (c.key == nil) != (key == nil) || !bytes.Equal(c.key, key) {
+		return nil, nil
+	}
+	return c, nil
+}
+
+// saveScanCursor saves c under a new random id, which is returned to the client as the next cursor.
+// The positions are kept by the storage, so that an iteration goes on through any modis node and
+// across modis restarts
+func saveScanCursor(ctx *CmdContext, c *scanCursor) (uint64, error) {
+	id := rand.Uint64()
+	for id == 0 {
+		id = rand.Uint64()
+	}
+	err := ctx.CodecCtx.DB.Storage.SaveCursor(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, id, c.encode(), scanCursorTTL)
+	return id, err
+}
+
+// scanArgs is the options of the SCAN family
+type scanArgs struct {
+	pattern  []byte
+	count    int64
+	typ      []byte
+	noValues bool
+}
+
+// parseScanArgs parses the options following the cursor, typ and noValues are allowed only if
+// allowType and allowNoValues are set, a non empty error reply is returned on failure
+func parseScanArgs(args [][]byte, allowType bool, allowNoValues bool) (*scanArgs, string) {
+	var err error
+	sa := &scanArgs{count: defaultScanCount}
+	for i := 0; i < len(args); i++ {
+		opt := strings.ToLower(util.BytesToString(args[i]))
+		if opt == "novalues" && allowNoValues {
+			sa.noValues = true
+			continue
+		}
+		if i+1 >= len(args) {
+			return nil, resp.ResponseSyntaxErr
+		}
+		i++
+		switch {
+		case opt == "match":
+			sa.pattern = args[i]
+		case opt == "count":
+			sa.count, err = strconv.ParseInt(util.BytesToString(args[i]), 10, 64)
+			if err != nil {
+				return nil, resp.ResponseIntegerErr
+			}
+			if sa.count < 1 {
+				return nil, resp.ResponseSyntaxErr
+			}
+		case opt == "type" && allowType:
+			sa.typ = args[i]
+		default:
+			return nil, resp.ResponseSyntaxErr
+		}
+	}
+	return sa, ""
+}
+
+// encScanReply encodes the reply of the SCAN family: the next cursor and the elements
+func encScanReply(cursor uint64, elems [][]byte) string {
+	return "*2\r\n" + resp.EncBulkString(strconv.FormatUint(cursor, 10)) + resp.EncArray(elems)
+}
+
+// Scan iterates the keys of the current db
+func Scan(ctx *CmdContext) error {
+	id, ok := parseCursorID(ctx.Args[0])
+	if !ok {
+		ctx.OutContent = resp.EncError("ERR invalid cursor")
+		return nil
+	}
+	sa, errReply := parseScanArgs(ctx.Args[1:], true, false)
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+
+	cursor := &scanCursor{}
+	if id != 0 {
+		c, err := loadScanCursor(ctx, id, nil)
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+		if c == nil || c.typeIdx >= len(scanTypes) {
+			ctx.OutContent = resp.EncError("ERR invalid cursor")
+			return nil
+		}
+		cursor = c
+	} else if sa.typ != nil {
+		cursor.typeIdx = len(scanTypes)
+		for i, typ := range scanTypes {
+			if strings.EqualFold(typ, util.BytesToString(sa.typ)) {
+				cursor.typeIdx = i
+			}
+		}
+	}
+
+	// keys not matching the pattern are counted as well, like redis
+	keys := make([][]byte, 0, sa.count)
+	for scanned := int64(0); scanned < sa.count && cursor.typeIdx < len(scanTypes); {
+		res, err := ctx.CodecCtx.DB.Storage.Scan(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID,
+			scanTypes[cursor.typeIdx], cursor.after, sa.count-scanned)
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+		if len(res) == 0 {
+			cursor.after = nil
+			cursor.typeIdx++
+			if sa.typ != nil {
+				cursor.typeIdx = len(scanTypes)
+			}
+			continue
+		}
+		scanned += int64(len(res))
+		cursor.after = res[len(res)-1]
+		for _, key := range res {
+			if sa.pattern == nil || util.GlobMatch(sa.pattern, key, false) {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	var next uint64
+	if cursor.typeIdx < len(scanTypes) {
+		var err error
+		if next, err = saveScanCursor(ctx, cursor); err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+	}
+	ctx.OutContent = encScanReply(next, keys)
+	return nil
+}
+
+// Keys returns all keys matching pattern
+func Keys(ctx *CmdContext) error {
+	pattern := ctx.Args[0]
+	keys := make([][]byte, 0)
+	for _, typ := range scanTypes {
+		var after []byte
+		for {
+			res, err := ctx.CodecCtx.DB.Storage.Scan(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, typ, after, keysScanCount)
+			if err != nil {
+				ctx.OutContent = encStorageError(err)
+				return nil
+			}
+			if len(res) == 0 {
+				break
+			}
+			after = res[len(res)-1]
+			for _, key := range res {
+				if util.GlobMatch(pattern, key, false) {
+					keys = append(keys, key)
+				}
+			}
+		}
+	}
+	ctx.OutContent = resp.EncArray(keys)
+	return nil
+}
+
+// scanCollection implements HSCAN, SSCAN and ZSCAN, the position of a cursor is the last field or member.
+// scan returns the fields or members of the page along with their values, values is nil if the collection has no values
+func scanCollection(ctx *CmdContext, allowNoValues bool,
+	scan func(after []byte, count int64) (members [][]byte, values [][]byte, err error)) error {
+	key := ctx.Args[0]
+	id, ok := parseCursorID(ctx.Args[1])
+	if !ok {
+		ctx.OutContent = resp.EncError("ERR invalid cursor")
+		return nil
+	}
+	sa, errReply := parseScanArgs(ctx.Args[2:], false, allowNoValues)
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+	cursor := &scanCursor{key: key}
+	if id != 0 {
+		c, err := loadScanCursor(ctx, id, key)
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+		if c == nil {
+			ctx.OutContent = resp.EncError("ERR invalid cursor")
+			return nil
+		}
+		cursor = c
+	}
+
+	members, values, err := scan(cursor.after, sa.count)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+		return nil
+	}
+	elems := make([][]byte, 0, 2*len(members))
+	for i, member := range members {
+		if sa.pattern != nil && !util.GlobMatch(sa.pattern, member, false) {
+			continue
+		}
+		elems = append(elems, member)
+		if values != nil && !sa.noValues {
+			elems = append(elems, values[i])
+		}
+	}
+
+	// a page shorter than count is the last one
+	var next uint64
+	if int64(len(members)) == sa.count {
+		cursor.after = members[len(members)-1]
+		if next, err = saveScanCursor(ctx, cursor); err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+	}
+	ctx.OutContent = encScanReply(next, elems)
+	return nil
+}
+
+// HScan iterates the fields of a hash along with their values
+func HScan(ctx *CmdContext) error {
+	return scanCollection(ctx, true, func(after []byte, count int64) ([][]byte, [][]byte, error) {
+		res, err := ctx.CodecCtx.DB.Storage.HScan(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], after, count)
+		if err != nil {
+			return nil, nil, err
+		}
+		fields := make([][]byte, 0, len(res)/2)
+		values := make([][]byte, 0, len(res)/2)
+		for i := 0; i+1 < len(res); i += 2 {
+			fields = append(fields, res[i])
+			values = append(values, res[i+1])
+		}
+		return fields, values, nil
+	})
+}
+
+// SScan iterates the members of a set
+func SScan(ctx *CmdContext) error {
+	return scanCollection(ctx, false, func(after []byte, count int64) ([][]byte, [][]byte, error) {
+		members, err := ctx.CodecCtx.DB.Storage.SScan(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], after, count)
+		return members, nil, err
+	})
+}
+
+// ZScan iterates the members of a sorted set along with their scores
+func ZScan(ctx *CmdContext) error {
+	return scanCollection(ctx, false, func(after []byte, count int64) ([][]byte, [][]byte, error) {
+		res, err := ctx.CodecCtx.DB.Storage.ZScan(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], after, count)
+		if err != nil {
+			return nil, nil, err
+		}
+		members := make([][]byte, 0, len(res))
+		scores := make([][]byte, 0, len(res))
+		for _, m := range res {
+			members = append(members, m.Member)
+			scores = append(scores, []byte(formatScore(m.Score)))
+		}
+		return members, scores, nil
+	})
+}
@@ -0,0 +1,374 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+
+	"github.com/oceanbase/modis/log"
+	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/oceanbase/modis/util"
+)
+
+const (
+	luaLogDebug = iota
+	luaLogVerbose
+	luaLogNotice
+	luaLogWarning
+)
+
+// sha1Hex returns the SHA1 digest of s in lowercase hex, the name of a script in the cache
+func sha1Hex(s []byte) string {
+	sum := sha1.Sum(s)
+	return hex.EncodeToString(sum[:])
+}
+
+// compileScript compiles the body of a script
+func compileScript(body []byte) (*lua.FunctionProto, error) {
+	chunk, err := parse.Parse(strings.NewReader(string(body)), "user_script")
+	if err != nil {
+		return nil, err
+	}
+	return lua.Compile(chunk, "user_script")
+}
+
+// Eval executes a script, the script is cached for EVALSHA
+func Eval(ctx *CmdContext) error {
+	body := ctx.Args[0]
+	sha := sha1Hex(body)
+	proto := ctx.ServCtx.Scripts.Get(sha)
+	if proto == nil {
+		var err error
+		if proto, err = compileScript(body); err != nil {
+			ctx.OutContent = resp.EncError("ERR Error compiling script (new function): " + err.Error())
+			return nil
+		}
+		ctx.ServCtx.Scripts.Set(sha, proto)
+	}
+	evalScript(ctx, sha, proto)
+	return nil
+}
+
+// EvalSha executes a script cached by EVAL or SCRIPT LOAD
+func EvalSha(ctx *CmdContext) error {
+	sha := strings.ToLower(util.BytesToString(ctx.Args[0]))
+	proto := ctx.ServCtx.Scripts.Get(sha)
+	if proto == nil {
+		ctx.OutContent = resp.EncError("NOSCRIPT No matching script. Please use EVAL.")
+		return nil
+	}
+	evalScript(ctx, sha, proto)
+	return nil
+}
+
+// evalScript runs the script with the keys and args of EVAL and EVALSHA, the script is
+// stopped once it runs longer than the lua time limit
+func evalScript(ctx *CmdContext, sha string, proto *lua.FunctionProto) {
+	numKeys, err := strconv.Atoi(util.BytesToString(ctx.Args[1]))
+	if err != nil {
+		ctx.OutContent = resp.ResponseIntegerErr
+		return
+	}
+	if numKeys < 0 {
+		ctx.OutContent = resp.EncError("ERR Number of keys can't be negative")
+		return
+	}
+	if numKeys > len(ctx.Args)-2 {
+		ctx.OutContent = resp.EncError("ERR Number of keys can't be greater than number of args")
+		return
+	}
+
+	L := newLuaState(ctx)
+	defer L.Close()
+	L.SetGlobal("KEYS", luaStringArray(L, ctx.Args[2:2+numKeys]))
+	L.SetGlobal("ARGV", luaStringArray(L, ctx.Args[2+numKeys:]))
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), ctx.ServCtx.LuaTimeLimit)
+	defer cancel()
+	L.SetContext(timeoutCtx)
+
+	// SELECT in the script does not change the db of the client
+	db := ctx.CodecCtx.DB
+	defer func() { ctx.CodecCtx.DB = db }()
+
+	L.Push(L.NewFunctionFromProto(proto))
+	if err = L.PCall(0, 1, nil); err != nil {
+		if errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+			log.Warn("command", ctx.TraceID, "script timed out", log.String("sha", sha))
+			ctx.OutContent = resp.EncError("ERR Error running script (call to f_" + sha + "): script timed out after " +
+				ctx.ServCtx.LuaTimeLimit.String())
+			return
+		}
+		var apiErr *lua.ApiError
+		if errors.As(err, &apiErr) {
+			// errors raised by redis.call are replied as is
+			if tbl, ok := apiErr.Object.(*lua.LTable); ok {
+				if msg, ok := tbl.RawGetString("err").(lua.LString); ok {
+					ctx.OutContent = resp.EncError(string(msg))
+					return
+				}
+			}
+			err = errors.New(apiErr.Object.String())
+		}
+		ctx.OutContent = resp.EncError("ERR Error running script (call to f_" + sha + "): " + err.Error())
+		return
+	}
+	ctx.OutContent = luaToResp(L.Get(-1))
+}
+
+// newLuaState creates a lua state with the libraries available to scripts and the redis module
+func newLuaState(ctx *CmdContext) *lua.LState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	// scripts have no access to files
+	for _, name := range []string{"dofile", "loadfile"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+
+	redisMod := L.NewTable()
+	L.SetFuncs(redisMod, map[string]lua.LGFunction{
+		"call": func(L *lua.LState) int {
+			return luaRedisCall(ctx, L, true)
+		},
+		"pcall": func(L *lua.LState) int {
+			return luaRedisCall(ctx, L, false)
+		},
+		"sha1hex": func(L *lua.LState) int {
+			L.Push(lua.LString(sha1Hex([]byte(L.CheckString(1)))))
+			return 1
+		},
+		"error_reply": func(L *lua.LState) int {
+			L.Push(luaReplyTable(L, "err", L.CheckString(1)))
+			return 1
+		},
+		"status_reply": func(L *lua.LState) int {
+			L.Push(luaReplyTable(L, "ok", L.CheckString(1)))
+			return 1
+		},
+		"log": func(L *lua.LState) int {
+			level := L.CheckInt(1)
+			msgs := make([]string, 0, L.GetTop()-1)
+			for i := 2; i <= L.GetTop(); i++ {
+				msgs = append(msgs, L.ToStringMeta(L.Get(i)).String())
+			}
+			msg := strings.Join(msgs, " ")
+			if level >= luaLogWarning {
+				log.Warn("script", ctx.TraceID, msg)
+			} else {
+				log.Info("script", ctx.TraceID, msg)
+			}
+			return 0
+		},
+	})
+	redisMod.RawSetString("LOG_DEBUG", lua.LNumber(luaLogDebug))
+	redisMod.RawSetString("LOG_VERBOSE", lua.LNumber(luaLogVerbose))
+	redisMod.RawSetString("LOG_NOTICE", lua.LNumber(luaLogNotice))
+	redisMod.RawSetString("LOG_WARNING", lua.LNumber(luaLogWarning))
+	L.SetGlobal("redis", redisMod)
+	return L
+}
+
+// luaRedisCall runs a command from redis.call and redis.pcall, an error reply is raised
+// as a lua error by redis.call and returned as a table with the err field by redis.pcall
+func luaRedisCall(ctx *CmdContext, L *lua.LState, raise bool) int {
+	fail := func(msg string) int {
+		errTable := luaReplyTable(L, "err", msg)
+		if raise {
+			L.Error(errTable, 1)
+			return 0
+		}
+		L.Push(errTable)
+		return 1
+	}
+
+	argc := L.GetTop()
+	if argc == 0 {
+		return fail("ERR Please specify at least one argument for this redis lib call")
+	}
+	argv := make([][]byte, 0, argc)
+	for i := 1; i <= argc; i++ {
+		switch arg := L.Get(i).(type) {
+		case lua.LString:
+			argv = append(argv, []byte(arg))
+		case lua.LNumber:
+			argv = append(argv, []byte(formatLuaNumber(arg)))
+		default:
+			return fail("ERR Lua redis lib command arguments must be strings or integers")
+		}
+	}
+
+	subCtx := NewCmdContext(string(argv[0]), argv[1:], ctx.TraceID, []byte(resp.EncArray(argv)), ctx.CodecCtx, ctx.ServCtx)
+	cmdInfo, errReply := lookupCommand(subCtx)
+	if errReply != "" {
+		return fail("ERR Unknown Redis command called from script")
+	}
+	if cmdInfo.Flag&CmdNoScript != 0 {
+		return fail("ERR This Redis command is not allowed from script")
+	}
+	// the script holds the transaction lock already
+	execCommand(subCtx, cmdInfo)
+
+	reply, err := resp.DecReply(subCtx.OutContent)
+	var errReplyVal resp.ErrorReply
+	if errors.As(err, &errReplyVal) {
+		return fail(errReplyVal.Error())
+	} else if err != nil {
+		return fail("ERR " + err.Error())
+	}
+	L.Push(respToLua(L, reply))
+	return 1
+}
+
+// luaStringArray converts strings to a lua array
+func luaStringArray(L *lua.LState, elems [][]byte) *lua.LTable {
+	tbl := L.CreateTable(len(elems), 0)
+	for _, elem := range elems {
+		tbl.Append(lua.LString(elem))
+	}
+	return tbl
+}
+
+// luaReplyTable returns a table with a single field, e.g. {err = "ERR ..."} for an error reply
+func luaReplyTable(L *lua.LState, field string, msg string) *lua.LTable {
+	tbl := L.CreateTable(0, 1)
+	tbl.RawSetString(field, lua.LString(msg))
+	return tbl
+}
+
+// formatLuaNumber formats a number passed to redis.call like redis does
+func formatLuaNumber(n lua.LNumber) string {
+	f := float64(n)
+	if f == math.Trunc(f) && math.Abs(f) < 1e17 {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return strconv.FormatFloat(f, 'g', 17, 64)
+}
+
+// respToLua converts a decoded reply of a command to a lua value: integers to numbers,
+// bulk strings to strings, arrays to tables, nil to false, status replies to tables
+// with the ok field and error replies to tables with the err field
+func respToLua(L *lua.LState, reply interface{}) lua.LValue {
+	switch val := reply.(type) {
+	case nil:
+		return lua.LFalse
+	case int64:
+		return lua.LNumber(val)
+	case []byte:
+		return lua.LString(val)
+	case string:
+		return luaReplyTable(L, "ok", val)
+	case resp.ErrorReply:
+		return luaReplyTable(L, "err", string(val))
+	case []interface{}:
+		tbl := L.CreateTable(len(val), 0)
+		for _, elem := range val {
+			tbl.Append(respToLua(L, elem))
+		}
+		return tbl
+	}
+	return lua.LFalse
+}
+
+// luaToResp encodes the value returned by a script: numbers are truncated to integers,
+// true is 1 while false and nil are null, a table with the ok or err field is a status
+// or error reply, other tables are arrays up to the first nil
+func luaToResp(lv lua.LValue) string {
+	switch val := lv.(type) {
+	case lua.LNumber:
+		return resp.EncInteger(int64(val))
+	case lua.LString:
+		return resp.EncBulkString(string(val))
+	case lua.LBool:
+		if val {
+			return resp.EncInteger(1)
+		}
+		return resp.ResponsesNullBulkString
+	case *lua.LTable:
+		if msg, ok := val.RawGetString("err").(lua.LString); ok {
+			return resp.EncError(string(msg))
+		}
+		if msg, ok := val.RawGetString("ok").(lua.LString); ok {
+			return resp.EncSimpleString(string(msg))
+		}
+		var sb strings.Builder
+		n := 0
+		for ; val.RawGetInt(n+1) != lua.LNil; n++ {
+			sb.WriteString(luaToResp(val.RawGetInt(n + 1)))
+		}
+		return resp.ArrayFlag + strconv.Itoa(n) + resp.CRLF + sb.String()
+	}
+	return resp.ResponsesNullBulkString
+}
+
+// ScriptLoad loads a script into the script cache without executing it
+func ScriptLoad(ctx *CmdContext) error {
+	body := ctx.Args[1]
+	proto, err := compileScript(body)
+	if err != nil {
+		ctx.OutContent = resp.EncError("ERR Error compiling script (new function): " + err.Error())
+		return nil
+	}
+	sha := sha1Hex(body)
+	ctx.ServCtx.Scripts.Set(sha, proto)
+	ctx.OutContent = resp.EncBulkString(sha)
+	return nil
+}
+
+// ScriptExists returns whether each of the scripts exists in the script cache
+func ScriptExists(ctx *CmdContext) error {
+	shas := ctx.Args[1:]
+	var sb strings.Builder
+	sb.WriteString(resp.ArrayFlag + strconv.Itoa(len(shas)) + resp.CRLF)
+	for _, sha := range shas {
+		exists := ctx.ServCtx.Scripts.Get(strings.ToLower(util.BytesToString(sha))) != nil
+		sb.WriteString(encBool(exists))
+	}
+	ctx.OutContent = sb.String()
+	return nil
+}
+
+// ScriptFlush flushes the script cache, ASYNC makes no difference since it is done at once
+func ScriptFlush(ctx *CmdContext) error {
+	if _, ok := parseFlushMode(ctx.Args[1:]); !ok {
+		ctx.OutContent = resp.EncError("ERR SCRIPT FLUSH only support SYNC|ASYNC option")
+		return nil
+	}
+	ctx.ServCtx.Scripts.Flush()
+	ctx.OutContent = resp.ResponsesOk
+	return nil
+}
@@ -19,6 +19,7 @@ package command
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
@@ -166,11 +167,13 @@ func Info(ctx *CmdContext) error {
 					"connected_clients:%d\r\n"+
 					"maxclients:%d\r\n"+
 					"client_recent_max_input_buffer:%d\r\n"+
-					"client_recent_max_output_buffer:%d\r\n",
+					"client_recent_max_output_buffer:%d\r\n"+
+					"blocked_clients:%d\r\n",
 				ctx.ServCtx.ClientNum.Load(),
 				ctx.ServCtx.MaxClientNum,
 				ctx.ServCtx.ClientsPeakMemInput,
 				ctx.ServCtx.ClientsPeakMemOutput,
+				ctx.ServCtx.Blocked.Len(),
 			))
 		case "persistence":
 			if idx++; idx > 0 {
@@ -180,8 +183,12 @@ func Info(ctx *CmdContext) error {
 			}
 			_, err = infoBuilder.WriteString(fmt.Sprintf(
 				"# Persistence\r\n"+
-					"backend:%s\r\n",
+					"backend:%s\r\n"+
+					"async_flush_in_progress:%d\r\n"+
+					"async_flush_last_status:%s\r\n",
 				ctx.ServCtx.Backend,
+				ctx.ServCtx.AsyncFlushes.Load(),
+				asyncFlushStatus(ctx.ServCtx),
 			))
 		case "stats":
 			if idx++; idx > 0 {
@@ -198,7 +205,8 @@ func Info(ctx *CmdContext) error {
 					"total_net_output_bytes:%d\r\n"+
 					"instantaneous_input_kbps:%.2f\r\n"+
 					"instantaneous_output_kbps:%.2f\r\n"+
-					"rejected_connections:%d\r\n",
+					"rejected_connections:%d\r\n"+
+					"pubsub_bus_dropped_messages:%d\r\n",
 				ctx.ServCtx.TotalClientNum,
 				ctx.ServCtx.TotalCmdNum.GetSample(),
 				ctx.ServCtx.TotalCmdNum.GetAvg(),
@@ -207,6 +215,7 @@ func Info(ctx *CmdContext) error {
 				ctx.ServCtx.TotalReadBytes.GetAvg(),
 				ctx.ServCtx.TotalWriteBytes.GetAvg(),
 				ctx.ServCtx.RejectClientNum,
+				ctx.ServCtx.BusDroppedMessages.Load(),
 			))
 		case "cpu":
 			if idx++; idx > 0 {
@@ -268,23 +277,22 @@ func Info(ctx *CmdContext) error {
 				"# Cluster\r\n" +
 					"cluster_enabled:0\r\n", // no cluster currenctly
 			)
-			// TODO: keyspace
-			// case "keyspace":
-			// 	if idx++; idx > 0 {
-			// 		if _, err = infoBuilder.WriteString("\r\n"); err != nil {
-			// 			break
-			// 		}
-			// 	}
-			// 	_, err = infoBuilder.WriteString("# Keyspace\r\n")
-			// 	if err != nil {
-			// 		log.Warn("command", ctx.TraceID, "fail to write string to infoBuilder", log.Errors(err))
-			// 		break
-			// 	}
-			// 	err = formatDBInfo(ctx, &infoBuilder)
-			// 	if err != nil {
-			// 		log.Warn("command", ctx.TraceID, "fail to format db info", log.Errors(err))
-			// 		break
-			// 	}
+		case "keyspace":
+			if idx++; idx > 0 {
+				if _, err = infoBuilder.WriteString("\r\n"); err != nil {
+					break
+				}
+			}
+			_, err = infoBuilder.WriteString("# Keyspace\r\n")
+			if err != nil {
+				log.Warn("command", ctx.TraceID, "fail to write string to infoBuilder", log.Errors(err))
+				break
+			}
+			err = formatDBInfo(ctx, &infoBuilder)
+			if err != nil {
+				log.Warn("command", ctx.TraceID, "fail to format db info", log.Errors(err))
+				break
+			}
 		}
 
 		if err != nil {
@@ -299,9 +307,158 @@ func Info(ctx *CmdContext) error {
 	return nil
 }
 
+// DBSize returns the number of keys in the currently-selected database
+func DBSize(ctx *CmdContext) error {
+	dbInfo, err := getDBInfo(ctx, ctx.CodecCtx.DB.ID)
+	if err != nil {
+		ctx.OutContent = resp.EncError("ERR " + err.Error())
+	} else {
+		ctx.OutContent = resp.EncInteger(dbInfo.Keys)
+	}
+	return nil
+}
+
+// FlushDB deletes all the keys of the currently selected db
+func FlushDB(ctx *CmdContext) error {
+	async, ok := parseFlushMode(ctx.Args)
+	if !ok {
+		ctx.OutContent = resp.ResponseSyntaxErr
+		return nil
+	}
+	return flush(ctx, []int64{ctx.CodecCtx.DB.ID}, async)
+}
+
+// FlushAll deletes all the keys of all the dbs
+func FlushAll(ctx *CmdContext) error {
+	async, ok := parseFlushMode(ctx.Args)
+	if !ok {
+		ctx.OutContent = resp.ResponseSyntaxErr
+		return nil
+	}
+	dbs := make([]int64, 0, ctx.ServCtx.DbNum)
+	for db := int64(0); db < ctx.ServCtx.DbNum; db++ {
+		dbs = append(dbs, db)
+	}
+	return flush(ctx, dbs, async)
+}
+
+// flush deletes the dbs, it returns at once and runs in the background if async is set
+func flush(ctx *CmdContext, dbs []int64, async bool) error {
+	stor, traceID := ctx.CodecCtx.DB.Storage, ctx.TraceID
+	for _, db := range dbs {
+		ctx.ServCtx.Watched.TouchDB(db)
+	}
+	run := func() error {
+		for _, db := range dbs {
+			if err := flushDB(stor, traceID, db); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if async {
+		servCtx := ctx.ServCtx
+		servCtx.AsyncFlushes.Add(1)
+		go func() {
+			defer servCtx.AsyncFlushes.Add(-1)
+			log.Info("command", traceID, "async flush started", log.Int("dbs", len(dbs)))
+			if err := run(); err != nil {
+				servCtx.AsyncFlushFailed.Store(true)
+				log.Warn("command", traceID, "async flush failed", log.Int("dbs", len(dbs)), log.Errors(err))
+				return
+			}
+			servCtx.AsyncFlushFailed.Store(false)
+			log.Info("command", traceID, "async flush ended", log.Int("dbs", len(dbs)))
+		}()
+		ctx.OutContent = resp.ResponsesOk
+		return nil
+	}
+	if err := run(); err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.ResponsesOk
+	}
+	return nil
+}
+
+// asyncFlushStatus returns the result of the last async flush in the form of rdb_last_bgsave_status
+func asyncFlushStatus(sc *conncontext.ServerContext) string {
+	if sc.AsyncFlushFailed.Load() {
+		return "err"
+	}
+	return "ok"
+}
+
 func Monitor(ctx *CmdContext) error {
 	ctx.ServCtx.Monitors.Set(ctx.CodecCtx.ID, ctx.CodecCtx)
 	ctx.CodecCtx.Flag |= conncontext.ClientMonitor
 	ctx.OutContent = resp.ResponsesOk
 	return nil
 }
+
+// configParam is a server parameter which can be read by CONFIG GET and changed by CONFIG SET
+type configParam struct {
+	get func(sc *conncontext.ServerContext) string
+	set func(sc *conncontext.ServerContext, value string) error
+}
+
+var configParams = map[string]configParam{
+	"notify-keyspace-events": {
+		get: func(sc *conncontext.ServerContext) string {
+			return sc.NotifyEvents().String()
+		},
+		set: func(sc *conncontext.ServerContext, value string) error {
+			classes, err := conncontext.ParseNotifyClasses(value)
+			if err != nil {
+				return err
+			}
+			return sc.SetNotifyEvents(classes)
+		},
+	},
+}
+
+// ConfigGet returns the parameters matching any of the glob-style patterns
+func ConfigGet(ctx *CmdContext) error {
+	names := make([]string, 0, len(configParams))
+	for name := range configParams {
+		for _, pattern := range ctx.Args[1:] {
+			if util.GlobMatch(pattern, []byte(name), true) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	out := make([][]byte, 0, 2*len(names))
+	for _, name := range names {
+		out = append(out, []byte(name), []byte(configParams[name].get(ctx.ServCtx)))
+	}
+	ctx.OutContent = resp.EncArray(out)
+	return nil
+}
+
+// ConfigSet changes the parameters at runtime, the configuration file is left untouched
+func ConfigSet(ctx *CmdContext) error {
+	args := ctx.Args[1:]
+	if len(args)%2 != 0 {
+		ctx.OutContent = resp.ErrWrongArgs(ctx.FullName)
+		return nil
+	}
+	// check all parameters before any is changed
+	for i := 0; i < len(args); i += 2 {
+		name := strings.ToLower(util.BytesToString(args[i]))
+		if _, ok := configParams[name]; !ok {
+			ctx.OutContent = resp.EncError("ERR Unknown option or number of arguments for CONFIG SET - '" + name + "'")
+			return nil
+		}
+	}
+	for i := 0; i < len(args); i += 2 {
+		name := strings.ToLower(util.BytesToString(args[i]))
+		if err := configParams[name].set(ctx.ServCtx, string(args[i+1])); err != nil {
+			ctx.OutContent = resp.EncError("ERR CONFIG SET failed (possibly related to argument '" + name + "') - " + err.Error())
+			return nil
+		}
+	}
+	ctx.OutContent = resp.ResponsesOk
+	return nil
+}
@@ -19,7 +19,6 @@ package command
 import (
 	"strconv"
 
-	"github.com/oceanbase/obkv-table-client-go/table"
 	"github.com/oceanbase/obkv-table-client-go/util"
 
 	"github.com/oceanbase/modis/protocol/resp"
@@ -35,7 +34,7 @@ func SMembers(ctx *CmdContext) error {
 
 	values, err := ctx.CodecCtx.DB.Storage.SMembers(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncArray(values)
 	}
@@ -65,7 +64,7 @@ func SRandMember(ctx *CmdContext) error {
 
 	members, err := ctx.CodecCtx.DB.Storage.SRandMember(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, count)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else if len(ctx.Args) == 1 {
 		// return bulk string
 		if len(members) == 0 {
@@ -85,7 +84,7 @@ func SCard(ctx *CmdContext) error {
 	key := ctx.Args[0]
 	size, err := ctx.CodecCtx.DB.Storage.SCard(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncInteger(size)
 	}
@@ -98,7 +97,7 @@ func SIsmember(ctx *CmdContext) error {
 	member := ctx.Args[1]
 	returnValue, err := ctx.CodecCtx.DB.Storage.SIsmember(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, member)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncInteger(int64(returnValue))
 	}
@@ -125,7 +124,7 @@ func SPop(ctx *CmdContext) error {
 
 	members, err := ctx.CodecCtx.DB.Storage.SPop(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, count)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else if len(ctx.Args) == 1 {
 		// return bulk string
 		if len(members) == 0 {
@@ -149,7 +148,7 @@ func SRem(ctx *CmdContext) error {
 	}
 	returnValue, err := ctx.CodecCtx.DB.Storage.SRem(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, members)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncInteger(returnValue)
 	}
@@ -164,23 +163,86 @@ func SMove(ctx *CmdContext) error {
 
 	res, err := ctx.CodecCtx.DB.Storage.Smove(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, srcKey, dstKey, member)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncInteger(int64(res))
 	}
 	return nil
 }
 
-func SetCmdWithKey(ctx *CmdContext) error {
-	key := ctx.Args[0]
-	var err error
-	rowKey := []*table.Column{
-		table.NewColumn(dbColumnName, ctx.CodecCtx.DB.ID),
-		table.NewColumn(keyColumnName, key),
+// SAdd adds the specified members to the set stored at key
+func SAdd(ctx *CmdContext) error {
+	added, err := ctx.CodecCtx.DB.Storage.SAdd(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], ctx.Args[1:])
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(added)
+	}
+	return nil
+}
+
+// SUnion returns the members of the set resulting from the union of all the given sets
+func SUnion(ctx *CmdContext) error {
+	members, err := ctx.CodecCtx.DB.Storage.SUnion(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncArray(members)
+	}
+	return nil
+}
+
+// SInter returns the members of the set resulting from the intersection of all the given sets
+func SInter(ctx *CmdContext) error {
+	members, err := ctx.CodecCtx.DB.Storage.SInter(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncArray(members)
+	}
+	return nil
+}
+
+// SDiff returns the members of the set resulting from the difference between the first set and all the successive sets
+func SDiff(ctx *CmdContext) error {
+	members, err := ctx.CodecCtx.DB.Storage.SDiff(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncArray(members)
+	}
+	return nil
+}
+
+// SUnionStore is equal to SUnion, but instead of returning the resulting set, it is stored in destination
+func SUnionStore(ctx *CmdContext) error {
+	size, err := ctx.CodecCtx.DB.Storage.SUnionStore(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], ctx.Args[1:])
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(size)
+	}
+	return nil
+}
+
+// SInterStore is equal to SInter, but instead of returning the resulting set, it is stored in destination
+func SInterStore(ctx *CmdContext) error {
+	size, err := ctx.CodecCtx.DB.Storage.SInterStore(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], ctx.Args[1:])
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(size)
 	}
-	ctx.OutContent, err = ctx.CodecCtx.DB.Storage.ObServerCmd(ctx.CodecCtx.DB.Ctx, setTableName, rowKey, ctx.PlainReq)
+	return nil
+}
+
+// SDiffStore is equal to SDiff, but instead of returning the resulting set, it is stored in destination
+func SDiffStore(ctx *CmdContext) error {
+	size, err := ctx.CodecCtx.DB.Storage.SDiffStore(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], ctx.Args[1:])
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(size)
 	}
 	return nil
 }
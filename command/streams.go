@@ -0,0 +1,1284 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oceanbase/modis/connection/conncontext"
+	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/oceanbase/modis/storage"
+	"github.com/oceanbase/modis/util"
+)
+
+const (
+	streamTableName = "modis_stream_table"
+
+	// the default LIMIT of the approximate trimming, redis uses 100 times stream-node-max-entries
+	defaultApproxTrimLimit = 10000
+	// the default COUNT of XINFO STREAM FULL
+	defaultInfoFullCount = 10
+	// the default COUNT of XAUTOCLAIM
+	defaultAutoClaimCount = 100
+	// the number of pending entries XAUTOCLAIM examines for each entry to claim
+	autoClaimAttemptsFactor = 10
+)
+
+var (
+	errInvalidStreamID = resp.EncError("ERR Invalid stream ID specified as stream command argument")
+	errXGroupNoKey     = resp.EncError("ERR The XGROUP subcommand requires the key to exist. " +
+		"Note that for CREATE you may want to use the MKSTREAM option to create an empty stream automatically.")
+)
+
+// parseStreamID parses "<ms>-<seq>" or "<ms>", in which case the seq is missingSeq. "-" and "+" are the
+// least and the greatest ids unless strict is set
+func parseStreamID(b []byte, missingSeq uint64, strict bool) (storage.StreamID, bool) {
+	s := util.BytesToString(b)
+	if !strict {
+		switch s {
+		case "-":
+			return storage.StreamID{}, true
+		case "+":
+			return storage.MaxStreamID, true
+		}
+	}
+	msPart, seqPart, hasSeq := strings.Cut(s, "-")
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return storage.StreamID{}, false
+	}
+	seq := missingSeq
+	if hasSeq {
+		if seq, err = strconv.ParseUint(seqPart, 10, 64); err != nil {
+			return storage.StreamID{}, false
+		}
+	}
+	return storage.StreamID{Ms: ms, Seq: seq}, true
+}
+
+// parseStreamIDs parses the strict ids, an error reply is returned if any of them is invalid
+func parseStreamIDs(args [][]byte) ([]storage.StreamID, string) {
+	ids := make([]storage.StreamID, 0, len(args))
+	for _, arg := range args {
+		id, ok := parseStreamID(arg, 0, true)
+		if !ok {
+			return nil, errInvalidStreamID
+		}
+		ids = append(ids, id)
+	}
+	return ids, ""
+}
+
+// parseRangeID parses an id bounding a range, "(" makes the bound exclusive.
+// The missing seq of a start is 0 and that of an end is the max
+func parseRangeID(b []byte, start bool) (storage.StreamID, string) {
+	exclusive := len(b) > 1 && b[0] == '('
+	if exclusive {
+		b = b[1:]
+	}
+	var missingSeq uint64 = math.MaxUint64
+	if start {
+		missingSeq = 0
+	}
+	id, ok := parseStreamID(b, missingSeq, false)
+	if !ok {
+		return id, errInvalidStreamID
+	}
+	if !exclusive {
+		return id, ""
+	}
+	if start {
+		if id, ok = id.Next(); !ok {
+			return id, resp.EncError("ERR invalid start ID for the interval")
+		}
+	} else if id, ok = id.Prev(); !ok {
+		return id, resp.EncError("ERR invalid end ID for the interval")
+	}
+	return id, ""
+}
+
+// parseStreamTrim parses the trimming options of XADD and XTRIM from args[i], for XADD it stops
+// at the id of the entry. Returns the trim, nil if no strategy is given, and the index it stops at
+func parseStreamTrim(args [][]byte, i int, xadd bool, addArgs *storage.StreamAddArgs) (*storage.StreamTrim, int, string) {
+	trim := &storage.StreamTrim{}
+	strategy, approx, limitGiven := "", false, false
+	for ; i < len(args); i++ {
+		moreArgs := len(args) - 1 - i
+		opt := strings.ToLower(util.BytesToString(args[i]))
+		switch {
+		case xadd && opt == "*":
+			addArgs.AutoID = true
+			return finishStreamTrim(trim, strategy, approx, limitGiven, xadd, i)
+		case (opt == "maxlen" || opt == "minid") && moreArgs > 0:
+			if strategy != "" {
+				return nil, i, resp.EncError("ERR syntax error, MAXLEN and MINID options at the same time are not compatible")
+			}
+			strategy = opt
+			if next := util.BytesToString(args[i+1]); moreArgs >= 2 && (next == "~" || next == "=") {
+				approx = next == "~"
+				i++
+			}
+			i++
+			if opt == "maxlen" {
+				maxLen, err := strconv.ParseInt(util.BytesToString(args[i]), 10, 64)
+				if err != nil {
+					return nil, i, resp.ResponseIntegerErr
+				}
+				if maxLen < 0 {
+					return nil, i, resp.EncError("ERR The MAXLEN argument must be >= 0.")
+				}
+				trim.MaxLen = maxLen
+			} else {
+				minID, ok := parseStreamID(args[i], 0, true)
+				if !ok {
+					return nil, i, errInvalidStreamID
+				}
+				trim.MinID, trim.ByMinID = minID, true
+			}
+		case opt == "limit" && moreArgs > 0:
+			i++
+			limit, err := strconv.ParseInt(util.BytesToString(args[i]), 10, 64)
+			if err != nil {
+				return nil, i, resp.ResponseIntegerErr
+			}
+			if limit < 0 {
+				return nil, i, resp.EncError("ERR The LIMIT argument must be >= 0.")
+			}
+			trim.Limit, limitGiven = limit, true
+		case xadd && opt == "nomkstream":
+			addArgs.NoMkStream = true
+		case xadd:
+			// the id of the entry, "<ms>-*" to generate the seq only
+			s := util.BytesToString(args[i])
+			if msPart, ok := strings.CutSuffix(s, "-*"); ok {
+				ms, err := strconv.ParseUint(msPart, 10, 64)
+				if err != nil {
+					return nil, i, errInvalidStreamID
+				}
+				addArgs.ID, addArgs.AutoSeq = storage.StreamID{Ms: ms}, true
+			} else {
+				id, ok := parseStreamID(args[i], 0, true)
+				if !ok {
+					return nil, i, errInvalidStreamID
+				}
+				if id.IsZero() {
+					return nil, i, resp.EncError("ERR The ID specified in XADD must be greater than 0-0")
+				}
+				addArgs.ID = id
+			}
+			return finishStreamTrim(trim, strategy, approx, limitGiven, xadd, i)
+		default:
+			return nil, i, resp.ResponseSyntaxErr
+		}
+	}
+	if xadd {
+		// no id given
+		return nil, i, resp.ErrWrongArgs("xadd")
+	}
+	return finishStreamTrim(trim, strategy, approx, limitGiven, xadd, i)
+}
+
+// finishStreamTrim checks the trimming options once they are all parsed. The approximate trimming
+// is as exact as the default one, but evicts at most LIMIT entries, 10000 if not given
+func finishStreamTrim(trim *storage.StreamTrim, strategy string, approx bool, limitGiven bool, xadd bool, i int) (*storage.StreamTrim, int, string) {
+	if strategy == "" {
+		if trim.Limit != 0 {
+			return nil, i, resp.EncError("ERR syntax error, LIMIT cannot be used without specifying a trimming strategy")
+		}
+		if !xadd {
+			return nil, i, resp.EncError("ERR syntax error, XTRIM must be called with a trimming strategy")
+		}
+		return nil, i, ""
+	}
+	if limitGiven {
+		if !approx {
+			return nil, i, resp.EncError("ERR syntax error, LIMIT cannot be used without the special ~ option")
+		}
+	} else if approx {
+		trim.Limit = defaultApproxTrimLimit
+	}
+	return trim, i, ""
+}
+
+// encStreamEntry encodes an entry as [id, [field, value, ...]], the fields of a deleted entry are nil
+func encStreamEntry(out *strings.Builder, e *storage.StreamEntry) {
+	out.WriteString(resp.ArrayFlag + "2" + resp.CRLF)
+	out.WriteString(resp.EncBulkString(e.ID.String()))
+	if e.Fields == nil {
+		out.WriteString(resp.ResponsesNullArray)
+	} else {
+		out.WriteString(resp.EncArray(e.Fields))
+	}
+}
+
+func encStreamEntries(out *strings.Builder, entries []storage.StreamEntry) {
+	out.WriteString(resp.ArrayFlag + strconv.Itoa(len(entries)) + resp.CRLF)
+	for i := range entries {
+		encStreamEntry(out, &entries[i])
+	}
+}
+
+// encStreamIDs encodes the ids as an array of bulk strings, e.g. the reply of JUSTID
+func encStreamIDs(out *strings.Builder, ids []storage.StreamID) {
+	out.WriteString(resp.ArrayFlag + strconv.Itoa(len(ids)) + resp.CRLF)
+	for _, id := range ids {
+		out.WriteString(resp.EncBulkString(id.String()))
+	}
+}
+
+// XAdd appends an entry to the stream stored at key, the stream is created unless NOMKSTREAM is given
+func XAdd(ctx *CmdContext) error {
+	key := ctx.Args[0]
+	args := &storage.StreamAddArgs{}
+	trim, i, errReply := parseStreamTrim(ctx.Args, 1, true, args)
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+	fields := ctx.Args[i+1:]
+	if len(fields) == 0 || len(fields)%2 != 0 {
+		ctx.OutContent = resp.ErrWrongArgs("xadd")
+		return nil
+	}
+	args.Trim = trim
+
+	id, trimmed, err := ctx.CodecCtx.DB.Storage.XAdd(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, args, fields)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+		return nil
+	}
+	if id == nil {
+		ctx.Modified = [][]byte{}
+		ctx.OutContent = resp.ResponsesNullBulkString
+		return nil
+	}
+	ctx.ServCtx.NotifyKeyspaceEvent(conncontext.NotifyStream, "xadd", ctx.CodecCtx.DB.ID, key)
+	if trimmed != 0 {
+		ctx.ServCtx.NotifyKeyspaceEvent(conncontext.NotifyStream, "xtrim", ctx.CodecCtx.DB.ID, key)
+	}
+	ctx.OutContent = resp.EncBulkString(id.String())
+	return nil
+}
+
+// XRange returns the entries with ids in the range, at most COUNT of them
+func XRange(ctx *CmdContext) error {
+	return xRange(ctx, ctx.Args[1], ctx.Args[2], false)
+}
+
+// XRevRange is XRANGE in reverse order, the end of the range is given first
+func XRevRange(ctx *CmdContext) error {
+	return xRange(ctx, ctx.Args[2], ctx.Args[1], true)
+}
+
+func xRange(ctx *CmdContext, startArg []byte, endArg []byte, reverse bool) error {
+	start, errReply := parseRangeID(startArg, true)
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+	end, errReply := parseRangeID(endArg, false)
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+	var count int64 = -1
+	opts := ctx.Args[3:]
+	if len(opts) != 0 {
+		if len(opts) != 2 || !strings.EqualFold(util.BytesToString(opts[0]), "count") {
+			ctx.OutContent = resp.ResponseSyntaxErr
+			return nil
+		}
+		var err error
+		if count, err = strconv.ParseInt(util.BytesToString(opts[1]), 10, 64); err != nil {
+			ctx.OutContent = resp.ResponseIntegerErr
+			return nil
+		}
+		if count < 0 {
+			count = 0
+		}
+	}
+	if count == 0 {
+		// an empty array still if the key does not exist
+		info, err := ctx.CodecCtx.DB.Storage.XInfoStream(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0])
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+		} else if info == nil {
+			ctx.OutContent = resp.EncArray([][]byte{})
+		} else {
+			ctx.OutContent = resp.ResponsesNullArray
+		}
+		return nil
+	}
+	if count < 0 {
+		count = 0
+	}
+
+	entries, err := ctx.CodecCtx.DB.Storage.XRange(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], start, end, count, reverse)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+		return nil
+	}
+	var out strings.Builder
+	encStreamEntries(&out, entries)
+	ctx.OutContent = out.String()
+	return nil
+}
+
+// XLen returns the number of entries of the stream stored at key
+func XLen(ctx *CmdContext) error {
+	length, err := ctx.CodecCtx.DB.Storage.XLen(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0])
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(length)
+	}
+	return nil
+}
+
+// XDel deletes the entries from the stream, returns the number of entries deleted
+func XDel(ctx *CmdContext) error {
+	ids, errReply := parseStreamIDs(ctx.Args[1:])
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+	deleted, err := ctx.CodecCtx.DB.Storage.XDel(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], ids)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(deleted)
+	}
+	return nil
+}
+
+// XTrim evicts the oldest entries of the stream, returns the number of entries evicted
+func XTrim(ctx *CmdContext) error {
+	trim, _, errReply := parseStreamTrim(ctx.Args, 1, false, nil)
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+	trimmed, err := ctx.CodecCtx.DB.Storage.XTrim(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0], trim)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(trimmed)
+	}
+	return nil
+}
+
+// xReadArgs are the arguments of XREAD and XREADGROUP
+type xReadArgs struct {
+	count    int64
+	block    bool
+	timeout  time.Duration
+	group    []byte
+	consumer []byte
+	noAck    bool
+	keys     [][]byte
+	// the index of the first id in ctx.Args
+	idsArg int
+}
+
+// parseXReadArgs parses [GROUP group consumer] [COUNT count] [BLOCK ms] [NOACK] STREAMS key [key ...] id [id ...]
+func parseXReadArgs(args [][]byte, group bool) (*xReadArgs, string) {
+	xa := &xReadArgs{}
+	cmdName, symbol := "xread", "$"
+	if group {
+		cmdName, symbol = "xreadgroup", ">"
+	}
+	streamsArg := -1
+	for i := 0; i < len(args) && streamsArg < 0; i++ {
+		moreArgs := len(args) - 1 - i
+		switch opt := strings.ToLower(util.BytesToString(args[i])); {
+		case opt == "block" && moreArgs > 0:
+			i++
+			timeout, errReply := parseBlockTimeoutMillis(args[i])
+			if errReply != "" {
+				return nil, errReply
+			}
+			xa.block, xa.timeout = true, timeout
+		case opt == "count" && moreArgs > 0:
+			i++
+			count, err := strconv.ParseInt(util.BytesToString(args[i]), 10, 64)
+			if err != nil {
+				return nil, resp.ResponseIntegerErr
+			}
+			if count < 0 {
+				count = 0
+			}
+			xa.count = count
+		case opt == "streams" && moreArgs > 0:
+			streamsArg = i + 1
+			if moreArgs%2 != 0 {
+				return nil, resp.EncError(fmt.Sprintf("ERR Unbalanced '%s' list of streams: "+
+					"for each stream key an ID or '%s' must be specified.", cmdName, symbol))
+			}
+		case opt == "group" && moreArgs >= 2:
+			if !group {
+				return nil, resp.EncError("ERR The GROUP option is only supported by XREADGROUP. You called XREAD instead.")
+			}
+			xa.group, xa.consumer = args[i+1], args[i+2]
+			i += 2
+		case opt == "noack":
+			if !group {
+				return nil, resp.EncError("ERR The NOACK option is only supported by XREADGROUP. You called XREAD instead.")
+			}
+			xa.noAck = true
+		default:
+			return nil, resp.ResponseSyntaxErr
+		}
+	}
+	if streamsArg < 0 {
+		return nil, resp.ResponseSyntaxErr
+	}
+	if group && xa.group == nil {
+		return nil, resp.EncError("ERR Missing GROUP option for XREADGROUP")
+	}
+	n := (len(args) - streamsArg) / 2
+	xa.keys = args[streamsArg : streamsArg+n]
+	xa.idsArg = streamsArg + n
+	return xa, ""
+}
+
+// XRead returns the entries after the given ids from one or more streams, the client is blocked
+// with BLOCK until one of the streams gets an entry
+func XRead(ctx *CmdContext) error {
+	xa, errReply := parseXReadArgs(ctx.Args, false)
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+	ids := make([]storage.StreamID, len(xa.keys))
+	for i, key := range xa.keys {
+		arg := ctx.Args[xa.idsArg+i]
+		switch util.BytesToString(arg) {
+		case "$":
+			info, err := ctx.CodecCtx.DB.Storage.XInfoStream(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
+			if err != nil {
+				ctx.OutContent = encStorageError(err)
+				return nil
+			}
+			if info != nil {
+				ids[i] = info.LastID
+			}
+			// a blocked client is retried with the id resolved now, so that it reads the entries added later
+			ctx.Args[xa.idsArg+i] = []byte(ids[i].String())
+		case ">":
+			ctx.OutContent = resp.EncError("ERR The > ID can be specified only when calling XREADGROUP using the GROUP <group> <consumer> option.")
+			return nil
+		default:
+			id, ok := parseStreamID(arg, 0, false)
+			if !ok {
+				ctx.OutContent = errInvalidStreamID
+				return nil
+			}
+			ids[i] = id
+		}
+	}
+
+	var out strings.Builder
+	served := 0
+	for i, key := range xa.keys {
+		start, ok := ids[i].Next()
+		if !ok {
+			continue
+		}
+		entries, err := ctx.CodecCtx.DB.Storage.XRange(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, start, storage.MaxStreamID, xa.count, false)
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		out.WriteString(resp.ArrayFlag + "2" + resp.CRLF)
+		out.WriteString(resp.EncBulkString(util.BytesToString(key)))
+		encStreamEntries(&out, entries)
+		served++
+	}
+	if served != 0 {
+		ctx.OutContent = resp.ArrayFlag + strconv.Itoa(served) + resp.CRLF + out.String()
+		return nil
+	}
+	ctx.OutContent = resp.ResponsesNullArray
+	if xa.block {
+		ctx.block = &blockSpec{keys: xa.keys, timeout: xa.timeout}
+	}
+	return nil
+}
+
+// XReadGroup reads the entries of the streams as a consumer of a group. The new entries are delivered
+// with the ">" id and kept pending until acknowledged, other ids read the pending entries of the consumer
+func XReadGroup(ctx *CmdContext) error {
+	xa, errReply := parseXReadArgs(ctx.Args, true)
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+	// nil for ">"
+	afters := make([]*storage.StreamID, len(xa.keys))
+	for i := range xa.keys {
+		arg := ctx.Args[xa.idsArg+i]
+		switch util.BytesToString(arg) {
+		case "$":
+			ctx.OutContent = resp.EncError("ERR The $ ID is meaningless in the context of XREADGROUP: " +
+				"you want to read the history of this consumer by specifying a proper ID, " +
+				"or use the > ID to get new messages. The $ ID would just return an empty result set.")
+			return nil
+		case ">":
+		default:
+			id, ok := parseStreamID(arg, 0, false)
+			if !ok {
+				ctx.OutContent = errInvalidStreamID
+				return nil
+			}
+			afters[i] = &id
+		}
+	}
+
+	var out strings.Builder
+	served := 0
+	history := false
+	for i, key := range xa.keys {
+		entries, err := ctx.CodecCtx.DB.Storage.XReadGroup(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, xa.group, xa.consumer,
+			afters[i], xa.count, xa.noAck)
+		if errors.Is(err, storage.ErrNoGroup) {
+			ctx.OutContent = resp.EncError(fmt.Sprintf("NOGROUP No such key '%s' or consumer group '%s' in XREADGROUP with GROUP option",
+				key, xa.group))
+			return nil
+		} else if err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+		// the history is served even if it is empty
+		if afters[i] != nil {
+			history = true
+		} else if len(entries) == 0 {
+			continue
+		}
+		out.WriteString(resp.ArrayFlag + "2" + resp.CRLF)
+		out.WriteString(resp.EncBulkString(util.BytesToString(key)))
+		encStreamEntries(&out, entries)
+		served++
+	}
+	if served != 0 {
+		ctx.OutContent = resp.ArrayFlag + strconv.Itoa(served) + resp.CRLF + out.String()
+		return nil
+	}
+	ctx.OutContent = resp.ResponsesNullArray
+	if xa.block && !history {
+		ctx.block = &blockSpec{keys: xa.keys, timeout: xa.timeout}
+	}
+	return nil
+}
+
+// XAck acknowledges the pending entries of the group, returns the number of entries acknowledged
+func XAck(ctx *CmdContext) error {
+	key, group := ctx.Args[0], ctx.Args[1]
+	ids, errReply := parseStreamIDs(ctx.Args[2:])
+	if errReply != "" {
+		// nothing to acknowledge without the group, whatever the ids are
+		_, err := ctx.CodecCtx.DB.Storage.XPending(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, group)
+		if errors.Is(err, storage.ErrNoGroup) {
+			ctx.OutContent = resp.EncInteger(0)
+		} else if err != nil {
+			ctx.OutContent = encStorageError(err)
+		} else {
+			ctx.OutContent = errReply
+		}
+		return nil
+	}
+	acked, err := ctx.CodecCtx.DB.Storage.XAck(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, group, ids)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(acked)
+	}
+	return nil
+}
+
+// encNoGroupError encodes the error of XPENDING, XCLAIM and XAUTOCLAIM on a missing key or group
+func encNoGroupError(err error, key []byte, group []byte) string {
+	if errors.Is(err, storage.ErrNoGroup) {
+		return resp.EncError(fmt.Sprintf("NOGROUP No such key '%s' or consumer group '%s'", key, group))
+	}
+	return encStorageError(err)
+}
+
+// XPending summarizes the pending entries of the group, or lists those in a range of ids
+func XPending(ctx *CmdContext) error {
+	key, group := ctx.Args[0], ctx.Args[1]
+	if len(ctx.Args) == 2 {
+		return xPendingSummary(ctx, key, group)
+	}
+	if len(ctx.Args) < 5 || len(ctx.Args) > 8 {
+		ctx.OutContent = resp.ResponseSyntaxErr
+		return nil
+	}
+
+	args := &storage.StreamPendingArgs{}
+	opts := ctx.Args[2:]
+	if strings.EqualFold(util.BytesToString(opts[0]), "idle") {
+		minIdle, err := strconv.ParseInt(util.BytesToString(opts[1]), 10, 64)
+		if err != nil {
+			ctx.OutContent = resp.ResponseIntegerErr
+			return nil
+		}
+		if len(opts) < 5 {
+			ctx.OutContent = resp.ResponseSyntaxErr
+			return nil
+		}
+		args.MinIdle = time.Duration(minIdle) * time.Millisecond
+		opts = opts[2:]
+	}
+	if len(opts) > 4 {
+		ctx.OutContent = resp.ResponseSyntaxErr
+		return nil
+	}
+	count, err := strconv.ParseInt(util.BytesToString(opts[2]), 10, 64)
+	if err != nil {
+		ctx.OutContent = resp.ResponseIntegerErr
+		return nil
+	}
+	var errReply string
+	if args.Start, errReply = parseRangeID(opts[0], true); errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+	if args.End, errReply = parseRangeID(opts[1], false); errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+	if len(opts) == 4 {
+		args.Consumer = opts[3]
+	}
+	args.Count = count
+	if count <= 0 {
+		// nothing to list, but the group is still checked
+		args.Count, args.Start, args.End = 1, storage.MaxStreamID, storage.StreamID{}
+	}
+
+	entries, err := ctx.CodecCtx.DB.Storage.XPendingRange(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, group, args)
+	if err != nil {
+		ctx.OutContent = encNoGroupError(err, key, group)
+		return nil
+	}
+	now := time.Now()
+	var out strings.Builder
+	out.WriteString(resp.ArrayFlag + strconv.Itoa(len(entries)) + resp.CRLF)
+	for _, e := range entries {
+		out.WriteString(resp.ArrayFlag + "4" + resp.CRLF)
+		out.WriteString(resp.EncBulkString(e.ID.String()))
+		out.WriteString(resp.EncBulkString(util.BytesToString(e.Consumer)))
+		out.WriteString(resp.EncInteger(now.Sub(e.DeliveryTime).Milliseconds()))
+		out.WriteString(resp.EncInteger(e.Deliveries))
+	}
+	ctx.OutContent = out.String()
+	return nil
+}
+
+func xPendingSummary(ctx *CmdContext, key []byte, group []byte) error {
+	summary, err := ctx.CodecCtx.DB.Storage.XPending(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, group)
+	if err != nil {
+		ctx.OutContent = encNoGroupError(err, key, group)
+		return nil
+	}
+	var out strings.Builder
+	out.WriteString(resp.ArrayFlag + "4" + resp.CRLF)
+	out.WriteString(resp.EncInteger(summary.Count))
+	if summary.Count == 0 {
+		out.WriteString(resp.ResponsesNullBulkString + resp.ResponsesNullBulkString + resp.ResponsesNullArray)
+		ctx.OutContent = out.String()
+		return nil
+	}
+	out.WriteString(resp.EncBulkString(summary.MinID.String()))
+	out.WriteString(resp.EncBulkString(summary.MaxID.String()))
+	out.WriteString(resp.ArrayFlag + strconv.Itoa(len(summary.Consumers)) + resp.CRLF)
+	for _, c := range summary.Consumers {
+		out.WriteString(resp.EncArray([][]byte{c.Name, []byte(strconv.FormatInt(c.Count, 10))}))
+	}
+	ctx.OutContent = out.String()
+	return nil
+}
+
+// XClaim transfers the pending entries idle for at least min-idle-time to the consumer
+func XClaim(ctx *CmdContext) error {
+	key, group, consumer := ctx.Args[0], ctx.Args[1], ctx.Args[2]
+	minIdle, err := strconv.ParseInt(util.BytesToString(ctx.Args[3]), 10, 64)
+	if err != nil {
+		ctx.OutContent = resp.EncError("ERR Invalid min-idle-time argument for XCLAIM")
+		return nil
+	}
+	if minIdle < 0 {
+		minIdle = 0
+	}
+	// the ids go first, the options follow
+	i := 4
+	var ids []storage.StreamID
+	for ; i < len(ctx.Args); i++ {
+		id, ok := parseStreamID(ctx.Args[i], 0, true)
+		if !ok {
+			break
+		}
+		ids = append(ids, id)
+	}
+
+	now := time.Now()
+	args := &storage.StreamClaimArgs{MinIdle: time.Duration(minIdle) * time.Millisecond, RetryCount: -1}
+	deliveryTime := int64(-1)
+	for ; i < len(ctx.Args); i++ {
+		moreArgs := len(ctx.Args) - 1 - i
+		opt := util.BytesToString(ctx.Args[i])
+		switch lower := strings.ToLower(opt); {
+		case lower == "force":
+			args.Force = true
+		case lower == "justid":
+			args.JustID = true
+		case (lower == "idle" || lower == "time" || lower == "retrycount") && moreArgs > 0:
+			i++
+			val, err := strconv.ParseInt(util.BytesToString(ctx.Args[i]), 10, 64)
+			if err != nil {
+				ctx.OutContent = resp.EncError(fmt.Sprintf("ERR Invalid %s option argument for XCLAIM", strings.ToUpper(lower)))
+				return nil
+			}
+			switch lower {
+			case "idle":
+				deliveryTime = now.UnixMilli() - val
+			case "time":
+				deliveryTime = val
+			default:
+				args.RetryCount = val
+			}
+		case lower == "lastid" && moreArgs > 0:
+			i++
+			id, ok := parseStreamID(ctx.Args[i], 0, true)
+			if !ok {
+				ctx.OutContent = errInvalidStreamID
+				return nil
+			}
+			args.LastID = id
+		default:
+			ctx.OutContent = resp.EncError(fmt.Sprintf("ERR Unrecognized XCLAIM option '%s'", opt))
+			return nil
+		}
+	}
+	// a bogus delivery time is taken as now, since clients may compute it with a skewed clock
+	if deliveryTime < 0 || deliveryTime > now.UnixMilli() {
+		args.DeliveryTime = now
+	} else {
+		args.DeliveryTime = time.UnixMilli(deliveryTime)
+	}
+
+	entries, err := ctx.CodecCtx.DB.Storage.XClaim(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, group, consumer, ids, args)
+	if err != nil {
+		ctx.OutContent = encNoGroupError(err, key, group)
+		return nil
+	}
+	var out strings.Builder
+	if args.JustID {
+		claimed := make([]storage.StreamID, 0, len(entries))
+		for _, e := range entries {
+			claimed = append(claimed, e.ID)
+		}
+		encStreamIDs(&out, claimed)
+	} else {
+		encStreamEntries(&out, entries)
+	}
+	ctx.OutContent = out.String()
+	return nil
+}
+
+// XAutoClaim claims the pending entries idle for at least min-idle-time from the start id, the reply
+// tells the id to start the next call from, and the ids found deleted from the stream
+func XAutoClaim(ctx *CmdContext) error {
+	key, group, consumer := ctx.Args[0], ctx.Args[1], ctx.Args[2]
+	minIdle, err := strconv.ParseInt(util.BytesToString(ctx.Args[3]), 10, 64)
+	if err != nil {
+		ctx.OutContent = resp.EncError("ERR Invalid min-idle-time argument for XAUTOCLAIM")
+		return nil
+	}
+	if minIdle < 0 {
+		minIdle = 0
+	}
+	start, errReply := parseRangeID(ctx.Args[4], true)
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+	var count int64 = defaultAutoClaimCount
+	justID := false
+	for i := 5; i < len(ctx.Args); i++ {
+		moreArgs := len(ctx.Args) - 1 - i
+		opt := strings.ToLower(util.BytesToString(ctx.Args[i]))
+		switch {
+		case opt == "count" && moreArgs > 0:
+			i++
+			count, err = strconv.ParseInt(util.BytesToString(ctx.Args[i]), 10, 64)
+			if err != nil {
+				ctx.OutContent = resp.ResponseIntegerErr
+				return nil
+			}
+			if count < 1 || count > math.MaxInt64/(autoClaimAttemptsFactor*2) {
+				ctx.OutContent = resp.EncError("ERR COUNT must be > 0")
+				return nil
+			}
+		case opt == "justid":
+			justID = true
+		default:
+			ctx.OutContent = resp.ResponseSyntaxErr
+			return nil
+		}
+	}
+
+	next, entries, deleted, err := ctx.CodecCtx.DB.Storage.XAutoClaim(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, group, consumer,
+		start, time.Duration(minIdle)*time.Millisecond, count, justID)
+	if err != nil {
+		ctx.OutContent = encNoGroupError(err, key, group)
+		return nil
+	}
+	var out strings.Builder
+	out.WriteString(resp.ArrayFlag + "3" + resp.CRLF)
+	out.WriteString(resp.EncBulkString(next.String()))
+	if justID {
+		claimed := make([]storage.StreamID, 0, len(entries))
+		for _, e := range entries {
+			claimed = append(claimed, e.ID)
+		}
+		encStreamIDs(&out, claimed)
+	} else {
+		encStreamEntries(&out, entries)
+	}
+	encStreamIDs(&out, deleted)
+	ctx.OutContent = out.String()
+	return nil
+}
+
+// encXGroupError encodes the errors of the XGROUP subcommands
+func encXGroupError(err error, key []byte, group []byte) string {
+	switch {
+	case errors.Is(err, storage.ErrNoSuchKey):
+		return errXGroupNoKey
+	case errors.Is(err, storage.ErrNoGroup):
+		return resp.EncError(fmt.Sprintf("NOGROUP No such consumer group '%s' for key name '%s'", group, key))
+	}
+	return encStorageError(err)
+}
+
+// parseGroupID parses the last delivered id of a group, nil for "$"
+func parseGroupID(arg []byte) (*storage.StreamID, bool) {
+	if util.BytesToString(arg) == "$" {
+		return nil, true
+	}
+	id, ok := parseStreamID(arg, 0, true)
+	return &id, ok
+}
+
+// parseEntriesRead parses the value of ENTRIESREAD
+func parseEntriesRead(arg []byte) (int64, string) {
+	entriesRead, err := strconv.ParseInt(util.BytesToString(arg), 10, 64)
+	if err != nil {
+		return 0, resp.ResponseIntegerErr
+	}
+	if entriesRead < -1 {
+		return 0, resp.EncError("ERR value for ENTRIESREAD must be positive or -1")
+	}
+	return entriesRead, ""
+}
+
+// errXGroupSyntax is the reply to the wrong options of an XGROUP subcommand
+func errXGroupSyntax(ctx *CmdContext) string {
+	return resp.EncError(fmt.Sprintf("ERR unknown subcommand or wrong number of arguments for '%s'. Try XGROUP HELP.", ctx.Args[0]))
+}
+
+// XGroupCreate creates a consumer group of the stream, which reads the entries after the given id
+func XGroupCreate(ctx *CmdContext) error {
+	key, group := ctx.Args[1], ctx.Args[2]
+	if len(ctx.Args) > 7 {
+		ctx.OutContent = errXGroupSyntax(ctx)
+		return nil
+	}
+	mkStream := false
+	entriesRead := int64(-1)
+	for i := 4; i < len(ctx.Args); i++ {
+		opt := util.BytesToString(ctx.Args[i])
+		switch {
+		case strings.EqualFold(opt, "mkstream"):
+			mkStream = true
+		case strings.EqualFold(opt, "entriesread") && i+1 < len(ctx.Args):
+			var errReply string
+			if entriesRead, errReply = parseEntriesRead(ctx.Args[i+1]); errReply != "" {
+				ctx.OutContent = errReply
+				return nil
+			}
+			i++
+		default:
+			ctx.OutContent = errXGroupSyntax(ctx)
+			return nil
+		}
+	}
+	id, ok := parseGroupID(ctx.Args[3])
+	if !ok {
+		ctx.OutContent = errInvalidStreamID
+		return nil
+	}
+
+	err := ctx.CodecCtx.DB.Storage.XGroupCreate(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, group, id, entriesRead, mkStream)
+	if err != nil {
+		ctx.OutContent = encXGroupError(err, key, group)
+	} else {
+		ctx.OutContent = resp.ResponsesOk
+	}
+	return nil
+}
+
+// XGroupSetID sets the last delivered id of the consumer group
+func XGroupSetID(ctx *CmdContext) error {
+	key, group := ctx.Args[1], ctx.Args[2]
+	entriesRead := int64(-1)
+	switch {
+	case len(ctx.Args) == 4:
+	case len(ctx.Args) == 6 && strings.EqualFold(util.BytesToString(ctx.Args[4]), "entriesread"):
+		var errReply string
+		if entriesRead, errReply = parseEntriesRead(ctx.Args[5]); errReply != "" {
+			ctx.OutContent = errReply
+			return nil
+		}
+	default:
+		ctx.OutContent = errXGroupSyntax(ctx)
+		return nil
+	}
+	id, ok := parseGroupID(ctx.Args[3])
+	if !ok {
+		ctx.OutContent = errInvalidStreamID
+		return nil
+	}
+
+	err := ctx.CodecCtx.DB.Storage.XGroupSetID(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, group, id, entriesRead)
+	if err != nil {
+		ctx.OutContent = encXGroupError(err, key, group)
+	} else {
+		ctx.OutContent = resp.ResponsesOk
+	}
+	return nil
+}
+
+// XGroupDestroy destroys the consumer group, returns 1 if it existed
+func XGroupDestroy(ctx *CmdContext) error {
+	key, group := ctx.Args[1], ctx.Args[2]
+	ok, err := ctx.CodecCtx.DB.Storage.XGroupDestroy(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, group)
+	if err != nil {
+		ctx.OutContent = encXGroupError(err, key, group)
+	} else {
+		ctx.OutContent = encBool(ok)
+	}
+	return nil
+}
+
+// XGroupCreateConsumer creates a consumer in the group, returns 1 if it is created
+func XGroupCreateConsumer(ctx *CmdContext) error {
+	key, group := ctx.Args[1], ctx.Args[2]
+	ok, err := ctx.CodecCtx.DB.Storage.XGroupCreateConsumer(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, group, ctx.Args[3])
+	if err != nil {
+		ctx.OutContent = encXGroupError(err, key, group)
+	} else {
+		ctx.OutContent = encBool(ok)
+	}
+	return nil
+}
+
+// XGroupDelConsumer deletes the consumer from the group, returns the number of its pending entries
+func XGroupDelConsumer(ctx *CmdContext) error {
+	key, group := ctx.Args[1], ctx.Args[2]
+	pending, ok, err := ctx.CodecCtx.DB.Storage.XGroupDelConsumer(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, group, ctx.Args[3])
+	if err != nil {
+		ctx.OutContent = encXGroupError(err, key, group)
+		return nil
+	}
+	if ok {
+		ctx.ServCtx.NotifyKeyspaceEvent(conncontext.NotifyStream, "xgroup-delconsumer", ctx.CodecCtx.DB.ID, key)
+	} else {
+		ctx.Modified = [][]byte{}
+	}
+	ctx.OutContent = resp.EncInteger(pending)
+	return nil
+}
+
+func XGroupHelp(ctx *CmdContext) error {
+	out := [][]byte{
+		[]byte("CREATE <key> <groupname> <id|$> [option]"),
+		[]byte("    Create a new consumer group. Options are:"),
+		[]byte("    * MKSTREAM"),
+		[]byte("      Create the empty stream if it does not exist."),
+		[]byte("    * ENTRIESREAD entries_read"),
+		[]byte("      Set the group's entries_read counter (internal use)."),
+		[]byte("CREATECONSUMER <key> <groupname> <consumer>"),
+		[]byte("    Create a new consumer in the specified group."),
+		[]byte("DELCONSUMER <key> <groupname> <consumer>"),
+		[]byte("    Remove the specified consumer."),
+		[]byte("DESTROY <key> <groupname>"),
+		[]byte("    Remove the specified group."),
+		[]byte("SETID <key> <groupname> <id|$> [ENTRIESREAD entries_read]"),
+		[]byte("    Set the current group ID and entries_read counter."),
+		[]byte("HELP"),
+		[]byte("    Print this help."),
+	}
+	ctx.OutContent = resp.EncArray(out)
+	return nil
+}
+
+// encXInfoError encodes the errors of the XINFO subcommands
+func encXInfoError(err error, key []byte, group []byte) string {
+	if errors.Is(err, storage.ErrNoGroup) {
+		return resp.EncError(fmt.Sprintf("NOGROUP No such consumer group '%s' for key name '%s'", group, key))
+	}
+	return encStorageError(err)
+}
+
+// XInfoStream returns the state of the stream, with FULL the entries, groups and consumers are listed
+// as well, at most COUNT entries of each list
+func XInfoStream(ctx *CmdContext) error {
+	key := ctx.Args[1]
+	full := false
+	var count int64 = defaultInfoFullCount
+	if len(ctx.Args) > 2 {
+		if !strings.EqualFold(util.BytesToString(ctx.Args[2]), "full") {
+			ctx.OutContent = resp.ResponseSyntaxErr
+			return nil
+		}
+		full = true
+		if len(ctx.Args) == 5 && strings.EqualFold(util.BytesToString(ctx.Args[3]), "count") {
+			var err error
+			if count, err = strconv.ParseInt(util.BytesToString(ctx.Args[4]), 10, 64); err != nil {
+				ctx.OutContent = resp.ResponseIntegerErr
+				return nil
+			}
+			if count < 0 {
+				count = defaultInfoFullCount
+			}
+		} else if len(ctx.Args) > 3 {
+			ctx.OutContent = resp.EncError(fmt.Sprintf("ERR unknown subcommand or wrong number of arguments for '%s'. Try XINFO HELP.", ctx.Args[0]))
+			return nil
+		}
+	}
+
+	info, err := ctx.CodecCtx.DB.Storage.XInfoStream(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+		return nil
+	}
+	if info == nil {
+		ctx.OutContent = encStorageError(storage.ErrNoSuchKey)
+		return nil
+	}
+
+	var out strings.Builder
+	n := 20
+	if full {
+		n = 18
+	}
+	out.WriteString(resp.ArrayFlag + strconv.Itoa(n) + resp.CRLF)
+	out.WriteString(resp.EncBulkString("length") + resp.EncInteger(info.Length))
+	// there is no radix tree behind a stream, both are reported as 0
+	out.WriteString(resp.EncBulkString("radix-tree-keys") + resp.EncInteger(0))
+	out.WriteString(resp.EncBulkString("radix-tree-nodes") + resp.EncInteger(0))
+	out.WriteString(resp.EncBulkString("last-generated-id") + resp.EncBulkString(info.LastID.String()))
+	out.WriteString(resp.EncBulkString("max-deleted-entry-id") + resp.EncBulkString(info.MaxDeletedID.String()))
+	out.WriteString(resp.EncBulkString("entries-added") + resp.EncInteger(info.EntriesAdded))
+	out.WriteString(resp.EncBulkString("recorded-first-entry-id") + resp.EncBulkString(info.FirstID.String()))
+	if !full {
+		out.WriteString(resp.EncBulkString("groups") + resp.EncInteger(info.Groups))
+		for _, e := range []struct {
+			name  string
+			entry *storage.StreamEntry
+		}{{"first-entry", info.FirstEntry}, {"last-entry", info.LastEntry}} {
+			out.WriteString(resp.EncBulkString(e.name))
+			if e.entry == nil {
+				out.WriteString(resp.ResponsesNullBulkString)
+			} else {
+				encStreamEntry(&out, e.entry)
+			}
+		}
+		ctx.OutContent = out.String()
+		return nil
+	}
+
+	entries, err := ctx.CodecCtx.DB.Storage.XRange(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, storage.StreamID{}, storage.MaxStreamID, count, false)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+		return nil
+	}
+	out.WriteString(resp.EncBulkString("entries"))
+	encStreamEntries(&out, entries)
+	groups, err := xInfoFullGroups(ctx, key, count)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+		return nil
+	}
+	out.WriteString(resp.EncBulkString("groups") + groups)
+	ctx.OutContent = out.String()
+	return nil
+}
+
+// xInfoFullGroups encodes the groups of XINFO STREAM FULL along with their pending entries and consumers
+func xInfoFullGroups(ctx *CmdContext, key []byte, count int64) (string, error) {
+	stor, dbCtx, db := ctx.CodecCtx.DB.Storage, ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID
+	groups, err := stor.XInfoGroups(dbCtx, db, key)
+	if err != nil {
+		return "", err
+	}
+	all := &storage.StreamPendingArgs{Start: storage.StreamID{}, End: storage.MaxStreamID, Count: count}
+
+	var out strings.Builder
+	out.WriteString(resp.ArrayFlag + strconv.Itoa(len(groups)) + resp.CRLF)
+	for _, g := range groups {
+		out.WriteString(resp.ArrayFlag + "14" + resp.CRLF)
+		out.WriteString(resp.EncBulkString("name") + resp.EncBulkString(util.BytesToString(g.Name)))
+		out.WriteString(resp.EncBulkString("last-delivered-id") + resp.EncBulkString(g.LastDeliveredID.String()))
+		out.WriteString(resp.EncBulkString("entries-read") + encOptionalInteger(g.EntriesRead, g.EntriesRead >= 0))
+		out.WriteString(resp.EncBulkString("lag") + encOptionalInteger(g.Lag, g.LagKnown))
+		out.WriteString(resp.EncBulkString("pel-count") + resp.EncInteger(g.Pending))
+
+		pending, err := stor.XPendingRange(dbCtx, db, key, g.Name, all)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(resp.EncBulkString("pending"))
+		out.WriteString(resp.ArrayFlag + strconv.Itoa(len(pending)) + resp.CRLF)
+		for _, p := range pending {
+			out.WriteString(resp.ArrayFlag + "4" + resp.CRLF)
+			out.WriteString(resp.EncBulkString(p.ID.String()))
+			out.WriteString(resp.EncBulkString(util.BytesToString(p.Consumer)))
+			out.WriteString(resp.EncInteger(p.DeliveryTime.UnixMilli()))
+			out.WriteString(resp.EncInteger(p.Deliveries))
+		}
+
+		consumers, err := stor.XInfoConsumers(dbCtx, db, key, g.Name)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(resp.EncBulkString("consumers"))
+		out.WriteString(resp.ArrayFlag + strconv.Itoa(len(consumers)) + resp.CRLF)
+		for _, c := range consumers {
+			out.WriteString(resp.ArrayFlag + "10" + resp.CRLF)
+			out.WriteString(resp.EncBulkString("name") + resp.EncBulkString(util.BytesToString(c.Name)))
+			out.WriteString(resp.EncBulkString("seen-time") + resp.EncInteger(c.SeenTime.UnixMilli()))
+			out.WriteString(resp.EncBulkString("active-time") + resp.EncInteger(activeTimeMillis(c.ActiveTime)))
+			out.WriteString(resp.EncBulkString("pel-count") + resp.EncInteger(c.Pending))
+
+			args := *all
+			args.Consumer = c.Name
+			pending, err := stor.XPendingRange(dbCtx, db, key, g.Name, &args)
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(resp.EncBulkString("pending"))
+			out.WriteString(resp.ArrayFlag + strconv.Itoa(len(pending)) + resp.CRLF)
+			for _, p := range pending {
+				out.WriteString(resp.ArrayFlag + "3" + resp.CRLF)
+				out.WriteString(resp.EncBulkString(p.ID.String()))
+				out.WriteString(resp.EncInteger(p.DeliveryTime.UnixMilli()))
+				out.WriteString(resp.EncInteger(p.Deliveries))
+			}
+		}
+	}
+	return out.String(), nil
+}
+
+// encOptionalInteger encodes val, or nil if it is not known
+func encOptionalInteger(val int64, known bool) string {
+	if !known {
+		return resp.ResponsesNullBulkString
+	}
+	return resp.EncInteger(val)
+}
+
+// activeTimeMillis returns the unix time in milliseconds of an active time, -1 if never active
+func activeTimeMillis(t time.Time) int64 {
+	if t.IsZero() {
+		return -1
+	}
+	return t.UnixMilli()
+}
+
+// XInfoGroups lists the consumer groups of the stream
+func XInfoGroups(ctx *CmdContext) error {
+	groups, err := ctx.CodecCtx.DB.Storage.XInfoGroups(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[1])
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+		return nil
+	}
+	var out strings.Builder
+	out.WriteString(resp.ArrayFlag + strconv.Itoa(len(groups)) + resp.CRLF)
+	for _, g := range groups {
+		out.WriteString(resp.ArrayFlag + "12" + resp.CRLF)
+		out.WriteString(resp.EncBulkString("name") + resp.EncBulkString(util.BytesToString(g.Name)))
+		out.WriteString(resp.EncBulkString("consumers") + resp.EncInteger(g.Consumers))
+		out.WriteString(resp.EncBulkString("pending") + resp.EncInteger(g.Pending))
+		out.WriteString(resp.EncBulkString("last-delivered-id") + resp.EncBulkString(g.LastDeliveredID.String()))
+		out.WriteString(resp.EncBulkString("entries-read") + encOptionalInteger(g.EntriesRead, g.EntriesRead >= 0))
+		out.WriteString(resp.EncBulkString("lag") + encOptionalInteger(g.Lag, g.LagKnown))
+	}
+	ctx.OutContent = out.String()
+	return nil
+}
+
+// XInfoConsumers lists the consumers of the group
+func XInfoConsumers(ctx *CmdContext) error {
+	key, group := ctx.Args[1], ctx.Args[2]
+	consumers, err := ctx.CodecCtx.DB.Storage.XInfoConsumers(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, group)
+	if err != nil {
+		ctx.OutContent = encXInfoError(err, key, group)
+		return nil
+	}
+	now := time.Now()
+	var out strings.Builder
+	out.WriteString(resp.ArrayFlag + strconv.Itoa(len(consumers)) + resp.CRLF)
+	for _, c := range consumers {
+		inactive := int64(-1)
+		if !c.ActiveTime.IsZero() {
+			inactive = now.Sub(c.ActiveTime).Milliseconds()
+		}
+		out.WriteString(resp.ArrayFlag + "8" + resp.CRLF)
+		out.WriteString(resp.EncBulkString("name") + resp.EncBulkString(util.BytesToString(c.Name)))
+		out.WriteString(resp.EncBulkString("pending") + resp.EncInteger(c.Pending))
+		out.WriteString(resp.EncBulkString("idle") + resp.EncInteger(now.Sub(c.SeenTime).Milliseconds()))
+		out.WriteString(resp.EncBulkString("inactive") + resp.EncInteger(inactive))
+	}
+	ctx.OutContent = out.String()
+	return nil
+}
+
+func XInfoHelp(ctx *CmdContext) error {
+	out := [][]byte{
+		[]byte("CONSUMERS <key> <groupname>"),
+		[]byte("    Show consumers of <groupname>."),
+		[]byte("GROUPS <key>"),
+		[]byte("    Show the stream consumer groups."),
+		[]byte("STREAM <key> [FULL [COUNT <count>]"),
+		[]byte("    Show information about the stream."),
+		[]byte("HELP"),
+		[]byte("    Print this help."),
+	}
+	ctx.OutContent = resp.EncArray(out)
+	return nil
+}
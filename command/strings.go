@@ -36,7 +36,7 @@ func Get(ctx *CmdContext) error {
 	key := ctx.Args[0]
 	val, err := ctx.CodecCtx.DB.Storage.Get(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else if val == nil {
 		ctx.OutContent = resp.EncNullBulkString()
 	} else {
@@ -52,7 +52,7 @@ func Set(ctx *CmdContext) error {
 
 	err := ctx.CodecCtx.DB.Storage.Set(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, value)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.ResponsesOk
 	}
@@ -67,7 +67,7 @@ func MGet(ctx *CmdContext) error {
 
 	resValues, err := ctx.CodecCtx.DB.Storage.MGet(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, keys)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncArray(resValues)
 	}
@@ -89,7 +89,7 @@ func MSet(ctx *CmdContext) error {
 
 		_, err := ctx.CodecCtx.DB.Storage.MSet(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, setValues)
 		if err != nil {
-			ctx.OutContent = resp.EncError("ERR " + err.Error())
+			ctx.OutContent = encStorageError(err)
 		} else {
 			ctx.OutContent = resp.ResponsesOk
 		}
@@ -102,7 +102,7 @@ func Strlen(ctx *CmdContext) error {
 	key := ctx.Args[0]
 	val, err := ctx.CodecCtx.DB.Storage.Get(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncInteger(int64(len(util.BytesToString(val))))
 	}
@@ -115,7 +115,7 @@ func Append(ctx *CmdContext) error {
 	value := ctx.Args[1]
 	length, err := ctx.CodecCtx.DB.Storage.Append(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, value)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncInteger(int64(length))
 	}
@@ -128,7 +128,7 @@ func SetNx(ctx *CmdContext) error {
 	value := ctx.Args[1]
 	resValue, err := ctx.CodecCtx.DB.Storage.SetNx(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, value)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncInteger(int64(resValue))
 	}
@@ -142,7 +142,7 @@ func SetEx(ctx *CmdContext) error {
 
 	ui, err := strconv.ParseUint(util.BytesToString(ctx.Args[1]), 10, 64)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 		return nil
 	}
 	if ui <= 0 {
@@ -153,7 +153,7 @@ func SetEx(ctx *CmdContext) error {
 
 	err = ctx.CodecCtx.DB.Storage.SetEx(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, expireTimes, value)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.ResponsesOk
 	}
@@ -166,7 +166,7 @@ func PSetEx(ctx *CmdContext) error {
 	value := ctx.Args[2]
 	ui, err := strconv.ParseUint(util.BytesToString(ctx.Args[1]), 10, 64)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	}
 	if ui <= 0 {
 		ctx.OutContent = resp.ErrInvalidExpire(ctx.FullName)
@@ -176,7 +176,7 @@ func PSetEx(ctx *CmdContext) error {
 
 	err = ctx.CodecCtx.DB.Storage.PSetEx(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, expireTimeMs, value)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.ResponsesOk
 	}
@@ -231,7 +231,7 @@ func IncrByFloat(ctx *CmdContext) error {
 		if strings.Contains(err.Error(), "-5114") {
 			ctx.OutContent = resp.EncError("ERR value is not a valid float")
 		} else {
-			ctx.OutContent = resp.EncError("ERR " + err.Error())
+			ctx.OutContent = encStorageError(err)
 		}
 	} else {
 		ctx.OutContent = resp.EncBulkString(strconv.FormatFloat(f64, 'f', -1, 64))
@@ -281,7 +281,7 @@ func GetBit(ctx *CmdContext) error {
 
 	res, err := ctx.CodecCtx.DB.Storage.GetBit(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, offset)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncInteger(int64(res))
 	}
@@ -290,40 +290,42 @@ func GetBit(ctx *CmdContext) error {
 
 // BitCount counts the number of set bits (population counting) in a string.
 func BitCount(ctx *CmdContext) error {
-	key := ctx.Args[0]
-	val, err := ctx.CodecCtx.DB.Storage.Get(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
-	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
-	} else if val == nil {
-		ctx.OutContent = resp.EncInteger(0)
-	} else {
-		var begin, end int
-		switch len(ctx.Args) {
-		case 3:
-			begin, err = strconv.Atoi(util.BytesToString(ctx.Args[1]))
-			if err != nil {
-				ctx.OutContent = resp.ResponseIntegerErr
-				return nil
-			}
-			end, err = strconv.Atoi(util.BytesToString(ctx.Args[2]))
-			if err != nil {
-				ctx.OutContent = resp.ResponseIntegerErr
-				return nil
-			}
-		case 1:
-			begin = 0
-			end = len(val) - 1
-		default:
-			ctx.OutContent = resp.ResponseSyntaxErr
+	start, end := int64(0), int64(-1)
+	isBit := false
+	switch len(ctx.Args) {
+	case 1:
+	case 3, 4:
+		var err error
+		start, err = strconv.ParseInt(util.BytesToString(ctx.Args[1]), 10, 64)
+		if err != nil {
+			ctx.OutContent = resp.ResponseIntegerErr
 			return nil
 		}
-
-		count, err := bitCount(val, begin, end)
+		end, err = strconv.ParseInt(util.BytesToString(ctx.Args[2]), 10, 64)
 		if err != nil {
-			ctx.OutContent = resp.EncError("ERR " + err.Error())
-		} else {
-			ctx.OutContent = resp.EncInteger(int64(count))
+			ctx.OutContent = resp.ResponseIntegerErr
+			return nil
+		}
+		if len(ctx.Args) == 4 {
+			var ok bool
+			if isBit, ok = parseBitUnit(ctx.Args[3]); !ok {
+				ctx.OutContent = resp.ResponseSyntaxErr
+				return nil
+			}
 		}
+	default:
+		ctx.OutContent = resp.ResponseSyntaxErr
+		return nil
+	}
+
+	val, err := ctx.CodecCtx.DB.Storage.Get(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, ctx.Args[0])
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else if val == nil || (start < 0 && end < 0 && start > end) {
+		ctx.OutContent = resp.EncInteger(0)
+	} else {
+		start, end = bitRange(len(val), start, end, isBit)
+		ctx.OutContent = resp.EncInteger(countBits(val, start, end))
 	}
 	return nil
 }
@@ -349,7 +351,7 @@ func SetRange(ctx *CmdContext) error {
 	// 1. get
 	val, err := ctx.CodecCtx.DB.Storage.Get(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 		return nil
 	}
 
@@ -363,7 +365,7 @@ func SetRange(ctx *CmdContext) error {
 	// 3. insert
 	err = ctx.CodecCtx.DB.Storage.Set(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, resBytes)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	} else {
 		ctx.OutContent = resp.EncInteger(int64(len(resBytes)))
 	}
@@ -376,7 +378,7 @@ func GetRange(ctx *CmdContext) error {
 
 	val, err := ctx.CodecCtx.DB.Storage.Get(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 		return nil
 	}
 
@@ -412,7 +414,7 @@ func StringCmdWithKey(ctx *CmdContext) error {
 	}
 	ctx.OutContent, err = ctx.CodecCtx.DB.Storage.ObServerCmd(ctx.CodecCtx.DB.Ctx, stringTableName, rowKey, ctx.PlainReq)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
 	}
 	return nil
 }
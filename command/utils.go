@@ -18,10 +18,16 @@ package command
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"math/bits"
+	"strconv"
+	"strings"
 
 	"github.com/oceanbase/modis/connection/conncontext"
 	"github.com/oceanbase/modis/log"
-	"github.com/oceanbase/modis/storage/obkv"
+	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/oceanbase/modis/storage"
 )
 
 const (
@@ -33,40 +39,82 @@ const (
 	indexColumnName  = "index"
 )
 
-func bitCount(bytes []byte, start, end int) (int, error) {
-	length := len(bytes)
-	if end < 0 {
-		end = length + end
+// encStorageError encodes an error returned by storage, redis error replies
+// (e.g. from the observer) are returned as is
+func encStorageError(err error) string {
+	var errReply resp.ErrorReply
+	if errors.As(err, &errReply) {
+		return resp.EncError(errReply.Error())
 	}
+	return resp.EncError("ERR " + err.Error())
+}
 
+// bitRange converts the start and end of BITCOUNT and BITPOS, in bytes or in bits, to the offsets
+// of the first and the last bit of a string of length bytes, start > end if the range is empty
+func bitRange(length int, start, end int64, isBit bool) (int64, int64) {
+	total := int64(length)
+	if isBit {
+		total <<= 3
+	}
 	if start < 0 {
-		start = length + start
+		start += total
 	}
-
-	if start > end || start >= length {
-		return 0, nil
+	if end < 0 {
+		end += total
 	}
-
 	if start < 0 {
 		start = 0
 	}
-
 	if end < 0 {
 		end = 0
-	} else if end >= length {
-		end = length - 1
 	}
+	if end >= total {
+		end = total - 1
+	}
+	if !isBit {
+		start, end = start<<3, end<<3+7
+	}
+	return start, end
+}
 
-	count := 0
-	for i := start; i <= end; i++ {
-		for j := 0; j < 8; j++ {
-			if (bytes[i]>>j)&1 == 1 {
-				count++
-			}
+// bitAt returns the bit at offset of bytes, the most significant bit of a byte comes first
+func bitAt(bytes []byte, offset int64) byte {
+	return (bytes[offset>>3] >> (7 - offset&7)) & 1
+}
+
+// countBits counts the set bits of bytes from the bit start to the bit end
+func countBits(bytes []byte, start, end int64) int64 {
+	var count int64
+	for start <= end {
+		if start&7 == 0 && end-start >= 7 {
+			count += int64(bits.OnesCount8(bytes[start>>3]))
+			start += 8
+		} else {
+			count += int64(bitAt(bytes, start))
+			start++
 		}
 	}
+	return count
+}
 
-	return count, nil
+// bitPos returns the offset of the first bit of bytes equal to bit from the bit start to the bit end,
+// or -1 if there is none
+func bitPos(bytes []byte, bit byte, start, end int64) int64 {
+	skip := byte(0)
+	if bit == 0 {
+		skip = 0xff
+	}
+	for start <= end {
+		if start&7 == 0 && end-start >= 7 && bytes[start>>3] == skip {
+			start += 8
+			continue
+		}
+		if bitAt(bytes, start) == bit {
+			return start
+		}
+		start++
+	}
+	return -1
 }
 
 func getRange(bytes []byte, start, end int) []byte {
@@ -184,6 +232,15 @@ func clientFlag2Str(flag conncontext.ClientFlag) string {
 	if (flag & conncontext.ClientMonitor) != 0 {
 		flagStr += "O"
 	}
+	if (flag & conncontext.ClientPubSub) != 0 {
+		flagStr += "P"
+	}
+	if (flag & conncontext.ClientBlocked) != 0 {
+		flagStr += "b"
+	}
+	if (flag & conncontext.ClientMulti) != 0 {
+		flagStr += "x"
+	}
 	if flagStr == "" {
 		flagStr = "N"
 	}
@@ -191,7 +248,7 @@ func clientFlag2Str(flag conncontext.ClientFlag) string {
 }
 
 func getDBInfo(ctx *CmdContext, db int64) (*DBInfo, error) {
-	var tbInfo *obkv.TableInfo
+	var tbInfo *storage.TableInfo
 	var err error
 	dbInfo := &DBInfo{Keys: 0, Expires: 0}
 	for _, tbName := range tables {
@@ -207,6 +264,61 @@ func getDBInfo(ctx *CmdContext, db int64) (*DBInfo, error) {
 	return dbInfo, nil
 }
 
+// flushDB deletes all data of db from the tables, the progress is logged table by table
+func flushDB(stor storage.Storage, traceID string, db int64) error {
+	for _, tbName := range tables {
+		deleted, err := stor.FlushTable(context.Background(), db, tbName)
+		if err != nil {
+			log.Warn("command", traceID, "fail to flush table",
+				log.Errors(err), log.Int64("db", db), log.String("table name", tbName), log.Int64("deleted", deleted))
+			return err
+		}
+		log.Info("command", traceID, "flush table ended",
+			log.Int64("db", db), log.String("table name", tbName), log.Int64("deleted", deleted))
+	}
+	// for the clients watching keys of db through other modis
+	return stor.TouchDB(context.Background(), db)
+}
+
+// parseFlushMode parses the optional ASYNC|SYNC argument of FLUSHDB and FLUSHALL
+func parseFlushMode(args [][]byte) (async bool, ok bool) {
+	if len(args) == 0 {
+		return false, true
+	}
+	if len(args) > 1 {
+		return false, false
+	}
+	switch strings.ToLower(string(args[0])) {
+	case "async":
+		return true, true
+	case "sync":
+		return false, true
+	}
+	return false, false
+}
+
+// parseDBIndex parses a db index argument, the error reply is set if it is not a valid db
+func parseDBIndex(ctx *CmdContext, arg []byte) (int64, bool) {
+	idx, err := strconv.ParseInt(string(arg), 10, 64)
+	if err != nil {
+		ctx.OutContent = resp.ResponseIntegerErr
+		return 0, false
+	}
+	if idx < 0 || idx >= ctx.ServCtx.DbNum {
+		ctx.OutContent = resp.EncError("ERR DB index is out of range")
+		return 0, false
+	}
+	return idx, true
+}
+
+// encBool encodes b as the integer reply 1 or 0
+func encBool(b bool) string {
+	if b {
+		return resp.EncInteger(1)
+	}
+	return resp.EncInteger(0)
+}
+
 func replaceWithRedacted(arg []byte) {
 	red := []byte("(redacted)")
 	if !bytes.Equal(arg, red) {
@@ -17,125 +17,987 @@
 package command
 
 import (
-	"github.com/oceanbase/modis/protocol/resp"
-	"github.com/oceanbase/obkv-table-client-go/table"
-	"github.com/oceanbase/obkv-table-client-go/util"
+	"fmt"
 	"math"
 	"strconv"
 	"strings"
+
+	"github.com/oceanbase/obkv-table-client-go/util"
+
+	"github.com/oceanbase/modis/connection/conncontext"
+	"github.com/oceanbase/modis/log"
+	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/oceanbase/modis/storage"
 )
 
 const (
 	zsetTableName = "modis_zset_table"
 )
 
-func ZSetCmdWithKey(ctx *CmdContext) error {
+// parseFloat parses a score or weight like redis does, NaN is not a valid float
+func parseFloat(b []byte) (float64, bool) {
+	f, err := strconv.ParseFloat(util.BytesToString(b), 64)
+	if err != nil || math.IsNaN(f) {
+		return 0, false
+	}
+	return f, true
+}
+
+// formatScore formats a score the way redis replies it, i.e. the shortest
+// representation that "%.17g" would produce
+func formatScore(f float64) string {
+	if math.IsInf(f, 1) {
+		return "inf"
+	} else if math.IsInf(f, -1) {
+		return "-inf"
+	}
+	exp := 0
+	if f != 0 {
+		exp = int(math.Floor(math.Log10(math.Abs(f))))
+	}
+	if exp < -4 || exp >= 17 {
+		return strconv.FormatFloat(f, 'e', -1, 64)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// parseScoreBound parses the min or max of a score range, "(" prefix means exclusive
+func parseScoreBound(b []byte) (storage.ScoreBound, bool) {
+	bound := storage.ScoreBound{}
+	if len(b) > 0 && b[0] == '(' {
+		bound.Exclusive = true
+		b = b[1:]
+	}
+	score, ok := parseFloat(b)
+	bound.Score = score
+	return bound, ok
+}
+
+// parseLexBound parses the min or max of a member range, "[" prefix means inclusive, "(" prefix means
+// exclusive, "-" and "+" are the lowest and the highest
+func parseLexBound(b []byte) (storage.LexBound, bool) {
+	if len(b) == 1 && b[0] == '-' {
+		return storage.LexBound{Inf: -1}, true
+	} else if len(b) == 1 && b[0] == '+' {
+		return storage.LexBound{Inf: 1}, true
+	} else if len(b) > 0 && (b[0] == '[' || b[0] == '(') {
+		return storage.LexBound{Member: b[1:], Exclusive: b[0] == '('}, true
+	}
+	return storage.LexBound{}, false
+}
+
+// parseLexRange parses the min and max of a member range, returns a RESP error if they are invalid
+func parseLexRange(minArg []byte, maxArg []byte) (storage.LexBound, storage.LexBound, string) {
+	min, ok1 := parseLexBound(minArg)
+	max, ok2 := parseLexBound(maxArg)
+	if !ok1 || !ok2 {
+		return min, max, resp.EncError("ERR min or max not valid string range item")
+	}
+	return min, max, ""
+}
+
+// encZMembers encodes members with optional scores as a RESP array
+func encZMembers(members []storage.ZMember, withScores bool) string {
+	res := make([][]byte, 0, 2*len(members))
+	for _, m := range members {
+		res = append(res, m.Member)
+		if withScores {
+			res = append(res, []byte(formatScore(m.Score)))
+		}
+	}
+	return resp.EncArray(res)
+}
+
+// ZAdd adds all the specified members with the specified scores to the sorted set stored at key
+func ZAdd(ctx *CmdContext) error {
 	key := ctx.Args[0]
-	var err error
-	rowKey := []*table.Column{
-		table.NewColumn(dbColumnName, ctx.CodecCtx.DB.ID),
-		table.NewColumn(keyColumnName, key),
+	flags := storage.ZAddFlags{}
+	incr := false
+	idx := 1
+options:
+	for ; idx < len(ctx.Args); idx++ {
+		switch strings.ToLower(util.BytesToString(ctx.Args[idx])) {
+		case "nx":
+			flags.NX = true
+		case "xx":
+			flags.XX = true
+		case "gt":
+			flags.GT = true
+		case "lt":
+			flags.LT = true
+		case "ch":
+			flags.CH = true
+		case "incr":
+			incr = true
+		default:
+			break options
+		}
+	}
+	scoreMembers := ctx.Args[idx:]
+	if len(scoreMembers) == 0 || len(scoreMembers)%2 != 0 {
+		ctx.OutContent = resp.ResponseSyntaxErr
+		return nil
 	}
-	ctx.OutContent, err = ctx.CodecCtx.DB.Storage.ObServerCmd(ctx.CodecCtx.DB.Ctx, zsetTableName, rowKey, ctx.PlainReq)
+	if flags.NX && flags.XX {
+		ctx.OutContent = resp.EncError("ERR XX and NX options at the same time are not compatible")
+		return nil
+	}
+	if (flags.GT && flags.NX) || (flags.LT && flags.NX) || (flags.GT && flags.LT) {
+		ctx.OutContent = resp.EncError("ERR GT, LT, and/or NX options at the same time are not compatible")
+		return nil
+	}
+	if incr && len(scoreMembers) > 2 {
+		ctx.OutContent = resp.EncError("ERR INCR option supports a single increment-element pair")
+		return nil
+	}
+	members := make([]storage.ZMember, 0, len(scoreMembers)/2)
+	for i := 0; i < len(scoreMembers); i += 2 {
+		score, ok := parseFloat(scoreMembers[i])
+		if !ok {
+			ctx.OutContent = resp.ResponseFloatErr
+			return nil
+		}
+		members = append(members, storage.ZMember{Member: scoreMembers[i+1], Score: score})
+	}
+
+	if incr {
+		return zAddIncr(ctx, key, members[0], flags)
+	}
+	added, changed, err := ctx.CodecCtx.DB.Storage.ZAdd(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, members, flags)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
+		return nil
+	}
+	if added+changed == 0 {
+		ctx.Modified = [][]byte{}
+	} else {
+		ctx.ServCtx.NotifyKeyspaceEvent(conncontext.NotifyZSet, "zadd", ctx.CodecCtx.DB.ID, key)
+	}
+	if flags.CH {
+		added += changed
 	}
+	ctx.OutContent = resp.EncInteger(added)
 	return nil
 }
 
+// zAddIncr increments the score of member like ZINCRBY as flags allow, replies nil if flags prevent it
+func zAddIncr(ctx *CmdContext, key []byte, member storage.ZMember, flags storage.ZAddFlags) error {
+	score, ok, err := ctx.CodecCtx.DB.Storage.ZAddIncr(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, member.Member, member.Score, flags)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else if !ok {
+		ctx.Modified = [][]byte{}
+		ctx.OutContent = resp.EncNullBulkString()
+	} else {
+		ctx.ServCtx.NotifyKeyspaceEvent(conncontext.NotifyZSet, "zincr", ctx.CodecCtx.DB.ID, key)
+		ctx.OutContent = resp.EncBulkString(formatScore(score))
+	}
+	return nil
+}
+
+// ZIncrBy increments the score of member in the sorted set stored at key by increment
 func ZIncrBy(ctx *CmdContext) error {
-	var err error
-	rowKey := []*table.Column{
-		table.NewColumn(dbColumnName, ctx.CodecCtx.DB.ID),
-		table.NewColumn(keyColumnName, ctx.Args[0]),
-		table.NewColumn(memberColumnName, ctx.Args[2]),
+	key := ctx.Args[0]
+	incr, ok := parseFloat(ctx.Args[1])
+	if !ok {
+		ctx.OutContent = resp.ResponseFloatErr
+		return nil
 	}
-	ctx.OutContent, err = ctx.CodecCtx.DB.Storage.ObServerCmd(ctx.CodecCtx.DB.Ctx, zsetTableName, rowKey, ctx.PlainReq)
+	member := ctx.Args[2]
+
+	score, err := ctx.CodecCtx.DB.Storage.ZIncrBy(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, member, incr)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncBulkString(formatScore(score))
 	}
 	return nil
 }
 
-func ZSetCmdWithKeyMember(ctx *CmdContext) error {
-	var err error
-	rowKey := []*table.Column{
-		table.NewColumn(dbColumnName, ctx.CodecCtx.DB.ID),
-		table.NewColumn(keyColumnName, ctx.Args[0]),
-		table.NewColumn(memberColumnName, ctx.Args[1]),
+// ZRem removes the specified members from the sorted set stored at key
+func ZRem(ctx *CmdContext) error {
+	key := ctx.Args[0]
+	members := ctx.Args[1:]
+
+	removed, err := ctx.CodecCtx.DB.Storage.ZRem(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, members)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(removed)
 	}
-	ctx.OutContent, err = ctx.CodecCtx.DB.Storage.ObServerCmd(ctx.CodecCtx.DB.Ctx, zsetTableName, rowKey, ctx.PlainReq)
+	return nil
+}
+
+// ZCard returns the number of members of the sorted set stored at key
+func ZCard(ctx *CmdContext) error {
+	key := ctx.Args[0]
+
+	size, err := ctx.CodecCtx.DB.Storage.ZCard(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(size)
 	}
 	return nil
 }
 
+// ZScore returns the score of member in the sorted set stored at key
+func ZScore(ctx *CmdContext) error {
+	key := ctx.Args[0]
+	member := ctx.Args[1]
+
+	score, ok, err := ctx.CodecCtx.DB.Storage.ZScore(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, member)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else if !ok {
+		ctx.OutContent = resp.EncNullBulkString()
+	} else {
+		ctx.OutContent = resp.EncBulkString(formatScore(score))
+	}
+	return nil
+}
+
+// zPopEvent returns the keyspace event of popping from the lowest or the highest scores
+func zPopEvent(reverse bool) string {
+	if reverse {
+		return "zpopmax"
+	}
+	return "zpopmin"
+}
+
+// zPop pops at most count members from the lowest or the highest scores of the sorted set
+func zPop(ctx *CmdContext, key []byte, count int64, reverse bool) ([]storage.ZMember, error) {
+	members, err := ctx.CodecCtx.DB.Storage.ZPop(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, count, reverse)
+	if err != nil && len(members) != 0 {
+		// the members already removed are replied, or they would be lost
+		log.Warn("command", ctx.TraceID, "fail to pop all the members", log.Errors(err),
+			log.String("key", string(key)), log.Int("popped", len(members)))
+		return members, nil
+	}
+	if err == nil && len(members) == 0 {
+		// nothing is popped
+		ctx.Modified = [][]byte{}
+	}
+	return members, err
+}
+
+func zPopCommand(ctx *CmdContext, reverse bool) error {
+	key := ctx.Args[0]
+	count := int64(1)
+	if len(ctx.Args) > 2 {
+		ctx.OutContent = resp.ResponseSyntaxErr
+		return nil
+	} else if len(ctx.Args) == 2 {
+		var err error
+		count, err = strconv.ParseInt(util.BytesToString(ctx.Args[1]), 10, 64)
+		if err != nil || count < 0 {
+			ctx.OutContent = resp.EncError("ERR value is out of range, must be positive")
+			return nil
+		}
+	}
+
+	members, err := zPop(ctx, key, count, reverse)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = encZMembers(members, true)
+	}
+	return nil
+}
+
+// ZPopMin removes and returns up to count members with the lowest scores in the sorted set stored at key
+func ZPopMin(ctx *CmdContext) error {
+	return zPopCommand(ctx, false)
+}
+
+// ZPopMax removes and returns up to count members with the highest scores in the sorted set stored at key
+func ZPopMax(ctx *CmdContext) error {
+	return zPopCommand(ctx, true)
+}
+
+// parseZPopWhere parses MIN or MAX, returns true for MAX
+func parseZPopWhere(arg []byte) (reverse bool, ok bool) {
+	switch strings.ToLower(util.BytesToString(arg)) {
+	case "min":
+		return false, true
+	case "max":
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+// ZMPop pops up to count members from the first non-empty sorted set of the keys
+func ZMPop(ctx *CmdContext) error {
+	numKeys, err := strconv.ParseInt(util.BytesToString(ctx.Args[0]), 10, 64)
+	if err != nil {
+		ctx.OutContent = resp.ResponseIntegerErr
+		return nil
+	}
+	if numKeys <= 0 {
+		ctx.OutContent = resp.EncError("ERR numkeys should be greater than 0")
+		return nil
+	}
+	if numKeys > int64(len(ctx.Args)-2) {
+		ctx.OutContent = resp.ResponseSyntaxErr
+		return nil
+	}
+	keys := ctx.Args[1 : 1+numKeys]
+	reverse, ok := parseZPopWhere(ctx.Args[1+numKeys])
+	if !ok {
+		ctx.OutContent = resp.ResponseSyntaxErr
+		return nil
+	}
+	count := int64(1)
+	opts := ctx.Args[2+numKeys:]
+	if len(opts) != 0 {
+		if len(opts) != 2 || !strings.EqualFold(util.BytesToString(opts[0]), "count") {
+			ctx.OutContent = resp.ResponseSyntaxErr
+			return nil
+		}
+		count, err = strconv.ParseInt(util.BytesToString(opts[1]), 10, 64)
+		if err != nil || count <= 0 {
+			ctx.OutContent = resp.EncError("ERR count should be greater than 0")
+			return nil
+		}
+	}
+
+	for _, key := range keys {
+		members, err := zPop(ctx, key, count, reverse)
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+		if len(members) != 0 {
+			ctx.Modified = [][]byte{key}
+			ctx.ServCtx.NotifyKeyspaceEvent(conncontext.NotifyZSet, zPopEvent(reverse), ctx.CodecCtx.DB.ID, key)
+			var out strings.Builder
+			out.WriteString(resp.ArrayFlag + "2" + resp.CRLF)
+			out.WriteString(resp.EncBulkString(util.BytesToString(key)))
+			out.WriteString(resp.ArrayFlag + strconv.Itoa(len(members)) + resp.CRLF)
+			for _, m := range members {
+				out.WriteString(resp.EncArray([][]byte{m.Member, []byte(formatScore(m.Score))}))
+			}
+			ctx.OutContent = out.String()
+			return nil
+		}
+	}
+	ctx.OutContent = resp.ResponsesNullArray
+	return nil
+}
+
+// BZPopMin is the blocking version of ZPOPMIN, it pops from the first non-empty sorted set of the keys
+func BZPopMin(ctx *CmdContext) error {
+	return blockingZPop(ctx, false)
+}
+
+// BZPopMax is the blocking version of ZPOPMAX, it pops from the first non-empty sorted set of the keys
+func BZPopMax(ctx *CmdContext) error {
+	return blockingZPop(ctx, true)
+}
+
+// blockingZPop pops a member from the lowest or the highest scores of the first non-empty sorted set,
+// the client is blocked if all sorted sets are empty
+func blockingZPop(ctx *CmdContext, reverse bool) error {
+	keys := ctx.Args[:len(ctx.Args)-1]
+	timeout, errReply := parseBlockTimeout(ctx.Args[len(ctx.Args)-1])
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+
+	for _, key := range keys {
+		members, err := zPop(ctx, key, 1, reverse)
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+		if len(members) != 0 {
+			ctx.Modified = [][]byte{key}
+			ctx.OutContent = resp.EncArray([][]byte{key, members[0].Member, []byte(formatScore(members[0].Score))})
+			return nil
+		}
+	}
+	ctx.OutContent = resp.ResponsesNullArray
+	ctx.block = &blockSpec{keys: keys, timeout: timeout}
+	return nil
+}
+
+// ZMScore returns the scores of members in the sorted set stored at key
+func ZMScore(ctx *CmdContext) error {
+	key := ctx.Args[0]
+	scores := make([][]byte, 0, len(ctx.Args)-1)
+	for _, member := range ctx.Args[1:] {
+		score, ok, err := ctx.CodecCtx.DB.Storage.ZScore(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, member)
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+			return nil
+		}
+		if ok {
+			scores = append(scores, []byte(formatScore(score)))
+		} else {
+			scores = append(scores, nil)
+		}
+	}
+	ctx.OutContent = resp.EncArray(scores)
+	return nil
+}
+
+// ZRandMember returns random members of the sorted set stored at key, count < 0 allows the same
+// member to be returned multiple times
+func ZRandMember(ctx *CmdContext) error {
+	key := ctx.Args[0]
+	if len(ctx.Args) == 1 {
+		members, err := ctx.CodecCtx.DB.Storage.ZRandMember(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, 1)
+		if err != nil {
+			ctx.OutContent = encStorageError(err)
+		} else if len(members) == 0 {
+			ctx.OutContent = resp.EncNullBulkString()
+		} else {
+			ctx.OutContent = resp.EncBulkString(util.BytesToString(members[0].Member))
+		}
+		return nil
+	}
+
+	withScores := false
+	if len(ctx.Args) == 3 && strings.EqualFold(util.BytesToString(ctx.Args[2]), "withscores") {
+		withScores = true
+	} else if len(ctx.Args) != 2 {
+		ctx.OutContent = resp.ResponseSyntaxErr
+		return nil
+	}
+	count, err := strconv.ParseInt(util.BytesToString(ctx.Args[1]), 10, 64)
+	if err != nil {
+		ctx.OutContent = resp.ResponseIntegerErr
+		return nil
+	}
+	if count < -math.MaxInt64 || (withScores && count < -math.MaxInt64/2) {
+		ctx.OutContent = resp.EncError("ERR value is out of range")
+		return nil
+	}
+
+	members, err := ctx.CodecCtx.DB.Storage.ZRandMember(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, count)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = encZMembers(members, withScores)
+	}
+	return nil
+}
+
+func zRank(ctx *CmdContext, reverse bool) error {
+	key := ctx.Args[0]
+	member := ctx.Args[1]
+
+	rank, ok, err := ctx.CodecCtx.DB.Storage.ZRank(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, member, reverse)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else if !ok {
+		ctx.OutContent = resp.EncNullBulkString()
+	} else {
+		ctx.OutContent = resp.EncInteger(rank)
+	}
+	return nil
+}
+
+// ZRank returns the rank of member in the sorted set stored at key, with the scores ordered from low to high
+func ZRank(ctx *CmdContext) error {
+	return zRank(ctx, false)
+}
+
+// ZRevRank returns the rank of member in the sorted set stored at key, with the scores ordered from high to low
+func ZRevRank(ctx *CmdContext) error {
+	return zRank(ctx, true)
+}
+
+// ZRemRangeByRank removes all members in the sorted set stored at key with rank between start and stop
+func ZRemRangeByRank(ctx *CmdContext) error {
+	key := ctx.Args[0]
+	start, err := strconv.ParseInt(util.BytesToString(ctx.Args[1]), 10, 64)
+	if err != nil {
+		ctx.OutContent = resp.ResponseIntegerErr
+		return nil
+	}
+	stop, err := strconv.ParseInt(util.BytesToString(ctx.Args[2]), 10, 64)
+	if err != nil {
+		ctx.OutContent = resp.ResponseIntegerErr
+		return nil
+	}
+
+	removed, err := ctx.CodecCtx.DB.Storage.ZRemRangeByRank(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, start, stop)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(removed)
+	}
+	return nil
+}
+
+// ZCount returns the number of members in the sorted set at key with a score between min and max
+func ZCount(ctx *CmdContext) error {
+	key := ctx.Args[0]
+	min, ok1 := parseScoreBound(ctx.Args[1])
+	max, ok2 := parseScoreBound(ctx.Args[2])
+	if !ok1 || !ok2 {
+		ctx.OutContent = resp.EncError("ERR min or max is not a float")
+		return nil
+	}
+
+	count, err := ctx.CodecCtx.DB.Storage.ZCount(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, min, max)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(count)
+	}
+	return nil
+}
+
+// ZRemRangeByScore removes all members in the sorted set stored at key with a score between min and max
+func ZRemRangeByScore(ctx *CmdContext) error {
+	key := ctx.Args[0]
+	min, ok1 := parseScoreBound(ctx.Args[1])
+	max, ok2 := parseScoreBound(ctx.Args[2])
+	if !ok1 || !ok2 {
+		ctx.OutContent = resp.EncError("ERR min or max is not a float")
+		return nil
+	}
+
+	removed, err := ctx.CodecCtx.DB.Storage.ZRemRangeByScore(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, min, max)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(removed)
+	}
+	return nil
+}
+
+// zRangeType is the kind of range of the range commands
+type zRangeType int
+
+const (
+	// the range type is given by the options of ZRANGE and ZRANGESTORE
+	zRangeAuto zRangeType = iota
+	zRangeRank
+	zRangeScore
+	zRangeLex
+)
+
+// zRangeSpec is a parsed range of ZRANGE, ZRANGESTORE and the range commands before them
+type zRangeSpec struct {
+	rangeType  zRangeType
+	reverse    bool
+	start      int64
+	stop       int64
+	minScore   storage.ScoreBound
+	maxScore   storage.ScoreBound
+	minLex     storage.LexBound
+	maxLex     storage.LexBound
+	offset     int64
+	count      int64
+	withScores bool
+}
+
+// parseZRange parses min max [BYSCORE|BYLEX] [REV] [LIMIT offset count] [WITHSCORES]. BYSCORE, BYLEX and REV
+// are only accepted if rangeType is zRangeAuto, WITHSCORES is not accepted by store commands
+func parseZRange(args [][]byte, rangeType zRangeType, reverse bool, store bool) (*zRangeSpec, string) {
+	spec := &zRangeSpec{rangeType: rangeType, reverse: reverse, count: -1}
+	revOption := rangeType == zRangeAuto
+	for idx := 2; idx < len(args); idx++ {
+		option := strings.ToLower(util.BytesToString(args[idx]))
+		if option == "withscores" && !store {
+			spec.withScores = true
+		} else if option == "limit" && idx+2 < len(args) {
+			var err error
+			spec.offset, err = strconv.ParseInt(util.BytesToString(args[idx+1]), 10, 64)
+			if err != nil {
+				return nil, resp.ResponseIntegerErr
+			}
+			spec.count, err = strconv.ParseInt(util.BytesToString(args[idx+2]), 10, 64)
+			if err != nil {
+				return nil, resp.ResponseIntegerErr
+			}
+			idx += 2
+		} else if option == "rev" && revOption && !spec.reverse {
+			spec.reverse = true
+		} else if option == "byscore" && spec.rangeType == zRangeAuto {
+			spec.rangeType = zRangeScore
+		} else if option == "bylex" && spec.rangeType == zRangeAuto {
+			spec.rangeType = zRangeLex
+		} else {
+			return nil, resp.ResponseSyntaxErr
+		}
+	}
+	if spec.rangeType == zRangeAuto {
+		spec.rangeType = zRangeRank
+	}
+	if (spec.offset != 0 || spec.count != -1) && spec.rangeType == zRangeRank {
+		return nil, resp.EncError("ERR syntax error, LIMIT is only supported in combination with either BYSCORE or BYLEX")
+	}
+	if spec.withScores && spec.rangeType == zRangeLex {
+		return nil, resp.EncError("ERR syntax error, WITHSCORES not supported in combination with BYLEX")
+	}
+
+	minArg, maxArg := args[0], args[1]
+	if spec.reverse && spec.rangeType != zRangeRank {
+		minArg, maxArg = maxArg, minArg
+	}
+	switch spec.rangeType {
+	case zRangeScore:
+		var ok1, ok2 bool
+		spec.minScore, ok1 = parseScoreBound(minArg)
+		spec.maxScore, ok2 = parseScoreBound(maxArg)
+		if !ok1 || !ok2 {
+			return nil, resp.EncError("ERR min or max is not a float")
+		}
+	case zRangeLex:
+		var errReply string
+		spec.minLex, spec.maxLex, errReply = parseLexRange(minArg, maxArg)
+		if errReply != "" {
+			return nil, errReply
+		}
+	default:
+		var err1, err2 error
+		spec.start, err1 = strconv.ParseInt(util.BytesToString(minArg), 10, 64)
+		spec.stop, err2 = strconv.ParseInt(util.BytesToString(maxArg), 10, 64)
+		if err1 != nil || err2 != nil {
+			return nil, resp.ResponseIntegerErr
+		}
+	}
+	return spec, ""
+}
+
+// zRangeMembers returns the members of the sorted set stored at key in the range
+func zRangeMembers(ctx *CmdContext, key []byte, spec *zRangeSpec) ([]storage.ZMember, error) {
+	db := ctx.CodecCtx.DB
+	switch spec.rangeType {
+	case zRangeScore:
+		if spec.offset < 0 || spec.count == 0 {
+			return []storage.ZMember{}, nil
+		}
+		return db.Storage.ZRangeByScore(db.Ctx, db.ID, key, spec.minScore, spec.maxScore, spec.reverse, spec.offset, spec.count)
+	case zRangeLex:
+		return db.Storage.ZRangeByLex(db.Ctx, db.ID, key, spec.minLex, spec.maxLex, spec.reverse, spec.offset, spec.count)
+	default:
+		return db.Storage.ZRange(db.Ctx, db.ID, key, spec.start, spec.stop, spec.reverse)
+	}
+}
+
+// zRangeCommand replies the members in the range given by the arguments after the key
+func zRangeCommand(ctx *CmdContext, rangeType zRangeType, reverse bool) error {
+	spec, errReply := parseZRange(ctx.Args[1:], rangeType, reverse, false)
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+
+	members, err := zRangeMembers(ctx, ctx.Args[0], spec)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = encZMembers(members, spec.withScores)
+	}
+	return nil
+}
+
+// ZRange returns the specified range of members in the sorted set stored at key, the range is
+// by rank, by score or lexicographical
+func ZRange(ctx *CmdContext) error {
+	return zRangeCommand(ctx, zRangeAuto, false)
+}
+
+// ZRevRange returns the specified range of members in the sorted set stored at key, ordered from high to low scores
+func ZRevRange(ctx *CmdContext) error {
+	return zRangeCommand(ctx, zRangeRank, true)
+}
+
+// ZRangeByScore returns all the members in the sorted set at key with a score between min and max,
+// ordered from low to high scores
 func ZRangeByScore(ctx *CmdContext) error {
-	var err error
-	rowKey := []*table.Column{
-		table.NewColumn(dbColumnName, ctx.CodecCtx.DB.ID),
-		table.NewColumn(keyColumnName, ctx.Args[0]),
-		table.NewColumn(memberColumnName, ctx.Args[1]),
-	}
-	is_count_less_zero := false
-	if len(ctx.Args) >= 4 {
-		idx := 3
-		for idx < len(ctx.Args) {
-			option := util.BytesToString(ctx.Args[idx])
-			idx++
-			if strings.EqualFold(option, "withscores") {
-			} else if strings.EqualFold(option, "limit") {
-				if (len(ctx.Args) - idx) < 2 {
-					ctx.OutContent = resp.ResponseSyntaxErr
-					return nil
-				} else {
-					offset, err := strconv.Atoi(util.BytesToString(ctx.Args[idx]))
-					idx++
-					if err != nil {
-						ctx.OutContent = resp.ResponseIntegerErr
-						return nil
-					}
-					if offset < 0 {
-						var empty_arr [][]byte
-						ctx.OutContent = resp.EncArray(empty_arr)
-						return nil
-					}
-					count, err := strconv.Atoi(util.BytesToString(ctx.Args[idx]))
-					if err != nil {
-						ctx.OutContent = resp.ResponseIntegerErr
-						return nil
-					}
-					if count == 0 {
-						var empty_arr [][]byte
-						ctx.OutContent = resp.EncArray(empty_arr)
-						return nil
-					}
-					if count < 0 {
-						is_count_less_zero = true
-						count = math.MaxInt32
-						count_str := strconv.Itoa(count)
-						ctx.Args[idx] = []byte(count_str)
-					}
-					idx++
+	return zRangeCommand(ctx, zRangeScore, false)
+}
+
+// ZRevRangeByScore returns all the members in the sorted set at key with a score between max and min,
+// ordered from high to low scores
+func ZRevRangeByScore(ctx *CmdContext) error {
+	return zRangeCommand(ctx, zRangeScore, true)
+}
+
+// ZRangeByLex returns all the members in the sorted set at key between min and max, ordered
+// lexicographically, the members are expected to have the same score
+func ZRangeByLex(ctx *CmdContext) error {
+	return zRangeCommand(ctx, zRangeLex, false)
+}
+
+// ZRevRangeByLex returns all the members in the sorted set at key between max and min, ordered
+// lexicographically from high to low, the members are expected to have the same score
+func ZRevRangeByLex(ctx *CmdContext) error {
+	return zRangeCommand(ctx, zRangeLex, true)
+}
+
+// ZRangeStore stores the specified range of members of the sorted set src in dst
+func ZRangeStore(ctx *CmdContext) error {
+	dst, src := ctx.Args[0], ctx.Args[1]
+	spec, errReply := parseZRange(ctx.Args[2:], zRangeAuto, false, true)
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+
+	members, err := zRangeMembers(ctx, src, spec)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+		return nil
+	}
+	storeZMembers(ctx, dst, members, "zrangestore")
+	return nil
+}
+
+// storeZMembers overwrites dst with the members and fires event, dst is deleted if there is no member
+func storeZMembers(ctx *CmdContext, dst []byte, members []storage.ZMember, event string) {
+	deleted, err := ctx.CodecCtx.DB.Storage.Delete(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, [][]byte{dst})
+	if err == nil && len(members) != 0 {
+		_, _, err = ctx.CodecCtx.DB.Storage.ZAdd(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, dst, members, storage.ZAddFlags{})
+	}
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+		return
+	}
+	if len(members) != 0 {
+		ctx.Modified = [][]byte{dst}
+		ctx.ServCtx.NotifyKeyspaceEvent(conncontext.NotifyZSet, event, ctx.CodecCtx.DB.ID, dst)
+	} else if deleted != 0 {
+		ctx.Modified = [][]byte{dst}
+		ctx.ServCtx.NotifyKeyspaceEvent(conncontext.NotifyGeneric, "del", ctx.CodecCtx.DB.ID, dst)
+	} else {
+		ctx.Modified = [][]byte{}
+	}
+	ctx.OutContent = resp.EncInteger(int64(len(members)))
+}
+
+// ZLexCount returns the number of members in the sorted set at key between min and max
+func ZLexCount(ctx *CmdContext) error {
+	key := ctx.Args[0]
+	min, max, errReply := parseLexRange(ctx.Args[1], ctx.Args[2])
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+
+	count, err := ctx.CodecCtx.DB.Storage.ZLexCount(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, min, max)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(count)
+	}
+	return nil
+}
+
+// ZRemRangeByLex removes all members in the sorted set stored at key between min and max
+func ZRemRangeByLex(ctx *CmdContext) error {
+	key := ctx.Args[0]
+	min, max, errReply := parseLexRange(ctx.Args[1], ctx.Args[2])
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+
+	removed, err := ctx.CodecCtx.DB.Storage.ZRemRangeByLex(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, key, min, max)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(removed)
+	}
+	return nil
+}
+
+// zsetOpArgs are the parsed arguments of ZUNION, ZINTER, ZDIFF and their STORE versions
+type zsetOpArgs struct {
+	keys       [][]byte
+	weights    []float64
+	aggregate  storage.Aggregate
+	withScores bool
+}
+
+// parseZSetOpArgs parses numkeys key [key ...] [WEIGHTS weight [weight ...]] [AGGREGATE SUM|MIN|MAX] [WITHSCORES],
+// WEIGHTS and AGGREGATE are not accepted by ZDIFF, WITHSCORES is not accepted by the STORE versions
+func parseZSetOpArgs(ctx *CmdContext, args [][]byte, diff bool, store bool) (*zsetOpArgs, string) {
+	numKeys, err := strconv.ParseInt(util.BytesToString(args[0]), 10, 64)
+	if err != nil {
+		return nil, resp.ResponseIntegerErr
+	}
+	if numKeys < 1 {
+		return nil, resp.EncError(fmt.Sprintf("ERR at least 1 input key is needed for '%s' command", ctx.Name))
+	}
+	if numKeys > int64(len(args)-1) {
+		return nil, resp.ResponseSyntaxErr
+	}
+	opArgs := &zsetOpArgs{keys: args[1 : 1+numKeys], aggregate: storage.AggregateSum}
+	if !diff {
+		opArgs.weights = make([]float64, numKeys)
+		for i := range opArgs.weights {
+			opArgs.weights[i] = 1
+		}
+	}
+	for idx := 1 + int(numKeys); idx < len(args); idx++ {
+		option := util.BytesToString(args[idx])
+		remaining := len(args) - idx - 1
+		if strings.EqualFold(option, "weights") && !diff && remaining >= int(numKeys) {
+			for i := range opArgs.weights {
+				weight, ok := parseFloat(args[idx+1+i])
+				if !ok {
+					return nil, resp.EncError("ERR weight value is not a float")
 				}
-			} else {
-				ctx.OutContent = resp.ResponseSyntaxErr
-				return nil
+				opArgs.weights[i] = weight
+			}
+			idx += int(numKeys)
+		} else if strings.EqualFold(option, "aggregate") && !diff && remaining >= 1 {
+			switch strings.ToLower(util.BytesToString(args[idx+1])) {
+			case "sum":
+				opArgs.aggregate = storage.AggregateSum
+			case "min":
+				opArgs.aggregate = storage.AggregateMin
+			case "max":
+				opArgs.aggregate = storage.AggregateMax
+			default:
+				return nil, resp.ResponseSyntaxErr
 			}
+			idx++
+		} else if strings.EqualFold(option, "withscores") && !store {
+			opArgs.withScores = true
+		} else {
+			return nil, resp.ResponseSyntaxErr
+		}
+	}
+	return opArgs, ""
+}
+
+// ZUnion returns the union of numkeys sorted sets
+func ZUnion(ctx *CmdContext) error {
+	opArgs, errReply := parseZSetOpArgs(ctx, ctx.Args, false, false)
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+
+	members, err := ctx.CodecCtx.DB.Storage.ZUnion(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, opArgs.keys, opArgs.weights, opArgs.aggregate)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = encZMembers(members, opArgs.withScores)
+	}
+	return nil
+}
+
+// ZInter returns the intersection of numkeys sorted sets
+func ZInter(ctx *CmdContext) error {
+	opArgs, errReply := parseZSetOpArgs(ctx, ctx.Args, false, false)
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+
+	members, err := ctx.CodecCtx.DB.Storage.ZInter(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, opArgs.keys, opArgs.weights, opArgs.aggregate)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = encZMembers(members, opArgs.withScores)
+	}
+	return nil
+}
+
+// ZDiff returns the members of the first sorted set not in the other numkeys-1 sorted sets
+func ZDiff(ctx *CmdContext) error {
+	opArgs, errReply := parseZSetOpArgs(ctx, ctx.Args, true, false)
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
+	}
+
+	members, err := ctx.CodecCtx.DB.Storage.ZDiff(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, opArgs.keys)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = encZMembers(members, opArgs.withScores)
+	}
+	return nil
+}
+
+// ZInterCard returns the number of members in the intersection of numkeys sorted sets, counting
+// up to limit if it is given and not 0
+func ZInterCard(ctx *CmdContext) error {
+	numKeys, err := strconv.ParseInt(util.BytesToString(ctx.Args[0]), 10, 64)
+	if err != nil {
+		ctx.OutContent = resp.ResponseIntegerErr
+		return nil
+	}
+	if numKeys <= 0 {
+		ctx.OutContent = resp.EncError("ERR numkeys should be greater than 0")
+		return nil
+	}
+	if numKeys > int64(len(ctx.Args)-1) {
+		ctx.OutContent = resp.EncError("ERR Number of keys can't be greater than number of args")
+		return nil
+	}
+	keys := ctx.Args[1 : 1+numKeys]
+	var limit int64
+	opts := ctx.Args[1+numKeys:]
+	if len(opts) != 0 {
+		if len(opts) != 2 || !strings.EqualFold(util.BytesToString(opts[0]), "limit") {
+			ctx.OutContent = resp.ResponseSyntaxErr
+			return nil
+		}
+		limit, err = strconv.ParseInt(util.BytesToString(opts[1]), 10, 64)
+		if err != nil || limit < 0 {
+			ctx.OutContent = resp.EncError("ERR LIMIT can't be negative")
+			return nil
 		}
+	}
+
+	members, err := ctx.CodecCtx.DB.Storage.ZInter(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, keys, nil, storage.AggregateSum)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+		return nil
+	}
+	card := int64(len(members))
+	if limit > 0 && card > limit {
+		card = limit
+	}
+	ctx.OutContent = resp.EncInteger(card)
+	return nil
+}
 
+// ZUnionStore computes the union of numkeys sorted sets and stores the result in destination
+func ZUnionStore(ctx *CmdContext) error {
+	dst := ctx.Args[0]
+	opArgs, errReply := parseZSetOpArgs(ctx, ctx.Args[1:], false, true)
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
 	}
-	if is_count_less_zero {
-		var new_args [][]byte
-		new_args = append(new_args, []byte(ctx.FullName))
-		new_args = append(new_args, ctx.Args...)
-		ctx.PlainReq = util.StringToBytes(resp.EncArray(new_args))
+
+	size, err := ctx.CodecCtx.DB.Storage.ZUnionStore(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, dst, opArgs.keys, opArgs.weights, opArgs.aggregate)
+	if err != nil {
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(size)
+	}
+	return nil
+}
+
+// ZInterStore computes the intersection of numkeys sorted sets and stores the result in destination
+func ZInterStore(ctx *CmdContext) error {
+	dst := ctx.Args[0]
+	opArgs, errReply := parseZSetOpArgs(ctx, ctx.Args[1:], false, true)
+	if errReply != "" {
+		ctx.OutContent = errReply
+		return nil
 	}
-	ctx.OutContent, err = ctx.CodecCtx.DB.Storage.ObServerCmd(ctx.CodecCtx.DB.Ctx, zsetTableName, rowKey, ctx.PlainReq)
 
+	size, err := ctx.CodecCtx.DB.Storage.ZInterStore(ctx.CodecCtx.DB.Ctx, ctx.CodecCtx.DB.ID, dst, opArgs.keys, opArgs.weights, opArgs.aggregate)
 	if err != nil {
-		ctx.OutContent = resp.EncError("ERR " + err.Error())
+		ctx.OutContent = encStorageError(err)
+	} else {
+		ctx.OutContent = resp.EncInteger(size)
 	}
 	return nil
 }
@@ -23,6 +23,7 @@ import (
 	"os"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
@@ -57,12 +58,53 @@ type ServerConfig struct {
 	Password      string `mapstructure:"password" json:"password" yaml:"password"`
 	DBNum         int64  `mapstructure:"databases" json:"databases" yaml:"databases"`
 	Supervised    string `mapstructure:"supervised" json:"supervised" yaml:"supervised"`
+	// max execution time of a script in milliseconds, 5000 if not set
+	LuaTimeLimit int64 `mapstructure:"lua-time-limit" json:"lua-time-limit" yaml:"lua-time-limit"`
+	// interval in milliseconds of the blocked clients polling the storage, 100 if not set
+	BlockPollInterval int64 `mapstructure:"block-poll-interval" json:"block-poll-interval" yaml:"block-poll-interval"`
+	// classes of the keyspace events to publish, same as notify-keyspace-events of redis, e.g. "KEA", x is refused by the obkv backend
+	NotifyKeyspaceEvents string `mapstructure:"notify-keyspace-events" json:"notify-keyspace-events" yaml:"notify-keyspace-events"`
+	// bus delivering the messages published on a node to the subscribers of the other nodes
+	PubSubBus PubSubBusConfig `mapstructure:"pubsub-bus" json:"pubsub-bus" yaml:"pubsub-bus"`
 	TLS
 }
 
+type PubSubBusConfig struct {
+	// address to receive the messages of the other nodes, the bus is disabled if empty
+	Listen string `mapstructure:"listen" json:"listen" yaml:"listen"`
+	// bus addresses of all nodes, the address of this node is allowed and skipped,
+	// so that every node shares the same list
+	Peers []string `mapstructure:"peers" json:"peers" yaml:"peers"`
+	// password the nodes authenticate each other with, the server password if empty,
+	// the bus is not started if both are empty
+	Password string `mapstructure:"password" json:"password" yaml:"password"`
+}
+
 type StorageConfig struct {
 	Backend    string            `mapstructure:"backend" json:"backend" yaml:"backend"`
 	ObkvConfig ObkvStorageConfig `mapstructure:"obkv" json:"obkv" yaml:"obkv"`
+	// config sections of the other backends, keyed by backend name
+	Sections map[string]interface{} `mapstructure:",remain" json:"-" yaml:"-"`
+}
+
+// DecodeSection decodes the config section of the named backend into out,
+// out is left untouched if the section does not exist
+func (c *StorageConfig) DecodeSection(name string, out interface{}) error {
+	section, ok := c.Sections[name]
+	if !ok {
+		return nil
+	}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           out,
+		WeaklyTypedInput: true,
+	})
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(section); err != nil {
+		return fmt.Errorf("invalid config of storage backend '%s': %v", name, err)
+	}
+	return nil
 }
 
 type TLS struct {
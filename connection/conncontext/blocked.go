@@ -0,0 +1,149 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conncontext
+
+import (
+	"sync"
+)
+
+// UnblockReason tells a blocked client why it is woken up
+type UnblockReason int
+
+const (
+	// one of the keys is modified, the command is retried
+	UnblockKeyReady UnblockReason = iota
+	// CLIENT UNBLOCK TIMEOUT, the command replies as if it timed out
+	UnblockTimeout
+	// CLIENT UNBLOCK ERROR, the command replies an error
+	UnblockError
+	// the connection is closed, the reply is dropped
+	UnblockClosed
+)
+
+// BlockedClients tracks the clients blocked on keys by BLPOP and friends. A blocked client is woken up
+// once one of its keys is touched, so that it retries the command. Only the keys modified through this
+// modis are touched, the blocked commands poll the storage for the writes from other modis.
+type BlockedClients struct {
+	mu      sync.Mutex
+	keys    map[watchKey]map[int64]*CodecContext
+	clients map[int64]*CodecContext
+}
+
+// NewBlockedClients creates an empty blocked clients registry
+func NewBlockedClients() *BlockedClients {
+	return &BlockedClients{
+		keys:    make(map[watchKey]map[int64]*CodecContext),
+		clients: make(map[int64]*CodecContext),
+	}
+}
+
+// Block blocks cc on keys of db, returns the channel receiving the reason to wake up
+func (bc *BlockedClients) Block(cc *CodecContext, db int64, keys [][]byte) <-chan UnblockReason {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	for _, key := range keys {
+		k := watchKey{db: db, key: string(key)}
+		clients, ok := bc.keys[k]
+		if !ok {
+			clients = make(map[int64]*CodecContext)
+			bc.keys[k] = clients
+		}
+		clients[cc.ID] = cc
+		cc.blockingKeys = append(cc.blockingKeys, k)
+	}
+	// buffered, so that waking up never waits for the client
+	cc.wakeChan = make(chan UnblockReason, 1)
+	cc.Flag |= ClientBlocked
+	bc.clients[cc.ID] = cc
+	return cc.wakeChan
+}
+
+// Unblock removes cc from the blocked clients
+func (bc *BlockedClients) Unblock(cc *CodecContext) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	for _, k := range cc.blockingKeys {
+		clients := bc.keys[k]
+		delete(clients, cc.ID)
+		if len(clients) == 0 {
+			delete(bc.keys, k)
+		}
+	}
+	cc.blockingKeys = nil
+	cc.wakeChan = nil
+	cc.Flag &^= ClientBlocked
+	delete(bc.clients, cc.ID)
+}
+
+// Touch wakes up the clients blocked on any of keys of db
+func (bc *BlockedClients) Touch(db int64, keys [][]byte) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if len(bc.keys) == 0 {
+		return
+	}
+	for _, key := range keys {
+		for _, cc := range bc.keys[watchKey{db: db, key: string(key)}] {
+			wake(cc, UnblockKeyReady)
+		}
+	}
+}
+
+// Wake wakes up the blocked client of id for reason, returns false if the client is not blocked
+func (bc *BlockedClients) Wake(id int64, reason UnblockReason) bool {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	cc, ok := bc.clients[id]
+	if !ok {
+		return false
+	}
+	wake(cc, reason)
+	return true
+}
+
+// Len returns the number of blocked clients
+func (bc *BlockedClients) Len() int {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	return len(bc.clients)
+}
+
+// wake sends reason to cc unless a reason is pending, a pending UnblockKeyReady is replaced
+// so that CLIENT UNBLOCK is never lost
+func wake(cc *CodecContext, reason UnblockReason) {
+	select {
+	case cc.wakeChan <- reason:
+		return
+	default:
+	}
+	if reason == UnblockKeyReady {
+		return
+	}
+	select {
+	case <-cc.wakeChan:
+	default:
+	}
+	select {
+	case cc.wakeChan <- reason:
+	default:
+	}
+}
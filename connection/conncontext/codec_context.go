@@ -37,6 +37,14 @@ const (
 	ClientNone ClientFlag = 0
 	// This client is a slave monitor
 	ClientMonitor ClientFlag = 1 << iota
+	// This client is in the subscriber mode
+	ClientPubSub
+	// This client is in a MULTI context
+	ClientMulti
+	// EXEC will fail for errors while queueing
+	ClientDirtyExec
+	// This client is blocked by a blocking command
+	ClientBlocked
 )
 
 type ClientType int
@@ -63,11 +71,30 @@ type CodecContext struct {
 	LastCmdTime   time.Time
 	LastArgvLen   int64
 	LastCmd       string
-	RespVer       int        // only support 2 currently
-	Flag          ClientFlag // only support ClientNone currently
+	RespVer       int // only support 2 currently
+	Flag          ClientFlag
 	Type          ClientType // only support ClientNormal currently
 	QueLimit      int64
 	QueNum        *atomic.Int64
+	TxQueue       []QueuedCmd // commands queued since MULTI
+	WatchDirty    atomic.Bool // set once a watched key is touched
+	watching      []watchedKey
+	subChannels   map[string]struct{} // guarded by PubSub
+	subPatterns   map[string]struct{} // guarded by PubSub
+	pubsubOut     chan []byte         // guarded by PubSub, the messages queued for the subscriber
+	pubsubDone    chan struct{}       // guarded by PubSub, closed to stop the writer of pubsubOut
+	pubsubQueued  atomic.Int64        // the size of the messages in pubsubOut
+	pubsubSlow    atomic.Bool         // set once the subscriber is disconnected for falling behind
+	blockingKeys  []watchKey          // guarded by Blocked
+	wakeChan      chan UnblockReason  // guarded by Blocked
+}
+
+// QueuedCmd is a command queued in a MULTI context, it runs on EXEC
+type QueuedCmd struct {
+	Name     string
+	FullName string
+	Args     [][]byte
+	PlainReq []byte
 }
 
 // ReadCounter record totoal bytes read from reader
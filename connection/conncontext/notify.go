@@ -0,0 +1,176 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conncontext
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NotifyClass is a class of keyspace events, as configured by notify-keyspace-events
+type NotifyClass int
+
+const (
+	NotifyNone NotifyClass = 0
+	// K, events are published on __keyspace@<db>__:<key>
+	NotifyKeyspace NotifyClass = 1 << iota
+	// E, events are published on __keyevent@<db>__:<event>
+	NotifyKeyevent
+	// g, generic commands like DEL, EXPIRE and RENAME
+	NotifyGeneric
+	// $, string commands
+	NotifyString
+	// l, list commands
+	NotifyList
+	// s, set commands
+	NotifySet
+	// h, hash commands
+	NotifyHash
+	// z, sorted set commands
+	NotifyZSet
+	// x, keys found expired
+	NotifyExpired
+	// e, keys evicted, never fired by modis
+	NotifyEvicted
+	// t, stream commands
+	NotifyStream
+	// m, key misses, never fired by modis
+	NotifyKeyMiss
+	// d, module commands, never fired by modis
+	NotifyModule
+	// n, new keys, never fired by modis
+	NotifyNew
+	// A, alias of "g$lshzxetd"
+	NotifyAll = NotifyGeneric | NotifyString | NotifyList | NotifySet | NotifyHash | NotifyZSet |
+		NotifyExpired | NotifyEvicted | NotifyStream | NotifyModule
+)
+
+// ParseNotifyClasses parses the classes of notify-keyspace-events, e.g. "KEA" or "Kgx"
+func ParseNotifyClasses(classes string) (NotifyClass, error) {
+	flags := NotifyNone
+	for _, c := range classes {
+		switch c {
+		case 'A':
+			flags |= NotifyAll
+		case 'g':
+			flags |= NotifyGeneric
+		case '$':
+			flags |= NotifyString
+		case 'l':
+			flags |= NotifyList
+		case 's':
+			flags |= NotifySet
+		case 'h':
+			flags |= NotifyHash
+		case 'z':
+			flags |= NotifyZSet
+		case 'x':
+			flags |= NotifyExpired
+		case 'e':
+			flags |= NotifyEvicted
+		case 'K':
+			flags |= NotifyKeyspace
+		case 'E':
+			flags |= NotifyKeyevent
+		case 't':
+			flags |= NotifyStream
+		case 'm':
+			flags |= NotifyKeyMiss
+		case 'd':
+			flags |= NotifyModule
+		case 'n':
+			flags |= NotifyNew
+		default:
+			return NotifyNone, fmt.Errorf("invalid class '%c' of notify-keyspace-events", c)
+		}
+	}
+	return flags, nil
+}
+
+// String formats the classes the way ParseNotifyClasses accepts
+func (c NotifyClass) String() string {
+	var b strings.Builder
+	if c&NotifyAll == NotifyAll {
+		b.WriteByte('A')
+	} else {
+		for _, cc := range []struct {
+			class NotifyClass
+			flag  byte
+		}{
+			{NotifyGeneric, 'g'}, {NotifyString, '$'}, {NotifyList, 'l'}, {NotifySet, 's'},
+			{NotifyHash, 'h'}, {NotifyZSet, 'z'}, {NotifyExpired, 'x'}, {NotifyEvicted, 'e'},
+			{NotifyStream, 't'}, {NotifyModule, 'd'},
+		} {
+			if c&cc.class != 0 {
+				b.WriteByte(cc.flag)
+			}
+		}
+	}
+	if c&NotifyKeyspace != 0 {
+		b.WriteByte('K')
+	}
+	if c&NotifyKeyevent != 0 {
+		b.WriteByte('E')
+	}
+	if c&NotifyKeyMiss != 0 {
+		b.WriteByte('m')
+	}
+	if c&NotifyNew != 0 {
+		b.WriteByte('n')
+	}
+	return b.String()
+}
+
+// NotifyEvents returns the classes of the keyspace events to publish
+func (sc *ServerContext) NotifyEvents() NotifyClass {
+	return NotifyClass(sc.notifyEvents.Load())
+}
+
+// SetNotifyEvents sets the classes of the keyspace events to publish. The expired events are refused
+// if the storage cannot tell the keys found expired, e.g. obkv expires keys on the server side
+func (sc *ServerContext) SetNotifyEvents(classes NotifyClass) error {
+	if classes&NotifyExpired != 0 && !sc.expireNotifier {
+		return fmt.Errorf("the expired events (x) are not supported by the %s backend", sc.Backend)
+	}
+	sc.notifyEvents.Store(int64(classes))
+	return nil
+}
+
+// NotifyKeyspaceEvent publishes event of key in db if its class is enabled, nothing is
+// published unless either keyspace (K) or keyevent (E) events are enabled
+func (sc *ServerContext) NotifyKeyspaceEvent(class NotifyClass, event string, db int64, key []byte) {
+	flags := sc.NotifyEvents()
+	if flags&class == 0 {
+		return
+	}
+	dbStr := strconv.FormatInt(db, 10)
+	if flags&NotifyKeyspace != 0 {
+		channel := make([]byte, 0, len("__keyspace@__:")+len(dbStr)+len(key))
+		channel = append(channel, "__keyspace@"+dbStr+"__:"...)
+		channel = append(channel, key...)
+		sc.PubSub.Publish(channel, []byte(event))
+	}
+	if flags&NotifyKeyevent != 0 {
+		sc.PubSub.Publish([]byte("__keyevent@"+dbStr+"__:"+event), key)
+	}
+}
+
+// notifyExpired publishes the expired event of a key found expired by the storage
+func (sc *ServerContext) notifyExpired(db int64, key []byte) {
+	sc.NotifyKeyspaceEvent(NotifyExpired, "expired", db, key)
+}
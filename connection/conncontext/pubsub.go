@@ -0,0 +1,334 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conncontext
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/oceanbase/modis/log"
+	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/oceanbase/modis/util"
+)
+
+const (
+	// pubsubQueueLimit is the number of messages queued for a subscriber at most
+	pubsubQueueLimit = 4096
+	// pubsubQueueBytes is the size of the messages queued for a subscriber at most, a subscriber falling
+	// behind by more is disconnected, like the hard pubsub limit of client-output-buffer-limit of redis
+	pubsubQueueBytes = 32 << 20
+	// pubsubWriteTimeout bounds the write of a message to a subscriber, which is disconnected once it expires
+	pubsubWriteTimeout = 60 * time.Second
+)
+
+// PubSub tracks the channels and patterns subscribed by clients and delivers published messages
+type PubSub struct {
+	mu sync.RWMutex
+	// [channel, [client id, CodecContext]]
+	channels map[string]map[int64]*CodecContext
+	// [pattern, [client id, CodecContext]]
+	patterns map[string]map[int64]*CodecContext
+	// forwards the published messages to the other nodes, nil if there are none
+	forward func(channel []byte, message []byte)
+}
+
+// NewPubSub creates an empty pub/sub registry
+func NewPubSub() *PubSub {
+	return &PubSub{
+		channels: make(map[string]map[int64]*CodecContext),
+		patterns: make(map[string]map[int64]*CodecContext),
+	}
+}
+
+// subscribe adds cc to the clients of name, returns false if it is subscribed already
+func subscribe(registry map[string]map[int64]*CodecContext, subscribed map[string]struct{},
+	cc *CodecContext, name string) bool {
+	if _, ok := subscribed[name]; ok {
+		return false
+	}
+	subscribed[name] = struct{}{}
+	clients, ok := registry[name]
+	if !ok {
+		clients = make(map[int64]*CodecContext)
+		registry[name] = clients
+	}
+	clients[cc.ID] = cc
+	return true
+}
+
+// unsubscribe removes cc from the clients of name, returns false if it is not subscribed
+func unsubscribe(registry map[string]map[int64]*CodecContext, subscribed map[string]struct{},
+	cc *CodecContext, name string) bool {
+	if _, ok := subscribed[name]; !ok {
+		return false
+	}
+	delete(subscribed, name)
+	clients := registry[name]
+	delete(clients, cc.ID)
+	if len(clients) == 0 {
+		delete(registry, name)
+	}
+	return true
+}
+
+// updatePubSubFlag puts cc into or out of the subscriber mode by its number of subscriptions,
+// which is returned
+func (cc *CodecContext) updatePubSubFlag() int {
+	n := len(cc.subChannels) + len(cc.subPatterns)
+	if n == 0 {
+		cc.Flag &^= ClientPubSub
+	} else {
+		cc.Flag |= ClientPubSub
+	}
+	return n
+}
+
+// startPubSubWriter starts the goroutine writing the messages queued for cc, if not started yet
+func (cc *CodecContext) startPubSubWriter() {
+	if cc.pubsubOut != nil {
+		return
+	}
+	cc.pubsubOut = make(chan []byte, pubsubQueueLimit)
+	cc.pubsubDone = make(chan struct{})
+	go cc.writePubSub(cc.pubsubOut, cc.pubsubDone)
+}
+
+// writePubSub writes the messages queued for cc in order until done is closed
+func (cc *CodecContext) writePubSub(out <-chan []byte, done <-chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case msg := <-out:
+			cc.Conn.SetWriteDeadline(time.Now().Add(pubsubWriteTimeout))
+			_, err := cc.Conn.Write(msg)
+			cc.Conn.SetWriteDeadline(time.Time{})
+			cc.pubsubQueued.Add(-int64(len(msg)))
+			if err != nil {
+				// closing the connection unsubscribes the client
+				log.Warn("pubsub", nil, "fail to write message, close the subscriber", log.Errors(err),
+					log.Int64("client id", cc.ID))
+				cc.Conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// queuePubSub queues msg to be written to cc by out, the connection of cc is closed instead if its
+// queue is full, returns whether msg is queued
+func (cc *CodecContext) queuePubSub(out chan<- []byte, msg []byte) bool {
+	if cc.pubsubQueued.Add(int64(len(msg))) <= pubsubQueueBytes {
+		select {
+		case out <- msg:
+			return true
+		default:
+		}
+	}
+	cc.pubsubQueued.Add(-int64(len(msg)))
+	if cc.pubsubSlow.CompareAndSwap(false, true) {
+		log.Warn("pubsub", nil, "subscriber falls behind, close it", log.Int64("client id", cc.ID),
+			log.Int64("queued bytes", cc.pubsubQueued.Load()))
+		cc.Conn.Close()
+	}
+	return false
+}
+
+// Subscribe subscribes cc to channel, returns the number of subscriptions of cc
+func (ps *PubSub) Subscribe(cc *CodecContext, channel []byte) int {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if cc.subChannels == nil {
+		cc.subChannels = make(map[string]struct{})
+	}
+	cc.startPubSubWriter()
+	subscribe(ps.channels, cc.subChannels, cc, string(channel))
+	return cc.updatePubSubFlag()
+}
+
+// Unsubscribe unsubscribes cc from channel, returns the number of subscriptions of cc
+func (ps *PubSub) Unsubscribe(cc *CodecContext, channel []byte) int {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	unsubscribe(ps.channels, cc.subChannels, cc, string(channel))
+	return cc.updatePubSubFlag()
+}
+
+// PSubscribe subscribes cc to the channels matching pattern, returns the number of subscriptions of cc
+func (ps *PubSub) PSubscribe(cc *CodecContext, pattern []byte) int {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if cc.subPatterns == nil {
+		cc.subPatterns = make(map[string]struct{})
+	}
+	cc.startPubSubWriter()
+	subscribe(ps.patterns, cc.subPatterns, cc, string(pattern))
+	return cc.updatePubSubFlag()
+}
+
+// PUnsubscribe unsubscribes cc from pattern, returns the number of subscriptions of cc
+func (ps *PubSub) PUnsubscribe(cc *CodecContext, pattern []byte) int {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	unsubscribe(ps.patterns, cc.subPatterns, cc, string(pattern))
+	return cc.updatePubSubFlag()
+}
+
+// SubscribedChannels returns the channels subscribed by cc
+func (ps *PubSub) SubscribedChannels(cc *CodecContext) [][]byte {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return sortedNames(cc.subChannels)
+}
+
+// SubscribedPatterns returns the patterns subscribed by cc
+func (ps *PubSub) SubscribedPatterns(cc *CodecContext) [][]byte {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return sortedNames(cc.subPatterns)
+}
+
+// NumSubscriptions returns the number of channels and patterns subscribed by cc
+func (ps *PubSub) NumSubscriptions(cc *CodecContext) (int, int) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return len(cc.subChannels), len(cc.subPatterns)
+}
+
+// UnsubscribeAll removes all subscriptions of cc, it is called when the client is closed
+func (ps *PubSub) UnsubscribeAll(cc *CodecContext) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for channel := range cc.subChannels {
+		unsubscribe(ps.channels, cc.subChannels, cc, channel)
+	}
+	for pattern := range cc.subPatterns {
+		unsubscribe(ps.patterns, cc.subPatterns, cc, pattern)
+	}
+	cc.updatePubSubFlag()
+	if cc.pubsubDone != nil {
+		close(cc.pubsubDone)
+		cc.pubsubOut, cc.pubsubDone = nil, nil
+	}
+}
+
+// SetForwarder sets the function forwarding the published messages to the other nodes,
+// it must be called before any message is published
+func (ps *PubSub) SetForwarder(forward func(channel []byte, message []byte)) {
+	ps.forward = forward
+}
+
+// Publish delivers message to the subscribers of this node and forwards it to the other nodes,
+// returns the number of clients of this node that received the message
+func (ps *PubSub) Publish(channel []byte, message []byte) int64 {
+	if ps.forward != nil {
+		ps.forward(channel, message)
+	}
+	return ps.Deliver(channel, message)
+}
+
+// Deliver delivers message to the clients subscribing channel or a pattern matching channel,
+// returns the number of clients that received the message. The message is queued for every client
+// and written by its own goroutine, so that a slow subscriber blocks neither the publisher nor the
+// other subscribers, and a subscriber whose queue is full is disconnected
+func (ps *PubSub) Deliver(channel []byte, message []byte) int64 {
+	type delivery struct {
+		cc  *CodecContext
+		out chan<- []byte
+		msg []byte
+	}
+	var deliveries []delivery
+
+	ps.mu.RLock()
+	if clients := ps.channels[string(channel)]; len(clients) != 0 {
+		msg := []byte(resp.EncArray([][]byte{[]byte("message"), channel, message}))
+		for _, cc := range clients {
+			deliveries = append(deliveries, delivery{cc: cc, out: cc.pubsubOut, msg: msg})
+		}
+	}
+	for pattern, clients := range ps.patterns {
+		if !util.GlobMatch([]byte(pattern), channel, false) {
+			continue
+		}
+		msg := []byte(resp.EncArray([][]byte{[]byte("pmessage"), []byte(pattern), channel, message}))
+		for _, cc := range clients {
+			deliveries = append(deliveries, delivery{cc: cc, out: cc.pubsubOut, msg: msg})
+		}
+	}
+	ps.mu.RUnlock()
+
+	var receivers int64
+	for _, d := range deliveries {
+		if d.cc.queuePubSub(d.out, d.msg) {
+			receivers++
+		}
+	}
+	return receivers
+}
+
+// Channels returns the channels having at least one subscriber, only the ones
+// matching pattern are returned if pattern is not nil
+func (ps *PubSub) Channels(pattern []byte) [][]byte {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	channels := make([][]byte, 0, len(ps.channels))
+	for channel := range ps.channels {
+		if pattern == nil || util.GlobMatch(pattern, []byte(channel), false) {
+			channels = append(channels, []byte(channel))
+		}
+	}
+	return channels
+}
+
+// NumSub returns the number of subscribers of channel, subscribers of patterns are not counted
+func (ps *PubSub) NumSub(channel []byte) int64 {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return int64(len(ps.channels[string(channel)]))
+}
+
+// NumPat returns the number of patterns subscribed by all clients
+func (ps *PubSub) NumPat() int64 {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return int64(len(ps.patterns))
+}
+
+func sortedNames(names map[string]struct{}) [][]byte {
+	keys := make([]string, 0, len(names))
+	for name := range names {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	out := make([][]byte, len(keys))
+	for i, name := range keys {
+		out[i] = []byte(name)
+	}
+	return out
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conncontext
+
+import (
+	"sync"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// ScriptCache keeps the compiled scripts of EVAL and SCRIPT LOAD by their SHA1 digests,
+// it is shared by all clients
+type ScriptCache struct {
+	mu      sync.RWMutex
+	scripts map[string]*lua.FunctionProto
+}
+
+// NewScriptCache creates an empty script cache
+func NewScriptCache() *ScriptCache {
+	return &ScriptCache{
+		scripts: make(map[string]*lua.FunctionProto),
+	}
+}
+
+// Get returns the script with the digest sha, nil if not found
+func (sc *ScriptCache) Get(sha string) *lua.FunctionProto {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.scripts[sha]
+}
+
+// Set adds the script with the digest sha
+func (sc *ScriptCache) Set(sha string, proto *lua.FunctionProto) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.scripts[sha] = proto
+}
+
+// Flush removes all scripts
+func (sc *ScriptCache) Flush() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.scripts = make(map[string]*lua.FunctionProto)
+}
@@ -24,6 +24,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
@@ -39,6 +40,10 @@ import (
 const (
 	// DefaultNamespace is default namespace of DB
 	DefaultNamespace = "default"
+	// defaultLuaTimeLimit is the max execution time of a script if not configured
+	defaultLuaTimeLimit = 5 * time.Second
+	// defaultBlockPollInterval is the interval of the blocked clients polling the storage if not configured
+	defaultBlockPollInterval = 100 * time.Millisecond
 )
 
 type SupervisedMode int
@@ -72,6 +77,31 @@ type ServerContext struct {
 	// [cliend id, CodecContext], record clients with monitor
 	Monitors  *haxmap.Map[int64, *CodecContext]
 	LastCliID int64
+	// channels and patterns subscribed by clients
+	PubSub *PubSub
+	// keys watched by clients with WATCH
+	Watched *WatchedKeys
+	// clients blocked on keys by the blocking commands
+	Blocked *BlockedClients
+	// interval of the blocked clients polling the storage for the writes from other modis
+	BlockPollInterval time.Duration
+	// EXEC and EVAL hold the lock exclusively while other commands share it,
+	// so that the commands of a transaction or a script run without interleaving
+	TxLock sync.RWMutex
+	// scripts loaded by EVAL and SCRIPT LOAD
+	Scripts *ScriptCache
+	// max execution time of a script
+	LuaTimeLimit time.Duration
+	// classes of the keyspace events to publish, a NotifyClass
+	notifyEvents atomic.Int64
+	// whether the storage tells the keys found expired, see storage.ExpireNotifier
+	expireNotifier bool
+	// number of FLUSHDB ASYNC and FLUSHALL ASYNC still running in the background
+	AsyncFlushes atomic.Int64
+	// whether the last async flush failed, shown in INFO persistence
+	AsyncFlushFailed atomic.Bool
+	// number of messages dropped for a full queue of a pubsub bus peer, shown in INFO stats
+	BusDroppedMessages atomic.Int64
 
 	// atomic, include all clients
 	TotalCmdNum     *metrics.Metrics
@@ -101,11 +131,36 @@ func NewServerContext(s storage.Storage, cfg *config.Config, cfgPath string) (*S
 		Backend:         cfg.Storage.Backend,
 		Clients:         haxmap.New[int64, *CodecContext](),
 		Monitors:        haxmap.New[int64, *CodecContext](),
+		Watched:         NewWatchedKeys(),
+		Blocked:         NewBlockedClients(),
+		PubSub:          NewPubSub(),
+		Scripts:         NewScriptCache(),
+		LuaTimeLimit:    time.Duration(servCfg.LuaTimeLimit) * time.Millisecond,
+	}
+	if sc.LuaTimeLimit <= 0 {
+		sc.LuaTimeLimit = defaultLuaTimeLimit
+	}
+	sc.BlockPollInterval = time.Duration(servCfg.BlockPollInterval) * time.Millisecond
+	if sc.BlockPollInterval <= 0 {
+		sc.BlockPollInterval = defaultBlockPollInterval
+	}
+	notifyEvents, err := ParseNotifyClasses(servCfg.NotifyKeyspaceEvents)
+	if err != nil {
+		log.Warn("server", nil, "invalid server config: notify-keyspace-events", log.Errors(err))
+		return nil, err
+	}
+	if notifier, ok := s.(storage.ExpireNotifier); ok {
+		notifier.OnExpired(sc.notifyExpired)
+		sc.expireNotifier = true
+	}
+	if err = sc.SetNotifyEvents(notifyEvents); err != nil {
+		log.Warn("server", nil, "invalid server config: notify-keyspace-events", log.Errors(err))
+		return nil, err
 	}
 	sc.ClientNum.Store(0)
 
 	// init modis path
-	err := sc.initModisPath()
+	err = sc.initModisPath()
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conncontext
+
+import (
+	"bytes"
+	"sync"
+)
+
+// watchKey is a key in a db
+type watchKey struct {
+	db  int64
+	key string
+}
+
+// watchedKey is a key watched by a client along with its version at WATCH time
+type watchedKey struct {
+	watchKey
+	version []byte
+}
+
+// WatchedKeys tracks the keys watched by clients with WATCH. A client is marked dirty once one of
+// its watched keys is touched, so that its next EXEC is aborted. Only the keys modified through
+// this modis are touched, so the version of each key in the storage is recorded as well, EXEC compares
+// it with the current one to find the writes from other modis sharing the storage.
+type WatchedKeys struct {
+	mu      sync.Mutex
+	clients map[watchKey]map[int64]*CodecContext
+}
+
+// NewWatchedKeys creates an empty watched keys registry
+func NewWatchedKeys() *WatchedKeys {
+	return &WatchedKeys{
+		clients: make(map[watchKey]map[int64]*CodecContext),
+	}
+}
+
+// Watch adds key of db to the keys watched by cc, version is the version of key
+func (wk *WatchedKeys) Watch(cc *CodecContext, db int64, key []byte, version []byte) {
+	wk.mu.Lock()
+	defer wk.mu.Unlock()
+
+	k := watchKey{db: db, key: string(key)}
+	clients, ok := wk.clients[k]
+	if !ok {
+		clients = make(map[int64]*CodecContext)
+		wk.clients[k] = clients
+	}
+	if _, ok = clients[cc.ID]; ok {
+		return
+	}
+	clients[cc.ID] = cc
+	cc.watching = append(cc.watching, watchedKey{watchKey: k, version: version})
+}
+
+// Unwatch removes all keys watched by cc and clears its dirty mark
+func (wk *WatchedKeys) Unwatch(cc *CodecContext) {
+	wk.mu.Lock()
+	defer wk.mu.Unlock()
+
+	for _, k := range cc.watching {
+		clients := wk.clients[k.watchKey]
+		delete(clients, cc.ID)
+		if len(clients) == 0 {
+			delete(wk.clients, k.watchKey)
+		}
+	}
+	cc.watching = nil
+	cc.WatchDirty.Store(false)
+}
+
+// Changed reports whether the version of any key watched by cc differs from the one at WATCH time,
+// version returns the current version of key of db
+func (wk *WatchedKeys) Changed(cc *CodecContext, version func(db int64, key []byte) ([]byte, error)) (bool, error) {
+	wk.mu.Lock()
+	watching := append([]watchedKey(nil), cc.watching...)
+	wk.mu.Unlock()
+
+	for _, k := range watching {
+		cur, err := version(k.db, []byte(k.key))
+		if err != nil {
+			return false, err
+		}
+		if !bytes.Equal(cur, k.version) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Touch marks the clients watching any of keys of db dirty
+func (wk *WatchedKeys) Touch(db int64, keys [][]byte) {
+	wk.mu.Lock()
+	defer wk.mu.Unlock()
+
+	if len(wk.clients) == 0 {
+		return
+	}
+	for _, key := range keys {
+		for _, cc := range wk.clients[watchKey{db: db, key: string(key)}] {
+			cc.WatchDirty.Store(true)
+		}
+	}
+}
+
+// TouchDB marks the clients watching any key of db dirty, it is used by the commands
+// that modify the whole db, e.g. FLUSHDB
+func (wk *WatchedKeys) TouchDB(db int64) {
+	wk.mu.Lock()
+	defer wk.mu.Unlock()
+
+	for k, clients := range wk.clients {
+		if k.db != db {
+			continue
+		}
+		for _, cc := range clients {
+			cc.WatchDirty.Store(true)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/oceanbase/modis/config"
+	"github.com/oceanbase/modis/connection/conncontext"
+	"github.com/oceanbase/modis/log"
+	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/pkg/errors"
+)
+
+const (
+	// max number of messages waiting to be sent to a peer, the new ones are dropped once it is reached
+	busQueueSize     = 1024
+	busDialTimeout   = 3 * time.Second
+	busRetryInterval = time.Second
+	// min interval between the warnings about the messages dropped for a peer
+	busDropLogInterval = 10 * time.Second
+
+	busHello   = "hello"
+	busPublish = "publish"
+	// replied to the hello of a node connecting to itself
+	busErrSelf = "ERR self"
+)
+
+// PubSubBus delivers the messages published on this node to the subscribers of the other nodes.
+// A node connects to every peer, says hello with its run id and the password of the bus, and then
+// sends the published messages as RESP arrays of ["publish", channel, message]. The messages received
+// from the peers are delivered to the local subscribers only, so every node has to list all the others.
+// A message is delivered at most once, it is dropped if the queue of an unreachable peer is full,
+// and the messages dropped are counted in INFO stats.
+// The bus runs over TLS if the server does, a peer has to present a certificate trusted by this node
+type PubSubBus struct {
+	servCtx   *conncontext.ServerContext
+	password  string
+	listener  net.Listener
+	dialTLS   *tls.Config // nil if the bus runs over plain TCP
+	peers     []*busPeer
+	closeChan chan struct{}
+	closeOnce sync.Once
+	mu        sync.Mutex
+	conns     map[net.Conn]struct{} // connections from the peers
+}
+
+// busPeer is another node on the bus
+type busPeer struct {
+	addr        string
+	queue       chan []byte
+	dropped     atomic.Int64 // messages dropped since the last warning
+	lastDropLog atomic.Int64 // unix nano of the last warning about the messages dropped
+}
+
+// NewPubSubBus creates a bus receiving messages on listener, the messages published on this node are
+// forwarded to the peers once the bus is served. Both the listener and the connections to the peers
+// use TLS if tlsCfg is not nil
+func NewPubSubBus(cfg *config.PubSubBusConfig, password string, listener net.Listener, tlsCfg *tls.Config,
+	servCtx *conncontext.ServerContext) (*PubSubBus, error) {
+	var dialTLS *tls.Config
+	if tlsCfg != nil {
+		var err error
+		if dialTLS, err = busDialTLSConfig(tlsCfg); err != nil {
+			return nil, err
+		}
+		listener = tls.NewListener(listener, tlsCfg)
+	}
+	b := &PubSubBus{
+		servCtx:   servCtx,
+		password:  password,
+		listener:  listener,
+		dialTLS:   dialTLS,
+		peers:     make([]*busPeer, 0, len(cfg.Peers)),
+		closeChan: make(chan struct{}),
+		conns:     make(map[net.Conn]struct{}),
+	}
+	for _, addr := range cfg.Peers {
+		b.peers = append(b.peers, &busPeer{addr: addr, queue: make(chan []byte, busQueueSize)})
+	}
+	servCtx.PubSub.SetForwarder(b.forward)
+	return b, nil
+}
+
+// busDialTLSConfig returns the TLS config connecting to the peers, which presents the certificate of
+// this node and trusts the peers presenting a certificate signed by a system root or by a certificate
+// of this node, e.g. all nodes share a certificate or its CA. The host names are not checked as the
+// peers are often listed by ip
+func busDialTLSConfig(tlsCfg *tls.Config) (*tls.Config, error) {
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	for _, cert := range tlsCfg.Certificates {
+		for _, der := range cert.Certificate {
+			c, err := x509.ParseCertificate(der)
+			if err != nil {
+				return nil, err
+			}
+			roots.AddCert(c)
+		}
+	}
+	return &tls.Config{
+		Certificates:       tlsCfg.Certificates,
+		Rand:               tlsCfg.Rand,
+		InsecureSkipVerify: true, // the chain is verified by VerifyPeerCertificate without the host name
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("peer presents no certificate")
+			}
+			certs := make([]*x509.Certificate, 0, len(rawCerts))
+			for _, raw := range rawCerts {
+				c, err := x509.ParseCertificate(raw)
+				if err != nil {
+					return err
+				}
+				certs = append(certs, c)
+			}
+			intermediates := x509.NewCertPool()
+			for _, c := range certs[1:] {
+				intermediates.AddCert(c)
+			}
+			_, err := certs[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+			return err
+		},
+	}, nil
+}
+
+// Serve connects to the peers and accepts the connections from them until the bus is closed
+func (b *PubSubBus) Serve() {
+	for _, p := range b.peers {
+		go b.runPeer(p)
+	}
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			select {
+			case <-b.closeChan:
+			default:
+				log.Error("bus", nil, "fail to accept connection", log.Errors(err), log.String("addr", b.listener.Addr().String()))
+			}
+			return
+		}
+		go b.serveConn(conn)
+	}
+}
+
+// Close stops the bus, the messages not sent yet are dropped
+func (b *PubSubBus) Close() {
+	b.closeOnce.Do(func() {
+		close(b.closeChan)
+		err := b.listener.Close()
+		if err != nil {
+			log.Warn("bus", nil, "fail to close listener", log.Errors(err))
+		}
+		b.mu.Lock()
+		for conn := range b.conns {
+			conn.Close()
+		}
+		b.mu.Unlock()
+	})
+}
+
+// forward queues the message to be sent to every peer, the drops for a full queue are warned once
+// per busDropLogInterval at most
+func (b *PubSubBus) forward(channel []byte, message []byte) {
+	msg := []byte(resp.EncArray([][]byte{[]byte(busPublish), channel, message}))
+	for _, p := range b.peers {
+		select {
+		case p.queue <- msg:
+		default:
+			b.servCtx.BusDroppedMessages.Add(1)
+			p.dropped.Add(1)
+			now := time.Now().UnixNano()
+			last := p.lastDropLog.Load()
+			if now-last >= int64(busDropLogInterval) && p.lastDropLog.CompareAndSwap(last, now) {
+				log.Warn("bus", nil, "queue of peer is full, drop messages", log.String("peer", p.addr),
+					log.Int64("dropped", p.dropped.Swap(0)))
+			}
+		}
+	}
+}
+
+// runPeer keeps sending the queued messages to the peer, it reconnects if the connection is broken
+func (b *PubSubBus) runPeer(p *busPeer) {
+	for {
+		conn, err := b.connectPeer(p)
+		if err == resp.ErrorReply(busErrSelf) {
+			log.Info("bus", nil, "skip peer of this node", log.String("peer", p.addr))
+			return
+		} else if err != nil {
+			log.Warn("bus", nil, "fail to connect peer", log.Errors(err), log.String("peer", p.addr))
+			select {
+			case <-b.closeChan:
+				return
+			case <-time.After(busRetryInterval):
+				continue
+			}
+		}
+		log.Info("bus", nil, "connected to peer", log.String("peer", p.addr))
+		if !b.sendToPeer(p, conn) {
+			return
+		}
+	}
+}
+
+// connectPeer connects to the peer and says hello
+func (b *PubSubBus) connectPeer(p *busPeer) (net.Conn, error) {
+	var conn net.Conn
+	var err error
+	if b.dialTLS != nil {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: busDialTimeout}, "tcp", p.addr, b.dialTLS)
+	} else {
+		conn, err = net.DialTimeout("tcp", p.addr, busDialTimeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	hello := resp.EncArray([][]byte{[]byte(busHello), []byte(b.servCtx.RunID), []byte(b.password)})
+	err = conn.SetDeadline(time.Now().Add(busDialTimeout))
+	if err == nil {
+		_, err = conn.Write([]byte(hello))
+	}
+	if err == nil {
+		_, err = resp.NewDecoder(bufio.NewReader(conn)).Reply()
+	}
+	if err == nil {
+		err = conn.SetDeadline(time.Time{})
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// sendToPeer sends the queued messages over conn until it is broken,
+// returns false if the bus is closed
+func (b *PubSubBus) sendToPeer(p *busPeer, conn net.Conn) bool {
+	defer conn.Close()
+	// the peer never writes after hello, a read returns once the connection is closed,
+	// so that a restarted peer is reconnected before a message is lost on the stale connection
+	broken := make(chan struct{})
+	go func() {
+		_, _ = conn.Read(make([]byte, 1))
+		close(broken)
+	}()
+	for {
+		select {
+		case <-b.closeChan:
+			return false
+		case <-broken:
+			log.Warn("bus", nil, "connection to peer is closed", log.String("peer", p.addr))
+			return true
+		case msg := <-p.queue:
+			if _, err := conn.Write(msg); err != nil {
+				log.Warn("bus", nil, "fail to send message to peer", log.Errors(err), log.String("peer", p.addr))
+				return true
+			}
+		}
+	}
+}
+
+// serveConn checks the hello of a peer and delivers the messages from it
+func (b *PubSubBus) serveConn(conn net.Conn) {
+	b.mu.Lock()
+	b.conns[conn] = struct{}{}
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.conns, conn)
+		b.mu.Unlock()
+		conn.Close()
+	}()
+
+	// a connection not saying hello in time is closed, so that it does not hold a goroutine forever
+	if err := conn.SetDeadline(time.Now().Add(busDialTimeout)); err != nil {
+		log.Warn("bus", nil, "fail to set deadline", log.Errors(err), log.String("addr", conn.RemoteAddr().String()))
+		return
+	}
+	decoder := resp.NewDecoder(bufio.NewReader(conn))
+	args, err := decoder.Array()
+	if err != nil || len(args) != 3 || string(args[0]) != busHello {
+		log.Warn("bus", nil, "invalid hello from peer", log.Errors(err), log.String("addr", conn.RemoteAddr().String()))
+		return
+	}
+	var reply string
+	switch {
+	case string(args[1]) == b.servCtx.RunID:
+		reply = resp.EncError(busErrSelf)
+	case subtle.ConstantTimeCompare(args[2], []byte(b.password)) != 1:
+		reply = resp.EncError("WRONGPASS invalid password.")
+	default:
+		reply = resp.ResponsesOk
+	}
+	if _, err = conn.Write([]byte(reply)); err != nil || reply != resp.ResponsesOk {
+		return
+	}
+	if err = conn.SetDeadline(time.Time{}); err != nil {
+		log.Warn("bus", nil, "fail to clear deadline", log.Errors(err), log.String("addr", conn.RemoteAddr().String()))
+		return
+	}
+
+	for {
+		args, err = decoder.Array()
+		if err != nil {
+			if err != io.EOF {
+				log.Warn("bus", nil, "fail to read message from peer", log.Errors(err), log.String("addr", conn.RemoteAddr().String()))
+			}
+			return
+		}
+		if len(args) == 3 && string(args[0]) == busPublish {
+			b.servCtx.PubSub.Deliver(args[1], args[2])
+		}
+	}
+}
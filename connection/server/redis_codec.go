@@ -124,6 +124,9 @@ func (rs *RedisCodec) Close() {
 	}
 	rs.ServCtx.ClientNum.Add(-1)
 	rs.ServCtx.Clients.Del(rs.CodecCtx.ID)
+	rs.ServCtx.Watched.Unwatch(rs.CodecCtx)
+	rs.ServCtx.PubSub.UnsubscribeAll(rs.CodecCtx)
+	rs.ServCtx.Blocked.Wake(rs.CodecCtx.ID, conncontext.UnblockClosed)
 }
 
 func (rs *RedisCodec) readCommand(plainReq *[]byte) ([][]byte, error) {
@@ -45,6 +45,8 @@ type Server struct {
 	Listener    net.Listener
 	IDGenerator func() int64
 	CloseChan   chan struct{}
+	// nil if the pub/sub bus is not configured
+	Bus *PubSubBus
 }
 
 // NewServer creates a new server
@@ -65,6 +67,9 @@ func (s *Server) Close() {
 	}
 	// close current connection
 	close(s.CloseChan)
+	if s.Bus != nil {
+		s.Bus.Close()
+	}
 	if s.ServCtx.Storage != nil {
 		err = s.ServCtx.Storage.Close()
 		if err != nil {
@@ -171,6 +176,29 @@ func (s *Server) ListenAndServe(servCfg *config.ServerConfig, tlsCfg *tls.Config
 	if tlsCfg != nil {
 		s.Listener = tls.NewListener(s.Listener, tlsCfg)
 	}
+	if busCfg := &servCfg.PubSubBus; busCfg.Listen != "" {
+		password := busCfg.Password
+		if password == "" {
+			password = s.ServCtx.Password
+		}
+		if password == "" {
+			err = errors.New("pub/sub bus needs a password, set the password of the bus or the server")
+			log.Warn("server", nil, "invalid server config: pubsub-bus", log.Errors(err))
+			return err
+		}
+		busListener, err := gnet.Listen("tcp", busCfg.Listen)
+		if err != nil {
+			log.Warn("server", nil, "fail to listen address of pub/sub bus", log.Errors(err), log.String("addr", busCfg.Listen))
+			return err
+		}
+		s.Bus, err = NewPubSubBus(busCfg, password, busListener, tlsCfg, s.ServCtx)
+		if err != nil {
+			busListener.Close()
+			log.Warn("server", nil, "fail to create pub/sub bus", log.Errors(err))
+			return err
+		}
+		go s.Bus.Serve()
+	}
 
 	if s.ServCtx.SuperMode == conncontext.SupervisedSystemd {
 		err = util.SdNotify("STATUS=Ready to accept connections\n")
@@ -34,6 +34,8 @@ const (
 	ResponsesOk             = "+OK\r\n"
 	ResponsesNullBulkString = "$-1\r\n"
 	ResponsesPong           = "+PONG\r\n"
+	ResponsesQueued         = "+QUEUED\r\n"
+	ResponsesNullArray      = "*-1\r\n"
 
 	// Shared command error responses
 	ResponsesNoautherr    = "-NOAUTH Authentication required.\r\n"
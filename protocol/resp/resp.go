@@ -32,6 +32,13 @@ var (
 	ErrInvalidProtocol = errors.New("invalid protocol")
 )
 
+// ErrorReply is a RESP error reply, the message is without the leading '-'
+type ErrorReply string
+
+func (e ErrorReply) Error() string {
+	return string(e)
+}
+
 ///////////////////////////////////////////////////////////////////////////////////////////////////
 // Encoder //
 ///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -169,6 +176,104 @@ func (r *Decoder) BulkString(plainReq *[]byte) ([]byte, error) {
 	return body[:len(body)-2], nil
 }
 
+// Array parses a RESP array of bulkstrings
+func (r *Decoder) Array() ([][]byte, error) {
+	line, err := r.bufReader.ReadBytes('\n')
+	if err != nil {
+		log.Warn("decoder", nil, "fail to read bytes", log.Errors(err))
+		return nil, err
+	}
+
+	l := len(line)
+	if l < len("*0\r\n") || line[l-2] != '\r' || line[0] != '*' {
+		return nil, ErrInvalidProtocol
+	}
+
+	argc, err := strconv.Atoi(util.BytesToString(line[1 : l-2]))
+	if err != nil || argc < 0 {
+		log.Warn("decoder", nil, "fail to read bytes", log.Errors(err))
+		return nil, ErrInvalidProtocol
+	}
+
+	var plainReq []byte
+	argv := make([][]byte, argc)
+	for i := 0; i < argc; i++ {
+		argv[i], err = r.BulkString(&plainReq)
+		if err != nil {
+			return nil, err
+		}
+		plainReq = plainReq[:0]
+	}
+	return argv, nil
+}
+
+// Reply parses a RESP reply of any type: simple strings are decoded to string,
+// integers to int64, bulk strings to []byte, arrays to []interface{}, null bulk
+// strings and null arrays to nil. An error reply is returned as ErrorReply
+func (r *Decoder) Reply() (interface{}, error) {
+	val, err := r.value()
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := val.(ErrorReply); ok {
+		return nil, e
+	}
+	return val, nil
+}
+
+// value parses a RESP value, error replies are returned as values
+func (r *Decoder) value() (interface{}, error) {
+	line, err := r.bufReader.ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+	l := len(line)
+	if l < len("+\r\n") || line[l-2] != '\r' {
+		return nil, ErrInvalidProtocol
+	}
+	body := util.BytesToString(line[1 : l-2])
+
+	switch line[0] {
+	case '+':
+		return string(body), nil
+	case '-':
+		return ErrorReply(body), nil
+	case ':':
+		val, err := strconv.ParseInt(body, 10, 64)
+		if err != nil {
+			return nil, ErrInvalidProtocol
+		}
+		return val, nil
+	case '$':
+		msgLen, err := strconv.Atoi(body)
+		if err != nil || msgLen < -1 {
+			return nil, ErrInvalidProtocol
+		} else if msgLen == -1 {
+			return nil, nil
+		}
+		buf := make([]byte, msgLen+2) // end with \r\n
+		if _, err = io.ReadFull(r.bufReader, buf); err != nil {
+			return nil, ErrInvalidProtocol
+		}
+		return buf[:msgLen], nil
+	case '*':
+		num, err := strconv.Atoi(body)
+		if err != nil || num < -1 {
+			return nil, ErrInvalidProtocol
+		} else if num == -1 {
+			return nil, nil
+		}
+		arr := make([]interface{}, num)
+		for i := range arr {
+			if arr[i], err = r.value(); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	}
+	return nil, ErrInvalidProtocol
+}
+
 func (r *Decoder) Integer() (int, error) {
 	line, err := r.bufReader.ReadBytes('\n')
 	if err != nil {
@@ -194,3 +299,15 @@ func DecInteger(msg string) (int, error) {
 	val, err := d.Integer()
 	return val, err
 }
+
+// DecReply decodes a RESP reply, see Decoder.Reply
+func DecReply(msg string) (interface{}, error) {
+	d := NewDecoder(bufio.NewReader(bytes.NewBufferString(msg)))
+	return d.Reply()
+}
+
+// DecArray decodes a RESP array of bulkstrings, e.g. a plain request
+func DecArray(msg []byte) ([][]byte, error) {
+	d := NewDecoder(bufio.NewReader(bytes.NewReader(msg)))
+	return d.Array()
+}
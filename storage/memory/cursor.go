@@ -0,0 +1,64 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+// cursorPruneInterval is the number of cursors saved between two prunes of the expired cursors
+const cursorPruneInterval = 1024
+
+type cursorID struct {
+	db int64
+	id uint64
+}
+
+type savedCursor struct {
+	pos    []byte
+	expire time.Time
+}
+
+// SaveCursor keeps the position of an iteration under id for ttl
+func (s *Storage) SaveCursor(ctx context.Context, db int64, id uint64, pos []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	now := time.Now()
+	if s.cursorSaves++; s.cursorSaves%cursorPruneInterval == 0 {
+		for cid, c := range s.cursors {
+			if !c.expire.After(now) {
+				delete(s.cursors, cid)
+			}
+		}
+	}
+	s.cursors[cursorID{db: db, id: id}] = savedCursor{pos: append([]byte{}, pos...), expire: now.Add(ttl)}
+	return nil
+}
+
+// LoadCursor returns the position saved under id, nil if it not exists or expired
+func (s *Storage) LoadCursor(ctx context.Context, db int64, id uint64) ([]byte, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	c, ok := s.cursors[cursorID{db: db, id: id}]
+	if !ok || !c.expire.After(time.Now()) {
+		return nil, nil
+	}
+	return c.pos, nil
+}
@@ -0,0 +1,297 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/oceanbase/modis/util"
+)
+
+// HGet hash get
+func (s *Storage) HGet(ctx context.Context, db int64, key []byte, field []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeHash); err != nil {
+		return nil, err
+	}
+	e := s.getDB(db).hashes.get(key)
+	if e == nil {
+		return nil, nil
+	}
+	return e.val[string(field)], nil
+}
+
+// HDel delete hash fields, returns the number of fields deleted
+func (s *Storage) HDel(ctx context.Context, db int64, key []byte, fields [][]byte) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeHash); err != nil {
+		return 0, err
+	}
+	d := s.getDB(db)
+	e := d.hashes.get(key)
+	if e == nil {
+		return 0, nil
+	}
+	var deleteNum int64
+	for _, field := range fields {
+		if _, ok := e.val[string(field)]; ok {
+			delete(e.val, string(field))
+			deleteNum++
+		}
+	}
+	if len(e.val) == 0 {
+		d.hashes.remove(key)
+	}
+	return deleteNum, nil
+}
+
+// HGetAll returns all fields and values of the hash stored at key
+func (s *Storage) HGetAll(ctx context.Context, db int64, key []byte) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeHash); err != nil {
+		return nil, err
+	}
+	e := s.getDB(db).hashes.get(key)
+	if e == nil {
+		return [][]byte{}, nil
+	}
+	res := make([][]byte, 0, 2*len(e.val))
+	for _, field := range sortedKeys(e.val) {
+		res = append(res, []byte(field), e.val[field])
+	}
+	return res, nil
+}
+
+// HKeys returns all field names in the hash stored at key
+func (s *Storage) HKeys(ctx context.Context, db int64, key []byte) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeHash); err != nil {
+		return nil, err
+	}
+	e := s.getDB(db).hashes.get(key)
+	if e == nil {
+		return [][]byte{}, nil
+	}
+	res := make([][]byte, 0, len(e.val))
+	for _, field := range sortedKeys(e.val) {
+		res = append(res, []byte(field))
+	}
+	return res, nil
+}
+
+// HVals returns all values in the hash stored at key
+func (s *Storage) HVals(ctx context.Context, db int64, key []byte) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeHash); err != nil {
+		return nil, err
+	}
+	e := s.getDB(db).hashes.get(key)
+	if e == nil {
+		return [][]byte{}, nil
+	}
+	res := make([][]byte, 0, len(e.val))
+	for _, field := range sortedKeys(e.val) {
+		res = append(res, e.val[field])
+	}
+	return res, nil
+}
+
+// HLen returns the number of fields contained in the hash stored at key
+func (s *Storage) HLen(ctx context.Context, db int64, key []byte) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeHash); err != nil {
+		return 0, err
+	}
+	e := s.getDB(db).hashes.get(key)
+	if e == nil {
+		return 0, nil
+	}
+	return int64(len(e.val)), nil
+}
+
+// HSetNx sets field in the hash stored at key to value, only if field does not yet exist
+func (s *Storage) HSetNx(ctx context.Context, db int64, key []byte, field []byte, value []byte) (int, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeHash); err != nil {
+		return 0, err
+	}
+	d := s.getDB(db)
+	e := d.hashes.get(key)
+	if e == nil {
+		e = d.hashes.set(key, make(map[string][]byte))
+	}
+	if _, ok := e.val[string(field)]; ok {
+		return 0, nil
+	}
+	e.val[string(field)] = copyBytes(value)
+	return 1, nil
+}
+
+// HMGet returns the values associated with the specified fields in the hash stored at key
+func (s *Storage) HMGet(ctx context.Context, db int64, key []byte, fields [][]byte) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeHash); err != nil {
+		return nil, err
+	}
+	e := s.getDB(db).hashes.get(key)
+	res := make([][]byte, 0, len(fields))
+	for _, field := range fields {
+		if e == nil {
+			res = append(res, nil)
+		} else {
+			res = append(res, e.val[string(field)])
+		}
+	}
+	return res, nil
+}
+
+// HIncrBy Add value from the value of the key.
+// If the key does not exist, value is written and value is returned
+// Returns the value add value when key is present;
+func (s *Storage) HIncrBy(ctx context.Context, db int64, key []byte, field []byte, value []byte) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeHash); err != nil {
+		return 0, err
+	}
+	incr, err := strconv.ParseInt(util.BytesToString(value), 10, 64)
+	if err != nil {
+		return -1, errNotInteger
+	}
+
+	d := s.getDB(db)
+	var num int64
+	e := d.hashes.get(key)
+	if e != nil {
+		if old, ok := e.val[string(field)]; ok {
+			num, err = strconv.ParseInt(util.BytesToString(old), 10, 64)
+			if err != nil {
+				return -1, errNotInteger
+			}
+		}
+	}
+	if (incr < 0 && num < 0 && incr < math.MinInt64-num) ||
+		(incr > 0 && num > 0 && incr > math.MaxInt64-num) {
+		return -1, errOverflow
+	}
+	num += incr
+	if e == nil {
+		e = d.hashes.set(key, make(map[string][]byte))
+	}
+	e.val[string(field)] = []byte(strconv.FormatInt(num, 10))
+	return num, nil
+}
+
+// HIncrByFloat Add value from the value of the key.
+// If the key does not exist, value is written and value is returned
+// Returns the value add value when key is present;
+func (s *Storage) HIncrByFloat(ctx context.Context, db int64, key []byte, field []byte, value []byte) (float64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeHash); err != nil {
+		return 0, err
+	}
+	incr, err := parseFloat(value)
+	if err != nil {
+		return -1, err
+	}
+
+	d := s.getDB(db)
+	var num float64
+	e := d.hashes.get(key)
+	if e != nil {
+		if old, ok := e.val[string(field)]; ok {
+			num, err = parseFloat(old)
+			if err != nil {
+				return -1, err
+			}
+		}
+	}
+	num += incr
+	if math.IsNaN(num) || math.IsInf(num, 0) {
+		return -1, errors.New("increment would produce NaN or Infinity")
+	}
+	if e == nil {
+		e = d.hashes.set(key, make(map[string][]byte))
+	}
+	e.val[string(field)] = []byte(strconv.FormatFloat(num, 'f', -1, 64))
+	return num, nil
+}
+
+// HSet sets the fields of the hash, returns the number of fields added
+func (s *Storage) HSet(ctx context.Context, db int64, key []byte, fieldValues map[string][]byte) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeHash); err != nil {
+		return 0, err
+	}
+	d := s.getDB(db)
+	e := d.hashes.get(key)
+	if e == nil {
+		e = d.hashes.set(key, make(map[string][]byte))
+	}
+	var added int64
+	for field, value := range fieldValues {
+		if _, ok := e.val[field]; !ok {
+			added++
+		}
+		e.val[field] = copyBytes(value)
+	}
+	return added, nil
+}
+
+// HScan returns at most count fields greater than after in ascending order along with their values
+func (s *Storage) HScan(ctx context.Context, db int64, key []byte, after []byte, count int64) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeHash); err != nil {
+		return nil, err
+	}
+	e := s.getDB(db).hashes.get(key)
+	if e == nil {
+		return [][]byte{}, nil
+	}
+	page := pageAfter(sortedKeys(e.val), after, count)
+	res := make([][]byte, 0, 2*len(page))
+	for _, field := range page {
+		res = append(res, []byte(field), e.val[field])
+	}
+	return res, nil
+}
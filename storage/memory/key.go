@@ -0,0 +1,191 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/oceanbase/modis/storage"
+)
+
+// Type get the type of the key, nil if the key not exists
+func (s *Storage) Type(ctx context.Context, db int64, key []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	for _, tk := range s.getDB(db).keyspaces() {
+		if tk.ks.exists(key) {
+			return []byte(tk.typeName), nil
+		}
+	}
+	return nil, nil
+}
+
+// Exists check the number of keys that exist
+func (s *Storage) Exists(ctx context.Context, db int64, keys [][]byte) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	var existsNum int64
+	for _, tk := range s.getDB(db).keyspaces() {
+		for _, key := range keys {
+			if tk.ks.exists(key) {
+				existsNum++
+			}
+		}
+	}
+	return existsNum, nil
+}
+
+// Delete delete all keys
+func (s *Storage) Delete(ctx context.Context, db int64, keys [][]byte) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	var deleteNum int64
+	for _, tk := range s.getDB(db).keyspaces() {
+		for _, key := range keys {
+			if tk.ks.remove(key) {
+				deleteNum++
+			}
+		}
+	}
+	return deleteNum, nil
+}
+
+// Expire sets a timeout on key
+func (s *Storage) Expire(ctx context.Context, db int64, key []byte, at time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	expireNum := 0
+	for _, tk := range s.getDB(db).keyspaces() {
+		if tk.ks.expire(key, at) {
+			expireNum = 1
+		}
+	}
+	return expireNum, nil
+}
+
+// Persist removes the existing timeout on key, turning the key from volatile to persistent
+func (s *Storage) Persist(ctx context.Context, db int64, key []byte) (int, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	res := 0
+	for _, tk := range s.getDB(db).keyspaces() {
+		if tk.ks.persist(key) {
+			res = 1
+		}
+	}
+	return res, nil
+}
+
+// TTL returns the remaining time to live of a key, -2 if the key not exists
+// and -1 if the key has no associated expire
+func (s *Storage) TTL(ctx context.Context, db int64, key []byte) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	for _, tk := range s.getDB(db).keyspaces() {
+		if sub := tk.ks.ttl(key); sub >= -1 {
+			return sub, nil
+		}
+	}
+	return -2, nil
+}
+
+// Scan returns at most count keys of type typ greater than after in ascending order,
+// no keys are returned once the end is reached
+func (s *Storage) Scan(ctx context.Context, db int64, typ string, after []byte, count int64) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	for _, tk := range s.getDB(db).keyspaces() {
+		if tk.typeName != typ {
+			continue
+		}
+		page := pageAfter(tk.ks.keys(), after, count)
+		res := make([][]byte, 0, len(page))
+		for _, key := range page {
+			res = append(res, []byte(key))
+		}
+		return res, nil
+	}
+	return nil, nil
+}
+
+// Copy copies the value of src in srcDB to dst in dstDB along with its ttl
+func (s *Storage) Copy(ctx context.Context, srcDB int64, src []byte, dstDB int64, dst []byte, replace bool) (bool, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	idx := s.copyKey(srcDB, src, dstDB, dst, replace)
+	return idx > 0, nil
+}
+
+// Rename moves the value of src in srcDB to dst in dstDB along with its ttl
+func (s *Storage) Rename(ctx context.Context, srcDB int64, src []byte, dstDB int64, dst []byte, replace bool) (bool, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	idx := s.copyKey(srcDB, src, dstDB, dst, replace)
+	if idx < 0 {
+		return false, storage.ErrNoSuchKey
+	}
+	if idx == 0 {
+		return false, nil
+	}
+	if srcDB != dstDB || !bytes.Equal(src, dst) {
+		s.getDB(srcDB).keyspaces()[idx-1].ks.remove(src)
+	}
+	return true, nil
+}
+
+// copyKey copies src to dst, returns -1 if src not exists, 0 if dst exists and replace
+// is not set, otherwise the 1-based position of the keyspace of src in keyspaces().
+// Callers must hold s.mu.
+func (s *Storage) copyKey(srcDB int64, src []byte, dstDB int64, dst []byte, replace bool) int {
+	srcKeyspaces := s.getDB(srcDB).keyspaces()
+	idx := -1
+	for i, tk := range srcKeyspaces {
+		if tk.ks.exists(src) {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return -1
+	}
+	if srcDB == dstDB && bytes.Equal(src, dst) {
+		return idx + 1
+	}
+
+	dstKeyspaces := s.getDB(dstDB).keyspaces()
+	for _, tk := range dstKeyspaces {
+		if tk.ks.exists(dst) {
+			if !replace {
+				return 0
+			}
+			tk.ks.remove(dst)
+		}
+	}
+	srcKeyspaces[idx].ks.copyTo(src, dstKeyspaces[idx].ks, dst)
+	return idx + 1
+}
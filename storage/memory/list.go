@@ -0,0 +1,391 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/oceanbase/modis/protocol/resp"
+)
+
+// listIndex converts a redis list index (negative counts from the tail) to a slice index
+func listIndex(index int64, length int) int64 {
+	if index < 0 {
+		index += int64(length)
+	}
+	return index
+}
+
+// listRange clamps [start, stop] to the list, returns (0, -1) if the range is empty
+func listRange(start int64, stop int64, length int) (int, int) {
+	start = listIndex(start, length)
+	stop = listIndex(stop, length)
+	if start < 0 {
+		start = 0
+	}
+	if stop >= int64(length) {
+		stop = int64(length) - 1
+	}
+	if start > stop || start >= int64(length) {
+		return 0, -1
+	}
+	return int(start), int(stop)
+}
+
+// push inserts values at the head or the tail, only into an existing list if exists is set
+func (s *Storage) push(db int64, key []byte, values [][]byte, head bool, exists bool) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	d := s.getDB(db)
+	if err := d.checkType(key, typeList); err != nil {
+		return 0, err
+	}
+	e := d.lists.get(key)
+	if e == nil {
+		if exists {
+			return 0, nil
+		}
+		e = d.lists.set(key, nil)
+	}
+	for _, value := range values {
+		if head {
+			e.val = append([][]byte{copyBytes(value)}, e.val...)
+		} else {
+			e.val = append(e.val, copyBytes(value))
+		}
+	}
+	return int64(len(e.val)), nil
+}
+
+// LPush inserts the values at the head of the list, returns the length of the list
+func (s *Storage) LPush(ctx context.Context, db int64, key []byte, values [][]byte) (int64, error) {
+	return s.push(db, key, values, true, false)
+}
+
+// LPushX inserts the values at the head of the list only if the list exists
+func (s *Storage) LPushX(ctx context.Context, db int64, key []byte, values [][]byte) (int64, error) {
+	return s.push(db, key, values, true, true)
+}
+
+// RPush inserts the values at the tail of the list, returns the length of the list
+func (s *Storage) RPush(ctx context.Context, db int64, key []byte, values [][]byte) (int64, error) {
+	return s.push(db, key, values, false, false)
+}
+
+// RPushX inserts the values at the tail of the list only if the list exists
+func (s *Storage) RPushX(ctx context.Context, db int64, key []byte, values [][]byte) (int64, error) {
+	return s.push(db, key, values, false, true)
+}
+
+// pop removes and returns at most count elements from the head or the tail
+func (s *Storage) pop(db int64, key []byte, head bool, count int64) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	d := s.getDB(db)
+	if err := d.checkType(key, typeList); err != nil {
+		return nil, err
+	}
+	e := d.lists.get(key)
+	if e == nil {
+		return nil, nil
+	}
+	n := int(min(count, int64(len(e.val))))
+	values := make([][]byte, n)
+	if head {
+		copy(values, e.val[:n])
+		e.val = e.val[n:]
+	} else {
+		for i := range values {
+			values[i] = e.val[len(e.val)-1-i]
+		}
+		e.val = e.val[:len(e.val)-n]
+	}
+	if len(e.val) == 0 {
+		d.lists.remove(key)
+	}
+	return values, nil
+}
+
+// LPop removes and returns the first element of the list, nil if the list not exists
+func (s *Storage) LPop(ctx context.Context, db int64, key []byte) ([]byte, error) {
+	values, err := s.pop(db, key, true, 1)
+	if err != nil || len(values) == 0 {
+		return nil, err
+	}
+	return values[0], nil
+}
+
+// RPop removes and returns the last element of the list, nil if the list not exists
+func (s *Storage) RPop(ctx context.Context, db int64, key []byte) ([]byte, error) {
+	values, err := s.pop(db, key, false, 1)
+	if err != nil || len(values) == 0 {
+		return nil, err
+	}
+	return values[0], nil
+}
+
+// LPopCount removes and returns at most count elements from the head, nil if the list not exists
+func (s *Storage) LPopCount(ctx context.Context, db int64, key []byte, count int64) ([][]byte, error) {
+	return s.pop(db, key, true, count)
+}
+
+// RPopCount removes and returns at most count elements from the tail, nil if the list not exists
+func (s *Storage) RPopCount(ctx context.Context, db int64, key []byte, count int64) ([][]byte, error) {
+	return s.pop(db, key, false, count)
+}
+
+// LMove pops an element from src and pushes it to dst, returns the element, nil if src not exists
+func (s *Storage) LMove(ctx context.Context, db int64, src []byte, dst []byte, srcLeft bool, dstLeft bool) ([]byte, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	d := s.getDB(db)
+	if err := d.checkType(src, typeList); err != nil {
+		return nil, err
+	}
+	if err := d.checkType(dst, typeList); err != nil {
+		return nil, err
+	}
+	srcEntry := d.lists.get(src)
+	if srcEntry == nil {
+		return nil, nil
+	}
+	var value []byte
+	if srcLeft {
+		value, srcEntry.val = srcEntry.val[0], srcEntry.val[1:]
+	} else {
+		value, srcEntry.val = srcEntry.val[len(srcEntry.val)-1], srcEntry.val[:len(srcEntry.val)-1]
+	}
+	if len(srcEntry.val) == 0 {
+		d.lists.remove(src)
+	}
+
+	dstEntry := d.lists.get(dst)
+	if dstEntry == nil {
+		dstEntry = d.lists.set(dst, nil)
+	}
+	if dstLeft {
+		dstEntry.val = append([][]byte{value}, dstEntry.val...)
+	} else {
+		dstEntry.val = append(dstEntry.val, value)
+	}
+	return copyBytes(value), nil
+}
+
+// LIndex returns the element at index, nil if index is out of range
+func (s *Storage) LIndex(ctx context.Context, db int64, key []byte, index int64) ([]byte, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeList); err != nil {
+		return nil, err
+	}
+	e := s.getDB(db).lists.get(key)
+	if e == nil {
+		return nil, nil
+	}
+	index = listIndex(index, len(e.val))
+	if index < 0 || index >= int64(len(e.val)) {
+		return nil, nil
+	}
+	return e.val[index], nil
+}
+
+// LPos returns the indexes of the matches of element
+func (s *Storage) LPos(ctx context.Context, db int64, key []byte, element []byte, rank int64, count int64, maxLen int64) ([]int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeList); err != nil {
+		return nil, err
+	}
+	e := s.getDB(db).lists.get(key)
+	if e == nil {
+		return nil, nil
+	}
+	var indexes []int64
+	length := int64(len(e.val))
+	for scanned := int64(0); scanned < length && (maxLen == 0 || scanned < maxLen); scanned++ {
+		index := scanned
+		if rank < 0 {
+			index = length - 1 - scanned
+		}
+		if !bytes.Equal(e.val[index], element) {
+			continue
+		}
+		if rank > 1 {
+			rank--
+		} else if rank < -1 {
+			rank++
+		} else {
+			indexes = append(indexes, index)
+			if count != 0 && int64(len(indexes)) == count {
+				break
+			}
+		}
+	}
+	return indexes, nil
+}
+
+// LSet sets the element at index to value
+func (s *Storage) LSet(ctx context.Context, db int64, key []byte, index int64, value []byte) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeList); err != nil {
+		return err
+	}
+	e := s.getDB(db).lists.get(key)
+	if e == nil {
+		return resp.ErrorReply("ERR no such key")
+	}
+	index = listIndex(index, len(e.val))
+	if index < 0 || index >= int64(len(e.val)) {
+		return resp.ErrorReply("ERR index out of range")
+	}
+	e.val[index] = copyBytes(value)
+	return nil
+}
+
+// LRange returns the elements in [start, stop]
+func (s *Storage) LRange(ctx context.Context, db int64, key []byte, start int64, stop int64) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeList); err != nil {
+		return nil, err
+	}
+	e := s.getDB(db).lists.get(key)
+	if e == nil {
+		return [][]byte{}, nil
+	}
+	from, to := listRange(start, stop, len(e.val))
+	res := make([][]byte, to+1-from)
+	copy(res, e.val[from:to+1])
+	return res, nil
+}
+
+// LTrim trims the list to the elements in [start, stop]
+func (s *Storage) LTrim(ctx context.Context, db int64, key []byte, start int64, stop int64) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeList); err != nil {
+		return err
+	}
+	d := s.getDB(db)
+	e := d.lists.get(key)
+	if e == nil {
+		return nil
+	}
+	from, to := listRange(start, stop, len(e.val))
+	e.val = e.val[from : to+1]
+	if len(e.val) == 0 {
+		d.lists.remove(key)
+	}
+	return nil
+}
+
+// LInsert inserts value before or after pivot, returns the length of the list,
+// -1 if pivot is not found and 0 if the list not exists
+func (s *Storage) LInsert(ctx context.Context, db int64, key []byte, before bool, pivot []byte, value []byte) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeList); err != nil {
+		return 0, err
+	}
+	e := s.getDB(db).lists.get(key)
+	if e == nil {
+		return 0, nil
+	}
+	for i, elem := range e.val {
+		if !bytes.Equal(elem, pivot) {
+			continue
+		}
+		if !before {
+			i++
+		}
+		val := make([][]byte, 0, len(e.val)+1)
+		val = append(val, e.val[:i]...)
+		val = append(val, copyBytes(value))
+		e.val = append(val, e.val[i:]...)
+		return int64(len(e.val)), nil
+	}
+	return -1, nil
+}
+
+// LLen returns the length of the list
+func (s *Storage) LLen(ctx context.Context, db int64, key []byte) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeList); err != nil {
+		return 0, err
+	}
+	e := s.getDB(db).lists.get(key)
+	if e == nil {
+		return 0, nil
+	}
+	return int64(len(e.val)), nil
+}
+
+// LRem removes count occurrences of value, from the tail if count < 0 and all of them if count = 0
+func (s *Storage) LRem(ctx context.Context, db int64, key []byte, count int64, value []byte) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeList); err != nil {
+		return 0, err
+	}
+	d := s.getDB(db)
+	e := d.lists.get(key)
+	if e == nil {
+		return 0, nil
+	}
+	limit := count
+	if limit < 0 {
+		limit = -limit
+	}
+	var removed int64
+	keep := make([]bool, len(e.val))
+	for i := range e.val {
+		pos := i
+		if count < 0 {
+			pos = len(e.val) - 1 - i
+		}
+		if (limit == 0 || removed < limit) && bytes.Equal(e.val[pos], value) {
+			removed++
+			continue
+		}
+		keep[pos] = true
+	}
+	val := make([][]byte, 0, len(e.val)-int(removed))
+	for i, elem := range e.val {
+		if keep[i] {
+			val = append(val, elem)
+		}
+	}
+	e.val = val
+	if len(e.val) == 0 {
+		d.lists.remove(key)
+	}
+	return removed, nil
+}
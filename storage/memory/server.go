@@ -0,0 +1,48 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"errors"
+
+	"github.com/oceanbase/modis/storage"
+)
+
+func (s *Storage) GetTableInfo(ctx context.Context, db int64, tableName string) (*storage.TableInfo, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	ks := s.getDB(db).keyspaceOf(tableName)
+	if ks == nil {
+		return nil, errors.New("table not exists: " + tableName)
+	}
+	keys, expires := ks.info()
+	return &storage.TableInfo{Keys: keys, Expires: expires}, nil
+}
+
+// FlushTable removes all keys of db in the table, returns the number of keys removed
+func (s *Storage) FlushTable(ctx context.Context, db int64, tableName string) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	ks := s.getDB(db).keyspaceOf(tableName)
+	if ks == nil {
+		return 0, errors.New("table not exists: " + tableName)
+	}
+	return ks.clear(), nil
+}
@@ -0,0 +1,331 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"errors"
+)
+
+// SCard returns the set cardinality (number of elements) of the set stored at key
+func (s *Storage) SCard(ctx context.Context, db int64, key []byte) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeSet); err != nil {
+		return 0, err
+	}
+	e := s.getDB(db).sets.get(key)
+	if e == nil {
+		return 0, nil
+	}
+	return int64(len(e.val)), nil
+}
+
+// SRem removes the specified members from the set stored at key
+func (s *Storage) SRem(ctx context.Context, db int64, key []byte, members [][]byte) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeSet); err != nil {
+		return 0, err
+	}
+	d := s.getDB(db)
+	e := d.sets.get(key)
+	if e == nil {
+		return 0, nil
+	}
+	var deleteNum int64
+	for _, member := range members {
+		if _, ok := e.val[string(member)]; ok {
+			delete(e.val, string(member))
+			deleteNum++
+		}
+	}
+	if len(e.val) == 0 {
+		d.sets.remove(key)
+	}
+	return deleteNum, nil
+}
+
+// SIsmember returns if member is a member of the set stored at key
+func (s *Storage) SIsmember(ctx context.Context, db int64, key []byte, member []byte) (int, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeSet); err != nil {
+		return 0, err
+	}
+	e := s.getDB(db).sets.get(key)
+	if e == nil {
+		return 0, nil
+	}
+	if _, ok := e.val[string(member)]; ok {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// SMembers returns all the members of the set value stored at key
+func (s *Storage) SMembers(ctx context.Context, db int64, key []byte) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeSet); err != nil {
+		return nil, err
+	}
+	e := s.getDB(db).sets.get(key)
+	if e == nil {
+		return [][]byte{}, nil
+	}
+	return membersOf(e.val), nil
+}
+
+// Smove move member from src key to dest key
+func (s *Storage) Smove(ctx context.Context, db int64, src []byte, dst []byte, member []byte) (int, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	d := s.getDB(db)
+	if err := d.checkType(src, typeSet); err != nil {
+		return 0, err
+	}
+	if err := d.checkType(dst, typeSet); err != nil {
+		return 0, err
+	}
+	srcEntry := d.sets.get(src)
+	if srcEntry == nil {
+		return 0, nil
+	}
+	if _, ok := srcEntry.val[string(member)]; !ok {
+		return 0, nil
+	}
+	delete(srcEntry.val, string(member))
+	if len(srcEntry.val) == 0 {
+		d.sets.remove(src)
+	}
+
+	dstEntry := d.sets.get(dst)
+	if dstEntry == nil {
+		dstEntry = d.sets.set(dst, make(map[string]struct{}))
+	}
+	dstEntry.val[string(member)] = struct{}{}
+	return 1, nil
+}
+
+// SPop randomly delete count members
+func (s *Storage) SPop(ctx context.Context, db int64, key []byte, count int) ([][]byte, error) {
+	if count < 0 {
+		return nil, errors.New("value is out of range, must be positive")
+	}
+
+	s.mu.Lock()
+	defer s.unlock()
+
+	d := s.getDB(db)
+	if err := d.checkType(key, typeSet); err != nil {
+		return nil, err
+	}
+	e := d.sets.get(key)
+	if e == nil {
+		return [][]byte{}, nil
+	}
+	members := make([][]byte, 0, count)
+	for _, member := range randomKeys(e.val, count) {
+		delete(e.val, member)
+		members = append(members, []byte(member))
+	}
+	if len(e.val) == 0 {
+		d.sets.remove(key)
+	}
+	return members, nil
+}
+
+// SRandMember randomly get count members
+func (s *Storage) SRandMember(ctx context.Context, db int64, key []byte, count int) ([][]byte, error) {
+	if count < 0 {
+		return nil, errors.New("value is out of range, must be positive")
+	}
+
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeSet); err != nil {
+		return nil, err
+	}
+	e := s.getDB(db).sets.get(key)
+	if e == nil {
+		return [][]byte{}, nil
+	}
+	members := make([][]byte, 0, count)
+	for _, member := range randomKeys(e.val, count) {
+		members = append(members, []byte(member))
+	}
+	return members, nil
+}
+
+// membersOf returns the members of a set in ascending order
+func membersOf(set map[string]struct{}) [][]byte {
+	members := make([][]byte, 0, len(set))
+	for _, member := range sortedKeys(set) {
+		members = append(members, []byte(member))
+	}
+	return members
+}
+
+// SAdd adds the members to the set, returns the number of members added
+func (s *Storage) SAdd(ctx context.Context, db int64, key []byte, members [][]byte) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeSet); err != nil {
+		return 0, err
+	}
+	d := s.getDB(db)
+	e := d.sets.get(key)
+	if e == nil {
+		e = d.sets.set(key, make(map[string]struct{}))
+	}
+	var added int64
+	for _, member := range members {
+		if _, ok := e.val[string(member)]; !ok {
+			e.val[string(member)] = struct{}{}
+			added++
+		}
+	}
+	return added, nil
+}
+
+// SUnion returns the members of the union of the sets
+func (s *Storage) SUnion(ctx context.Context, db int64, keys [][]byte) ([][]byte, error) {
+	return s.setOperate(db, setUnion, keys)
+}
+
+// SInter returns the members of the intersection of the sets
+func (s *Storage) SInter(ctx context.Context, db int64, keys [][]byte) ([][]byte, error) {
+	return s.setOperate(db, setInter, keys)
+}
+
+// SDiff returns the members of the first set that are not in the other sets
+func (s *Storage) SDiff(ctx context.Context, db int64, keys [][]byte) ([][]byte, error) {
+	return s.setOperate(db, setDiff, keys)
+}
+
+// SUnionStore stores the union of the sets in dst, returns the size of dst
+func (s *Storage) SUnionStore(ctx context.Context, db int64, dst []byte, keys [][]byte) (int64, error) {
+	return s.setOperateStore(db, setUnion, dst, keys)
+}
+
+// SInterStore stores the intersection of the sets in dst, returns the size of dst
+func (s *Storage) SInterStore(ctx context.Context, db int64, dst []byte, keys [][]byte) (int64, error) {
+	return s.setOperateStore(db, setInter, dst, keys)
+}
+
+// SDiffStore stores the difference of the sets in dst, returns the size of dst
+func (s *Storage) SDiffStore(ctx context.Context, db int64, dst []byte, keys [][]byte) (int64, error) {
+	return s.setOperateStore(db, setDiff, dst, keys)
+}
+
+type setOp int
+
+const (
+	setUnion setOp = iota
+	setInter
+	setDiff
+)
+
+func (s *Storage) setOperate(db int64, op setOp, keys [][]byte) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	res, err := s.getDB(db).setOperate(op, keys)
+	if err != nil {
+		return nil, err
+	}
+	return membersOf(res), nil
+}
+
+// setOperateStore stores the result in dst, which is overwritten whatever type it holds
+func (s *Storage) setOperateStore(db int64, op setOp, dst []byte, keys [][]byte) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	d := s.getDB(db)
+	res, err := d.setOperate(op, keys)
+	if err != nil {
+		return 0, err
+	}
+	d.removeOtherTypes(dst, typeSet)
+	d.sets.remove(dst)
+	if len(res) > 0 {
+		d.sets.set(dst, res)
+	}
+	return int64(len(res)), nil
+}
+
+// setOperate computes the union, intersection or difference of the sets stored at keys
+func (d *database) setOperate(op setOp, keys [][]byte) (map[string]struct{}, error) {
+	for _, key := range keys {
+		if err := d.checkType(key, typeSet); err != nil {
+			return nil, err
+		}
+	}
+	res := make(map[string]struct{})
+	for i, key := range keys {
+		var members map[string]struct{}
+		if e := d.sets.get(key); e != nil {
+			members = e.val
+		}
+		switch {
+		case i == 0 || op == setUnion:
+			for member := range members {
+				res[member] = struct{}{}
+			}
+		case op == setInter:
+			for member := range res {
+				if _, ok := members[member]; !ok {
+					delete(res, member)
+				}
+			}
+		case op == setDiff:
+			for member := range members {
+				delete(res, member)
+			}
+		}
+	}
+	return res, nil
+}
+
+// SScan returns at most count members greater than after in ascending order
+func (s *Storage) SScan(ctx context.Context, db int64, key []byte, after []byte, count int64) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeSet); err != nil {
+		return nil, err
+	}
+	e := s.getDB(db).sets.get(key)
+	if e == nil {
+		return [][]byte{}, nil
+	}
+	page := pageAfter(sortedKeys(e.val), after, count)
+	res := make([][]byte, 0, len(page))
+	for _, member := range page {
+		res = append(res, []byte(member))
+	}
+	return res, nil
+}
@@ -0,0 +1,362 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/oceanbase/modis/config"
+	"github.com/oceanbase/modis/storage"
+)
+
+const BackendName = "memory"
+
+func init() {
+	storage.Register(BackendName, func(cfg *config.StorageConfig) (storage.Storage, error) {
+		memCfg, err := NewConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return NewStorage(memCfg), nil
+	})
+}
+
+const (
+	stringTableName = "modis_string_table"
+	hashTableName   = "modis_hash_table"
+	listTableName   = "modis_list_table"
+	zsetTableName   = "modis_zset_table"
+	setTableName    = "modis_set_table"
+	streamTableName = "modis_stream_table"
+
+	dbColumnName = "db"
+)
+
+// Storage keeps all data in process memory, every table of the obkv backend
+// is mirrored by a keyspace of the same model. A key lives in one keyspace
+// at a time, commands against a key of another type fail with storage.ErrWrongType.
+type Storage struct {
+	mu  sync.Mutex
+	cfg *Config
+	dbs map[int64]*database
+	// keys found expired while mu is held, they are reported once it is released
+	expired   []expiredKey
+	onExpired func(db int64, key []byte)
+	// positions of the SCAN family iterations, see SaveCursor
+	cursors     map[cursorID]savedCursor
+	cursorSaves int
+	// versions of the keys and dbs touched, see TouchKeys
+	versions    map[versionKey]uint64
+	lastVersion uint64
+}
+
+type expiredKey struct {
+	db  int64
+	key []byte
+}
+
+type database struct {
+	strings keyspace[[]byte]
+	hashes  keyspace[map[string][]byte]
+	lists   keyspace[[][]byte]
+	zsets   keyspace[map[string]float64]
+	sets    keyspace[map[string]struct{}]
+	streams keyspace[*stream]
+}
+
+func NewStorage(cfg *Config) *Storage {
+	return &Storage{
+		cfg: cfg,
+	}
+}
+
+// Initialize init memory storage
+func (s *Storage) Initialize() error {
+	s.dbs = make(map[int64]*database)
+	s.cursors = make(map[cursorID]savedCursor)
+	s.versions = make(map[versionKey]uint64)
+	return nil
+}
+
+// Close memory storage
+func (s *Storage) Close() error {
+	s.mu.Lock()
+	defer s.unlock()
+	s.dbs = make(map[int64]*database)
+	s.cursors = make(map[cursorID]savedCursor)
+	s.versions = make(map[versionKey]uint64)
+	return nil
+}
+
+// OnExpired sets fn to be called with every key found expired
+func (s *Storage) OnExpired(fn func(db int64, key []byte)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onExpired = fn
+}
+
+// unlock releases s.mu and reports the keys found expired meanwhile
+func (s *Storage) unlock() {
+	expired := s.expired
+	s.expired = nil
+	onExpired := s.onExpired
+	s.mu.Unlock()
+	for _, k := range expired {
+		onExpired(k.db, k.key)
+	}
+}
+
+// getDB returns the database with the given id, creating it if needed.
+// Callers must hold s.mu.
+func (s *Storage) getDB(db int64) *database {
+	d, ok := s.dbs[db]
+	if !ok {
+		onExpired := func(key string) {
+			if s.onExpired != nil {
+				s.expired = append(s.expired, expiredKey{db: db, key: []byte(key)})
+			}
+		}
+		d = &database{
+			strings: newKeyspace[[]byte](onExpired),
+			hashes:  newKeyspace[map[string][]byte](onExpired),
+			lists:   newKeyspace[[][]byte](onExpired),
+			zsets:   newKeyspace[map[string]float64](onExpired),
+			sets:    newKeyspace[map[string]struct{}](onExpired),
+			streams: newKeyspace[*stream](onExpired),
+		}
+		s.dbs[db] = d
+	}
+	return d
+}
+
+const (
+	typeString = "string"
+	typeHash   = "hash"
+	typeList   = "list"
+	typeZSet   = "zset"
+	typeSet    = "set"
+	typeStream = "stream"
+)
+
+// typedKeyspace is a keyspace along with the name of the type it stores
+type typedKeyspace struct {
+	typeName string
+	ks       expirable
+}
+
+// keyspaces returns the keyspaces in the type check order: string hash list zset set stream
+func (d *database) keyspaces() []typedKeyspace {
+	return []typedKeyspace{
+		{typeString, d.strings},
+		{typeHash, d.hashes},
+		{typeList, d.lists},
+		{typeZSet, d.zsets},
+		{typeSet, d.sets},
+		{typeStream, d.streams},
+	}
+}
+
+// checkType returns storage.ErrWrongType if key holds a value of a type other than typeName
+func (d *database) checkType(key []byte, typeName string) error {
+	for _, tk := range d.keyspaces() {
+		if tk.typeName != typeName && tk.ks.exists(key) {
+			return storage.ErrWrongType
+		}
+	}
+	return nil
+}
+
+// removeOtherTypes removes key from the keyspaces of types other than typeName,
+// it is used by the commands that overwrite key whatever type it holds, e.g. SET
+func (d *database) removeOtherTypes(key []byte, typeName string) {
+	for _, tk := range d.keyspaces() {
+		if tk.typeName != typeName {
+			tk.ks.remove(key)
+		}
+	}
+}
+
+// keyspaceOf returns the keyspace backing the given table
+func (d *database) keyspaceOf(tableName string) expirable {
+	switch tableName {
+	case stringTableName:
+		return d.strings
+	case hashTableName:
+		return d.hashes
+	case listTableName:
+		return d.lists
+	case zsetTableName:
+		return d.zsets
+	case setTableName:
+		return d.sets
+	case streamTableName:
+		return d.streams
+	}
+	return nil
+}
+
+// entry is a value with an optional expire time
+type entry[T any] struct {
+	val      T
+	expireAt time.Time
+}
+
+func (e *entry[T]) expired(now time.Time) bool {
+	return !e.expireAt.IsZero() && !now.Before(e.expireAt)
+}
+
+// keyspace maps keys to entries, expired entries are removed lazily on access
+// and reported to onExpired
+type keyspace[T any] struct {
+	entries   map[string]*entry[T]
+	onExpired func(key string)
+}
+
+func newKeyspace[T any](onExpired func(key string)) keyspace[T] {
+	return keyspace[T]{entries: make(map[string]*entry[T]), onExpired: onExpired}
+}
+
+// removeExpired removes the expired entry of key
+func (ks keyspace[T]) removeExpired(key string) {
+	delete(ks.entries, key)
+	ks.onExpired(key)
+}
+
+// expirable is the type independent part of a keyspace
+type expirable interface {
+	exists(key []byte) bool
+	remove(key []byte) bool
+	expire(key []byte, at time.Time) bool
+	persist(key []byte) bool
+	ttl(key []byte) time.Duration
+	info() (keys int64, expires int64)
+	keys() []string
+	clear() int64
+	copyTo(key []byte, dst expirable, dstKey []byte) bool
+}
+
+func (ks keyspace[T]) get(key []byte) *entry[T] {
+	e, ok := ks.entries[string(key)]
+	if !ok {
+		return nil
+	}
+	if e.expired(time.Now()) {
+		ks.removeExpired(string(key))
+		return nil
+	}
+	return e
+}
+
+func (ks keyspace[T]) set(key []byte, val T) *entry[T] {
+	e := &entry[T]{val: val}
+	ks.entries[string(key)] = e
+	return e
+}
+
+func (ks keyspace[T]) exists(key []byte) bool {
+	return ks.get(key) != nil
+}
+
+func (ks keyspace[T]) remove(key []byte) bool {
+	if ks.get(key) == nil {
+		return false
+	}
+	delete(ks.entries, string(key))
+	return true
+}
+
+func (ks keyspace[T]) expire(key []byte, at time.Time) bool {
+	e := ks.get(key)
+	if e == nil {
+		return false
+	}
+	e.expireAt = at
+	return true
+}
+
+func (ks keyspace[T]) persist(key []byte) bool {
+	e := ks.get(key)
+	if e == nil || e.expireAt.IsZero() {
+		return false
+	}
+	e.expireAt = time.Time{}
+	return true
+}
+
+// ttl returns -2 if key not exists, -1 if key has no expire time
+func (ks keyspace[T]) ttl(key []byte) time.Duration {
+	e := ks.get(key)
+	if e == nil {
+		return -2
+	}
+	if e.expireAt.IsZero() {
+		return -1
+	}
+	return time.Until(e.expireAt)
+}
+
+func (ks keyspace[T]) info() (int64, int64) {
+	var keys, expires int64
+	now := time.Now()
+	for k, e := range ks.entries {
+		if e.expired(now) {
+			ks.removeExpired(k)
+			continue
+		}
+		keys++
+		if !e.expireAt.IsZero() {
+			expires++
+		}
+	}
+	return keys, expires
+}
+
+// clear removes all entries, returns the number of entries removed
+func (ks keyspace[T]) clear() int64 {
+	n := int64(len(ks.entries))
+	for k := range ks.entries {
+		delete(ks.entries, k)
+	}
+	return n
+}
+
+// copyTo copies key to dstKey of dst along with its expire time, dst must be a keyspace of the same type
+func (ks keyspace[T]) copyTo(key []byte, dst expirable, dstKey []byte) bool {
+	e := ks.get(key)
+	if e == nil {
+		return false
+	}
+	dst.(keyspace[T]).entries[string(dstKey)] = &entry[T]{val: cloneValue(e.val), expireAt: e.expireAt}
+	return true
+}
+
+// keys returns the keys in ascending order
+func (ks keyspace[T]) keys() []string {
+	keys := make([]string, 0, len(ks.entries))
+	now := time.Now()
+	for k, e := range ks.entries {
+		if e.expired(now) {
+			ks.removeExpired(k)
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,760 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/oceanbase/modis/storage"
+)
+
+// stream is the value of a stream key
+type stream struct {
+	// in ascending order of the ids
+	entries      []storage.StreamEntry
+	lastID       storage.StreamID
+	maxDeletedID storage.StreamID
+	entriesAdded int64
+	groups       map[string]*streamGroup
+}
+
+// streamGroup is a consumer group of a stream
+type streamGroup struct {
+	lastID      storage.StreamID
+	entriesRead int64
+	pending     map[storage.StreamID]*pendingEntry
+	consumers   map[string]*streamConsumer
+}
+
+// pendingEntry is an entry delivered to a consumer but not acknowledged yet
+type pendingEntry struct {
+	consumer     string
+	deliveryTime time.Time
+	deliveries   int64
+}
+
+type streamConsumer struct {
+	seenTime   time.Time
+	activeTime time.Time
+}
+
+func newStream() *stream {
+	return &stream{groups: make(map[string]*streamGroup)}
+}
+
+func newStreamGroup(lastID storage.StreamID, entriesRead int64) *streamGroup {
+	return &streamGroup{
+		lastID:      lastID,
+		entriesRead: entriesRead,
+		pending:     make(map[storage.StreamID]*pendingEntry),
+		consumers:   make(map[string]*streamConsumer),
+	}
+}
+
+// clone returns a deep copy of the stream
+func (st *stream) clone() *stream {
+	c := &stream{
+		entries:      make([]storage.StreamEntry, len(st.entries)),
+		lastID:       st.lastID,
+		maxDeletedID: st.maxDeletedID,
+		entriesAdded: st.entriesAdded,
+		groups:       make(map[string]*streamGroup, len(st.groups)),
+	}
+	for i, e := range st.entries {
+		c.entries[i] = storage.StreamEntry{ID: e.ID, Fields: cloneValue(e.Fields)}
+	}
+	for name, g := range st.groups {
+		cg := newStreamGroup(g.lastID, g.entriesRead)
+		for id, pe := range g.pending {
+			cpe := *pe
+			cg.pending[id] = &cpe
+		}
+		for name, consumer := range g.consumers {
+			cc := *consumer
+			cg.consumers[name] = &cc
+		}
+		c.groups[name] = cg
+	}
+	return c
+}
+
+func (st *stream) meta() storage.StreamMeta {
+	m := storage.StreamMeta{
+		Length:       int64(len(st.entries)),
+		LastID:       st.lastID,
+		MaxDeletedID: st.maxDeletedID,
+		EntriesAdded: st.entriesAdded,
+	}
+	if len(st.entries) != 0 {
+		m.FirstID = st.entries[0].ID
+	}
+	return m
+}
+
+// search returns the index of the first entry not less than id
+func (st *stream) search(id storage.StreamID) int {
+	return sort.Search(len(st.entries), func(i int) bool { return st.entries[i].ID.Compare(id) >= 0 })
+}
+
+// find returns the index of the entry id, false if it not exists
+func (st *stream) find(id storage.StreamID) (int, bool) {
+	i := st.search(id)
+	return i, i < len(st.entries) && st.entries[i].ID == id
+}
+
+// rangeEntries returns at most count entries in [start, end], from end if reverse is set
+func (st *stream) rangeEntries(start storage.StreamID, end storage.StreamID, count int64, reverse bool) []storage.StreamEntry {
+	lo := st.search(start)
+	hi := sort.Search(len(st.entries), func(i int) bool { return st.entries[i].ID.Compare(end) > 0 })
+	if lo >= hi {
+		return []storage.StreamEntry{}
+	}
+	n := hi - lo
+	if count > 0 && int64(n) > count {
+		n = int(count)
+	}
+	entries := make([]storage.StreamEntry, n)
+	for i := range entries {
+		if reverse {
+			entries[i] = st.entries[hi-1-i]
+		} else {
+			entries[i] = st.entries[lo+i]
+		}
+	}
+	return entries
+}
+
+// trim evicts the oldest entries following t, returns the number of entries evicted
+func (st *stream) trim(t *storage.StreamTrim) int64 {
+	n := 0
+	for n < len(st.entries) && t.Evict(st.entries[n].ID, int64(len(st.entries)-n)) {
+		if t.Limit > 0 && int64(n) == t.Limit {
+			break
+		}
+		n++
+	}
+	st.entries = append([]storage.StreamEntry(nil), st.entries[n:]...)
+	return int64(n)
+}
+
+// consumer returns the consumer of the group, creating it if needed, and marks it seen at now
+func (g *streamGroup) consumer(name []byte, now time.Time) *streamConsumer {
+	c, ok := g.consumers[string(name)]
+	if !ok {
+		c = &streamConsumer{}
+		g.consumers[string(name)] = c
+	}
+	c.seenTime = now
+	return c
+}
+
+// pendingIDs returns the ids of the pending entries not less than start in ascending order
+func (g *streamGroup) pendingIDs(start storage.StreamID) []storage.StreamID {
+	ids := make([]storage.StreamID, 0, len(g.pending))
+	for id := range g.pending {
+		if id.Compare(start) >= 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Compare(ids[j]) < 0 })
+	return ids
+}
+
+// consumerPending returns the number of pending entries of the consumer
+func (g *streamGroup) consumerPending(name string) int64 {
+	var n int64
+	for _, pe := range g.pending {
+		if pe.consumer == name {
+			n++
+		}
+	}
+	return n
+}
+
+// getStream returns the stream of key, nil if key not exists. Callers must hold s.mu.
+func (d *database) getStream(key []byte) (*stream, error) {
+	if err := d.checkType(key, typeStream); err != nil {
+		return nil, err
+	}
+	e := d.streams.get(key)
+	if e == nil {
+		return nil, nil
+	}
+	return e.val, nil
+}
+
+// getGroup returns the stream of key and its consumer group, storage.ErrNoGroup if either not exists.
+// Callers must hold s.mu.
+func (d *database) getGroup(key []byte, group []byte) (*stream, *streamGroup, error) {
+	st, err := d.getStream(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if st == nil {
+		return nil, nil, storage.ErrNoGroup
+	}
+	g, ok := st.groups[string(group)]
+	if !ok {
+		return nil, nil, storage.ErrNoGroup
+	}
+	return st, g, nil
+}
+
+// getGroupOfKey is getGroup for the commands requiring the stream, storage.ErrNoSuchKey is returned
+// if the stream not exists. Callers must hold s.mu.
+func (d *database) getGroupOfKey(key []byte, group []byte) (*stream, *streamGroup, error) {
+	st, err := d.getStream(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if st == nil {
+		return nil, nil, storage.ErrNoSuchKey
+	}
+	return d.getGroup(key, group)
+}
+
+// XAdd appends an entry to the stream, creating the stream if needed unless args.NoMkStream is set
+func (s *Storage) XAdd(ctx context.Context, db int64, key []byte, args *storage.StreamAddArgs, fields [][]byte) (*storage.StreamID, int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	d := s.getDB(db)
+	st, err := d.getStream(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	created := st == nil
+	if created {
+		if args.NoMkStream {
+			return nil, 0, nil
+		}
+		st = newStream()
+	}
+	id, err := args.NextID(st.lastID, time.Now())
+	if err != nil {
+		return nil, 0, err
+	}
+	if created {
+		d.streams.set(key, st)
+	}
+	st.entries = append(st.entries, storage.StreamEntry{ID: id, Fields: cloneValue(fields)})
+	st.lastID = id
+	st.entriesAdded++
+
+	var trimmed int64
+	if args.Trim != nil {
+		trimmed = st.trim(args.Trim)
+	}
+	return &id, trimmed, nil
+}
+
+// XLen returns the number of entries of the stream
+func (s *Storage) XLen(ctx context.Context, db int64, key []byte) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	st, err := s.getDB(db).getStream(key)
+	if err != nil || st == nil {
+		return 0, err
+	}
+	return int64(len(st.entries)), nil
+}
+
+// XRange returns at most count entries in [start, end], from end if reverse is set
+func (s *Storage) XRange(ctx context.Context, db int64, key []byte, start storage.StreamID, end storage.StreamID,
+	count int64, reverse bool) ([]storage.StreamEntry, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	st, err := s.getDB(db).getStream(key)
+	if err != nil {
+		return nil, err
+	}
+	if st == nil {
+		return []storage.StreamEntry{}, nil
+	}
+	return st.rangeEntries(start, end, count, reverse), nil
+}
+
+// XDel deletes the entries, returns the number of entries deleted
+func (s *Storage) XDel(ctx context.Context, db int64, key []byte, ids []storage.StreamID) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	st, err := s.getDB(db).getStream(key)
+	if err != nil || st == nil {
+		return 0, err
+	}
+	var deleted int64
+	for _, id := range ids {
+		i, ok := st.find(id)
+		if !ok {
+			continue
+		}
+		st.entries = append(st.entries[:i], st.entries[i+1:]...)
+		if id.Compare(st.maxDeletedID) > 0 {
+			st.maxDeletedID = id
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// XTrim evicts the oldest entries of the stream, returns the number of entries evicted
+func (s *Storage) XTrim(ctx context.Context, db int64, key []byte, trim *storage.StreamTrim) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	st, err := s.getDB(db).getStream(key)
+	if err != nil || st == nil {
+		return 0, err
+	}
+	return st.trim(trim), nil
+}
+
+// XInfoStream returns the state of the stream, nil if the stream not exists
+func (s *Storage) XInfoStream(ctx context.Context, db int64, key []byte) (*storage.StreamInfo, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	st, err := s.getDB(db).getStream(key)
+	if err != nil || st == nil {
+		return nil, err
+	}
+	info := &storage.StreamInfo{StreamMeta: st.meta(), Groups: int64(len(st.groups))}
+	if n := len(st.entries); n != 0 {
+		info.FirstEntry = &st.entries[0]
+		info.LastEntry = &st.entries[n-1]
+	}
+	return info, nil
+}
+
+// XInfoGroups returns the consumer groups of the stream in ascending order of the names
+func (s *Storage) XInfoGroups(ctx context.Context, db int64, key []byte) ([]storage.StreamGroupInfo, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	st, err := s.getDB(db).getStream(key)
+	if err != nil {
+		return nil, err
+	}
+	if st == nil {
+		return nil, storage.ErrNoSuchKey
+	}
+	meta := st.meta()
+	groups := make([]storage.StreamGroupInfo, 0, len(st.groups))
+	for _, name := range sortedKeys(st.groups) {
+		g := st.groups[name]
+		lag, lagKnown := meta.Lag(g.lastID, g.entriesRead)
+		groups = append(groups, storage.StreamGroupInfo{
+			Name:            []byte(name),
+			Consumers:       int64(len(g.consumers)),
+			Pending:         int64(len(g.pending)),
+			LastDeliveredID: g.lastID,
+			EntriesRead:     g.entriesRead,
+			Lag:             lag,
+			LagKnown:        lagKnown,
+		})
+	}
+	return groups, nil
+}
+
+// XInfoConsumers returns the consumers of the group in ascending order of the names
+func (s *Storage) XInfoConsumers(ctx context.Context, db int64, key []byte, group []byte) ([]storage.StreamConsumerInfo, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	_, g, err := s.getDB(db).getGroupOfKey(key, group)
+	if err != nil {
+		return nil, err
+	}
+	consumers := make([]storage.StreamConsumerInfo, 0, len(g.consumers))
+	for _, name := range sortedKeys(g.consumers) {
+		c := g.consumers[name]
+		consumers = append(consumers, storage.StreamConsumerInfo{
+			Name:       []byte(name),
+			Pending:    g.consumerPending(name),
+			SeenTime:   c.seenTime,
+			ActiveTime: c.activeTime,
+		})
+	}
+	return consumers, nil
+}
+
+// XGroupCreate creates a consumer group of the stream
+func (s *Storage) XGroupCreate(ctx context.Context, db int64, key []byte, group []byte, id *storage.StreamID,
+	entriesRead int64, mkStream bool) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	d := s.getDB(db)
+	st, err := d.getStream(key)
+	if err != nil {
+		return err
+	}
+	if st == nil {
+		if !mkStream {
+			return storage.ErrNoSuchKey
+		}
+		st = newStream()
+		d.streams.set(key, st)
+	}
+	if _, ok := st.groups[string(group)]; ok {
+		return storage.ErrBusyGroup
+	}
+	lastID := st.lastID
+	if id != nil {
+		lastID = *id
+	}
+	st.groups[string(group)] = newStreamGroup(lastID, entriesRead)
+	return nil
+}
+
+// XGroupSetID sets the last delivered id of the consumer group
+func (s *Storage) XGroupSetID(ctx context.Context, db int64, key []byte, group []byte, id *storage.StreamID, entriesRead int64) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	st, g, err := s.getDB(db).getGroupOfKey(key, group)
+	if err != nil {
+		return err
+	}
+	g.lastID = st.lastID
+	if id != nil {
+		g.lastID = *id
+	}
+	g.entriesRead = entriesRead
+	return nil
+}
+
+// XGroupDestroy destroys the consumer group along with its pending entries and consumers
+func (s *Storage) XGroupDestroy(ctx context.Context, db int64, key []byte, group []byte) (bool, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	st, err := s.getDB(db).getStream(key)
+	if err != nil {
+		return false, err
+	}
+	if st == nil {
+		return false, storage.ErrNoSuchKey
+	}
+	if _, ok := st.groups[string(group)]; !ok {
+		return false, nil
+	}
+	delete(st.groups, string(group))
+	return true, nil
+}
+
+// XGroupCreateConsumer creates a consumer in the group, returns false if it exists
+func (s *Storage) XGroupCreateConsumer(ctx context.Context, db int64, key []byte, group []byte, consumer []byte) (bool, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	_, g, err := s.getDB(db).getGroupOfKey(key, group)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := g.consumers[string(consumer)]; ok {
+		return false, nil
+	}
+	g.consumer(consumer, time.Now())
+	return true, nil
+}
+
+// XGroupDelConsumer deletes the consumer along with its pending entries
+func (s *Storage) XGroupDelConsumer(ctx context.Context, db int64, key []byte, group []byte, consumer []byte) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	_, g, err := s.getDB(db).getGroupOfKey(key, group)
+	if err != nil {
+		return 0, false, err
+	}
+	name := string(consumer)
+	if _, ok := g.consumers[name]; !ok {
+		return 0, false, nil
+	}
+	var pending int64
+	for id, pe := range g.pending {
+		if pe.consumer == name {
+			delete(g.pending, id)
+			pending++
+		}
+	}
+	delete(g.consumers, name)
+	return pending, true, nil
+}
+
+// XReadGroup delivers the new entries to the consumer, or returns its pending entries after the given id
+func (s *Storage) XReadGroup(ctx context.Context, db int64, key []byte, group []byte, consumer []byte, after *storage.StreamID,
+	count int64, noAck bool) ([]storage.StreamEntry, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	st, g, err := s.getDB(db).getGroup(key, group)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	c := g.consumer(consumer, now)
+
+	if after != nil {
+		// the history of the consumer, the entries deleted from the stream are returned without fields
+		start, ok := after.Next()
+		if !ok {
+			return []storage.StreamEntry{}, nil
+		}
+		entries := []storage.StreamEntry{}
+		for _, id := range g.pendingIDs(start) {
+			pe := g.pending[id]
+			if pe.consumer != string(consumer) {
+				continue
+			}
+			if count > 0 && int64(len(entries)) == count {
+				break
+			}
+			entry := storage.StreamEntry{ID: id}
+			if i, ok := st.find(id); ok {
+				entry.Fields = st.entries[i].Fields
+				pe.deliveryTime = now
+				pe.deliveries++
+			}
+			entries = append(entries, entry)
+		}
+		return entries, nil
+	}
+
+	start, ok := g.lastID.Next()
+	if !ok {
+		return []storage.StreamEntry{}, nil
+	}
+	entries := st.rangeEntries(start, storage.MaxStreamID, count, false)
+	meta := st.meta()
+	for _, e := range entries {
+		g.entriesRead = meta.AdvanceEntriesRead(g.entriesRead, e.ID)
+		g.lastID = e.ID
+		if !noAck {
+			g.pending[e.ID] = &pendingEntry{consumer: string(consumer), deliveryTime: now, deliveries: 1}
+		}
+	}
+	if len(entries) != 0 {
+		c.activeTime = now
+	}
+	return entries, nil
+}
+
+// XAck acknowledges the pending entries of the group, returns the number of entries acknowledged
+func (s *Storage) XAck(ctx context.Context, db int64, key []byte, group []byte, ids []storage.StreamID) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	_, g, err := s.getDB(db).getGroup(key, group)
+	if err == storage.ErrNoGroup {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	var acked int64
+	for _, id := range ids {
+		if _, ok := g.pending[id]; ok {
+			delete(g.pending, id)
+			acked++
+		}
+	}
+	return acked, nil
+}
+
+// XPending summarizes the pending entries of the group
+func (s *Storage) XPending(ctx context.Context, db int64, key []byte, group []byte) (*storage.StreamPendingSummary, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	_, g, err := s.getDB(db).getGroup(key, group)
+	if err != nil {
+		return nil, err
+	}
+	summary := &storage.StreamPendingSummary{Count: int64(len(g.pending))}
+	ids := g.pendingIDs(storage.StreamID{})
+	if len(ids) == 0 {
+		return summary, nil
+	}
+	summary.MinID, summary.MaxID = ids[0], ids[len(ids)-1]
+	counts := make(map[string]int64)
+	for _, pe := range g.pending {
+		counts[pe.consumer]++
+	}
+	for _, name := range sortedKeys(counts) {
+		summary.Consumers = append(summary.Consumers, storage.StreamConsumerPending{Name: []byte(name), Count: counts[name]})
+	}
+	return summary, nil
+}
+
+// XPendingRange returns the pending entries of the group selected by args
+func (s *Storage) XPendingRange(ctx context.Context, db int64, key []byte, group []byte,
+	args *storage.StreamPendingArgs) ([]storage.StreamPendingEntry, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	_, g, err := s.getDB(db).getGroup(key, group)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	entries := []storage.StreamPendingEntry{}
+	for _, id := range g.pendingIDs(args.Start) {
+		if id.Compare(args.End) > 0 || (args.Count > 0 && int64(len(entries)) == args.Count) {
+			break
+		}
+		pe := g.pending[id]
+		if args.Consumer != nil && pe.consumer != string(args.Consumer) {
+			continue
+		}
+		if now.Sub(pe.deliveryTime) < args.MinIdle {
+			continue
+		}
+		entries = append(entries, storage.StreamPendingEntry{
+			ID:           id,
+			Consumer:     []byte(pe.consumer),
+			DeliveryTime: pe.deliveryTime,
+			Deliveries:   pe.deliveries,
+		})
+	}
+	return entries, nil
+}
+
+// XClaim transfers the pending entries to the consumer
+func (s *Storage) XClaim(ctx context.Context, db int64, key []byte, group []byte, consumer []byte, ids []storage.StreamID,
+	args *storage.StreamClaimArgs) ([]storage.StreamEntry, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	st, g, err := s.getDB(db).getGroup(key, group)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if args.LastID.Compare(g.lastID) > 0 {
+		g.lastID = args.LastID
+	}
+	c := g.consumers[string(consumer)]
+	if c != nil {
+		c.seenTime = now
+	}
+
+	entries := []storage.StreamEntry{}
+	for _, id := range ids {
+		pe := g.pending[id]
+		i, ok := st.find(id)
+		if !ok {
+			// the entry is deleted from the stream
+			delete(g.pending, id)
+			continue
+		}
+		if pe == nil {
+			if !args.Force {
+				continue
+			}
+			pe = &pendingEntry{deliveryTime: now, deliveries: 1}
+			g.pending[id] = pe
+		} else if args.MinIdle > 0 && now.Sub(pe.deliveryTime) < args.MinIdle {
+			continue
+		}
+		if c == nil {
+			c = g.consumer(consumer, now)
+		}
+		claimEntry(pe, string(consumer), args.DeliveryTime, args.RetryCount, args.JustID)
+		entry := storage.StreamEntry{ID: id}
+		if !args.JustID {
+			entry.Fields = st.entries[i].Fields
+		}
+		entries = append(entries, entry)
+		c.activeTime = now
+	}
+	return entries, nil
+}
+
+// XAutoClaim claims the pending entries from start idle for at least minIdle
+func (s *Storage) XAutoClaim(ctx context.Context, db int64, key []byte, group []byte, consumer []byte, start storage.StreamID,
+	minIdle time.Duration, count int64, justID bool) (storage.StreamID, []storage.StreamEntry, []storage.StreamID, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	st, g, err := s.getDB(db).getGroup(key, group)
+	if err != nil {
+		return storage.StreamID{}, nil, nil, err
+	}
+	now := time.Now()
+	c := g.consumers[string(consumer)]
+	if c != nil {
+		c.seenTime = now
+	}
+
+	entries := []storage.StreamEntry{}
+	deleted := []storage.StreamID{}
+	ids := g.pendingIDs(start)
+	attempts := count * autoClaimAttemptsFactor
+	i := 0
+	for ; i < len(ids) && attempts > 0 && count > 0; i++ {
+		attempts--
+		id := ids[i]
+		pe := g.pending[id]
+		j, ok := st.find(id)
+		if !ok {
+			delete(g.pending, id)
+			deleted = append(deleted, id)
+			count--
+			continue
+		}
+		if minIdle > 0 && now.Sub(pe.deliveryTime) < minIdle {
+			continue
+		}
+		if c == nil {
+			c = g.consumer(consumer, now)
+		}
+		claimEntry(pe, string(consumer), now, -1, justID)
+		entry := storage.StreamEntry{ID: id}
+		if !justID {
+			entry.Fields = st.entries[j].Fields
+		}
+		entries = append(entries, entry)
+		count--
+		c.activeTime = now
+	}
+	var next storage.StreamID
+	if i < len(ids) {
+		next = ids[i]
+	}
+	return next, entries, deleted, nil
+}
+
+// autoClaimAttemptsFactor limits the pending entries XAUTOCLAIM examines to count times of it, like redis
+const autoClaimAttemptsFactor = 10
+
+// claimEntry transfers the pending entry to consumer, the delivery counter is set to retryCount
+// if it is not negative, otherwise it is incremented unless justID is set
+func claimEntry(pe *pendingEntry, consumer string, deliveryTime time.Time, retryCount int64, justID bool) {
+	pe.consumer = consumer
+	pe.deliveryTime = deliveryTime
+	if retryCount >= 0 {
+		pe.deliveries = retryCount
+	} else if !justID {
+		pe.deliveries++
+	}
+}
@@ -0,0 +1,352 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/oceanbase/modis/util"
+)
+
+var (
+	errNotInteger = errors.New("value is not an integer or out of range")
+	errNotFloat   = errors.New("value is not a valid float")
+	errOverflow   = errors.New("increment or decrement would overflow")
+)
+
+// Get value by key. Return value if exists, nil if not exists
+func (s *Storage) Get(ctx context.Context, db int64, key []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeString); err != nil {
+		return nil, err
+	}
+	e := s.getDB(db).strings.get(key)
+	if e == nil {
+		return nil, nil
+	}
+	return e.val, nil
+}
+
+// MGet obtain key-value pairs in batches. If keys do not exist, null is returned.
+func (s *Storage) MGet(ctx context.Context, db int64, keys [][]byte) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	d := s.getDB(db)
+	returnValues := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		if e := d.strings.get(key); e != nil {
+			returnValues = append(returnValues, e.val)
+		} else {
+			returnValues = append(returnValues, nil)
+		}
+	}
+	return returnValues, nil
+}
+
+// MSet set key pairs in batches. If the key already exists, the old value is overwritten.
+// Returns the number of keys successfully set
+func (s *Storage) MSet(ctx context.Context, db int64, kv map[string][]byte) (int, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	d := s.getDB(db)
+	for key, value := range kv {
+		d.removeOtherTypes([]byte(key), typeString)
+		d.strings.set([]byte(key), copyBytes(value))
+	}
+	return len(kv), nil
+}
+
+// PSetEx set the value and expiration time (in milliseconds), update key if the key already exists.
+func (s *Storage) PSetEx(ctx context.Context, db int64, key []byte, expireTime uint64, value []byte) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	d := s.getDB(db)
+	d.removeOtherTypes(key, typeString)
+	e := d.strings.set(key, copyBytes(value))
+	e.expireAt = time.Now().Add(time.Duration(expireTime))
+	return nil
+}
+
+// Set the value of the specified key, insert if it does not exist and update if it does.
+func (s *Storage) Set(ctx context.Context, db int64, key []byte, value []byte) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	d := s.getDB(db)
+	d.removeOtherTypes(key, typeString)
+	d.strings.set(key, copyBytes(value))
+	return nil
+}
+
+// SetKeepTTL sets the value of the specified key like Set, the expire time of an existing key is kept.
+func (s *Storage) SetKeepTTL(ctx context.Context, db int64, key []byte, value []byte) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	d := s.getDB(db)
+	if err := d.checkType(key, typeString); err != nil {
+		return err
+	}
+	if e := d.strings.get(key); e != nil {
+		e.val = copyBytes(value)
+	} else {
+		d.strings.set(key, copyBytes(value))
+	}
+	return nil
+}
+
+// CompareAndSet sets the value of the string to value if it is still old, or creates it if old is nil
+func (s *Storage) CompareAndSet(ctx context.Context, db int64, key []byte, old []byte, value []byte) (bool, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	d := s.getDB(db)
+	if err := d.checkType(key, typeString); err != nil {
+		return false, err
+	}
+	e := d.strings.get(key)
+	if old == nil {
+		if e != nil {
+			return false, nil
+		}
+		d.strings.set(key, copyBytes(value))
+		return true, nil
+	}
+	if e == nil || !bytes.Equal(e.val, old) {
+		return false, nil
+	}
+	e.val = copyBytes(value)
+	return true, nil
+}
+
+// SetEx set the value and expiration time (in second), update key if the key already exists.
+func (s *Storage) SetEx(ctx context.Context, db int64, key []byte, expireTime uint64, value []byte) error {
+	return s.PSetEx(ctx, db, key, expireTime, value)
+}
+
+// SetNx set a key-value pair, returning 0 if the key already exists and setting a value if the key does not exist.
+func (s *Storage) SetNx(ctx context.Context, db int64, key []byte, value []byte) (int, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	d := s.getDB(db)
+	if d.strings.exists(key) || d.checkType(key, typeString) != nil {
+		return 0, nil
+	}
+	d.strings.set(key, copyBytes(value))
+	return 1, nil
+}
+
+// Append appends a string to the value of the key. Returns the length of the final value.
+func (s *Storage) Append(ctx context.Context, db int64, key []byte, value []byte) (int, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeString); err != nil {
+		return 0, err
+	}
+	d := s.getDB(db)
+	e := d.strings.get(key)
+	if e == nil {
+		e = d.strings.set(key, nil)
+	}
+	e.val = append(copyBytes(e.val), value...)
+	return len(e.val), nil
+}
+
+// IncrBy Add value from the value of the key.
+// If the key does not exist, value is written and value is returned
+// Returns the value add value when key is present;
+func (s *Storage) IncrBy(ctx context.Context, db int64, key []byte, value []byte) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeString); err != nil {
+		return 0, err
+	}
+	incr, err := strconv.ParseInt(util.BytesToString(value), 10, 64)
+	if err != nil {
+		return -1, errNotInteger
+	}
+	return s.getDB(db).incrBy(key, incr)
+}
+
+// IncrByFloat Add value from the value of the key.
+// If the key does not exist, value is written and value is returned
+// Returns the value add value when key is present;
+func (s *Storage) IncrByFloat(ctx context.Context, db int64, key []byte, value []byte) (float64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeString); err != nil {
+		return 0, err
+	}
+	incr, err := parseFloat(value)
+	if err != nil {
+		return -1, err
+	}
+
+	d := s.getDB(db)
+	var num float64
+	e := d.strings.get(key)
+	if e != nil {
+		num, err = parseFloat(e.val)
+		if err != nil {
+			return -1, err
+		}
+	}
+	num += incr
+	if math.IsNaN(num) || math.IsInf(num, 0) {
+		return -1, errors.New("increment would produce NaN or Infinity")
+	}
+	if e == nil {
+		e = d.strings.set(key, nil)
+	}
+	e.val = []byte(strconv.FormatFloat(num, 'f', -1, 64))
+	return num, nil
+}
+
+// GetBit get the bit value of the specified offset position in the value of the specified key.
+func (s *Storage) GetBit(ctx context.Context, db int64, key []byte, offset int) (byte, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeString); err != nil {
+		return 0, err
+	}
+	e := s.getDB(db).strings.get(key)
+	if e == nil {
+		return 0, nil
+	}
+	return getBit(e.val, offset), nil
+}
+
+// incrBy adds incr to the integer stored at key, the expire time is kept
+func (d *database) incrBy(key []byte, incr int64) (int64, error) {
+	var num int64
+	var err error
+	e := d.strings.get(key)
+	if e != nil {
+		num, err = strconv.ParseInt(util.BytesToString(e.val), 10, 64)
+		if err != nil {
+			return -1, errNotInteger
+		}
+	}
+	if (incr < 0 && num < 0 && incr < math.MinInt64-num) ||
+		(incr > 0 && num > 0 && incr > math.MaxInt64-num) {
+		return -1, errOverflow
+	}
+	num += incr
+	if e == nil {
+		e = d.strings.set(key, nil)
+	}
+	e.val = []byte(strconv.FormatInt(num, 10))
+	return num, nil
+}
+
+// stringCmd executes the string commands that obkv runs on the observer side
+func (d *database) stringCmd(cmd string, key []byte, args [][]byte) string {
+	if err := d.checkType(key, typeString); err != nil {
+		return resp.EncError(err.Error())
+	}
+	switch cmd {
+	case "incr", "decr", "incrby", "decrby":
+		var incr int64 = 1
+		if cmd == "incrby" || cmd == "decrby" {
+			if len(args) != 1 {
+				return resp.ErrWrongArgs(cmd)
+			}
+			var err error
+			incr, err = strconv.ParseInt(util.BytesToString(args[0]), 10, 64)
+			if err != nil {
+				return resp.ResponseIntegerErr
+			}
+		} else if len(args) != 0 {
+			return resp.ErrWrongArgs(cmd)
+		}
+		if strings.HasPrefix(cmd, "decr") {
+			if incr == math.MinInt64 {
+				return resp.EncError("ERR decrement would overflow")
+			}
+			incr = -incr
+		}
+		num, err := d.incrBy(key, incr)
+		if err != nil {
+			return resp.EncError("ERR " + err.Error())
+		}
+		return resp.EncInteger(num)
+
+	case "setbit":
+		if len(args) != 2 {
+			return resp.ErrWrongArgs(cmd)
+		}
+		offset, err := strconv.ParseInt(util.BytesToString(args[0]), 10, 64)
+		if err != nil || offset < 0 || offset >= 4*1024*1024*1024 {
+			return resp.ResponseBitOffsetErr
+		}
+		bit := util.BytesToString(args[1])
+		if bit != "0" && bit != "1" {
+			return resp.ResponseBitIntegerErr
+		}
+		e := d.strings.get(key)
+		if e == nil {
+			e = d.strings.set(key, nil)
+		}
+		// values may be shared with earlier replies, never modify them in place
+		val := copyBytes(e.val)
+		byteIndex := int(offset / 8)
+		if byteIndex >= len(val) {
+			val = append(val, make([]byte, byteIndex+1-len(val))...)
+		}
+		old := getBit(val, int(offset))
+		mask := byte(1) << uint(7-offset%8)
+		if bit == "1" {
+			val[byteIndex] |= mask
+		} else {
+			val[byteIndex] &^= mask
+		}
+		e.val = val
+		return resp.EncInteger(int64(old))
+
+	case "getset":
+		if len(args) != 1 {
+			return resp.ErrWrongArgs(cmd)
+		}
+		var old []byte
+		if e := d.strings.get(key); e != nil {
+			old = e.val
+		}
+		d.strings.set(key, copyBytes(args[0]))
+		if old == nil {
+			return resp.EncNullBulkString()
+		}
+		return resp.EncBulkString(util.BytesToString(old))
+	}
+	return resp.ErrUnKnownCommand(cmd)
+}
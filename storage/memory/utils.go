@@ -0,0 +1,189 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/oceanbase/obkv-table-client-go/table"
+
+	"github.com/oceanbase/modis/log"
+	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/oceanbase/modis/util"
+)
+
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
+
+// cloneValue returns a deep copy of a value stored in a keyspace
+func cloneValue[T any](v T) T {
+	var c any
+	switch val := any(v).(type) {
+	case []byte:
+		c = copyBytes(val)
+	case [][]byte:
+		list := make([][]byte, len(val))
+		for i, elem := range val {
+			list[i] = copyBytes(elem)
+		}
+		c = list
+	case map[string][]byte:
+		hash := make(map[string][]byte, len(val))
+		for field, value := range val {
+			hash[field] = copyBytes(value)
+		}
+		c = hash
+	case map[string]float64:
+		zset := make(map[string]float64, len(val))
+		for member, score := range val {
+			zset[member] = score
+		}
+		c = zset
+	case map[string]struct{}:
+		set := make(map[string]struct{}, len(val))
+		for member := range val {
+			set[member] = struct{}{}
+		}
+		c = set
+	case *stream:
+		c = val.clone()
+	default:
+		return v
+	}
+	return c.(T)
+}
+
+func getBit(bytes []byte, offset int) byte {
+	byteIndex := offset / 8
+	bitIndex := offset % 8
+
+	if byteIndex >= len(bytes) {
+		return 0
+	}
+	return (bytes[byteIndex] >> uint(7-bitIndex)) & 1
+}
+
+// parseFloat parses a float like redis does, NaN is not a valid float
+func parseFloat(b []byte) (float64, error) {
+	f, err := strconv.ParseFloat(util.BytesToString(b), 64)
+	if err != nil || math.IsNaN(f) {
+		return 0, errNotFloat
+	}
+	return f, nil
+}
+
+// formatFloat formats a float the way redis replies scores, i.e. "%.17g"
+// with the shortest representation
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "inf"
+	} else if math.IsInf(f, -1) {
+		return "-inf"
+	}
+	exp := 0
+	if f != 0 {
+		exp = int(math.Floor(math.Log10(math.Abs(f))))
+	}
+	if exp < -4 || exp >= 17 {
+		return strconv.FormatFloat(f, 'e', -1, 64)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// sortedKeys returns the keys of m in ascending order, like a primary key scan
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// pageAfter returns at most count keys greater than after from the sorted keys
+func pageAfter(keys []string, after []byte, count int64) []string {
+	i := 0
+	if after != nil {
+		i = sort.SearchStrings(keys, string(after)+"\x00")
+	}
+	keys = keys[i:]
+	if int64(len(keys)) > count {
+		keys = keys[:count]
+	}
+	return keys
+}
+
+// randomKeys returns count distinct keys of m chosen at random
+func randomKeys[T any](m map[string]T, count int) []string {
+	keys := sortedKeys(m)
+	rand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	if count < len(keys) {
+		keys = keys[:count]
+	}
+	return keys
+}
+
+// dbFromRowKey extracts the db column from the row key of a command
+func dbFromRowKey(rowKey []*table.Column) (int64, error) {
+	for _, col := range rowKey {
+		if col.Name() == dbColumnName {
+			if db, ok := col.Value().(int64); ok {
+				return db, nil
+			}
+		}
+	}
+	return 0, errors.New("db column not found in row key")
+}
+
+// ObServerCmd is a general interface for commands that can be executed on the observer side,
+// the memory storage executes the string commands locally with the same semantics
+func (s *Storage) ObServerCmd(ctx context.Context, tableName string, rowKey []*table.Column, plainText []byte) (string, error) {
+	log.Debug("storage", nil, "Redis command", log.String("table name", tableName), log.String("table name", string(plainText)))
+	argv, err := resp.DecArray(plainText)
+	if err != nil {
+		return "", err
+	}
+	if len(argv) < 2 {
+		return "", errors.New("invalid redis command: " + string(plainText))
+	}
+	db, err := dbFromRowKey(rowKey)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.unlock()
+
+	d := s.getDB(db)
+	cmd := strings.ToLower(util.BytesToString(argv[0]))
+	if tableName == stringTableName {
+		return d.stringCmd(cmd, argv[1], argv[2:]), nil
+	}
+	return "", errors.New("table not exists: " + tableName)
+}
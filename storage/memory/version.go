@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"encoding/binary"
+)
+
+// versionKey is a key of db, or db itself if isDB is set
+type versionKey struct {
+	db   int64
+	isDB bool
+	key  string
+}
+
+// TouchKeys changes the versions of keys of db
+func (s *Storage) TouchKeys(ctx context.Context, db int64, keys [][]byte) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	for _, key := range keys {
+		s.lastVersion++
+		s.versions[versionKey{db: db, key: string(key)}] = s.lastVersion
+	}
+	return nil
+}
+
+// TouchDB changes the versions of all the keys of db, the versions of its keys are dropped
+// as the version of db is part of them
+func (s *Storage) TouchDB(ctx context.Context, db int64) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	for k := range s.versions {
+		if k.db == db && !k.isDB {
+			delete(s.versions, k)
+		}
+	}
+	s.lastVersion++
+	s.versions[versionKey{db: db, isDB: true}] = s.lastVersion
+	return nil
+}
+
+// KeyVersion returns the versions of key and db
+func (s *Storage) KeyVersion(ctx context.Context, db int64, key []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	version := make([]byte, 16)
+	binary.BigEndian.PutUint64(version, s.versions[versionKey{db: db, key: string(key)}])
+	binary.BigEndian.PutUint64(version[8:], s.versions[versionKey{db: db, isDB: true}])
+	return version, nil
+}
@@ -0,0 +1,549 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/oceanbase/modis/storage"
+)
+
+type scoredMember struct {
+	member string
+	score  float64
+}
+
+// sortedMembers returns the members ordered by score, then by member
+func sortedMembers(zset map[string]float64) []scoredMember {
+	members := make([]scoredMember, 0, len(zset))
+	for member, score := range zset {
+		members = append(members, scoredMember{member, score})
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].score != members[j].score {
+			return members[i].score < members[j].score
+		}
+		return members[i].member < members[j].member
+	})
+	return members
+}
+
+func reverseMembers(members []scoredMember) {
+	for i, j := 0, len(members)-1; i < j; i, j = i+1, j-1 {
+		members[i], members[j] = members[j], members[i]
+	}
+}
+
+func zMembers(members []scoredMember) []storage.ZMember {
+	res := make([]storage.ZMember, 0, len(members))
+	for _, m := range members {
+		res = append(res, storage.ZMember{Member: []byte(m.member), Score: m.score})
+	}
+	return res
+}
+
+func scoreAbove(min storage.ScoreBound, score float64) bool {
+	if min.Exclusive {
+		return min.Score < score
+	}
+	return min.Score <= score
+}
+
+func scoreBelow(max storage.ScoreBound, score float64) bool {
+	if max.Exclusive {
+		return max.Score > score
+	}
+	return max.Score >= score
+}
+
+// membersInScoreRange returns the ordered members with min <= score <= max
+func membersInScoreRange(zset map[string]float64, min storage.ScoreBound, max storage.ScoreBound) []scoredMember {
+	var res []scoredMember
+	for _, m := range sortedMembers(zset) {
+		if scoreAbove(min, m.score) && scoreBelow(max, m.score) {
+			res = append(res, m)
+		}
+	}
+	return res
+}
+
+func lexAbove(min storage.LexBound, member string) bool {
+	if min.Inf != 0 {
+		return min.Inf < 0
+	}
+	if min.Exclusive {
+		return string(min.Member) < member
+	}
+	return string(min.Member) <= member
+}
+
+func lexBelow(max storage.LexBound, member string) bool {
+	if max.Inf != 0 {
+		return max.Inf > 0
+	}
+	if max.Exclusive {
+		return string(max.Member) > member
+	}
+	return string(max.Member) >= member
+}
+
+// membersInLexRange returns the members with min <= member <= max in ascending order of the members
+func membersInLexRange(zset map[string]float64, min storage.LexBound, max storage.LexBound) []scoredMember {
+	var res []scoredMember
+	for _, member := range sortedKeys(zset) {
+		if lexAbove(min, member) && lexBelow(max, member) {
+			res = append(res, scoredMember{member, zset[member]})
+		}
+	}
+	return res
+}
+
+// removeMembers removes the members from the zset, and the key if the zset becomes empty
+func (d *database) removeMembers(key []byte, zset map[string]float64, members []scoredMember) {
+	for _, m := range members {
+		delete(zset, m.member)
+	}
+	if zset != nil && len(zset) == 0 {
+		d.zsets.remove(key)
+	}
+}
+
+func (d *database) zset(key []byte) map[string]float64 {
+	if e := d.zsets.get(key); e != nil {
+		return e.val
+	}
+	return nil
+}
+
+// ZAdd adds the members or updates their scores as flags allow, returns the number of members
+// added and changed
+func (s *Storage) ZAdd(ctx context.Context, db int64, key []byte, members []storage.ZMember, flags storage.ZAddFlags) (int64, int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeZSet); err != nil {
+		return 0, 0, err
+	}
+	d := s.getDB(db)
+	zset := d.zset(key)
+	var added, changed int64
+	for _, m := range members {
+		old, exists := zset[string(m.Member)]
+		if !flags.Allow(old, exists, m.Score) {
+			continue
+		}
+		if !exists {
+			added++
+		} else if old != m.Score {
+			changed++
+		}
+		if zset == nil {
+			zset = d.zsets.set(key, make(map[string]float64)).val
+		}
+		zset[string(m.Member)] = m.Score
+	}
+	return added, changed, nil
+}
+
+// ZAddIncr increments the score of member by incr as flags allow, returns the new score
+func (s *Storage) ZAddIncr(ctx context.Context, db int64, key []byte, member []byte, incr float64, flags storage.ZAddFlags) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeZSet); err != nil {
+		return 0, false, err
+	}
+	d := s.getDB(db)
+	zset := d.zset(key)
+	old, exists := zset[string(member)]
+	score := old + incr
+	if math.IsNaN(score) {
+		return 0, false, resp.ErrorReply("ERR resulting score is not a number (NaN)")
+	}
+	if !flags.Allow(old, exists, score) {
+		return 0, false, nil
+	}
+	if zset == nil {
+		zset = d.zsets.set(key, make(map[string]float64)).val
+	}
+	zset[string(member)] = score
+	return score, true, nil
+}
+
+// ZIncrBy increments the score of member by incr, returns the new score
+func (s *Storage) ZIncrBy(ctx context.Context, db int64, key []byte, member []byte, incr float64) (float64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeZSet); err != nil {
+		return 0, err
+	}
+	d := s.getDB(db)
+	zset := d.zset(key)
+	score := zset[string(member)] + incr
+	if math.IsNaN(score) {
+		return 0, resp.ErrorReply("ERR resulting score is not a number (NaN)")
+	}
+	if zset == nil {
+		zset = d.zsets.set(key, make(map[string]float64)).val
+	}
+	zset[string(member)] = score
+	return score, nil
+}
+
+// ZRem removes the members, returns the number of members removed
+func (s *Storage) ZRem(ctx context.Context, db int64, key []byte, members [][]byte) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeZSet); err != nil {
+		return 0, err
+	}
+	d := s.getDB(db)
+	zset := d.zset(key)
+	var removed []scoredMember
+	for _, member := range members {
+		if score, ok := zset[string(member)]; ok {
+			removed = append(removed, scoredMember{string(member), score})
+			delete(zset, string(member))
+		}
+	}
+	d.removeMembers(key, zset, removed)
+	return int64(len(removed)), nil
+}
+
+// ZCard returns the number of members
+func (s *Storage) ZCard(ctx context.Context, db int64, key []byte) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeZSet); err != nil {
+		return 0, err
+	}
+	return int64(len(s.getDB(db).zset(key))), nil
+}
+
+// ZScore returns the score of member, false if member not exists
+func (s *Storage) ZScore(ctx context.Context, db int64, key []byte, member []byte) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeZSet); err != nil {
+		return 0, false, err
+	}
+	score, ok := s.getDB(db).zset(key)[string(member)]
+	return score, ok, nil
+}
+
+// ZRandMember returns count distinct random members, or -count random members if count < 0
+func (s *Storage) ZRandMember(ctx context.Context, db int64, key []byte, count int64) ([]storage.ZMember, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeZSet); err != nil {
+		return nil, err
+	}
+	zset := s.getDB(db).zset(key)
+	if count >= 0 {
+		res := make([]storage.ZMember, 0, min(count, int64(len(zset))))
+		for _, member := range randomKeys(zset, int(min(count, int64(len(zset))))) {
+			res = append(res, storage.ZMember{Member: []byte(member), Score: zset[member]})
+		}
+		return res, nil
+	}
+	if len(zset) == 0 {
+		return []storage.ZMember{}, nil
+	}
+	members := sortedKeys(zset)
+	var res []storage.ZMember
+	for i := int64(0); i < -count; i++ {
+		member := members[rand.Intn(len(members))]
+		res = append(res, storage.ZMember{Member: []byte(member), Score: zset[member]})
+	}
+	return res, nil
+}
+
+// ZPop removes and returns at most count members with the lowest scores, or the highest if reverse
+func (s *Storage) ZPop(ctx context.Context, db int64, key []byte, count int64, reverse bool) ([]storage.ZMember, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeZSet); err != nil {
+		return nil, err
+	}
+	d := s.getDB(db)
+	zset := d.zset(key)
+	members := sortedMembers(zset)
+	if reverse {
+		reverseMembers(members)
+	}
+	if count < int64(len(members)) {
+		members = members[:count]
+	}
+	d.removeMembers(key, zset, members)
+	return zMembers(members), nil
+}
+
+// ZRank returns the rank of member ordered from low to high scores, or high to low if reverse
+func (s *Storage) ZRank(ctx context.Context, db int64, key []byte, member []byte, reverse bool) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeZSet); err != nil {
+		return 0, false, err
+	}
+	zset := s.getDB(db).zset(key)
+	if _, ok := zset[string(member)]; !ok {
+		return 0, false, nil
+	}
+	members := sortedMembers(zset)
+	if reverse {
+		reverseMembers(members)
+	}
+	for rank, m := range members {
+		if m.member == string(member) {
+			return int64(rank), true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// ZRange returns the members with rank in [start, stop]
+func (s *Storage) ZRange(ctx context.Context, db int64, key []byte, start int64, stop int64, reverse bool) ([]storage.ZMember, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeZSet); err != nil {
+		return nil, err
+	}
+	members := sortedMembers(s.getDB(db).zset(key))
+	if reverse {
+		reverseMembers(members)
+	}
+	from, to := listRange(start, stop, len(members))
+	return zMembers(members[from : to+1]), nil
+}
+
+// ZRangeByScore returns the members with min <= score <= max, skipping offset members
+// and returning at most count members if count >= 0
+func (s *Storage) ZRangeByScore(ctx context.Context, db int64, key []byte, min storage.ScoreBound, max storage.ScoreBound,
+	reverse bool, offset int64, count int64) ([]storage.ZMember, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeZSet); err != nil {
+		return nil, err
+	}
+	members := membersInScoreRange(s.getDB(db).zset(key), min, max)
+	if reverse {
+		reverseMembers(members)
+	}
+	if offset < 0 || offset >= int64(len(members)) {
+		return []storage.ZMember{}, nil
+	}
+	members = members[offset:]
+	if count >= 0 && count < int64(len(members)) {
+		members = members[:count]
+	}
+	return zMembers(members), nil
+}
+
+// ZRangeByLex returns the members with min <= member <= max, skipping offset members
+// and returning at most count members if count >= 0
+func (s *Storage) ZRangeByLex(ctx context.Context, db int64, key []byte, min storage.LexBound, max storage.LexBound,
+	reverse bool, offset int64, count int64) ([]storage.ZMember, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeZSet); err != nil {
+		return nil, err
+	}
+	members := membersInLexRange(s.getDB(db).zset(key), min, max)
+	if reverse {
+		reverseMembers(members)
+	}
+	if offset < 0 || offset >= int64(len(members)) {
+		return []storage.ZMember{}, nil
+	}
+	members = members[offset:]
+	if count >= 0 && count < int64(len(members)) {
+		members = members[:count]
+	}
+	return zMembers(members), nil
+}
+
+// ZLexCount returns the number of members with min <= member <= max
+func (s *Storage) ZLexCount(ctx context.Context, db int64, key []byte, min storage.LexBound, max storage.LexBound) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeZSet); err != nil {
+		return 0, err
+	}
+	return int64(len(membersInLexRange(s.getDB(db).zset(key), min, max))), nil
+}
+
+// ZRemRangeByLex removes the members with min <= member <= max, returns the number of members removed
+func (s *Storage) ZRemRangeByLex(ctx context.Context, db int64, key []byte, min storage.LexBound, max storage.LexBound) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeZSet); err != nil {
+		return 0, err
+	}
+	d := s.getDB(db)
+	zset := d.zset(key)
+	members := membersInLexRange(zset, min, max)
+	d.removeMembers(key, zset, members)
+	return int64(len(members)), nil
+}
+
+// ZCount returns the number of members with min <= score <= max
+func (s *Storage) ZCount(ctx context.Context, db int64, key []byte, min storage.ScoreBound, max storage.ScoreBound) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeZSet); err != nil {
+		return 0, err
+	}
+	return int64(len(membersInScoreRange(s.getDB(db).zset(key), min, max))), nil
+}
+
+// ZRemRangeByRank removes the members with rank in [start, stop], returns the number of members removed
+func (s *Storage) ZRemRangeByRank(ctx context.Context, db int64, key []byte, start int64, stop int64) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeZSet); err != nil {
+		return 0, err
+	}
+	d := s.getDB(db)
+	zset := d.zset(key)
+	members := sortedMembers(zset)
+	from, to := listRange(start, stop, len(members))
+	members = members[from : to+1]
+	d.removeMembers(key, zset, members)
+	return int64(len(members)), nil
+}
+
+// ZRemRangeByScore removes the members with min <= score <= max, returns the number of members removed
+func (s *Storage) ZRemRangeByScore(ctx context.Context, db int64, key []byte, min storage.ScoreBound, max storage.ScoreBound) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if err := s.getDB(db).checkType(key, typeZSet); err != nil {
+		return 0, err
+	}
+	d := s.getDB(db)
+	zset := d.zset(key)
+	members := membersInScoreRange(zset, min, max)
+	d.removeMembers(key, zset, members)
+	return int64(len(members)), nil
+}
+
+// ZUnionStore stores the union of the zsets in dst, returns the size of dst
+func (s *Storage) ZUnionStore(ctx context.Context, db int64, dst []byte, keys [][]byte, weights []float64, aggregate storage.Aggregate) (int64, error) {
+	return s.zsetStore(db, true, dst, keys, weights, aggregate)
+}
+
+// ZInterStore stores the intersection of the zsets in dst, returns the size of dst
+func (s *Storage) ZInterStore(ctx context.Context, db int64, dst []byte, keys [][]byte, weights []float64, aggregate storage.Aggregate) (int64, error) {
+	return s.zsetStore(db, false, dst, keys, weights, aggregate)
+}
+
+// ZUnion returns the union of the zsets ordered by score
+func (s *Storage) ZUnion(ctx context.Context, db int64, keys [][]byte, weights []float64, aggregate storage.Aggregate) ([]storage.ZMember, error) {
+	return s.zsetCombine(db, keys, func(zsets []map[string]float64) map[string]float64 {
+		return storage.CombineZSets(zsets, true, weights, aggregate)
+	})
+}
+
+// ZInter returns the intersection of the zsets ordered by score
+func (s *Storage) ZInter(ctx context.Context, db int64, keys [][]byte, weights []float64, aggregate storage.Aggregate) ([]storage.ZMember, error) {
+	return s.zsetCombine(db, keys, func(zsets []map[string]float64) map[string]float64 {
+		return storage.CombineZSets(zsets, false, weights, aggregate)
+	})
+}
+
+// ZDiff returns the members of the first zset not in the others ordered by score
+func (s *Storage) ZDiff(ctx context.Context, db int64, keys [][]byte) ([]storage.ZMember, error) {
+	return s.zsetCombine(db, keys, storage.DiffZSets)
+}
+
+// zsetCombine combines the zsets with combine and returns the result ordered by score
+func (s *Storage) zsetCombine(db int64, keys [][]byte, combine func([]map[string]float64) map[string]float64) ([]storage.ZMember, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	d := s.getDB(db)
+	zsets := make([]map[string]float64, 0, len(keys))
+	for _, key := range keys {
+		if err := d.checkType(key, typeZSet); err != nil {
+			return nil, err
+		}
+		zsets = append(zsets, d.zset(key))
+	}
+	return zMembers(sortedMembers(combine(zsets))), nil
+}
+
+// zsetStore computes the union or intersection of the zsets and stores it in dst,
+// which is overwritten whatever type it holds. Missing weights default to 1
+func (s *Storage) zsetStore(db int64, union bool, dst []byte, keys [][]byte, weights []float64, aggregate storage.Aggregate) (int64, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	d := s.getDB(db)
+	for _, key := range keys {
+		if err := d.checkType(key, typeZSet); err != nil {
+			return 0, err
+		}
+	}
+	zsets := make([]map[string]float64, 0, len(keys))
+	for _, key := range keys {
+		zsets = append(zsets, d.zset(key))
+	}
+	res := storage.CombineZSets(zsets, union, weights, aggregate)
+
+	d.removeOtherTypes(dst, typeZSet)
+	d.zsets.remove(dst)
+	if len(res) > 0 {
+		d.zsets.set(dst, res)
+	}
+	return int64(len(res)), nil
+}
+
+// ZScan returns at most count members greater than after in ascending order of the members
+func (s *Storage) ZScan(ctx context.Context, db int64, key []byte, after []byte, count int64) ([]storage.ZMember, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	d := s.getDB(db)
+	if err := d.checkType(key, typeZSet); err != nil {
+		return nil, err
+	}
+	zset := d.zset(key)
+	page := pageAfter(sortedKeys(zset), after, count)
+	res := make([]storage.ZMember, 0, len(page))
+	for _, member := range page {
+		res = append(res, storage.ZMember{Member: []byte(member), Score: zset[member]})
+	}
+	return res, nil
+}
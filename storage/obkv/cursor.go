@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package obkv
+
+import (
+	"context"
+	"time"
+
+	"github.com/oceanbase/obkv-table-client-go/table"
+)
+
+/*
+cursor model:
+CREATE TABLE modis_cursor_table(
+  db bigint not null,
+  id bigint not null,
+  expire_ts timestamp(6) not null,
+  pos varbinary(65535) not null,
+  PRIMARY KEY(db, id))
+  TTL(expire_ts + INTERVAL 0 SECOND)
+  PARTITION BY KEY(db, id) PARTITIONS 3;
+
+A row keeps the position where a SCAN family iteration stopped, id is the cursor returned to
+the client, so that the iteration can go on through any modis node.
+*/
+
+const (
+	cursorTableName = "modis_cursor_table"
+
+	cursorIDColumnName  = "id"
+	cursorPosColumnName = "pos"
+)
+
+func cursorRowKey(db int64, id uint64) []*table.Column {
+	return []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(cursorIDColumnName, int64(id)),
+	}
+}
+
+// SaveCursor keeps the position of an iteration under id for ttl
+func (s *Storage) SaveCursor(ctx context.Context, db int64, id uint64, pos []byte, ttl time.Duration) error {
+	_, err := s.cli.InsertOrUpdate(ctx, cursorTableName, cursorRowKey(db, id), []*table.Column{
+		table.NewColumn(cursorPosColumnName, pos),
+		table.NewColumn(expireColumnName, table.TimeStamp(time.Now().Add(ttl))),
+	})
+	return err
+}
+
+// LoadCursor returns the position saved under id, nil if it not exists or expired
+func (s *Storage) LoadCursor(ctx context.Context, db int64, id uint64) ([]byte, error) {
+	res, err := s.cli.Get(ctx, cursorTableName, cursorRowKey(db, id), []string{cursorPosColumnName, expireColumnName})
+	if err != nil || res.IsEmptySet() {
+		return nil, err
+	}
+	// the expired rows are purged in the background
+	if expire, ok := res.Value(expireColumnName).(time.Time); !ok || !expire.After(time.Now()) {
+		return nil, nil
+	}
+	pos, _ := res.Value(cursorPosColumnName).([]byte)
+	return pos, nil
+}
@@ -71,7 +71,7 @@ func (s *Storage) HGet(ctx context.Context, db int64, key []byte, field []byte)
 	if res.Value(valueColumnName) != nil {
 		return res.Value(valueColumnName).([]byte), nil
 	} else {
-		return nil, nil
+		return nil, s.checkType(ctx, db, key, hashTableName)
 	}
 }
 
@@ -158,15 +158,23 @@ func (s *Storage) HGetAll(ctx context.Context, db int64, key []byte) ([][]byte,
 		values = append(values, res.Value(fieldColumnName).([]byte))
 		values = append(values, res.Value(valueColumnName).([]byte))
 	}
-	if err != nil {
-		return nil, err
+	if err != nil || len(values) != 0 {
+		return values, err
 	}
-
-	return values, nil
+	return values, s.checkType(ctx, db, key, hashTableName)
 }
 
 // HKeys hash keys
 func (s *Storage) HKeys(ctx context.Context, db int64, key []byte) ([][]byte, error) {
+	fields, err := s.hashKeys(ctx, db, key)
+	if err != nil || len(fields) != 0 {
+		return fields, err
+	}
+	return fields, s.checkType(ctx, db, key, hashTableName)
+}
+
+// hashKeys returns the fields of the hash without checking the type of key
+func (s *Storage) hashKeys(ctx context.Context, db int64, key []byte) ([][]byte, error) {
 	tableName := hashTableName
 
 	// Prepare key range
@@ -250,15 +258,23 @@ func (s *Storage) HVals(ctx context.Context, db int64, key []byte) ([][]byte, er
 	for ; res != nil && err == nil; res, err = resSet.Next() {
 		values = append(values, res.Value(valueColumnName).([]byte))
 	}
-	if err != nil {
-		return nil, err
+	if err != nil || len(values) != 0 {
+		return values, err
 	}
-
-	return values, nil
+	return values, s.checkType(ctx, db, key, hashTableName)
 }
 
 // HLen hash length
 func (s *Storage) HLen(ctx context.Context, db int64, key []byte) (int64, error) {
+	num, err := s.hashLen(ctx, db, key)
+	if err != nil || num != 0 {
+		return num, err
+	}
+	return 0, s.checkType(ctx, db, key, hashTableName)
+}
+
+// hashLen returns the number of fields of the hash without checking the type of key
+func (s *Storage) hashLen(ctx context.Context, db int64, key []byte) (int64, error) {
 	tableName := hashTableName
 
 	// Prepare key range
@@ -290,6 +306,10 @@ func (s *Storage) HLen(ctx context.Context, db int64, key []byte) (int64, error)
 
 // HSetNx hash set if not exist
 func (s *Storage) HSetNx(ctx context.Context, db int64, key []byte, field []byte, value []byte) (int, error) {
+	if err := s.checkType(ctx, db, key, hashTableName); err != nil {
+		return -1, err
+	}
+
 	tableName := hashTableName
 
 	// Set rowKey columns
@@ -348,6 +368,7 @@ func (s *Storage) HMGet(ctx context.Context, db int64, key []byte, fields [][]by
 
 	// Handle result
 	values := make([][]byte, 0, res.Size())
+	found := false
 	for i := 0; i < res.Size(); i++ {
 		singleRes := res.GetResults()[i]
 		if singleRes == nil {
@@ -358,16 +379,23 @@ func (s *Storage) HMGet(ctx context.Context, db int64, key []byte, fields [][]by
 			values = append(values, nil)
 		} else {
 			values = append(values, value.([]byte))
+			found = true
 		}
 	}
-
-	return values, nil
+	if found {
+		return values, nil
+	}
+	return values, s.checkType(ctx, db, key, hashTableName)
 }
 
 // HIncrBy Add value from the value of the key.
 // If the key does not exist, value is written and value is returned
 // Returns the value add value when key is present;
 func (s *Storage) HIncrBy(ctx context.Context, db int64, key []byte, field []byte, value []byte) (int64, error) {
+	if err := s.checkType(ctx, db, key, hashTableName); err != nil {
+		return -1, err
+	}
+
 	tableName := hashTableName
 
 	// Set rowKey columns
@@ -402,6 +430,10 @@ func (s *Storage) HIncrBy(ctx context.Context, db int64, key []byte, field []byt
 // If the key does not exist, value is written and value is returned
 // Returns the value add value when key is present;
 func (s *Storage) HIncrByFloat(ctx context.Context, db int64, key []byte, field []byte, value []byte) (float64, error) {
+	if err := s.checkType(ctx, db, key, hashTableName); err != nil {
+		return -1, err
+	}
+
 	tableName := hashTableName
 
 	// Set rowKey columns
@@ -432,11 +464,29 @@ func (s *Storage) HIncrByFloat(ctx context.Context, db int64, key []byte, field
 	return f64, nil
 }
 
+// HSet sets the fields of the hash, returns the number of fields added
+func (s *Storage) HSet(ctx context.Context, db int64, key []byte, fieldValues map[string][]byte) (int64, error) {
+	if err := s.checkType(ctx, db, key, hashTableName); err != nil {
+		return 0, err
+	}
+
+	rowKey := []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(keyColumnName, key),
+	}
+	args := make([][]byte, 0, 2+2*len(fieldValues))
+	args = append(args, []byte("hset"), key)
+	for field, value := range fieldValues {
+		args = append(args, []byte(field), value)
+	}
+	return replyInteger(s.redisCmd(ctx, hashTableName, rowKey, args...))
+}
+
 // hashExists check the number of keys that exist in hash table
 func (s *Storage) hashExists(ctx context.Context, db int64, keys [][]byte) (int64, error) {
 	var existNum int64
 	for _, key := range keys {
-		num, err := s.HLen(ctx, db, key)
+		num, err := s.hashLen(ctx, db, key)
 		if err != nil {
 			return 0, err
 		}
@@ -454,7 +504,7 @@ func (s *Storage) deleteHash(ctx context.Context, db int64, keys [][]byte) (int6
 	var deleteNum int64
 	for _, key := range keys {
 		// Get fields by key
-		fields, err := s.HKeys(ctx, db, key)
+		fields, err := s.hashKeys(ctx, db, key)
 		if err != nil {
 			return 0, err
 		}
@@ -470,13 +520,30 @@ func (s *Storage) deleteHash(ctx context.Context, db int64, keys [][]byte) (int6
 	return deleteNum, nil
 }
 
+// HScan returns at most count fields greater than after in ascending order, each followed by its value
+func (s *Storage) HScan(ctx context.Context, db int64, key []byte, after []byte, count int64) ([][]byte, error) {
+	rows, err := s.scanKeyRows(ctx, hashTableName, db, key, fieldColumnName, after, count,
+		[]string{fieldColumnName, valueColumnName})
+	if err != nil {
+		return nil, err
+	}
+	res := make([][]byte, 0, 2*len(rows))
+	for _, row := range rows {
+		res = append(res, row.Value(fieldColumnName).([]byte), row.Value(valueColumnName).([]byte))
+	}
+	if len(res) != 0 {
+		return res, nil
+	}
+	return res, s.checkType(ctx, db, key, hashTableName)
+}
+
 // expireHash expire hash table
 func (s *Storage) expireHash(ctx context.Context, db int64, key []byte, expire_ts table.TimeStamp) (int, error) {
 	tableName := hashTableName
 	var res = 0
 
 	// 1. Get all fields
-	fields, err := s.HKeys(ctx, db, key)
+	fields, err := s.hashKeys(ctx, db, key)
 	if err != nil {
 		return 0, err
 	}
@@ -515,7 +582,7 @@ func (s *Storage) persistHash(ctx context.Context, db int64, key []byte) (int, e
 	var res = 0
 
 	// 1. Get all fields
-	fields, err := s.HKeys(ctx, db, key)
+	fields, err := s.hashKeys(ctx, db, key)
 	if err != nil {
 		return 0, err
 	}
@@ -554,7 +621,7 @@ func (s *Storage) ttlHash(ctx context.Context, db int64, key []byte) (time.Durat
 	batchExecutor := s.cli.NewBatchExecutor(tableName)
 
 	// 1. Get all fields
-	fields, err := s.HKeys(ctx, db, key)
+	fields, err := s.hashKeys(ctx, db, key)
 	if err != nil {
 		return 0, err
 	}
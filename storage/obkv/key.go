@@ -17,79 +17,130 @@
 package obkv
 
 import (
+	"bytes"
 	"context"
-	"errors"
-	"strings"
+	"sort"
 	"time"
 
+	"github.com/oceanbase/obkv-table-client-go/client/filter"
+	"github.com/oceanbase/obkv-table-client-go/client/option"
 	"github.com/oceanbase/obkv-table-client-go/table"
+
+	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/oceanbase/modis/storage"
 )
 
-// Type get the type of the key
-// check order: string hash list zset set
-func (s *Storage) Type(ctx context.Context, db int64, key []byte) ([]byte, error) {
-	var keys [][]byte
-	var types []byte
-	keys = append(keys, key)
-	is_first := true
+// keyType is a data type stored in a table of its own
+type keyType struct {
+	name      string
+	tableName string
+	delete    func(ctx context.Context, db int64, keys [][]byte) (int64, error)
+	expire    func(ctx context.Context, db int64, key []byte, expire_ts table.TimeStamp) (int, error)
+	persist   func(ctx context.Context, db int64, key []byte) (int, error)
+	ttl       func(ctx context.Context, db int64, key []byte) (time.Duration, error)
+}
 
-	num, err := s.stringExists(ctx, db, keys)
-	if err != nil {
-		return nil, err
-	}
-	if num != 0 {
-		types = append(types, []byte("string")...)
-		is_first = false
+// keyTypes returns the data types in the type check order: string hash list zset set stream
+func (s *Storage) keyTypes() []keyType {
+	return []keyType{
+		{"string", stringTableName, s.deleteString, s.expireString, s.persistString, s.ttlString},
+		{"hash", hashTableName, s.deleteHash, s.expireHash, s.persistHash, s.ttlHash},
+		{"list", listTableName, s.deleteList, s.expireList, s.persistList, s.ttlList},
+		{"zset", zsetTableName, s.deleteZSet, s.expireZSet, s.persistZSet, s.ttlZSet},
+		{"set", setTableName, s.deleteSet, s.expireSet, s.persistSet, s.ttlSet},
+		{"stream", streamTableName, s.deleteStream, s.expireStream, s.persistStream, s.ttlStream},
 	}
+}
 
-	num, err = s.hashExists(ctx, db, keys)
-	if err != nil {
-		return nil, err
-	}
-	if num != 0 {
-		if !is_first {
-			types = append(types, []byte(", ")...)
+// keyTypeOf returns the data type of key, nil if the key not exists.
+// The tables are probed in the type check order and the first one holding key wins
+func (s *Storage) keyTypeOf(ctx context.Context, db int64, key []byte) (*keyType, error) {
+	return s.probeKeyType(ctx, db, key, "")
+}
+
+// probeKeyType returns the data type of the first table other than skipTable holding key, nil if none holds it
+func (s *Storage) probeKeyType(ctx context.Context, db int64, key []byte, skipTable string) (*keyType, error) {
+	for _, kt := range s.keyTypes() {
+		if kt.tableName == skipTable {
+			continue
+		}
+		ok, err := s.keyInTable(ctx, db, key, kt.tableName)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return &kt, nil
 		}
-		types = append(types, []byte("hash")...)
-		is_first = false
 	}
+	return nil, nil
+}
 
-	num, err = s.listExists(ctx, db, keys)
+// keyInTable reports whether key has live rows in the table by reading at most one row of it.
+// The list and stream keys carry their expire column in the meta row, which goes first,
+// while the ttl of a hash, set or sorted set is kept in its data rows, so one of them is read
+func (s *Storage) keyInTable(ctx context.Context, db int64, key []byte, tableName string) (bool, error) {
+	opts := []option.ObQueryOption{
+		option.WithQuerySelectColumns([]string{expireColumnName}),
+		option.WithQueryLimit(1),
+	}
+	switch tableName {
+	case hashTableName, setTableName, zsetTableName:
+		opts = append(opts, option.WithQueryFilter(filter.CompareVal(filter.Equal, isDataColumnName, true)))
+	}
+	resSet, err := s.cli.Query(ctx, tableName, keyRowKeyRange(db, key, tableName), opts...)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
-	if num != 0 {
-		if !is_first {
-			types = append(types, []byte(", ")...)
-		}
-		types = append(types, []byte("list")...)
-		is_first = false
+	defer resSet.Close()
+
+	row, err := resSet.Next()
+	if err != nil || row == nil {
+		return false, err
 	}
+	expire, ok := row.Value(expireColumnName).(time.Time)
+	return !ok || expire.After(time.Now()), nil
+}
 
-	num, err = s.zsetExists(ctx, db, keys)
-	if err != nil {
+// Type get the type of the key, nil if the key not exists
+// check order: string hash list zset set stream
+func (s *Storage) Type(ctx context.Context, db int64, key []byte) ([]byte, error) {
+	kt, err := s.keyTypeOf(ctx, db, key)
+	if err != nil || kt == nil {
 		return nil, err
 	}
-	if num != 0 {
-		if !is_first {
-			types = append(types, []byte(", ")...)
-		}
-		types = append(types, []byte("zset")...)
-		is_first = false
-	}
+	return []byte(kt.name), nil
+}
 
-	num, err = s.setExists(ctx, db, keys)
+// checkType returns storage.ErrWrongType if key exists in a table other than tableName.
+// It is called by the commands that may create key, so that a key holds one type only.
+// A key with rows in tableName holds that type, so the other tables are probed only if it has none
+func (s *Storage) checkType(ctx context.Context, db int64, key []byte, tableName string) error {
+	ok, err := s.keyInTable(ctx, db, key, tableName)
+	if err != nil || ok {
+		return err
+	}
+	kt, err := s.probeKeyType(ctx, db, key, tableName)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if num != 0 {
-		if !is_first {
-			types = append(types, []byte(", ")...)
-		}
-		types = append(types, []byte("set")...)
+	if kt != nil {
+		return storage.ErrWrongType
 	}
+	return nil
+}
 
-	return types, nil
+// deleteOtherTypes deletes keys from the tables other than tableName,
+// it is called by the commands that overwrite keys whatever type they hold, e.g. SET
+func (s *Storage) deleteOtherTypes(ctx context.Context, db int64, keys [][]byte, tableName string) error {
+	for _, kt := range s.keyTypes() {
+		if kt.tableName == tableName {
+			continue
+		}
+		if _, err := kt.delete(ctx, db, keys); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Exists check the number of keys that exist
@@ -125,6 +176,12 @@ func (s *Storage) Exists(ctx context.Context, db int64, keys [][]byte) (int64, e
 	}
 	existsNum += num
 
+	num, err = s.streamExists(ctx, db, keys)
+	if err != nil {
+		return 0, err
+	}
+	existsNum += num
+
 	return existsNum, nil
 }
 
@@ -161,184 +218,228 @@ func (s *Storage) Delete(ctx context.Context, db int64, keys [][]byte) (int64, e
 	}
 	deleteNum += num
 
+	num, err = s.deleteStream(ctx, db, keys)
+	if err != nil {
+		return 0, err
+	}
+	deleteNum += num
+
 	return deleteNum, nil
 }
 
-// Expire sets a timeout on key
+// Expire sets a timeout on key, the key is deleted at once if at is not in the future
 func (s *Storage) Expire(ctx context.Context, db int64, key []byte, at time.Time) (int, error) {
-	var expireNum int
-	var err_msg string
-	var err error
-
-	val, err := s.Type(ctx, db, key)
+	kt, err := s.keyTypeOf(ctx, db, key)
+	if err != nil || kt == nil {
+		return 0, err
+	}
+	if !at.After(time.Now()) {
+		num, err := kt.delete(ctx, db, [][]byte{key})
+		return int(num), err
+	}
+	res, err := kt.expire(ctx, db, key, table.TimeStamp(at))
 	if err != nil {
 		return 0, err
-	} else if val != nil {
-		if strings.Contains(string(val), "string") {
-			// expire string
-			_, err = s.expireString(ctx, db, key, table.TimeStamp(at))
-			if err != nil {
-				return 0, err
-			}
-			expireNum += 1
-			err_msg += "expire string success, "
-		}
-		if len(val) != 6 || string(val) != "string" {
-			return expireNum, errors.New(err_msg + "expire types other than string are not supported")
-		}
 	}
-
-	// // expire hash
-	// res, err = s.expireHash(ctx, db, key, table.TimeStamp(at))
-	// if err != nil {
-	// 	return 0, err
-	// }
-	// if res != 0 {
-	// 	return res, nil
-	// }
-
-	// // expire list
-	// res, err = s.expireList(ctx, db, key, table.TimeStamp(at))
-	// if err != nil {
-	// 	return 0, err
-	// }
-	// if res != 0 {
-	// 	return res, nil
-	// }
-
-	// // expire zset
-	// res, err = s.expireZSet(ctx, db, key, table.TimeStamp(at))
-	// if err != nil {
-	// 	return 0, err
-	// }
-	// if res != 0 {
-	// 	return res, nil
-	// }
-
-	// // expire set
-	// res, err = s.expireSet(ctx, db, key, table.TimeStamp(at))
-	// if err != nil {
-	// 	return 0, err
-	// }
-	// if res != 0 {
-	// 	return res, nil
-	// }
-
-	return expireNum, nil
+	if res != 0 {
+		res = 1
+	}
+	return res, nil
 }
 
 // Persist removes the existing timeout on key, turning the key from volatile to persistent
 func (s *Storage) Persist(ctx context.Context, db int64, key []byte) (int, error) {
-	res := 0
-	var err error
-
-	val, err := s.Type(ctx, db, key)
-	if err != nil {
+	kt, err := s.keyTypeOf(ctx, db, key)
+	if err != nil || kt == nil {
 		return 0, err
-	} else if val != nil && (len(val) != 6 || string(val) != "string") {
-		return 0, errors.New("expire types other than string are not supported")
 	}
-
-	// persist string
-	res, err = s.persistString(ctx, db, key)
+	sub, err := kt.ttl(ctx, db, key)
+	if err != nil || sub < 0 {
+		return 0, err
+	}
+	res, err := kt.persist(ctx, db, key)
 	if err != nil {
 		return 0, err
 	}
 	if res != 0 {
-		return res, nil
-	}
-
-	// // persist hash
-	// res, err = s.persistHash(ctx, db, key)
-	// if err != nil {
-	// 	return 0, err
-	// }
-	// if res != 0 {
-	// 	return res, nil
-	// }
-
-	// // persist list
-	// res, err = s.persistList(ctx, db, key)
-	// if err != nil {
-	// 	return 0, err
-	// }
-	// if res != 0 {
-	// 	return res, nil
-	// }
-
-	// // persist zset
-	// res, err = s.persistZSet(ctx, db, key)
-	// if err != nil {
-	// 	return 0, err
-	// }
-	// if res != 0 {
-	// 	return res, nil
-	// }
-
-	// // persist set
-	// res, err = s.persistSet(ctx, db, key)
-	// if err != nil {
-	// 	return 0, err
-	// }
-	// if res != 0 {
-	// 	return res, nil
-	// }
-
-	return 0, nil
+		res = 1
+	}
+	return res, nil
 }
 
+// TTL returns the remaining time to live of a key, -2 if the key not exists
+// and -1 if the key has no associated expire
 func (s *Storage) TTL(ctx context.Context, db int64, key []byte) (time.Duration, error) {
-	val, err := s.Type(ctx, db, key)
+	kt, err := s.keyTypeOf(ctx, db, key)
 	if err != nil {
 		return 0, err
-	} else if val != nil && (len(val) != 6 || string(val) != "string") {
-		return 0, errors.New("expire types other than string are not supported")
+	}
+	if kt == nil {
+		return -2, nil
+	}
+	return kt.ttl(ctx, db, key)
+}
+
+// Scan returns about count keys of type typ greater than after in ascending order.
+// The rows are ordered within a partition only and the query limit applies to each partition,
+// so the rows up to the count-th smallest one are all the rows in that range across partitions
+func (s *Storage) Scan(ctx context.Context, db int64, typ string, after []byte, count int64) ([][]byte, error) {
+	tableName := ""
+	for _, kt := range s.keyTypes() {
+		if kt.name == typ {
+			tableName = kt.tableName
+		}
+	}
+	if tableName == "" {
+		return nil, nil
 	}
 
-	// ttl string
-	sub, err := s.ttlString(ctx, db, key)
+	keyRanges, err := dbRowKeyRange(db, tableName, after)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	resSet, err := s.cli.Query(
+		ctx,
+		tableName,
+		keyRanges,
+		option.WithQuerySelectColumns([]string{keyColumnName}),
+		option.WithQueryLimit(int(count)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer resSet.Close()
+
+	rowKeys := make([][]byte, 0, count)
+	res, err := resSet.Next()
+	for ; res != nil && err == nil; res, err = resSet.Next() {
+		rowKeys = append(rowKeys, res.Value(keyColumnName).([]byte))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rowKeys) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(rowKeys, func(i, j int) bool { return bytes.Compare(rowKeys[i], rowKeys[j]) < 0 })
+	if int64(len(rowKeys)) > count {
+		rowKeys = rowKeys[:count]
+	}
+	keys := make([][]byte, 0, len(rowKeys))
+	for _, key := range rowKeys {
+		if len(keys) == 0 || !bytes.Equal(keys[len(keys)-1], key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// Copy copies the value of src in srcDB to dst in dstDB along with its ttl
+func (s *Storage) Copy(ctx context.Context, srcDB int64, src []byte, dstDB int64, dst []byte, replace bool) (bool, error) {
+	kt, err := s.keyTypeOf(ctx, srcDB, src)
+	if err != nil || kt == nil {
+		return false, err
+	}
+	return s.copyKey(ctx, kt, srcDB, src, dstDB, dst, replace)
+}
+
+// renameAttempts is the number of times Rename copies src again after finding it written during the copy
+const renameAttempts = 10
+
+// Rename moves the value of src in srcDB to dst in dstDB along with its ttl.
+// The rows of src are copied to dst and then deleted, the two steps are not in one transaction,
+// so src is deleted only if its version, see KeyVersion, is still the one copied, and it is copied
+// again otherwise. A write to src between the last check and the deletion is still lost, and src
+// and dst may both exist if the deletion fails
+func (s *Storage) Rename(ctx context.Context, srcDB int64, src []byte, dstDB int64, dst []byte, replace bool) (bool, error) {
+	kt, err := s.keyTypeOf(ctx, srcDB, src)
+	if err != nil {
+		return false, err
+	}
+	if kt == nil {
+		return false, storage.ErrNoSuchKey
+	}
+	if srcDB == dstDB && bytes.Equal(src, dst) {
+		return true, nil
+	}
+	version, err := s.KeyVersion(ctx, srcDB, src)
+	if err != nil {
+		return false, err
+	}
+	for attempts := 1; ; attempts++ {
+		ok, err := s.copyKey(ctx, kt, srcDB, src, dstDB, dst, replace)
+		if err != nil || !ok {
+			return false, err
+		}
+		cur, err := s.KeyVersion(ctx, srcDB, src)
+		if err != nil {
+			return false, err
+		}
+		if bytes.Equal(cur, version) {
+			break
+		}
+		if attempts == renameAttempts {
+			return false, resp.ErrorReply("ERR the source key keeps being written, both keys exist")
+		}
+		// dst is our copy now, it is replaced by the copy of the new version
+		version, replace = cur, true
+		if kt, err = s.keyTypeOf(ctx, srcDB, src); err != nil {
+			return false, err
+		}
+		if kt == nil {
+			// deleted meanwhile, dst keeps the last version copied
+			return true, nil
+		}
+	}
+	if _, err = kt.delete(ctx, srcDB, [][]byte{src}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// copyKey copies src of type kt to dst, returns false if dst exists and replace is not set.
+// The rows of src are written over dst first, the rest of dst is deleted only once the copy succeeds,
+// so that a failed copy never leaves dst deleted
+func (s *Storage) copyKey(ctx context.Context, kt *keyType, srcDB int64, src []byte, dstDB int64, dst []byte, replace bool) (bool, error) {
+	if srcDB == dstDB && bytes.Equal(src, dst) {
+		return true, nil
+	}
+	dstType, err := s.keyTypeOf(ctx, dstDB, dst)
+	if err != nil {
+		return false, err
+	}
+	if dstType != nil && !replace {
+		return false, nil
+	}
+	// the consumer groups of a stream are kept in a table of their own
+	tableNames := []string{kt.tableName}
+	if kt.tableName == streamTableName {
+		tableNames = append(tableNames, streamGroupTableName)
+	}
+	stale := make(map[string]map[string][]interface{}, len(tableNames))
+	if dstType != nil && dstType.tableName == kt.tableName {
+		for _, tableName := range tableNames {
+			if stale[tableName], err = s.queryRowKeys(ctx, tableName, dstDB, dst); err != nil {
+				return false, err
+			}
+		}
+	}
+	for _, tableName := range tableNames {
+		if err = s.copyRows(ctx, tableName, srcDB, src, dstDB, dst, stale[tableName]); err != nil {
+			return false, err
+		}
+	}
+
+	if dstType != nil && dstType.tableName != kt.tableName {
+		if _, err = dstType.delete(ctx, dstDB, [][]byte{dst}); err != nil {
+			return false, err
+		}
+	}
+	for tableName, rows := range stale {
+		if err = s.deleteRowKeys(ctx, tableName, dstDB, dst, rows); err != nil {
+			return false, err
+		}
 	}
-	if sub >= -1 {
-		return sub, nil
-	}
-
-	// // ttl hash
-	// sub, err = s.ttlHash(ctx, db, key)
-	// if err != nil {
-	// 	return 0, err
-	// }
-	// if sub >= -1 {
-	// 	return sub, nil
-	// }
-
-	// // ttl list
-	// sub, err = s.ttlList(ctx, db, key)
-	// if err != nil {
-	// 	return 0, err
-	// }
-	// if sub >= -1 {
-	// 	return sub, nil
-	// }
-
-	// // ttl zset
-	// sub, err = s.ttlZSet(ctx, db, key)
-	// if err != nil {
-	// 	return 0, err
-	// }
-	// if sub >= -1 {
-	// 	return sub, nil
-	// }
-
-	// // ttl set
-	// sub, err = s.ttlSet(ctx, db, key)
-	// if err != nil {
-	// 	return 0, err
-	// }
-	// if sub >= -1 {
-	// 	return sub, nil
-	// }
-
-	return -2, nil
+	return true, nil
 }
@@ -17,11 +17,14 @@
 package obkv
 
 import (
+	"bytes"
 	"context"
 	"math"
 	"time"
 
+	"github.com/oceanbase/modis/log"
 	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/oceanbase/obkv-table-client-go/client/option"
 	"github.com/oceanbase/obkv-table-client-go/table"
 )
 
@@ -46,6 +49,246 @@ const (
 	listTableName = "modis_list_table"
 )
 
+// listRowKey returns the row key to route a list command, which is the meta row of the list
+func listRowKey(db int64, key []byte) []*table.Column {
+	return []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(keyColumnName, key),
+		table.NewColumn(indexColumnName, int64(math.MinInt64)),
+	}
+}
+
+func (s *Storage) push(ctx context.Context, cmd string, db int64, key []byte, values [][]byte) (int64, error) {
+	if err := s.checkType(ctx, db, key, listTableName); err != nil {
+		return 0, err
+	}
+	args := make([][]byte, 0, 2+len(values))
+	args = append(args, []byte(cmd), key)
+	args = append(args, values...)
+	return replyInteger(s.redisCmd(ctx, listTableName, listRowKey(db, key), args...))
+}
+
+// LPush inserts the values at the head of the list, returns the length of the list
+func (s *Storage) LPush(ctx context.Context, db int64, key []byte, values [][]byte) (int64, error) {
+	return s.push(ctx, "lpush", db, key, values)
+}
+
+// LPushX inserts the values at the head of the list only if the list exists
+func (s *Storage) LPushX(ctx context.Context, db int64, key []byte, values [][]byte) (int64, error) {
+	return s.push(ctx, "lpushx", db, key, values)
+}
+
+// RPush inserts the values at the tail of the list, returns the length of the list
+func (s *Storage) RPush(ctx context.Context, db int64, key []byte, values [][]byte) (int64, error) {
+	return s.push(ctx, "rpush", db, key, values)
+}
+
+// RPushX inserts the values at the tail of the list only if the list exists
+func (s *Storage) RPushX(ctx context.Context, db int64, key []byte, values [][]byte) (int64, error) {
+	return s.push(ctx, "rpushx", db, key, values)
+}
+
+// LPop removes and returns the first element of the list, nil if the list not exists
+func (s *Storage) LPop(ctx context.Context, db int64, key []byte) ([]byte, error) {
+	return s.listBulkString(ctx, db, key, []byte("lpop"), key)
+}
+
+// RPop removes and returns the last element of the list, nil if the list not exists
+func (s *Storage) RPop(ctx context.Context, db int64, key []byte) ([]byte, error) {
+	return s.listBulkString(ctx, db, key, []byte("rpop"), key)
+}
+
+// listBulkString runs a list command replying a bulk string, the type of key is checked if the reply is nil
+func (s *Storage) listBulkString(ctx context.Context, db int64, key []byte, args ...[]byte) ([]byte, error) {
+	value, err := replyBulkString(s.redisCmd(ctx, listTableName, listRowKey(db, key), args...))
+	if err != nil || value != nil {
+		return value, err
+	}
+	return nil, s.checkType(ctx, db, key, listTableName)
+}
+
+// popCount pops at most count elements one by one, nil if the list not exists
+func (s *Storage) popCount(ctx context.Context, cmd string, db int64, key []byte, count int64) ([][]byte, error) {
+	var values [][]byte
+	for int64(len(values)) < count {
+		value, err := replyBulkString(s.redisCmd(ctx, listTableName, listRowKey(db, key), []byte(cmd), key))
+		if err != nil {
+			return values, err
+		}
+		if value == nil {
+			break
+		}
+		values = append(values, value)
+	}
+	if values == nil && count == 0 {
+		// tell an empty list from a missing one
+		length, err := s.LLen(ctx, db, key)
+		if err != nil || length == 0 {
+			return nil, err
+		}
+		return [][]byte{}, nil
+	}
+	if values == nil {
+		return nil, s.checkType(ctx, db, key, listTableName)
+	}
+	return values, nil
+}
+
+// LPopCount removes and returns at most count elements from the head, nil if the list not exists
+func (s *Storage) LPopCount(ctx context.Context, db int64, key []byte, count int64) ([][]byte, error) {
+	return s.popCount(ctx, "lpop", db, key, count)
+}
+
+// RPopCount removes and returns at most count elements from the tail, nil if the list not exists
+func (s *Storage) RPopCount(ctx context.Context, db int64, key []byte, count int64) ([][]byte, error) {
+	return s.popCount(ctx, "rpop", db, key, count)
+}
+
+// LMove moves an element from src to dst, returns the element, nil if src not exists.
+// The rows of src and dst are in different partitions, so the element is claimed first by popping the
+// end row of src, which the observer does atomically on that row, so that it is never moved by two
+// clients nor left visible in both lists. It is then pushed to dst, and pushed back to the same end of
+// src if that fails. The commands moving elements hold the transaction lock exclusively, so the move is
+// atomic to the other commands through the same modis
+func (s *Storage) LMove(ctx context.Context, db int64, src []byte, dst []byte, srcLeft bool, dstLeft bool) ([]byte, error) {
+	if err := s.checkType(ctx, db, dst, listTableName); err != nil {
+		return nil, err
+	}
+
+	// 1. Claim the element at the end of src
+	var value []byte
+	var err error
+	if srcLeft {
+		value, err = s.LPop(ctx, db, src)
+	} else {
+		value, err = s.RPop(ctx, db, src)
+	}
+	if err != nil || value == nil {
+		return nil, err
+	}
+
+	// 2. Push it to dst
+	if dstLeft {
+		_, err = s.LPush(ctx, db, dst, [][]byte{value})
+	} else {
+		_, err = s.RPush(ctx, db, dst, [][]byte{value})
+	}
+	if err == nil {
+		return value, nil
+	}
+
+	// 3. Give it back to src
+	var restoreErr error
+	if srcLeft {
+		_, restoreErr = s.LPush(ctx, db, src, [][]byte{value})
+	} else {
+		_, restoreErr = s.RPush(ctx, db, src, [][]byte{value})
+	}
+	if restoreErr != nil {
+		log.Warn("storage", nil, "the element moved is lost, fail to push it to dst and back to src",
+			log.Errors(err), log.String("restore error", restoreErr.Error()),
+			log.String("src", string(src)), log.String("dst", string(dst)))
+	}
+	return nil, err
+}
+
+// LIndex returns the element at index, nil if index is out of range
+func (s *Storage) LIndex(ctx context.Context, db int64, key []byte, index int64) ([]byte, error) {
+	return s.listBulkString(ctx, db, key, []byte("lindex"), key, formatInt(index))
+}
+
+// LPos returns the indexes of the matches of element, the list is scanned by ranges of rowBatchSize elements
+func (s *Storage) LPos(ctx context.Context, db int64, key []byte, element []byte, rank int64, count int64, maxLen int64) ([]int64, error) {
+	length, err := s.LLen(ctx, db, key)
+	if err != nil || length == 0 {
+		return nil, err
+	}
+	if maxLen == 0 || maxLen > length {
+		maxLen = length
+	}
+	var indexes []int64
+	for scanned := int64(0); scanned < maxLen; scanned += rowBatchSize {
+		n := min(rowBatchSize, maxLen-scanned)
+		start := scanned
+		if rank < 0 {
+			start = length - scanned - n
+		}
+		values, err := s.LRange(ctx, db, key, start, start+n-1)
+		if err != nil {
+			return nil, err
+		}
+		for i := range values {
+			offset := int64(i)
+			if rank < 0 {
+				offset = int64(len(values)) - 1 - int64(i)
+			}
+			if !bytes.Equal(values[offset], element) {
+				continue
+			}
+			if rank > 1 {
+				rank--
+			} else if rank < -1 {
+				rank++
+			} else {
+				indexes = append(indexes, start+offset)
+				if count != 0 && int64(len(indexes)) == count {
+					return indexes, nil
+				}
+			}
+		}
+		if int64(len(values)) < n {
+			// the list is trimmed meanwhile
+			break
+		}
+	}
+	return indexes, nil
+}
+
+// LSet sets the element at index to value
+func (s *Storage) LSet(ctx context.Context, db int64, key []byte, index int64, value []byte) error {
+	return replyOk(s.redisCmd(ctx, listTableName, listRowKey(db, key), []byte("lset"), key, formatInt(index), value))
+}
+
+// LRange returns the elements in [start, stop]
+func (s *Storage) LRange(ctx context.Context, db int64, key []byte, start int64, stop int64) ([][]byte, error) {
+	values, err := replyArray(s.redisCmd(ctx, listTableName, listRowKey(db, key),
+		[]byte("lrange"), key, formatInt(start), formatInt(stop)))
+	if err != nil || len(values) != 0 {
+		return values, err
+	}
+	return values, s.checkType(ctx, db, key, listTableName)
+}
+
+// LTrim trims the list to the elements in [start, stop]
+func (s *Storage) LTrim(ctx context.Context, db int64, key []byte, start int64, stop int64) error {
+	return replyOk(s.redisCmd(ctx, listTableName, listRowKey(db, key),
+		[]byte("ltrim"), key, formatInt(start), formatInt(stop)))
+}
+
+// LInsert inserts value before or after pivot, returns the length of the list,
+// -1 if pivot is not found and 0 if the list not exists
+func (s *Storage) LInsert(ctx context.Context, db int64, key []byte, before bool, pivot []byte, value []byte) (int64, error) {
+	where := []byte("after")
+	if before {
+		where = []byte("before")
+	}
+	return replyInteger(s.redisCmd(ctx, listTableName, listRowKey(db, key), []byte("linsert"), key, where, pivot, value))
+}
+
+// LLen returns the length of the list
+func (s *Storage) LLen(ctx context.Context, db int64, key []byte) (int64, error) {
+	length, err := replyInteger(s.redisCmd(ctx, listTableName, listRowKey(db, key), []byte("llen"), key))
+	if err != nil || length != 0 {
+		return length, err
+	}
+	return 0, s.checkType(ctx, db, key, listTableName)
+}
+
+// LRem removes count occurrences of value, from the tail if count < 0 and all of them if count = 0
+func (s *Storage) LRem(ctx context.Context, db int64, key []byte, count int64, value []byte) (int64, error) {
+	return replyInteger(s.redisCmd(ctx, listTableName, listRowKey(db, key), []byte("lrem"), key, formatInt(count), value))
+}
+
 // listExists check the number of keys that exist in list table
 func (s *Storage) listExists(ctx context.Context, db int64, keys [][]byte) (int64, error) {
 	var exist_key_count int64 = 0
@@ -61,7 +304,7 @@ func (s *Storage) listExists(ctx context.Context, db int64, keys [][]byte) (int6
 			table.NewColumn(keyColumnName, keys[i]),
 			table.NewColumn(indexColumnName, int64(math.MinInt64)),
 		}
-		list_len, err := s.ObServerCmd(ctx, listTableName, rowKey, []byte(encodedArray))
+		list_len, err := s.obServerCmd(ctx, listTableName, rowKey, []byte(encodedArray))
 		if err != nil {
 			return exist_key_count, err
 		}
@@ -94,7 +337,7 @@ func (s *Storage) deleteList(ctx context.Context, db int64, keys [][]byte) (int6
 			table.NewColumn(keyColumnName, keys[i]),
 			table.NewColumn(indexColumnName, int64(math.MinInt64)),
 		}
-		res, err := s.ObServerCmd(ctx, listTableName, rowKey, []byte(encodedArray))
+		res, err := s.obServerCmd(ctx, listTableName, rowKey, []byte(encodedArray))
 		if err != nil {
 			continue
 		}
@@ -108,15 +351,89 @@ func (s *Storage) deleteList(ctx context.Context, db int64, keys [][]byte) (int6
 
 // expireList expire list table
 func (s *Storage) expireList(ctx context.Context, db int64, key []byte, expire_ts table.TimeStamp) (int, error) {
-	return 0, nil
+	return s.updateListExpire(ctx, db, key, table.NewColumn(expireColumnName, expire_ts))
 }
 
 // persistList persist list table
 func (s *Storage) persistList(ctx context.Context, db int64, key []byte) (int, error) {
-	return 0, nil
+	return s.updateListExpire(ctx, db, key, table.NewColumn(expireColumnName, nil))
+}
+
+// updateListExpire updates the expire column of all rows of the list, the meta row included,
+// so that the elements and the meta row expire together
+func (s *Storage) updateListExpire(ctx context.Context, db int64, key []byte, expire *table.Column) (int, error) {
+	tableName := listTableName
+	var res = 0
+
+	// 1. Get the row keys of all rows
+	resSet, err := s.cli.Query(
+		ctx,
+		tableName,
+		keyRowKeyRange(db, key, listTableName),
+		option.WithQuerySelectColumns([]string{isDataColumnName, indexColumnName}),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer resSet.Close()
+
+	rowKeys := make([][]*table.Column, 0, 128)
+	row, err := resSet.Next()
+	for ; row != nil && err == nil; row, err = resSet.Next() {
+		rowKeys = append(rowKeys, []*table.Column{
+			table.NewColumn(dbColumnName, db),
+			table.NewColumn(keyColumnName, key),
+			table.NewColumn(isDataColumnName, row.Value(isDataColumnName)),
+			table.NewColumn(indexColumnName, row.Value(indexColumnName)),
+		})
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// 2. Update all rows
+	for _, rowKey := range rowKeys {
+		affectedRows, err := s.cli.Update(ctx, tableName, rowKey, []*table.Column{expire})
+		if err != nil {
+			return 0, err
+		}
+		if affectedRows != 0 && res != 1 {
+			res = 1
+		}
+	}
+
+	return res, nil
 }
 
 // ttlList get expire time of list table
 func (s *Storage) ttlList(ctx context.Context, db int64, key []byte) (time.Duration, error) {
-	return -2, nil
+	tableName := listTableName
+
+	resSet, err := s.cli.Query(
+		ctx,
+		tableName,
+		keyRowKeyRange(db, key, listTableName),
+		option.WithQuerySelectColumns([]string{expireColumnName}),
+		option.WithQueryLimit(1),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer resSet.Close()
+
+	row, err := resSet.Next()
+	if err != nil {
+		return 0, err
+	}
+	if row == nil {
+		return -2, nil
+	}
+
+	if row.Value(expireColumnName) == nil {
+		return -1, nil
+	}
+
+	expire := row.Value(expireColumnName)
+	sub := time.Until(expire.(time.Time))
+	return sub, nil
 }
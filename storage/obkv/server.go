@@ -17,61 +17,367 @@
 package obkv
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oceanbase/obkv-table-client-go/client/filter"
+	"github.com/oceanbase/obkv-table-client-go/client/option"
+	"github.com/oceanbase/obkv-table-client-go/table"
+
+	"github.com/oceanbase/modis/storage"
 )
 
-type TableInfo struct {
-	Keys    int64 // num of keys in db
-	Expires int64 // num of keys with ttl in db
+// rowBatchSize is the number of rows handled at a time by the commands that move data in batches
+const rowBatchSize = 1000
+
+// tableRowKeyColumns is the row key columns of each table following db and rkey
+var tableRowKeyColumns = map[string][]string{
+	stringTableName:      {},
+	hashTableName:        {isDataColumnName, fieldColumnName},
+	listTableName:        {isDataColumnName, indexColumnName},
+	zsetTableName:        {isDataColumnName, memberColumnName},
+	setTableName:         {isDataColumnName, memberColumnName},
+	streamTableName:      {isDataColumnName, idMsColumnName, idSeqColumnName},
+	streamGroupTableName: {groupColumnName, rowTypeColumnName, consumerColumnName, idMsColumnName, idSeqColumnName},
+}
+
+// tableValueColumns is the columns of each table other than the row key columns
+var tableValueColumns = map[string][]string{
+	stringTableName: {valueColumnName, expireColumnName},
+	hashTableName:   {insertColumnName, expireColumnName, valueColumnName},
+	listTableName:   {insertColumnName, expireColumnName, valueColumnName},
+	zsetTableName:   {insertColumnName, expireColumnName, scoreColumnName},
+	setTableName:    {insertColumnName, expireColumnName},
+	streamTableName: {expireColumnName, valueColumnName, lastMsColumnName, lastSeqColumnName, entriesAddedColumnName,
+		maxDeletedMsColumnName, maxDeletedSeqColumnName},
+	streamGroupTableName: {expireColumnName, lastMsColumnName, lastSeqColumnName, entriesReadColumnName, seenTimeColumnName,
+		activeTimeColumnName, ownerColumnName, deliveryTimeColumnName, deliveryCountColumnName},
+}
+
+// keyRowKeyRange returns the range of all rows of key in the table, the meta row included
+func keyRowKeyRange(db int64, key []byte, tableName string) []*table.RangePair {
+	startRowKey := []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(keyColumnName, key),
+	}
+	endRowKey := []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(keyColumnName, key),
+	}
+	for _, column := range tableRowKeyColumns[tableName] {
+		startRowKey = append(startRowKey, table.NewColumn(column, table.Min))
+		endRowKey = append(endRowKey, table.NewColumn(column, table.Max))
+	}
+	return []*table.RangePair{table.NewRangePair(startRowKey, endRowKey)}
+}
+
+// dbRowKeyRange returns the range of the rows of db in the table, only the rows of the keys
+// greater than after are in the range if after is not nil
+func dbRowKeyRange(db int64, tableName string, after []byte) ([]*table.RangePair, error) {
+	columns, ok := tableRowKeyColumns[tableName]
+	if !ok {
+		return nil, errors.New("table not exists: " + tableName)
+	}
+	// the rows of after are skipped by starting right after its last row
+	var startKey, startRest interface{} = table.Min, table.Min
+	if after != nil {
+		startKey, startRest = after, table.Max
+	}
+	startRowKey := []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(keyColumnName, startKey),
+	}
+	endRowKey := []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(keyColumnName, table.Max),
+	}
+	for _, column := range columns {
+		startRowKey = append(startRowKey, table.NewColumn(column, startRest))
+		endRowKey = append(endRowKey, table.NewColumn(column, table.Max))
+	}
+	return []*table.RangePair{table.NewRangePair(startRowKey, endRowKey, after == nil, true)}, nil
+}
+
+// GetTableInfo counts the keys of db in the table across all partitions, and the keys with ttl among them.
+// The counts are done by the server: a string is a row, and a key of the other types is counted by its meta
+// row, whose is_data is false. The list and stream meta rows carry the expire column of the key, while the
+// ttl of a hash, set or sorted set is kept in its data rows, so only the rows with ttl are read to count them
+func (s *Storage) GetTableInfo(ctx context.Context, db int64, tableName string) (*storage.TableInfo, error) {
+	keyRanges, err := dbRowKeyRange(db, tableName, nil)
+	if err != nil {
+		return nil, err
+	}
+	hasExpire := filter.CompareVal(filter.IsNotNull, expireColumnName, nil)
+	if tableName == stringTableName {
+		keys, err := s.countRows(ctx, tableName, keyRanges, nil)
+		if err != nil {
+			return nil, err
+		}
+		expires, err := s.countRows(ctx, tableName, keyRanges, hasExpire)
+		if err != nil {
+			return nil, err
+		}
+		return &storage.TableInfo{Keys: keys, Expires: expires}, nil
+	}
+
+	isMeta := filter.CompareVal(filter.Equal, isDataColumnName, false)
+	keys, err := s.countRows(ctx, tableName, keyRanges, isMeta)
+	if err != nil {
+		return nil, err
+	}
+	if keys == 0 {
+		// no meta row is kept for the keys in this table, count them by reading the rows
+		if keys, err = s.countKeys(ctx, tableName, keyRanges, nil); err != nil {
+			return nil, err
+		}
+	}
+	var expires int64
+	if tableName == listTableName || tableName == streamTableName {
+		expires, err = s.countRows(ctx, tableName, keyRanges, filter.AndList(isMeta, hasExpire))
+	} else {
+		expires, err = s.countKeys(ctx, tableName, keyRanges, hasExpire)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &storage.TableInfo{Keys: keys, Expires: expires}, nil
 }
 
-func (s *Storage) GetTableInfo(ctx context.Context, db int64, tableName string) (*TableInfo, error) {
-	// TODO: with multi partitions
-	tableInfo := &TableInfo{Keys: 0, Expires: 0}
-	// // 1. count keys
-	// // Prepare key range
-	// startRowKey := []*table.Column{
-	// 	table.NewColumn(dbColumnName, db),
-	// 	table.NewColumn(keyColumnName, table.Min),
-	// }
-	// endRowKey := []*table.Column{
-	// 	table.NewColumn(dbColumnName, db),
-	// 	table.NewColumn(keyColumnName, table.Max),
-	// }
-	// keyRanges := []*table.RangePair{table.NewRangePair(startRowKey, endRowKey)}
-
-	// // Create aggregation executor
-	// aggExecutor := s.cli.NewAggExecutor(tableName, keyRanges).Count()
-
-	// // Execute
-	// resSet, err := aggExecutor.ExecuteWithPartition(ctx)
-	// if err != nil {
-	// 	return nil, err
-	// }
-	// res, err := resSet.NextBatch()
-	// for ; res != nil && err == nil; res, err = resSet.NextBatch() {
-	// 	for _, c := range res {
-	// 		tableInfo.Keys += c.Value("count(*)").(int64)
-	// 	}
-	// }
-
-	// // 2. count expires
-	// aggOptExecutor := s.cli.NewAggExecutor(
-	// 	tableName,
-	// 	keyRanges,
-	// 	option.WithQueryFilter(filter.CompareVal(filter.IsNotNull, ttlColumnName, nil)),
-	// ).Count()
-	// resSet, err = aggOptExecutor.ExecuteWithPartition(ctx)
-	// if err != nil {
-	// 	return nil, err
-	// }
-	// cnt := 0
-	// res, err = resSet.NextBatch()
-	// for ; res != nil && err == nil; res, err = resSet.NextBatch() {
-	// 	for _, c := range res {
-	// 		tableInfo.Keys += c.Value("count(*)").(int64)
-	// 		cnt++
-	// 	}
-	// }
-	return tableInfo, nil
+// countRows counts the rows in keyRanges matching f by the server, all the rows if f is nil
+func (s *Storage) countRows(ctx context.Context, tableName string, keyRanges []*table.RangePair,
+	f filter.ObTableFilter) (int64, error) {
+	var opts []option.ObQueryOption
+	if f != nil {
+		opts = append(opts, option.WithQueryFilter(f))
+	}
+	res, err := s.cli.NewAggExecutor(tableName, keyRanges, opts...).Count().Execute(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.Value("count(*)").(int64), nil
+}
+
+// countKeys counts the keys of the rows in keyRanges matching f by reading them, all the rows if f is nil.
+// The rows of a key are adjacent since they are in the same partition, so a key is counted at its first row
+func (s *Storage) countKeys(ctx context.Context, tableName string, keyRanges []*table.RangePair,
+	f filter.ObTableFilter) (int64, error) {
+	opts := []option.ObQueryOption{option.WithQuerySelectColumns([]string{keyColumnName})}
+	if f != nil {
+		opts = append(opts, option.WithQueryFilter(f))
+	}
+	resSet, err := s.cli.Query(ctx, tableName, keyRanges, opts...)
+	if err != nil {
+		return 0, err
+	}
+	defer resSet.Close()
+
+	var keys int64
+	var lastKey []byte
+	res, err := resSet.Next()
+	for ; res != nil && err == nil; res, err = resSet.Next() {
+		key := res.Value(keyColumnName).([]byte)
+		if lastKey != nil && bytes.Equal(key, lastKey) {
+			continue
+		}
+		lastKey = key
+		keys++
+	}
+	if err != nil {
+		return 0, err
+	}
+	return keys, nil
+}
+
+// FlushTable deletes all rows of db in the table batch by batch, returns the number of rows deleted
+func (s *Storage) FlushTable(ctx context.Context, db int64, tableName string) (int64, error) {
+	keyRanges, err := dbRowKeyRange(db, tableName, nil)
+	if err != nil {
+		return 0, err
+	}
+	// the consumer groups of the streams go along with them
+	if tableName == streamTableName {
+		groupRanges, err := dbRowKeyRange(db, streamGroupTableName, nil)
+		if err != nil {
+			return 0, err
+		}
+		if _, err = s.deleteRows(ctx, streamGroupTableName, groupRanges); err != nil {
+			return 0, err
+		}
+	}
+	return s.deleteRows(ctx, tableName, keyRanges)
+}
+
+// deleteRows deletes all rows of the table in the ranges batch by batch, returns the number of rows deleted
+func (s *Storage) deleteRows(ctx context.Context, tableName string, keyRanges []*table.RangePair, opts ...option.ObQueryOption) (int64, error) {
+	columns := append([]string{dbColumnName, keyColumnName}, tableRowKeyColumns[tableName]...)
+	var deleted int64
+	for {
+		// 1. Get the row keys of a batch, the limit applies to each partition
+		resSet, err := s.cli.Query(ctx, tableName, keyRanges,
+			append([]option.ObQueryOption{option.WithQuerySelectColumns(columns), option.WithQueryLimit(rowBatchSize)}, opts...)...)
+		if err != nil {
+			return deleted, err
+		}
+		batchExecutor := s.cli.NewBatchExecutor(tableName)
+		rows := 0
+		res, err := resSet.Next()
+		for ; res != nil && err == nil; res, err = resSet.Next() {
+			rowKey := make([]*table.Column, 0, len(columns))
+			for _, column := range columns {
+				rowKey = append(rowKey, table.NewColumn(column, res.Value(column)))
+			}
+			if err = batchExecutor.AddDeleteOp(rowKey); err != nil {
+				break
+			}
+			rows++
+		}
+		resSet.Close()
+		if err != nil {
+			return deleted, err
+		}
+		if rows == 0 {
+			return deleted, nil
+		}
+
+		// 2. Delete the batch
+		if _, err = batchExecutor.Execute(ctx); err != nil {
+			return deleted, err
+		}
+		deleted += int64(rows)
+	}
+}
+
+// rowID tells apart the rows of a key by the values of the row key columns following db and rkey
+func rowID(values []interface{}) string {
+	return fmt.Sprintf("%v", values)
+}
+
+// queryRowKeys returns the values of the row key columns following db and rkey of all rows of key,
+// the meta row included, by their row ids
+func (s *Storage) queryRowKeys(ctx context.Context, tableName string, db int64, key []byte) (map[string][]interface{}, error) {
+	columns := tableRowKeyColumns[tableName]
+	rows := make(map[string][]interface{})
+	if len(columns) == 0 {
+		return rows, nil
+	}
+	resSet, err := s.cli.Query(ctx, tableName, keyRowKeyRange(db, key, tableName), option.WithQuerySelectColumns(columns))
+	if err != nil {
+		return nil, err
+	}
+	defer resSet.Close()
+
+	res, err := resSet.Next()
+	for ; res != nil && err == nil; res, err = resSet.Next() {
+		values := make([]interface{}, 0, len(columns))
+		for _, column := range columns {
+			values = append(values, res.Value(column))
+		}
+		rows[rowID(values)] = values
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// deleteRowKeys deletes the rows of key batch by batch, rows is the values of the row key columns
+// following db and rkey
+func (s *Storage) deleteRowKeys(ctx context.Context, tableName string, db int64, key []byte, rows map[string][]interface{}) error {
+	columns := tableRowKeyColumns[tableName]
+	batchExecutor := s.cli.NewBatchExecutor(tableName)
+	n := 0
+	for _, values := range rows {
+		rowKey := []*table.Column{
+			table.NewColumn(dbColumnName, db),
+			table.NewColumn(keyColumnName, key),
+		}
+		for i, column := range columns {
+			rowKey = append(rowKey, table.NewColumn(column, values[i]))
+		}
+		if err := batchExecutor.AddDeleteOp(rowKey); err != nil {
+			return err
+		}
+		n++
+		if n == rowBatchSize {
+			if _, err := batchExecutor.Execute(ctx); err != nil {
+				return err
+			}
+			batchExecutor = s.cli.NewBatchExecutor(tableName)
+			n = 0
+		}
+	}
+	if n != 0 {
+		_, err := batchExecutor.Execute(ctx)
+		return err
+	}
+	return nil
+}
+
+// copyRows copies all rows of src in srcDB to dst in dstDB, the meta row and the expire column included.
+// The rows of dst are overwritten, the ids of the rows written are removed from stale if it is not nil
+func (s *Storage) copyRows(ctx context.Context, tableName string, srcDB int64, src []byte, dstDB int64, dst []byte,
+	stale map[string][]interface{}) error {
+	rowKeyColumns := tableRowKeyColumns[tableName]
+	valueColumns := tableValueColumns[tableName]
+	columns := make([]string, 0, len(rowKeyColumns)+len(valueColumns))
+	columns = append(append(columns, rowKeyColumns...), valueColumns...)
+
+	resSet, err := s.cli.Query(
+		ctx,
+		tableName,
+		keyRowKeyRange(srcDB, src, tableName),
+		option.WithQuerySelectColumns(columns),
+	)
+	if err != nil {
+		return err
+	}
+	defer resSet.Close()
+
+	batchExecutor := s.cli.NewBatchExecutor(tableName)
+	rows := 0
+	res, err := resSet.Next()
+	for ; res != nil && err == nil; res, err = resSet.Next() {
+		rowKey := []*table.Column{
+			table.NewColumn(dbColumnName, dstDB),
+			table.NewColumn(keyColumnName, dst),
+		}
+		values := make([]interface{}, 0, len(rowKeyColumns))
+		for _, column := range rowKeyColumns {
+			rowKey = append(rowKey, table.NewColumn(column, res.Value(column)))
+			values = append(values, res.Value(column))
+		}
+		if stale != nil {
+			delete(stale, rowID(values))
+		}
+		mutates := make([]*table.Column, 0, len(valueColumns))
+		for _, column := range valueColumns {
+			value := res.Value(column)
+			// timestamps are read as time.Time but written as table.TimeStamp
+			if t, ok := value.(time.Time); ok {
+				value = table.TimeStamp(t)
+			}
+			mutates = append(mutates, table.NewColumn(column, value))
+		}
+		if err = batchExecutor.AddInsertOrUpdateOp(rowKey, mutates); err != nil {
+			return err
+		}
+		rows++
+		if rows == rowBatchSize {
+			if _, err = batchExecutor.Execute(ctx); err != nil {
+				return err
+			}
+			batchExecutor = s.cli.NewBatchExecutor(tableName)
+			rows = 0
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if rows != 0 {
+		_, err = batchExecutor.Execute(ctx)
+	}
+	return err
 }
@@ -45,8 +45,76 @@ const (
 	memberColumnName = "member"
 )
 
+// setCmd executes a set command on the observer side, the command is routed by the first key
+func (s *Storage) setCmd(ctx context.Context, db int64, cmd string, keys [][]byte, args ...[]byte) (interface{}, error) {
+	rowKey := []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(keyColumnName, keys[0]),
+	}
+	cmdArgs := make([][]byte, 0, 1+len(keys)+len(args))
+	cmdArgs = append(cmdArgs, []byte(cmd))
+	cmdArgs = append(cmdArgs, keys...)
+	cmdArgs = append(cmdArgs, args...)
+	return s.redisCmd(ctx, setTableName, rowKey, cmdArgs...)
+}
+
+// SAdd adds the members to the set, returns the number of members added
+func (s *Storage) SAdd(ctx context.Context, db int64, key []byte, members [][]byte) (int64, error) {
+	if err := s.checkType(ctx, db, key, setTableName); err != nil {
+		return 0, err
+	}
+	return replyInteger(s.setCmd(ctx, db, "sadd", [][]byte{key}, members...))
+}
+
+// SUnion returns the members of the union of all the sets
+func (s *Storage) SUnion(ctx context.Context, db int64, keys [][]byte) ([][]byte, error) {
+	return replyArray(s.setCmd(ctx, db, "sunion", keys))
+}
+
+// SInter returns the members of the intersection of all the sets
+func (s *Storage) SInter(ctx context.Context, db int64, keys [][]byte) ([][]byte, error) {
+	return replyArray(s.setCmd(ctx, db, "sinter", keys))
+}
+
+// SDiff returns the members of the difference between the first set and all the successive sets
+func (s *Storage) SDiff(ctx context.Context, db int64, keys [][]byte) ([][]byte, error) {
+	return replyArray(s.setCmd(ctx, db, "sdiff", keys))
+}
+
+// SUnionStore stores the union of the sets in dst, returns the number of members in dst
+func (s *Storage) SUnionStore(ctx context.Context, db int64, dst []byte, keys [][]byte) (int64, error) {
+	return s.setStore(ctx, db, "sunionstore", dst, keys)
+}
+
+// SInterStore stores the intersection of the sets in dst, returns the number of members in dst
+func (s *Storage) SInterStore(ctx context.Context, db int64, dst []byte, keys [][]byte) (int64, error) {
+	return s.setStore(ctx, db, "sinterstore", dst, keys)
+}
+
+// SDiffStore stores the difference of the sets in dst, returns the number of members in dst
+func (s *Storage) SDiffStore(ctx context.Context, db int64, dst []byte, keys [][]byte) (int64, error) {
+	return s.setStore(ctx, db, "sdiffstore", dst, keys)
+}
+
+// setStore executes a set store command, dst is overwritten whatever type it holds
+func (s *Storage) setStore(ctx context.Context, db int64, cmd string, dst []byte, keys [][]byte) (int64, error) {
+	if err := s.deleteOtherTypes(ctx, db, [][]byte{dst}, setTableName); err != nil {
+		return 0, err
+	}
+	return replyInteger(s.setCmd(ctx, db, cmd, append([][]byte{dst}, keys...)))
+}
+
 // SCard get the size of the key
 func (s *Storage) SCard(ctx context.Context, db int64, key []byte) (int64, error) {
+	num, err := s.setCard(ctx, db, key)
+	if err != nil || num != 0 {
+		return num, err
+	}
+	return 0, s.checkType(ctx, db, key, setTableName)
+}
+
+// setCard returns the number of members of the set without checking the type of key
+func (s *Storage) setCard(ctx context.Context, db int64, key []byte) (int64, error) {
 	tableName := setTableName
 
 	// Prepare key range
@@ -142,12 +210,21 @@ func (s *Storage) SIsmember(ctx context.Context, db int64, key []byte, member []
 	if res.Value(memberColumnName) != nil {
 		return 1, nil
 	} else {
-		return 0, nil
+		return 0, s.checkType(ctx, db, key, setTableName)
 	}
 }
 
 // SMembers get all member
 func (s *Storage) SMembers(ctx context.Context, db int64, key []byte) ([][]byte, error) {
+	members, err := s.setMembers(ctx, db, key)
+	if err != nil || len(members) != 0 {
+		return members, err
+	}
+	return members, s.checkType(ctx, db, key, setTableName)
+}
+
+// setMembers returns the members of the set without checking the type of key
+func (s *Storage) setMembers(ctx context.Context, db int64, key []byte) ([][]byte, error) {
 	tableName := setTableName
 
 	// Prepare key range
@@ -195,6 +272,10 @@ func (s *Storage) SMembers(ctx context.Context, db int64, key []byte) ([][]byte,
 
 // Smove move member from src key to dest key
 func (s *Storage) Smove(ctx context.Context, db int64, src []byte, dst []byte, member []byte) (int, error) {
+	if err := s.checkType(ctx, db, dst, setTableName); err != nil {
+		return 0, err
+	}
+
 	tableName := setTableName
 
 	// 1. Delete from src key
@@ -305,7 +386,7 @@ func (s *Storage) SRandMember(ctx context.Context, db int64, key []byte, count i
 	}
 	defer resSet.Close()
 
-	cnt, err := s.SCard(ctx, db, key)
+	cnt, err := s.setCard(ctx, db, key)
 	if err != nil {
 		return nil, err
 	}
@@ -329,18 +410,17 @@ func (s *Storage) SRandMember(ctx context.Context, db int64, key []byte, count i
 		}
 	}
 
-	if err != nil {
-		return nil, err
+	if err != nil || len(members) != 0 {
+		return members, err
 	}
-
-	return members, nil
+	return members, s.checkType(ctx, db, key, setTableName)
 }
 
 // setExists check the number of keys that exist in set table
 func (s *Storage) setExists(ctx context.Context, db int64, keys [][]byte) (int64, error) {
 	var existNum int64
 	for _, key := range keys {
-		num, err := s.SCard(ctx, db, key)
+		num, err := s.setCard(ctx, db, key)
 		if err != nil {
 			return 0, err
 		}
@@ -358,7 +438,7 @@ func (s *Storage) deleteSet(ctx context.Context, db int64, keys [][]byte) (int64
 	var deleteNum int64
 	for _, key := range keys {
 		// Get members by key
-		members, err := s.SMembers(ctx, db, key)
+		members, err := s.setMembers(ctx, db, key)
 		if err != nil {
 			return 0, err
 		}
@@ -374,13 +454,30 @@ func (s *Storage) deleteSet(ctx context.Context, db int64, keys [][]byte) (int64
 	return deleteNum, nil
 }
 
+// SScan returns at most count members greater than after in ascending order
+func (s *Storage) SScan(ctx context.Context, db int64, key []byte, after []byte, count int64) ([][]byte, error) {
+	rows, err := s.scanKeyRows(ctx, setTableName, db, key, memberColumnName, after, count,
+		[]string{memberColumnName})
+	if err != nil {
+		return nil, err
+	}
+	res := make([][]byte, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, row.Value(memberColumnName).([]byte))
+	}
+	if len(res) != 0 {
+		return res, nil
+	}
+	return res, s.checkType(ctx, db, key, setTableName)
+}
+
 // expireSet expire set table
 func (s *Storage) expireSet(ctx context.Context, db int64, key []byte, expire_ts table.TimeStamp) (int, error) {
 	tableName := setTableName
 	var res = 0
 
 	// 1. Get all members
-	members, err := s.SMembers(ctx, db, key)
+	members, err := s.setMembers(ctx, db, key)
 	if err != nil {
 		return 0, err
 	}
@@ -419,7 +516,7 @@ func (s *Storage) persistSet(ctx context.Context, db int64, key []byte) (int, er
 	var res = 0
 
 	// 1. Get all members
-	members, err := s.SMembers(ctx, db, key)
+	members, err := s.setMembers(ctx, db, key)
 	if err != nil {
 		return 0, err
 	}
@@ -458,7 +555,7 @@ func (s *Storage) ttlSet(ctx context.Context, db int64, key []byte) (time.Durati
 	batchExecutor := s.cli.NewBatchExecutor(tableName)
 
 	// 1. Get all members
-	members, err := s.SMembers(ctx, db, key)
+	members, err := s.setMembers(ctx, db, key)
 	if err != nil {
 		return 0, err
 	}
@@ -19,8 +19,13 @@ package obkv
 import (
 	"github.com/oceanbase/obkv-table-client-go/client"
 	"github.com/oceanbase/obkv-table-client-go/protocol"
+
+	"github.com/oceanbase/modis/config"
+	"github.com/oceanbase/modis/storage"
 )
 
+const BackendName = "obkv"
+
 const (
 	dbColumnName     = "db"
 	keyColumnName    = "rkey"
@@ -28,8 +33,15 @@ const (
 	expireColumnName = "expire_ts"
 	indexColumnName  = "index"
 	isDataColumnName = "is_data"
+	insertColumnName = "insert_ts"
 )
 
+func init() {
+	storage.Register(BackendName, func(cfg *config.StorageConfig) (storage.Storage, error) {
+		return NewStorage(NewConfig(&cfg.ObkvConfig)), nil
+	})
+}
+
 type Storage struct {
 	cli client.Client
 	cfg *Config
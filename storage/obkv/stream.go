@@ -0,0 +1,1564 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package obkv
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oceanbase/obkv-table-client-go/client"
+	"github.com/oceanbase/obkv-table-client-go/client/filter"
+	"github.com/oceanbase/obkv-table-client-go/client/option"
+	"github.com/oceanbase/obkv-table-client-go/table"
+
+	"github.com/oceanbase/modis/log"
+	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/oceanbase/modis/storage"
+)
+
+/*
+stream model:
+CREATE TABLE modis_stream_table(
+  db bigint not null,
+  rkey varbinary(1024) not null,
+  is_data tinyint(1) not null,
+  id_ms bigint unsigned not null,
+  id_seq bigint unsigned not null,
+  expire_ts timestamp(6) default null,
+  value varbinary(65535) default null,
+  last_ms bigint unsigned default null,
+  last_seq bigint unsigned default null,
+  entries_added bigint default null,
+  max_deleted_ms bigint unsigned default null,
+  max_deleted_seq bigint unsigned default null,
+  pending_ms bigint unsigned default null,
+  pending_seq bigint unsigned default null,
+  PRIMARY KEY(db, rkey, is_data, id_ms, id_seq))
+  TTL(expire_ts + INTERVAL 0 SECOND)
+  PARTITION BY KEY(db, rkey) PARTITIONS 3;
+
+A stream exists as long as its meta row (is_data = 0, id 0-0) exists, the last_*, max_deleted_* and
+pending_* columns are set in the meta row only. An entry is a data row keyed by its id, whose value is
+the field value pairs encoded as a resp array. A data row with a null value is a tombstone written at
+an id reserved by an XADD which never wrote its entry, see XAdd, the readers skip it.
+
+consumer group model:
+CREATE TABLE modis_stream_group_table(
+  db bigint not null,
+  rkey varbinary(1024) not null,
+  group_name varbinary(1024) not null,
+  row_type bigint not null,
+  consumer varbinary(1024) not null,
+  id_ms bigint unsigned not null,
+  id_seq bigint unsigned not null,
+  expire_ts timestamp(6) default null,
+  last_ms bigint unsigned default null,
+  last_seq bigint unsigned default null,
+  entries_read bigint default null,
+  seen_time bigint default null,
+  active_time bigint default null,
+  owner varbinary(1024) default null,
+  delivery_time bigint default null,
+  delivery_count bigint default null,
+  PRIMARY KEY(db, rkey, group_name, row_type, consumer, id_ms, id_seq))
+  TTL(expire_ts + INTERVAL 0 SECOND)
+  PARTITION BY KEY(db, rkey) PARTITIONS 3;
+
+A group has a group row, a consumer row for each consumer and a pending row for each entry
+delivered but not acknowledged, see the stream*Row constants. The times are in milliseconds.
+The rows of a stream in both tables carry the same expire_ts.
+*/
+
+const (
+	streamTableName      = "modis_stream_table"
+	streamGroupTableName = "modis_stream_group_table"
+
+	idMsColumnName          = "id_ms"
+	idSeqColumnName         = "id_seq"
+	lastMsColumnName        = "last_ms"
+	lastSeqColumnName       = "last_seq"
+	entriesAddedColumnName  = "entries_added"
+	maxDeletedMsColumnName  = "max_deleted_ms"
+	maxDeletedSeqColumnName = "max_deleted_seq"
+	pendingMsColumnName     = "pending_ms"
+	pendingSeqColumnName    = "pending_seq"
+
+	groupColumnName         = "group_name"
+	rowTypeColumnName       = "row_type"
+	consumerColumnName      = "consumer"
+	entriesReadColumnName   = "entries_read"
+	seenTimeColumnName      = "seen_time"
+	activeTimeColumnName    = "active_time"
+	ownerColumnName         = "owner"
+	deliveryTimeColumnName  = "delivery_time"
+	deliveryCountColumnName = "delivery_count"
+)
+
+// the row types of the consumer group table
+const (
+	streamGroupRow    int64 = 0
+	streamConsumerRow int64 = 1
+	streamPendingRow  int64 = 2
+)
+
+// autoClaimAttemptsFactor limits the pending entries XAUTOCLAIM examines to count times of it, like redis
+const autoClaimAttemptsFactor = 10
+
+// streamMetaRow is the meta row of a stream
+type streamMetaRow struct {
+	storage.StreamMeta
+	// the expire column, copied to every row written to the stream
+	expire interface{}
+	// the id reserved by an XADD whose entry may not be written yet, nil if none
+	pending *storage.StreamID
+}
+
+// committedID returns the greatest id whose entry is written for good, the entries above it are skipped
+// by the readers. It is the last id unless the entry of the last id is pending
+func (m *streamMetaRow) committedID() storage.StreamID {
+	if m.pending == nil {
+		return m.LastID
+	}
+	id, _ := m.pending.Prev()
+	return id
+}
+
+// streamGroupRowValue is the group row of a consumer group
+type streamGroupRowValue struct {
+	lastID      storage.StreamID
+	entriesRead int64
+}
+
+// streamPendingRowValue is a pending row of a consumer group
+type streamPendingRowValue struct {
+	id            storage.StreamID
+	owner         []byte
+	deliveryTime  time.Time
+	deliveryCount int64
+}
+
+func streamRowKey(db int64, key []byte, isData bool, id storage.StreamID) []*table.Column {
+	return []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(keyColumnName, key),
+		table.NewColumn(isDataColumnName, isData),
+		table.NewColumn(idMsColumnName, id.Ms),
+		table.NewColumn(idSeqColumnName, id.Seq),
+	}
+}
+
+// streamEntryRange returns the range of the entries in [start, end]
+func streamEntryRange(db int64, key []byte, start storage.StreamID, end storage.StreamID) []*table.RangePair {
+	return []*table.RangePair{table.NewRangePair(streamRowKey(db, key, true, start), streamRowKey(db, key, true, end))}
+}
+
+func streamGroupRowKey(db int64, key []byte, group []byte, rowType int64, consumer []byte, id storage.StreamID) []*table.Column {
+	return []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(keyColumnName, key),
+		table.NewColumn(groupColumnName, group),
+		table.NewColumn(rowTypeColumnName, rowType),
+		table.NewColumn(consumerColumnName, consumer),
+		table.NewColumn(idMsColumnName, id.Ms),
+		table.NewColumn(idSeqColumnName, id.Seq),
+	}
+}
+
+// streamGroupRowsRange returns the range of the rows of the given type of a group, all rows of the group if rowType < 0
+func streamGroupRowsRange(db int64, key []byte, group []byte, rowType int64) []*table.RangePair {
+	var startType, endType interface{} = rowType, rowType
+	if rowType < 0 {
+		startType, endType = table.Min, table.Max
+	}
+	startRowKey := []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(keyColumnName, key),
+		table.NewColumn(groupColumnName, group),
+		table.NewColumn(rowTypeColumnName, startType),
+		table.NewColumn(consumerColumnName, table.Min),
+		table.NewColumn(idMsColumnName, table.Min),
+		table.NewColumn(idSeqColumnName, table.Min),
+	}
+	endRowKey := []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(keyColumnName, key),
+		table.NewColumn(groupColumnName, group),
+		table.NewColumn(rowTypeColumnName, endType),
+		table.NewColumn(consumerColumnName, table.Max),
+		table.NewColumn(idMsColumnName, table.Max),
+		table.NewColumn(idSeqColumnName, table.Max),
+	}
+	return []*table.RangePair{table.NewRangePair(startRowKey, endRowKey)}
+}
+
+// streamPendingRange returns the range of the pending rows of a group in [start, end]
+func streamPendingRange(db int64, key []byte, group []byte, start storage.StreamID, end storage.StreamID) []*table.RangePair {
+	return []*table.RangePair{table.NewRangePair(
+		streamGroupRowKey(db, key, group, streamPendingRow, []byte{}, start),
+		streamGroupRowKey(db, key, group, streamPendingRow, []byte{}, end),
+	)}
+}
+
+func uint64Value(v interface{}) uint64 {
+	switch val := v.(type) {
+	case uint64:
+		return val
+	case int64:
+		return uint64(val)
+	}
+	return 0
+}
+
+func int64Value(v interface{}) int64 {
+	switch val := v.(type) {
+	case int64:
+		return val
+	case uint64:
+		return int64(val)
+	}
+	return 0
+}
+
+// msTime converts a time column in milliseconds, zero time if it is null
+func msTime(v interface{}) time.Time {
+	if v == nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(int64Value(v))
+}
+
+// expireValue converts the expire column read to the value written
+func expireValue(v interface{}) interface{} {
+	if t, ok := v.(time.Time); ok {
+		return table.TimeStamp(t)
+	}
+	return nil
+}
+
+func isDuplicateKey(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "errCode:-5024")
+}
+
+func idFilter(msColumn string, seqColumn string, id storage.StreamID) filter.ObTableFilter {
+	return filter.AndList(
+		filter.CompareVal(filter.Equal, msColumn, id.Ms),
+		filter.CompareVal(filter.Equal, seqColumn, id.Seq),
+	)
+}
+
+// getStreamMeta returns the meta row of the stream, nil if the stream not exists.
+// The length and the first id are counted only if full is set
+func (s *Storage) getStreamMeta(ctx context.Context, db int64, key []byte, full bool) (*streamMetaRow, error) {
+	res, err := s.cli.Get(ctx, streamTableName, streamRowKey(db, key, false, storage.StreamID{}), []string{
+		expireColumnName, lastMsColumnName, lastSeqColumnName, entriesAddedColumnName,
+		maxDeletedMsColumnName, maxDeletedSeqColumnName, pendingMsColumnName, pendingSeqColumnName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.IsEmptySet() {
+		return nil, nil
+	}
+	meta := &streamMetaRow{
+		StreamMeta: storage.StreamMeta{
+			LastID: storage.StreamID{
+				Ms:  uint64Value(res.Value(lastMsColumnName)),
+				Seq: uint64Value(res.Value(lastSeqColumnName)),
+			},
+			MaxDeletedID: storage.StreamID{
+				Ms:  uint64Value(res.Value(maxDeletedMsColumnName)),
+				Seq: uint64Value(res.Value(maxDeletedSeqColumnName)),
+			},
+			EntriesAdded: int64Value(res.Value(entriesAddedColumnName)),
+		},
+		expire: expireValue(res.Value(expireColumnName)),
+	}
+	if res.Value(pendingMsColumnName) != nil {
+		meta.pending = &storage.StreamID{
+			Ms:  uint64Value(res.Value(pendingMsColumnName)),
+			Seq: uint64Value(res.Value(pendingSeqColumnName)),
+		}
+	}
+	if !full {
+		return meta, nil
+	}
+	if meta.Length, err = s.streamLen(ctx, db, key); err != nil {
+		return nil, err
+	}
+	first, err := s.queryStreamEntries(ctx, db, key, storage.StreamID{}, meta.committedID(), 1, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(first) != 0 {
+		meta.FirstID = first[0].ID
+	}
+	return meta, nil
+}
+
+// getStreamMetaOrType is getStreamMeta for the commands reading the stream,
+// storage.ErrWrongType is returned if the key holds another type
+func (s *Storage) getStreamMetaOrType(ctx context.Context, db int64, key []byte, full bool) (*streamMetaRow, error) {
+	meta, err := s.getStreamMeta(ctx, db, key, full)
+	if err != nil || meta != nil {
+		return meta, err
+	}
+	return nil, s.checkType(ctx, db, key, streamTableName)
+}
+
+// createStream inserts the meta row of an empty stream, returns false if another one inserted it meanwhile.
+// lastID is reserved by XADD as the pending id if pending is set
+func (s *Storage) createStream(ctx context.Context, db int64, key []byte, lastID storage.StreamID, entriesAdded int64,
+	pending bool) (bool, error) {
+	if err := s.checkType(ctx, db, key, streamTableName); err != nil {
+		return false, err
+	}
+	columns := []*table.Column{
+		table.NewColumn(lastMsColumnName, lastID.Ms),
+		table.NewColumn(lastSeqColumnName, lastID.Seq),
+		table.NewColumn(entriesAddedColumnName, entriesAdded),
+		table.NewColumn(maxDeletedMsColumnName, uint64(0)),
+		table.NewColumn(maxDeletedSeqColumnName, uint64(0)),
+	}
+	if pending {
+		columns = append(columns,
+			table.NewColumn(pendingMsColumnName, lastID.Ms),
+			table.NewColumn(pendingSeqColumnName, lastID.Seq),
+		)
+	}
+	_, err := s.cli.Insert(ctx, streamTableName, streamRowKey(db, key, false, storage.StreamID{}), columns)
+	if isDuplicateKey(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// streamEntryFilter skips the tombstone rows
+var streamEntryFilter = filter.CompareVal(filter.IsNotNull, valueColumnName, nil)
+
+func (s *Storage) streamLen(ctx context.Context, db int64, key []byte) (int64, error) {
+	res, err := s.cli.NewAggExecutor(streamTableName, streamEntryRange(db, key, storage.StreamID{}, storage.MaxStreamID),
+		option.WithQueryFilter(streamEntryFilter)).Count().Execute(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.Value("count(*)").(int64), nil
+}
+
+// queryStreamEntries returns at most count entries in [start, end], from end if reverse is set
+func (s *Storage) queryStreamEntries(ctx context.Context, db int64, key []byte, start storage.StreamID, end storage.StreamID,
+	count int64, reverse bool) ([]storage.StreamEntry, error) {
+	if start.Compare(end) > 0 {
+		return []storage.StreamEntry{}, nil
+	}
+	scanOrder := table.Forward
+	if reverse {
+		scanOrder = table.Reverse
+	}
+	opts := []option.ObQueryOption{
+		option.WithQuerySelectColumns([]string{idMsColumnName, idSeqColumnName, valueColumnName}),
+		option.WithQueryScanOrder(scanOrder),
+		option.WithQueryFilter(streamEntryFilter),
+	}
+	if count > 0 {
+		opts = append(opts, option.WithQueryLimit(int(count)))
+	}
+	resSet, err := s.cli.Query(ctx, streamTableName, streamEntryRange(db, key, start, end), opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer resSet.Close()
+
+	entries := []storage.StreamEntry{}
+	res, err := resSet.Next()
+	for ; res != nil && err == nil; res, err = resSet.Next() {
+		entry, err := decodeStreamEntry(res)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func decodeStreamEntry(res client.QueryResult) (storage.StreamEntry, error) {
+	fields, err := resp.DecArray(res.Value(valueColumnName).([]byte))
+	if err != nil {
+		return storage.StreamEntry{}, err
+	}
+	return storage.StreamEntry{
+		ID:     storage.StreamID{Ms: uint64Value(res.Value(idMsColumnName)), Seq: uint64Value(res.Value(idSeqColumnName))},
+		Fields: fields,
+	}, nil
+}
+
+// getStreamEntry returns the entry id, nil if it not exists
+func (s *Storage) getStreamEntry(ctx context.Context, db int64, key []byte, id storage.StreamID) (*storage.StreamEntry, error) {
+	entries, err := s.queryStreamEntries(ctx, db, key, id, id, 1, false)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// deleteStreamEntries deletes the entries batch by batch, returns the number of entries deleted
+func (s *Storage) deleteStreamEntries(ctx context.Context, db int64, key []byte, ids []storage.StreamID) (int64, error) {
+	var deleted int64
+	for len(ids) != 0 {
+		n := len(ids)
+		if n > rowBatchSize {
+			n = rowBatchSize
+		}
+		batchExecutor := s.cli.NewBatchExecutor(streamTableName)
+		for _, id := range ids[:n] {
+			if err := batchExecutor.AddDeleteOp(streamRowKey(db, key, true, id)); err != nil {
+				return deleted, err
+			}
+		}
+		res, err := batchExecutor.Execute(ctx)
+		if err != nil {
+			return deleted, err
+		}
+		for _, singleRes := range res.GetResults() {
+			deleted += singleRes.AffectedRows()
+		}
+		ids = ids[n:]
+	}
+	return deleted, nil
+}
+
+// trimStream evicts the oldest entries following trim, returns the number of entries evicted
+func (s *Storage) trimStream(ctx context.Context, db int64, key []byte, trim *storage.StreamTrim) (int64, error) {
+	end, count := storage.MaxStreamID, trim.Limit
+	if trim.ByMinID {
+		var ok bool
+		if end, ok = trim.MinID.Prev(); !ok {
+			return 0, nil
+		}
+	} else {
+		length, err := s.streamLen(ctx, db, key)
+		if err != nil {
+			return 0, err
+		}
+		if length <= trim.MaxLen {
+			return 0, nil
+		}
+		if count <= 0 || count > length-trim.MaxLen {
+			count = length - trim.MaxLen
+		}
+	}
+	entries, err := s.queryStreamEntries(ctx, db, key, storage.StreamID{}, end, count, false)
+	if err != nil {
+		return 0, err
+	}
+	ids := make([]storage.StreamID, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	return s.deleteStreamEntries(ctx, db, key, ids)
+}
+
+// streamResolveAttempts is the number of times XADD finds the same id pending before it gives the id up
+const streamResolveAttempts = 100
+
+// XAdd appends an entry to the stream, creating the stream if needed unless args.NoMkStream is set.
+// The id is reserved first by advancing the last id of the meta row by compare and set, which records
+// it as the pending id, and the entry row is written then. An id is reserved only once the entry of the
+// pending id is written, so that the ids are generated monotonically even if several modis instances
+// add to the stream at the same time, every entry below the pending id is written for good, and the
+// readers never skip an entry added concurrently by reading up to the committed id only.
+// If the entry of the pending id is not written after streamResolveAttempts tries, e.g. the XADD is gone,
+// a tombstone row is written at the id in its place, the XADD fails to write the entry then and reserves
+// another id, so that the row of a reserved id is written once and never deleted by XADD
+func (s *Storage) XAdd(ctx context.Context, db int64, key []byte, args *storage.StreamAddArgs, fields [][]byte) (*storage.StreamID, int64, error) {
+	var id, waiting storage.StreamID
+	attempts := 0
+	for {
+		meta, err := s.getStreamMeta(ctx, db, key, false)
+		if err != nil {
+			return nil, 0, err
+		}
+		var last storage.StreamID
+		var expire interface{}
+		if meta == nil {
+			if args.NoMkStream {
+				return nil, 0, s.checkType(ctx, db, key, streamTableName)
+			}
+		} else {
+			last, expire = meta.LastID, meta.expire
+		}
+
+		// 1. Wait for the entry of the pending id
+		if meta != nil && meta.pending != nil {
+			if *meta.pending != waiting {
+				waiting, attempts = *meta.pending, 0
+			}
+			attempts++
+			if err = s.resolveStreamPending(ctx, db, key, meta, attempts >= streamResolveAttempts); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+
+		// 2. Reserve the id
+		if id, err = args.NextID(last, time.Now()); err != nil {
+			return nil, 0, err
+		}
+		var reserved bool
+		if meta == nil {
+			reserved, err = s.createStream(ctx, db, key, id, 1, true)
+		} else {
+			reserved, err = s.reserveStreamID(ctx, db, key, meta, id)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if !reserved {
+			continue
+		}
+
+		// 3. Write the entry
+		_, err = s.cli.Insert(ctx, streamTableName, streamRowKey(db, key, true, id), []*table.Column{
+			table.NewColumn(valueColumnName, []byte(resp.EncArray(fields))),
+			table.NewColumn(expireColumnName, expire),
+		})
+		if isDuplicateKey(err) {
+			// given up by another XADD, see resolveStreamPending
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// 4. Clear the pending id, it is cleared by the next XADD otherwise
+		if err = s.clearStreamPending(ctx, db, key, id); err != nil {
+			log.Warn("storage", nil, "fail to clear the pending id of the stream", log.Errors(err),
+				log.String("key", string(key)), log.String("id", id.String()))
+		}
+		break
+	}
+
+	var trimmed int64
+	if args.Trim != nil {
+		var err error
+		if trimmed, err = s.trimStream(ctx, db, key, args.Trim); err != nil {
+			return nil, 0, err
+		}
+	}
+	return &id, trimmed, nil
+}
+
+// reserveStreamID sets the last id and the pending id of the meta row to id if the meta row is still meta
+// and has no pending id, returns false if it has been changed
+func (s *Storage) reserveStreamID(ctx context.Context, db int64, key []byte, meta *streamMetaRow, id storage.StreamID) (bool, error) {
+	affectedRows, err := s.cli.Update(ctx, streamTableName, streamRowKey(db, key, false, storage.StreamID{}),
+		[]*table.Column{
+			table.NewColumn(lastMsColumnName, id.Ms),
+			table.NewColumn(lastSeqColumnName, id.Seq),
+			table.NewColumn(entriesAddedColumnName, meta.EntriesAdded+1),
+			table.NewColumn(pendingMsColumnName, id.Ms),
+			table.NewColumn(pendingSeqColumnName, id.Seq),
+		},
+		option.WithFilter(filter.AndList(
+			idFilter(lastMsColumnName, lastSeqColumnName, meta.LastID),
+			filter.CompareVal(filter.Equal, entriesAddedColumnName, meta.EntriesAdded),
+			filter.CompareVal(filter.IsNull, pendingMsColumnName, nil),
+		)),
+	)
+	return affectedRows != 0, err
+}
+
+// resolveStreamPending clears the pending id of the meta row once the row of the id is written. If the row
+// is not written yet, it waits a moment unless giveUp is set, in which case a tombstone row is written at
+// the id, so that the XADD reserving it fails to write its entry, and the pending id is cleared
+func (s *Storage) resolveStreamPending(ctx context.Context, db int64, key []byte, meta *streamMetaRow, giveUp bool) error {
+	id := *meta.pending
+	res, err := s.cli.Get(ctx, streamTableName, streamRowKey(db, key, true, id), []string{idMsColumnName})
+	if err != nil {
+		return err
+	}
+	if res.IsEmptySet() {
+		if !giveUp {
+			time.Sleep(time.Millisecond)
+			return nil
+		}
+		_, err = s.cli.Insert(ctx, streamTableName, streamRowKey(db, key, true, id), []*table.Column{
+			table.NewColumn(valueColumnName, nil),
+			table.NewColumn(expireColumnName, meta.expire),
+		})
+		if err != nil && !isDuplicateKey(err) {
+			return err
+		}
+		if err == nil {
+			log.Warn("storage", nil, "give up the stream id reserved by an XADD not writing its entry",
+				log.String("key", string(key)), log.String("id", id.String()))
+		}
+	}
+	return s.clearStreamPending(ctx, db, key, id)
+}
+
+// clearStreamPending clears the pending id of the meta row if it is still id
+func (s *Storage) clearStreamPending(ctx context.Context, db int64, key []byte, id storage.StreamID) error {
+	_, err := s.cli.Update(ctx, streamTableName, streamRowKey(db, key, false, storage.StreamID{}),
+		[]*table.Column{
+			table.NewColumn(pendingMsColumnName, nil),
+			table.NewColumn(pendingSeqColumnName, nil),
+		},
+		option.WithFilter(idFilter(pendingMsColumnName, pendingSeqColumnName, id)),
+	)
+	return err
+}
+
+// XLen returns the number of entries of the stream
+func (s *Storage) XLen(ctx context.Context, db int64, key []byte) (int64, error) {
+	length, err := s.streamLen(ctx, db, key)
+	if err != nil || length != 0 {
+		return length, err
+	}
+	return 0, s.checkType(ctx, db, key, streamTableName)
+}
+
+// XRange returns at most count entries in [start, end], from end if reverse is set, the entry of the
+// pending id is not returned until its XADD is done
+func (s *Storage) XRange(ctx context.Context, db int64, key []byte, start storage.StreamID, end storage.StreamID,
+	count int64, reverse bool) ([]storage.StreamEntry, error) {
+	meta, err := s.getStreamMetaOrType(ctx, db, key, false)
+	if err != nil || meta == nil {
+		return []storage.StreamEntry{}, err
+	}
+	if committed := meta.committedID(); end.Compare(committed) > 0 {
+		end = committed
+	}
+	return s.queryStreamEntries(ctx, db, key, start, end, count, reverse)
+}
+
+// XDel deletes the entries, returns the number of entries deleted
+func (s *Storage) XDel(ctx context.Context, db int64, key []byte, ids []storage.StreamID) (int64, error) {
+	var maxDeleted storage.StreamID
+	var deleted int64
+	for _, id := range ids {
+		affectedRows, err := s.cli.Delete(ctx, streamTableName, streamRowKey(db, key, true, id))
+		if err != nil {
+			return deleted, err
+		}
+		if affectedRows == 0 {
+			continue
+		}
+		deleted++
+		if id.Compare(maxDeleted) > 0 {
+			maxDeleted = id
+		}
+	}
+	if deleted == 0 {
+		return 0, s.checkType(ctx, db, key, streamTableName)
+	}
+
+	// raise the max deleted id of the meta row by compare and set
+	for {
+		meta, err := s.getStreamMeta(ctx, db, key, false)
+		if err != nil || meta == nil || maxDeleted.Compare(meta.MaxDeletedID) <= 0 {
+			return deleted, err
+		}
+		affectedRows, err := s.cli.Update(ctx, streamTableName, streamRowKey(db, key, false, storage.StreamID{}),
+			[]*table.Column{
+				table.NewColumn(maxDeletedMsColumnName, maxDeleted.Ms),
+				table.NewColumn(maxDeletedSeqColumnName, maxDeleted.Seq),
+			},
+			option.WithFilter(idFilter(maxDeletedMsColumnName, maxDeletedSeqColumnName, meta.MaxDeletedID)),
+		)
+		if err != nil || affectedRows != 0 {
+			return deleted, err
+		}
+	}
+}
+
+// XTrim evicts the oldest entries of the stream, returns the number of entries evicted
+func (s *Storage) XTrim(ctx context.Context, db int64, key []byte, trim *storage.StreamTrim) (int64, error) {
+	meta, err := s.getStreamMetaOrType(ctx, db, key, false)
+	if err != nil || meta == nil {
+		return 0, err
+	}
+	return s.trimStream(ctx, db, key, trim)
+}
+
+// XInfoStream returns the state of the stream, nil if the stream not exists
+func (s *Storage) XInfoStream(ctx context.Context, db int64, key []byte) (*storage.StreamInfo, error) {
+	meta, err := s.getStreamMetaOrType(ctx, db, key, true)
+	if err != nil || meta == nil {
+		return nil, err
+	}
+	info := &storage.StreamInfo{StreamMeta: meta.StreamMeta}
+	groups, err := s.queryStreamGroups(ctx, db, key)
+	if err != nil {
+		return nil, err
+	}
+	info.Groups = int64(len(groups))
+	if meta.Length != 0 {
+		first, err := s.queryStreamEntries(ctx, db, key, storage.StreamID{}, meta.committedID(), 1, false)
+		if err != nil {
+			return nil, err
+		}
+		last, err := s.queryStreamEntries(ctx, db, key, storage.StreamID{}, meta.committedID(), 1, true)
+		if err != nil {
+			return nil, err
+		}
+		if len(first) != 0 && len(last) != 0 {
+			info.FirstEntry, info.LastEntry = &first[0], &last[0]
+		}
+	}
+	return info, nil
+}
+
+// queryStreamGroups returns the group rows of the stream by name
+func (s *Storage) queryStreamGroups(ctx context.Context, db int64, key []byte) (map[string]streamGroupRowValue, error) {
+	resSet, err := s.cli.Query(
+		ctx,
+		streamGroupTableName,
+		keyRowKeyRange(db, key, streamGroupTableName),
+		option.WithQuerySelectColumns([]string{groupColumnName, lastMsColumnName, lastSeqColumnName, entriesReadColumnName}),
+		option.WithQueryFilter(filter.CompareVal(filter.Equal, rowTypeColumnName, streamGroupRow)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer resSet.Close()
+
+	groups := make(map[string]streamGroupRowValue)
+	res, err := resSet.Next()
+	for ; res != nil && err == nil; res, err = resSet.Next() {
+		groups[string(res.Value(groupColumnName).([]byte))] = decodeStreamGroupRow(res)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func decodeStreamGroupRow(res interface{ Value(string) interface{} }) streamGroupRowValue {
+	return streamGroupRowValue{
+		lastID:      storage.StreamID{Ms: uint64Value(res.Value(lastMsColumnName)), Seq: uint64Value(res.Value(lastSeqColumnName))},
+		entriesRead: int64Value(res.Value(entriesReadColumnName)),
+	}
+}
+
+// getStreamGroup returns the meta row of the stream and the group row, storage.ErrNoGroup if either not exists
+func (s *Storage) getStreamGroup(ctx context.Context, db int64, key []byte, group []byte, full bool) (*streamMetaRow, *streamGroupRowValue, error) {
+	meta, err := s.getStreamMetaOrType(ctx, db, key, full)
+	if err != nil {
+		return nil, nil, err
+	}
+	if meta == nil {
+		return nil, nil, storage.ErrNoGroup
+	}
+	res, err := s.cli.Get(ctx, streamGroupTableName, streamGroupRowKey(db, key, group, streamGroupRow, []byte{}, storage.StreamID{}),
+		[]string{lastMsColumnName, lastSeqColumnName, entriesReadColumnName})
+	if err != nil {
+		return nil, nil, err
+	}
+	if res.IsEmptySet() {
+		return nil, nil, storage.ErrNoGroup
+	}
+	g := decodeStreamGroupRow(res)
+	return meta, &g, nil
+}
+
+// getStreamGroupOfKey is getStreamGroup for the commands requiring the stream,
+// storage.ErrNoSuchKey is returned if the stream not exists
+func (s *Storage) getStreamGroupOfKey(ctx context.Context, db int64, key []byte, group []byte) (*streamMetaRow, *streamGroupRowValue, error) {
+	meta, err := s.getStreamMetaOrType(ctx, db, key, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if meta == nil {
+		return nil, nil, storage.ErrNoSuchKey
+	}
+	return s.getStreamGroup(ctx, db, key, group, false)
+}
+
+// XInfoGroups returns the consumer groups of the stream in ascending order of the names
+func (s *Storage) XInfoGroups(ctx context.Context, db int64, key []byte) ([]storage.StreamGroupInfo, error) {
+	meta, err := s.getStreamMetaOrType(ctx, db, key, true)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		return nil, storage.ErrNoSuchKey
+	}
+	groups, err := s.queryStreamGroups(ctx, db, key)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	infos := make([]storage.StreamGroupInfo, 0, len(names))
+	for _, name := range names {
+		g := groups[name]
+		consumers, pending, err := s.countStreamGroupRows(ctx, db, key, []byte(name))
+		if err != nil {
+			return nil, err
+		}
+		lag, lagKnown := meta.Lag(g.lastID, g.entriesRead)
+		infos = append(infos, storage.StreamGroupInfo{
+			Name:            []byte(name),
+			Consumers:       consumers,
+			Pending:         pending,
+			LastDeliveredID: g.lastID,
+			EntriesRead:     g.entriesRead,
+			Lag:             lag,
+			LagKnown:        lagKnown,
+		})
+	}
+	return infos, nil
+}
+
+// countStreamGroupRows returns the number of consumers and pending entries of the group
+func (s *Storage) countStreamGroupRows(ctx context.Context, db int64, key []byte, group []byte) (int64, int64, error) {
+	var counts [2]int64
+	for i, rowType := range []int64{streamConsumerRow, streamPendingRow} {
+		res, err := s.cli.NewAggExecutor(streamGroupTableName, streamGroupRowsRange(db, key, group, rowType)).Count().Execute(ctx)
+		if err != nil {
+			return 0, 0, err
+		}
+		counts[i] = res.Value("count(*)").(int64)
+	}
+	return counts[0], counts[1], nil
+}
+
+// queryStreamPending returns the pending rows of the group in [start, end] in ascending order of the ids,
+// those of consumer only if it is not nil. At most count rows are returned if count > 0
+func (s *Storage) queryStreamPending(ctx context.Context, db int64, key []byte, group []byte, start storage.StreamID,
+	end storage.StreamID, consumer []byte, count int64) ([]streamPendingRowValue, error) {
+	opts := []option.ObQueryOption{
+		option.WithQuerySelectColumns([]string{idMsColumnName, idSeqColumnName, ownerColumnName, deliveryTimeColumnName, deliveryCountColumnName}),
+		option.WithQueryScanOrder(table.Forward),
+	}
+	if consumer != nil {
+		opts = append(opts, option.WithQueryFilter(filter.CompareVal(filter.Equal, ownerColumnName, consumer)))
+	}
+	if count > 0 {
+		opts = append(opts, option.WithQueryLimit(int(count)))
+	}
+	resSet, err := s.cli.Query(ctx, streamGroupTableName, streamPendingRange(db, key, group, start, end), opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer resSet.Close()
+
+	var rows []streamPendingRowValue
+	res, err := resSet.Next()
+	for ; res != nil && err == nil; res, err = resSet.Next() {
+		rows = append(rows, streamPendingRowValue{
+			id:            storage.StreamID{Ms: uint64Value(res.Value(idMsColumnName)), Seq: uint64Value(res.Value(idSeqColumnName))},
+			owner:         res.Value(ownerColumnName).([]byte),
+			deliveryTime:  msTime(res.Value(deliveryTimeColumnName)),
+			deliveryCount: int64Value(res.Value(deliveryCountColumnName)),
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// XInfoConsumers returns the consumers of the group in ascending order of the names
+func (s *Storage) XInfoConsumers(ctx context.Context, db int64, key []byte, group []byte) ([]storage.StreamConsumerInfo, error) {
+	if _, _, err := s.getStreamGroupOfKey(ctx, db, key, group); err != nil {
+		return nil, err
+	}
+	resSet, err := s.cli.Query(
+		ctx,
+		streamGroupTableName,
+		streamGroupRowsRange(db, key, group, streamConsumerRow),
+		option.WithQuerySelectColumns([]string{consumerColumnName, seenTimeColumnName, activeTimeColumnName}),
+		option.WithQueryScanOrder(table.Forward),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer resSet.Close()
+
+	consumers := []storage.StreamConsumerInfo{}
+	res, err := resSet.Next()
+	for ; res != nil && err == nil; res, err = resSet.Next() {
+		consumers = append(consumers, storage.StreamConsumerInfo{
+			Name:       res.Value(consumerColumnName).([]byte),
+			SeenTime:   msTime(res.Value(seenTimeColumnName)),
+			ActiveTime: msTime(res.Value(activeTimeColumnName)),
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := s.queryStreamPending(ctx, db, key, group, storage.StreamID{}, storage.MaxStreamID, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64)
+	for _, row := range pending {
+		counts[string(row.owner)]++
+	}
+	for i := range consumers {
+		consumers[i].Pending = counts[string(consumers[i].Name)]
+	}
+	return consumers, nil
+}
+
+// XGroupCreate creates a consumer group of the stream
+func (s *Storage) XGroupCreate(ctx context.Context, db int64, key []byte, group []byte, id *storage.StreamID,
+	entriesRead int64, mkStream bool) error {
+	meta, err := s.getStreamMetaOrType(ctx, db, key, false)
+	if err != nil {
+		return err
+	}
+	if meta == nil {
+		if !mkStream {
+			return storage.ErrNoSuchKey
+		}
+		if _, err = s.createStream(ctx, db, key, storage.StreamID{}, 0, false); err != nil {
+			return err
+		}
+		if meta, err = s.getStreamMeta(ctx, db, key, false); err != nil {
+			return err
+		}
+		if meta == nil {
+			return storage.ErrNoSuchKey
+		}
+	}
+	lastID := meta.LastID
+	if id != nil {
+		lastID = *id
+	}
+	_, err = s.cli.Insert(ctx, streamGroupTableName, streamGroupRowKey(db, key, group, streamGroupRow, []byte{}, storage.StreamID{}),
+		[]*table.Column{
+			table.NewColumn(lastMsColumnName, lastID.Ms),
+			table.NewColumn(lastSeqColumnName, lastID.Seq),
+			table.NewColumn(entriesReadColumnName, entriesRead),
+			table.NewColumn(expireColumnName, meta.expire),
+		})
+	if isDuplicateKey(err) {
+		return storage.ErrBusyGroup
+	}
+	return err
+}
+
+// XGroupSetID sets the last delivered id of the consumer group
+func (s *Storage) XGroupSetID(ctx context.Context, db int64, key []byte, group []byte, id *storage.StreamID, entriesRead int64) error {
+	meta, _, err := s.getStreamGroupOfKey(ctx, db, key, group)
+	if err != nil {
+		return err
+	}
+	lastID := meta.LastID
+	if id != nil {
+		lastID = *id
+	}
+	_, err = s.cli.Update(ctx, streamGroupTableName, streamGroupRowKey(db, key, group, streamGroupRow, []byte{}, storage.StreamID{}),
+		[]*table.Column{
+			table.NewColumn(lastMsColumnName, lastID.Ms),
+			table.NewColumn(lastSeqColumnName, lastID.Seq),
+			table.NewColumn(entriesReadColumnName, entriesRead),
+		})
+	return err
+}
+
+// XGroupDestroy destroys the consumer group along with its pending entries and consumers
+func (s *Storage) XGroupDestroy(ctx context.Context, db int64, key []byte, group []byte) (bool, error) {
+	_, _, err := s.getStreamGroupOfKey(ctx, db, key, group)
+	if err == storage.ErrNoGroup {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if _, err = s.deleteRows(ctx, streamGroupTableName, streamGroupRowsRange(db, key, group, -1)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// XGroupCreateConsumer creates a consumer in the group, returns false if it exists
+func (s *Storage) XGroupCreateConsumer(ctx context.Context, db int64, key []byte, group []byte, consumer []byte) (bool, error) {
+	meta, _, err := s.getStreamGroupOfKey(ctx, db, key, group)
+	if err != nil {
+		return false, err
+	}
+	_, err = s.cli.Insert(ctx, streamGroupTableName, streamGroupRowKey(db, key, group, streamConsumerRow, consumer, storage.StreamID{}),
+		[]*table.Column{
+			table.NewColumn(seenTimeColumnName, time.Now().UnixMilli()),
+			table.NewColumn(expireColumnName, meta.expire),
+		})
+	if isDuplicateKey(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// XGroupDelConsumer deletes the consumer along with its pending entries
+func (s *Storage) XGroupDelConsumer(ctx context.Context, db int64, key []byte, group []byte, consumer []byte) (int64, bool, error) {
+	if _, _, err := s.getStreamGroupOfKey(ctx, db, key, group); err != nil {
+		return 0, false, err
+	}
+	affectedRows, err := s.cli.Delete(ctx, streamGroupTableName, streamGroupRowKey(db, key, group, streamConsumerRow, consumer, storage.StreamID{}))
+	if err != nil || affectedRows == 0 {
+		return 0, false, err
+	}
+	pending, err := s.deleteRows(ctx, streamGroupTableName, streamPendingRange(db, key, group, storage.StreamID{}, storage.MaxStreamID),
+		option.WithQueryFilter(filter.CompareVal(filter.Equal, ownerColumnName, consumer)))
+	if err != nil {
+		return 0, false, err
+	}
+	return pending, true, nil
+}
+
+// touchStreamConsumer upserts the consumer row, setting its seen time and its active time if active is set
+func (s *Storage) touchStreamConsumer(ctx context.Context, db int64, key []byte, group []byte, consumer []byte,
+	expire interface{}, now time.Time, active bool) error {
+	mutates := []*table.Column{
+		table.NewColumn(seenTimeColumnName, now.UnixMilli()),
+		table.NewColumn(expireColumnName, expire),
+	}
+	if active {
+		mutates = append(mutates, table.NewColumn(activeTimeColumnName, now.UnixMilli()))
+	}
+	_, err := s.cli.InsertOrUpdate(ctx, streamGroupTableName, streamGroupRowKey(db, key, group, streamConsumerRow, consumer, storage.StreamID{}), mutates)
+	return err
+}
+
+// XReadGroup delivers the new entries to the consumer, or returns its pending entries after the given id.
+// The last delivered id of the group is advanced by compare and set, so that an entry is delivered
+// once even if several modis instances serve the group at the same time
+func (s *Storage) XReadGroup(ctx context.Context, db int64, key []byte, group []byte, consumer []byte, after *storage.StreamID,
+	count int64, noAck bool) ([]storage.StreamEntry, error) {
+	if after != nil {
+		return s.readStreamHistory(ctx, db, key, group, consumer, *after, count)
+	}
+
+	for {
+		meta, g, err := s.getStreamGroup(ctx, db, key, group, true)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		var entries []storage.StreamEntry
+		if start, ok := g.lastID.Next(); ok {
+			if entries, err = s.queryStreamEntries(ctx, db, key, start, meta.committedID(), count, false); err != nil {
+				return nil, err
+			}
+		}
+		if len(entries) == 0 {
+			return []storage.StreamEntry{}, s.touchStreamConsumer(ctx, db, key, group, consumer, meta.expire, now, false)
+		}
+
+		entriesRead := g.entriesRead
+		for _, e := range entries {
+			entriesRead = meta.AdvanceEntriesRead(entriesRead, e.ID)
+		}
+		lastID := entries[len(entries)-1].ID
+		affectedRows, err := s.cli.Update(ctx, streamGroupTableName, streamGroupRowKey(db, key, group, streamGroupRow, []byte{}, storage.StreamID{}),
+			[]*table.Column{
+				table.NewColumn(lastMsColumnName, lastID.Ms),
+				table.NewColumn(lastSeqColumnName, lastID.Seq),
+				table.NewColumn(entriesReadColumnName, entriesRead),
+			},
+			option.WithFilter(idFilter(lastMsColumnName, lastSeqColumnName, g.lastID)),
+		)
+		if err != nil {
+			return nil, err
+		}
+		if affectedRows == 0 {
+			// delivered by another instance meanwhile
+			continue
+		}
+
+		if !noAck {
+			batchExecutor := s.cli.NewBatchExecutor(streamGroupTableName)
+			for _, e := range entries {
+				err = batchExecutor.AddInsertOrUpdateOp(streamGroupRowKey(db, key, group, streamPendingRow, []byte{}, e.ID),
+					[]*table.Column{
+						table.NewColumn(ownerColumnName, consumer),
+						table.NewColumn(deliveryTimeColumnName, now.UnixMilli()),
+						table.NewColumn(deliveryCountColumnName, int64(1)),
+						table.NewColumn(expireColumnName, meta.expire),
+					})
+				if err != nil {
+					return nil, err
+				}
+			}
+			if _, err = batchExecutor.Execute(ctx); err != nil {
+				return nil, err
+			}
+		}
+		return entries, s.touchStreamConsumer(ctx, db, key, group, consumer, meta.expire, now, true)
+	}
+}
+
+// readStreamHistory returns the pending entries of the consumer after the given id,
+// the entries deleted from the stream are returned without fields
+func (s *Storage) readStreamHistory(ctx context.Context, db int64, key []byte, group []byte, consumer []byte,
+	after storage.StreamID, count int64) ([]storage.StreamEntry, error) {
+	meta, _, err := s.getStreamGroup(ctx, db, key, group, false)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	entries := []storage.StreamEntry{}
+	if start, ok := after.Next(); ok {
+		rows, err := s.queryStreamPending(ctx, db, key, group, start, storage.MaxStreamID, consumer, count)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			entry, err := s.getStreamEntry(ctx, db, key, row.id)
+			if err != nil {
+				return nil, err
+			}
+			if entry == nil {
+				entries = append(entries, storage.StreamEntry{ID: row.id})
+				continue
+			}
+			_, err = s.cli.Update(ctx, streamGroupTableName, streamGroupRowKey(db, key, group, streamPendingRow, []byte{}, row.id),
+				[]*table.Column{
+					table.NewColumn(deliveryTimeColumnName, now.UnixMilli()),
+					table.NewColumn(deliveryCountColumnName, row.deliveryCount+1),
+				})
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, *entry)
+		}
+	}
+	return entries, s.touchStreamConsumer(ctx, db, key, group, consumer, meta.expire, now, false)
+}
+
+// XAck acknowledges the pending entries of the group, returns the number of entries acknowledged
+func (s *Storage) XAck(ctx context.Context, db int64, key []byte, group []byte, ids []storage.StreamID) (int64, error) {
+	_, _, err := s.getStreamGroup(ctx, db, key, group, false)
+	if err == storage.ErrNoGroup {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	batchExecutor := s.cli.NewBatchExecutor(streamGroupTableName)
+	for _, id := range ids {
+		if err = batchExecutor.AddDeleteOp(streamGroupRowKey(db, key, group, streamPendingRow, []byte{}, id)); err != nil {
+			return 0, err
+		}
+	}
+	res, err := batchExecutor.Execute(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var acked int64
+	for _, singleRes := range res.GetResults() {
+		acked += singleRes.AffectedRows()
+	}
+	return acked, nil
+}
+
+// XPending summarizes the pending entries of the group
+func (s *Storage) XPending(ctx context.Context, db int64, key []byte, group []byte) (*storage.StreamPendingSummary, error) {
+	if _, _, err := s.getStreamGroup(ctx, db, key, group, false); err != nil {
+		return nil, err
+	}
+	rows, err := s.queryStreamPending(ctx, db, key, group, storage.StreamID{}, storage.MaxStreamID, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	summary := &storage.StreamPendingSummary{Count: int64(len(rows))}
+	if len(rows) == 0 {
+		return summary, nil
+	}
+	summary.MinID, summary.MaxID = rows[0].id, rows[len(rows)-1].id
+	counts := make(map[string]int64)
+	for _, row := range rows {
+		counts[string(row.owner)]++
+	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		summary.Consumers = append(summary.Consumers, storage.StreamConsumerPending{Name: []byte(name), Count: counts[name]})
+	}
+	return summary, nil
+}
+
+// XPendingRange returns the pending entries of the group selected by args
+func (s *Storage) XPendingRange(ctx context.Context, db int64, key []byte, group []byte,
+	args *storage.StreamPendingArgs) ([]storage.StreamPendingEntry, error) {
+	if _, _, err := s.getStreamGroup(ctx, db, key, group, false); err != nil {
+		return nil, err
+	}
+	// the idle time is checked here, so the rows can not be limited by the query if it is set
+	limit := args.Count
+	if args.MinIdle > 0 {
+		limit = 0
+	}
+	rows, err := s.queryStreamPending(ctx, db, key, group, args.Start, args.End, args.Consumer, limit)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	entries := []storage.StreamPendingEntry{}
+	for _, row := range rows {
+		if args.Count > 0 && int64(len(entries)) == args.Count {
+			break
+		}
+		if now.Sub(row.deliveryTime) < args.MinIdle {
+			continue
+		}
+		entries = append(entries, storage.StreamPendingEntry{
+			ID:           row.id,
+			Consumer:     row.owner,
+			DeliveryTime: row.deliveryTime,
+			Deliveries:   row.deliveryCount,
+		})
+	}
+	return entries, nil
+}
+
+// getStreamPending returns the pending row of the entry id, nil if it not exists
+func (s *Storage) getStreamPending(ctx context.Context, db int64, key []byte, group []byte, id storage.StreamID) (*streamPendingRowValue, error) {
+	res, err := s.cli.Get(ctx, streamGroupTableName, streamGroupRowKey(db, key, group, streamPendingRow, []byte{}, id),
+		[]string{ownerColumnName, deliveryTimeColumnName, deliveryCountColumnName})
+	if err != nil || res.IsEmptySet() {
+		return nil, err
+	}
+	return &streamPendingRowValue{
+		id:            id,
+		owner:         res.Value(ownerColumnName).([]byte),
+		deliveryTime:  msTime(res.Value(deliveryTimeColumnName)),
+		deliveryCount: int64Value(res.Value(deliveryCountColumnName)),
+	}, nil
+}
+
+// claimStreamPending transfers the pending row to consumer by compare and set, so that an entry is claimed
+// once even if several modis instances claim it at the same time. A nil row is inserted as a new pending entry
+func (s *Storage) claimStreamPending(ctx context.Context, db int64, key []byte, group []byte, row *streamPendingRowValue,
+	id storage.StreamID, consumer []byte, expire interface{}, deliveryTime time.Time, deliveryCount int64) (bool, error) {
+	rowKey := streamGroupRowKey(db, key, group, streamPendingRow, []byte{}, id)
+	mutates := []*table.Column{
+		table.NewColumn(ownerColumnName, consumer),
+		table.NewColumn(deliveryTimeColumnName, deliveryTime.UnixMilli()),
+		table.NewColumn(deliveryCountColumnName, deliveryCount),
+	}
+	if row == nil {
+		_, err := s.cli.Insert(ctx, streamGroupTableName, rowKey, append(mutates, table.NewColumn(expireColumnName, expire)))
+		if isDuplicateKey(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	affectedRows, err := s.cli.Update(ctx, streamGroupTableName, rowKey, mutates,
+		option.WithFilter(filter.AndList(
+			filter.CompareVal(filter.Equal, ownerColumnName, row.owner),
+			filter.CompareVal(filter.Equal, deliveryTimeColumnName, row.deliveryTime.UnixMilli()),
+			filter.CompareVal(filter.Equal, deliveryCountColumnName, row.deliveryCount),
+		)),
+	)
+	return affectedRows != 0, err
+}
+
+// XClaim transfers the pending entries to the consumer
+func (s *Storage) XClaim(ctx context.Context, db int64, key []byte, group []byte, consumer []byte, ids []storage.StreamID,
+	args *storage.StreamClaimArgs) ([]storage.StreamEntry, error) {
+	meta, _, err := s.getStreamGroup(ctx, db, key, group, false)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !args.LastID.IsZero() {
+		// raise the last delivered id only
+		_, err = s.cli.Update(ctx, streamGroupTableName, streamGroupRowKey(db, key, group, streamGroupRow, []byte{}, storage.StreamID{}),
+			[]*table.Column{
+				table.NewColumn(lastMsColumnName, args.LastID.Ms),
+				table.NewColumn(lastSeqColumnName, args.LastID.Seq),
+			},
+			option.WithFilter(filter.OrList(
+				filter.CompareVal(filter.LessThan, lastMsColumnName, args.LastID.Ms),
+				filter.AndList(
+					filter.CompareVal(filter.Equal, lastMsColumnName, args.LastID.Ms),
+					filter.CompareVal(filter.LessThan, lastSeqColumnName, args.LastID.Seq),
+				),
+			)),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	entries := []storage.StreamEntry{}
+	for _, id := range ids {
+		row, err := s.getStreamPending(ctx, db, key, group, id)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := s.getStreamEntry(ctx, db, key, id)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			// the entry is deleted from the stream
+			if row != nil {
+				if _, err = s.cli.Delete(ctx, streamGroupTableName, streamGroupRowKey(db, key, group, streamPendingRow, []byte{}, id)); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		var deliveryCount int64 = 1
+		if row == nil {
+			if !args.Force {
+				continue
+			}
+		} else {
+			if args.MinIdle > 0 && now.Sub(row.deliveryTime) < args.MinIdle {
+				continue
+			}
+			deliveryCount = row.deliveryCount
+		}
+		if args.RetryCount >= 0 {
+			deliveryCount = args.RetryCount
+		} else if !args.JustID {
+			deliveryCount++
+		}
+		ok, err := s.claimStreamPending(ctx, db, key, group, row, id, consumer, meta.expire, args.DeliveryTime, deliveryCount)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if args.JustID {
+			entry.Fields = nil
+		}
+		entries = append(entries, *entry)
+	}
+
+	if len(entries) != 0 {
+		return entries, s.touchStreamConsumer(ctx, db, key, group, consumer, meta.expire, now, true)
+	}
+	// the consumer is not created if nothing is claimed
+	_, err = s.cli.Update(ctx, streamGroupTableName, streamGroupRowKey(db, key, group, streamConsumerRow, consumer, storage.StreamID{}),
+		[]*table.Column{table.NewColumn(seenTimeColumnName, now.UnixMilli())})
+	return entries, err
+}
+
+// XAutoClaim claims the pending entries from start idle for at least minIdle
+func (s *Storage) XAutoClaim(ctx context.Context, db int64, key []byte, group []byte, consumer []byte, start storage.StreamID,
+	minIdle time.Duration, count int64, justID bool) (storage.StreamID, []storage.StreamEntry, []storage.StreamID, error) {
+	meta, _, err := s.getStreamGroup(ctx, db, key, group, false)
+	if err != nil {
+		return storage.StreamID{}, nil, nil, err
+	}
+	now := time.Now()
+	attempts := count * autoClaimAttemptsFactor
+	// one more row tells the id to start the next call from
+	rows, err := s.queryStreamPending(ctx, db, key, group, start, storage.MaxStreamID, nil, attempts+1)
+	if err != nil {
+		return storage.StreamID{}, nil, nil, err
+	}
+
+	entries := []storage.StreamEntry{}
+	deleted := []storage.StreamID{}
+	i := 0
+	for ; i < len(rows) && attempts > 0 && count > 0; i++ {
+		attempts--
+		row := &rows[i]
+		entry, err := s.getStreamEntry(ctx, db, key, row.id)
+		if err != nil {
+			return storage.StreamID{}, nil, nil, err
+		}
+		if entry == nil {
+			if _, err = s.cli.Delete(ctx, streamGroupTableName, streamGroupRowKey(db, key, group, streamPendingRow, []byte{}, row.id)); err != nil {
+				return storage.StreamID{}, nil, nil, err
+			}
+			deleted = append(deleted, row.id)
+			count--
+			continue
+		}
+		if minIdle > 0 && now.Sub(row.deliveryTime) < minIdle {
+			continue
+		}
+		deliveryCount := row.deliveryCount
+		if !justID {
+			deliveryCount++
+		}
+		ok, err := s.claimStreamPending(ctx, db, key, group, row, row.id, consumer, meta.expire, now, deliveryCount)
+		if err != nil {
+			return storage.StreamID{}, nil, nil, err
+		}
+		if !ok {
+			continue
+		}
+		if justID {
+			entry.Fields = nil
+		}
+		entries = append(entries, *entry)
+		count--
+	}
+	var next storage.StreamID
+	if i < len(rows) {
+		next = rows[i].id
+	}
+
+	if len(entries) != 0 {
+		err = s.touchStreamConsumer(ctx, db, key, group, consumer, meta.expire, now, true)
+	} else {
+		_, err = s.cli.Update(ctx, streamGroupTableName, streamGroupRowKey(db, key, group, streamConsumerRow, consumer, storage.StreamID{}),
+			[]*table.Column{table.NewColumn(seenTimeColumnName, now.UnixMilli())})
+	}
+	if err != nil {
+		return storage.StreamID{}, nil, nil, err
+	}
+	return next, entries, deleted, nil
+}
+
+// streamExists check the number of keys that exist in stream table
+func (s *Storage) streamExists(ctx context.Context, db int64, keys [][]byte) (int64, error) {
+	var existNum int64
+	for _, key := range keys {
+		meta, err := s.getStreamMeta(ctx, db, key, false)
+		if err != nil {
+			return 0, err
+		}
+		if meta != nil {
+			existNum++
+		}
+	}
+	return existNum, nil
+}
+
+// deleteStream deletes the streams along with their consumer groups
+func (s *Storage) deleteStream(ctx context.Context, db int64, keys [][]byte) (int64, error) {
+	var deleteNum int64
+	for _, key := range keys {
+		meta, err := s.getStreamMeta(ctx, db, key, false)
+		if err != nil {
+			return 0, err
+		}
+		if meta == nil {
+			continue
+		}
+		// the meta row goes last, so the stream exists until all its rows are deleted
+		if _, err = s.deleteRows(ctx, streamGroupTableName, keyRowKeyRange(db, key, streamGroupTableName)); err != nil {
+			return 0, err
+		}
+		if _, err = s.deleteRows(ctx, streamTableName, streamEntryRange(db, key, storage.StreamID{}, storage.MaxStreamID)); err != nil {
+			return 0, err
+		}
+		if _, err = s.cli.Delete(ctx, streamTableName, streamRowKey(db, key, false, storage.StreamID{})); err != nil {
+			return 0, err
+		}
+		deleteNum++
+	}
+	return deleteNum, nil
+}
+
+// expireStream expire stream table
+func (s *Storage) expireStream(ctx context.Context, db int64, key []byte, expire_ts table.TimeStamp) (int, error) {
+	return s.updateStreamExpire(ctx, db, key, expire_ts)
+}
+
+// persistStream persist stream table
+func (s *Storage) persistStream(ctx context.Context, db int64, key []byte) (int, error) {
+	return s.updateStreamExpire(ctx, db, key, nil)
+}
+
+// updateStreamExpire updates the expire column of all rows of the stream in both tables, the meta row last
+func (s *Storage) updateStreamExpire(ctx context.Context, db int64, key []byte, expire interface{}) (int, error) {
+	for _, tableName := range []string{streamGroupTableName, streamTableName} {
+		if err := s.updateRowsExpire(ctx, tableName, db, key, expire); err != nil {
+			return 0, err
+		}
+	}
+	affectedRows, err := s.cli.Update(ctx, streamTableName, streamRowKey(db, key, false, storage.StreamID{}),
+		[]*table.Column{table.NewColumn(expireColumnName, expire)})
+	if err != nil || affectedRows == 0 {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// updateRowsExpire updates the expire column of all rows of key in the table batch by batch
+func (s *Storage) updateRowsExpire(ctx context.Context, tableName string, db int64, key []byte, expire interface{}) error {
+	rowKeyColumns := tableRowKeyColumns[tableName]
+	resSet, err := s.cli.Query(
+		ctx,
+		tableName,
+		keyRowKeyRange(db, key, tableName),
+		option.WithQuerySelectColumns(rowKeyColumns),
+	)
+	if err != nil {
+		return err
+	}
+	defer resSet.Close()
+
+	batchExecutor := s.cli.NewBatchExecutor(tableName)
+	rows := 0
+	res, err := resSet.Next()
+	for ; res != nil && err == nil; res, err = resSet.Next() {
+		rowKey := []*table.Column{
+			table.NewColumn(dbColumnName, db),
+			table.NewColumn(keyColumnName, key),
+		}
+		for _, column := range rowKeyColumns {
+			rowKey = append(rowKey, table.NewColumn(column, res.Value(column)))
+		}
+		if err = batchExecutor.AddUpdateOp(rowKey, []*table.Column{table.NewColumn(expireColumnName, expire)}); err != nil {
+			return err
+		}
+		rows++
+		if rows == rowBatchSize {
+			if _, err = batchExecutor.Execute(ctx); err != nil {
+				return err
+			}
+			batchExecutor = s.cli.NewBatchExecutor(tableName)
+			rows = 0
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if rows != 0 {
+		_, err = batchExecutor.Execute(ctx)
+	}
+	return err
+}
+
+// ttlStream get expire time of stream table
+func (s *Storage) ttlStream(ctx context.Context, db int64, key []byte) (time.Duration, error) {
+	res, err := s.cli.Get(ctx, streamTableName, streamRowKey(db, key, false, storage.StreamID{}), []string{expireColumnName})
+	if err != nil {
+		return 0, err
+	}
+	if res.IsEmptySet() {
+		return -2, nil
+	}
+	if res.Value(expireColumnName) == nil {
+		return -1, nil
+	}
+	return time.Until(res.Value(expireColumnName).(time.Time)), nil
+}
@@ -22,9 +22,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/oceanbase/obkv-table-client-go/client/filter"
 	"github.com/oceanbase/obkv-table-client-go/client/option"
 	"github.com/oceanbase/obkv-table-client-go/table"
 
+	"github.com/oceanbase/modis/storage"
 	"github.com/oceanbase/modis/util"
 )
 
@@ -60,11 +62,12 @@ func (s *Storage) Get(ctx context.Context, db int64, key []byte) ([]byte, error)
 		return nil, err
 	}
 
-	// Return value if exists, nil if not exists
+	// Return value if exists, nil if not exists. The other tables are only checked
+	// on a miss, so that reading an existing string costs no more
 	if res.Value(valueColumnName) != nil {
 		return res.Value(valueColumnName).([]byte), nil
 	} else {
-		return nil, nil
+		return nil, s.checkType(ctx, db, key, stringTableName)
 	}
 }
 
@@ -109,6 +112,14 @@ func (s *Storage) MGet(ctx context.Context, db int64, keys [][]byte) ([][]byte,
 // MSet set key pairs in batches. If the key already exists, the old value is overwritten.
 // Returns the number of keys successfully set
 func (s *Storage) MSet(ctx context.Context, db int64, kv map[string][]byte) (int, error) {
+	keys := make([][]byte, 0, len(kv))
+	for key := range kv {
+		keys = append(keys, []byte(key))
+	}
+	if err := s.deleteOtherTypes(ctx, db, keys, stringTableName); err != nil {
+		return -1, err
+	}
+
 	tableName := stringTableName
 	batchExecutor := s.cli.NewBatchExecutor(tableName)
 
@@ -140,6 +151,10 @@ func (s *Storage) MSet(ctx context.Context, db int64, kv map[string][]byte) (int
 
 // PSetEx set the value and expiration time (in milliseconds), update key if the key already exists.
 func (s *Storage) PSetEx(ctx context.Context, db int64, key []byte, expireTime uint64, value []byte) error {
+	if err := s.deleteOtherTypes(ctx, db, [][]byte{key}, stringTableName); err != nil {
+		return err
+	}
+
 	tableName := stringTableName
 
 	// Set rowKey columns
@@ -165,6 +180,10 @@ func (s *Storage) PSetEx(ctx context.Context, db int64, key []byte, expireTime u
 
 // Set the value of the specified key, insert if it does not exist and update if it does.
 func (s *Storage) Set(ctx context.Context, db int64, key []byte, value []byte) error {
+	if err := s.deleteOtherTypes(ctx, db, [][]byte{key}, stringTableName); err != nil {
+		return err
+	}
+
 	tableName := stringTableName
 
 	// Set rowKey columns
@@ -187,8 +206,62 @@ func (s *Storage) Set(ctx context.Context, db int64, key []byte, value []byte) e
 	return nil
 }
 
+// SetKeepTTL sets the value of the specified key like Set, the expire time of an existing key is kept.
+func (s *Storage) SetKeepTTL(ctx context.Context, db int64, key []byte, value []byte) error {
+	if err := s.checkType(ctx, db, key, stringTableName); err != nil {
+		return err
+	}
+
+	tableName := stringTableName
+
+	// Set rowKey columns
+	rowKey := []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(keyColumnName, key),
+	}
+
+	// Set other columns, the expire time is left as it is
+	mutates := []*table.Column{
+		table.NewColumn(valueColumnName, value),
+	}
+
+	// Execute
+	_, err := s.cli.InsertOrUpdate(ctx, tableName, rowKey, mutates)
+	return err
+}
+
+// CompareAndSet sets the value of the string to value if it is still old, or inserts it if old is nil,
+// the expire time is left as it is
+func (s *Storage) CompareAndSet(ctx context.Context, db int64, key []byte, old []byte, value []byte) (bool, error) {
+	if err := s.checkType(ctx, db, key, stringTableName); err != nil {
+		return false, err
+	}
+
+	rowKey := []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(keyColumnName, key),
+	}
+	mutates := []*table.Column{
+		table.NewColumn(valueColumnName, value),
+	}
+	if old == nil {
+		_, err := s.cli.Insert(ctx, stringTableName, rowKey, mutates)
+		if isDuplicateKey(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	affectedRows, err := s.cli.Update(ctx, stringTableName, rowKey, mutates,
+		option.WithFilter(filter.CompareVal(filter.Equal, valueColumnName, old)))
+	return affectedRows != 0, err
+}
+
 // SetEx set the value and expiration time (in second), update key if the key already exists.
 func (s *Storage) SetEx(ctx context.Context, db int64, key []byte, expireTime uint64, value []byte) error {
+	if err := s.deleteOtherTypes(ctx, db, [][]byte{key}, stringTableName); err != nil {
+		return err
+	}
+
 	tableName := stringTableName
 
 	// Set rowKey columns
@@ -214,6 +287,12 @@ func (s *Storage) SetEx(ctx context.Context, db int64, key []byte, expireTime ui
 
 // SetNx set a key-value pair, returning 0 if the key already exists and setting a value if the key does not exist.
 func (s *Storage) SetNx(ctx context.Context, db int64, key []byte, value []byte) (int, error) {
+	if err := s.checkType(ctx, db, key, stringTableName); err == storage.ErrWrongType {
+		return 0, nil
+	} else if err != nil {
+		return -1, err
+	}
+
 	tableName := stringTableName
 
 	// Set rowKey columns
@@ -244,6 +323,10 @@ func (s *Storage) SetNx(ctx context.Context, db int64, key []byte, value []byte)
 
 // Append appends a string to the value of the key. Returns the length of the final value.
 func (s *Storage) Append(ctx context.Context, db int64, key []byte, value []byte) (int, error) {
+	if err := s.checkType(ctx, db, key, stringTableName); err != nil {
+		return -1, err
+	}
+
 	tableName := stringTableName
 
 	// Set rowKey columns
@@ -270,6 +353,10 @@ func (s *Storage) Append(ctx context.Context, db int64, key []byte, value []byte
 // If the key does not exist, value is written and value is returned
 // Returns the value add value when key is present;
 func (s *Storage) IncrBy(ctx context.Context, db int64, key []byte, value []byte) (int64, error) {
+	if err := s.checkType(ctx, db, key, stringTableName); err != nil {
+		return -1, err
+	}
+
 	tableName := stringTableName
 
 	// Set rowKey columns
@@ -303,6 +390,10 @@ func (s *Storage) IncrBy(ctx context.Context, db int64, key []byte, value []byte
 // If the key does not exist, value is written and value is returned
 // Returns the value add value when key is present;
 func (s *Storage) IncrByFloat(ctx context.Context, db int64, key []byte, value []byte) (float64, error) {
+	if err := s.checkType(ctx, db, key, stringTableName); err != nil {
+		return -1, err
+	}
+
 	tableName := stringTableName
 
 	// Set rowKey columns
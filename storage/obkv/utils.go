@@ -19,10 +19,17 @@ package obkv
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
 	"math/rand"
+	"strconv"
 	"time"
 
 	"github.com/oceanbase/modis/log"
+	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/oceanbase/modis/storage"
+	"github.com/oceanbase/modis/util"
+	"github.com/oceanbase/obkv-table-client-go/client"
 	"github.com/oceanbase/obkv-table-client-go/client/option"
 	"github.com/oceanbase/obkv-table-client-go/table"
 )
@@ -51,8 +58,35 @@ func getRandomArray(min int, max int, count int) []int {
 	return arr
 }
 
-// ObServerCmd is a general interface for commands that can be executed on the observer side
+// ObServerCmd is a general interface for commands that can be executed on the observer side,
+// commands against a key existing in another table are rejected
 func (s *Storage) ObServerCmd(ctx context.Context, tableName string, rowKey []*table.Column, plainText []byte) (string, error) {
+	db, key, ok := keyOfRowKey(rowKey)
+	if ok {
+		if err := s.checkType(ctx, db, key, tableName); err != nil {
+			return "", err
+		}
+	}
+	return s.obServerCmd(ctx, tableName, rowKey, plainText)
+}
+
+// keyOfRowKey returns the db and key columns of a row key
+func keyOfRowKey(rowKey []*table.Column) (int64, []byte, bool) {
+	var db int64
+	var key []byte
+	var hasDB bool
+	for _, col := range rowKey {
+		switch col.Name() {
+		case dbColumnName:
+			db, hasDB = col.Value().(int64)
+		case keyColumnName:
+			key, _ = col.Value().([]byte)
+		}
+	}
+	return db, key, hasDB && key != nil
+}
+
+func (s *Storage) obServerCmd(ctx context.Context, tableName string, rowKey []*table.Column, plainText []byte) (string, error) {
 	mutateColumns := []*table.Column{
 		table.NewColumn("REDIS_CODE_STR", plainText),
 	}
@@ -75,4 +109,171 @@ func (s *Storage) ObServerCmd(ctx context.Context, tableName string, rowKey []*t
 		return "", err
 	}
 	return encodedRes, nil
-}
\ No newline at end of file
+}
+
+// redisCmd encodes args as a redis command and executes it on the observer side,
+// an error replied by the observer is returned as resp.ErrorReply
+func (s *Storage) redisCmd(ctx context.Context, tableName string, rowKey []*table.Column, args ...[]byte) (interface{}, error) {
+	res, err := s.obServerCmd(ctx, tableName, rowKey, []byte(resp.EncArray(args)))
+	if err != nil {
+		return nil, err
+	}
+	return resp.DecReply(res)
+}
+
+func errUnexpectedReply(reply interface{}) error {
+	return fmt.Errorf("unexpected reply from observer: %v", reply)
+}
+
+func replyInteger(reply interface{}, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	val, ok := reply.(int64)
+	if !ok {
+		return 0, errUnexpectedReply(reply)
+	}
+	return val, nil
+}
+
+// replyBulkString returns nil for a null bulk string
+func replyBulkString(reply interface{}, err error) ([]byte, error) {
+	if err != nil || reply == nil {
+		return nil, err
+	}
+	val, ok := reply.([]byte)
+	if !ok {
+		return nil, errUnexpectedReply(reply)
+	}
+	return val, nil
+}
+
+func replyOk(reply interface{}, err error) error {
+	if err != nil {
+		return err
+	}
+	if val, ok := reply.(string); !ok || val != "OK" {
+		return errUnexpectedReply(reply)
+	}
+	return nil
+}
+
+func replyArray(reply interface{}, err error) ([][]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := reply.([]interface{})
+	if !ok {
+		return nil, errUnexpectedReply(reply)
+	}
+	res := make([][]byte, 0, len(arr))
+	for _, elem := range arr {
+		val, ok := elem.([]byte)
+		if !ok && elem != nil {
+			return nil, errUnexpectedReply(reply)
+		}
+		res = append(res, val)
+	}
+	return res, nil
+}
+
+// replyMembers decodes an array of members and scores replied with WITHSCORES
+func replyMembers(reply interface{}, err error) ([]storage.ZMember, error) {
+	arr, err := replyArray(reply, err)
+	if err != nil {
+		return nil, err
+	}
+	if len(arr)%2 != 0 {
+		return nil, errUnexpectedReply(reply)
+	}
+	members := make([]storage.ZMember, 0, len(arr)/2)
+	for i := 0; i < len(arr); i += 2 {
+		score, err := parseScore(arr[i+1])
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, storage.ZMember{Member: arr[i], Score: score})
+	}
+	return members, nil
+}
+
+func formatFloat(f float64) []byte {
+	if math.IsInf(f, 1) {
+		return []byte("+inf")
+	} else if math.IsInf(f, -1) {
+		return []byte("-inf")
+	}
+	return []byte(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+func formatInt(i int64) []byte {
+	return []byte(strconv.FormatInt(i, 10))
+}
+
+func formatScoreBound(bound storage.ScoreBound) []byte {
+	if bound.Exclusive {
+		return append([]byte("("), formatFloat(bound.Score)...)
+	}
+	return formatFloat(bound.Score)
+}
+
+// parseScore parses a score replied by the observer
+func parseScore(b []byte) (float64, error) {
+	score, err := strconv.ParseFloat(util.BytesToString(b), 64)
+	if err != nil {
+		return 0, errUnexpectedReply(b)
+	}
+	return score, nil
+}
+
+// scanKeyRows returns at most count data rows of key whose column is greater than after in ascending order,
+// column is the last row key column of the table, e.g. field of the hash table.
+// storage.ErrWrongType is returned if no rows are found and key holds another type
+func (s *Storage) scanKeyRows(ctx context.Context, tableName string, db int64, key []byte, column string,
+	after []byte, count int64, selectColumns []string) ([]client.QueryResult, error) {
+	var start interface{} = table.Min
+	if after != nil {
+		start = after
+	}
+	startRowKey := []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(keyColumnName, key),
+		table.NewColumn(isDataColumnName, true),
+		table.NewColumn(column, start),
+	}
+	endRowKey := []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(keyColumnName, key),
+		table.NewColumn(isDataColumnName, true),
+		table.NewColumn(column, table.Max),
+	}
+	keyRanges := []*table.RangePair{table.NewRangePair(startRowKey, endRowKey, after == nil, true)}
+
+	resSet, err := s.cli.Query(
+		ctx,
+		tableName,
+		keyRanges,
+		option.WithQuerySelectColumns(selectColumns),
+		option.WithQueryScanOrder(table.KeepOrder),
+		option.WithQueryLimit(int(count)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer resSet.Close()
+
+	rows := make([]client.QueryResult, 0, count)
+	res, err := resSet.Next()
+	for ; res != nil && err == nil; res, err = resSet.Next() {
+		rows = append(rows, res)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		if err := s.checkType(ctx, db, key, tableName); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package obkv
+
+import (
+	"context"
+	"encoding/binary"
+	"math/rand"
+	"time"
+
+	"github.com/oceanbase/obkv-table-client-go/table"
+)
+
+/*
+version model:
+CREATE TABLE modis_version_table(
+  db bigint not null,
+  is_db tinyint(1) not null,
+  rkey varbinary(1024) not null,
+  expire_ts timestamp(6) not null,
+  version bigint not null,
+  PRIMARY KEY(db, is_db, rkey))
+  TTL(expire_ts + INTERVAL 0 SECOND)
+  PARTITION BY KEY(db, rkey) PARTITIONS 3;
+
+A row keeps a random version of a key written through modis, or of a db (is_db = 1, rkey empty)
+flushed through modis, so that WATCH finds the writes through other modis nodes by the version
+alone. The rows of the keys not written for versionTTL are purged, the version of such a key
+reads as 0 then.
+*/
+
+const (
+	versionTableName = "modis_version_table"
+
+	isDBColumnName    = "is_db"
+	versionColumnName = "version"
+
+	// versionTTL is how long the version of a key is kept after its last write
+	versionTTL = 24 * time.Hour
+)
+
+func versionRowKey(db int64, isDB bool, key []byte) []*table.Column {
+	return []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(isDBColumnName, isDB),
+		table.NewColumn(keyColumnName, key),
+	}
+}
+
+// TouchKeys sets the versions of keys of db to new random versions in one batch
+func (s *Storage) TouchKeys(ctx context.Context, db int64, keys [][]byte) error {
+	batchExecutor := s.cli.NewBatchExecutor(versionTableName)
+	expire := table.TimeStamp(time.Now().Add(versionTTL))
+	for _, key := range keys {
+		err := batchExecutor.AddInsertOrUpdateOp(versionRowKey(db, false, key), []*table.Column{
+			table.NewColumn(versionColumnName, rand.Int63()),
+			table.NewColumn(expireColumnName, expire),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	_, err := batchExecutor.Execute(ctx)
+	return err
+}
+
+// TouchDB sets the version of db to a new random version, which is part of the version of every key of db
+func (s *Storage) TouchDB(ctx context.Context, db int64) error {
+	_, err := s.cli.InsertOrUpdate(ctx, versionTableName, versionRowKey(db, true, []byte{}), []*table.Column{
+		table.NewColumn(versionColumnName, rand.Int63()),
+		table.NewColumn(expireColumnName, table.TimeStamp(time.Now().Add(versionTTL))),
+	})
+	return err
+}
+
+// KeyVersion reads the versions of key and db in one batch
+func (s *Storage) KeyVersion(ctx context.Context, db int64, key []byte) ([]byte, error) {
+	batchExecutor := s.cli.NewBatchExecutor(versionTableName)
+	for _, rowKey := range [][]*table.Column{versionRowKey(db, false, key), versionRowKey(db, true, []byte{})} {
+		if err := batchExecutor.AddGetOp(rowKey, []string{versionColumnName}); err != nil {
+			return nil, err
+		}
+	}
+	res, err := batchExecutor.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	version := make([]byte, 16)
+	for i, r := range res.GetResults() {
+		if v, ok := r.Value(versionColumnName).(int64); ok {
+			binary.BigEndian.PutUint64(version[8*i:], uint64(v))
+		}
+	}
+	return version, nil
+}
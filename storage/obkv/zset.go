@@ -18,9 +18,14 @@ package obkv
 
 import (
 	"context"
+	"math"
+	"math/rand"
 	"time"
 
 	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/oceanbase/modis/storage"
+	"github.com/oceanbase/obkv-table-client-go/client/filter"
+	"github.com/oceanbase/obkv-table-client-go/client/option"
 	"github.com/oceanbase/obkv-table-client-go/table"
 )
 
@@ -41,9 +46,605 @@ CREATE TABLE modis_zset_table(
 */
 
 const (
-	zsetTableName = "modis_zset_table"
+	zsetTableName   = "modis_zset_table"
+	scoreColumnName = "score"
 )
 
+// zsetRowKey returns the row key to route a zset command, member is optional
+func zsetRowKey(db int64, key []byte, member []byte) []*table.Column {
+	rowKey := []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(keyColumnName, key),
+	}
+	if member != nil {
+		rowKey = append(rowKey, table.NewColumn(memberColumnName, member))
+	}
+	return rowKey
+}
+
+// zsetDataRowKey returns the row key of the row of member
+func zsetDataRowKey(db int64, key []byte, member []byte) []*table.Column {
+	return []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(keyColumnName, key),
+		table.NewColumn(isDataColumnName, true),
+		table.NewColumn(memberColumnName, member),
+	}
+}
+
+// ZAdd adds all the members with their scores to the sorted set as flags allow, returns the number
+// of members added and changed. Without flags the members are passed to the observer as they are,
+// and all the members not added are counted as changed. With flags the current scores are read in
+// one batch, the new members are added by the observer, and the existing members are updated only
+// if their scores are still the ones read, see zsetSetScore
+func (s *Storage) ZAdd(ctx context.Context, db int64, key []byte, members []storage.ZMember, flags storage.ZAddFlags) (int64, int64, error) {
+	if err := s.checkType(ctx, db, key, zsetTableName); err != nil {
+		return 0, 0, err
+	}
+	if flags == (storage.ZAddFlags{}) {
+		added, err := s.zadd(ctx, db, key, members)
+		return added, int64(len(members)) - added, err
+	}
+
+	// the observer knows no flags, the members are looked up and filtered here
+	scores, err := s.zsetScores(ctx, db, key, members)
+	if err != nil {
+		return 0, 0, err
+	}
+	olds := make(map[string]float64, len(scores))
+	for member, score := range scores {
+		olds[member] = score
+	}
+	adds := make(map[string]float64, len(members))
+	for _, m := range members {
+		member := string(m.Member)
+		old, exists := scores[member]
+		if !flags.Allow(old, exists, m.Score) {
+			continue
+		}
+		if _, ok := olds[member]; !ok {
+			adds[member] = m.Score
+		}
+		scores[member] = m.Score
+	}
+
+	var added, changed int64
+	for member, old := range olds {
+		score := scores[member]
+		if score == old {
+			continue
+		}
+		isAdded, isChanged, err := s.zsetSetScore(ctx, db, key, []byte(member), old, true,
+			func(old float64, exists bool) (float64, bool, error) {
+				return score, flags.Allow(old, exists, score), nil
+			})
+		if err != nil {
+			return added, changed, err
+		}
+		if isAdded {
+			added++
+		} else if isChanged {
+			changed++
+		}
+	}
+	if len(adds) > 0 {
+		zmembers := make([]storage.ZMember, 0, len(adds))
+		for member, score := range adds {
+			zmembers = append(zmembers, storage.ZMember{Member: []byte(member), Score: score})
+		}
+		n, err := s.zadd(ctx, db, key, zmembers)
+		if err != nil {
+			return added, changed, err
+		}
+		// the members added meanwhile through another modis are overwritten
+		added += n
+		changed += int64(len(zmembers)) - n
+	}
+	return added, changed, nil
+}
+
+// zadd executes zadd on the observer, returns the number of members added
+func (s *Storage) zadd(ctx context.Context, db int64, key []byte, members []storage.ZMember) (int64, error) {
+	args := make([][]byte, 0, 2+2*len(members))
+	args = append(args, []byte("zadd"), key)
+	for _, m := range members {
+		args = append(args, formatFloat(m.Score), m.Member)
+	}
+	return replyInteger(s.redisCmd(ctx, zsetTableName, zsetRowKey(db, key, nil), args...))
+}
+
+// zsetScores reads the scores of the members in one batch, the members not in the sorted set are absent
+func (s *Storage) zsetScores(ctx context.Context, db int64, key []byte, members []storage.ZMember) (map[string]float64, error) {
+	batchExecutor := s.cli.NewBatchExecutor(zsetTableName)
+	for _, m := range members {
+		if err := batchExecutor.AddGetOp(zsetDataRowKey(db, key, m.Member), []string{scoreColumnName}); err != nil {
+			return nil, err
+		}
+	}
+	res, err := batchExecutor.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[string]float64, len(members))
+	for i, r := range res.GetResults() {
+		if score := r.Value(scoreColumnName); score != nil {
+			scores[string(members[i].Member)] = score.(float64)
+		}
+	}
+	return scores, nil
+}
+
+// ZAddIncr increments the score of member by incr as flags allow, returns the new score
+func (s *Storage) ZAddIncr(ctx context.Context, db int64, key []byte, member []byte, incr float64, flags storage.ZAddFlags) (float64, bool, error) {
+	if flags == (storage.ZAddFlags{}) {
+		score, err := s.ZIncrBy(ctx, db, key, member, incr)
+		return score, err == nil, err
+	}
+	if err := s.checkType(ctx, db, key, zsetTableName); err != nil {
+		return 0, false, err
+	}
+	scores, err := s.zsetScores(ctx, db, key, []storage.ZMember{{Member: member}})
+	if err != nil {
+		return 0, false, err
+	}
+	old, exists := scores[string(member)]
+	var score float64
+	allowed := false
+	_, _, err = s.zsetSetScore(ctx, db, key, member, old, exists, func(old float64, exists bool) (float64, bool, error) {
+		score = old + incr
+		if math.IsNaN(score) {
+			return 0, false, resp.ErrorReply("ERR resulting score is not a number (NaN)")
+		}
+		allowed = flags.Allow(old, exists, score)
+		return score, allowed, nil
+	})
+	if err != nil || !allowed {
+		return 0, false, err
+	}
+	return score, true, nil
+}
+
+// zsetSetScore writes the score of member returned by next, which is given the current score and
+// returns false to leave the member as it is. A new member is added by the observer, so that the
+// sorted set is kept by its model, and an existing member is updated only if its score is still the
+// one given to next, otherwise next is called again with the score written by another modis.
+// The results are whether member is added and whether its score is changed
+func (s *Storage) zsetSetScore(ctx context.Context, db int64, key []byte, member []byte, old float64, exists bool,
+	next func(old float64, exists bool) (float64, bool, error)) (bool, bool, error) {
+	rowKey := zsetDataRowKey(db, key, member)
+	for {
+		score, ok, err := next(old, exists)
+		if err != nil || !ok || (exists && old == score) {
+			return false, false, err
+		}
+		if !exists {
+			n, err := s.zadd(ctx, db, key, []storage.ZMember{{Member: member, Score: score}})
+			return n == 1, n == 0, err
+		}
+		affectedRows, err := s.cli.Update(ctx, zsetTableName, rowKey,
+			[]*table.Column{table.NewColumn(scoreColumnName, score)},
+			option.WithFilter(filter.CompareVal(filter.Equal, scoreColumnName, old)),
+		)
+		if err != nil {
+			return false, false, err
+		}
+		if affectedRows != 0 {
+			return false, true, nil
+		}
+		res, err := s.cli.Get(ctx, zsetTableName, rowKey, []string{scoreColumnName})
+		if err != nil {
+			return false, false, err
+		}
+		if exists = !res.IsEmptySet(); exists {
+			old = res.Value(scoreColumnName).(float64)
+		}
+	}
+}
+
+// ZIncrBy increments the score of member by incr, returns the new score
+func (s *Storage) ZIncrBy(ctx context.Context, db int64, key []byte, member []byte, incr float64) (float64, error) {
+	if err := s.checkType(ctx, db, key, zsetTableName); err != nil {
+		return 0, err
+	}
+	res, err := replyBulkString(s.redisCmd(ctx, zsetTableName, zsetRowKey(db, key, member),
+		[]byte("zincrby"), key, formatFloat(incr), member))
+	if err != nil {
+		return 0, err
+	}
+	return parseScore(res)
+}
+
+// ZRem removes the members from the sorted set, returns the number of members removed
+func (s *Storage) ZRem(ctx context.Context, db int64, key []byte, members [][]byte) (int64, error) {
+	args := make([][]byte, 0, 2+len(members))
+	args = append(args, []byte("zrem"), key)
+	args = append(args, members...)
+	return replyInteger(s.redisCmd(ctx, zsetTableName, zsetRowKey(db, key, nil), args...))
+}
+
+// ZCard returns the number of members in the sorted set
+func (s *Storage) ZCard(ctx context.Context, db int64, key []byte) (int64, error) {
+	size, err := replyInteger(s.redisCmd(ctx, zsetTableName, zsetRowKey(db, key, nil), []byte("zcard"), key))
+	if err != nil || size != 0 {
+		return size, err
+	}
+	return 0, s.checkType(ctx, db, key, zsetTableName)
+}
+
+// ZScore returns the score of member, the bool result is false if member not exists
+func (s *Storage) ZScore(ctx context.Context, db int64, key []byte, member []byte) (float64, bool, error) {
+	res, err := replyBulkString(s.redisCmd(ctx, zsetTableName, zsetRowKey(db, key, member),
+		[]byte("zscore"), key, member))
+	if err != nil {
+		return 0, false, err
+	}
+	if res == nil {
+		return 0, false, s.checkType(ctx, db, key, zsetTableName)
+	}
+	score, err := parseScore(res)
+	return score, err == nil, err
+}
+
+// ZRandMember returns count distinct random members, or -count random members if count < 0,
+// every member is read by its rank
+func (s *Storage) ZRandMember(ctx context.Context, db int64, key []byte, count int64) ([]storage.ZMember, error) {
+	size, err := s.ZCard(ctx, db, key)
+	if err != nil {
+		return nil, err
+	}
+	if size == 0 || count == 0 {
+		return []storage.ZMember{}, nil
+	}
+	if count >= size {
+		return s.ZRange(ctx, db, key, 0, -1, false)
+	}
+
+	var ranks []int
+	if count > 0 {
+		ranks = getRandomArray(0, int(size), int(count))
+	} else {
+		randGen := rand.New(rand.NewSource(time.Now().UnixNano()))
+		for i := int64(0); i < -count; i++ {
+			ranks = append(ranks, randGen.Intn(int(size)))
+		}
+	}
+	res := make([]storage.ZMember, 0, len(ranks))
+	for _, rank := range ranks {
+		members, err := s.ZRange(ctx, db, key, int64(rank), int64(rank), false)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, members...)
+	}
+	return res, nil
+}
+
+// ZPop removes and returns at most count members with the lowest scores, or the highest if reverse.
+// A member is claimed by ZREM on the observer, so that it is popped by one client only, and its row is
+// read right before to check the score of the range, the members are ranged again once a score has
+// changed. The members removed before a failure are returned along with the error
+func (s *Storage) ZPop(ctx context.Context, db int64, key []byte, count int64, reverse bool) ([]storage.ZMember, error) {
+	if err := s.checkType(ctx, db, key, zsetTableName); err != nil {
+		return nil, err
+	}
+	res := []storage.ZMember{}
+	for int64(len(res)) < count {
+		members, err := s.ZRange(ctx, db, key, 0, count-int64(len(res))-1, reverse)
+		if err != nil {
+			return res, err
+		}
+		if len(members) == 0 {
+			break
+		}
+		for _, m := range members {
+			row, err := s.cli.Get(ctx, zsetTableName, zsetDataRowKey(db, key, m.Member), []string{scoreColumnName})
+			if err != nil {
+				return res, err
+			}
+			if row.IsEmptySet() {
+				// popped by another client
+				continue
+			}
+			if row.Value(scoreColumnName).(float64) != m.Score {
+				// rescored meanwhile, the range may be out of order
+				break
+			}
+			removed, err := s.ZRem(ctx, db, key, [][]byte{m.Member})
+			if err != nil {
+				return res, err
+			}
+			if removed == 1 {
+				res = append(res, m)
+			}
+		}
+	}
+	return res, nil
+}
+
+// ZRank returns the rank of member, the bool result is false if member not exists
+func (s *Storage) ZRank(ctx context.Context, db int64, key []byte, member []byte, reverse bool) (int64, bool, error) {
+	cmd := []byte("zrank")
+	if reverse {
+		cmd = []byte("zrevrank")
+	}
+	reply, err := s.redisCmd(ctx, zsetTableName, zsetRowKey(db, key, member), cmd, key, member)
+	if err != nil {
+		return 0, false, err
+	}
+	if reply == nil {
+		return 0, false, s.checkType(ctx, db, key, zsetTableName)
+	}
+	rank, err := replyInteger(reply, nil)
+	return rank, err == nil, err
+}
+
+// ZRange returns the members with rank in [start, stop]
+func (s *Storage) ZRange(ctx context.Context, db int64, key []byte, start int64, stop int64, reverse bool) ([]storage.ZMember, error) {
+	cmd := []byte("zrange")
+	if reverse {
+		cmd = []byte("zrevrange")
+	}
+	return s.zsetMembers(ctx, db, key, zsetRowKey(db, key, nil),
+		cmd, key, formatInt(start), formatInt(stop), []byte("withscores"))
+}
+
+// zsetMembers runs a sorted set command replying members with scores, the type of key is checked
+// if no member is replied
+func (s *Storage) zsetMembers(ctx context.Context, db int64, key []byte, rowKey []*table.Column,
+	args ...[]byte) ([]storage.ZMember, error) {
+	members, err := replyMembers(s.redisCmd(ctx, zsetTableName, rowKey, args...))
+	if err != nil || len(members) != 0 {
+		return members, err
+	}
+	return members, s.checkType(ctx, db, key, zsetTableName)
+}
+
+// ZRangeByScore returns the members with score in [min, max], skipping offset members and
+// returning at most count members if count >= 0
+func (s *Storage) ZRangeByScore(ctx context.Context, db int64, key []byte, min storage.ScoreBound, max storage.ScoreBound,
+	reverse bool, offset int64, count int64) ([]storage.ZMember, error) {
+	cmd := []byte("zrangebyscore")
+	minArg, maxArg := formatScoreBound(min), formatScoreBound(max)
+	if reverse {
+		cmd = []byte("zrevrangebyscore")
+		minArg, maxArg = maxArg, minArg
+	}
+	args := [][]byte{cmd, key, minArg, maxArg, []byte("withscores")}
+	if offset != 0 || count >= 0 {
+		if count < 0 {
+			count = math.MaxInt32
+		}
+		args = append(args, []byte("limit"), formatInt(offset), formatInt(count))
+	}
+	return s.zsetMembers(ctx, db, key, zsetRowKey(db, key, minArg), args...)
+}
+
+// zsetMemberRange returns the range of the rows of the members in [min, max]
+func zsetMemberRange(db int64, key []byte, min storage.LexBound, max storage.LexBound) []*table.RangePair {
+	var start, end interface{} = table.Min, table.Max
+	if min.Inf == 0 {
+		start = min.Member
+	}
+	if max.Inf == 0 {
+		end = max.Member
+	}
+	startRowKey := []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(keyColumnName, key),
+		table.NewColumn(isDataColumnName, true),
+		table.NewColumn(memberColumnName, start),
+	}
+	endRowKey := []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(keyColumnName, key),
+		table.NewColumn(isDataColumnName, true),
+		table.NewColumn(memberColumnName, end),
+	}
+	return []*table.RangePair{table.NewRangePair(startRowKey, endRowKey, !min.Exclusive, !max.Exclusive)}
+}
+
+// queryZSetMembers scans the members in [min, max] by the primary key, from max if reverse is set,
+// skipping offset members and returning at most count members if count >= 0
+func (s *Storage) queryZSetMembers(ctx context.Context, db int64, key []byte, min storage.LexBound, max storage.LexBound,
+	reverse bool, offset int64, count int64) ([]storage.ZMember, error) {
+	scanOrder := table.Forward
+	if reverse {
+		scanOrder = table.Reverse
+	}
+	opts := []option.ObQueryOption{
+		option.WithQuerySelectColumns([]string{memberColumnName, scoreColumnName}),
+		option.WithQueryScanOrder(scanOrder),
+	}
+	if offset != 0 || count >= 0 {
+		if count < 0 {
+			count = math.MaxInt32
+		}
+		opts = append(opts, option.WithQueryOffset(int(offset)), option.WithQueryLimit(int(count)))
+	}
+	resSet, err := s.cli.Query(ctx, zsetTableName, zsetMemberRange(db, key, min, max), opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer resSet.Close()
+
+	members := []storage.ZMember{}
+	res, err := resSet.Next()
+	for ; res != nil && err == nil; res, err = resSet.Next() {
+		members = append(members, storage.ZMember{
+			Member: res.Value(memberColumnName).([]byte),
+			Score:  res.Value(scoreColumnName).(float64),
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// ZRangeByLex returns the members in [min, max] in the order of the members, skipping offset members
+// and returning at most count members if count >= 0
+func (s *Storage) ZRangeByLex(ctx context.Context, db int64, key []byte, min storage.LexBound, max storage.LexBound,
+	reverse bool, offset int64, count int64) ([]storage.ZMember, error) {
+	if err := s.checkType(ctx, db, key, zsetTableName); err != nil {
+		return nil, err
+	}
+	if storage.EmptyLexRange(min, max) || offset < 0 || count == 0 {
+		return []storage.ZMember{}, nil
+	}
+	return s.queryZSetMembers(ctx, db, key, min, max, reverse, offset, count)
+}
+
+// ZLexCount returns the number of members in [min, max]
+func (s *Storage) ZLexCount(ctx context.Context, db int64, key []byte, min storage.LexBound, max storage.LexBound) (int64, error) {
+	if err := s.checkType(ctx, db, key, zsetTableName); err != nil {
+		return 0, err
+	}
+	if storage.EmptyLexRange(min, max) {
+		return 0, nil
+	}
+	res, err := s.cli.NewAggExecutor(zsetTableName, zsetMemberRange(db, key, min, max)).Count().Execute(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.Value("count(*)").(int64), nil
+}
+
+// ZRemRangeByLex removes the members in [min, max], returns the number of members removed
+func (s *Storage) ZRemRangeByLex(ctx context.Context, db int64, key []byte, min storage.LexBound, max storage.LexBound) (int64, error) {
+	members, err := s.ZRangeByLex(ctx, db, key, min, max, false, 0, -1)
+	if err != nil || len(members) == 0 {
+		return 0, err
+	}
+	args := make([][]byte, 0, len(members))
+	for _, m := range members {
+		args = append(args, m.Member)
+	}
+	return s.ZRem(ctx, db, key, args)
+}
+
+// ZCount returns the number of members with score in [min, max]
+func (s *Storage) ZCount(ctx context.Context, db int64, key []byte, min storage.ScoreBound, max storage.ScoreBound) (int64, error) {
+	num, err := replyInteger(s.redisCmd(ctx, zsetTableName, zsetRowKey(db, key, nil),
+		[]byte("zcount"), key, formatScoreBound(min), formatScoreBound(max)))
+	if err != nil || num != 0 {
+		return num, err
+	}
+	return 0, s.checkType(ctx, db, key, zsetTableName)
+}
+
+// ZRemRangeByRank removes the members with rank in [start, stop], returns the number of members removed
+func (s *Storage) ZRemRangeByRank(ctx context.Context, db int64, key []byte, start int64, stop int64) (int64, error) {
+	return replyInteger(s.redisCmd(ctx, zsetTableName, zsetRowKey(db, key, nil),
+		[]byte("zremrangebyrank"), key, formatInt(start), formatInt(stop)))
+}
+
+// ZRemRangeByScore removes the members with score in [min, max], returns the number of members removed
+func (s *Storage) ZRemRangeByScore(ctx context.Context, db int64, key []byte, min storage.ScoreBound, max storage.ScoreBound) (int64, error) {
+	return replyInteger(s.redisCmd(ctx, zsetTableName, zsetRowKey(db, key, nil),
+		[]byte("zremrangebyscore"), key, formatScoreBound(min), formatScoreBound(max)))
+}
+
+// ZUnionStore stores the union of the sorted sets in dst, returns the number of members in dst
+func (s *Storage) ZUnionStore(ctx context.Context, db int64, dst []byte, keys [][]byte, weights []float64, aggregate storage.Aggregate) (int64, error) {
+	return s.zsetStore(ctx, db, []byte("zunionstore"), dst, keys, weights, aggregate)
+}
+
+// ZInterStore stores the intersection of the sorted sets in dst, returns the number of members in dst
+func (s *Storage) ZInterStore(ctx context.Context, db int64, dst []byte, keys [][]byte, weights []float64, aggregate storage.Aggregate) (int64, error) {
+	return s.zsetStore(ctx, db, []byte("zinterstore"), dst, keys, weights, aggregate)
+}
+
+// ZUnion returns the union of the sorted sets ordered by score
+func (s *Storage) ZUnion(ctx context.Context, db int64, keys [][]byte, weights []float64, aggregate storage.Aggregate) ([]storage.ZMember, error) {
+	zsets, err := s.readZSets(ctx, db, keys)
+	if err != nil {
+		return nil, err
+	}
+	return storage.SortedZMembers(storage.CombineZSets(zsets, true, weights, aggregate)), nil
+}
+
+// ZInter returns the intersection of the sorted sets ordered by score
+func (s *Storage) ZInter(ctx context.Context, db int64, keys [][]byte, weights []float64, aggregate storage.Aggregate) ([]storage.ZMember, error) {
+	zsets, err := s.readZSets(ctx, db, keys)
+	if err != nil {
+		return nil, err
+	}
+	return storage.SortedZMembers(storage.CombineZSets(zsets, false, weights, aggregate)), nil
+}
+
+// ZDiff returns the members of the first sorted set not in the others ordered by score
+func (s *Storage) ZDiff(ctx context.Context, db int64, keys [][]byte) ([]storage.ZMember, error) {
+	zsets, err := s.readZSets(ctx, db, keys)
+	if err != nil {
+		return nil, err
+	}
+	return storage.SortedZMembers(storage.DiffZSets(zsets)), nil
+}
+
+// readZSets reads all the members of the sorted sets, the observer only stores the results of the
+// set operations, so they are computed by modis
+func (s *Storage) readZSets(ctx context.Context, db int64, keys [][]byte) ([]map[string]float64, error) {
+	zsets := make([]map[string]float64, 0, len(keys))
+	for _, key := range keys {
+		if err := s.checkType(ctx, db, key, zsetTableName); err != nil {
+			return nil, err
+		}
+		members, err := s.ZRange(ctx, db, key, 0, -1, false)
+		if err != nil {
+			return nil, err
+		}
+		zset := make(map[string]float64, len(members))
+		for _, m := range members {
+			zset[string(m.Member)] = m.Score
+		}
+		zsets = append(zsets, zset)
+	}
+	return zsets, nil
+}
+
+// zsetStore executes a zset store command, dst is overwritten whatever type it holds
+func (s *Storage) zsetStore(ctx context.Context, db int64, cmd []byte, dst []byte, keys [][]byte, weights []float64, aggregate storage.Aggregate) (int64, error) {
+	if err := s.deleteOtherTypes(ctx, db, [][]byte{dst}, zsetTableName); err != nil {
+		return 0, err
+	}
+	args := make([][]byte, 0, 6+2*len(keys))
+	args = append(args, cmd, dst, formatInt(int64(len(keys))))
+	args = append(args, keys...)
+	if weights != nil {
+		args = append(args, []byte("weights"))
+		for _, w := range weights {
+			args = append(args, formatFloat(w))
+		}
+	}
+	switch aggregate {
+	case storage.AggregateMin:
+		args = append(args, []byte("aggregate"), []byte("min"))
+	case storage.AggregateMax:
+		args = append(args, []byte("aggregate"), []byte("max"))
+	}
+	return replyInteger(s.redisCmd(ctx, zsetTableName, zsetRowKey(db, dst, nil), args...))
+}
+
+// ZScan returns at most count members greater than after in ascending order of the members
+func (s *Storage) ZScan(ctx context.Context, db int64, key []byte, after []byte, count int64) ([]storage.ZMember, error) {
+	rows, err := s.scanKeyRows(ctx, zsetTableName, db, key, memberColumnName, after, count,
+		[]string{memberColumnName, scoreColumnName})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]storage.ZMember, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, storage.ZMember{
+			Member: row.Value(memberColumnName).([]byte),
+			Score:  row.Value(scoreColumnName).(float64),
+		})
+	}
+	if len(res) != 0 {
+		return res, nil
+	}
+	return res, s.checkType(ctx, db, key, zsetTableName)
+}
+
 // zsetExists check the number of keys that exist in zset table
 func (s *Storage) zsetExists(ctx context.Context, db int64, keys [][]byte) (int64, error) {
 	var existNum int64 = 0
@@ -58,7 +659,7 @@ func (s *Storage) zsetExists(ctx context.Context, db int64, keys [][]byte) (int6
 			table.NewColumn(keyColumnName, key),
 		}
 
-		outContent, err := s.ObServerCmd(ctx, zsetTableName, rowKey, []byte(encodedArray))
+		outContent, err := s.obServerCmd(ctx, zsetTableName, rowKey, []byte(encodedArray))
 		if err != nil {
 			return 0, err
 		}
@@ -91,7 +692,7 @@ func (s *Storage) deleteZSet(ctx context.Context, db int64, keys [][]byte) (int6
 			table.NewColumn(keyColumnName, key),
 		}
 
-		outContent, err := s.ObServerCmd(ctx, zsetTableName, rowKey, []byte(encodedArray))
+		outContent, err := s.obServerCmd(ctx, zsetTableName, rowKey, []byte(encodedArray))
 		if err != nil {
 			return 0, err
 		}
@@ -110,15 +711,82 @@ func (s *Storage) deleteZSet(ctx context.Context, db int64, keys [][]byte) (int6
 
 // expireZSet expire zset table
 func (s *Storage) expireZSet(ctx context.Context, db int64, key []byte, expire_ts table.TimeStamp) (int, error) {
-	return 0, nil
+	return s.updateZSetExpire(ctx, db, key, table.NewColumn(expireColumnName, expire_ts))
 }
 
-// persistZSet expire zset table
+// persistZSet persist zset table
 func (s *Storage) persistZSet(ctx context.Context, db int64, key []byte) (int, error) {
-	return 0, nil
+	return s.updateZSetExpire(ctx, db, key, table.NewColumn(expireColumnName, nil))
+}
+
+// updateZSetExpire updates the expire column of all members of the zset
+func (s *Storage) updateZSetExpire(ctx context.Context, db int64, key []byte, expire *table.Column) (int, error) {
+	tableName := zsetTableName
+	var res = 0
+
+	// 1. Get all members
+	members, err := s.ZRange(ctx, db, key, 0, -1, false)
+	if err != nil {
+		return 0, err
+	}
+
+	// 2. Update all members
+	for _, m := range members {
+		// Set rowKey columns
+		rowKey := []*table.Column{
+			table.NewColumn(dbColumnName, db),
+			table.NewColumn(keyColumnName, key),
+			table.NewColumn(isDataColumnName, true),
+			table.NewColumn(memberColumnName, m.Member),
+		}
+
+		// Execute
+		affectedRows, err := s.cli.Update(ctx, tableName, rowKey, []*table.Column{expire})
+		if err != nil {
+			return 0, err
+		}
+		if affectedRows != 0 && res != 1 {
+			res = 1
+		}
+	}
+
+	return res, nil
 }
 
 // ttlZSet get expire time of zset table
 func (s *Storage) ttlZSet(ctx context.Context, db int64, key []byte) (time.Duration, error) {
-	return -2, nil
+	tableName := zsetTableName
+
+	// 1. Get the first member
+	members, err := s.ZRange(ctx, db, key, 0, 0, false)
+	if err != nil {
+		return 0, err
+	}
+	if len(members) == 0 {
+		return -2, nil
+	}
+
+	// 2. Get its expire time
+	rowKey := []*table.Column{
+		table.NewColumn(dbColumnName, db),
+		table.NewColumn(keyColumnName, key),
+		table.NewColumn(isDataColumnName, true),
+		table.NewColumn(memberColumnName, members[0].Member),
+	}
+	res, err := s.cli.Get(ctx, tableName, rowKey, []string{expireColumnName})
+	if err != nil {
+		return 0, err
+	}
+
+	if res.IsEmptySet() {
+		return -2, nil
+	}
+
+	if res.Value(expireColumnName) == nil {
+		return -1, nil
+	}
+
+	expire := res.Value(expireColumnName)
+	sub := time.Until(expire.(time.Time))
+	return sub, nil
 }
@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/oceanbase/modis/config"
+)
+
+// DefaultBackend is used when storage.backend is not set
+const DefaultBackend = "obkv"
+
+// Factory creates a storage from the storage config, a backend reads its
+// own section of the config, see config.StorageConfig.DecodeSection
+type Factory func(cfg *config.StorageConfig) (Storage, error)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = make(map[string]Factory)
+)
+
+// Register makes a storage backend available by the provided name,
+// it is usually called in the init function of the backend package.
+// Register panics if it is called twice with the same name or if factory is nil
+func Register(name string, factory Factory) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if factory == nil {
+		panic("storage: Register factory is nil")
+	}
+	if _, dup := backends[name]; dup {
+		panic("storage: Register called twice for backend " + name)
+	}
+	backends[name] = factory
+}
+
+// Backends returns a sorted list of the names of the registered backends
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewStorage creates a storage of the backend selected by storage.backend
+func NewStorage(cfg *config.StorageConfig) (Storage, error) {
+	name := cfg.Backend
+	if name == "" {
+		name = DefaultBackend
+	}
+	backendsMu.RLock()
+	factory, ok := backends[name]
+	backendsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown storage backend '%s', registered backends: %s",
+			name, strings.Join(Backends(), ", "))
+	}
+	return factory(cfg)
+}
@@ -17,29 +17,131 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"time"
 
 	"github.com/oceanbase/modis/config"
 	"github.com/oceanbase/modis/log"
-	"github.com/oceanbase/modis/storage/obkv"
+	"github.com/oceanbase/modis/protocol/resp"
 	"github.com/oceanbase/obkv-table-client-go/table"
 )
 
+// ErrWrongType is returned when a command is against a key holding another type of value,
+// a key holds exactly one of the types string, hash, list, zset, set and stream
+var ErrWrongType = resp.ErrorReply("WRONGTYPE Operation against a key holding the wrong kind of value")
+
+// ErrNoSuchKey is returned when a command requires an existing key, e.g. RENAME
+var ErrNoSuchKey = resp.ErrorReply("ERR no such key")
+
+type TableInfo struct {
+	Keys    int64 // num of keys in db
+	Expires int64 // num of keys with ttl in db
+}
+
+// ZMember is a member of a sorted set with its score
+type ZMember struct {
+	Member []byte
+	Score  float64
+}
+
+// ZAddFlags are the NX, XX, GT, LT and CH options of ZADD
+type ZAddFlags struct {
+	NX bool
+	XX bool
+	GT bool
+	LT bool
+	CH bool
+}
+
+// Allow tells whether ZADD sets the score of a member to score, old is the current
+// score of the member if exists is true
+func (f ZAddFlags) Allow(old float64, exists bool, score float64) bool {
+	if !exists {
+		return !f.XX
+	}
+	return !f.NX && !(f.GT && score <= old) && !(f.LT && score >= old)
+}
+
+// ScoreBound is the min or max of a score range, the score itself
+// is not in the range if Exclusive is set, like "(1.5" in redis
+type ScoreBound struct {
+	Score     float64
+	Exclusive bool
+}
+
+// LexBound is the min or max of a member range, the member itself is not in the range if Exclusive
+// is set, like "[a" and "(a" in redis. Inf is -1 for "-" and 1 for "+", the member is ignored then
+type LexBound struct {
+	Member    []byte
+	Exclusive bool
+	Inf       int
+}
+
+// EmptyLexRange tells whether no member can be in the range between min and max
+func EmptyLexRange(min LexBound, max LexBound) bool {
+	if min.Inf > 0 || max.Inf < 0 {
+		return true
+	}
+	if min.Inf < 0 || max.Inf > 0 {
+		return false
+	}
+	cmp := bytes.Compare(min.Member, max.Member)
+	return cmp > 0 || (cmp == 0 && (min.Exclusive || max.Exclusive))
+}
+
+// Aggregate specifies how scores of the same member are combined by ZUNIONSTORE and ZINTERSTORE
+type Aggregate int
+
+const (
+	AggregateSum Aggregate = iota
+	AggregateMin
+	AggregateMax
+)
+
 type Storage interface {
 	Initialize() error
 	// key commands
 	Delete(ctx context.Context, db int64, keys [][]byte) (int64, error)
+	// Type returns the type name of the key, nil if the key not exists
 	Type(ctx context.Context, db int64, key []byte) ([]byte, error)
 	Exists(ctx context.Context, db int64, keys [][]byte) (int64, error)
 	Expire(ctx context.Context, db int64, key []byte, t time.Time) (int, error)
 	Persist(ctx context.Context, db int64, key []byte) (int, error)
 	TTL(ctx context.Context, db int64, key []byte) (time.Duration, error)
+	// Scan returns about count keys of type typ greater than after in ascending order, at least one
+	// key is returned unless there are no more keys, after is nil to scan from the beginning
+	Scan(ctx context.Context, db int64, typ string, after []byte, count int64) ([][]byte, error)
+	// SaveCursor keeps pos, the position where a SCAN family iteration of db stopped, under id for ttl,
+	// a cursor saved by one modis can be loaded by every modis sharing the storage
+	SaveCursor(ctx context.Context, db int64, id uint64, pos []byte, ttl time.Duration) error
+	// LoadCursor returns the position saved under id, nil if it not exists or expired
+	LoadCursor(ctx context.Context, db int64, id uint64) ([]byte, error)
+	// TouchKeys changes the versions of keys of db, modis touches every key it writes, so that WATCH
+	// finds the writes through every modis sharing the storage, see KeyVersion
+	TouchKeys(ctx context.Context, db int64, keys [][]byte) error
+	// TouchDB changes the versions of all the keys of db, e.g. when db is flushed
+	TouchDB(ctx context.Context, db int64) error
+	// KeyVersion returns the version of key of db, which is changed whenever key or db is touched
+	KeyVersion(ctx context.Context, db int64, key []byte) ([]byte, error)
+	// Copy copies the value of src in srcDB to dst in dstDB along with its ttl, returns false if src
+	// not exists, or dst exists and replace is not set
+	Copy(ctx context.Context, srcDB int64, src []byte, dstDB int64, dst []byte, replace bool) (bool, error)
+	// Rename moves the value of src in srcDB to dst in dstDB along with its ttl, returns false if dst
+	// exists and replace is not set, ErrNoSuchKey if src not exists
+	Rename(ctx context.Context, srcDB int64, src []byte, dstDB int64, dst []byte, replace bool) (bool, error)
 
 	// string commands
 	Get(ctx context.Context, db int64, key []byte) ([]byte, error)
 	Set(ctx context.Context, db int64, key []byte, value []byte) error
+	// SetKeepTTL sets the value of the string like Set, but keeps the ttl of an existing key,
+	// ErrWrongType is returned if the key holds another type
+	SetKeepTTL(ctx context.Context, db int64, key []byte, value []byte) error
+	// CompareAndSet sets the value of the string to value if it is still old, or creates it if old is nil
+	// and the key not exists, the ttl is kept. It returns false if the value has been changed meanwhile,
+	// ErrWrongType is returned if the key holds another type
+	CompareAndSet(ctx context.Context, db int64, key []byte, old []byte, value []byte) (bool, error)
 	PSetEx(ctx context.Context, db int64, key []byte, expireTime uint64, value []byte) error
 	SetEx(ctx context.Context, db int64, key []byte, expireTime uint64, value []byte) error
 	MGet(ctx context.Context, db int64, keys [][]byte) ([][]byte, error)
@@ -62,17 +164,133 @@ type Storage interface {
 	HIncrBy(ctx context.Context, db int64, key []byte, field []byte, value []byte) (int64, error)
 	HIncrByFloat(ctx context.Context, db int64, key []byte, field []byte, value []byte) (float64, error)
 
+	// HSet sets the fields of the hash and returns the number of fields added
+	HSet(ctx context.Context, db int64, key []byte, fieldValues map[string][]byte) (int64, error)
+	// HScan returns at most count fields greater than after in ascending order, each followed by its value
+	HScan(ctx context.Context, db int64, key []byte, after []byte, count int64) ([][]byte, error)
+
 	// set commands
+	SAdd(ctx context.Context, db int64, key []byte, members [][]byte) (int64, error)
+	SUnion(ctx context.Context, db int64, keys [][]byte) ([][]byte, error)
+	SInter(ctx context.Context, db int64, keys [][]byte) ([][]byte, error)
+	SDiff(ctx context.Context, db int64, keys [][]byte) ([][]byte, error)
+	SUnionStore(ctx context.Context, db int64, dst []byte, keys [][]byte) (int64, error)
+	SInterStore(ctx context.Context, db int64, dst []byte, keys [][]byte) (int64, error)
+	SDiffStore(ctx context.Context, db int64, dst []byte, keys [][]byte) (int64, error)
 	SCard(ctx context.Context, db int64, key []byte) (int64, error)
 	SIsmember(ctx context.Context, db int64, key []byte, member []byte) (int, error)
 	SMembers(ctx context.Context, db int64, key []byte) ([][]byte, error)
+	// SScan returns at most count members greater than after in ascending order
+	SScan(ctx context.Context, db int64, key []byte, after []byte, count int64) ([][]byte, error)
 	Smove(ctx context.Context, db int64, src []byte, dst []byte, member []byte) (int, error)
 	SPop(ctx context.Context, db int64, key []byte, count int) ([][]byte, error)
 	SRandMember(ctx context.Context, db int64, key []byte, count int) ([][]byte, error)
 	SRem(ctx context.Context, db int64, key []byte, members [][]byte) (int64, error)
 
+	// list commands, indexes and ranges follow redis, negative values count from the tail
+	LPush(ctx context.Context, db int64, key []byte, values [][]byte) (int64, error)
+	LPushX(ctx context.Context, db int64, key []byte, values [][]byte) (int64, error)
+	RPush(ctx context.Context, db int64, key []byte, values [][]byte) (int64, error)
+	RPushX(ctx context.Context, db int64, key []byte, values [][]byte) (int64, error)
+	LPop(ctx context.Context, db int64, key []byte) ([]byte, error)
+	RPop(ctx context.Context, db int64, key []byte) ([]byte, error)
+	// LPopCount and RPopCount pop at most count elements, nil if the list not exists
+	LPopCount(ctx context.Context, db int64, key []byte, count int64) ([][]byte, error)
+	RPopCount(ctx context.Context, db int64, key []byte, count int64) ([][]byte, error)
+	// LMove pops an element from the head (srcLeft) or the tail of src and pushes it to the head (dstLeft)
+	// or the tail of dst, returns the element, nil if src not exists
+	LMove(ctx context.Context, db int64, src []byte, dst []byte, srcLeft bool, dstLeft bool) ([]byte, error)
+	LIndex(ctx context.Context, db int64, key []byte, index int64) ([]byte, error)
+	// LPos returns the indexes of at most count (0 for all) matches of element, skipping the first rank-1
+	// matches, scanning from the tail if rank < 0, and among the first maxLen (0 for all) elements scanned
+	LPos(ctx context.Context, db int64, key []byte, element []byte, rank int64, count int64, maxLen int64) ([]int64, error)
+	LSet(ctx context.Context, db int64, key []byte, index int64, value []byte) error
+	LRange(ctx context.Context, db int64, key []byte, start int64, stop int64) ([][]byte, error)
+	LTrim(ctx context.Context, db int64, key []byte, start int64, stop int64) error
+	LInsert(ctx context.Context, db int64, key []byte, before bool, pivot []byte, value []byte) (int64, error)
+	LLen(ctx context.Context, db int64, key []byte) (int64, error)
+	LRem(ctx context.Context, db int64, key []byte, count int64, value []byte) (int64, error)
+
+	// zset commands, count < 0 means no limit
+	// ZAdd adds the members or updates their scores as flags allow, returns the number of members added
+	// and the number of members whose score changed. Without flags the latter may count the members
+	// updated with the same score, and with flags the members added meanwhile by another client
+	ZAdd(ctx context.Context, db int64, key []byte, members []ZMember, flags ZAddFlags) (int64, int64, error)
+	// ZAddIncr increments the score of member by incr as flags allow, returns the new score,
+	// false if flags prevent the update
+	ZAddIncr(ctx context.Context, db int64, key []byte, member []byte, incr float64, flags ZAddFlags) (float64, bool, error)
+	ZIncrBy(ctx context.Context, db int64, key []byte, member []byte, incr float64) (float64, error)
+	ZRem(ctx context.Context, db int64, key []byte, members [][]byte) (int64, error)
+	ZCard(ctx context.Context, db int64, key []byte) (int64, error)
+	ZScore(ctx context.Context, db int64, key []byte, member []byte) (float64, bool, error)
+	// ZRandMember returns count distinct members chosen at random, or -count members which
+	// may repeat if count < 0
+	ZRandMember(ctx context.Context, db int64, key []byte, count int64) ([]ZMember, error)
+	// ZPop removes and returns at most count members with the lowest scores, or the highest if reverse,
+	// a member is never returned by two concurrent pops. The members removed before a failure are
+	// returned along with the error
+	ZPop(ctx context.Context, db int64, key []byte, count int64, reverse bool) ([]ZMember, error)
+	ZRank(ctx context.Context, db int64, key []byte, member []byte, reverse bool) (int64, bool, error)
+	ZRange(ctx context.Context, db int64, key []byte, start int64, stop int64, reverse bool) ([]ZMember, error)
+	ZRangeByScore(ctx context.Context, db int64, key []byte, min ScoreBound, max ScoreBound, reverse bool, offset int64, count int64) ([]ZMember, error)
+	// ZRangeByLex returns the members between min and max in the order of the members regardless of the
+	// scores, from max if reverse is set, skipping offset members and returning at most count members
+	ZRangeByLex(ctx context.Context, db int64, key []byte, min LexBound, max LexBound, reverse bool, offset int64, count int64) ([]ZMember, error)
+	ZLexCount(ctx context.Context, db int64, key []byte, min LexBound, max LexBound) (int64, error)
+	ZRemRangeByLex(ctx context.Context, db int64, key []byte, min LexBound, max LexBound) (int64, error)
+	ZCount(ctx context.Context, db int64, key []byte, min ScoreBound, max ScoreBound) (int64, error)
+	ZRemRangeByRank(ctx context.Context, db int64, key []byte, start int64, stop int64) (int64, error)
+	ZRemRangeByScore(ctx context.Context, db int64, key []byte, min ScoreBound, max ScoreBound) (int64, error)
+	ZUnionStore(ctx context.Context, db int64, dst []byte, keys [][]byte, weights []float64, aggregate Aggregate) (int64, error)
+	ZInterStore(ctx context.Context, db int64, dst []byte, keys [][]byte, weights []float64, aggregate Aggregate) (int64, error)
+	// ZUnion, ZInter and ZDiff return the union, the intersection and the difference of the sorted sets
+	// ordered by score, the difference has the members of the first sorted set not in the others
+	ZUnion(ctx context.Context, db int64, keys [][]byte, weights []float64, aggregate Aggregate) ([]ZMember, error)
+	ZInter(ctx context.Context, db int64, keys [][]byte, weights []float64, aggregate Aggregate) ([]ZMember, error)
+	ZDiff(ctx context.Context, db int64, keys [][]byte) ([]ZMember, error)
+	// ZScan returns at most count members greater than after in ascending order of the members
+	ZScan(ctx context.Context, db int64, key []byte, after []byte, count int64) ([]ZMember, error)
+
+	// stream commands, id ranges are inclusive and count <= 0 means no limit.
+	// XAdd returns the id of the new entry and the number of entries trimmed, nil if the stream
+	// not exists and args.NoMkStream is set
+	XAdd(ctx context.Context, db int64, key []byte, args *StreamAddArgs, fields [][]byte) (*StreamID, int64, error)
+	XLen(ctx context.Context, db int64, key []byte) (int64, error)
+	XRange(ctx context.Context, db int64, key []byte, start StreamID, end StreamID, count int64, reverse bool) ([]StreamEntry, error)
+	XDel(ctx context.Context, db int64, key []byte, ids []StreamID) (int64, error)
+	XTrim(ctx context.Context, db int64, key []byte, trim *StreamTrim) (int64, error)
+	// XInfoStream returns the state of the stream, nil if the stream not exists
+	XInfoStream(ctx context.Context, db int64, key []byte) (*StreamInfo, error)
+	// XInfoGroups returns the consumer groups in ascending order of the names, ErrNoSuchKey if the stream not exists
+	XInfoGroups(ctx context.Context, db int64, key []byte) ([]StreamGroupInfo, error)
+	// XInfoConsumers returns the consumers of the group in ascending order of the names
+	XInfoConsumers(ctx context.Context, db int64, key []byte, group []byte) ([]StreamConsumerInfo, error)
+	// XGroupCreate creates a consumer group whose last delivered id is id, or the last id of the stream if id
+	// is nil, the stream is created if it not exists and mkStream is set, otherwise ErrNoSuchKey is returned
+	XGroupCreate(ctx context.Context, db int64, key []byte, group []byte, id *StreamID, entriesRead int64, mkStream bool) error
+	XGroupSetID(ctx context.Context, db int64, key []byte, group []byte, id *StreamID, entriesRead int64) error
+	XGroupDestroy(ctx context.Context, db int64, key []byte, group []byte) (bool, error)
+	XGroupCreateConsumer(ctx context.Context, db int64, key []byte, group []byte, consumer []byte) (bool, error)
+	// XGroupDelConsumer deletes the consumer and returns the number of its pending entries, false if it not exists
+	XGroupDelConsumer(ctx context.Context, db int64, key []byte, group []byte, consumer []byte) (int64, bool, error)
+	// XReadGroup delivers the entries never delivered to the group if after is nil, otherwise
+	// returns the pending entries of the consumer greater than after
+	XReadGroup(ctx context.Context, db int64, key []byte, group []byte, consumer []byte, after *StreamID, count int64, noAck bool) ([]StreamEntry, error)
+	XAck(ctx context.Context, db int64, key []byte, group []byte, ids []StreamID) (int64, error)
+	XPending(ctx context.Context, db int64, key []byte, group []byte) (*StreamPendingSummary, error)
+	XPendingRange(ctx context.Context, db int64, key []byte, group []byte, args *StreamPendingArgs) ([]StreamPendingEntry, error)
+	// XClaim transfers the pending entries to the consumer, the entries deleted from the stream are
+	// removed from the group instead. Fields of the entries are not returned if args.JustID is set
+	XClaim(ctx context.Context, db int64, key []byte, group []byte, consumer []byte, ids []StreamID, args *StreamClaimArgs) ([]StreamEntry, error)
+	// XAutoClaim claims at most count pending entries from start idle for at least minIdle, returns the id
+	// to continue with (0-0 once the end is reached), the entries claimed and the ids removed from the group
+	XAutoClaim(ctx context.Context, db int64, key []byte, group []byte, consumer []byte, start StreamID,
+		minIdle time.Duration, count int64, justID bool) (StreamID, []StreamEntry, []StreamID, error)
+
 	// server commands
-	GetTableInfo(ctx context.Context, db int64, tableName string) (*obkv.TableInfo, error)
+	GetTableInfo(ctx context.Context, db int64, tableName string) (*TableInfo, error)
+	// FlushTable deletes all data of db in the table, returns the number of rows deleted
+	FlushTable(ctx context.Context, db int64, tableName string) (int64, error)
 
 	// general interface for commands that can be executed on the observer side
 	ObServerCmd(ctx context.Context, tableName string, rowKey []*table.Column, plainText []byte) (string, error)
@@ -80,16 +298,23 @@ type Storage interface {
 	Close() error
 }
 
-func NewStorage(cfg Config) Storage {
-	return obkv.NewStorage(cfg.(*obkv.Config))
+// ExpireNotifier is implemented by the storages which remove expired keys themselves and can tell
+// which keys were removed. Storages expiring keys on the server side like obkv do not implement it,
+// their expired rows are never returned to modis, and the expired events are dropped from
+// notify-keyspace-events
+type ExpireNotifier interface {
+	// OnExpired sets fn to be called with every key found expired, after the key is removed
+	OnExpired(fn func(db int64, key []byte))
 }
 
 // Open a storage instance
-func Open(config *config.ObkvStorageConfig) (Storage, error) {
+func Open(config *config.StorageConfig) (Storage, error) {
 	fmt.Println("start to connect to database...")
-	log.Info("Storage", nil, "start to connect to database...")
-	cfg := NewConfig(config)
-	storage := NewStorage(cfg)
+	log.Info("Storage", nil, "start to connect to database...", log.String("backend", config.Backend))
+	storage, err := NewStorage(config)
+	if err != nil {
+		return nil, err
+	}
 	if err := storage.Initialize(); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,312 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/oceanbase/modis/protocol/resp"
+)
+
+// ErrStreamIDTooSmall is returned when XADD is given an id not greater than the last id of the stream
+var ErrStreamIDTooSmall = resp.ErrorReply("ERR The ID specified in XADD is equal or smaller than the target stream top item")
+
+// ErrStreamExhausted is returned when XADD generates an id after the max id
+var ErrStreamExhausted = resp.ErrorReply("ERR The stream has exhausted the last possible ID, unable to add more items")
+
+// ErrBusyGroup is returned when XGROUP CREATE is given the name of an existing consumer group
+var ErrBusyGroup = resp.ErrorReply("BUSYGROUP Consumer Group name already exists")
+
+// ErrNoGroup is returned when a command requires a consumer group which not exists,
+// the error replied differs among the commands
+var ErrNoGroup = errors.New("no such consumer group")
+
+// StreamID is the id of a stream entry, ids are ordered by Ms then Seq
+type StreamID struct {
+	Ms  uint64
+	Seq uint64
+}
+
+// MaxStreamID is the greatest id, "+" in a range
+var MaxStreamID = StreamID{Ms: math.MaxUint64, Seq: math.MaxUint64}
+
+// String formats the id as "<ms>-<seq>"
+func (id StreamID) String() string {
+	return strconv.FormatUint(id.Ms, 10) + "-" + strconv.FormatUint(id.Seq, 10)
+}
+
+// Compare returns -1, 0 or 1 if id is less than, equal to or greater than other
+func (id StreamID) Compare(other StreamID) int {
+	switch {
+	case id.Ms < other.Ms:
+		return -1
+	case id.Ms > other.Ms:
+		return 1
+	case id.Seq < other.Seq:
+		return -1
+	case id.Seq > other.Seq:
+		return 1
+	}
+	return 0
+}
+
+func (id StreamID) IsZero() bool {
+	return id.Ms == 0 && id.Seq == 0
+}
+
+// Next returns the least id greater than id, false if id is the max
+func (id StreamID) Next() (StreamID, bool) {
+	if id.Seq < math.MaxUint64 {
+		return StreamID{Ms: id.Ms, Seq: id.Seq + 1}, true
+	}
+	if id.Ms < math.MaxUint64 {
+		return StreamID{Ms: id.Ms + 1}, true
+	}
+	return id, false
+}
+
+// Prev returns the greatest id less than id, false if id is 0-0
+func (id StreamID) Prev() (StreamID, bool) {
+	if id.Seq > 0 {
+		return StreamID{Ms: id.Ms, Seq: id.Seq - 1}, true
+	}
+	if id.Ms > 0 {
+		return StreamID{Ms: id.Ms - 1, Seq: math.MaxUint64}, true
+	}
+	return id, false
+}
+
+// StreamEntry is an entry of a stream, Fields holds the field value pairs.
+// Fields is nil for an entry deleted from the stream but still pending in a consumer group
+type StreamEntry struct {
+	ID     StreamID
+	Fields [][]byte
+}
+
+// StreamTrim tells how a stream is trimmed, the entries are evicted from the oldest one
+// while the stream is longer than MaxLen, or while they are less than MinID if ByMinID is set.
+// At most Limit entries are evicted if Limit > 0
+type StreamTrim struct {
+	MaxLen  int64
+	MinID   StreamID
+	ByMinID bool
+	Limit   int64
+}
+
+// Evict reports whether the oldest entry id is evicted from a stream of length entries
+func (t *StreamTrim) Evict(id StreamID, length int64) bool {
+	if t.ByMinID {
+		return id.Compare(t.MinID) < 0
+	}
+	return length > t.MaxLen
+}
+
+// StreamAddArgs are the arguments of XADD
+type StreamAddArgs struct {
+	// ID is the id of the new entry, the ms part only if AutoSeq is set, ignored if AutoID is set
+	ID      StreamID
+	AutoID  bool
+	AutoSeq bool
+	// the stream is not created if it not exists
+	NoMkStream bool
+	// nil not to trim the stream
+	Trim *StreamTrim
+}
+
+// NextID returns the id of the entry added after last at now
+func (args *StreamAddArgs) NextID(last StreamID, now time.Time) (StreamID, error) {
+	switch {
+	case args.AutoID:
+		if ms := uint64(now.UnixMilli()); ms > last.Ms {
+			return StreamID{Ms: ms}, nil
+		}
+		next, ok := last.Next()
+		if !ok {
+			return StreamID{}, ErrStreamExhausted
+		}
+		return next, nil
+	case args.AutoSeq:
+		if args.ID.Ms > last.Ms {
+			return StreamID{Ms: args.ID.Ms}, nil
+		}
+		if args.ID.Ms < last.Ms || last.Seq == math.MaxUint64 {
+			return StreamID{}, ErrStreamIDTooSmall
+		}
+		return StreamID{Ms: last.Ms, Seq: last.Seq + 1}, nil
+	default:
+		if args.ID.Compare(last) <= 0 {
+			return StreamID{}, ErrStreamIDTooSmall
+		}
+		return args.ID, nil
+	}
+}
+
+// StreamMeta is the metadata of a stream
+type StreamMeta struct {
+	Length int64
+	// the id of the first entry, 0-0 if the stream is empty
+	FirstID StreamID
+	// the last id generated, the entry may have been deleted
+	LastID StreamID
+	// the greatest id deleted by XDEL
+	MaxDeletedID StreamID
+	// the number of entries ever added
+	EntriesAdded int64
+}
+
+// hasTombstones reports whether an entry not less than start has been deleted by XDEL
+func (m *StreamMeta) hasTombstones(start StreamID) bool {
+	if m.Length == 0 || m.MaxDeletedID.IsZero() || m.FirstID.Compare(m.MaxDeletedID) > 0 {
+		return false
+	}
+	return start.Compare(m.MaxDeletedID) <= 0
+}
+
+// EntriesReadAt estimates the number of entries added up to id, -1 if it can not be told
+// because of the entries deleted by XDEL, the same as redis does
+func (m *StreamMeta) EntriesReadAt(id StreamID) int64 {
+	if m.EntriesAdded == 0 {
+		return 0
+	}
+	cmpLast := id.Compare(m.LastID)
+	if m.Length == 0 && cmpLast <= 0 {
+		return m.EntriesAdded
+	}
+	if cmpLast == 0 {
+		return m.EntriesAdded
+	} else if cmpLast > 0 {
+		return -1
+	}
+	if m.MaxDeletedID.IsZero() || m.MaxDeletedID.Compare(m.FirstID) < 0 {
+		// no entries in the stream have been deleted
+		switch id.Compare(m.FirstID) {
+		case -1:
+			return m.EntriesAdded - m.Length
+		case 0:
+			return m.EntriesAdded - m.Length + 1
+		}
+	}
+	return -1
+}
+
+// AdvanceEntriesRead returns the counter of the entries read by a consumer group once
+// it reads the entry id, entriesRead is the counter before, -1 if it is unknown
+func (m *StreamMeta) AdvanceEntriesRead(entriesRead int64, id StreamID) int64 {
+	if entriesRead >= 0 && !m.hasTombstones(id) {
+		return entriesRead + 1
+	}
+	if m.EntriesAdded > 0 {
+		return m.EntriesReadAt(id)
+	}
+	return entriesRead
+}
+
+// Lag returns the number of entries not read yet by a consumer group, false if it can not be told
+func (m *StreamMeta) Lag(lastDelivered StreamID, entriesRead int64) (int64, bool) {
+	if m.EntriesAdded == 0 {
+		return 0, true
+	}
+	if entriesRead >= 0 && !m.hasTombstones(lastDelivered) {
+		return m.EntriesAdded - entriesRead, true
+	}
+	if read := m.EntriesReadAt(lastDelivered); read >= 0 {
+		return m.EntriesAdded - read, true
+	}
+	return 0, false
+}
+
+// StreamInfo is the state of a stream replied by XINFO STREAM
+type StreamInfo struct {
+	StreamMeta
+	Groups int64
+	// nil if the stream is empty
+	FirstEntry *StreamEntry
+	LastEntry  *StreamEntry
+}
+
+// StreamGroupInfo is the state of a consumer group replied by XINFO GROUPS
+type StreamGroupInfo struct {
+	Name            []byte
+	Consumers       int64
+	Pending         int64
+	LastDeliveredID StreamID
+	// -1 if it is unknown
+	EntriesRead int64
+	Lag         int64
+	LagKnown    bool
+}
+
+// StreamConsumerInfo is the state of a consumer replied by XINFO CONSUMERS
+type StreamConsumerInfo struct {
+	Name    []byte
+	Pending int64
+	// the last time the consumer tried to read or claim entries
+	SeenTime time.Time
+	// the last time the consumer read or claimed entries, zero if never
+	ActiveTime time.Time
+}
+
+// StreamPendingEntry is an entry delivered to a consumer but not acknowledged yet
+type StreamPendingEntry struct {
+	ID           StreamID
+	Consumer     []byte
+	DeliveryTime time.Time
+	Deliveries   int64
+}
+
+// StreamPendingSummary summarizes the pending entries of a consumer group
+type StreamPendingSummary struct {
+	Count int64
+	MinID StreamID
+	MaxID StreamID
+	// the consumers with pending entries, in ascending order of the names
+	Consumers []StreamConsumerPending
+}
+
+// StreamConsumerPending is the number of pending entries of a consumer
+type StreamConsumerPending struct {
+	Name  []byte
+	Count int64
+}
+
+// StreamPendingArgs selects the pending entries of XPENDING
+type StreamPendingArgs struct {
+	Start StreamID
+	End   StreamID
+	Count int64
+	// nil for the entries of all consumers
+	Consumer []byte
+	// only the entries delivered at least MinIdle ago are selected
+	MinIdle time.Duration
+}
+
+// StreamClaimArgs are the options of XCLAIM
+type StreamClaimArgs struct {
+	MinIdle time.Duration
+	// set as the last delivery time of the claimed entries
+	DeliveryTime time.Time
+	// set as the delivery counter of the claimed entries, -1 to increment it unless JustID is set
+	RetryCount int64
+	// the ids not pending are claimed as well if they are in the stream
+	Force  bool
+	JustID bool
+	// the last delivered id of the group is updated if LastID is greater
+	LastID StreamID
+}
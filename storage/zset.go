@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"math"
+	"sort"
+)
+
+// CombineZSets computes the union of the sorted sets, or the intersection if union is not set. The
+// scores are multiplied by the weights, which default to 1, and the scores of a member are combined
+// by aggregate, a NaN score becomes 0 like redis
+func CombineZSets(zsets []map[string]float64, union bool, weights []float64, aggregate Aggregate) map[string]float64 {
+	var res map[string]float64
+	for i, zset := range zsets {
+		weight := 1.0
+		if i < len(weights) {
+			weight = weights[i]
+		}
+		if i == 0 {
+			res = make(map[string]float64, len(zset))
+		}
+		next := make(map[string]float64)
+		if union {
+			for member, score := range res {
+				next[member] = score
+			}
+		}
+		for member, score := range zset {
+			score = weightedScore(score, weight)
+			old, ok := res[member]
+			if i == 0 || !ok {
+				if i == 0 || union {
+					next[member] = score
+				}
+				continue
+			}
+			next[member] = aggregateScore(aggregate, old, score)
+		}
+		res = next
+	}
+	return res
+}
+
+// DiffZSets returns the members of the first sorted set not in the others
+func DiffZSets(zsets []map[string]float64) map[string]float64 {
+	res := make(map[string]float64)
+	if len(zsets) == 0 {
+		return res
+	}
+	for member, score := range zsets[0] {
+		found := false
+		for _, zset := range zsets[1:] {
+			if _, found = zset[member]; found {
+				break
+			}
+		}
+		if !found {
+			res[member] = score
+		}
+	}
+	return res
+}
+
+// SortedZMembers returns the members of the sorted set ordered by score, then by member
+func SortedZMembers(zset map[string]float64) []ZMember {
+	res := make([]ZMember, 0, len(zset))
+	for member, score := range zset {
+		res = append(res, ZMember{Member: []byte(member), Score: score})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Score != res[j].Score {
+			return res[i].Score < res[j].Score
+		}
+		return string(res[i].Member) < string(res[j].Member)
+	})
+	return res
+}
+
+func weightedScore(score float64, weight float64) float64 {
+	res := score * weight
+	if math.IsNaN(res) {
+		return 0
+	}
+	return res
+}
+
+func aggregateScore(aggregate Aggregate, a float64, b float64) float64 {
+	switch aggregate {
+	case AggregateMin:
+		return math.Min(a, b)
+	case AggregateMax:
+		return math.Max(a, b)
+	}
+	res := a + b
+	if math.IsNaN(res) {
+		return 0
+	}
+	return res
+}
@@ -18,8 +18,10 @@ package hash
 
 import (
 	"context"
+	"strconv"
 	"testing"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/oceanbase/modis/test"
@@ -336,3 +338,58 @@ func TestHash_HVals(t *testing.T) {
 	assert.Equal(t, nil, mErr)
 	assert.Equal(t, rVal, mVal)
 }
+
+// hscanAll iterates the hash with HSCAN and returns the fields with their values
+func hscanAll(t *testing.T, cli *redis.Client, key string, match string, count int64) map[string]string {
+	res := make(map[string]string)
+	var cursor uint64
+	for {
+		page, next, err := cli.HScan(context.TODO(), key, cursor, match, count).Result()
+		assert.Equal(t, nil, err)
+		if err != nil {
+			return nil
+		}
+		for i := 0; i+1 < len(page); i += 2 {
+			res[page[i]] = page[i+1]
+		}
+		if cursor = next; cursor == 0 {
+			return res
+		}
+	}
+}
+
+func TestHash_HScan(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisHashTableName)
+
+	// empty
+	assert.Equal(t, hscanAll(t, rCli, "myhash", "", 10), hscanAll(t, mCli, "myhash", "", 10))
+
+	for i := 0; i < 50; i++ {
+		field, value := "field"+strconv.Itoa(i), "value"+strconv.Itoa(i)
+		assert.Equal(t, nil, rCli.HSet(context.TODO(), "myhash", field, value).Err())
+		assert.Equal(t, nil, mCli.HSet(context.TODO(), "myhash", field, value).Err())
+	}
+	for _, count := range []int64{1, 7, 10, 100} {
+		assert.Equal(t, hscanAll(t, rCli, "myhash", "", count), hscanAll(t, mCli, "myhash", "", count))
+		assert.Equal(t, hscanAll(t, rCli, "myhash", "field1*", count), hscanAll(t, mCli, "myhash", "field1*", count))
+	}
+	assert.Equal(t, 50, len(hscanAll(t, mCli, "myhash", "", 7)))
+
+	// a cursor is bound to its key
+	_, cursor, err := mCli.HScan(context.TODO(), "myhash", 0, "", 5).Result()
+	assert.Equal(t, nil, err)
+	err = mCli.HScan(context.TODO(), "otherhash", cursor, "", 5).Err()
+	assert.Equal(t, "ERR invalid cursor", err.Error())
+
+	// novalues
+	page, err := mCli.Do(context.TODO(), "hscan", "myhash", "0", "match", "field1", "count", "100", "novalues").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []interface{}{"0", []interface{}{"field1"}}, page)
+
+	// wrong type
+	assert.Equal(t, nil, rCli.Set(context.TODO(), "mystring", "value", 0).Err())
+	assert.Equal(t, nil, mCli.Set(context.TODO(), "mystring", "value", 0).Err())
+	_, _, rErr := rCli.HScan(context.TODO(), "mystring", 0, "", 10).Result()
+	_, _, mErr := mCli.HScan(context.TODO(), "mystring", 0, "", 10).Result()
+	assert.Equal(t, rErr, mErr)
+}
@@ -18,6 +18,9 @@ package key
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -308,11 +311,100 @@ func TestKey_Type(t *testing.T) {
 	assert.EqualValues(t, delRedis, delModis)
 }
 
+func TestKey_WrongType(t *testing.T) {
+	key := "Key"
+	dstKey := "DstKey"
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
+
+	hsetRedis, err := rCli.HSet(context.TODO(), key, "Field", "Value").Result()
+	assert.Equal(t, nil, err)
+	hsetModis, err := mCli.HSet(context.TODO(), key, "Field", "Value").Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, hsetRedis, hsetModis)
+
+	// commands against a hash
+	cmds := [][]interface{}{
+		{"get", key},
+		{"append", key, "Value"},
+		{"incr", key},
+		{"lpush", key, "Element"},
+		{"lrange", key, 0, -1},
+		{"llen", key},
+		{"lindex", key, 0},
+		{"lpop", key},
+		{"sadd", key, "Member"},
+		{"smembers", key},
+		{"scard", key},
+		{"sismember", key, "Member"},
+		{"zadd", key, 1, "Member"},
+		{"zrange", key, 0, -1},
+		{"zcard", key},
+		{"zscore", key, "Member"},
+		{"zrangebyscore", key, "-inf", "+inf"},
+	}
+	for _, cmd := range cmds {
+		_, errRedis := rCli.Do(context.TODO(), cmd...).Result()
+		_, errModis := mCli.Do(context.TODO(), cmd...).Result()
+		assert.NotEqual(t, nil, errModis, cmd[0])
+		assert.EqualValues(t, errRedis, errModis, cmd[0])
+	}
+	typeRedis, err := rCli.Type(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	typeModis, err := mCli.Type(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, typeRedis, typeModis)
+
+	// setnx does nothing, set overwrites
+	setnxRedis, err := rCli.SetNX(context.TODO(), key, "Value", 0).Result()
+	assert.Equal(t, nil, err)
+	setnxModis, err := mCli.SetNX(context.TODO(), key, "Value", 0).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, setnxRedis, setnxModis)
+	setRedis, err := rCli.Set(context.TODO(), key, "Value", 0).Result()
+	assert.Equal(t, nil, err)
+	setModis, err := mCli.Set(context.TODO(), key, "Value", 0).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, setRedis, setModis)
+	typeRedis, err = rCli.Type(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	typeModis, err = mCli.Type(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, typeRedis, typeModis)
+	for _, cmd := range [][]interface{}{{"hlen", key}, {"hget", key, "Field"}, {"hgetall", key}, {"hkeys", key}} {
+		resRedis, err := rCli.Do(context.TODO(), cmd...).Result()
+		resModis, errModis := mCli.Do(context.TODO(), cmd...).Result()
+		assert.EqualValues(t, err, errModis, cmd[0])
+		assert.EqualValues(t, resRedis, resModis, cmd[0])
+	}
+
+	// store commands overwrite the destination
+	saddRedis, err := rCli.SAdd(context.TODO(), dstKey, "Member").Result()
+	assert.Equal(t, nil, err)
+	saddModis, err := mCli.SAdd(context.TODO(), dstKey, "Member").Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, saddRedis, saddModis)
+	zaddRedis, err := rCli.ZAdd(context.TODO(), "ZKey", &redis.Z{Score: 1, Member: "Member"}).Result()
+	assert.Equal(t, nil, err)
+	zaddModis, err := mCli.ZAdd(context.TODO(), "ZKey", &redis.Z{Score: 1, Member: "Member"}).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, zaddRedis, zaddModis)
+	storeRedis, err := rCli.ZUnionStore(context.TODO(), dstKey, &redis.ZStore{Keys: []string{"ZKey"}}).Result()
+	assert.Equal(t, nil, err)
+	storeModis, err := mCli.ZUnionStore(context.TODO(), dstKey, &redis.ZStore{Keys: []string{"ZKey"}}).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, storeRedis, storeModis)
+	typeRedis, err = rCli.Type(context.TODO(), dstKey).Result()
+	assert.Equal(t, nil, err)
+	typeModis, err = mCli.Type(context.TODO(), dstKey).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, typeRedis, typeModis)
+}
+
 func TestKey_Expire(t *testing.T) {
 	key := "Key"
 	value := "Value"
-	// field := "Field"
-	// member := "Member"
+	field := "Field"
+	member := "Member"
 	expiration := time.Second * 1
 	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
 
@@ -337,52 +429,88 @@ func TestKey_Expire(t *testing.T) {
 	time.Sleep(expiration)
 	existRedis, err := rCli.Exists(context.TODO(), key).Result()
 	assert.Equal(t, nil, err)
-	existModis, err := rCli.Exists(context.TODO(), key).Result()
+	existModis, err := mCli.Exists(context.TODO(), key).Result()
 	assert.Equal(t, nil, err)
 	assert.EqualValues(t, existRedis, existModis)
 
-	// // hash
-	// hsetRedis, err := rCli.HSet(context.TODO(), key, field, value).Result()
-	// assert.Equal(t, nil, err)
-	// hsetModis, err := mCli.HSet(context.TODO(), key, field, value).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, hsetRedis, hsetModis)
-	// expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
-	// assert.Equal(t, nil, err)
-	// expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, expireRedis, expireModis)
-	// time.Sleep(expiration)
-	// hexistRedis, err := rCli.HExists(context.TODO(), key, field).Result()
-	// assert.Equal(t, nil, err)
-	// hexistModis, err := rCli.HExists(context.TODO(), key, field).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, hexistRedis, hexistModis)
-
-	// // set
-	// saddRedis, err := rCli.SAdd(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// saddModis, err := mCli.SAdd(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, saddRedis, saddModis)
-	// expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
-	// assert.Equal(t, nil, err)
-	// expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, expireRedis, expireModis)
-	// time.Sleep(expiration)
-	// ismemRedis, err := rCli.SIsMember(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// ismemModis, err := rCli.SIsMember(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, ismemRedis, ismemModis)
+	// hash
+	hsetRedis, err := rCli.HSet(context.TODO(), key, field, value).Result()
+	assert.Equal(t, nil, err)
+	hsetModis, err := mCli.HSet(context.TODO(), key, field, value).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, hsetRedis, hsetModis)
+	expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	time.Sleep(expiration)
+	hexistRedis, err := rCli.HExists(context.TODO(), key, field).Result()
+	assert.Equal(t, nil, err)
+	hexistModis, err := mCli.HExists(context.TODO(), key, field).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, hexistRedis, hexistModis)
+
+	// set
+	saddRedis, err := rCli.SAdd(context.TODO(), key, member).Result()
+	assert.Equal(t, nil, err)
+	saddModis, err := mCli.SAdd(context.TODO(), key, member).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, saddRedis, saddModis)
+	expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	time.Sleep(expiration)
+	ismemRedis, err := rCli.SIsMember(context.TODO(), key, member).Result()
+	assert.Equal(t, nil, err)
+	ismemModis, err := mCli.SIsMember(context.TODO(), key, member).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, ismemRedis, ismemModis)
+
+	// list
+	rpushRedis, err := rCli.RPush(context.TODO(), key, value).Result()
+	assert.Equal(t, nil, err)
+	rpushModis, err := mCli.RPush(context.TODO(), key, value).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, rpushRedis, rpushModis)
+	expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	time.Sleep(expiration)
+	llenRedis, err := rCli.LLen(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	llenModis, err := mCli.LLen(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, llenRedis, llenModis)
+
+	// zset
+	zaddRedis, err := rCli.ZAdd(context.TODO(), key, &redis.Z{Score: 1, Member: member}).Result()
+	assert.Equal(t, nil, err)
+	zaddModis, err := mCli.ZAdd(context.TODO(), key, &redis.Z{Score: 1, Member: member}).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, zaddRedis, zaddModis)
+	expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	time.Sleep(expiration)
+	zcardRedis, err := rCli.ZCard(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	zcardModis, err := mCli.ZCard(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, zcardRedis, zcardModis)
 }
 
 func TestKey_ExpireAt(t *testing.T) {
 	key := "Key"
 	value := "Value"
-	// field := "Field"
-	// member := "Member"
+	field := "Field"
+	member := "Member"
 	expiration := 1 * time.Second
 	tm := time.Now().Add(expiration)
 	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
@@ -408,52 +536,88 @@ func TestKey_ExpireAt(t *testing.T) {
 	time.Sleep(expiration)
 	existRedis, err := rCli.Exists(context.TODO(), key).Result()
 	assert.Equal(t, nil, err)
-	existModis, err := rCli.Exists(context.TODO(), key).Result()
+	existModis, err := mCli.Exists(context.TODO(), key).Result()
 	assert.Equal(t, nil, err)
 	assert.EqualValues(t, existRedis, existModis)
 
-	// // hash
-	// hsetRedis, err := rCli.HSet(context.TODO(), key, field, value).Result()
-	// assert.Equal(t, nil, err)
-	// hsetModis, err := mCli.HSet(context.TODO(), key, field, value).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, hsetRedis, hsetModis)
-	// expireRedis, err = rCli.ExpireAt(context.TODO(), key, tm).Result()
-	// assert.Equal(t, nil, err)
-	// expireModis, err = mCli.ExpireAt(context.TODO(), key, tm).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, expireRedis, expireModis)
-	// time.Sleep(expiration)
-	// hexistRedis, err := rCli.HExists(context.TODO(), key, field).Result()
-	// assert.Equal(t, nil, err)
-	// hexistModis, err := rCli.HExists(context.TODO(), key, field).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, hexistRedis, hexistModis)
-
-	// // set
-	// saddRedis, err := rCli.SAdd(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// saddModis, err := mCli.SAdd(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, saddRedis, saddModis)
-	// expireRedis, err = rCli.ExpireAt(context.TODO(), key, tm).Result()
-	// assert.Equal(t, nil, err)
-	// expireModis, err = mCli.ExpireAt(context.TODO(), key, tm).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, expireRedis, expireModis)
-	// time.Sleep(expiration)
-	// ismemRedis, err := rCli.SIsMember(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// ismemModis, err := rCli.SIsMember(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, ismemRedis, ismemModis)
+	// hash
+	hsetRedis, err := rCli.HSet(context.TODO(), key, field, value).Result()
+	assert.Equal(t, nil, err)
+	hsetModis, err := mCli.HSet(context.TODO(), key, field, value).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, hsetRedis, hsetModis)
+	expireRedis, err = rCli.ExpireAt(context.TODO(), key, tm).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.ExpireAt(context.TODO(), key, tm).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	time.Sleep(expiration)
+	hexistRedis, err := rCli.HExists(context.TODO(), key, field).Result()
+	assert.Equal(t, nil, err)
+	hexistModis, err := mCli.HExists(context.TODO(), key, field).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, hexistRedis, hexistModis)
+
+	// set
+	saddRedis, err := rCli.SAdd(context.TODO(), key, member).Result()
+	assert.Equal(t, nil, err)
+	saddModis, err := mCli.SAdd(context.TODO(), key, member).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, saddRedis, saddModis)
+	expireRedis, err = rCli.ExpireAt(context.TODO(), key, tm).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.ExpireAt(context.TODO(), key, tm).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	time.Sleep(expiration)
+	ismemRedis, err := rCli.SIsMember(context.TODO(), key, member).Result()
+	assert.Equal(t, nil, err)
+	ismemModis, err := mCli.SIsMember(context.TODO(), key, member).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, ismemRedis, ismemModis)
+
+	// list
+	rpushRedis, err := rCli.RPush(context.TODO(), key, value).Result()
+	assert.Equal(t, nil, err)
+	rpushModis, err := mCli.RPush(context.TODO(), key, value).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, rpushRedis, rpushModis)
+	expireRedis, err = rCli.ExpireAt(context.TODO(), key, tm).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.ExpireAt(context.TODO(), key, tm).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	time.Sleep(expiration)
+	llenRedis, err := rCli.LLen(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	llenModis, err := mCli.LLen(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, llenRedis, llenModis)
+
+	// zset
+	zaddRedis, err := rCli.ZAdd(context.TODO(), key, &redis.Z{Score: 1, Member: member}).Result()
+	assert.Equal(t, nil, err)
+	zaddModis, err := mCli.ZAdd(context.TODO(), key, &redis.Z{Score: 1, Member: member}).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, zaddRedis, zaddModis)
+	expireRedis, err = rCli.ExpireAt(context.TODO(), key, tm).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.ExpireAt(context.TODO(), key, tm).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	time.Sleep(expiration)
+	zcardRedis, err := rCli.ZCard(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	zcardModis, err := mCli.ZCard(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, zcardRedis, zcardModis)
 }
 
 func TestKey_PExpire(t *testing.T) {
 	key := "Key"
 	value := "Value"
-	// field := "Field"
-	// member := "Member"
+	field := "Field"
+	member := "Member"
 	expiration := 1000 * time.Microsecond
 	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
 
@@ -478,52 +642,88 @@ func TestKey_PExpire(t *testing.T) {
 	time.Sleep(expiration)
 	existRedis, err := rCli.Exists(context.TODO(), key).Result()
 	assert.Equal(t, nil, err)
-	existModis, err := rCli.Exists(context.TODO(), key).Result()
+	existModis, err := mCli.Exists(context.TODO(), key).Result()
 	assert.Equal(t, nil, err)
 	assert.EqualValues(t, existRedis, existModis)
 
-	// // hash
-	// hsetRedis, err := rCli.HSet(context.TODO(), key, field, value).Result()
-	// assert.Equal(t, nil, err)
-	// hsetModis, err := mCli.HSet(context.TODO(), key, field, value).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, hsetRedis, hsetModis)
-	// expireRedis, err = rCli.PExpire(context.TODO(), key, expiration).Result()
-	// assert.Equal(t, nil, err)
-	// expireModis, err = mCli.PExpire(context.TODO(), key, expiration).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, expireRedis, expireModis)
-	// time.Sleep(expiration)
-	// hexistRedis, err := rCli.HExists(context.TODO(), key, field).Result()
-	// assert.Equal(t, nil, err)
-	// hexistModis, err := rCli.HExists(context.TODO(), key, field).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, hexistRedis, hexistModis)
-
-	// // set
-	// saddRedis, err := rCli.SAdd(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// saddModis, err := mCli.SAdd(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, saddRedis, saddModis)
-	// expireRedis, err = rCli.PExpire(context.TODO(), key, expiration).Result()
-	// assert.Equal(t, nil, err)
-	// expireModis, err = mCli.PExpire(context.TODO(), key, expiration).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, expireRedis, expireModis)
-	// time.Sleep(expiration)
-	// ismemRedis, err := rCli.SIsMember(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// ismemModis, err := rCli.SIsMember(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, ismemRedis, ismemModis)
+	// hash
+	hsetRedis, err := rCli.HSet(context.TODO(), key, field, value).Result()
+	assert.Equal(t, nil, err)
+	hsetModis, err := mCli.HSet(context.TODO(), key, field, value).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, hsetRedis, hsetModis)
+	expireRedis, err = rCli.PExpire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.PExpire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	time.Sleep(expiration)
+	hexistRedis, err := rCli.HExists(context.TODO(), key, field).Result()
+	assert.Equal(t, nil, err)
+	hexistModis, err := mCli.HExists(context.TODO(), key, field).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, hexistRedis, hexistModis)
+
+	// set
+	saddRedis, err := rCli.SAdd(context.TODO(), key, member).Result()
+	assert.Equal(t, nil, err)
+	saddModis, err := mCli.SAdd(context.TODO(), key, member).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, saddRedis, saddModis)
+	expireRedis, err = rCli.PExpire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.PExpire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	time.Sleep(expiration)
+	ismemRedis, err := rCli.SIsMember(context.TODO(), key, member).Result()
+	assert.Equal(t, nil, err)
+	ismemModis, err := mCli.SIsMember(context.TODO(), key, member).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, ismemRedis, ismemModis)
+
+	// list
+	rpushRedis, err := rCli.RPush(context.TODO(), key, value).Result()
+	assert.Equal(t, nil, err)
+	rpushModis, err := mCli.RPush(context.TODO(), key, value).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, rpushRedis, rpushModis)
+	expireRedis, err = rCli.PExpire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.PExpire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	time.Sleep(expiration)
+	llenRedis, err := rCli.LLen(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	llenModis, err := mCli.LLen(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, llenRedis, llenModis)
+
+	// zset
+	zaddRedis, err := rCli.ZAdd(context.TODO(), key, &redis.Z{Score: 1, Member: member}).Result()
+	assert.Equal(t, nil, err)
+	zaddModis, err := mCli.ZAdd(context.TODO(), key, &redis.Z{Score: 1, Member: member}).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, zaddRedis, zaddModis)
+	expireRedis, err = rCli.PExpire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.PExpire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	time.Sleep(expiration)
+	zcardRedis, err := rCli.ZCard(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	zcardModis, err := mCli.ZCard(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, zcardRedis, zcardModis)
 }
 
 func TestKey_PExpireAt(t *testing.T) {
 	key := "Key"
 	value := "Value"
-	// field := "Field"
-	// member := "Member"
+	field := "Field"
+	member := "Member"
 	expiration := 1000 * time.Microsecond
 	tm := time.Now().Add(expiration)
 	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
@@ -549,152 +749,244 @@ func TestKey_PExpireAt(t *testing.T) {
 	time.Sleep(expiration)
 	existRedis, err := rCli.Exists(context.TODO(), key).Result()
 	assert.Equal(t, nil, err)
-	existModis, err := rCli.Exists(context.TODO(), key).Result()
+	existModis, err := mCli.Exists(context.TODO(), key).Result()
 	assert.Equal(t, nil, err)
 	assert.EqualValues(t, existRedis, existModis)
 
-	// // hash
-	// hsetRedis, err := rCli.HSet(context.TODO(), key, field, value).Result()
-	// assert.Equal(t, nil, err)
-	// hsetModis, err := mCli.HSet(context.TODO(), key, field, value).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, hsetRedis, hsetModis)
-	// expireRedis, err = rCli.PExpireAt(context.TODO(), key, tm).Result()
-	// assert.Equal(t, nil, err)
-	// expireModis, err = mCli.PExpireAt(context.TODO(), key, tm).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, expireRedis, expireModis)
-	// time.Sleep(expiration)
-	// hexistRedis, err := rCli.HExists(context.TODO(), key, field).Result()
-	// assert.Equal(t, nil, err)
-	// hexistModis, err := rCli.HExists(context.TODO(), key, field).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, hexistRedis, hexistModis)
-
-	// // set
-	// saddRedis, err := rCli.SAdd(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// saddModis, err := mCli.SAdd(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, saddRedis, saddModis)
-	// expireRedis, err = rCli.PExpireAt(context.TODO(), key, tm).Result()
-	// assert.Equal(t, nil, err)
-	// expireModis, err = mCli.PExpireAt(context.TODO(), key, tm).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, expireRedis, expireModis)
-	// time.Sleep(expiration)
-	// ismemRedis, err := rCli.SIsMember(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// ismemModis, err := rCli.SIsMember(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, ismemRedis, ismemModis)
-}
-
-func TestKey_Persist(t *testing.T) {
-	key := "Key"
-	value := "Value"
-	// field := "Field"
-	// member := "Member"
-	expiration := time.Second * 1
-	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
-
-	// empty
-	persistRedis, err := rCli.Persist(context.TODO(), key).Result()
+	// hash
+	hsetRedis, err := rCli.HSet(context.TODO(), key, field, value).Result()
 	assert.Equal(t, nil, err)
-	persistModis, err := mCli.Persist(context.TODO(), key).Result()
+	hsetModis, err := mCli.HSet(context.TODO(), key, field, value).Result()
 	assert.Equal(t, nil, err)
-	assert.EqualValues(t, persistRedis, persistModis)
+	assert.EqualValues(t, hsetRedis, hsetModis)
+	expireRedis, err = rCli.PExpireAt(context.TODO(), key, tm).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.PExpireAt(context.TODO(), key, tm).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	time.Sleep(expiration)
+	hexistRedis, err := rCli.HExists(context.TODO(), key, field).Result()
+	assert.Equal(t, nil, err)
+	hexistModis, err := mCli.HExists(context.TODO(), key, field).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, hexistRedis, hexistModis)
 
-	// string
-	setRedis, err := rCli.Set(context.TODO(), key, value, 0).Result()
+	// set
+	saddRedis, err := rCli.SAdd(context.TODO(), key, member).Result()
 	assert.Equal(t, nil, err)
-	setModis, err := mCli.Set(context.TODO(), key, value, 0).Result()
+	saddModis, err := mCli.SAdd(context.TODO(), key, member).Result()
 	assert.Equal(t, nil, err)
-	assert.EqualValues(t, setRedis, setModis)
-	expireRedis, err := rCli.Expire(context.TODO(), key, expiration).Result()
+	assert.EqualValues(t, saddRedis, saddModis)
+	expireRedis, err = rCli.PExpireAt(context.TODO(), key, tm).Result()
 	assert.Equal(t, nil, err)
-	expireModis, err := mCli.Expire(context.TODO(), key, expiration).Result()
+	expireModis, err = mCli.PExpireAt(context.TODO(), key, tm).Result()
 	assert.Equal(t, nil, err)
 	assert.EqualValues(t, expireRedis, expireModis)
-	persistRedis, err = rCli.Persist(context.TODO(), key).Result()
+	time.Sleep(expiration)
+	ismemRedis, err := rCli.SIsMember(context.TODO(), key, member).Result()
 	assert.Equal(t, nil, err)
-	persistModis, err = mCli.Persist(context.TODO(), key).Result()
+	ismemModis, err := mCli.SIsMember(context.TODO(), key, member).Result()
 	assert.Equal(t, nil, err)
-	assert.EqualValues(t, persistRedis, persistModis)
-	time.Sleep(expiration)
-	existRedis, err := rCli.Exists(context.TODO(), key).Result()
+	assert.EqualValues(t, ismemRedis, ismemModis)
+
+	// list
+	rpushRedis, err := rCli.RPush(context.TODO(), key, value).Result()
 	assert.Equal(t, nil, err)
-	existModis, err := rCli.Exists(context.TODO(), key).Result()
+	rpushModis, err := mCli.RPush(context.TODO(), key, value).Result()
 	assert.Equal(t, nil, err)
-	assert.EqualValues(t, existRedis, existModis)
-	delRedis, err := rCli.Del(context.TODO(), key).Result()
+	assert.EqualValues(t, rpushRedis, rpushModis)
+	expireRedis, err = rCli.PExpireAt(context.TODO(), key, tm).Result()
 	assert.Equal(t, nil, err)
-	delModis, err := mCli.Del(context.TODO(), key).Result()
+	expireModis, err = mCli.PExpireAt(context.TODO(), key, tm).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	time.Sleep(expiration)
+	llenRedis, err := rCli.LLen(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	llenModis, err := mCli.LLen(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, llenRedis, llenModis)
+
+	// zset
+	zaddRedis, err := rCli.ZAdd(context.TODO(), key, &redis.Z{Score: 1, Member: member}).Result()
+	assert.Equal(t, nil, err)
+	zaddModis, err := mCli.ZAdd(context.TODO(), key, &redis.Z{Score: 1, Member: member}).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, zaddRedis, zaddModis)
+	expireRedis, err = rCli.PExpireAt(context.TODO(), key, tm).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.PExpireAt(context.TODO(), key, tm).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	time.Sleep(expiration)
+	zcardRedis, err := rCli.ZCard(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	zcardModis, err := mCli.ZCard(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, zcardRedis, zcardModis)
+}
+
+func TestKey_Persist(t *testing.T) {
+	key := "Key"
+	value := "Value"
+	field := "Field"
+	member := "Member"
+	expiration := time.Second * 1
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
+
+	// empty
+	persistRedis, err := rCli.Persist(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	persistModis, err := mCli.Persist(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, persistRedis, persistModis)
+
+	// string
+	setRedis, err := rCli.Set(context.TODO(), key, value, 0).Result()
+	assert.Equal(t, nil, err)
+	setModis, err := mCli.Set(context.TODO(), key, value, 0).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, setRedis, setModis)
+	expireRedis, err := rCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err := mCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	persistRedis, err = rCli.Persist(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	persistModis, err = mCli.Persist(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, persistRedis, persistModis)
+	time.Sleep(expiration)
+	existRedis, err := rCli.Exists(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	existModis, err := mCli.Exists(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, existRedis, existModis)
+	delRedis, err := rCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	delModis, err := mCli.Del(context.TODO(), key).Result()
 	assert.Equal(t, nil, err)
 	assert.EqualValues(t, delRedis, delModis)
 
-	// // hash
-	// hsetRedis, err := rCli.HSet(context.TODO(), key, field, value).Result()
-	// assert.Equal(t, nil, err)
-	// hsetModis, err := mCli.HSet(context.TODO(), key, field, value).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, hsetRedis, hsetModis)
-	// expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
-	// assert.Equal(t, nil, err)
-	// expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, expireRedis, expireModis)
-	// persistRedis, err = rCli.Persist(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// persistModis, err = mCli.Persist(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, persistRedis, persistModis)
-	// time.Sleep(expiration)
-	// hexistRedis, err := rCli.HExists(context.TODO(), key, field).Result()
-	// assert.Equal(t, nil, err)
-	// hexistModis, err := rCli.HExists(context.TODO(), key, field).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, hexistRedis, hexistModis)
-	// delRedis, err = rCli.Del(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// delModis, err = mCli.Del(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, delRedis, delModis)
-
-	// // set
-	// saddRedis, err := rCli.SAdd(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// saddModis, err := mCli.SAdd(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, saddRedis, saddModis)
-	// expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
-	// assert.Equal(t, nil, err)
-	// expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, expireRedis, expireModis)
-	// persistRedis, err = rCli.Persist(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// persistModis, err = mCli.Persist(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, persistRedis, persistModis)
-	// time.Sleep(expiration)
-	// ismemRedis, err := rCli.SIsMember(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// ismemModis, err := rCli.SIsMember(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, ismemRedis, ismemModis)
-	// delRedis, err = rCli.Del(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// delModis, err = mCli.Del(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, delRedis, delModis)
+	// hash
+	hsetRedis, err := rCli.HSet(context.TODO(), key, field, value).Result()
+	assert.Equal(t, nil, err)
+	hsetModis, err := mCli.HSet(context.TODO(), key, field, value).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, hsetRedis, hsetModis)
+	expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	persistRedis, err = rCli.Persist(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	persistModis, err = mCli.Persist(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, persistRedis, persistModis)
+	time.Sleep(expiration)
+	hexistRedis, err := rCli.HExists(context.TODO(), key, field).Result()
+	assert.Equal(t, nil, err)
+	hexistModis, err := mCli.HExists(context.TODO(), key, field).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, hexistRedis, hexistModis)
+	delRedis, err = rCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	delModis, err = mCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, delRedis, delModis)
+
+	// set
+	saddRedis, err := rCli.SAdd(context.TODO(), key, member).Result()
+	assert.Equal(t, nil, err)
+	saddModis, err := mCli.SAdd(context.TODO(), key, member).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, saddRedis, saddModis)
+	expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	persistRedis, err = rCli.Persist(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	persistModis, err = mCli.Persist(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, persistRedis, persistModis)
+	time.Sleep(expiration)
+	ismemRedis, err := rCli.SIsMember(context.TODO(), key, member).Result()
+	assert.Equal(t, nil, err)
+	ismemModis, err := mCli.SIsMember(context.TODO(), key, member).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, ismemRedis, ismemModis)
+	delRedis, err = rCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	delModis, err = mCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, delRedis, delModis)
+
+	// list
+	rpushRedis, err := rCli.RPush(context.TODO(), key, value).Result()
+	assert.Equal(t, nil, err)
+	rpushModis, err := mCli.RPush(context.TODO(), key, value).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, rpushRedis, rpushModis)
+	expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	persistRedis, err = rCli.Persist(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	persistModis, err = mCli.Persist(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, persistRedis, persistModis)
+	time.Sleep(expiration)
+	llenRedis, err := rCli.LLen(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	llenModis, err := mCli.LLen(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, llenRedis, llenModis)
+	delRedis, err = rCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	delModis, err = mCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, delRedis, delModis)
+
+	// zset
+	zaddRedis, err := rCli.ZAdd(context.TODO(), key, &redis.Z{Score: 1, Member: member}).Result()
+	assert.Equal(t, nil, err)
+	zaddModis, err := mCli.ZAdd(context.TODO(), key, &redis.Z{Score: 1, Member: member}).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, zaddRedis, zaddModis)
+	expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	persistRedis, err = rCli.Persist(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	persistModis, err = mCli.Persist(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, persistRedis, persistModis)
+	time.Sleep(expiration)
+	zcardRedis, err := rCli.ZCard(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	zcardModis, err := mCli.ZCard(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, zcardRedis, zcardModis)
+	delRedis, err = rCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	delModis, err = mCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, delRedis, delModis)
 }
 
 func TestKey_TTL(t *testing.T) {
 	key := "Key"
 	value := "Value"
-	// field := "Field"
-	// member := "Member"
+	field := "Field"
+	member := "Member"
 	expiration := time.Second * 10
 	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
 
@@ -727,56 +1019,100 @@ func TestKey_TTL(t *testing.T) {
 	assert.Equal(t, nil, err)
 	assert.EqualValues(t, delRedis, delModis)
 
-	// // hash
-	// hsetRedis, err := rCli.HSet(context.TODO(), key, field, value).Result()
-	// assert.Equal(t, nil, err)
-	// hsetModis, err := mCli.HSet(context.TODO(), key, field, value).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, hsetRedis, hsetModis)
-	// expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
-	// assert.Equal(t, nil, err)
-	// expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, expireRedis, expireModis)
-	// ttlRedis, err = rCli.TTL(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// ttlModis, err = mCli.TTL(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, ttlRedis, ttlModis)
-	// delRedis, err = rCli.Del(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// delModis, err = mCli.Del(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, delRedis, delModis)
-
-	// // set
-	// saddRedis, err := rCli.SAdd(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// saddModis, err := mCli.SAdd(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, saddRedis, saddModis)
-	// expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
-	// assert.Equal(t, nil, err)
-	// expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, expireRedis, expireModis)
-	// ttlRedis, err = rCli.TTL(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// ttlModis, err = mCli.TTL(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, ttlRedis, ttlModis)
-	// delRedis, err = rCli.Del(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// delModis, err = mCli.Del(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, delRedis, delModis)
+	// hash
+	hsetRedis, err := rCli.HSet(context.TODO(), key, field, value).Result()
+	assert.Equal(t, nil, err)
+	hsetModis, err := mCli.HSet(context.TODO(), key, field, value).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, hsetRedis, hsetModis)
+	expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	ttlRedis, err = rCli.TTL(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	ttlModis, err = mCli.TTL(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, ttlRedis, ttlModis)
+	delRedis, err = rCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	delModis, err = mCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, delRedis, delModis)
+
+	// set
+	saddRedis, err := rCli.SAdd(context.TODO(), key, member).Result()
+	assert.Equal(t, nil, err)
+	saddModis, err := mCli.SAdd(context.TODO(), key, member).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, saddRedis, saddModis)
+	expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	ttlRedis, err = rCli.TTL(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	ttlModis, err = mCli.TTL(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, ttlRedis, ttlModis)
+	delRedis, err = rCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	delModis, err = mCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, delRedis, delModis)
+
+	// list
+	rpushRedis, err := rCli.RPush(context.TODO(), key, value).Result()
+	assert.Equal(t, nil, err)
+	rpushModis, err := mCli.RPush(context.TODO(), key, value).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, rpushRedis, rpushModis)
+	expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	ttlRedis, err = rCli.TTL(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	ttlModis, err = mCli.TTL(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, ttlRedis, ttlModis)
+	delRedis, err = rCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	delModis, err = mCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, delRedis, delModis)
+
+	// zset
+	zaddRedis, err := rCli.ZAdd(context.TODO(), key, &redis.Z{Score: 1, Member: member}).Result()
+	assert.Equal(t, nil, err)
+	zaddModis, err := mCli.ZAdd(context.TODO(), key, &redis.Z{Score: 1, Member: member}).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, zaddRedis, zaddModis)
+	expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	ttlRedis, err = rCli.TTL(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	ttlModis, err = mCli.TTL(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, ttlRedis, ttlModis)
+	delRedis, err = rCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	delModis, err = mCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, delRedis, delModis)
 }
 
 func TestKey_PTTL(t *testing.T) {
 	key := "Key"
 	value := "Value"
-	// field := "Field"
-	// member := "Member"
+	field := "Field"
+	member := "Member"
 	expiration := time.Second * 10
 	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
 
@@ -809,47 +1145,522 @@ func TestKey_PTTL(t *testing.T) {
 	assert.Equal(t, nil, err)
 	assert.EqualValues(t, delRedis, delModis)
 
-	// // hash
-	// hsetRedis, err := rCli.HSet(context.TODO(), key, field, value).Result()
-	// assert.Equal(t, nil, err)
-	// hsetModis, err := mCli.HSet(context.TODO(), key, field, value).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, hsetRedis, hsetModis)
-	// expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
-	// assert.Equal(t, nil, err)
-	// expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, expireRedis, expireModis)
-	// ttlRedis, err = rCli.PTTL(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// ttlModis, err = mCli.PTTL(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, 0, int64(ttlRedis.Seconds()-ttlModis.Seconds()))
-	// delRedis, err = rCli.Del(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// delModis, err = mCli.Del(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, delRedis, delModis)
-
-	// // set
-	// saddRedis, err := rCli.SAdd(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// saddModis, err := mCli.SAdd(context.TODO(), key, member).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, saddRedis, saddModis)
-	// expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
-	// assert.Equal(t, nil, err)
-	// expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, expireRedis, expireModis)
-	// ttlRedis, err = rCli.PTTL(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// ttlModis, err = mCli.PTTL(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, 0, int64(ttlRedis.Seconds()-ttlModis.Seconds()))
-	// delRedis, err = rCli.Del(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// delModis, err = mCli.Del(context.TODO(), key).Result()
-	// assert.Equal(t, nil, err)
-	// assert.EqualValues(t, delRedis, delModis)
+	// hash
+	hsetRedis, err := rCli.HSet(context.TODO(), key, field, value).Result()
+	assert.Equal(t, nil, err)
+	hsetModis, err := mCli.HSet(context.TODO(), key, field, value).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, hsetRedis, hsetModis)
+	expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	ttlRedis, err = rCli.PTTL(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	ttlModis, err = mCli.PTTL(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, 0, int64(ttlRedis.Seconds()-ttlModis.Seconds()))
+	delRedis, err = rCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	delModis, err = mCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, delRedis, delModis)
+
+	// set
+	saddRedis, err := rCli.SAdd(context.TODO(), key, member).Result()
+	assert.Equal(t, nil, err)
+	saddModis, err := mCli.SAdd(context.TODO(), key, member).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, saddRedis, saddModis)
+	expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	ttlRedis, err = rCli.PTTL(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	ttlModis, err = mCli.PTTL(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, 0, int64(ttlRedis.Seconds()-ttlModis.Seconds()))
+	delRedis, err = rCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	delModis, err = mCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, delRedis, delModis)
+
+	// list
+	rpushRedis, err := rCli.RPush(context.TODO(), key, value).Result()
+	assert.Equal(t, nil, err)
+	rpushModis, err := mCli.RPush(context.TODO(), key, value).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, rpushRedis, rpushModis)
+	expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	ttlRedis, err = rCli.PTTL(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	ttlModis, err = mCli.PTTL(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, 0, int64(ttlRedis.Seconds()-ttlModis.Seconds()))
+	delRedis, err = rCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	delModis, err = mCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, delRedis, delModis)
+
+	// zset
+	zaddRedis, err := rCli.ZAdd(context.TODO(), key, &redis.Z{Score: 1, Member: member}).Result()
+	assert.Equal(t, nil, err)
+	zaddModis, err := mCli.ZAdd(context.TODO(), key, &redis.Z{Score: 1, Member: member}).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, zaddRedis, zaddModis)
+	expireRedis, err = rCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	expireModis, err = mCli.Expire(context.TODO(), key, expiration).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, expireRedis, expireModis)
+	ttlRedis, err = rCli.PTTL(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	ttlModis, err = mCli.PTTL(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, 0, int64(ttlRedis.Seconds()-ttlModis.Seconds()))
+	delRedis, err = rCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	delModis, err = mCli.Del(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, delRedis, delModis)
+}
+
+func TestKey_DBSize(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
+
+	// empty
+	sizeRedis, err := rCli.DBSize(context.TODO()).Result()
+	assert.Equal(t, nil, err)
+	sizeModis, err := mCli.DBSize(context.TODO()).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, sizeRedis, sizeModis)
+
+	// one key of each type, the keys with several fields or members are counted once
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		assert.Equal(t, nil, cli.Set(context.TODO(), "string", "Value", 0).Err())
+		assert.Equal(t, nil, cli.HSet(context.TODO(), "hash", "f1", "v1", "f2", "v2").Err())
+		assert.Equal(t, nil, cli.RPush(context.TODO(), "list", "a", "b", "c").Err())
+		assert.Equal(t, nil, cli.SAdd(context.TODO(), "set", "a", "b").Err())
+		assert.Equal(t, nil, cli.ZAdd(context.TODO(), "zset", &redis.Z{Score: 1, Member: "a"}, &redis.Z{Score: 2, Member: "b"}).Err())
+	}
+	sizeRedis, err = rCli.DBSize(context.TODO()).Result()
+	assert.Equal(t, nil, err)
+	sizeModis, err = mCli.DBSize(context.TODO()).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, sizeRedis, sizeModis)
+	assert.EqualValues(t, 5, sizeModis)
+
+	// expired keys are not counted
+	expiration := 100 * time.Millisecond
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		assert.Equal(t, nil, cli.PExpire(context.TODO(), "hash", expiration).Err())
+	}
+	time.Sleep(2 * expiration)
+	sizeRedis, err = rCli.DBSize(context.TODO()).Result()
+	assert.Equal(t, nil, err)
+	sizeModis, err = mCli.DBSize(context.TODO()).Result()
+	assert.Equal(t, nil, err)
+	assert.EqualValues(t, sizeRedis, sizeModis)
+}
+
+func TestKey_InfoKeyspace(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
+
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		assert.Equal(t, nil, cli.Set(context.TODO(), "string", "Value", 0).Err())
+		assert.Equal(t, nil, cli.HSet(context.TODO(), "hash", "f1", "v1", "f2", "v2").Err())
+		assert.Equal(t, nil, cli.SAdd(context.TODO(), "set", "a", "b").Err())
+		assert.Equal(t, nil, cli.Expire(context.TODO(), "set", time.Minute).Err())
+	}
+	info, err := mCli.Info(context.TODO(), "keyspace").Result()
+	assert.Equal(t, nil, err)
+	assert.Contains(t, info, "# Keyspace\r\n")
+	assert.Contains(t, info, "db0:keys=3,expires=1\r\n")
+}
+
+// scanAll iterates the keyspace with SCAN and returns the keys sorted
+func scanAll(t *testing.T, cli *redis.Client, match string, count int64, keyType string) []string {
+	var keys []string
+	var cursor uint64
+	for {
+		var page []string
+		var err error
+		if keyType != "" {
+			page, cursor, err = cli.ScanType(context.TODO(), cursor, match, count, keyType).Result()
+		} else {
+			page, cursor, err = cli.Scan(context.TODO(), cursor, match, count).Result()
+		}
+		assert.Equal(t, nil, err)
+		if err != nil {
+			return nil
+		}
+		keys = append(keys, page...)
+		if cursor == 0 {
+			break
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestKey_Scan(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
+
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		for i := 0; i < 20; i++ {
+			assert.Equal(t, nil, cli.Set(context.TODO(), fmt.Sprintf("string:%d", i), "Value", 0).Err())
+			assert.Equal(t, nil, cli.HSet(context.TODO(), fmt.Sprintf("hash:%d", i), "f1", "v1", "f2", "v2").Err())
+		}
+		assert.Equal(t, nil, cli.RPush(context.TODO(), "list", "a", "b", "c").Err())
+		assert.Equal(t, nil, cli.SAdd(context.TODO(), "set", "a", "b").Err())
+		assert.Equal(t, nil, cli.ZAdd(context.TODO(), "zset", &redis.Z{Score: 1, Member: "a"}).Err())
+	}
+
+	for _, count := range []int64{1, 3, 10, 100} {
+		assert.Equal(t, scanAll(t, rCli, "", count, ""), scanAll(t, mCli, "", count, ""))
+		assert.Equal(t, scanAll(t, rCli, "hash:1*", count, ""), scanAll(t, mCli, "hash:1*", count, ""))
+		assert.Equal(t, scanAll(t, rCli, "", count, "hash"), scanAll(t, mCli, "", count, "hash"))
+		assert.Equal(t, scanAll(t, rCli, "*:?", count, "string"), scanAll(t, mCli, "*:?", count, "string"))
+	}
+	assert.Equal(t, 43, len(scanAll(t, mCli, "", 5, "")))
+	assert.Equal(t, 0, len(scanAll(t, mCli, "", 5, "stream")))
+
+	// errors
+	_, _, err := mCli.Scan(context.TODO(), 0, "", 0).Result()
+	assert.Equal(t, nil, err)
+	err = mCli.Do(context.TODO(), "scan", "0", "count", "0").Err()
+	assert.Equal(t, "ERR syntax error", err.Error())
+	err = mCli.Do(context.TODO(), "scan", "0", "count").Err()
+	assert.Equal(t, "ERR syntax error", err.Error())
+	err = mCli.Do(context.TODO(), "scan", "x").Err()
+	assert.Equal(t, "ERR invalid cursor", err.Error())
+	err = mCli.Scan(context.TODO(), 5, "", 3).Err()
+	assert.Equal(t, "ERR invalid cursor", err.Error())
+
+	// a cursor is a 64-bit integer kept by the storage, it stays valid however many iterations run meanwhile
+	page, cursor, err := mCli.Scan(context.TODO(), 0, "", 3).Result()
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, uint64(0), cursor)
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, nil, mCli.Scan(context.TODO(), 0, "", 1).Err())
+	}
+	next, _, err := mCli.Scan(context.TODO(), cursor, "", 3).Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(next))
+	assert.NotContains(t, next, page[0])
+}
+
+func TestKey_Keys(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
+
+	keys := []string{"hello", "hallo", "hxllo", "hllo", "heeeello", "h*llo", "user:1", "user:2", "user:10", "User:a"}
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		for i, key := range keys {
+			if i%2 == 0 {
+				assert.Equal(t, nil, cli.Set(context.TODO(), key, "Value", 0).Err())
+			} else {
+				assert.Equal(t, nil, cli.SAdd(context.TODO(), key, "Member").Err())
+			}
+		}
+	}
+
+	patterns := []string{"*", "h?llo", "h*llo", "h[ae]llo", "h[^e]llo", "h[a-b]llo", "h\\*llo", "user:*", "user:[0-9]", "[uU]ser:*", "nothing*"}
+	for _, pattern := range patterns {
+		keysRedis, err := rCli.Keys(context.TODO(), pattern).Result()
+		assert.Equal(t, nil, err)
+		keysModis, err := mCli.Keys(context.TODO(), pattern).Result()
+		assert.Equal(t, nil, err)
+		sort.Strings(keysRedis)
+		sort.Strings(keysModis)
+		assert.Equal(t, keysRedis, keysModis, pattern)
+	}
+}
+
+// writeKeys writes a key of each type with cli
+func writeKeys(t *testing.T, cli redis.Cmdable) {
+	assert.Equal(t, nil, cli.Set(context.TODO(), "string", "Value", 0).Err())
+	assert.Equal(t, nil, cli.HSet(context.TODO(), "hash", "f1", "v1", "f2", "v2").Err())
+	assert.Equal(t, nil, cli.RPush(context.TODO(), "list", "a", "b", "c").Err())
+	assert.Equal(t, nil, cli.SAdd(context.TODO(), "set", "a", "b").Err())
+	assert.Equal(t, nil, cli.ZAdd(context.TODO(), "zset", &redis.Z{Score: 1, Member: "a"}).Err())
+}
+
+func TestKey_FlushDB(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
+
+	// sync
+	writeKeys(t, rCli)
+	writeKeys(t, mCli)
+	flushRedis, err := rCli.FlushDB(context.TODO()).Result()
+	assert.Equal(t, nil, err)
+	flushModis, err := mCli.FlushDB(context.TODO()).Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, flushRedis, flushModis)
+	for _, key := range []string{"string", "hash", "list", "set", "zset"} {
+		typeModis, err := mCli.Type(context.TODO(), key).Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "none", typeModis)
+	}
+
+	// async
+	writeKeys(t, mCli)
+	flushModis, err = mCli.FlushDBAsync(context.TODO()).Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "OK", flushModis)
+	assert.Eventually(t, func() bool {
+		size, err := mCli.DBSize(context.TODO()).Result()
+		return err == nil && size == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		info, err := mCli.Info(context.TODO(), "persistence").Result()
+		return err == nil && strings.Contains(info, "async_flush_in_progress:0\r\n") &&
+			strings.Contains(info, "async_flush_last_status:ok\r\n")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// errors
+	err = mCli.Do(context.TODO(), "flushdb", "lazy").Err()
+	assert.Equal(t, "ERR syntax error", err.Error())
+	err = mCli.Do(context.TODO(), "flushdb", "sync", "async").Err()
+	assert.Equal(t, "ERR syntax error", err.Error())
+}
+
+func TestKey_FlushAll(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
+
+	conn := mCli.Conn(context.TODO())
+	defer conn.Close()
+	assert.Equal(t, nil, conn.Select(context.TODO(), 1).Err())
+	writeKeys(t, conn)
+	assert.Equal(t, nil, conn.Select(context.TODO(), 0).Err())
+	writeKeys(t, conn)
+
+	assert.Equal(t, nil, conn.FlushAll(context.TODO()).Err())
+	for _, db := range []int{0, 1} {
+		assert.Equal(t, nil, conn.Select(context.TODO(), db).Err())
+		size, err := conn.DBSize(context.TODO()).Result()
+		assert.Equal(t, nil, err)
+		assert.EqualValues(t, 0, size)
+	}
+	assert.Equal(t, nil, conn.Select(context.TODO(), 0).Err())
+}
+
+// dumpKey returns the type and the value of key in a form comparable between redis and modis
+func dumpKey(t *testing.T, cli redis.Cmdable, key string) string {
+	typ, err := cli.Type(context.TODO(), key).Result()
+	assert.Equal(t, nil, err)
+	var val interface{}
+	switch typ {
+	case "string":
+		val, err = cli.Get(context.TODO(), key).Result()
+	case "hash":
+		val, err = cli.HGetAll(context.TODO(), key).Result()
+	case "list":
+		val, err = cli.LRange(context.TODO(), key, 0, -1).Result()
+	case "set":
+		var members []string
+		members, err = cli.SMembers(context.TODO(), key).Result()
+		sort.Strings(members)
+		val = members
+	case "zset":
+		val, err = cli.ZRangeWithScores(context.TODO(), key, 0, -1).Result()
+	}
+	assert.Equal(t, nil, err)
+	return fmt.Sprint(typ, val)
+}
+
+// flushDB1 clears db 1, which is written by the tests moving keys across dbs
+func flushDB1(t *testing.T) {
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		conn := cli.Conn(context.TODO())
+		assert.Equal(t, nil, conn.Select(context.TODO(), 1).Err())
+		assert.Equal(t, nil, conn.FlushDB(context.TODO()).Err())
+		// the connection goes back to the pool, which expects db 0
+		assert.Equal(t, nil, conn.Select(context.TODO(), 0).Err())
+		conn.Close()
+	}
+}
+
+func TestKey_Rename(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
+
+	// no such key
+	_, errRedis := rCli.Rename(context.TODO(), "none", "none2").Result()
+	_, errModis := mCli.Rename(context.TODO(), "none", "none2").Result()
+	assert.Equal(t, errRedis, errModis)
+
+	writeKeys(t, rCli)
+	writeKeys(t, mCli)
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		assert.Equal(t, nil, cli.Expire(context.TODO(), "list", 100*time.Second).Err())
+	}
+	for _, key := range []string{"string", "hash", "list", "set", "zset"} {
+		renameRedis, err := rCli.Rename(context.TODO(), key, key+"2").Result()
+		assert.Equal(t, nil, err)
+		renameModis, err := mCli.Rename(context.TODO(), key, key+"2").Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, renameRedis, renameModis)
+		assert.Equal(t, dumpKey(t, rCli, key+"2"), dumpKey(t, mCli, key+"2"))
+		assert.Equal(t, dumpKey(t, rCli, key), dumpKey(t, mCli, key))
+
+		ttlRedis, err := rCli.TTL(context.TODO(), key+"2").Result()
+		assert.Equal(t, nil, err)
+		ttlModis, err := mCli.TTL(context.TODO(), key+"2").Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, ttlRedis, ttlModis)
+	}
+
+	// the same key
+	renameRedis, err := rCli.Rename(context.TODO(), "list2", "list2").Result()
+	assert.Equal(t, nil, err)
+	renameModis, err := mCli.Rename(context.TODO(), "list2", "list2").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, renameRedis, renameModis)
+	assert.Equal(t, dumpKey(t, rCli, "list2"), dumpKey(t, mCli, "list2"))
+
+	// overwrite a key of another type
+	renameRedis, err = rCli.Rename(context.TODO(), "zset2", "hash2").Result()
+	assert.Equal(t, nil, err)
+	renameModis, err = mCli.Rename(context.TODO(), "zset2", "hash2").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, renameRedis, renameModis)
+	assert.Equal(t, dumpKey(t, rCli, "hash2"), dumpKey(t, mCli, "hash2"))
+	assert.Equal(t, dumpKey(t, rCli, "zset2"), dumpKey(t, mCli, "zset2"))
+}
+
+func TestKey_RenameNX(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
+
+	writeKeys(t, rCli)
+	writeKeys(t, mCli)
+	for _, args := range [][2]string{{"list", "set"}, {"list", "list"}, {"list", "list2"}, {"hash", "list2"}} {
+		renameRedis, err := rCli.RenameNX(context.TODO(), args[0], args[1]).Result()
+		assert.Equal(t, nil, err)
+		renameModis, err := mCli.RenameNX(context.TODO(), args[0], args[1]).Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, renameRedis, renameModis)
+		assert.Equal(t, dumpKey(t, rCli, args[0]), dumpKey(t, mCli, args[0]))
+		assert.Equal(t, dumpKey(t, rCli, args[1]), dumpKey(t, mCli, args[1]))
+	}
+
+	_, errRedis := rCli.RenameNX(context.TODO(), "none", "none2").Result()
+	_, errModis := mCli.RenameNX(context.TODO(), "none", "none2").Result()
+	assert.Equal(t, errRedis, errModis)
+}
+
+func TestKey_Copy(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
+	defer flushDB1(t)
+
+	writeKeys(t, rCli)
+	writeKeys(t, mCli)
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		assert.Equal(t, nil, cli.Expire(context.TODO(), "zset", 100*time.Second).Err())
+	}
+	for _, key := range []string{"string", "hash", "list", "set", "zset", "none"} {
+		copyRedis, err := rCli.Copy(context.TODO(), key, key+"2", 0, false).Result()
+		assert.Equal(t, nil, err)
+		copyModis, err := mCli.Copy(context.TODO(), key, key+"2", 0, false).Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, copyRedis, copyModis)
+		assert.Equal(t, dumpKey(t, rCli, key+"2"), dumpKey(t, mCli, key+"2"))
+		assert.Equal(t, dumpKey(t, rCli, key), dumpKey(t, mCli, key))
+
+		ttlRedis, err := rCli.TTL(context.TODO(), key+"2").Result()
+		assert.Equal(t, nil, err)
+		ttlModis, err := mCli.TTL(context.TODO(), key+"2").Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, ttlRedis, ttlModis)
+	}
+
+	// the destination exists
+	for _, replace := range []bool{false, true} {
+		copyRedis, err := rCli.Copy(context.TODO(), "hash", "list2", 0, replace).Result()
+		assert.Equal(t, nil, err)
+		copyModis, err := mCli.Copy(context.TODO(), "hash", "list2", 0, replace).Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, copyRedis, copyModis)
+		assert.Equal(t, dumpKey(t, rCli, "list2"), dumpKey(t, mCli, "list2"))
+	}
+
+	// another db
+	copyRedis, err := rCli.Copy(context.TODO(), "list", "list", 1, false).Result()
+	assert.Equal(t, nil, err)
+	copyModis, err := mCli.Copy(context.TODO(), "list", "list", 1, false).Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, copyRedis, copyModis)
+	conn := mCli.Conn(context.TODO())
+	defer conn.Close()
+	assert.Equal(t, nil, conn.Select(context.TODO(), 1).Err())
+	assert.Equal(t, dumpKey(t, rCli, "list"), dumpKey(t, conn, "list"))
+	assert.Equal(t, nil, conn.Select(context.TODO(), 0).Err())
+
+	// errors
+	_, errRedis := rCli.Copy(context.TODO(), "list", "list", 0, false).Result()
+	_, errModis := mCli.Copy(context.TODO(), "list", "list", 0, false).Result()
+	assert.Equal(t, errRedis, errModis)
+	_, errRedis = rCli.Do(context.TODO(), "copy", "list", "list3", "db").Result()
+	_, errModis = mCli.Do(context.TODO(), "copy", "list", "list3", "db").Result()
+	assert.Equal(t, errRedis, errModis)
+	_, errRedis = rCli.Do(context.TODO(), "copy", "list", "list3", "db", "x").Result()
+	_, errModis = mCli.Do(context.TODO(), "copy", "list", "list3", "db", "x").Result()
+	assert.Equal(t, errRedis, errModis)
+}
+
+func TestKey_Move(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
+	defer flushDB1(t)
+
+	writeKeys(t, rCli)
+	writeKeys(t, mCli)
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		assert.Equal(t, nil, cli.Expire(context.TODO(), "hash", 100*time.Second).Err())
+	}
+	conn := mCli.Conn(context.TODO())
+	defer conn.Close()
+	for _, key := range []string{"string", "hash", "list", "set", "zset", "none"} {
+		dump := dumpKey(t, mCli, key)
+		moveRedis, err := rCli.Move(context.TODO(), key, 1).Result()
+		assert.Equal(t, nil, err)
+		moveModis, err := mCli.Move(context.TODO(), key, 1).Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, moveRedis, moveModis)
+		assert.Equal(t, dumpKey(t, rCli, key), dumpKey(t, mCli, key))
+
+		assert.Equal(t, nil, conn.Select(context.TODO(), 1).Err())
+		assert.Equal(t, dump, dumpKey(t, conn, key))
+		if key == "hash" {
+			ttl, err := conn.TTL(context.TODO(), key).Result()
+			assert.Equal(t, nil, err)
+			assert.Equal(t, 100*time.Second, ttl)
+		}
+		assert.Equal(t, nil, conn.Select(context.TODO(), 0).Err())
+	}
+
+	// the key exists in the target db
+	writeKeys(t, rCli)
+	writeKeys(t, mCli)
+	moveRedis, err := rCli.Move(context.TODO(), "list", 1).Result()
+	assert.Equal(t, nil, err)
+	moveModis, err := mCli.Move(context.TODO(), "list", 1).Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, moveRedis, moveModis)
+	assert.Equal(t, dumpKey(t, rCli, "list"), dumpKey(t, mCli, "list"))
+
+	// errors
+	_, errRedis := rCli.Move(context.TODO(), "list", 0).Result()
+	_, errModis := mCli.Move(context.TODO(), "list", 0).Result()
+	assert.Equal(t, errRedis, errModis)
+	_, errModis = mCli.Do(context.TODO(), "move", "list", "x").Result()
+	assert.EqualError(t, errModis, "ERR value is not an integer or out of range")
+	_, errModis = mCli.Do(context.TODO(), "move", "list", "100000").Result()
+	assert.EqualError(t, errModis, "ERR DB index is out of range")
 }
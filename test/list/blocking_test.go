@@ -0,0 +1,181 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package list
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oceanbase/modis/test"
+)
+
+// pushLater pushes value to the tail of key after a while
+func pushLater(cli *redis.Client, key string, value string) {
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cli.RPush(context.TODO(), key, value)
+	}()
+}
+
+func TestBLPop(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisListTableName)
+
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		cli.RPush(context.TODO(), "list2", "a", "b", "c")
+	}
+
+	// not blocked
+	val, err := rCli.BLPop(context.TODO(), time.Second, "list1", "list2").Result()
+	assert.Equal(t, nil, err)
+	val_m, err := mCli.BLPop(context.TODO(), time.Second, "list1", "list2").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, val, val_m)
+	val, err = rCli.BRPop(context.TODO(), time.Second, "list1", "list2").Result()
+	assert.Equal(t, nil, err)
+	val_m, err = mCli.BRPop(context.TODO(), time.Second, "list1", "list2").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, val, val_m)
+
+	// timeout
+	err = rCli.Do(context.TODO(), "blpop", "list1", "0.1").Err()
+	assert.Equal(t, redis.Nil, err)
+	err = mCli.Do(context.TODO(), "blpop", "list1", "0.1").Err()
+	assert.Equal(t, redis.Nil, err)
+
+	// woken up by a push
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		pushLater(cli, "list1", "d")
+		st := time.Now()
+		val, err = cli.BRPop(context.TODO(), 5*time.Second, "list1").Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []string{"list1", "d"}, val)
+		assert.Less(t, time.Since(st), 2*time.Second)
+	}
+
+	// blocks forever
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		pushLater(cli, "list1", "e")
+		val, err = cli.BLPop(context.TODO(), 0, "list3", "list1").Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []string{"list1", "e"}, val)
+	}
+
+	// wrong type
+	rCli.Set(context.TODO(), "str", "value", 0)
+	mCli.Set(context.TODO(), "str", "value", 0)
+	err = rCli.BLPop(context.TODO(), time.Second, "str").Err()
+	err_m := mCli.BLPop(context.TODO(), time.Second, "str").Err()
+	assert.Equal(t, err, err_m)
+}
+
+func TestBLPop_Timeout(t *testing.T) {
+	for _, timeout := range []string{"-1", "abc", "inf"} {
+		err := rCli.Do(context.TODO(), "blpop", "list", timeout).Err()
+		err_m := mCli.Do(context.TODO(), "blpop", "list", timeout).Err()
+		assert.Equal(t, err, err_m)
+	}
+
+	// not blocked in MULTI
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		cmds, err := cli.TxPipelined(context.TODO(), func(pipe redis.Pipeliner) error {
+			pipe.BLPop(context.TODO(), 0, "list")
+			return nil
+		})
+		assert.Equal(t, redis.Nil, err)
+		assert.Equal(t, 1, len(cmds))
+	}
+}
+
+func TestBRPopLPush(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisListTableName)
+
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		cli.RPush(context.TODO(), "src", "a", "b", "c")
+	}
+
+	val, err := rCli.BRPopLPush(context.TODO(), "src", "dst", time.Second).Result()
+	assert.Equal(t, nil, err)
+	val_m, err := mCli.BRPopLPush(context.TODO(), "src", "dst", time.Second).Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, val, val_m)
+	for _, where := range [][2]string{{"LEFT", "LEFT"}, {"LEFT", "RIGHT"}, {"RIGHT", "RIGHT"}} {
+		val, err = rCli.BLMove(context.TODO(), "src", "dst", where[0], where[1], time.Second).Result()
+		val_m, err_m := mCli.BLMove(context.TODO(), "src", "dst", where[0], where[1], time.Second).Result()
+		assert.Equal(t, err, err_m)
+		assert.Equal(t, val, val_m)
+	}
+	vals, err := rCli.LRange(context.TODO(), "dst", 0, -1).Result()
+	assert.Equal(t, nil, err)
+	vals_m, err := mCli.LRange(context.TODO(), "dst", 0, -1).Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, vals, vals_m)
+	assert.Equal(t, rCli.Exists(context.TODO(), "src").Val(), mCli.Exists(context.TODO(), "src").Val())
+
+	// timeout
+	err = rCli.Do(context.TODO(), "brpoplpush", "src", "dst", "0.1").Err()
+	assert.Equal(t, redis.Nil, err)
+	err = mCli.Do(context.TODO(), "brpoplpush", "src", "dst", "0.1").Err()
+	assert.Equal(t, redis.Nil, err)
+
+	// woken up by a push
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		pushLater(cli, "src", "d")
+		val, err = cli.BLMove(context.TODO(), "src", "dst", "RIGHT", "LEFT", 5*time.Second).Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "d", val)
+	}
+	vals, err = rCli.LRange(context.TODO(), "dst", 0, -1).Result()
+	assert.Equal(t, nil, err)
+	vals_m, err = mCli.LRange(context.TODO(), "dst", 0, -1).Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, vals, vals_m)
+
+	// syntax error
+	err = mCli.BLMove(context.TODO(), "src", "dst", "UP", "LEFT", time.Second).Err()
+	assert.Equal(t, "ERR syntax error", err.Error())
+}
+
+func TestClientUnblock(t *testing.T) {
+	conn := mCli.Conn(context.TODO())
+	defer conn.Close()
+	id, err := conn.ClientID(context.TODO()).Result()
+	assert.Equal(t, nil, err)
+
+	// not blocked
+	assert.Equal(t, int64(0), mCli.ClientUnblock(context.TODO(), id).Val())
+
+	for _, reason := range []string{"TIMEOUT", "ERROR"} {
+		errChan := make(chan error)
+		go func() {
+			errChan <- conn.BLPop(context.TODO(), 0, "list").Err()
+		}()
+		time.Sleep(100 * time.Millisecond)
+		assert.Contains(t, mCli.ClientList(context.TODO()).Val(), "flags=b ")
+		if reason == "TIMEOUT" {
+			assert.Equal(t, int64(1), mCli.ClientUnblock(context.TODO(), id).Val())
+			assert.Equal(t, redis.Nil, <-errChan)
+		} else {
+			assert.Equal(t, int64(1), mCli.ClientUnblockWithError(context.TODO(), id).Val())
+			assert.Equal(t, "UNBLOCKED client unblocked via CLIENT UNBLOCK", (<-errChan).Error())
+		}
+	}
+	assert.NotNil(t, mCli.Do(context.TODO(), "client", "unblock", id, "later").Err())
+}
@@ -443,35 +443,182 @@ func TestLRem(t *testing.T) {
 }
 
 // Not supported currently
-// func TestRPopLPush(t *testing.T) {
-// 	key1 := "listKey1"
-// 	key2 := "listKey2"
-// 	defer test.ClearDb(0, rCli, test.TestModisListTableName)
-
-// 	members := generateTestData(10)
-// 	for _, member := range members {
-// 		val, err := rCli.RPush(context.TODO(), key1, member).Result()
-// 		assert.Equal(t, nil, err)
-// 		val_m, err := mCli.RPush(context.TODO(), key2, member).Result()
-// 		assert.Equal(t, nil, err)
-// 		assert.Equal(t, val, val_m)
-// 	}
-
-// 	rPopLPushR, err := rCli.RPopLPush(context.TODO(), key1, key2).Result()
-// 	assert.Equal(t, nil, err)
-// 	rPopLPushM, err := mCli.RPopLPush(context.TODO(), key1, key2).Result()
-// 	assert.Equal(t, nil, err)
-// 	assert.Equal(t, rPopLPushR, rPopLPushM)
-
-// 	membersRedis, err := rCli.LRange(context.TODO(), key1, 0, -1).Result()
-// 	assert.Equal(t, nil, err)
-// 	membersModis, err := mCli.LRange(context.TODO(), key1, 0, -1).Result()
-// 	assert.Equal(t, nil, err)
-// 	assert.Equal(t, membersRedis, membersModis)
-
-// 	membersRedis, err = rCli.LRange(context.TODO(), key2, 0, -1).Result()
-// 	assert.Equal(t, nil, err)
-// 	membersModis, err = mCli.LRange(context.TODO(), key2, 0, -1).Result()
-// 	assert.Equal(t, nil, err)
-// 	assert.Equal(t, membersRedis, membersModis)
-// }
+func TestPopCount(t *testing.T) {
+	key := "listKey"
+	defer test.ClearDb(0, rCli, test.TestModisListTableName)
+
+	members := generateTestData(10)
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		cli.RPush(context.TODO(), key, members)
+	}
+
+	for _, count := range []int{0, 3, 1, 10} {
+		val, err := rCli.LPopCount(context.TODO(), key, count).Result()
+		val_m, err_m := mCli.LPopCount(context.TODO(), key, count).Result()
+		assert.Equal(t, err, err_m)
+		assert.Equal(t, val, val_m)
+	}
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		cli.RPush(context.TODO(), key, members)
+	}
+	for _, count := range []int{2, 20} {
+		val, err := rCli.RPopCount(context.TODO(), key, count).Result()
+		val_m, err_m := mCli.RPopCount(context.TODO(), key, count).Result()
+		assert.Equal(t, err, err_m)
+		assert.Equal(t, val, val_m)
+	}
+
+	// key not exist
+	err := rCli.LPopCount(context.TODO(), key, 2).Err()
+	err_m := mCli.LPopCount(context.TODO(), key, 2).Err()
+	assert.Equal(t, redis.Nil, err)
+	assert.Equal(t, err, err_m)
+
+	// invalid count
+	for _, count := range []string{"-1", "abc"} {
+		err_m = mCli.Do(context.TODO(), "lpop", key, count).Err()
+		assert.Equal(t, "ERR value is out of range, must be positive", err_m.Error())
+	}
+}
+
+func TestRPopLPush(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisListTableName)
+
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		cli.RPush(context.TODO(), "src", "a", "b", "c", "d")
+	}
+
+	for i := 0; i < 3; i++ {
+		val, err := rCli.RPopLPush(context.TODO(), "src", "dst").Result()
+		val_m, err_m := mCli.RPopLPush(context.TODO(), "src", "dst").Result()
+		assert.Equal(t, err, err_m)
+		assert.Equal(t, val, val_m)
+	}
+	// rotate
+	val, err := rCli.RPopLPush(context.TODO(), "dst", "dst").Result()
+	val_m, err_m := mCli.RPopLPush(context.TODO(), "dst", "dst").Result()
+	assert.Equal(t, err, err_m)
+	assert.Equal(t, val, val_m)
+	for _, key := range []string{"src", "dst"} {
+		vals, err := rCli.LRange(context.TODO(), key, 0, -1).Result()
+		assert.Equal(t, nil, err)
+		vals_m, err := mCli.LRange(context.TODO(), key, 0, -1).Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, vals, vals_m)
+	}
+
+	// src not exist
+	err = rCli.RPopLPush(context.TODO(), "none", "dst").Err()
+	err_m = mCli.RPopLPush(context.TODO(), "none", "dst").Err()
+	assert.Equal(t, redis.Nil, err)
+	assert.Equal(t, err, err_m)
+
+	// wrong type of dst
+	rCli.Set(context.TODO(), "str", "value", 0)
+	mCli.Set(context.TODO(), "str", "value", 0)
+	err = rCli.RPopLPush(context.TODO(), "src", "str").Err()
+	err_m = mCli.RPopLPush(context.TODO(), "src", "str").Err()
+	assert.Equal(t, err, err_m)
+	assert.Equal(t, rCli.LLen(context.TODO(), "src").Val(), mCli.LLen(context.TODO(), "src").Val())
+}
+
+func TestLMove(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisListTableName)
+
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		cli.RPush(context.TODO(), "src", generateTestData(6))
+	}
+
+	for _, where := range [][2]string{{"LEFT", "LEFT"}, {"LEFT", "RIGHT"}, {"RIGHT", "LEFT"}, {"right", "right"}} {
+		val, err := rCli.LMove(context.TODO(), "src", "dst", where[0], where[1]).Result()
+		val_m, err_m := mCli.LMove(context.TODO(), "src", "dst", where[0], where[1]).Result()
+		assert.Equal(t, err, err_m)
+		assert.Equal(t, val, val_m)
+	}
+	for _, key := range []string{"src", "dst"} {
+		vals, err := rCli.LRange(context.TODO(), key, 0, -1).Result()
+		assert.Equal(t, nil, err)
+		vals_m, err := mCli.LRange(context.TODO(), key, 0, -1).Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, vals, vals_m)
+	}
+
+	err := rCli.LMove(context.TODO(), "src", "dst", "UP", "LEFT").Err()
+	err_m := mCli.LMove(context.TODO(), "src", "dst", "UP", "LEFT").Err()
+	assert.Equal(t, err, err_m)
+}
+
+func TestLPos(t *testing.T) {
+	key := "listKey"
+	defer test.ClearDb(0, rCli, test.TestModisListTableName)
+
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		cli.RPush(context.TODO(), key, "a", "b", "c", "a", "b", "a", "c", "a")
+	}
+
+	for _, args := range []redis.LPosArgs{{}, {Rank: 2}, {Rank: -1}, {Rank: -3}, {Rank: 5}, {MaxLen: 3}, {Rank: 2, MaxLen: 3}, {Rank: -2, MaxLen: 4}} {
+		val, err := rCli.LPos(context.TODO(), key, "a", args).Result()
+		val_m, err_m := mCli.LPos(context.TODO(), key, "a", args).Result()
+		assert.Equal(t, err, err_m)
+		assert.Equal(t, val, val_m)
+	}
+	for _, count := range []int64{0, 1, 2, 10} {
+		for _, args := range []redis.LPosArgs{{}, {Rank: -1}, {Rank: 2, MaxLen: 6}} {
+			val, err := rCli.LPosCount(context.TODO(), key, "a", count, args).Result()
+			val_m, err_m := mCli.LPosCount(context.TODO(), key, "a", count, args).Result()
+			assert.Equal(t, err, err_m)
+			assert.Equal(t, val, val_m)
+		}
+	}
+
+	// not found
+	err := rCli.LPos(context.TODO(), key, "d", redis.LPosArgs{}).Err()
+	err_m := mCli.LPos(context.TODO(), key, "d", redis.LPosArgs{}).Err()
+	assert.Equal(t, redis.Nil, err)
+	assert.Equal(t, err, err_m)
+	val_m, err_m := mCli.LPosCount(context.TODO(), "none", "a", 1, redis.LPosArgs{}).Result()
+	assert.Equal(t, nil, err_m)
+	assert.Equal(t, []int64{}, val_m)
+
+	// invalid options
+	for _, args := range [][]interface{}{{"rank", 0}, {"count", -1}, {"maxlen", -1}, {"rank"}, {"foo", 1}, {"rank", "x"}} {
+		cmd := append([]interface{}{"lpos", key, "a"}, args...)
+		err = rCli.Do(context.TODO(), cmd...).Err()
+		err_m = mCli.Do(context.TODO(), cmd...).Err()
+		assert.NotNil(t, err_m)
+		assert.Equal(t, err, err_m)
+	}
+}
+
+func TestLMPop(t *testing.T) {
+	defer mCli.Del(context.TODO(), "list1", "list2")
+
+	mCli.RPush(context.TODO(), "list2", "a", "b", "c", "d")
+
+	val, err := mCli.Do(context.TODO(), "lmpop", 2, "list1", "list2", "left").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []interface{}{"list2", []interface{}{"a"}}, val)
+	val, err = mCli.Do(context.TODO(), "lmpop", 2, "list1", "list2", "right", "count", 2).Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []interface{}{"list2", []interface{}{"d", "c"}}, val)
+	val, err = mCli.Do(context.TODO(), "lmpop", 1, "list2", "LEFT", "COUNT", 10).Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []interface{}{"list2", []interface{}{"b"}}, val)
+
+	// all empty
+	err = mCli.Do(context.TODO(), "lmpop", 2, "list1", "list2", "left").Err()
+	assert.Equal(t, redis.Nil, err)
+
+	// invalid args
+	for _, args := range [][]interface{}{
+		{0, "list1", "left"},
+		{3, "list1", "left"},
+		{1, "list1", "up"},
+		{1, "list1", "left", "count", 0},
+		{1, "list1", "left", "count"},
+		{1, "list1", "left", "limit", 1},
+	} {
+		err = mCli.Do(context.TODO(), append([]interface{}{"lmpop"}, args...)...).Err()
+		assert.NotNil(t, err)
+	}
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pubsub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oceanbase/modis/test"
+)
+
+func TestPubSub_Bus(t *testing.T) {
+	peerCli := test.CreateModisPeerClient()
+	if peerCli == nil {
+		t.Skip("set " + test.PeerEnv + " to test the pub/sub bus")
+	}
+	defer peerCli.Close()
+
+	peerSub := peerCli.Subscribe(context.TODO(), "bus")
+	defer peerSub.Close()
+	receive(t, peerSub)
+	localSub := mCli.PSubscribe(context.TODO(), "bus*")
+	defer localSub.Close()
+	receive(t, localSub)
+
+	// only the subscribers of the node are counted
+	assert.Equal(t, int64(1), mCli.Publish(context.TODO(), "bus", "hello").Val())
+	assert.Equal(t, &redis.Message{Channel: "bus", Pattern: "bus*", Payload: "hello"}, receive(t, localSub))
+	assert.Equal(t, &redis.Message{Channel: "bus", Payload: "hello"}, receive(t, peerSub))
+
+	assert.Equal(t, int64(0), peerCli.Publish(context.TODO(), "bus.other", "world").Val())
+	assert.Equal(t, &redis.Message{Channel: "bus.other", Pattern: "bus*", Payload: "world"}, receive(t, localSub))
+
+	// the messages of the peer are not sent back to it
+	assert.Equal(t, int64(1), peerCli.Publish(context.TODO(), "bus", "again").Val())
+	assert.Equal(t, &redis.Message{Channel: "bus", Payload: "again"}, receive(t, peerSub))
+	assert.Equal(t, &redis.Message{Channel: "bus", Pattern: "bus*", Payload: "again"}, receive(t, localSub))
+
+	// nothing is dropped while the peer keeps up
+	assert.Contains(t, mCli.Info(context.TODO(), "stats").Val(), "pubsub_bus_dropped_messages:0\r\n")
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pubsub
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oceanbase/modis/test"
+)
+
+// setNotifyEvents sets notify-keyspace-events of modis, returns the function restoring it
+func setNotifyEvents(t *testing.T, classes string) func() {
+	old := mCli.ConfigGet(context.TODO(), "notify-keyspace-events").Val()
+	assert.Equal(t, 2, len(old))
+	assert.Equal(t, "OK", mCli.ConfigSet(context.TODO(), "notify-keyspace-events", classes).Val())
+	return func() {
+		mCli.ConfigSet(context.TODO(), "notify-keyspace-events", old[1].(string))
+	}
+}
+
+func TestPubSub_ConfigNotifyEvents(t *testing.T) {
+	defer setNotifyEvents(t, "")()
+
+	expectations := map[string]string{
+		"":     "",
+		"KEA":  "AKE",
+		"Elg":  "glE",
+		"Kg$x": "g$xK",
+	}
+	if os.Getenv(test.BackendEnv) != test.BackendMemory {
+		// obkv expires keys on the server side, the expired events are refused
+		delete(expectations, "KEA")
+		delete(expectations, "Kg$x")
+		assert.NotNil(t, mCli.ConfigSet(context.TODO(), "notify-keyspace-events", "KEA").Err())
+		assert.NotNil(t, mCli.ConfigSet(context.TODO(), "notify-keyspace-events", "Kg$x").Err())
+	}
+	for classes, expected := range expectations {
+		assert.Equal(t, "OK", mCli.ConfigSet(context.TODO(), "notify-keyspace-events", classes).Val())
+		assert.Equal(t, []interface{}{"notify-keyspace-events", expected},
+			mCli.ConfigGet(context.TODO(), "notify-keyspace-*").Val())
+	}
+	assert.NotNil(t, mCli.ConfigSet(context.TODO(), "notify-keyspace-events", "KEy").Err())
+	assert.NotNil(t, mCli.ConfigSet(context.TODO(), "no-such-config", "1").Err())
+	assert.Equal(t, []interface{}{}, mCli.ConfigGet(context.TODO(), "no-such-*").Val())
+}
+
+func TestPubSub_KeyspaceEvents(t *testing.T) {
+	defer mCli.Del(context.TODO(), "key", "hash", "list2")
+	defer setNotifyEvents(t, "KEA")()
+
+	ps := mCli.PSubscribe(context.TODO(), "__key*@0__:*")
+	defer ps.Close()
+	receive(t, ps)
+
+	mCli.Set(context.TODO(), "key", "value", 0)
+	mCli.Expire(context.TODO(), "key", time.Minute)
+	mCli.Del(context.TODO(), "key", "none")
+	mCli.Del(context.TODO(), "none")
+	mCli.HSet(context.TODO(), "hash", "field", "value")
+	mCli.LPush(context.TODO(), "list", "a")
+	mCli.Rename(context.TODO(), "list", "list2")
+	expected := [][]string{
+		{"key", "set"},
+		{"key", "expire"},
+		{"key", "del"},
+		{"hash", "hset"},
+		{"list", "lpush"},
+		{"list", "rename_from"},
+		{"list2", "rename_to"},
+	}
+	for _, e := range expected {
+		key, event := e[0], e[1]
+		assert.Equal(t, &redis.Message{Pattern: "__key*@0__:*", Channel: "__keyspace@0__:" + key, Payload: event}, receive(t, ps))
+		assert.Equal(t, &redis.Message{Pattern: "__key*@0__:*", Channel: "__keyevent@0__:" + event, Payload: key}, receive(t, ps))
+	}
+
+	// only the enabled classes are published
+	assert.Equal(t, "OK", mCli.ConfigSet(context.TODO(), "notify-keyspace-events", "Eh").Val())
+	mCli.Set(context.TODO(), "key", "value", 0)
+	mCli.HDel(context.TODO(), "hash", "field")
+	assert.Equal(t, &redis.Message{Pattern: "__key*@0__:*", Channel: "__keyevent@0__:hdel", Payload: "hash"}, receive(t, ps))
+}
+
+func TestPubSub_ExpiredEvents(t *testing.T) {
+	if os.Getenv(test.BackendEnv) != test.BackendMemory {
+		t.Skip("expired keys are only observed by the memory backend")
+	}
+	defer setNotifyEvents(t, "Ex")()
+
+	ps := mCli.Subscribe(context.TODO(), "__keyevent@0__:expired")
+	defer ps.Close()
+	receive(t, ps)
+
+	mCli.Set(context.TODO(), "key", "value", 0)
+	mCli.PExpire(context.TODO(), "key", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, redis.Nil, mCli.Get(context.TODO(), "key").Err())
+	assert.Equal(t, &redis.Message{Channel: "__keyevent@0__:expired", Payload: "key"}, receive(t, ps))
+}
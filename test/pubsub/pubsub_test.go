@@ -0,0 +1,180 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pubsub
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oceanbase/modis/protocol/resp"
+	"github.com/oceanbase/modis/test"
+)
+
+// do runs a command on the connection, which keeps the subscriber mode
+func do(conn *redis.Conn, args ...interface{}) *redis.Cmd {
+	cmd := redis.NewCmd(context.TODO(), args...)
+	_ = conn.Process(context.TODO(), cmd)
+	return cmd
+}
+
+// receive returns the next message of the subscription
+func receive(t *testing.T, ps *redis.PubSub) interface{} {
+	msg, err := ps.ReceiveTimeout(context.TODO(), time.Second)
+	assert.Equal(t, nil, err)
+	return msg
+}
+
+func TestPubSub_Subscribe(t *testing.T) {
+	run := func(cli *redis.Client) []interface{} {
+		ps := cli.Subscribe(context.TODO(), "ch1", "ch2")
+		defer ps.Close()
+		res := []interface{}{receive(t, ps), receive(t, ps)}
+		res = append(res, cli.Publish(context.TODO(), "ch1", "hello").Val())
+		res = append(res, receive(t, ps))
+		res = append(res, cli.Publish(context.TODO(), "ch3", "hello").Val())
+
+		assert.Equal(t, nil, ps.Unsubscribe(context.TODO(), "ch1"))
+		res = append(res, receive(t, ps))
+		res = append(res, cli.Publish(context.TODO(), "ch1", "hello").Val())
+		res = append(res, cli.Publish(context.TODO(), "ch2", "world").Val())
+		res = append(res, receive(t, ps))
+
+		// unsubscribe all
+		assert.Equal(t, nil, ps.Unsubscribe(context.TODO()))
+		res = append(res, receive(t, ps))
+		res = append(res, cli.Publish(context.TODO(), "ch2", "world").Val())
+		return res
+	}
+	assert.Equal(t, run(rCli), run(mCli))
+}
+
+func TestPubSub_PSubscribe(t *testing.T) {
+	run := func(cli *redis.Client) []interface{} {
+		ps := cli.PSubscribe(context.TODO(), "news.*", "h?llo")
+		defer ps.Close()
+		res := []interface{}{receive(t, ps), receive(t, ps)}
+		assert.Equal(t, nil, ps.Subscribe(context.TODO(), "news.tech"))
+		res = append(res, receive(t, ps))
+
+		// matched by the channel and a pattern
+		res = append(res, cli.Publish(context.TODO(), "news.tech", "a").Val())
+		msgs := []interface{}{receive(t, ps), receive(t, ps)}
+		assert.ElementsMatch(t, []interface{}{
+			&redis.Message{Channel: "news.tech", Payload: "a"},
+			&redis.Message{Channel: "news.tech", Pattern: "news.*", Payload: "a"},
+		}, msgs)
+		res = append(res, cli.Publish(context.TODO(), "hello", "b").Val())
+		res = append(res, receive(t, ps))
+		res = append(res, cli.Publish(context.TODO(), "news", "c").Val())
+
+		assert.Equal(t, nil, ps.PUnsubscribe(context.TODO(), "news.*"))
+		res = append(res, receive(t, ps))
+		res = append(res, cli.Publish(context.TODO(), "news.tech", "d").Val())
+		res = append(res, receive(t, ps))
+		return res
+	}
+	assert.Equal(t, run(rCli), run(mCli))
+}
+
+func TestPubSub_Introspection(t *testing.T) {
+	run := func(cli *redis.Client) []interface{} {
+		ps1 := cli.Subscribe(context.TODO(), "ch1", "ch2")
+		defer ps1.Close()
+		receive(t, ps1)
+		receive(t, ps1)
+		ps2 := cli.Subscribe(context.TODO(), "ch1", "other")
+		defer ps2.Close()
+		receive(t, ps2)
+		receive(t, ps2)
+		ps3 := cli.PSubscribe(context.TODO(), "ch*", "o*")
+		defer ps3.Close()
+		receive(t, ps3)
+		receive(t, ps3)
+
+		var res []interface{}
+		channels := cli.PubSubChannels(context.TODO(), "*").Val()
+		assert.ElementsMatch(t, []string{"ch1", "ch2", "other"}, channels)
+		channels = cli.PubSubChannels(context.TODO(), "ch*").Val()
+		assert.ElementsMatch(t, []string{"ch1", "ch2"}, channels)
+		res = append(res, cli.PubSubNumSub(context.TODO(), "ch1", "ch2", "none").Val())
+		res = append(res, cli.PubSubNumPat(context.TODO()).Val())
+		res = append(res, cli.Publish(context.TODO(), "ch1", "msg").Val())
+		return res
+	}
+	assert.Equal(t, run(rCli), run(mCli))
+
+	// the subscriptions are removed once the clients are closed
+	assert.Eventually(t, func() bool {
+		return len(mCli.PubSubChannels(context.TODO(), "*").Val()) == 0 &&
+			mCli.PubSubNumPat(context.TODO()).Val() == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestPubSub_SubscriberMode(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName)
+
+	run := func(cli *redis.Client) []interface{} {
+		conn := cli.Conn(context.TODO())
+		defer conn.Close()
+		var res []interface{}
+		res = append(res, do(conn, "subscribe", "ch").Val())
+		res = append(res, do(conn, "get", "key").Err())
+		res = append(res, do(conn, "ping").Val())
+		res = append(res, do(conn, "ping", "msg").Val())
+		res = append(res, do(conn, "unsubscribe").Val())
+
+		// leaves the subscriber mode
+		res = append(res, do(conn, "punsubscribe").Val())
+		res = append(res, do(conn, "get", "key").Err())
+		res = append(res, do(conn, "ping").Val())
+		return res
+	}
+	assert.Equal(t, run(rCli), run(mCli))
+}
+
+func TestPubSub_SlowSubscriber(t *testing.T) {
+	// a subscriber never reading its messages
+	conn, err := net.Dial("tcp", test.ModisAddr)
+	assert.Equal(t, nil, err)
+	defer conn.Close()
+	if test.ModisPwd != "" {
+		_, err = conn.Write([]byte(resp.EncArray([][]byte{[]byte("auth"), []byte(test.ModisPwd)})))
+		assert.Equal(t, nil, err)
+	}
+	_, err = conn.Write([]byte(resp.EncArray([][]byte{[]byte("subscribe"), []byte("slow")})))
+	assert.Equal(t, nil, err)
+	assert.Eventually(t, func() bool {
+		return mCli.PubSubNumSub(context.TODO(), "slow").Val()["slow"] == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// the publisher is not blocked, and the subscriber is disconnected once it falls too far behind
+	msg := strings.Repeat("x", 1<<20)
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, nil, mCli.Publish(context.TODO(), "slow", msg).Err())
+	}
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.Eventually(t, func() bool {
+		return mCli.PubSubNumSub(context.TODO(), "slow").Val()["slow"] == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
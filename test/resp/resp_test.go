@@ -58,6 +58,59 @@ func TestArray_Encode(t *testing.T) {
 	assert.Equal("*2\r\n$10\r\nhelloworld\r\n$9\r\nfoobarbaz\r\n", code_array)
 }
 
+func TestArray_Decode(t *testing.T) {
+	assert := assert.New(t)
+	arr, err := resp.DecArray([]byte("*2\r\n$3\r\nget\r\n$3\r\nkey\r\n"))
+	assert.NoError(err)
+	assert.Equal([][]byte{[]byte("get"), []byte("key")}, arr)
+
+	// Empty array
+	arr, err = resp.DecArray([]byte("*0\r\n"))
+	assert.NoError(err)
+	assert.Equal(0, len(arr))
+
+	// Round trip
+	arr, err = resp.DecArray([]byte(resp.EncArray([][]byte{[]byte("zadd"), []byte(""), []byte("a\r\nb")})))
+	assert.NoError(err)
+	assert.Equal([][]byte{[]byte("zadd"), []byte(""), []byte("a\r\nb")}, arr)
+
+	// Missing elements
+	_, err = resp.DecArray([]byte("*2\r\n$3\r\nget\r\n"))
+	assert.Error(err)
+
+	// Invalid indicator
+	_, err = resp.DecArray([]byte("$3\r\nget\r\n"))
+	assert.Error(err)
+}
+
+func TestReply_Decode(t *testing.T) {
+	assert := assert.New(t)
+	val, err := resp.DecReply("+OK\r\n")
+	assert.NoError(err)
+	assert.Equal("OK", val)
+
+	val, err = resp.DecReply(":-3\r\n")
+	assert.NoError(err)
+	assert.Equal(int64(-3), val)
+
+	val, err = resp.DecReply("$-1\r\n")
+	assert.NoError(err)
+	assert.Nil(val)
+
+	// Nested array with a null element
+	val, err = resp.DecReply("*3\r\n$1\r\na\r\n$-1\r\n*1\r\n:1\r\n")
+	assert.NoError(err)
+	assert.Equal([]interface{}{[]byte("a"), nil, []interface{}{int64(1)}}, val)
+
+	// Error reply
+	_, err = resp.DecReply("-ERR index out of range\r\n")
+	assert.Equal(resp.ErrorReply("ERR index out of range"), err)
+
+	// Truncated bulk string
+	_, err = resp.DecReply("$3\r\nab")
+	assert.Error(err)
+}
+
 func TestSimpleString_Encode(t *testing.T) {
 	assert := assert.New(t)
 	encode_msg := resp.EncSimpleString("OK")
@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scripting
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oceanbase/modis/test"
+)
+
+func TestScripting_Eval(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
+
+	scripts := []struct {
+		script string
+		keys   []string
+		args   []interface{}
+	}{
+		{"return 1", nil, nil},
+		{"return 3.99", nil, nil},
+		{"return 'str'", nil, nil},
+		{"return true", nil, nil},
+		{"return {KEYS[1], KEYS[2], ARGV[1], ARGV[2]}", []string{"k1", "k2"}, []interface{}{"a1", 2}},
+		{"return {1, 'two', {3, 'four'}, nil, 5}", nil, nil},
+		{"return redis.call('set', KEYS[1], ARGV[1])", []string{"key"}, []interface{}{"value"}},
+		{"return redis.call('get', KEYS[1])", []string{"key"}, nil},
+		{"return redis.call('get', KEYS[1])", []string{"none"}, nil},
+		{"return redis.call('rpush', KEYS[1], ARGV[1], ARGV[2])", []string{"list"}, []interface{}{"a", 1.5}},
+		{"return redis.call('lrange', KEYS[1], 0, -1)", []string{"list"}, nil},
+		{"return redis.call('incrby', KEYS[1], 10)", []string{"counter"}, nil},
+		{"return redis.call('exists', KEYS[1]) == 1", []string{"counter"}, nil},
+		{"return redis.status_reply('FINE')", nil, nil},
+		{"return redis.sha1hex('')", nil, nil},
+		{"local res = redis.pcall('incr', KEYS[1]); return type(res) == 'table' and res.err ~= nil", []string{"list"}, nil},
+		{"return redis.error_reply('MY error')", nil, nil},
+	}
+	for _, s := range scripts {
+		resRedis, errRedis := rCli.Eval(context.TODO(), s.script, s.keys, s.args...).Result()
+		resModis, errModis := mCli.Eval(context.TODO(), s.script, s.keys, s.args...).Result()
+		assert.Equal(t, errRedis, errModis, s.script)
+		assert.Equal(t, resRedis, resModis, s.script)
+	}
+
+	// errors
+	for _, s := range []string{"return redis.call('incr', KEYS[1])", "return redis.call('nosuchcommand')", "return +"} {
+		errRedis := rCli.Eval(context.TODO(), s, []string{"list"}).Err()
+		errModis := mCli.Eval(context.TODO(), s, []string{"list"}).Err()
+		assert.NotEqual(t, nil, errRedis, s)
+		assert.NotEqual(t, nil, errModis, s)
+	}
+	errModis := mCli.Eval(context.TODO(), "return redis.call('monitor')", nil).Err()
+	assert.NotEqual(t, nil, errModis)
+	assert.Contains(t, errModis.Error(), "not allowed from script")
+	for _, numKeys := range []string{"x", "-1", "2"} {
+		errRedis := rCli.Do(context.TODO(), "eval", "return 1", numKeys, "key").Err()
+		errModis := mCli.Do(context.TODO(), "eval", "return 1", numKeys, "key").Err()
+		assert.Equal(t, errRedis, errModis, numKeys)
+	}
+}
+
+func TestScripting_EvalSha(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
+
+	script := "return redis.call('incrby', KEYS[1], ARGV[1])"
+	shaRedis, err := rCli.ScriptLoad(context.TODO(), script).Result()
+	assert.Equal(t, nil, err)
+	shaModis, err := mCli.ScriptLoad(context.TODO(), script).Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, shaRedis, shaModis)
+
+	for i := 0; i < 3; i++ {
+		resRedis, err := rCli.EvalSha(context.TODO(), shaRedis, []string{"counter"}, 2).Result()
+		assert.Equal(t, nil, err)
+		resModis, err := mCli.EvalSha(context.TODO(), strings.ToUpper(shaModis), []string{"counter"}, 2).Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, resRedis, resModis)
+	}
+
+	// scripts run by EVAL are cached too
+	sha := "e0e1f9fabfc9d4800c877a703b823ac0578ff8db" // return 1
+	existsRedis, err := rCli.ScriptExists(context.TODO(), shaRedis, sha).Result()
+	assert.Equal(t, nil, err)
+	existsModis, err := mCli.ScriptExists(context.TODO(), shaModis, sha).Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, existsRedis, existsModis)
+	assert.Equal(t, nil, mCli.Eval(context.TODO(), "return 1", nil).Err())
+	existsModis, err = mCli.ScriptExists(context.TODO(), sha).Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []bool{true}, existsModis)
+
+	// flush
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		assert.Equal(t, nil, cli.ScriptFlush(context.TODO()).Err())
+		exists, err := cli.ScriptExists(context.TODO(), shaRedis).Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []bool{false}, exists)
+	}
+	_, errRedis := rCli.EvalSha(context.TODO(), shaRedis, []string{"counter"}, 2).Result()
+	_, errModis := mCli.EvalSha(context.TODO(), shaModis, []string{"counter"}, 2).Result()
+	assert.Equal(t, errRedis, errModis)
+}
+
+func TestScripting_Timeout(t *testing.T) {
+	// the script runs for 5 seconds by default, longer than the read timeout of mCli
+	cli := redis.NewClient(&redis.Options{
+		Addr:        test.ModisAddr,
+		Password:    test.ModisPwd,
+		DB:          test.ModisDB,
+		ReadTimeout: 10 * time.Second,
+	})
+	defer cli.Close()
+	err := cli.Eval(context.TODO(), "while true do end", nil).Err()
+	assert.ErrorContains(t, err, "script timed out")
+
+	// the server is still serving
+	assert.Equal(t, nil, mCli.Ping(context.TODO()).Err())
+}
@@ -18,9 +18,11 @@ package set
 
 import (
 	"context"
+	"sort"
 	"strconv"
 	"testing"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/oceanbase/modis/test"
@@ -455,3 +457,37 @@ func TestSet_SRem(t *testing.T) {
 	assert.Equal(t, nil, err)
 	assert.EqualValues(t, sremRedis, sremModis)
 }
+
+// sscanAll iterates the set with SSCAN and returns the members sorted
+func sscanAll(t *testing.T, cli *redis.Client, key string, match string, count int64) []string {
+	var members []string
+	var cursor uint64
+	for {
+		page, next, err := cli.SScan(context.TODO(), key, cursor, match, count).Result()
+		assert.Equal(t, nil, err)
+		if err != nil {
+			return nil
+		}
+		members = append(members, page...)
+		if cursor = next; cursor == 0 {
+			sort.Strings(members)
+			return members
+		}
+	}
+}
+
+func TestSet_SScan(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisSetTableName)
+
+	// empty
+	assert.Equal(t, sscanAll(t, rCli, "myset", "", 10), sscanAll(t, mCli, "myset", "", 10))
+
+	members := generateTestData(50)
+	assert.Equal(t, nil, rCli.SAdd(context.TODO(), "myset", members).Err())
+	assert.Equal(t, nil, mCli.SAdd(context.TODO(), "myset", members).Err())
+	for _, count := range []int64{1, 7, 10, 100} {
+		assert.Equal(t, sscanAll(t, rCli, "myset", "", count), sscanAll(t, mCli, "myset", "", count))
+		assert.Equal(t, sscanAll(t, rCli, "myset", "*1?", count), sscanAll(t, mCli, "myset", "*1?", count))
+	}
+	assert.Equal(t, 50, len(sscanAll(t, mCli, "myset", "", 7)))
+}
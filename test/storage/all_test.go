@@ -17,13 +17,21 @@
 package storage
 
 import (
-	"github.com/oceanbase/modis/config"
-	"github.com/oceanbase/modis/storage/obkv"
+	"os"
+	"testing"
 )
 
-type Config interface {
+// the test package is not imported here, its mysql driver
+// conflicts with the one linked in by the obkv backend
+func setup() {
 }
 
-func NewConfig(storeCfg *config.ObkvStorageConfig) Config {
-	return obkv.NewConfig(storeCfg)
+func teardown() {
+}
+
+func TestMain(m *testing.M) {
+	setup()
+	code := m.Run()
+	teardown()
+	os.Exit(code)
 }
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oceanbase/modis/config"
+	"github.com/oceanbase/modis/storage"
+	_ "github.com/oceanbase/modis/storage/memory"
+	_ "github.com/oceanbase/modis/storage/obkv"
+)
+
+type shadowConfig struct {
+	Target   string `mapstructure:"target"`
+	Sampling int    `mapstructure:"sampling"`
+}
+
+func TestRegistry_Backends(t *testing.T) {
+	assert.Subset(t, storage.Backends(), []string{"memory", "obkv"})
+}
+
+func TestRegistry_UnknownBackend(t *testing.T) {
+	_, err := storage.NewStorage(&config.StorageConfig{Backend: "unknown"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown storage backend 'unknown'")
+	assert.Contains(t, err.Error(), "memory, obkv")
+}
+
+func TestRegistry_Register(t *testing.T) {
+	var section shadowConfig
+	storage.Register("shadow", func(cfg *config.StorageConfig) (storage.Storage, error) {
+		if err := cfg.DecodeSection("shadow", &section); err != nil {
+			return nil, err
+		}
+		return storage.NewStorage(&config.StorageConfig{Backend: section.Target})
+	})
+	assert.Contains(t, storage.Backends(), "shadow")
+	assert.Panics(t, func() {
+		storage.Register("shadow", func(cfg *config.StorageConfig) (storage.Storage, error) { return nil, nil })
+	})
+
+	// the backend reads its own section of the storage config
+	v := viper.New()
+	v.Set("storage", map[string]interface{}{
+		"backend": "shadow",
+		"shadow":  map[string]interface{}{"target": "memory", "sampling": "10"},
+	})
+	var cfg config.Config
+	assert.NoError(t, v.Unmarshal(&cfg))
+	s, err := storage.NewStorage(&cfg.Storage)
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+	assert.Equal(t, shadowConfig{Target: "memory", Sampling: 10}, section)
+}
+
+func TestRegistry_DefaultBackend(t *testing.T) {
+	s, err := storage.NewStorage(&config.StorageConfig{})
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+}
@@ -0,0 +1,295 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oceanbase/modis/test"
+)
+
+func TestXGroup(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStreamTableName, test.TestModisStreamGroupTableName)
+
+	doBoth(t, "xadd", "s", "1-1", "f", "v")
+	doBoth(t, "xgroup", "create", "s", "g1", "0")
+	doBoth(t, "xgroup", "create", "s", "g1", "0")
+	doBoth(t, "xgroup", "create", "s", "g2", "$")
+	doBoth(t, "xgroup", "create", "nokey", "g1", "0")
+	doBoth(t, "xgroup", "create", "s2", "g1", "$", "mkstream")
+	doBoth(t, "xlen", "s2")
+	assertDo(t, errors.New("ERR Invalid stream ID specified as stream command argument"), "xgroup", "create", "s", "g3", "abc")
+
+	assertDo(t, "OK", "xgroup", "setid", "s", "g1", "1-1")
+	assertDo(t, errors.New("NOGROUP No such consumer group 'nogroup' for key name 's'"), "xgroup", "setid", "s", "nogroup", "1-1")
+	doBoth(t, "xgroup", "createconsumer", "s", "g1", "c1")
+	doBoth(t, "xgroup", "createconsumer", "s", "g1", "c1")
+	doBoth(t, "xgroup", "createconsumer", "s", "nogroup", "c1")
+	doBoth(t, "xgroup", "delconsumer", "s", "g1", "c1")
+	doBoth(t, "xgroup", "delconsumer", "s", "g1", "c1")
+	doBoth(t, "xgroup", "destroy", "s", "g2")
+	doBoth(t, "xgroup", "destroy", "s", "g2")
+
+	// wrong args
+	assertDo(t, errors.New("ERR wrong number of arguments for 'xgroup|create' command"), "xgroup", "create", "s", "g1")
+	assertDo(t, errors.New("ERR value for ENTRIESREAD must be positive or -1"), "xgroup", "create", "s", "g4", "0", "entriesread", "-2")
+	assertDo(t, errors.New("ERR unknown subcommand or wrong number of arguments for 'create'. Try XGROUP HELP."),
+		"xgroup", "create", "s", "g4", "0", "unknown")
+
+	// the groups are deleted with the stream
+	doBoth(t, "del", "s")
+	doBoth(t, "xadd", "s", "1-1", "f", "v")
+	doBoth(t, "xgroup", "create", "s", "g1", "0")
+
+	val, err := mCli.Do(context.TODO(), "xgroup", "help").StringSlice()
+	assert.Equal(t, nil, err)
+	assert.Less(t, 0, len(val))
+}
+
+func TestXReadGroup(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStreamTableName, test.TestModisStreamGroupTableName)
+
+	for i := 1; i <= 4; i++ {
+		doBoth(t, "xadd", "s", i, "f", i)
+	}
+	doBoth(t, "xgroup", "create", "s", "g", "0")
+
+	// new entries
+	doBoth(t, "xreadgroup", "group", "g", "c1", "count", "2", "streams", "s", ">")
+	doBoth(t, "xreadgroup", "group", "g", "c2", "count", "1", "streams", "s", ">")
+	doBoth(t, "xreadgroup", "group", "g", "c1", "noack", "streams", "s", ">")
+	doBoth(t, "xreadgroup", "group", "g", "c1", "streams", "s", ">")
+
+	// history of the consumer
+	assertDo(t, []interface{}{[]interface{}{"s", []interface{}{entry("1-0", "f", "1"), entry("2-0", "f", "2")}}},
+		"xreadgroup", "group", "g", "c1", "streams", "s", "0")
+	assertDo(t, []interface{}{[]interface{}{"s", []interface{}{entry("3-0", "f", "3")}}},
+		"xreadgroup", "group", "g", "c2", "streams", "s", "0")
+	assertDo(t, []interface{}{[]interface{}{"s", []interface{}{entry("2-0", "f", "2")}}},
+		"xreadgroup", "group", "g", "c1", "streams", "s", "1-0")
+	assertDo(t, []interface{}{[]interface{}{"s", []interface{}{}}},
+		"xreadgroup", "group", "g", "c3", "streams", "s", "0")
+
+	// ack
+	assertDo(t, int64(2), "xack", "s", "g", "1", "3", "5")
+	assertDo(t, int64(0), "xack", "s", "g", "1")
+	assertDo(t, int64(0), "xack", "s", "nogroup", "abc")
+	assertDo(t, errors.New("ERR Invalid stream ID specified as stream command argument"), "xack", "s", "g", "abc")
+	assertDo(t, []interface{}{[]interface{}{"s", []interface{}{entry("2-0", "f", "2")}}},
+		"xreadgroup", "group", "g", "c1", "streams", "s", "0")
+
+	// entries deleted from the stream are still pending
+	assertDo(t, int64(1), "xdel", "s", "2")
+	assertDo(t, []interface{}{[]interface{}{"s", []interface{}{entry("2-0")}}},
+		"xreadgroup", "group", "g", "c1", "streams", "s", "0")
+
+	// wrong args
+	doBoth(t, "xreadgroup", "group", "nogroup", "c1", "streams", "s", ">")
+	doBoth(t, "xreadgroup", "group", "g", "c1", "streams", "nokey", ">")
+	err := mCli.Do(context.TODO(), "xreadgroup", "group", "g", "c1", "streams", "s", "$").Err()
+	assert.Contains(t, err.Error(), "ERR The $ ID is meaningless in the context of XREADGROUP")
+	err = mCli.Do(context.TODO(), "xread", "group", "g", "c1", "streams", "s", "0").Err()
+	assert.Equal(t, "ERR The GROUP option is only supported by XREADGROUP. You called XREAD instead.", err.Error())
+	err = mCli.Do(context.TODO(), "xreadgroup", "count", "1", "streams", "s", "0", "x", "y").Err()
+	assert.Equal(t, "ERR Missing GROUP option for XREADGROUP", err.Error())
+}
+
+func TestXReadGroup_Block(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStreamTableName, test.TestModisStreamGroupTableName)
+
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		cli.XGroupCreateMkStream(context.TODO(), "s", "g", "$")
+		addLater(cli, "s")
+		st := time.Now()
+		val, err := cli.Do(context.TODO(), "xreadgroup", "group", "g", "c", "block", "5000", "streams", "s", ">").Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []interface{}{[]interface{}{"s", []interface{}{
+			[]interface{}{"100-1", []interface{}{"f", "v"}}}}}, val)
+		assert.Less(t, time.Since(st), 2*time.Second)
+
+		// the history is never blocked on
+		val, err = cli.Do(context.TODO(), "xreadgroup", "group", "g", "c2", "block", "5000", "streams", "s", "0").Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []interface{}{[]interface{}{"s", []interface{}{}}}, val)
+	}
+}
+
+func TestXPending(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStreamTableName, test.TestModisStreamGroupTableName)
+
+	for i := 1; i <= 4; i++ {
+		doBoth(t, "xadd", "s", i, "f", i)
+	}
+	doBoth(t, "xgroup", "create", "s", "g", "0")
+	doBoth(t, "xpending", "s", "g")
+	doBoth(t, "xreadgroup", "group", "g", "c1", "count", "3", "streams", "s", ">")
+	doBoth(t, "xreadgroup", "group", "g", "c2", "streams", "s", ">")
+	doBoth(t, "xpending", "s", "g")
+	doBoth(t, "xpending", "s", "nogroup")
+
+	// the idle times differ between redis and modis
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		val, err := cli.XPendingExt(context.TODO(), &redis.XPendingExtArgs{Stream: "s", Group: "g", Start: "-", End: "+", Count: 10}).Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 4, len(val))
+		assert.Equal(t, "1-0", val[0].ID)
+		assert.Equal(t, "c1", val[0].Consumer)
+		assert.Equal(t, int64(1), val[0].RetryCount)
+		val, err = cli.XPendingExt(context.TODO(), &redis.XPendingExtArgs{Stream: "s", Group: "g", Start: "2", End: "+", Count: 2, Consumer: "c1"}).Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 2, len(val))
+		assert.Equal(t, "3-0", val[1].ID)
+		val, err = cli.XPendingExt(context.TODO(), &redis.XPendingExtArgs{Stream: "s", Group: "g", Idle: time.Hour, Start: "-", End: "+", Count: 10}).Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 0, len(val))
+	}
+	val, err := mCli.XPendingExt(context.TODO(), &redis.XPendingExtArgs{Stream: "s", Group: "g", Start: "(1", End: "(4-0", Count: 10}).Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(val))
+	assert.Equal(t, "2-0", val[0].ID)
+	doBoth(t, "xpending", "s", "g", "-", "+")
+	doBoth(t, "xpending", "s", "g", "-", "+", "abc")
+}
+
+func TestXClaim(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStreamTableName, test.TestModisStreamGroupTableName)
+
+	for i := 1; i <= 4; i++ {
+		doBoth(t, "xadd", "s", i, "f", i)
+	}
+	doBoth(t, "xgroup", "create", "s", "g", "0")
+	doBoth(t, "xreadgroup", "group", "g", "c1", "streams", "s", ">")
+
+	doBoth(t, "xclaim", "s", "g", "c2", "0", "1-0", "2-0")
+	assertDo(t, []interface{}{}, "xclaim", "s", "g", "c2", "3600000", "3-0")
+	doBoth(t, "xclaim", "s", "g", "c2", "0", "3-0", "justid")
+	doBoth(t, "xclaim", "s", "g", "c2", "0", "9-0")
+	doBoth(t, "xpending", "s", "g")
+
+	// an entry deleted from the stream is dropped from the pending entries
+	doBoth(t, "xdel", "s", "4-0")
+	assertDo(t, []interface{}{}, "xclaim", "s", "g", "c2", "0", "4-0")
+	assertDo(t, []interface{}{int64(3), "1-0", "3-0", []interface{}{[]interface{}{"c2", "3"}}}, "xpending", "s", "g")
+
+	// FORCE creates the pending entry
+	doBoth(t, "xadd", "s", "5", "f", "v")
+	assertDo(t, []interface{}{"5-0"}, "xclaim", "s", "g", "c3", "0", "5-0", "force", "justid")
+	assertDo(t, []interface{}{int64(4), "1-0", "5-0", []interface{}{[]interface{}{"c2", "3"}, []interface{}{"c3", "1"}}}, "xpending", "s", "g")
+
+	val, err := mCli.XPendingExt(context.TODO(), &redis.XPendingExtArgs{Stream: "s", Group: "g", Start: "1", End: "1", Count: 1}).Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(2), val[0].RetryCount)
+	mCli.Do(context.TODO(), "xclaim", "s", "g", "c1", "0", "1", "retrycount", "7", "idle", "60000")
+	val, err = mCli.XPendingExt(context.TODO(), &redis.XPendingExtArgs{Stream: "s", Group: "g", Start: "1", End: "1", Count: 1}).Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(7), val[0].RetryCount)
+	assert.LessOrEqual(t, 60*time.Second, val[0].Idle)
+
+	// wrong args
+	doBoth(t, "xclaim", "s", "nogroup", "c1", "0", "1")
+	err = mCli.Do(context.TODO(), "xclaim", "s", "g", "c1", "abc", "1").Err()
+	assert.Equal(t, "ERR Invalid min-idle-time argument for XCLAIM", err.Error())
+	err = mCli.Do(context.TODO(), "xclaim", "s", "g", "c1", "0", "1", "unknown").Err()
+	assert.Equal(t, "ERR Unrecognized XCLAIM option 'unknown'", err.Error())
+}
+
+func TestXAutoClaim(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStreamTableName, test.TestModisStreamGroupTableName)
+
+	for i := 1; i <= 5; i++ {
+		doBoth(t, "xadd", "s", i, "f", i)
+	}
+	doBoth(t, "xgroup", "create", "s", "g", "0")
+	doBoth(t, "xreadgroup", "group", "g", "c1", "streams", "s", ">")
+	doBoth(t, "xautoclaim", "s", "g", "c2", "0", "0", "count", "2")
+	doBoth(t, "xautoclaim", "s", "g", "c2", "0", "3", "count", "2", "justid")
+	doBoth(t, "xautoclaim", "s", "g", "c2", "3600000", "0")
+	doBoth(t, "xautoclaim", "s", "nogroup", "c2", "0", "0")
+
+	// the entries deleted from the stream are dropped from the pending entries and replied
+	doBoth(t, "xdel", "s", "2-0")
+	assertDo(t, []interface{}{"0-0", []interface{}{entry("1-0", "f", "1"), entry("3-0", "f", "3"), entry("4-0", "f", "4"),
+		entry("5-0", "f", "5")}, []interface{}{"2-0"}}, "xautoclaim", "s", "g", "c3", "0", "0")
+	assertDo(t, []interface{}{int64(4), "1-0", "5-0", []interface{}{[]interface{}{"c3", "4"}}}, "xpending", "s", "g")
+	assertDo(t, errors.New("ERR COUNT must be > 0"), "xautoclaim", "s", "g", "c2", "0", "0", "count", "0")
+}
+
+func TestXInfo(t *testing.T) {
+	defer mCli.Del(context.TODO(), "s")
+
+	for i := 1; i <= 3; i++ {
+		mCli.XAdd(context.TODO(), &redis.XAddArgs{Stream: "s", ID: strconv.Itoa(i), Values: []string{"f", "v"}})
+	}
+	mCli.XDel(context.TODO(), "s", "1")
+	mCli.XGroupCreate(context.TODO(), "s", "g", "0")
+	mCli.XReadGroup(context.TODO(), &redis.XReadGroupArgs{Group: "g", Consumer: "c", Streams: []string{"s", ">"}, Count: 1})
+
+	val, err := mCli.Do(context.TODO(), "xinfo", "stream", "s").Slice()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []interface{}{
+		"length", int64(2),
+		"radix-tree-keys", int64(0),
+		"radix-tree-nodes", int64(0),
+		"last-generated-id", "3-0",
+		"max-deleted-entry-id", "1-0",
+		"entries-added", int64(3),
+		"recorded-first-entry-id", "2-0",
+		"groups", int64(1),
+		"first-entry", []interface{}{"2-0", []interface{}{"f", "v"}},
+		"last-entry", []interface{}{"3-0", []interface{}{"f", "v"}},
+	}, val)
+
+	val, err = mCli.Do(context.TODO(), "xinfo", "groups", "s").Slice()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []interface{}{[]interface{}{
+		"name", "g",
+		"consumers", int64(1),
+		"pending", int64(1),
+		"last-delivered-id", "2-0",
+		"entries-read", int64(2),
+		"lag", int64(1),
+	}}, val)
+
+	val, err = mCli.Do(context.TODO(), "xinfo", "consumers", "s", "g").Slice()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(val))
+	consumer := val[0].([]interface{})
+	assert.Equal(t, "c", consumer[1])
+	assert.Equal(t, int64(1), consumer[3])
+
+	val, err = mCli.Do(context.TODO(), "xinfo", "stream", "s", "full").Slice()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "entries", val[14])
+	assert.Equal(t, 2, len(val[15].([]interface{})))
+	groups := val[17].([]interface{})
+	assert.Equal(t, 1, len(groups))
+	group := groups[0].([]interface{})
+	assert.Equal(t, "pel-count", group[8])
+	assert.Equal(t, int64(1), group[9])
+
+	err = mCli.Do(context.TODO(), "xinfo", "stream", "nokey").Err()
+	assert.Equal(t, "ERR no such key", err.Error())
+	err = mCli.Do(context.TODO(), "xinfo", "consumers", "s", "nogroup").Err()
+	assert.Equal(t, "NOGROUP No such consumer group 'nogroup' for key name 's'", err.Error())
+}
@@ -0,0 +1,289 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oceanbase/modis/test"
+)
+
+// doBoth runs the command on redis and modis and compares the replies
+func doBoth(t *testing.T, args ...interface{}) interface{} {
+	val, err := rCli.Do(context.TODO(), args...).Result()
+	val_m, err_m := mCli.Do(context.TODO(), args...).Result()
+	assert.Equal(t, err, err_m, args)
+	assert.Equal(t, val, val_m, args)
+	return val_m
+}
+
+// assertDo runs the command on modis and compares the reply with the one of redis 7,
+// for the commands that the redis under test does not support or replies differently
+func assertDo(t *testing.T, expected interface{}, args ...interface{}) {
+	val, err := mCli.Do(context.TODO(), args...).Result()
+	if msg, ok := expected.(error); ok {
+		assert.Equal(t, msg.Error(), err.Error(), args)
+		return
+	}
+	assert.Equal(t, nil, err, args)
+	assert.Equal(t, expected, val, args)
+}
+
+// entry is the reply of an entry of a stream
+func entry(id string, fields ...interface{}) []interface{} {
+	if len(fields) == 0 {
+		return []interface{}{id, nil}
+	}
+	return []interface{}{id, fields}
+}
+
+func TestXAdd(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStreamTableName, test.TestModisStreamGroupTableName)
+
+	doBoth(t, "xadd", "s", "1-1", "f1", "v1")
+	doBoth(t, "xadd", "s", "1-*", "f2", "v2")
+	doBoth(t, "xadd", "s", "2", "f3", "v3", "f4", "v4")
+	doBoth(t, "xlen", "s")
+	doBoth(t, "xrange", "s", "-", "+")
+
+	// ids
+	doBoth(t, "xadd", "s", "2-0", "f", "v")
+	doBoth(t, "xadd", "s", "1-5", "f", "v")
+	doBoth(t, "xadd", "s2", "0-0", "f", "v")
+	doBoth(t, "xadd", "s", "abc", "f", "v")
+	doBoth(t, "xadd", "s", "1-abc", "f", "v")
+	doBoth(t, "xadd", "s", "-", "f", "v")
+
+	// auto ids
+	id, err := mCli.XAdd(context.TODO(), &redis.XAddArgs{Stream: "s", Values: []string{"f", "v"}}).Result()
+	assert.Equal(t, nil, err)
+	assert.Regexp(t, `^\d+-\d+$`, id)
+	id2, err := mCli.XAdd(context.TODO(), &redis.XAddArgs{Stream: "s", Values: []string{"f", "v"}}).Result()
+	assert.Equal(t, nil, err)
+	assert.Less(t, id, id2)
+	rCli.XAdd(context.TODO(), &redis.XAddArgs{Stream: "s", Values: []string{"f", "v"}})
+	rCli.XAdd(context.TODO(), &redis.XAddArgs{Stream: "s", Values: []string{"f", "v"}})
+
+	// NOMKSTREAM
+	doBoth(t, "xadd", "s3", "nomkstream", "*", "f", "v")
+	doBoth(t, "exists", "s3")
+
+	// wrong args
+	doBoth(t, "xadd", "s", "*", "f")
+	assertDo(t, errors.New("ERR wrong number of arguments for 'xadd' command"), "xadd", "s", "*", "f", "v", "f2")
+	doBoth(t, "xadd", "s", "maxlen", "abc", "*", "f", "v")
+	doBoth(t, "xadd", "s", "maxlen", "-1", "*", "f", "v")
+
+	// wrong type
+	doBoth(t, "set", "str", "v")
+	doBoth(t, "xadd", "str", "*", "f", "v")
+	doBoth(t, "xlen", "str")
+	doBoth(t, "type", "s")
+}
+
+func TestXAdd_Trim(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStreamTableName, test.TestModisStreamGroupTableName)
+
+	for i := 1; i <= 10; i++ {
+		doBoth(t, "xadd", "s", i, "f", i)
+	}
+	doBoth(t, "xadd", "s", "maxlen", "8", "11", "f", "11")
+	doBoth(t, "xadd", "s", "maxlen", "=", "5", "12", "f", "12")
+	doBoth(t, "xrange", "s", "-", "+")
+	doBoth(t, "xadd", "s", "minid", "10", "13", "f", "13")
+	doBoth(t, "xrange", "s", "-", "+")
+
+	doBoth(t, "xtrim", "s", "maxlen", "2")
+	doBoth(t, "xtrim", "s", "minid", "=", "13")
+	doBoth(t, "xtrim", "s", "maxlen", "0")
+	doBoth(t, "xlen", "s")
+	doBoth(t, "exists", "s")
+	doBoth(t, "xtrim", "nokey", "maxlen", "0")
+
+	// approximate trimming is exact up to LIMIT in modis
+	for i := 1; i <= 10; i++ {
+		mCli.Do(context.TODO(), "xadd", "s2", i, "f", i)
+	}
+	n, err := mCli.Do(context.TODO(), "xtrim", "s2", "maxlen", "~", "2", "limit", "3").Int()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, n)
+	n, err = mCli.Do(context.TODO(), "xtrim", "s2", "maxlen", "~", "2").Int()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 5, n)
+	mCli.Del(context.TODO(), "s2")
+
+	// wrong args
+	err = mCli.Do(context.TODO(), "xtrim", "s", "maxlen", "2", "limit", "3").Err()
+	assert.Equal(t, "ERR syntax error, LIMIT cannot be used without the special ~ option", err.Error())
+	err = mCli.Do(context.TODO(), "xtrim", "s", "limit", "3").Err()
+	assert.Equal(t, "ERR syntax error, LIMIT cannot be used without specifying a trimming strategy", err.Error())
+	err = mCli.Do(context.TODO(), "xtrim", "s", "limit", "0").Err()
+	assert.Equal(t, "ERR syntax error, XTRIM must be called with a trimming strategy", err.Error())
+	err = mCli.Do(context.TODO(), "xtrim", "s", "maxlen", "2", "minid", "3").Err()
+	assert.Equal(t, "ERR syntax error, MAXLEN and MINID options at the same time are not compatible", err.Error())
+	err = mCli.Do(context.TODO(), "xtrim", "s", "maxlen", "~", "2", "limit", "-1").Err()
+	assert.Equal(t, "ERR The LIMIT argument must be >= 0.", err.Error())
+}
+
+func TestXRange(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStreamTableName, test.TestModisStreamGroupTableName)
+
+	for i := 1; i <= 5; i++ {
+		doBoth(t, "xadd", "s", fmt.Sprintf("1-%d", i), "f", i)
+		doBoth(t, "xadd", "s", fmt.Sprintf("2-%d", i), "f", i)
+	}
+	doBoth(t, "xrange", "s", "-", "+")
+	doBoth(t, "xrange", "s", "1", "1")
+	doBoth(t, "xrange", "s", "1-3", "2-2")
+	doBoth(t, "xrange", "s", "(1-3", "(2-2")
+	doBoth(t, "xrange", "s", "-", "+", "count", "3")
+	doBoth(t, "xrange", "s", "3", "+")
+	doBoth(t, "xrevrange", "s", "+", "-")
+	doBoth(t, "xrevrange", "s", "2", "1-4", "count", "2")
+	doBoth(t, "xrevrange", "s", "(2-3", "-")
+	doBoth(t, "xrange", "nokey", "-", "+")
+
+	// wrong args
+	doBoth(t, "xrange", "s", "abc", "+")
+	doBoth(t, "xrange", "s", "-", "+", "count")
+	doBoth(t, "xrange", "s", "-", "+", "count", "abc")
+	doBoth(t, "xrange", "s", "-", "+", "limit", "1")
+
+	// COUNT 0 replies a null array since redis 7
+	assertDo(t, redis.Nil, "xrange", "s", "-", "+", "count", "0")
+	assertDo(t, []interface{}{}, "xrange", "nokey", "-", "+", "count", "0")
+	err := mCli.Do(context.TODO(), "xrange", "s", "(18446744073709551615-18446744073709551615", "+").Err()
+	assert.Equal(t, "ERR invalid start ID for the interval", err.Error())
+	err = mCli.Do(context.TODO(), "xrange", "s", "-", "(0-0").Err()
+	assert.Equal(t, "ERR invalid end ID for the interval", err.Error())
+}
+
+func TestXDel(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStreamTableName, test.TestModisStreamGroupTableName)
+
+	for i := 1; i <= 5; i++ {
+		doBoth(t, "xadd", "s", i, "f", i)
+	}
+	doBoth(t, "xdel", "s", "2-0", "4-0", "6-0")
+	doBoth(t, "xdel", "s", "2-0")
+	doBoth(t, "xrange", "s", "-", "+")
+	doBoth(t, "xlen", "s")
+	doBoth(t, "xdel", "nokey", "1")
+	doBoth(t, "xdel", "s", "abc")
+
+	// the deleted ids are never reused
+	doBoth(t, "xdel", "s", "5-0")
+	assertDo(t, errors.New("ERR The ID specified in XADD is equal or smaller than the target stream top item"), "xadd", "s", "5", "f", "v")
+	assertDo(t, int64(1), "xdel", "s", "3")
+}
+
+func TestXRead(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStreamTableName, test.TestModisStreamGroupTableName)
+
+	for i := 1; i <= 3; i++ {
+		doBoth(t, "xadd", "s1", i, "f", i)
+		doBoth(t, "xadd", "s2", i+10, "f", i)
+	}
+	doBoth(t, "xread", "streams", "s1", "s2", "0", "0")
+	doBoth(t, "xread", "count", "2", "streams", "s1", "s2", "1", "11-0")
+	doBoth(t, "xread", "streams", "s1", "s2", "3", "0")
+	doBoth(t, "xread", "streams", "s1", "$")
+	doBoth(t, "xread", "streams", "nokey", "0")
+
+	// wrong args
+	doBoth(t, "xread", "streams", "s1", "s2", "0")
+	doBoth(t, "xread", "streams", "s1", "abc")
+	assertDo(t, errors.New("ERR value is not an integer or out of range"), "xread", "count", "abc", "streams", "s1", "0")
+	doBoth(t, "xread", "s1", "0")
+	err := mCli.Do(context.TODO(), "xread", "streams", "s1", ">").Err()
+	assert.Equal(t, "ERR The > ID can be specified only when calling XREADGROUP using the GROUP <group> <consumer> option.", err.Error())
+	err = mCli.Do(context.TODO(), "xread", "block", "-1", "streams", "s1", "0").Err()
+	assert.Equal(t, "ERR timeout is negative", err.Error())
+}
+
+func TestStreamKey(t *testing.T) {
+	defer mCli.Del(context.TODO(), "s", "s2", "s3")
+
+	mCli.XAdd(context.TODO(), &redis.XAddArgs{Stream: "s", ID: "1-1", Values: []string{"f", "v"}})
+	mCli.XGroupCreate(context.TODO(), "s", "g", "0")
+	assertDo(t, "stream", "type", "s")
+	keys, _, err := mCli.ScanType(context.TODO(), 0, "*", 10, "stream").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"s"}, keys)
+
+	// the groups go along with the stream
+	assertDo(t, "OK", "rename", "s", "s2")
+	assertDo(t, int64(1), "copy", "s2", "s3")
+	assertDo(t, int64(0), "exists", "s")
+	for _, key := range []string{"s2", "s3"} {
+		groups, err := mCli.Do(context.TODO(), "xinfo", "groups", key).Slice()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 1, len(groups))
+	}
+	mCli.XReadGroup(context.TODO(), &redis.XReadGroupArgs{Group: "g", Consumer: "c", Streams: []string{"s3", ">"}})
+	assertDo(t, []interface{}{int64(0), nil, nil, nil}, "xpending", "s2", "g")
+
+	assertDo(t, int64(1), "pexpire", "s2", "100")
+	time.Sleep(200 * time.Millisecond)
+	assertDo(t, int64(0), "exists", "s2")
+	assertDo(t, int64(1), "pexpire", "s3", "100000")
+	assertDo(t, int64(1), "persist", "s3")
+	assertDo(t, int64(-1), "ttl", "s3")
+}
+
+// addLater appends an entry to key after a while
+func addLater(cli *redis.Client, key string) {
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cli.XAdd(context.TODO(), &redis.XAddArgs{Stream: key, ID: "100-1", Values: []string{"f", "v"}})
+	}()
+}
+
+func TestXRead_Block(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStreamTableName, test.TestModisStreamGroupTableName)
+
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		cli.XAdd(context.TODO(), &redis.XAddArgs{Stream: "s", ID: "1-1", Values: []string{"f", "v"}})
+	}
+
+	// not blocked
+	doBoth(t, "xread", "block", "100", "streams", "s", "0")
+
+	// timeout
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		err := cli.Do(context.TODO(), "xread", "block", "100", "streams", "s", "$").Err()
+		assert.Equal(t, redis.Nil, err)
+	}
+
+	// woken up by XADD, "$" reads the entries added after the call only
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		addLater(cli, "s")
+		st := time.Now()
+		val, err := cli.Do(context.TODO(), "xread", "block", "5000", "streams", "nokey", "s", "$", "$").Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []interface{}{[]interface{}{"s", []interface{}{
+			[]interface{}{"100-1", []interface{}{"f", "v"}}}}}, val)
+		assert.Less(t, time.Since(st), 2*time.Second)
+	}
+}
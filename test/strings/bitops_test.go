@@ -0,0 +1,255 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package strings
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oceanbase/modis/test"
+)
+
+// assertModis asserts the reply of modis, for the commands or options that the redis under test lacks
+func assertModis(t *testing.T, expect interface{}, args ...interface{}) {
+	res, err := modisCli.Do(context.TODO(), args...).Result()
+	if expectErr, ok := expect.(error); ok {
+		assert.Equal(t, expectErr.Error(), errString(err), args)
+		return
+	}
+	if err == redis.Nil {
+		err = nil
+	}
+	assert.Equal(t, nil, err, args)
+	assert.Equal(t, expect, res, args)
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func testGet(t *testing.T, key string) {
+	redisRes, redisErr := redisCli.Get(context.TODO(), key).Result()
+	modisRes, modisErr := modisCli.Get(context.TODO(), key).Result()
+	assert.Equal(t, redisErr, modisErr)
+	assert.Equal(t, redisRes, modisRes)
+}
+
+func testBitOp(t *testing.T, op string, dest string, keys ...string) {
+	args := []interface{}{"bitop", op, dest}
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	redisRes, redisErr := redisCli.Do(context.TODO(), args...).Result()
+	modisRes, modisErr := modisCli.Do(context.TODO(), args...).Result()
+	assert.Equal(t, errString(redisErr), errString(modisErr), args)
+	assert.Equal(t, redisRes, modisRes, args)
+	testGet(t, dest)
+}
+
+func TestBitOp(t *testing.T) {
+	defer test.ClearDb(0, redisCli, test.TestModisStringTableName)
+
+	// a single source is copied
+	SetKey(t, "a", "\x01\x02\xff")
+	testBitOp(t, "and", "res", "a")
+	testBitOp(t, "or", "res", "a")
+	testBitOp(t, "xor", "res", "a")
+	testBitOp(t, "not", "res", "a")
+
+	// a missing key is a string of zeros, and the shorter strings are padded with zeros
+	SetKey(t, "b", "\x01\x02\xff\xff")
+	testBitOp(t, "and", "res", "nokey", "a")
+	testBitOp(t, "OR", "res", "nokey", "a", "b")
+	testBitOp(t, "xor", "res", "a", "b")
+	testBitOp(t, "and", "res", "a", "b")
+
+	// the destination is one of the sources
+	SetKey(t, "s", "\xaa")
+	testBitOp(t, "not", "s", "s")
+
+	// an empty result deletes the destination
+	SetKey(t, "empty", "")
+	testBitOp(t, "not", "res", "empty")
+	testBitOp(t, "or", "res", "nokey")
+
+	assertModis(t, errors.New("ERR BITOP NOT must be called with a single source key."), "bitop", "not", "res", "a", "b")
+	assertModis(t, errors.New("ERR syntax error"), "bitop", "nand", "res", "a", "b")
+
+	modisCli.LPush(context.TODO(), "list", "foo")
+	defer modisCli.Del(context.TODO(), "list")
+	assertModis(t, errors.New("WRONGTYPE Operation against a key holding the wrong kind of value"), "bitop", "xor", "res", "a", "list")
+}
+
+func TestBitPos(t *testing.T) {
+	defer test.ClearDb(0, redisCli, test.TestModisStringTableName)
+
+	// a missing key is an infinite string of zeros
+	assertModis(t, int64(0), "bitpos", "str", 0)
+	assertModis(t, int64(-1), "bitpos", "str", 1)
+	assertModis(t, int64(0), "bitpos", "str", 0, 0, -1, "bit")
+
+	SetKey(t, "str", "\xff\xf0\x00")
+	assertModis(t, int64(12), "bitpos", "str", 0)
+	assertModis(t, int64(12), "bitpos", "str", 0, 0, -1, "bit")
+	assertModis(t, int64(12), "bitpos", "str", 0, 1)
+	assertModis(t, int64(12), "bitpos", "str", 0, 1, -1, "bit")
+	SetKey(t, "str", "\x00\x0f\x00")
+	assertModis(t, int64(12), "bitpos", "str", 1)
+	assertModis(t, int64(12), "bitpos", "str", 1, 0, -1, "bit")
+
+	SetKey(t, "str", "\x00\xff\x00")
+	assertModis(t, int64(0), "bitpos", "str", 0, 0, -1)
+	assertModis(t, int64(16), "bitpos", "str", 0, 1, -1)
+	assertModis(t, int64(16), "bitpos", "str", 0, 2, 200)
+	assertModis(t, int64(-1), "bitpos", "str", 0, 1, 1)
+	assertModis(t, int64(0), "bitpos", "str", 0, 0, -1, "BIT")
+	assertModis(t, int64(16), "bitpos", "str", 0, 8, -1, "bit")
+	assertModis(t, int64(16), "bitpos", "str", 0, 16, 200, "bit")
+	assertModis(t, int64(-1), "bitpos", "str", 0, 8, 8, "bit")
+	assertModis(t, int64(8), "bitpos", "str", 1, 0, -1)
+	assertModis(t, int64(8), "bitpos", "str", 1, 1, 1)
+	assertModis(t, int64(-1), "bitpos", "str", 1, 2, 200)
+	assertModis(t, int64(8), "bitpos", "str", 1, 8, 8, "bit")
+	assertModis(t, int64(-1), "bitpos", "str", 1, 16, -1, "bit")
+	assertModis(t, int64(10), "bitpos", "str", 1, 10, 14, "bit")
+
+	// the string is padded with ones on the right only if the end is not given
+	SetKey(t, "str", "\xff\xff\xff")
+	assertModis(t, int64(24), "bitpos", "str", 0)
+	assertModis(t, int64(24), "bitpos", "str", 0, 0)
+	assertModis(t, int64(-1), "bitpos", "str", 0, 0, -1)
+	assertModis(t, int64(-1), "bitpos", "str", 0, 0, -1, "bit")
+	assertModis(t, int64(-1), "bitpos", "str", 0, 5)
+
+	assertModis(t, errors.New("ERR The bit argument must be 1 or 0."), "bitpos", "str", 2)
+	assertModis(t, errors.New("ERR syntax error"), "bitpos", "str", 0, 0, -1, "bits")
+	assertModis(t, errors.New("ERR value is not an integer or out of range"), "bitpos", "str", 0, "a")
+}
+
+func TestBitCount_Unit(t *testing.T) {
+	defer test.ClearDb(0, redisCli, test.TestModisStringTableName)
+
+	// f: 01100110, o: 01101111
+	SetKey(t, "s", "foobar")
+	assertModis(t, int64(26), "bitcount", "s", 0, -1, "byte")
+	assertModis(t, int64(26), "bitcount", "s", 0, -1, "bit")
+	assertModis(t, int64(17), "bitcount", "s", 5, 30, "BIT")
+	assertModis(t, int64(2), "bitcount", "s", 1, 4, "bit")
+	assertModis(t, int64(2), "bitcount", "s", 9, 10, "bit")
+	assertModis(t, int64(1), "bitcount", "s", -2, -2, "bit")
+	assertModis(t, int64(0), "bitcount", "s", -1, -2, "bit")
+	assertModis(t, int64(0), "bitcount", "s", -100, -200, "bit")
+	assertModis(t, errors.New("ERR syntax error"), "bitcount", "s", 0, 1, "hello")
+	assertModis(t, errors.New("ERR syntax error"), "bitcount", "s", 0)
+}
+
+func TestBitField(t *testing.T) {
+	defer modisCli.Del(context.TODO(), "bits", "mystring")
+
+	// signed and unsigned SET and GET
+	assertModis(t, []interface{}{int64(0)}, "bitfield", "bits", "set", "i8", 0, -100)
+	assertModis(t, []interface{}{int64(-100)}, "bitfield", "bits", "set", "i8", 0, 101)
+	assertModis(t, []interface{}{int64(101)}, "bitfield", "bits", "get", "i8", 0)
+	assertModis(t, []interface{}{int64(101)}, "bitfield", "bits", "set", "u8", 0, 255)
+	assertModis(t, []interface{}{int64(255)}, "bitfield", "bits", "set", "u8", 0, 100)
+	assertModis(t, []interface{}{int64(100)}, "bitfield_ro", "bits", "get", "u8", 0)
+
+	// the #<index> form multiplies the offset by the width
+	modisCli.Del(context.TODO(), "bits")
+	assertModis(t, []interface{}{int64(0), int64(0), int64(0)},
+		"bitfield", "bits", "set", "u8", "#0", 65, "set", "u8", "#1", 66, "set", "u8", "#2", 67)
+	assertModis(t, "ABC", "get", "bits")
+
+	// INCRBY, chained
+	assertModis(t, []interface{}{int64(65)}, "bitfield", "bits", "set", "u8", "#0", 10)
+	assertModis(t, []interface{}{int64(110), int64(210)}, "bitfield", "bits", "incrby", "u8", "#0", 100, "incrby", "u8", "#0", 100)
+
+	// overflows
+	assertModis(t, []interface{}{int64(210)}, "bitfield", "bits", "set", "u8", "#0", 100)
+	assertModis(t, []interface{}{int64(101)}, "bitfield", "bits", "incrby", "u8", 0, 257)
+	assertModis(t, []interface{}{int64(100)}, "bitfield", "bits", "incrby", "u8", 0, 255)
+	assertModis(t, []interface{}{int64(255)}, "bitfield", "bits", "overflow", "sat", "incrby", "u8", 0, 257)
+	assertModis(t, []interface{}{int64(0)}, "bitfield", "bits", "overflow", "SAT", "incrby", "u8", 0, -255)
+	assertModis(t, []interface{}{int64(0)}, "bitfield", "bits", "set", "i8", 0, 100)
+	assertModis(t, []interface{}{int64(101)}, "bitfield", "bits", "overflow", "wrap", "incrby", "i8", 0, 257)
+	assertModis(t, []interface{}{int64(100)}, "bitfield", "bits", "incrby", "i8", 0, 255)
+	assertModis(t, []interface{}{int64(127)}, "bitfield", "bits", "overflow", "sat", "incrby", "i8", 0, 257)
+	assertModis(t, []interface{}{int64(-128)}, "bitfield", "bits", "overflow", "sat", "incrby", "i8", 0, -255)
+	assertModis(t, []interface{}{nil, int64(-128)}, "bitfield", "bits", "overflow", "fail", "incrby", "i8", 0, -1, "get", "i8", 0)
+	assertModis(t, []interface{}{int64(-128), nil}, "bitfield", "bits", "set", "i8", 0, 255, "overflow", "fail", "set", "u4", 0, 16)
+	modisCli.Del(context.TODO(), "bits")
+	assertModis(t, []interface{}{int64(0), int64(-9223372036854775808)},
+		"bitfield", "bits", "set", "i64", 0, 1, "incrby", "i64", 0, "9223372036854775807")
+
+	// the string is grown to hold the farthest write, and the bits beyond it read as zeros
+	modisCli.Set(context.TODO(), "bits", "1", 0)
+	assertModis(t, []interface{}{int64(0), int64(0)}, "bitfield", "bits", "get", "u1", 0, "get", "u63", 100)
+	modisCli.Del(context.TODO(), "mystring")
+	assertModis(t, []interface{}{int64(0), int64(0), int64(60)},
+		"bitfield", "mystring", "SET", "i8", 0, 10, "SET", "i8", 64, 10, "INCRBY", "i8", 10, 99900)
+	assertModis(t, int64(9), "strlen", "mystring")
+
+	// the ttl is kept
+	modisCli.PExpire(context.TODO(), "mystring", time.Hour)
+	assertModis(t, []interface{}{int64(0)}, "bitfield", "mystring", "set", "u8", "#9", 1)
+	ttl, err := modisCli.TTL(context.TODO(), "mystring").Result()
+	assert.Equal(t, nil, err)
+	assert.Less(t, time.Minute, ttl)
+
+	assertModis(t, []interface{}{}, "bitfield", "bits")
+	assertModis(t, []interface{}{}, "bitfield_ro", "bits")
+	assertModis(t, errors.New("ERR BITFIELD_RO only supports the GET subcommand"), "bitfield_ro", "bits", "get", "u8", 0, "set", "u8", 0, 1)
+	assertModis(t, errors.New("ERR Invalid OVERFLOW type specified"), "bitfield", "bits", "overflow", "foo")
+	assertModis(t, errors.New(errBitFieldType), "bitfield", "bits", "get", "u64", 0)
+	assertModis(t, errors.New(errBitFieldType), "bitfield", "bits", "get", "x8", 0)
+	assertModis(t, errors.New("ERR bit offset is not an integer or out of range"), "bitfield", "bits", "get", "u8", -1)
+	assertModis(t, errors.New("ERR value is not an integer or out of range"), "bitfield", "bits", "set", "u8", 0, "a")
+	assertModis(t, errors.New("ERR syntax error"), "bitfield", "bits", "get", "u8")
+
+	modisCli.LPush(context.TODO(), "list", "foo")
+	defer modisCli.Del(context.TODO(), "list")
+	assertModis(t, errors.New("WRONGTYPE Operation against a key holding the wrong kind of value"), "bitfield", "list", "get", "u8", 0)
+}
+
+func TestBitField_Concurrent(t *testing.T) {
+	defer modisCli.Del(context.TODO(), "bits")
+
+	// no increment of a concurrent BITFIELD is lost
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				modisCli.Do(context.TODO(), "bitfield", "bits", "incrby", "u16", 0, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assertModis(t, []interface{}{int64(400)}, "bitfield", "bits", "get", "u16", 0)
+}
+
+const errBitFieldType = "ERR Invalid bitfield type. Use something like i16 u8. Note that u64 is not supported but i64 is."
@@ -0,0 +1,234 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package strings
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The HyperLogLog of the redis under test is not the one of redis, so the replies of modis
+// are compared with those of redis 7 instead
+
+func TestPFAdd(t *testing.T) {
+	defer modisCli.Del(context.TODO(), "hll", "str")
+
+	// PFADD without elements creates the key
+	n, err := modisCli.PFAdd(context.TODO(), "hll").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(1), n)
+	n, err = modisCli.PFCount(context.TODO(), "hll").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(0), n)
+
+	n, err = modisCli.PFAdd(context.TODO(), "hll", "a", "b", "c").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(1), n)
+	n, err = modisCli.PFAdd(context.TODO(), "hll", "a", "b", "c").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(0), n)
+	n, err = modisCli.PFCount(context.TODO(), "hll").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(3), n)
+	modisCli.PFAdd(context.TODO(), "hll", "d", "e", "f", "g", "g")
+	n, err = modisCli.PFCount(context.TODO(), "hll").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(7), n)
+
+	// the value is a sparse HyperLogLog
+	val, err := modisCli.GetRange(context.TODO(), "hll", 0, 4).Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "HYLL\x01", val)
+
+	// the ttl is kept
+	modisCli.PExpire(context.TODO(), "hll", time.Hour)
+	modisCli.PFAdd(context.TODO(), "hll", "h")
+	ttl, err := modisCli.TTL(context.TODO(), "hll").Result()
+	assert.Equal(t, nil, err)
+	assert.Less(t, time.Minute, ttl)
+
+	// wrong type
+	modisCli.Set(context.TODO(), "str", "value", 0)
+	err = modisCli.PFAdd(context.TODO(), "str", "a").Err()
+	assert.Equal(t, "WRONGTYPE Key is not a valid HyperLogLog string value.", err.Error())
+	modisCli.LPush(context.TODO(), "list", "a")
+	defer modisCli.Del(context.TODO(), "list")
+	err = modisCli.PFAdd(context.TODO(), "list", "a").Err()
+	assert.Equal(t, "WRONGTYPE Operation against a key holding the wrong kind of value", err.Error())
+}
+
+func TestPFAdd_Concurrent(t *testing.T) {
+	defer modisCli.Del(context.TODO(), "hll", "hll2")
+
+	// no register set by a concurrent PFADD is lost
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				modisCli.PFAdd(context.TODO(), "hll", strconv.Itoa(i)+"-"+strconv.Itoa(j))
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 50; j++ {
+			modisCli.PFAdd(context.TODO(), "hll2", strconv.Itoa(i)+"-"+strconv.Itoa(j))
+		}
+	}
+	n, err := modisCli.PFCount(context.TODO(), "hll").Result()
+	assert.Equal(t, nil, err)
+	expected, err := modisCli.PFCount(context.TODO(), "hll2").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, expected, n)
+}
+
+func TestPFCount_Cache(t *testing.T) {
+	defer modisCli.Del(context.TODO(), "hll")
+
+	// the most significant bit of the cached cardinality tells whether it is invalid
+	modisCli.PFAdd(context.TODO(), "hll", "a", "b", "c")
+	val, _ := modisCli.GetRange(context.TODO(), "hll", 15, 15).Result()
+	assert.Equal(t, "\x80", val)
+	modisCli.PFCount(context.TODO(), "hll")
+	val, _ = modisCli.GetRange(context.TODO(), "hll", 8, 15).Result()
+	assert.Equal(t, "\x03\x00\x00\x00\x00\x00\x00\x00", val)
+	modisCli.PFAdd(context.TODO(), "hll", "a", "b", "c")
+	val, _ = modisCli.GetRange(context.TODO(), "hll", 15, 15).Result()
+	assert.Equal(t, "\x00", val)
+	modisCli.PFAdd(context.TODO(), "hll", "1", "2", "3")
+	val, _ = modisCli.GetRange(context.TODO(), "hll", 15, 15).Result()
+	assert.Equal(t, "\x80", val)
+}
+
+func TestPFCount_Compatible(t *testing.T) {
+	defer modisCli.Del(context.TODO(), "hll")
+
+	// a sparse HyperLogLog of redis with the register 100 set to 3:
+	// XZERO 100, VAL 3, XZERO 16283, and the cached cardinality invalid
+	hll := "HYLL\x01\x00\x00\x00" + "\x00\x00\x00\x00\x00\x00\x00\x80" + "\x40\x63" + "\x88" + "\x7f\x9a"
+	modisCli.Set(context.TODO(), "hll", hll, 0)
+	n, err := modisCli.PFCount(context.TODO(), "hll").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(1), n)
+	val, err := modisCli.Get(context.TODO(), "hll").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "HYLL\x01\x00\x00\x00"+"\x01\x00\x00\x00\x00\x00\x00\x00"+"\x40\x63"+"\x88"+"\x7f\x9a", val)
+
+	// corrupted
+	modisCli.Append(context.TODO(), "hll", "hello")
+	modisCli.SetRange(context.TODO(), "hll", 15, "\x80")
+	err = modisCli.PFCount(context.TODO(), "hll").Err()
+	assert.Equal(t, "INVALIDOBJ Corrupted HLL object detected", err.Error())
+	modisCli.SetRange(context.TODO(), "hll", 0, "0123")
+	err = modisCli.PFCount(context.TODO(), "hll").Err()
+	assert.Equal(t, "WRONGTYPE Key is not a valid HyperLogLog string value.", err.Error())
+	modisCli.Set(context.TODO(), "hll", "HYLL\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x80", 0)
+	err = modisCli.PFCount(context.TODO(), "hll").Err()
+	assert.Equal(t, "WRONGTYPE Key is not a valid HyperLogLog string value.", err.Error())
+}
+
+func TestPFAdd_Dense(t *testing.T) {
+	defer modisCli.Del(context.TODO(), "hll", "hll2")
+
+	// the sparse registers are converted to the dense ones as they grow
+	elements := make([]interface{}, 0, 100)
+	for i := 0; i < 10000; i++ {
+		elements = append(elements, "ele:"+strconv.Itoa(i))
+		if len(elements) == cap(elements) {
+			modisCli.PFAdd(context.TODO(), "hll", elements...)
+			if i < 1000 {
+				modisCli.PFAdd(context.TODO(), "hll2", elements...)
+			}
+			elements = elements[:0]
+		}
+	}
+	val, err := modisCli.GetRange(context.TODO(), "hll", 4, 4).Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "\x00", val)
+	n, err := modisCli.StrLen(context.TODO(), "hll").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(12304), n)
+	n, err = modisCli.PFCount(context.TODO(), "hll").Result()
+	assert.Equal(t, nil, err)
+	assert.InDelta(t, 10000, n, 10000*0.05)
+
+	// the union of a sparse and a dense one
+	val, _ = modisCli.GetRange(context.TODO(), "hll2", 4, 4).Result()
+	assert.Equal(t, "\x01", val)
+	n, err = modisCli.PFCount(context.TODO(), "hll", "hll2").Result()
+	assert.Equal(t, nil, err)
+	assert.InDelta(t, 10000, n, 10000*0.05)
+}
+
+func TestPFMerge(t *testing.T) {
+	defer modisCli.Del(context.TODO(), "hll", "hll1", "hll2", "hll3", "dense")
+
+	modisCli.PFAdd(context.TODO(), "hll1", "a", "b", "c")
+	modisCli.PFAdd(context.TODO(), "hll2", "b", "c", "d")
+	modisCli.PFAdd(context.TODO(), "hll3", "c", "d", "e")
+	n, err := modisCli.PFCount(context.TODO(), "hll1", "hll2", "hll3", "nokey").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(5), n)
+	err = modisCli.PFMerge(context.TODO(), "hll", "hll1", "hll2", "hll3").Err()
+	assert.Equal(t, nil, err)
+	n, err = modisCli.PFCount(context.TODO(), "hll").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(5), n)
+
+	// the destination is one of the sources
+	err = modisCli.PFMerge(context.TODO(), "hll1").Err()
+	assert.Equal(t, nil, err)
+	n, err = modisCli.PFCount(context.TODO(), "hll1").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(3), n)
+
+	// an empty destination is created
+	modisCli.Del(context.TODO(), "hll")
+	err = modisCli.PFMerge(context.TODO(), "hll", "nokey").Err()
+	assert.Equal(t, nil, err)
+	n, err = modisCli.PFCount(context.TODO(), "hll").Result()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(0), n)
+
+	// the destination is dense if any of the sources is
+	elements := make([]interface{}, 0, 3000)
+	for i := 0; i < cap(elements); i++ {
+		elements = append(elements, i)
+	}
+	modisCli.PFAdd(context.TODO(), "dense", elements...)
+	err = modisCli.PFMerge(context.TODO(), "hll", "hll1", "dense").Err()
+	assert.Equal(t, nil, err)
+	val, _ := modisCli.GetRange(context.TODO(), "hll", 4, 4).Result()
+	assert.Equal(t, "\x00", val)
+	n, err = modisCli.PFCount(context.TODO(), "hll").Result()
+	assert.Equal(t, nil, err)
+	assert.InDelta(t, 3003, n, 3003*0.05)
+
+	// wrong type
+	modisCli.Set(context.TODO(), "hll2", "value", 0)
+	err = modisCli.PFMerge(context.TODO(), "hll", "hll2").Err()
+	assert.Equal(t, "WRONGTYPE Key is not a valid HyperLogLog string value.", err.Error())
+	err = modisCli.PFCount(context.TODO(), "hll", "hll2").Err()
+	assert.Equal(t, "WRONGTYPE Key is not a valid HyperLogLog string value.", err.Error())
+}
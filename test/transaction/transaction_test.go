@@ -0,0 +1,208 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transaction
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oceanbase/modis/test"
+)
+
+// do runs a command on the connection, which keeps the transaction state
+func do(conn *redis.Conn, args ...interface{}) *redis.Cmd {
+	cmd := redis.NewCmd(context.TODO(), args...)
+	_ = conn.Process(context.TODO(), cmd)
+	return cmd
+}
+
+func TestTransaction_MultiExec(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
+
+	txPipeline := func(cli *redis.Client) ([]interface{}, error) {
+		cmds, err := cli.TxPipelined(context.TODO(), func(pipe redis.Pipeliner) error {
+			pipe.Set(context.TODO(), "key", "1", 0)
+			pipe.Incr(context.TODO(), "key")
+			pipe.RPush(context.TODO(), "list", "a", "b")
+			pipe.HSet(context.TODO(), "key", "field", "value")
+			pipe.Get(context.TODO(), "key")
+			pipe.LRange(context.TODO(), "list", 0, -1)
+			return nil
+		})
+		res := make([]interface{}, 0, len(cmds))
+		for _, cmd := range cmds {
+			res = append(res, cmd.String())
+		}
+		return res, err
+	}
+	resRedis, errRedis := txPipeline(rCli)
+	resModis, errModis := txPipeline(mCli)
+	assert.Equal(t, errRedis, errModis)
+	assert.Equal(t, resRedis, resModis)
+}
+
+func TestTransaction_Discard(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
+
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		conn := cli.Conn(context.TODO())
+		assert.Equal(t, "OK", do(conn, "multi").Val())
+		assert.Equal(t, "QUEUED", do(conn, "set", "key", "value").Val())
+		assert.Equal(t, "OK", do(conn, "discard").Val())
+		assert.Equal(t, redis.Nil, conn.Get(context.TODO(), "key").Err())
+		conn.Close()
+	}
+
+	// errors
+	for _, cmd := range []string{"exec", "discard"} {
+		errRedis := rCli.Do(context.TODO(), cmd).Err()
+		errModis := mCli.Do(context.TODO(), cmd).Err()
+		assert.Equal(t, errRedis, errModis)
+	}
+}
+
+func TestTransaction_ExecAbort(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
+
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		conn := cli.Conn(context.TODO())
+		assert.Equal(t, "OK", do(conn, "multi").Val())
+		assert.Equal(t, "QUEUED", do(conn, "set", "key", "value").Val())
+		assert.NotEqual(t, nil, do(conn, "set", "key").Err())
+		assert.EqualError(t, do(conn, "multi").Err(), "ERR MULTI calls can not be nested")
+		assert.EqualError(t, do(conn, "exec").Err(), "EXECABORT Transaction discarded because of previous errors.")
+		assert.Equal(t, redis.Nil, conn.Get(context.TODO(), "key").Err())
+		conn.Close()
+	}
+}
+
+func TestTransaction_ExecError(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
+
+	// errors while executing do not stop the other commands
+	exec := func(cli *redis.Client) []interface{} {
+		conn := cli.Conn(context.TODO())
+		defer conn.Close()
+		assert.Equal(t, "OK", do(conn, "multi").Val())
+		assert.Equal(t, "QUEUED", do(conn, "set", "key", "value").Val())
+		assert.Equal(t, "QUEUED", do(conn, "incr", "key").Val())
+		assert.Equal(t, "QUEUED", do(conn, "lpush", "key", "a").Val())
+		assert.Equal(t, "QUEUED", do(conn, "append", "key", "1").Val())
+		res, err := do(conn, "exec").Slice()
+		assert.Equal(t, nil, err)
+		return res
+	}
+	assert.Equal(t, exec(rCli), exec(mCli))
+}
+
+func TestTransaction_Watch(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
+
+	watch := func(cli *redis.Client, key string, modify func()) error {
+		return cli.Watch(context.TODO(), func(tx *redis.Tx) error {
+			n, err := tx.Get(context.TODO(), "counter").Int()
+			if err != nil && err != redis.Nil {
+				return err
+			}
+			if modify != nil {
+				modify()
+			}
+			_, err = tx.TxPipelined(context.TODO(), func(pipe redis.Pipeliner) error {
+				pipe.Set(context.TODO(), "counter", n+1, 0)
+				return nil
+			})
+			return err
+		}, key)
+	}
+
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		// not modified
+		assert.Equal(t, nil, watch(cli, "counter", nil))
+		assert.Equal(t, "1", cli.Get(context.TODO(), "counter").Val())
+
+		// modified by another client
+		err := watch(cli, "counter", func() {
+			assert.Equal(t, nil, cli.Set(context.TODO(), "counter", "10", 0).Err())
+		})
+		assert.Equal(t, redis.TxFailedErr, err)
+		assert.Equal(t, "10", cli.Get(context.TODO(), "counter").Val())
+
+		// modified by commands of other types
+		err = watch(cli, "list", func() {
+			assert.Equal(t, nil, cli.RPush(context.TODO(), "list", "a").Err())
+		})
+		assert.Equal(t, redis.TxFailedErr, err)
+		err = watch(cli, "list", func() {
+			assert.Equal(t, nil, cli.Del(context.TODO(), "other", "list").Err())
+		})
+		assert.Equal(t, redis.TxFailedErr, err)
+		err = watch(cli, "counter", func() {
+			assert.Equal(t, nil, cli.FlushDB(context.TODO()).Err())
+		})
+		assert.Equal(t, redis.TxFailedErr, err)
+
+		// other keys are modified
+		err = watch(cli, "counter", func() {
+			assert.Equal(t, nil, cli.Set(context.TODO(), "other", "1", 0).Err())
+		})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "1", cli.Get(context.TODO(), "counter").Val())
+	}
+
+	// a zadd changing no score does not touch the key, miniredis touches it anyway
+	defer mCli.Del(context.TODO(), "zset")
+	assert.Equal(t, nil, mCli.ZAdd(context.TODO(), "zset", &redis.Z{Score: 1, Member: "a"}).Err())
+	err := watch(mCli, "zset", func() {
+		assert.Equal(t, nil, mCli.ZAdd(context.TODO(), "zset", &redis.Z{Score: 1, Member: "a"}).Err())
+	})
+	assert.Equal(t, nil, err)
+	err = watch(mCli, "zset", func() {
+		assert.Equal(t, nil, mCli.ZAdd(context.TODO(), "zset", &redis.Z{Score: 2, Member: "a"}).Err())
+	})
+	assert.Equal(t, redis.TxFailedErr, err)
+}
+
+func TestTransaction_Unwatch(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisStringTableName, test.TestModisHashTableName, test.TestModisSetTableName, test.TestModisZSetTableName, test.TestModisListTableName)
+
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		conn := cli.Conn(context.TODO())
+		assert.Equal(t, "OK", do(conn, "watch", "key").Val())
+		assert.Equal(t, nil, cli.Set(context.TODO(), "key", "1", 0).Err())
+		assert.Equal(t, "OK", do(conn, "unwatch").Val())
+		assert.Equal(t, "OK", do(conn, "multi").Val())
+		assert.Equal(t, "QUEUED", do(conn, "incr", "key").Val())
+		res, err := do(conn, "exec").Slice()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []interface{}{int64(2)}, res)
+
+		// the keys are unwatched after EXEC
+		assert.Equal(t, "OK", do(conn, "watch", "key").Val())
+		assert.Equal(t, "OK", do(conn, "multi").Val())
+		assert.Equal(t, []interface{}{}, do(conn, "exec").Val())
+		assert.Equal(t, nil, cli.Set(context.TODO(), "key", "1", 0).Err())
+		assert.Equal(t, "OK", do(conn, "multi").Val())
+		assert.Equal(t, "QUEUED", do(conn, "incr", "key").Val())
+		res, err = do(conn, "exec").Slice()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []interface{}{int64(2)}, res)
+		conn.Close()
+	}
+}
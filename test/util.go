@@ -47,6 +47,14 @@ const (
 
 // Config for Tests
 const (
+	// BackendEnv selects the storage backend of the modis under test,
+	// with "memory" no OceanBase is needed and the sql helpers are no-ops
+	BackendEnv    = "MODIS_TEST_BACKEND"
+	BackendMemory = "memory"
+	// PeerEnv is the address of another modis on the pub/sub bus of the modis under test,
+	// the tests of the bus are skipped if it is not set
+	PeerEnv = "MODIS_TEST_PEER"
+
 	SqlUser     = "root@mysql"
 	SqlPassWord = ""
 	SqlIp       = "127.0.0.1"
@@ -119,10 +127,54 @@ const (
 		PRIMARY KEY(db, rkey, is_data, field))
 		KV_ATTRIBUTES ='{"Redis": {"isTTL": true, "model": "hash"}}'
 		PARTITION BY KEY(db, rkey) PARTITIONS 3;`
+
+	TestModisStreamTableName       = "modis_stream_table"
+	TestModisStreamCreateStatement = `CREATE TABLE if not exists modis_stream_table(
+		db bigint not null,
+		rkey varbinary(1024) not null,
+		is_data tinyint(1) not null,
+		id_ms bigint unsigned not null,
+		id_seq bigint unsigned not null,
+		expire_ts timestamp(6) default null,
+		value varbinary(65535) default null,
+		last_ms bigint unsigned default null,
+		last_seq bigint unsigned default null,
+		entries_added bigint default null,
+		max_deleted_ms bigint unsigned default null,
+		max_deleted_seq bigint unsigned default null,
+		PRIMARY KEY(db, rkey, is_data, id_ms, id_seq))
+		TTL(expire_ts + INTERVAL 0 SECOND)
+		PARTITION BY KEY(db, rkey) PARTITIONS 3;`
+
+	TestModisStreamGroupTableName       = "modis_stream_group_table"
+	TestModisStreamGroupCreateStatement = `CREATE TABLE if not exists modis_stream_group_table(
+		db bigint not null,
+		rkey varbinary(1024) not null,
+		group_name varbinary(1024) not null,
+		row_type bigint not null,
+		consumer varbinary(1024) not null,
+		id_ms bigint unsigned not null,
+		id_seq bigint unsigned not null,
+		expire_ts timestamp(6) default null,
+		last_ms bigint unsigned default null,
+		last_seq bigint unsigned default null,
+		entries_read bigint default null,
+		seen_time bigint default null,
+		active_time bigint default null,
+		owner varbinary(1024) default null,
+		delivery_time bigint default null,
+		delivery_count bigint default null,
+		PRIMARY KEY(db, rkey, group_name, row_type, consumer, id_ms, id_seq))
+		TTL(expire_ts + INTERVAL 0 SECOND)
+		PARTITION BY KEY(db, rkey) PARTITIONS 3;`
 )
 
 var GlobalDB *sql.DB
 
+func isMemoryBackend() bool {
+	return os.Getenv(BackendEnv) == BackendMemory
+}
+
 func CreateRedisClient() *redis.Client {
 	cli := redis.NewClient(&redis.Options{
 		Addr:     RedisAddr,
@@ -153,7 +205,30 @@ func CreateModisClient() *redis.Client {
 	return cli
 }
 
+// CreateModisPeerClient connects to the modis given by PeerEnv, returns nil if it is not set
+func CreateModisPeerClient() *redis.Client {
+	addr := os.Getenv(PeerEnv)
+	if addr == "" {
+		return nil
+	}
+	cli := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: ModisPwd,
+		DB:       ModisDB,
+	})
+
+	err := cli.Ping(context.TODO()).Err()
+	if err != nil {
+		panic(err)
+	}
+
+	return cli
+}
+
 func CreateDB() {
+	if isMemoryBackend() {
+		return
+	}
 	if GlobalDB == nil {
 		// dsn format: "user:password@addr?dbname"
 		dsn := fmt.Sprintf("%s:%s@%s:%s?%s", SqlUser, SqlPassWord, SqlIp, SqlPort, SqlDatabase)
@@ -166,10 +241,16 @@ func CreateDB() {
 }
 
 func ClearDb(db int64, rCli *redis.Client, tableNames ...string) {
+	if isMemoryBackend() {
+		clearModisDb(rCli)
+	}
 	err := rCli.FlushDB(context.TODO()).Err()
 	if err != nil {
 		panic(err.Error())
 	}
+	if isMemoryBackend() {
+		return
+	}
 	for _, tb := range tableNames {
 		delSql := "delete from " + tb + " where db = " + strconv.FormatInt(db, 10) + ";"
 		_, err = GlobalDB.Exec(delSql)
@@ -179,11 +260,34 @@ func ClearDb(db int64, rCli *redis.Client, tableNames ...string) {
 	}
 }
 
+// clearModisDb deletes the keys written by the test from modis, which are the keys in redis
+func clearModisDb(rCli *redis.Client) {
+	keys, err := rCli.Keys(context.TODO(), "*").Result()
+	if err != nil {
+		panic(err.Error())
+	}
+	if len(keys) == 0 {
+		return
+	}
+	mCli := CreateModisClient()
+	defer mCli.Close()
+	err = mCli.Del(context.TODO(), keys...).Err()
+	if err != nil {
+		panic(err.Error())
+	}
+}
+
 func CloseDB() {
+	if isMemoryBackend() {
+		return
+	}
 	GlobalDB.Close()
 }
 
 func CreateTable(createTableStatement string) {
+	if isMemoryBackend() {
+		return
+	}
 	_, err := GlobalDB.Exec(createTableStatement)
 	if err != nil {
 		panic(err.Error())
@@ -191,6 +295,9 @@ func CreateTable(createTableStatement string) {
 }
 
 func DropTable(tableName string) {
+	if isMemoryBackend() {
+		return
+	}
 	_, err := GlobalDB.Exec(fmt.Sprintf("drop table %s;", tableName))
 	if err != nil {
 		panic(err.Error())
@@ -198,6 +305,9 @@ func DropTable(tableName string) {
 }
 
 func TruncateTable(tableName string) {
+	if isMemoryBackend() {
+		return
+	}
 	_, err := GlobalDB.Exec(fmt.Sprintf("truncate table %s;", tableName))
 	if err != nil {
 		panic(err.Error())
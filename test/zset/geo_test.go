@@ -0,0 +1,263 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package zset
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// The geohash of the redis under test is not the one of redis, so the replies of modis
+// are compared with those of redis 7 instead
+
+// assertModis asserts the reply of modis, an error is compared by its message
+func assertModis(t *testing.T, expect interface{}, args ...interface{}) {
+	res, err := mCli.Do(context.TODO(), args...).Result()
+	if expectErr, ok := expect.(error); ok {
+		if assert.NotNil(t, err, args) {
+			assert.Equal(t, expectErr.Error(), err.Error(), args)
+		}
+		return
+	}
+	if err == redis.Nil {
+		err = nil
+	}
+	assert.Equal(t, nil, err, args)
+	assert.Equal(t, expect, res, args)
+}
+
+func strs(members ...string) []interface{} {
+	res := make([]interface{}, 0, len(members))
+	for _, m := range members {
+		res = append(res, m)
+	}
+	return res
+}
+
+func addNyc(t *testing.T) {
+	mCli.Del(context.TODO(), "nyc")
+	assertModis(t, int64(7), "geoadd", "nyc", -73.9454966, 40.747533, "lic market",
+		-73.9733487, 40.7648057, "central park n/q/r", -73.9903085, 40.7362513, "union square",
+		-74.0131604, 40.7126674, "wtc one", -73.7858139, 40.6428986, "jfk",
+		-73.9375699, 40.7498929, "q4", -73.9564142, 40.7480973, "4545")
+}
+
+func TestGeoAdd(t *testing.T) {
+	defer mCli.Del(context.TODO(), "nyc", "str")
+
+	addNyc(t)
+	// the scores are the geohashes of redis
+	assertModis(t, []interface{}{
+		"wtc one", "1791873972053020", "union square", "1791875485187452", "central park n/q/r", "1791875761332224",
+		"4545", "1791875796750882", "lic market", "1791875804419201", "q4", "1791875830079666", "jfk", "1791895905559723",
+	}, "zrange", "nyc", 0, -1, "withscores")
+
+	assertModis(t, int64(0), "geoadd", "nyc", -73.9454966, 40.747533, "lic market")
+	assertModis(t, int64(1), "geoadd", "nyc", "CH", 40.747533, -73.9454966, "lic market")
+	assertModis(t, int64(0), "geoadd", "nyc", "nx", -73.9454966, 40.747533, "lic market")
+	assertModis(t, int64(0), "geoadd", "nyc", "ch", "nx", -73.9454966, 40.747533, "lic market")
+	assertModis(t, int64(0), "geoadd", "nyc", "xx", -73.9454966, 40.747533, "new market")
+	assertModis(t, int64(1), "geoadd", "nyc", "xx", "ch", -73.9454966, 40.747533, "lic market", -73.9454966, 40.747533, "new market")
+	assertModis(t, "1791875804419201", "zscore", "nyc", "lic market")
+	assertModis(t, int64(0), "exists", "new market")
+
+	assertModis(t, errors.New("ERR syntax error"), "geoadd", "nyc", "xx", "nx", -73.9454966, 40.747533, "lic market")
+	assertModis(t, errors.New("ERR syntax error"), "geoadd", "nyc", "ch", "xx", "foo", -73.9454966, 40.747533, "lic market")
+	assertModis(t, errors.New("ERR value is not a valid float"), "geoadd", "nyc", -73.9454966, 40.747533, "lic market", "foo", "bar", "luck market")
+	assertModis(t, errors.New("ERR invalid longitude,latitude pair 200.000000,100.000000"), "geoadd", "nyc", 200, 100, "nowhere")
+
+	mCli.Set(context.TODO(), "str", "value", 0)
+	assertModis(t, errors.New("WRONGTYPE Operation against a key holding the wrong kind of value"), "geoadd", "str", 10, 20, "a")
+}
+
+func TestGeoPosDistHash(t *testing.T) {
+	defer mCli.Del(context.TODO(), "Sicily", "points")
+
+	assertModis(t, int64(2), "geoadd", "Sicily", 13.361389, 38.115556, "Palermo", 15.087269, 37.502669, "Catania")
+	assertModis(t, "3479099956230698", "zscore", "Sicily", "Palermo")
+	assertModis(t, []interface{}{
+		strs("13.36138933897018433", "38.11555639549629859"),
+		strs("15.08726745843887329", "37.50266842333162032"),
+		nil,
+	}, "geopos", "Sicily", "Palermo", "Catania", "NonExisting")
+	assertModis(t, []interface{}{}, "geopos", "Sicily")
+	assertModis(t, []interface{}{nil}, "geopos", "nokey", "Palermo")
+
+	assertModis(t, "166274.1516", "geodist", "Sicily", "Palermo", "Catania")
+	assertModis(t, "166.2742", "geodist", "Sicily", "Palermo", "Catania", "km")
+	assertModis(t, "103.3182", "geodist", "Sicily", "Palermo", "Catania", "MI")
+	assertModis(t, "0.0000", "geodist", "Sicily", "Palermo", "Palermo")
+	assertModis(t, nil, "geodist", "Sicily", "Palermo", "Agrigento")
+	assertModis(t, nil, "geodist", "nokey", "Palermo", "Catania")
+	assertModis(t, errors.New("ERR unsupported unit provided. please use M, KM, FT, MI"), "geodist", "Sicily", "Palermo", "Catania", "miles")
+	assertModis(t, errors.New("ERR syntax error"), "geodist", "Sicily", "Palermo", "Catania", "km", "m")
+
+	assertModis(t, strs("sqc8b49rny0", "sqdtr74hyu0"), "geohash", "Sicily", "Palermo", "Catania")
+	assertModis(t, []interface{}{nil}, "geohash", "Sicily", "NonExisting")
+	assertModis(t, []interface{}{}, "geohash", "Sicily")
+	assertModis(t, int64(1), "geoadd", "points", -5.6, 42.6, "test")
+	assertModis(t, strs("ezs42e44yx0"), "geohash", "points", "test")
+}
+
+func TestGeoRadius(t *testing.T) {
+	defer mCli.Del(context.TODO(), "nyc", "users", "k1", "points")
+
+	addNyc(t)
+	assertModis(t, strs("central park n/q/r", "4545", "union square"), "georadius", "nyc", -73.9798091, 40.7598464, 3, "km", "asc")
+	assertModis(t, strs("central park n/q/r", "4545", "union square"), "georadius_ro", "nyc", -73.9798091, 40.7598464, 3, "km", "asc")
+	assertModis(t, []interface{}{strs("central park n/q/r", "0.7750"), strs("4545", "2.3651"), strs("union square", "2.7697")},
+		"georadius", "nyc", -73.9798091, 40.7598464, 3, "km", "withdist", "asc")
+	assertModis(t, strs("central park n/q/r", "4545", "union square"), "georadius", "nyc", -73.9798091, 40.7598464, 10, "km", "COUNT", 3)
+	assertModis(t, strs("wtc one", "q4"), "georadius", "nyc", -73.9798091, 40.7598464, 10, "km", "COUNT", 2, "DESC")
+	assertModis(t, strs("wtc one", "union square", "central park n/q/r"), "georadius", "nyc", -73.9798091, 40.7598464, 10, "km", "COUNT", 3, "ANY")
+	assertModis(t, strs("central park n/q/r", "union square", "wtc one"), "georadius", "nyc", -73.9798091, 40.7598464, 10, "km", "COUNT", 3, "ANY", "ASC")
+	assertModis(t, []interface{}{
+		[]interface{}{"central park n/q/r", int64(1791875761332224), strs("-73.97334784269332886", "40.76480639569881959")},
+		[]interface{}{"4545", int64(1791875796750882), strs("-73.95641237497329712", "40.74809751381645384")},
+	}, "georadius", "nyc", -73.9798091, 40.7598464, 10, "km", "WITHCOORD", "WITHHASH", "COUNT", 2)
+	assertModis(t, []interface{}{}, "georadius", "nokey", -73.9798091, 40.7598464, 10, "km")
+
+	assertModis(t, errors.New("ERR the ANY argument requires COUNT argument"), "georadius", "nyc", -73.9798091, 40.7598464, 10, "km", "ANY", "ASC")
+	assertModis(t, errors.New("ERR syntax error"), "georadius", "nyc", -73.9798091, 40.7598464, 10, "km", "COUNT")
+	assertModis(t, errors.New("ERR COUNT must be > 0"), "georadius", "nyc", -73.9798091, 40.7598464, 10, "km", "COUNT", 0)
+	assertModis(t, errors.New("ERR radius cannot be negative"), "georadius", "nyc", -73.9798091, 40.7598464, -10, "km")
+	assertModis(t, errors.New("ERR need numeric radius"), "georadius", "nyc", -73.9798091, 40.7598464, "ten", "km")
+	assertModis(t, errors.New("ERR syntax error"), "georadius_ro", "nyc", -73.9798091, 40.7598464, 10, "km", "store", "dst")
+
+	// by member
+	assertModis(t, strs("wtc one", "union square", "central park n/q/r", "4545", "lic market"),
+		"georadiusbymember", "nyc", "wtc one", 7, "km")
+	assertModis(t, []interface{}{strs("wtc one", "0.0000"), strs("union square", "3.2544"), strs("central park n/q/r", "6.7000"),
+		strs("4545", "6.1975"), strs("lic market", "6.8969")}, "georadiusbymember_ro", "nyc", "wtc one", 7, "km", "withdist")
+	assertModis(t, errors.New("ERR could not decode requested zset member"), "georadiusbymember", "nyc", "nowhere", 7, "km")
+	assertModis(t, []interface{}{}, "georadiusbymember", "nokey", "nowhere", 7, "km")
+
+	// huge radius, search areas in oblique direction and crossing the pole
+	assertModis(t, int64(1), "geoadd", "users", -47.271613776683807, -54.534504198047678, "user_000000")
+	res, err := mCli.Do(context.TODO(), "georadius", "users", 0, 0, 50000, "km", "WITHCOORD").Result()
+	assert.Equal(t, nil, err)
+	assert.Len(t, res, 1)
+	assertModis(t, int64(2), "geoadd", "k1", -0.15307903289794921875, 85, "n1", 0.3515625, 85.00019260486917005437, "n2")
+	assertModis(t, strs("n1", "n2"), "georadiusbymember", "k1", "n1", 4891.94, "m")
+	mCli.Del(context.TODO(), "k1")
+	assertModis(t, int64(2), "geoadd", "k1", -4.95211958885192871094, 85, "n3", 11.25, 85.0511, "n4")
+	assertModis(t, strs("n3", "n4"), "georadiusbymember", "k1", "n3", 156544, "m")
+	mCli.Del(context.TODO(), "k1")
+	assertModis(t, int64(2), "geoadd", "k1", 45, 65, "n1", -135, 85.05, "n2")
+	assertModis(t, strs("n1", "n2"), "georadiusbymember", "k1", "n1", 5009431, "m")
+
+	// small distance
+	assertModis(t, int64(2), "geoadd", "points", -122.407107, 37.794300, "1", -122.227336, 37.794300, "2")
+	assertModis(t, []interface{}{strs("1", "0.0001"), strs("2", "9.8182")}, "georadius", "points", -122.407107, 37.794300, 30, "mi", "ASC", "WITHDIST")
+}
+
+func TestGeoSearch(t *testing.T) {
+	defer mCli.Del(context.TODO(), "nyc", "Sicily")
+
+	addNyc(t)
+	assertModis(t, strs("central park n/q/r", "4545", "union square", "lic market"),
+		"geosearch", "nyc", "fromlonlat", -73.9798091, 40.7598464, "bybox", 6, 6, "km", "asc")
+	assertModis(t, []interface{}{strs("central park n/q/r", "0.7750"), strs("4545", "2.3651"), strs("union square", "2.7697"), strs("lic market", "3.1991")},
+		"geosearch", "nyc", "fromlonlat", -73.9798091, 40.7598464, "bybox", 6, 6, "km", "withdist", "asc")
+	assertModis(t, strs("wtc one", "union square", "central park n/q/r", "4545", "lic market", "q4"),
+		"geosearch", "nyc", "frommember", "wtc one", "bybox", 14, 14, "km")
+
+	assertModis(t, errors.New("ERR syntax error"),
+		"geosearch", "nyc", "fromlonlat", -73.9798091, 40.7598464, "frommember", "xxx", "bybox", 6, 6, "km", "asc")
+	assertModis(t, errors.New("ERR exactly one of FROMMEMBER or FROMLONLAT can be specified for geosearch"),
+		"geosearch", "nyc", "bybox", 3, 3, "km", "asc", "desc", "withhash", "withdist", "withcoord")
+	assertModis(t, errors.New("ERR syntax error"),
+		"geosearch", "nyc", "fromlonlat", -73.9798091, 40.7598464, "byradius", 3, "km", "bybox", 3, 3, "km", "asc")
+	assertModis(t, errors.New("ERR exactly one of BYRADIUS and BYBOX can be specified for geosearch"),
+		"geosearch", "nyc", "fromlonlat", -73.9798091, 40.7598464, "asc", "desc", "withhash", "withdist", "withcoord")
+	assertModis(t, errors.New("ERR syntax error"),
+		"geosearch", "nyc", "fromlonlat", -73.9798091, 40.7598464, "bybox", 6, 6, "km", "asc", "storedist")
+	assertModis(t, errors.New("ERR height or width cannot be negative"),
+		"geosearch", "nyc", "fromlonlat", -73.9798091, 40.7598464, "bybox", -6, 6, "km")
+
+	assertModis(t, int64(4), "geoadd", "Sicily", 13.361389, 38.115556, "Palermo", 15.087269, 37.502669, "Catania",
+		12.758489, 38.788135, "edge1", 17.241510, 38.788135, "edge2")
+	assertModis(t, strs("Catania", "Palermo"), "georadius", "Sicily", 15, 37, 200, "km", "asc")
+	assertModis(t, strs("Catania", "Palermo", "edge2", "edge1"), "geosearch", "Sicily", "fromlonlat", 15, 37, "bybox", 400, 400, "km", "asc")
+	assertModis(t, []interface{}{
+		[]interface{}{"Catania", "56.4413", strs("15.08726745843887329", "37.50266842333162032")},
+		[]interface{}{"Palermo", "190.4424", strs("13.36138933897018433", "38.11555639549629859")},
+		[]interface{}{"edge2", "279.7403", strs("17.24151045083999634", "38.78813451624225195")},
+		[]interface{}{"edge1", "279.7405", strs("12.7584877610206604", "38.78813451624225195")},
+	}, "geosearch", "Sicily", "fromlonlat", 15, 37, "bybox", 400, 400, "km", "asc", "withcoord", "withdist")
+
+	// long and narrow box
+	mCli.Del(context.TODO(), "Sicily")
+	mCli.Do(context.TODO(), "geoadd", "Sicily", 12.75, 36.995, "test1", 12.75, 36.50, "test2", 13.00, 36.50, "test3")
+	assertModis(t, strs("test1"), "geosearch", "Sicily", "fromlonlat", 15, 37, "bybox", 400, 2, "km")
+	mCli.Do(context.TODO(), "geoadd", "Sicily", -1, 37.00, "test3")
+	assertModis(t, strs("test1", "test3"), "geosearch", "Sicily", "fromlonlat", 15, 37, "bybox", 3000, 2, "km", "asc")
+
+	// corner points
+	mCli.Del(context.TODO(), "Sicily")
+	mCli.Do(context.TODO(), "geoadd", "Sicily", 12.758489, 38.788135, "edge1", 17.241510, 38.788135, "edge2",
+		17.250000, 35.202000, "edge3", 12.750000, 35.202000, "edge4", 12.748489955781654, 37, "edge5",
+		15, 38.798135872540925, "edge6", 17.251510044218346, 37, "edge7", 15, 35.201864127459075, "edge8",
+		12.692799634687903, 38.798135872540925, "corner1", 12.692799634687903, 38.798135872540925, "corner2",
+		17.200560937451133, 35.201864127459075, "corner3", 12.799439062548865, 35.201864127459075, "corner4")
+	res, err := mCli.Do(context.TODO(), "geosearch", "Sicily", "fromlonlat", 15, 37, "bybox", 400, 400, "km", "asc").StringSlice()
+	assert.Equal(t, nil, err)
+	assert.ElementsMatch(t, []string{"edge1", "edge2", "edge5", "edge7"}, res)
+}
+
+func TestGeoStore(t *testing.T) {
+	defer mCli.Del(context.TODO(), "points", "points2")
+
+	assertModis(t, int64(2), "geoadd", "points", 13.361389, 38.115556, "Palermo", 15.087269, 37.502669, "Catania")
+	assertModis(t, errors.New("ERR syntax error"), "georadius", "points", 13.361389, 38.115556, 50, "km", "store")
+	assertModis(t, errors.New("ERR syntax error"),
+		"geosearchstore", "abc", "points", "fromlonlat", 13.361389, 38.115556, "byradius", 50, "km", "store", "abc")
+	assertModis(t, errors.New("ERR STORE option in GEORADIUS is not compatible with WITHDIST, WITHHASH and WITHCOORD options"),
+		"georadius", "points", 13.361389, 38.115556, 50, "km", "store", "points2", "withdist")
+	assertModis(t, errors.New("ERR GEOSEARCHSTORE is not compatible with WITHDIST, WITHHASH and WITHCOORD options"),
+		"geosearchstore", "points2", "points", "fromlonlat", 13.361389, 38.115556, "byradius", 50, "km", "withhash")
+
+	assertModis(t, int64(2), "georadius", "points", 13.361389, 38.115556, 500, "km", "store", "points2")
+	assertModis(t, []interface{}{"Palermo", "3479099956230698", "Catania", "3479447370796909"}, "zrange", "points2", 0, -1, "withscores")
+	assertModis(t, int64(2), "georadiusbymember", "points", "Catania", 500, "km", "storedist", "points2")
+	res, err := mCli.ZRangeWithScores(context.TODO(), "points2", 0, -1).Result()
+	assert.Equal(t, nil, err)
+	if assert.Len(t, res, 2) {
+		assert.Equal(t, "Catania", res[0].Member)
+		assert.Less(t, res[0].Score, 1.0)
+		assert.Equal(t, "Palermo", res[1].Member)
+		assert.InDelta(t, 166.27, res[1].Score, 0.01)
+	}
+	assertModis(t, int64(1), "georadius", "points", 13.361389, 38.115556, 500, "km", "storedist", "points2", "desc", "count", 1)
+	assertModis(t, strs("Catania"), "zrange", "points2", 0, -1)
+
+	assertModis(t, int64(2), "geosearchstore", "points2", "points", "fromlonlat", 13.361389, 38.115556, "byradius", 500, "km")
+	assertModis(t, strs("Palermo", "Catania"), "zrange", "points2", 0, -1)
+	assertModis(t, int64(1), "geosearchstore", "points2", "points", "frommember", "Palermo", "byradius", 500, "km", "storedist", "count", 1)
+	assertModis(t, []interface{}{"Palermo", "0"}, "zrange", "points2", 0, -1, "withscores")
+
+	// nothing found deletes the destination
+	assertModis(t, int64(0), "geosearchstore", "points2", "points", "fromlonlat", 0, 0, "byradius", 1, "km")
+	assertModis(t, int64(0), "exists", "points2")
+	mCli.Set(context.TODO(), "points2", "value", 0)
+	assertModis(t, int64(0), "geosearchstore", "points2", "nokey", "frommember", "Palermo", "byradius", 1, "km")
+	assertModis(t, int64(0), "exists", "points2")
+}
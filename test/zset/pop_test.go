@@ -0,0 +1,166 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package zset
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oceanbase/modis/test"
+)
+
+// zaddLater adds member to key after a while
+func zaddLater(cli *redis.Client, key string, score float64, member string) {
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cli.ZAdd(context.TODO(), key, &redis.Z{Score: score, Member: member})
+	}()
+}
+
+func TestZPop(t *testing.T) {
+	key := "zsetkey"
+	defer test.ClearDb(0, rCli, test.TestModisZSetTableName)
+	members, scores := generateTestData(10)
+	assert.Equal(t, nil, do_zadd(t, key, members, scores))
+
+	assertSame(t, "zpopmin", key)
+	assertSame(t, "zpopmax", key)
+	assertSame(t, "zpopmin", key, 3)
+	assertSame(t, "zpopmax", key, 2)
+	assertSame(t, "zrange", key, 0, -1, "withscores")
+	// miniredis pops all the members with count 0
+	assertModis(t, []interface{}{}, "zpopmin", key, 0)
+	assertSame(t, "zpopmax", key, 10)
+	assertSame(t, "exists", key)
+	assertSame(t, "zpopmin", key)
+	assertSame(t, "zpopmin", key, 3)
+
+	assertSame(t, "zpopmin", key, -1)
+	assertSame(t, "zpopmax", key, "foo")
+	assertSame(t, "zpopmax", key, 1, 2)
+	assertSame(t, "set", "str", "value")
+	assertSame(t, "zpopmin", "str")
+	assertSame(t, "del", "str")
+}
+
+func TestZMPop(t *testing.T) {
+	defer mCli.Del(context.TODO(), "zset1", "zset2", "str")
+
+	assertModis(t, int64(3), "zadd", "zset2", 1, "a", 2, "b", 3, "c")
+	assertModis(t, []interface{}{"zset2", []interface{}{strs("a", "1")}}, "zmpop", 2, "zset1", "zset2", "min")
+	assertModis(t, []interface{}{"zset2", []interface{}{strs("c", "3"), strs("b", "2")}}, "zmpop", 2, "zset1", "zset2", "MAX", "count", 10)
+	assertModis(t, int64(0), "exists", "zset2")
+	assertModis(t, nil, "zmpop", 2, "zset1", "zset2", "min")
+
+	assertModis(t, errors.New("ERR numkeys should be greater than 0"), "zmpop", 0, "zset1", "min")
+	assertModis(t, errors.New("ERR value is not an integer or out of range"), "zmpop", "a", "zset1", "min")
+	assertModis(t, errors.New("ERR syntax error"), "zmpop", 3, "zset1", "zset2", "min")
+	assertModis(t, errors.New("ERR syntax error"), "zmpop", 1, "zset1", "left")
+	assertModis(t, errors.New("ERR syntax error"), "zmpop", 1, "zset1", "min", "count")
+	assertModis(t, errors.New("ERR count should be greater than 0"), "zmpop", 1, "zset1", "min", "count", 0)
+	mCli.Set(context.TODO(), "str", "value", 0)
+	assertModis(t, errors.New("WRONGTYPE Operation against a key holding the wrong kind of value"), "zmpop", 2, "zset1", "str", "max")
+}
+
+func TestBZPop(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisZSetTableName)
+
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		cli.ZAdd(context.TODO(), "zset2", &redis.Z{Score: 1, Member: "a"}, &redis.Z{Score: 2, Member: "b"}, &redis.Z{Score: 3, Member: "c"})
+	}
+
+	// not blocked
+	assertSame(t, "bzpopmin", "zset1", "zset2", 1)
+	assertSame(t, "bzpopmax", "zset1", "zset2", 1)
+
+	// timeout
+	assertSame(t, "bzpopmin", "zset1", "0.1")
+
+	// woken up by an add
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		zaddLater(cli, "zset1", 5, "d")
+		st := time.Now()
+		val, err := cli.BZPopMax(context.TODO(), 5*time.Second, "zset1").Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, &redis.ZWithKey{Z: redis.Z{Score: 5, Member: "d"}, Key: "zset1"}, val)
+		assert.Less(t, time.Since(st), 2*time.Second)
+	}
+
+	// blocks forever
+	for _, cli := range []*redis.Client{rCli, mCli} {
+		zaddLater(cli, "zset1", 6, "e")
+		val, err := cli.BZPopMin(context.TODO(), 0, "zset3", "zset1").Result()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, &redis.ZWithKey{Z: redis.Z{Score: 6, Member: "e"}, Key: "zset1"}, val)
+	}
+
+	assertSame(t, "bzpopmin", "zset1", "-1")
+	assertSame(t, "bzpopmin", "zset1", "abc")
+	assertSame(t, "set", "str", "value")
+	assertSame(t, "bzpopmin", "str", 1)
+	assertSame(t, "del", "str")
+}
+
+func TestZPop_Concurrent(t *testing.T) {
+	key := "zsetkey"
+	defer mCli.Del(context.TODO(), key)
+
+	members := make([]*redis.Z, 0, 200)
+	for i := 0; i < 200; i++ {
+		members = append(members, &redis.Z{Score: float64(i), Member: strconv.Itoa(i)})
+	}
+	assert.Equal(t, nil, mCli.ZAdd(context.TODO(), key, members...).Err())
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	popped := make(map[string]int)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for {
+				var res []redis.Z
+				var err error
+				if i%2 == 0 {
+					res, err = mCli.ZPopMin(context.TODO(), key, 3).Result()
+				} else {
+					res, err = mCli.ZPopMax(context.TODO(), key, 3).Result()
+				}
+				if !assert.Equal(t, nil, err) || len(res) == 0 {
+					return
+				}
+				mu.Lock()
+				for _, z := range res {
+					popped[z.Member.(string)]++
+				}
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Len(t, popped, 200)
+	for member, n := range popped {
+		assert.Equal(t, 1, n, member)
+	}
+}
@@ -0,0 +1,161 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package zset
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oceanbase/modis/test"
+)
+
+func TestZRangeUnified(t *testing.T) {
+	key := "zsetkey"
+	defer test.ClearDb(0, rCli, test.TestModisZSetTableName)
+	members, scores := generateTestData(10)
+	assert.Equal(t, nil, do_zadd(t, key, members, scores))
+
+	assertSame(t, "zrange", key, 0, -1, "rev")
+	assertSame(t, "zrange", key, 1, 3, "rev", "withscores")
+	assertSame(t, "zrange", key, "-inf", "+inf", "byscore")
+	assertSame(t, "zrange", key, "(2", 6, "byscore", "withscores")
+	assertSame(t, "zrange", key, 6, "(2", "byscore", "rev")
+	assertSame(t, "zrange", key, "-inf", "+inf", "byscore", "limit", 2, 3)
+	assertSame(t, "zrange", key, "+inf", "-inf", "byscore", "rev", "limit", 1, 2, "withscores")
+	assertSame(t, "zrange", "nonexist", 0, -1, "rev")
+
+	lexKey := "lexkey"
+	addLexTestData(t, lexKey)
+	assertSame(t, "zrange", lexKey, "-", "+", "bylex")
+	assertSame(t, "zrange", lexKey, "[bar", "(foo", "bylex")
+	assertSame(t, "zrange", lexKey, "+", "[cool", "bylex", "rev")
+	assertSame(t, "zrange", lexKey, "-", "+", "bylex", "limit", 1, 2)
+
+	assertSame(t, "zrange", key, 0, -1, "limit", 0, 1)
+	assertSame(t, "zrange", key, 0, -1, "byscore", "bylex")
+	assertSame(t, "zrange", key, 0, -1, "foo")
+	assertSame(t, "zrange", key, "a", 1, "byscore")
+	assertSame(t, "zrange", key, "a", 1)
+	assertModis(t, errors.New("ERR syntax error, WITHSCORES not supported in combination with BYLEX"),
+		"zrange", lexKey, "-", "+", "bylex", "withscores")
+	assertModis(t, errors.New("ERR syntax error"), "zrangebyscore", key, 0, 1, "rev")
+}
+
+func TestZRangeStore(t *testing.T) {
+	key := "zsetkey"
+	dst := "dstkey"
+	defer test.ClearDb(0, rCli, test.TestModisZSetTableName)
+	defer mCli.Del(context.TODO(), dst)
+	members, scores := generateTestData(10)
+	assert.Equal(t, nil, do_zadd(t, key, members, scores))
+
+	for _, args := range [][]interface{}{
+		{0, 2},
+		{5, "(7", "byscore"},
+		{"+inf", "-inf", "byscore", "rev", "limit", 1, 2},
+		{-3, -1, "rev"},
+	} {
+		expect, err := rCli.Do(context.TODO(), append([]interface{}{"zrange", key}, append(args, "withscores")...)...).Slice()
+		assert.Equal(t, nil, err)
+		assertModis(t, int64(len(expect)/2), append([]interface{}{"zrangestore", dst, key}, args...)...)
+		// the stored set holds the members in the range
+		stored, err := rCli.Do(context.TODO(), append([]interface{}{"zrange", key}, args...)...).Slice()
+		assert.Equal(t, nil, err)
+		res, err := mCli.ZRange(context.TODO(), dst, 0, -1).Result()
+		assert.Equal(t, nil, err)
+		assert.ElementsMatch(t, stored, strs(res...))
+	}
+	assertModis(t, int64(0), "zrangestore", dst, key, 20, 30)
+	assertModis(t, int64(0), "exists", dst)
+	assertModis(t, int64(0), "zrangestore", dst, "nonexist", 0, -1)
+
+	assertModis(t, errors.New("ERR syntax error"), "zrangestore", dst, key, 0, -1, "withscores")
+	assertModis(t, errors.New("ERR syntax error, LIMIT is only supported in combination with either BYSCORE or BYLEX"),
+		"zrangestore", dst, key, 0, -1, "limit", 0, 1)
+	assertModis(t, errors.New("ERR wrong number of arguments for 'zrangestore' command"), "zrangestore", dst, key, 0)
+}
+
+func addSetOpTestData(t *testing.T) {
+	assert.Equal(t, nil, do_zadd(t, "zset1", []string{"a", "b", "c"}, []float64{1, 2, 3}))
+	assert.Equal(t, nil, do_zadd(t, "zset2", []string{"b", "c", "d"}, []float64{10, 20, 30}))
+	assert.Equal(t, nil, do_zadd(t, "zset3", []string{"c", "e"}, []float64{100, 200}))
+}
+
+func TestZUnionInter(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisZSetTableName)
+	addSetOpTestData(t)
+
+	for _, cmd := range []string{"zunion", "zinter"} {
+		assertSame(t, cmd, 2, "zset1", "zset2")
+		assertSame(t, cmd, 2, "zset1", "zset2", "withscores")
+		assertSame(t, cmd, 3, "zset1", "zset2", "zset3", "withscores")
+		assertSame(t, cmd, 2, "zset1", "zset2", "weights", 2, 3, "withscores")
+		assertSame(t, cmd, 2, "zset1", "zset2", "aggregate", "min", "withscores")
+		assertSame(t, cmd, 2, "zset1", "zset2", "weights", 1, -1, "aggregate", "max", "withscores")
+		assertSame(t, cmd, 2, "zset1", "nonexist", "withscores")
+		assertSame(t, cmd, 1, "nonexist")
+
+		assertSame(t, cmd, 2, "zset1")
+		assertSame(t, cmd, "a", "zset1")
+		assertSame(t, cmd, 2, "zset1", "zset2", "weights", 1)
+		assertSame(t, cmd, 2, "zset1", "zset2", "weights", 1, "a")
+		assertSame(t, cmd, 2, "zset1", "zset2", "aggregate", "foo")
+		assertSame(t, cmd, 2, "zset1", "zset2", "foo")
+	}
+	assertModis(t, errors.New("ERR at least 1 input key is needed for 'zunion' command"), "zunion", 0, "zset1")
+	assertModis(t, errors.New("ERR at least 1 input key is needed for 'zinterstore' command"), "zinterstore", "dst", 0, "zset1")
+}
+
+func TestZDiff(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisZSetTableName)
+	addSetOpTestData(t)
+
+	assertModis(t, strs("a"), "zdiff", 2, "zset1", "zset2")
+	assertModis(t, strs("a", "1", "b", "2"), "zdiff", 2, "zset1", "zset3", "withscores")
+	assertModis(t, strs("a", "b", "c"), "zdiff", 1, "zset1")
+	assertModis(t, strs("a"), "zdiff", 3, "zset1", "zset2", "zset3")
+	assertModis(t, strs(), "zdiff", 2, "zset1", "zset1")
+	assertModis(t, strs(), "zdiff", 2, "nonexist", "zset1")
+	assertModis(t, strs("a", "b", "c"), "zdiff", 2, "zset1", "nonexist")
+
+	assertModis(t, errors.New("ERR syntax error"), "zdiff", 2, "zset1", "zset2", "weights", 1, 2)
+	assertModis(t, errors.New("ERR syntax error"), "zdiff", 2, "zset1", "zset2", "aggregate", "min")
+	assertModis(t, errors.New("ERR syntax error"), "zdiff", 3, "zset1", "zset2")
+	assertSame(t, "set", "str", "value")
+	assertModis(t, errors.New("WRONGTYPE Operation against a key holding the wrong kind of value"), "zdiff", 2, "zset1", "str")
+	assertSame(t, "del", "str")
+}
+
+func TestZInterCard(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisZSetTableName)
+	addSetOpTestData(t)
+
+	assertModis(t, int64(2), "zintercard", 2, "zset1", "zset2")
+	assertModis(t, int64(1), "zintercard", 3, "zset1", "zset2", "zset3")
+	assertModis(t, int64(1), "zintercard", 2, "zset1", "zset2", "limit", 1)
+	assertModis(t, int64(2), "zintercard", 2, "zset1", "zset2", "limit", 0)
+	assertModis(t, int64(3), "zintercard", 1, "zset1", "limit", 10)
+	assertModis(t, int64(0), "zintercard", 2, "zset1", "nonexist")
+
+	assertModis(t, errors.New("ERR numkeys should be greater than 0"), "zintercard", 0, "zset1")
+	assertModis(t, errors.New("ERR Number of keys can't be greater than number of args"), "zintercard", 3, "zset1", "zset2")
+	assertModis(t, errors.New("ERR LIMIT can't be negative"), "zintercard", 1, "zset1", "limit", -1)
+	assertModis(t, errors.New("ERR syntax error"), "zintercard", 1, "zset1", "foo", 1)
+}
@@ -595,3 +595,229 @@ func TestZInterStore(t *testing.T) {
 	assert.Equal(t, nil, err)
 	assert.Equal(t, vals, vals_m)
 }
+
+// zscanAll iterates the sorted set with ZSCAN and returns the members with their scores
+func zscanAll(t *testing.T, cli *redis.Client, key string, match string, count int64) map[string]string {
+	res := make(map[string]string)
+	var cursor uint64
+	for {
+		page, next, err := cli.ZScan(context.TODO(), key, cursor, match, count).Result()
+		assert.Equal(t, nil, err)
+		if err != nil {
+			return nil
+		}
+		for i := 0; i+1 < len(page); i += 2 {
+			res[page[i]] = page[i+1]
+		}
+		if cursor = next; cursor == 0 {
+			return res
+		}
+	}
+}
+
+func TestZScan(t *testing.T) {
+	defer test.ClearDb(0, rCli, test.TestModisZSetTableName)
+
+	// empty
+	assert.Equal(t, zscanAll(t, rCli, "myzset", "", 10), zscanAll(t, mCli, "myzset", "", 10))
+
+	for i := 0; i < 50; i++ {
+		z := &redis.Z{Score: float64(i) / 4, Member: "member" + strconv.Itoa(i)}
+		assert.Equal(t, nil, rCli.ZAdd(context.TODO(), "myzset", z).Err())
+		assert.Equal(t, nil, mCli.ZAdd(context.TODO(), "myzset", z).Err())
+	}
+	for _, count := range []int64{1, 7, 10, 100} {
+		assert.Equal(t, zscanAll(t, rCli, "myzset", "", count), zscanAll(t, mCli, "myzset", "", count))
+		assert.Equal(t, zscanAll(t, rCli, "myzset", "member[0-2]", count), zscanAll(t, mCli, "myzset", "member[0-2]", count))
+	}
+	assert.Equal(t, 50, len(zscanAll(t, mCli, "myzset", "", 7)))
+}
+
+// assertSame asserts modis replies the same as redis
+func assertSame(t *testing.T, args ...interface{}) {
+	res, err := rCli.Do(context.TODO(), args...).Result()
+	resM, errM := mCli.Do(context.TODO(), args...).Result()
+	assert.Equal(t, err, errM, args)
+	assert.Equal(t, res, resM, args)
+}
+
+func TestZAddOptions(t *testing.T) {
+	key := "zsetkey"
+	defer test.ClearDb(0, rCli, test.TestModisZSetTableName)
+
+	assertSame(t, "zadd", key, "xx", 1, "a")
+	assertSame(t, "exists", key)
+	assertSame(t, "zadd", key, "nx", 1, "a", 2, "b")
+	assertSame(t, "zadd", key, "nx", 10, "a", 3, "c")
+	assertSame(t, "zadd", key, "xx", 10, "a", 4, "d")
+	assertSame(t, "zadd", key, "xx", "ch", 11, "a", 2, "b", 5, "e")
+	assertSame(t, "zadd", key, "ch", 12, "a", 2, "b", 5, "e")
+	assertSame(t, "zadd", key, "gt", "ch", 20, "a", 1, "b", 6, "f")
+	assertSame(t, "zadd", key, "lt", "ch", 30, "a", 1, "b", 7, "g")
+	assertSame(t, "zadd", key, "xx", "gt", 40, "a", 0, "b", 8, "h")
+	assertSame(t, "zrange", key, 0, -1, "withscores")
+
+	assertSame(t, "zadd", key, "incr", 5, "a")
+	assertSame(t, "zadd", key, "incr", "xx", 5, "x")
+	assertSame(t, "zadd", key, "incr", "nx", 5, "a")
+	assertSame(t, "zadd", key, "incr", "nx", 5, "x")
+	assertSame(t, "zrange", key, 0, -1, "withscores")
+
+	assertSame(t, "zadd", key, "nx", "xx", 1, "a")
+	assertSame(t, "zadd", key, "gt", "lt", 1, "a")
+	assertSame(t, "zadd", key, "gt", "nx", 1, "a")
+	assertSame(t, "zadd", key, "incr", 1, "a", 2, "b")
+	assertSame(t, "zadd", key, "ch", 1)
+	assertSame(t, "zadd", key, "ch", 1, "a", 2)
+	assertSame(t, "zadd", key, "ch", "foo", "a")
+
+	// miniredis ignores GT and LT with INCR, and checks the scores before the options
+	assertModis(t, nil, "zadd", key, "incr", "gt", -1, "a")
+	assertModis(t, "46", "zadd", key, "incr", "gt", 1, "a")
+	assertModis(t, nil, "zadd", key, "incr", "lt", 1, "a")
+	assertModis(t, "-1", "zadd", key, "incr", "lt", -1, "newone")
+	assertModis(t, errors.New("ERR XX and NX options at the same time are not compatible"), "zadd", key, "nx", "xx", "foo", "a")
+	assertModis(t, errors.New("ERR GT, LT, and/or NX options at the same time are not compatible"), "zadd", key, "incr", "gt", "lt", 1, "a", 2, "b")
+	assertModis(t, "inf", "zadd", key, "incr", "inf", "inf")
+	assertModis(t, errors.New("ERR resulting score is not a number (NaN)"), "zadd", key, "incr", "-inf", "inf")
+}
+
+func TestZMScore(t *testing.T) {
+	key := "zsetkey"
+	defer test.ClearDb(0, rCli, test.TestModisZSetTableName)
+	members, scores := generateTestData(3)
+	assert.Equal(t, nil, do_zadd(t, key, members, scores))
+
+	assertSame(t, "zmscore", key, members[0], "nonexist", members[2])
+	assertSame(t, "zmscore", "nonexist", members[0], members[1])
+	assertSame(t, "zadd", key, "inf", "inf", "-inf", "-inf")
+	assertSame(t, "zmscore", key, "inf", "-inf")
+}
+
+func TestZRandMember(t *testing.T) {
+	key := "zsetkey"
+	defer test.ClearDb(0, rCli, test.TestModisZSetTableName)
+	members, scores := generateTestData(5)
+	assert.Equal(t, nil, do_zadd(t, key, members, scores))
+	scoreOf := make(map[string]string)
+	for i, member := range members {
+		scoreOf[member] = strconv.FormatFloat(scores[i], 'f', -1, 64)
+	}
+
+	assertSame(t, "zrandmember", "nonexist")
+	assertSame(t, "zrandmember", "nonexist", 5)
+	assertSame(t, "zrandmember", key, 0)
+	assertSame(t, "zrandmember", key, "foo")
+
+	member, err := mCli.ZRandMember(context.TODO(), key, 1, false).Result()
+	assert.Equal(t, nil, err)
+	assert.Len(t, member, 1)
+	assert.Contains(t, members, member[0])
+	res, err := mCli.Do(context.TODO(), "zrandmember", key).Text()
+	assert.Equal(t, nil, err)
+	assert.Contains(t, members, res)
+
+	// distinct members
+	for _, count := range []int{3, 5, 10} {
+		res, err := mCli.ZRandMember(context.TODO(), key, count, false).Result()
+		assert.Equal(t, nil, err)
+		assert.Len(t, res, min(count, len(members)))
+		seen := make(map[string]bool)
+		for _, member := range res {
+			assert.Contains(t, members, member)
+			assert.False(t, seen[member])
+			seen[member] = true
+		}
+	}
+	res2, err := mCli.ZRandMember(context.TODO(), key, 3, true).Result()
+	assert.Equal(t, nil, err)
+	assert.Len(t, res2, 6)
+	for i := 0; i < len(res2); i += 2 {
+		assert.Equal(t, scoreOf[res2[i]], res2[i+1])
+	}
+
+	// repeated members
+	res3, err := mCli.ZRandMember(context.TODO(), key, -20, true).Result()
+	assert.Equal(t, nil, err)
+	assert.Len(t, res3, 40)
+	for i := 0; i < len(res3); i += 2 {
+		assert.Equal(t, scoreOf[res3[i]], res3[i+1])
+	}
+
+	assertModis(t, errors.New("ERR syntax error"), "zrandmember", key, 3, "withscore")
+	assertModis(t, errors.New("ERR value is out of range"), "zrandmember", key, "-9223372036854775808")
+	assertModis(t, errors.New("ERR value is out of range"), "zrandmember", key, "-9223372036854775807", "withscores")
+}
+
+func addLexTestData(t *testing.T, key string) {
+	for _, member := range []string{"alpha", "bar", "cool", "down", "elephant", "foo", "great", "hill", "omega"} {
+		assertSame(t, "zadd", key, 0, member)
+	}
+}
+
+func TestZRangeByLex(t *testing.T) {
+	key := "zsetkey"
+	defer test.ClearDb(0, rCli, test.TestModisZSetTableName)
+	addLexTestData(t, key)
+
+	for _, bounds := range [][2]string{
+		{"-", "+"}, {"-", "[cool"}, {"-", "(cool"}, {"[bar", "[down"}, {"(bar", "(down"},
+		{"[foo", "+"}, {"(foo", "+"}, {"[great", "[great"}, {"(great", "[great"}, {"[hill", "[bar"},
+		{"+", "-"}, {"+", "+"}, {"-", "-"}, {"[a", "[z"}, {"[", "[b"}, {"(omega", "+"},
+	} {
+		assertSame(t, "zrangebylex", key, bounds[0], bounds[1])
+		assertSame(t, "zrevrangebylex", key, bounds[1], bounds[0])
+	}
+	assertSame(t, "zrangebylex", key, "-", "+", "limit", 0, 3)
+	assertSame(t, "zrangebylex", key, "-", "+", "limit", 2, 3)
+	assertSame(t, "zrangebylex", key, "[bar", "+", "LIMIT", 3, -1)
+	assertSame(t, "zrangebylex", key, "-", "+", "limit", 20, 3)
+	assertSame(t, "zrangebylex", key, "-", "+", "limit", -1, 3)
+	assertSame(t, "zrangebylex", key, "-", "+", "limit", 0, 0)
+	assertSame(t, "zrevrangebylex", key, "+", "[cool", "limit", 1, 2)
+	assertSame(t, "zrevrangebylex", key, "(hill", "-", "limit", 0, -1)
+	assertSame(t, "zrangebylex", "nonexist", "-", "+")
+
+	assertSame(t, "zrangebylex", key, "-", "+", "limit", 0)
+	assertSame(t, "zrangebylex", key, "-", "+", "limit", "a", 1)
+	assertSame(t, "zrangebylex", key, "-", "+", "foo")
+	assertSame(t, "zrangebylex", key, "foo", "+")
+	assertSame(t, "zrangebylex", key, "-", "bar")
+	assertSame(t, "zrangebylex", key, "", "+")
+	assertSame(t, "set", "str", "value")
+	assertSame(t, "zrangebylex", "str", "-", "+")
+	assertSame(t, "del", "str")
+}
+
+func TestZLexCount(t *testing.T) {
+	key := "zsetkey"
+	defer test.ClearDb(0, rCli, test.TestModisZSetTableName)
+	addLexTestData(t, key)
+
+	for _, bounds := range [][2]string{
+		{"-", "+"}, {"-", "[cool"}, {"(bar", "(down"}, {"[foo", "+"}, {"[great", "[great"},
+		{"(great", "(great"}, {"[hill", "[bar"}, {"+", "-"}, {"[a", "[z"},
+	} {
+		assertSame(t, "zlexcount", key, bounds[0], bounds[1])
+	}
+	assertSame(t, "zlexcount", "nonexist", "-", "+")
+	assertSame(t, "zlexcount", key, "a", "+")
+}
+
+func TestZRemRangeByLex(t *testing.T) {
+	key := "zsetkey"
+	defer test.ClearDb(0, rCli, test.TestModisZSetTableName)
+	addLexTestData(t, key)
+
+	assertSame(t, "zremrangebylex", key, "[hill", "[bar")
+	assertSame(t, "zremrangebylex", key, "(bar", "[down")
+	assertSame(t, "zrange", key, 0, -1)
+	assertSame(t, "zremrangebylex", key, "[foo", "+")
+	assertSame(t, "zrange", key, 0, -1)
+	assertSame(t, "zremrangebylex", key, "-", "(e")
+	assertSame(t, "zrange", key, 0, -1)
+	assertSame(t, "zremrangebylex", key, "-", "+")
+	assertSame(t, "exists", key)
+	assertSame(t, "zremrangebylex", key, "-", "+")
+	assertSame(t, "zremrangebylex", key, "-", "bar")
+}
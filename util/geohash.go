@@ -0,0 +1,370 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import "math"
+
+// The locations of GEO are kept in sorted sets the same way as redis: the score of a member is the
+// 52 bits geohash of its longitude and latitude, interleaving 26 bits of each, so that the near
+// locations get the near scores and an area is a range of scores.
+//
+// Unlike the standard geohash, the latitude ranges in [-85.05112878, 85.05112878], the limits of
+// the Web Mercator projection, while the longitude ranges in [-180, 180].
+const (
+	GeoLongMin = -180
+	GeoLongMax = 180
+	GeoLatMin  = -85.05112878
+	GeoLatMax  = 85.05112878
+
+	geoStepMax          = 26
+	geoEarthRadius      = 6372797.560856
+	geoMercatorMax      = 20037726.37
+	geoDegreeToRadian   = math.Pi / 180.0
+	geoStandardAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+)
+
+// geoHashBits is a geohash of step bits of each coordinate
+type geoHashBits struct {
+	bits uint64
+	step uint
+}
+
+func (h geoHashBits) isZero() bool {
+	return h.bits == 0 && h.step == 0
+}
+
+// align52 returns the geohash as a 52 bits score
+func (h geoHashBits) align52() uint64 {
+	return h.bits << (52 - h.step*2)
+}
+
+type geoRange struct {
+	min float64
+	max float64
+}
+
+type geoArea struct {
+	longitude geoRange
+	latitude  geoRange
+}
+
+var (
+	geoLongRange = geoRange{min: GeoLongMin, max: GeoLongMax}
+	geoLatRange  = geoRange{min: GeoLatMin, max: GeoLatMax}
+)
+
+// interleave64 interleaves the bits of x and y, x takes the even bits and y the odd ones
+func interleave64(xlo uint32, ylo uint32) uint64 {
+	b := [...]uint64{0x5555555555555555, 0x3333333333333333, 0x0F0F0F0F0F0F0F0F, 0x00FF00FF00FF00FF, 0x0000FFFF0000FFFF}
+	s := [...]uint{1, 2, 4, 8, 16}
+	x, y := uint64(xlo), uint64(ylo)
+	for i := len(s) - 1; i >= 0; i-- {
+		x = (x | (x << s[i])) & b[i]
+		y = (y | (y << s[i])) & b[i]
+	}
+	return x | (y << 1)
+}
+
+// deinterleave64 reverses interleave64, x is returned in the low 32 bits and y in the high ones
+func deinterleave64(interleaved uint64) uint64 {
+	b := [...]uint64{0x5555555555555555, 0x3333333333333333, 0x0F0F0F0F0F0F0F0F, 0x00FF00FF00FF00FF,
+		0x0000FFFF0000FFFF, 0x00000000FFFFFFFF}
+	s := [...]uint{0, 1, 2, 4, 8, 16}
+	x, y := interleaved, interleaved>>1
+	for i := range s {
+		x = (x | (x >> s[i])) & b[i]
+		y = (y | (y >> s[i])) & b[i]
+	}
+	return x | (y << 32)
+}
+
+// geoEncode returns the geohash of step bits of the location, false if it is out of the ranges
+func geoEncode(longRange, latRange geoRange, longitude, latitude float64, step uint) (geoHashBits, bool) {
+	if longitude > GeoLongMax || longitude < GeoLongMin || latitude > GeoLatMax || latitude < GeoLatMin ||
+		latitude < latRange.min || latitude > latRange.max ||
+		longitude < longRange.min || longitude > longRange.max {
+		return geoHashBits{}, false
+	}
+	latOffset := (latitude - latRange.min) / (latRange.max - latRange.min)
+	longOffset := (longitude - longRange.min) / (longRange.max - longRange.min)
+	latOffset *= float64(uint64(1) << step)
+	longOffset *= float64(uint64(1) << step)
+	return geoHashBits{bits: interleave64(uint32(latOffset), uint32(longOffset)), step: step}, true
+}
+
+// geoDecode returns the area of the geohash
+func geoDecode(hash geoHashBits) (geoArea, bool) {
+	if hash.isZero() {
+		return geoArea{}, false
+	}
+	sep := deinterleave64(hash.bits)
+	ilat, ilong := uint32(sep), uint32(sep>>32)
+	scale := float64(uint64(1) << hash.step)
+	latScale := geoLatRange.max - geoLatRange.min
+	longScale := geoLongRange.max - geoLongRange.min
+	return geoArea{
+		latitude: geoRange{
+			min: geoLatRange.min + (float64(ilat)/scale)*latScale,
+			max: geoLatRange.min + (float64(ilat+1)/scale)*latScale,
+		},
+		longitude: geoRange{
+			min: geoLongRange.min + (float64(ilong)/scale)*longScale,
+			max: geoLongRange.min + (float64(ilong+1)/scale)*longScale,
+		},
+	}, true
+}
+
+// center returns the center of the area, within the ranges of the coordinates
+func (a geoArea) center() (longitude float64, latitude float64) {
+	longitude = math.Min(math.Max((a.longitude.min+a.longitude.max)/2, GeoLongMin), GeoLongMax)
+	latitude = math.Min(math.Max((a.latitude.min+a.latitude.max)/2, GeoLatMin), GeoLatMax)
+	return longitude, latitude
+}
+
+// GeoScore returns the score of the location, false if it is out of the ranges
+func GeoScore(longitude, latitude float64) (uint64, bool) {
+	hash, ok := geoEncode(geoLongRange, geoLatRange, longitude, latitude, geoStepMax)
+	if !ok {
+		return 0, false
+	}
+	return hash.align52(), true
+}
+
+// GeoDecodeScore returns the location of the score, the center of the area of its geohash
+func GeoDecodeScore(score float64) (longitude float64, latitude float64, ok bool) {
+	area, ok := geoDecode(geoHashBits{bits: uint64(score), step: geoStepMax})
+	if !ok {
+		return 0, 0, false
+	}
+	longitude, latitude = area.center()
+	return longitude, latitude, true
+}
+
+// GeoHashString returns the standard 11 characters geohash of the score,
+// which is encoded again with the latitude in [-90, 90]
+func GeoHashString(score float64) (string, bool) {
+	longitude, latitude, ok := GeoDecodeScore(score)
+	if !ok {
+		return "", false
+	}
+	hash, _ := geoEncode(geoRange{min: -180, max: 180}, geoRange{min: -90, max: 90}, longitude, latitude, geoStepMax)
+	buf := make([]byte, 11)
+	for i := range buf {
+		idx := 0
+		// there are only 52 bits, the last character is always 0
+		if i < 10 {
+			idx = int(hash.bits>>(52-(i+1)*5)) & 0x1f
+		}
+		buf[i] = geoStandardAlphabet[idx]
+	}
+	return string(buf), true
+}
+
+// geoLatDistance returns the distance in meters between two latitudes on the same meridian
+func geoLatDistance(lat1, lat2 float64) float64 {
+	return geoEarthRadius * math.Abs(lat2*geoDegreeToRadian-lat1*geoDegreeToRadian)
+}
+
+// GeoDistance returns the distance in meters between two locations by the haversine formula
+func GeoDistance(long1, lat1, long2, lat2 float64) float64 {
+	v := math.Sin((long2*geoDegreeToRadian - long1*geoDegreeToRadian) / 2)
+	if v == 0 {
+		return geoLatDistance(lat1, lat2)
+	}
+	lat1r, lat2r := lat1*geoDegreeToRadian, lat2*geoDegreeToRadian
+	u := math.Sin((lat2r - lat1r) / 2)
+	a := u*u + math.Cos(lat1r)*math.Cos(lat2r)*v*v
+	return 2.0 * geoEarthRadius * math.Asin(math.Sqrt(a))
+}
+
+// GeoShape is the area searched by GEOSEARCH, a circle of Radius or a box of Width and Height
+// around the center, the distances are in meters
+type GeoShape struct {
+	Longitude float64
+	Latitude  float64
+	IsBox     bool
+	Radius    float64
+	Width     float64
+	Height    float64
+}
+
+// Contains returns the distance from the center to the location, false if it is out of the shape
+func (s *GeoShape) Contains(longitude, latitude float64) (float64, bool) {
+	if !s.IsBox {
+		distance := GeoDistance(s.Longitude, s.Latitude, longitude, latitude)
+		return distance, distance <= s.Radius
+	}
+	if geoLatDistance(latitude, s.Latitude) > s.Height/2 {
+		return 0, false
+	}
+	if GeoDistance(longitude, latitude, s.Longitude, latitude) > s.Width/2 {
+		return 0, false
+	}
+	return GeoDistance(s.Longitude, s.Latitude, longitude, latitude), true
+}
+
+// boundingBox returns the min longitude, min latitude, max longitude and max latitude of the shape
+func (s *GeoShape) boundingBox() (float64, float64, float64, float64) {
+	height, width := s.Radius, s.Radius
+	if s.IsBox {
+		height, width = s.Height/2, s.Width/2
+	}
+	latDelta := height / geoEarthRadius / geoDegreeToRadian
+	longDeltaTop := width / geoEarthRadius / math.Cos((s.Latitude+latDelta)*geoDegreeToRadian) / geoDegreeToRadian
+	longDeltaBottom := width / geoEarthRadius / math.Cos((s.Latitude-latDelta)*geoDegreeToRadian) / geoDegreeToRadian
+	// the directions of the northern and southern hemispheres are opposite
+	longDelta := longDeltaTop
+	if s.Latitude < 0 {
+		longDelta = longDeltaBottom
+	}
+	return s.Longitude - longDelta, s.Latitude - latDelta, s.Longitude + longDelta, s.Latitude + latDelta
+}
+
+// geoEstimateSteps returns the step of the geohash boxes to search a range of meters around latitude
+func geoEstimateSteps(rangeMeters float64, latitude float64) uint {
+	if rangeMeters == 0 {
+		return geoStepMax
+	}
+	step := 1
+	for rangeMeters < geoMercatorMax {
+		rangeMeters *= 2
+		step++
+	}
+	// make sure the range is included in most of the base cases
+	step -= 2
+	// wider range towards the poles
+	if latitude > 66 || latitude < -66 {
+		step--
+		if latitude > 80 || latitude < -80 {
+			step--
+		}
+	}
+	return uint(min(max(step, 1), geoStepMax))
+}
+
+// moveX moves the geohash by a box along the longitude, east if d > 0 and west if d < 0
+func (h *geoHashBits) moveX(d int) {
+	if d == 0 {
+		return
+	}
+	x := h.bits & 0xaaaaaaaaaaaaaaaa
+	y := h.bits & 0x5555555555555555
+	zz := uint64(0x5555555555555555) >> (64 - h.step*2)
+	if d > 0 {
+		x = x + (zz + 1)
+	} else {
+		x = x | zz
+		x = x - (zz + 1)
+	}
+	x &= uint64(0xaaaaaaaaaaaaaaaa) >> (64 - h.step*2)
+	h.bits = x | y
+}
+
+// moveY moves the geohash by a box along the latitude, north if d > 0 and south if d < 0
+func (h *geoHashBits) moveY(d int) {
+	if d == 0 {
+		return
+	}
+	x := h.bits & 0xaaaaaaaaaaaaaaaa
+	y := h.bits & 0x5555555555555555
+	zz := uint64(0xaaaaaaaaaaaaaaaa) >> (64 - h.step*2)
+	if d > 0 {
+		y = y + (zz + 1)
+	} else {
+		y = y | zz
+		y = y - (zz + 1)
+	}
+	y &= uint64(0x5555555555555555) >> (64 - h.step*2)
+	h.bits = x | y
+}
+
+// neighbor returns the box next to the geohash in the direction
+func (h geoHashBits) neighbor(dx int, dy int) geoHashBits {
+	h.moveX(dx)
+	h.moveY(dy)
+	return h
+}
+
+// Ranges returns the score ranges [min, max) to search for the locations in the shape, that is
+// the geohash box of the center and its 8 neighbors, in the same order as redis
+func (s *GeoShape) Ranges() [][2]uint64 {
+	minLong, minLat, maxLong, maxLat := s.boundingBox()
+	radius := s.Radius
+	if s.IsBox {
+		radius = math.Sqrt((s.Width/2)*(s.Width/2) + (s.Height/2)*(s.Height/2))
+	}
+	steps := geoEstimateSteps(radius, s.Latitude)
+
+	var hash geoHashBits
+	var area geoArea
+	var neighbors [8]geoHashBits // north, south, east, west, north east, north west, south east, south west
+	search := func() {
+		hash, _ = geoEncode(geoLongRange, geoLatRange, s.Longitude, s.Latitude, steps)
+		area, _ = geoDecode(hash)
+		neighbors = [8]geoHashBits{hash.neighbor(0, 1), hash.neighbor(0, -1), hash.neighbor(1, 0), hash.neighbor(-1, 0),
+			hash.neighbor(1, 1), hash.neighbor(-1, 1), hash.neighbor(1, -1), hash.neighbor(-1, -1)}
+	}
+	search()
+
+	// the estimated step may be too large when the search area is near the edge of the box
+	north, _ := geoDecode(neighbors[0])
+	south, _ := geoDecode(neighbors[1])
+	east, _ := geoDecode(neighbors[2])
+	west, _ := geoDecode(neighbors[3])
+	if steps > 1 && (north.latitude.max < maxLat || south.latitude.min > minLat ||
+		east.longitude.max < maxLong || west.longitude.min > minLong) {
+		steps--
+		search()
+	}
+
+	// exclude the useless boxes
+	if steps >= 2 {
+		if area.latitude.min < minLat {
+			neighbors[1], neighbors[7], neighbors[6] = geoHashBits{}, geoHashBits{}, geoHashBits{}
+		}
+		if area.latitude.max > maxLat {
+			neighbors[0], neighbors[4], neighbors[5] = geoHashBits{}, geoHashBits{}, geoHashBits{}
+		}
+		if area.longitude.min < minLong {
+			neighbors[3], neighbors[7], neighbors[5] = geoHashBits{}, geoHashBits{}, geoHashBits{}
+		}
+		if area.longitude.max > maxLong {
+			neighbors[2], neighbors[6], neighbors[4] = geoHashBits{}, geoHashBits{}, geoHashBits{}
+		}
+	}
+
+	boxes := append([]geoHashBits{hash}, neighbors[:]...)
+	ranges := make([][2]uint64, 0, len(boxes))
+	var last *geoHashBits
+	for i := range boxes {
+		box := boxes[i]
+		if box.isZero() {
+			continue
+		}
+		// the neighbors may be the same box with a huge radius
+		if last != nil && *last == box {
+			continue
+		}
+		if i > 0 {
+			last = &boxes[i]
+		}
+		next := box
+		next.bits++
+		ranges = append(ranges, [2]uint64{box.align52(), next.align52()})
+	}
+	return ranges
+}
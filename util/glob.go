@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+// GlobMatch reports whether str matches the glob-style pattern following the rules of redis:
+// * matches any sequence, ? matches one byte, [abc] [^abc] [a-z] match a set of bytes,
+// and \ escapes the next byte
+func GlobMatch(pattern []byte, str []byte, nocase bool) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(str); i++ {
+				if GlobMatch(pattern[1:], str[i:], nocase) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(str) == 0 {
+				return false
+			}
+			str = str[1:]
+		case '[':
+			if len(str) == 0 {
+				return false
+			}
+			var matched bool
+			matched, pattern = matchClass(pattern[1:], str[0], nocase)
+			if !matched {
+				return false
+			}
+			str = str[1:]
+			if len(pattern) == 0 {
+				// the class is not closed, the pattern ends with it
+				return len(str) == 0
+			}
+		case '\\':
+			if len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(str) == 0 || !equalByte(pattern[0], str[0], nocase) {
+				return false
+			}
+			str = str[1:]
+		}
+		pattern = pattern[1:]
+	}
+	return len(str) == 0
+}
+
+// matchClass matches c against the class at the head of pattern, which follows '['.
+// It returns the pattern starting at the closing ']', or empty if the class is not closed
+func matchClass(pattern []byte, c byte, nocase bool) (bool, []byte) {
+	not := len(pattern) > 0 && pattern[0] == '^'
+	if not {
+		pattern = pattern[1:]
+	}
+	match := false
+	for len(pattern) > 0 && pattern[0] != ']' {
+		switch {
+		case pattern[0] == '\\' && len(pattern) >= 2:
+			pattern = pattern[1:]
+			if pattern[0] == c {
+				match = true
+			}
+		case len(pattern) >= 3 && pattern[1] == '-':
+			start, end := pattern[0], pattern[2]
+			if start > end {
+				start, end = end, start
+			}
+			pattern = pattern[2:]
+			lc := c
+			if nocase {
+				start, end, lc = toLower(start), toLower(end), toLower(c)
+			}
+			if lc >= start && lc <= end {
+				match = true
+			}
+		default:
+			if equalByte(pattern[0], c, nocase) {
+				match = true
+			}
+		}
+		pattern = pattern[1:]
+	}
+	if not {
+		match = !match
+	}
+	return match, pattern
+}
+
+func equalByte(a byte, b byte, nocase bool) bool {
+	if nocase {
+		return toLower(a) == toLower(b)
+	}
+	return a == b
+}
+
+func toLower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
@@ -0,0 +1,574 @@
+/*
+ * Copyright (c) 2024 OceanBase.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// The HyperLogLog is kept in the string encoding of redis, so that the values are byte compatible
+// with redis: a 16 bytes header "HYLL" + encoding + 3 unused bytes + the cached cardinality in
+// little endian, whose most significant bit set means the cache is invalid, followed by the
+// registers, either dense or sparse.
+//
+// The dense registers are 16384 6-bit registers packed from the least significant bit of each byte.
+// The sparse registers are run-length encoded with three opcodes:
+// ZERO 00xxxxxx for 1 to 64 zero registers, XZERO 01xxxxxx yyyyyyyy for 1 to 16384 zero registers,
+// and VAL 1vvvvvxx for 1 to 4 registers set to 1 to 32.
+const (
+	HLLRegisters = 1 << hllP
+	// HLLSparseMaxBytes is the size beyond which a sparse HyperLogLog is converted to the dense one,
+	// hll-sparse-max-bytes of redis
+	HLLSparseMaxBytes = 3000
+
+	hllP           = 14
+	hllQ           = 64 - hllP
+	hllPMask       = HLLRegisters - 1
+	hllBits        = 6
+	hllRegisterMax = (1 << hllBits) - 1
+	hllHdrSize     = 16
+	hllDenseSize   = hllHdrSize + (HLLRegisters*hllBits+7)/8
+	hllDense       = 0
+	hllSparse      = 1
+	hllAlphaInf    = 0.721347520444481703680
+
+	hllSparseXZeroBit      = 0x40
+	hllSparseValBit        = 0x80
+	hllSparseValMaxValue   = 32
+	hllSparseValMaxLen     = 4
+	hllSparseZeroMaxLen    = 64
+	hllSparseXZeroMaxLen   = 16384
+	hllMurmurSeed          = 0xadc83b19
+	hllCardCacheInvalidBit = 1 << 7
+)
+
+// ErrInvalidHLL is returned when the registers of a HyperLogLog are corrupted
+var ErrInvalidHLL = errors.New("INVALIDOBJ Corrupted HLL object detected")
+
+// HLL is a HyperLogLog in the encoding of redis
+type HLL struct {
+	buf []byte
+}
+
+// NewHLL returns an empty sparse HyperLogLog
+func NewHLL() *HLL {
+	buf := make([]byte, hllHdrSize, hllHdrSize+2)
+	copy(buf, "HYLL")
+	buf[4] = hllSparse
+	// all the registers are zero
+	buf = append(buf, 0, 0)
+	hllSparseXZeroSet(buf[hllHdrSize:], HLLRegisters)
+	return &HLL{buf: buf}
+}
+
+// ParseHLL returns the HyperLogLog of the value, false if it is not a HyperLogLog.
+// The registers are not checked until they are read
+func ParseHLL(val []byte) (*HLL, bool) {
+	if len(val) < hllHdrSize || string(val[:4]) != "HYLL" || val[4] > hllSparse {
+		return nil, false
+	}
+	if val[4] == hllDense && len(val) != hllDenseSize {
+		return nil, false
+	}
+	return &HLL{buf: append([]byte(nil), val...)}, true
+}
+
+// Bytes returns the encoded HyperLogLog
+func (h *HLL) Bytes() []byte {
+	return h.buf
+}
+
+// IsDense reports whether the registers are dense
+func (h *HLL) IsDense() bool {
+	return h.buf[4] == hllDense
+}
+
+// InvalidateCache marks the cached cardinality invalid, it is computed again by Count
+func (h *HLL) InvalidateCache() {
+	h.buf[15] |= hllCardCacheInvalidBit
+}
+
+// Add adds the element, returns true if a register is updated, in which case the cache needs to be invalidated
+func (h *HLL) Add(ele []byte) (bool, error) {
+	index, count := hllPatLen(ele)
+	if h.IsDense() {
+		return hllDenseSet(h.buf[hllHdrSize:], index, count), nil
+	}
+	return h.sparseSet(index, count)
+}
+
+// Count returns the estimated cardinality, the cached one if it is valid. Otherwise the cache is
+// updated and cached is false, so that the new value needs to be saved
+func (h *HLL) Count() (card uint64, cached bool, err error) {
+	if h.buf[15]&hllCardCacheInvalidBit == 0 {
+		return binary.LittleEndian.Uint64(h.buf[8:hllHdrSize]), true, nil
+	}
+	var histo [hllQ + 2]int
+	if h.IsDense() {
+		registers := h.buf[hllHdrSize:]
+		for i := 0; i < HLLRegisters; i++ {
+			histo[hllDenseGet(registers, i)]++
+		}
+	} else {
+		idx := 0
+		err = h.forEachSparse(func(op byte, runLen int, val uint8) bool {
+			if op == hllSparseValBit && idx+runLen > HLLRegisters {
+				return false
+			}
+			idx += runLen
+			histo[val] += runLen
+			return true
+		})
+		if err == nil && idx != HLLRegisters {
+			err = ErrInvalidHLL
+		}
+		if err != nil {
+			return 0, false, err
+		}
+	}
+	card = hllCount(&histo)
+	binary.LittleEndian.PutUint64(h.buf[8:hllHdrSize], card)
+	return card, false, nil
+}
+
+// MergeInto sets every register of max to the greater of the two
+func (h *HLL) MergeInto(max []uint8) error {
+	if h.IsDense() {
+		registers := h.buf[hllHdrSize:]
+		for i := 0; i < HLLRegisters; i++ {
+			if val := hllDenseGet(registers, i); val > max[i] {
+				max[i] = val
+			}
+		}
+		return nil
+	}
+	idx := 0
+	err := h.forEachSparse(func(op byte, runLen int, val uint8) bool {
+		if op != hllSparseValBit {
+			idx += runLen
+			return true
+		}
+		if idx+runLen > HLLRegisters {
+			return false
+		}
+		for ; runLen > 0; runLen-- {
+			if val > max[idx] {
+				max[idx] = val
+			}
+			idx++
+		}
+		return true
+	})
+	if err == nil && idx != HLLRegisters {
+		err = ErrInvalidHLL
+	}
+	return err
+}
+
+// SetRegisters raises the registers to the values of max, the registers already greater are kept
+func (h *HLL) SetRegisters(max []uint8) {
+	for i, val := range max {
+		if val == 0 {
+			continue
+		}
+		if h.IsDense() {
+			hllDenseSet(h.buf[hllHdrSize:], i, val)
+		} else {
+			// a corrupted HyperLogLog is left as it is, like redis does
+			_, _ = h.sparseSet(i, val)
+		}
+	}
+	h.InvalidateCache()
+}
+
+// ToDense converts the sparse registers to the dense ones
+func (h *HLL) ToDense() error {
+	if h.IsDense() {
+		return nil
+	}
+	dense := make([]byte, hllDenseSize)
+	// the magic and the cached cardinality are kept
+	copy(dense, h.buf[:hllHdrSize])
+	dense[4] = hllDense
+	registers := dense[hllHdrSize:]
+	idx := 0
+	err := h.forEachSparse(func(op byte, runLen int, val uint8) bool {
+		if op != hllSparseValBit {
+			idx += runLen
+			return true
+		}
+		if idx+runLen > HLLRegisters {
+			return false
+		}
+		for ; runLen > 0; runLen-- {
+			hllDenseSetRegister(registers, idx, val)
+			idx++
+		}
+		return true
+	})
+	if err == nil && idx != HLLRegisters {
+		err = ErrInvalidHLL
+	}
+	if err != nil {
+		return err
+	}
+	h.buf = dense
+	return nil
+}
+
+// forEachSparse calls fn with each opcode of the sparse registers along with its run length and
+// value, until fn returns false
+func (h *HLL) forEachSparse(fn func(op byte, runLen int, val uint8) bool) error {
+	p := h.buf[hllHdrSize:]
+	for i := 0; i < len(p); {
+		switch {
+		case hllSparseIsZero(p[i]):
+			if !fn(0, hllSparseZeroLen(p[i]), 0) {
+				return nil
+			}
+			i++
+		case hllSparseIsXZero(p[i]):
+			if i+1 >= len(p) {
+				return ErrInvalidHLL
+			}
+			if !fn(hllSparseXZeroBit, hllSparseXZeroLen(p[i:]), 0) {
+				return nil
+			}
+			i += 2
+		default:
+			if !fn(hllSparseValBit, hllSparseValLen(p[i]), hllSparseValValue(p[i])) {
+				return nil
+			}
+			i++
+		}
+	}
+	return nil
+}
+
+// sparseSet raises the register at index to count, the registers are converted to the dense ones if
+// count does not fit or they grow beyond HLLSparseMaxBytes. Returns true if the register is updated
+func (h *HLL) sparseSet(index int, count uint8) (bool, error) {
+	if count > hllSparseValMaxValue {
+		return h.promoteAndSet(index, count)
+	}
+
+	// step 1: locate the opcode covering the register
+	p, end := hllHdrSize, len(h.buf)
+	first, span, prev := 0, 0, -1
+	for p < end {
+		oplen := 1
+		switch {
+		case hllSparseIsZero(h.buf[p]):
+			span = hllSparseZeroLen(h.buf[p])
+		case hllSparseIsVal(h.buf[p]):
+			span = hllSparseValLen(h.buf[p])
+		default:
+			if p+1 >= end {
+				return false, ErrInvalidHLL
+			}
+			span = hllSparseXZeroLen(h.buf[p:])
+			oplen = 2
+		}
+		if index <= first+span-1 {
+			break
+		}
+		prev = p
+		p += oplen
+		first += span
+	}
+	if span == 0 || p >= end {
+		return false, ErrInvalidHLL
+	}
+
+	op := h.buf[p]
+	isZero, isXZero, isVal := hllSparseIsZero(op), hllSparseIsXZero(op), hllSparseIsVal(op)
+	var runLen int
+	switch {
+	case isZero:
+		runLen = hllSparseZeroLen(op)
+	case isXZero:
+		runLen = hllSparseXZeroLen(h.buf[p:])
+	default:
+		runLen = hllSparseValLen(op)
+	}
+
+	// step 2: update the opcode in place if it covers the register only, or split it
+	updated := false
+	if isVal {
+		if hllSparseValValue(op) >= count {
+			return false, nil
+		}
+		if runLen == 1 {
+			h.buf[p] = hllSparseValSet(count, 1)
+			updated = true
+		}
+	}
+	if !updated && isZero && runLen == 1 {
+		h.buf[p] = hllSparseValSet(count, 1)
+		updated = true
+	}
+	if !updated {
+		// the worst case is XZERO split into XZERO VAL XZERO
+		seq := make([]byte, 0, 5)
+		last := first + span - 1
+		if isZero || isXZero {
+			seq = hllAppendZeros(seq, index-first)
+			seq = append(seq, hllSparseValSet(count, 1))
+			seq = hllAppendZeros(seq, last-index)
+		} else {
+			curVal := hllSparseValValue(op)
+			if index != first {
+				seq = append(seq, hllSparseValSet(curVal, index-first))
+			}
+			seq = append(seq, hllSparseValSet(count, 1))
+			if index != last {
+				seq = append(seq, hllSparseValSet(curVal, last-index))
+			}
+		}
+
+		// step 3: substitute the new sequence for the old opcode
+		oldLen := 1
+		if isXZero {
+			oldLen = 2
+		}
+		if len(seq) > oldLen && len(h.buf)+len(seq)-oldLen > HLLSparseMaxBytes {
+			return h.promoteAndSet(index, count)
+		}
+		buf := make([]byte, 0, len(h.buf)+len(seq)-oldLen)
+		buf = append(buf, h.buf[:p]...)
+		buf = append(buf, seq...)
+		buf = append(buf, h.buf[p+oldLen:]...)
+		h.buf = buf
+		end = len(h.buf)
+	}
+
+	// step 4: merge the adjacent VAL opcodes of the same value around the update
+	p = hllHdrSize
+	if prev >= 0 {
+		p = prev
+	}
+	for scan := 5; p < end && scan > 0; scan-- {
+		switch {
+		case hllSparseIsXZero(h.buf[p]):
+			p += 2
+			continue
+		case hllSparseIsZero(h.buf[p]):
+			p++
+			continue
+		}
+		if p+1 < end && hllSparseIsVal(h.buf[p+1]) {
+			v1, v2 := hllSparseValValue(h.buf[p]), hllSparseValValue(h.buf[p+1])
+			if v1 == v2 {
+				if n := hllSparseValLen(h.buf[p]) + hllSparseValLen(h.buf[p+1]); n <= hllSparseValMaxLen {
+					h.buf[p+1] = hllSparseValSet(v1, n)
+					h.buf = append(h.buf[:p], h.buf[p+1:]...)
+					end--
+					// try to merge the merged value with the one on its right
+					continue
+				}
+			}
+		}
+		p++
+	}
+	h.InvalidateCache()
+	return true, nil
+}
+
+// promoteAndSet converts the registers to the dense ones and sets the register
+func (h *HLL) promoteAndSet(index int, count uint8) (bool, error) {
+	if err := h.ToDense(); err != nil {
+		return false, err
+	}
+	return hllDenseSet(h.buf[hllHdrSize:], index, count), nil
+}
+
+// hllAppendZeros appends the opcode of n zero registers
+func hllAppendZeros(seq []byte, n int) []byte {
+	switch {
+	case n == 0:
+		return seq
+	case n > hllSparseZeroMaxLen:
+		seq = append(seq, 0, 0)
+		hllSparseXZeroSet(seq[len(seq)-2:], n)
+		return seq
+	default:
+		return append(seq, byte(n-1))
+	}
+}
+
+func hllSparseIsZero(b byte) bool  { return b&0xc0 == 0 }
+func hllSparseIsXZero(b byte) bool { return b&0xc0 == hllSparseXZeroBit }
+func hllSparseIsVal(b byte) bool   { return b&hllSparseValBit != 0 }
+
+func hllSparseZeroLen(b byte) int    { return int(b&0x3f) + 1 }
+func hllSparseXZeroLen(p []byte) int { return (int(p[0]&0x3f)<<8 | int(p[1])) + 1 }
+func hllSparseValValue(b byte) uint8 { return (b>>2)&0x1f + 1 }
+func hllSparseValLen(b byte) int     { return int(b&0x3) + 1 }
+func hllSparseValSet(val uint8, n int) byte {
+	return byte(int(val-1)<<2|(n-1)) | hllSparseValBit
+}
+
+func hllSparseXZeroSet(p []byte, n int) {
+	n--
+	p[0] = byte(n>>8) | hllSparseXZeroBit
+	p[1] = byte(n)
+}
+
+func hllDenseGet(registers []byte, index int) uint8 {
+	b := index * hllBits / 8
+	fb := uint(index * hllBits & 7)
+	b0 := registers[b]
+	var b1 byte
+	if b+1 < len(registers) {
+		b1 = registers[b+1]
+	}
+	return (b0>>fb | b1<<(8-fb)) & hllRegisterMax
+}
+
+func hllDenseSetRegister(registers []byte, index int, val uint8) {
+	b := index * hllBits / 8
+	fb := uint(index * hllBits & 7)
+	registers[b] &^= hllRegisterMax << fb
+	registers[b] |= val << fb
+	if b+1 < len(registers) {
+		registers[b+1] &^= hllRegisterMax >> (8 - fb)
+		registers[b+1] |= val >> (8 - fb)
+	}
+}
+
+// hllDenseSet raises the register at index to count, returns true if it is updated
+func hllDenseSet(registers []byte, index int, count uint8) bool {
+	if count > hllDenseGet(registers, index) {
+		hllDenseSetRegister(registers, index, count)
+		return true
+	}
+	return false
+}
+
+// hllPatLen returns the register of the element and the length of the pattern 000..1 of its hash
+func hllPatLen(ele []byte) (int, uint8) {
+	hash := murmurHash64A(ele, hllMurmurSeed)
+	index := int(hash & hllPMask)
+	hash >>= hllP
+	// make sure the loop terminates
+	hash |= 1 << hllQ
+	count := uint8(1)
+	for bit := uint64(1); hash&bit == 0; bit <<= 1 {
+		count++
+	}
+	return index, count
+}
+
+// CountHLLRegisters returns the estimated cardinality of the raw registers, e.g. the merged ones
+func CountHLLRegisters(registers []uint8) uint64 {
+	var histo [hllQ + 2]int
+	for _, val := range registers {
+		histo[val]++
+	}
+	return hllCount(&histo)
+}
+
+// hllCount estimates the cardinality from the histogram of the registers, see
+// "New cardinality estimation algorithms for HyperLogLog sketches", Otmar Ertl, arXiv:1702.01284
+func hllCount(histo *[hllQ + 2]int) uint64 {
+	m := float64(HLLRegisters)
+	z := m * hllTau((m-float64(histo[hllQ+1]))/m)
+	for j := hllQ; j >= 1; j-- {
+		z += float64(histo[j])
+		z *= 0.5
+	}
+	z += m * hllSigma(float64(histo[0])/m)
+	return uint64(math.Round(hllAlphaInf * m * m / z))
+}
+
+func hllSigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+	y, z := 1.0, x
+	for {
+		x *= x
+		zPrime := z
+		z += x * y
+		y += y
+		if zPrime == z {
+			return z
+		}
+	}
+}
+
+func hllTau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+	y, z := 1.0, 1-x
+	for {
+		x = math.Sqrt(x)
+		zPrime := z
+		y *= 0.5
+		z -= math.Pow(1-x, 2) * y
+		if zPrime == z {
+			return z / 3
+		}
+	}
+}
+
+// murmurHash64A is the 64-bit MurmurHash2 by Austin Appleby, reading the words in little endian as redis does
+func murmurHash64A(key []byte, seed uint64) uint64 {
+	const m = 0xc6a4a7935bd1e995
+	const r = 47
+	h := seed ^ (uint64(len(key)) * m)
+	n := len(key) - len(key)&7
+	for i := 0; i < n; i += 8 {
+		k := binary.LittleEndian.Uint64(key[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+		h ^= k
+		h *= m
+	}
+	tail := key[n:]
+	switch len(tail) {
+	case 7:
+		h ^= uint64(tail[6]) << 48
+		fallthrough
+	case 6:
+		h ^= uint64(tail[5]) << 40
+		fallthrough
+	case 5:
+		h ^= uint64(tail[4]) << 32
+		fallthrough
+	case 4:
+		h ^= uint64(tail[3]) << 24
+		fallthrough
+	case 3:
+		h ^= uint64(tail[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint64(tail[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint64(tail[0])
+		h *= m
+	}
+	h ^= h >> r
+	h *= m
+	h ^= h >> r
+	return h
+}